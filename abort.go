@@ -0,0 +1,112 @@
+package frost
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+)
+
+// AbortError is returned when a signer sent an invalid signature share. It
+// identifies the signer, and holds evidence that anyone with the public shares
+// of the group can check with VerifyAbortEvidence: the share does not match the
+// commitments of the session and the public share of the culprit.
+//
+// The evidence does not prove by itself that the culprit sent the share and its
+// commitment; that is up to the transport, e.g. by signing every message, and
+// whoever checks the evidence should also check those signatures.
+type AbortError struct {
+	Culprit party.ID
+	// Evidence is the JSON encoding of the commitments of the session, the
+	// invalid share and the signed message.
+	Evidence []byte
+}
+
+func (e *AbortError) Error() string {
+	return fmt.Sprintf("signature share of party %d is invalid", e.Culprit)
+}
+
+// Unwrap returns ErrInvalidShare.
+func (e *AbortError) Unwrap() error {
+	return ErrInvalidShare
+}
+
+// abortEvidence is everything needed to recompute the binding factors and the
+// challenge of a session, and to check the share of the culprit.
+type abortEvidence struct {
+	Culprit     party.ID          `json:"culprit"`
+	Commitments CommitmentList    `json:"commitments"`
+	Share       string            `json:"share"`
+	Message     []byte            `json:"message"`
+	Request     *SignatureRequest `json:"request,omitempty"`
+	RFC9591     bool              `json:"rfc9591,omitempty"`
+	Prehash     bool              `json:"prehash,omitempty"`
+}
+
+// newAbortError returns the AbortError for the invalid share zi of culprit.
+func newAbortError(culprit party.ID, zi *ristretto.Scalar, signerIDs party.IDSlice, signers map[party.ID]*signer, message []byte, request *SignatureRequest, rfc9591, prehash bool) *AbortError {
+	l := make(CommitmentList, len(signerIDs))
+	for i, id := range signerIDs {
+		l[i] = Commitment{ID: id, Hiding: signers[id].Di, Binding: signers[id].Ei}
+	}
+	evidence, _ := json.Marshal(&abortEvidence{
+		Culprit:     culprit,
+		Commitments: l,
+		Share:       base64.StdEncoding.EncodeToString(zi.Bytes()),
+		Message:     message,
+		Request:     request,
+		RFC9591:     rfc9591,
+		Prehash:     prehash,
+	})
+	return &AbortError{Culprit: culprit, Evidence: evidence}
+}
+
+// VerifyAbortEvidence checks the evidence of an AbortError against the public
+// shares of the group, and returns the party whose share is invalid.
+func VerifyAbortEvidence(shares *eddsa.Public, evidence []byte) (party.ID, error) {
+	var e abortEvidence
+	if err := json.Unmarshal(evidence, &e); err != nil {
+		return 0, fmt.Errorf("AbortEvidence: %w", err)
+	}
+	if err := e.Commitments.validate(); err != nil {
+		return 0, fmt.Errorf("AbortEvidence: %w", err)
+	}
+	var zi ristretto.Scalar
+	if err := decodeScalar(e.Share, &zi); err != nil {
+		return 0, fmt.Errorf("AbortEvidence: %w", err)
+	}
+	if e.Request != nil && string(e.Request.Message) != string(e.Message) {
+		return 0, errors.New("AbortEvidence: request is for another message")
+	}
+
+	group, err := NewSigningGroup(e.Commitments.IDs(), shares)
+	if err != nil {
+		return 0, fmt.Errorf("AbortEvidence: %w", err)
+	}
+	signers := group.newSigners()
+	culprit, ok := signers[e.Culprit]
+	if !ok {
+		return 0, fmt.Errorf("AbortEvidence: party %d is not a signer", e.Culprit)
+	}
+	for _, c := range e.Commitments {
+		signers[c.ID].Di.Set(&c.Hiding)
+		signers[c.ID].Ei.Set(&c.Binding)
+	}
+	if e.RFC9591 {
+		computeRhosRFC9591(group.SignerIDs, signers, &group.GroupKey, e.Message)
+	} else {
+		computeRhos(group.SignerIDs, signers, e.Message, e.Request)
+	}
+	var R ristretto.Element
+	computeGroupCommitment(group.SignerIDs, signers, &R)
+	c := computeChallenge(&R, &group.GroupKey, e.Message, e.Prehash)
+
+	if culprit.verifyShare(c, &zi) {
+		return 0, fmt.Errorf("AbortEvidence: share of party %d is valid", e.Culprit)
+	}
+	return e.Culprit, nil
+}
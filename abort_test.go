@@ -0,0 +1,80 @@
+package frost
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbortError(t *testing.T) {
+	public, secrets := generateKeys(t, 4, 2)
+	signers := party.IDSlice{1, 2, 4}
+	message := []byte("blame")
+
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInit(signers, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	var round2 []*Message
+	var valid *Message
+	for _, id := range signers {
+		msg, _, err := SignRound1(states[id], round1)
+		require.NoError(t, err)
+		if id == 2 {
+			valid = msg
+			msg = NewSign2(2, scalar.NewScalarRandom())
+		}
+		round2 = append(round2, msg)
+	}
+
+	_, _, err := SignRound2(states[4], round2)
+	var abortErr *AbortError
+	require.True(t, errors.As(err, &abortErr))
+	assert.Equal(t, party.ID(2), abortErr.Culprit)
+	// the nonces are erased on abort
+	assert.Equal(t, 1, states[4].D.Equal(ristretto.NewScalar()))
+	assert.Equal(t, 1, states[4].E.Equal(ristretto.NewScalar()))
+	culprit, err := VerifyAbortEvidence(public, abortErr.Evidence)
+	require.NoError(t, err)
+	assert.Equal(t, party.ID(2), culprit)
+
+	// the valid share is not evidence
+	var e abortEvidence
+	require.NoError(t, json.Unmarshal(abortErr.Evidence, &e))
+	e.Share = base64.StdEncoding.EncodeToString(valid.Sign2.Zi.Bytes())
+	evidence, err := json.Marshal(&e)
+	require.NoError(t, err)
+	_, err = VerifyAbortEvidence(public, evidence)
+	assert.Error(t, err)
+
+	// the evidence of the aggregator is checked the same way
+	agg, err := NewAggregator(signers, public, message)
+	require.NoError(t, err)
+	_, err = agg.AddCommitments(round1)
+	require.NoError(t, err)
+	_, err = agg.Aggregate(round2)
+	require.True(t, errors.As(err, &abortErr))
+	culprit, err = VerifyAbortEvidence(public, abortErr.Evidence)
+	require.NoError(t, err)
+	assert.Equal(t, party.ID(2), culprit)
+
+	// the culprit must be one of the signers
+	e.Culprit = 3
+	evidence, err = json.Marshal(&e)
+	require.NoError(t, err)
+	_, err = VerifyAbortEvidence(public, evidence)
+	assert.Error(t, err)
+	_, err = VerifyAbortEvidence(public, []byte("{}"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,220 @@
+package frost
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+)
+
+// Aggregator is the untrusted aggregator role from the FROST paper.
+//
+// Instead of broadcasting their messages to every other signer,
+// signers only talk to the aggregator:
+//
+//  1. Each signer sends its Sign1 message from SignInit to the aggregator.
+//  2. The aggregator validates them with AddCommitments, and sends the returned
+//     list of commitments to every signer, which passes it to SignRound1.
+//  3. Each signer sends its Sign2 message to the aggregator.
+//  4. The aggregator verifies every share with Aggregate and outputs the signature.
+//
+// This reduces the traffic from O(N²) to O(N) messages, and signers never need to call SignRound2.
+// The aggregator holds no secret; a malicious aggregator can at worst prevent a signature from being produced.
+type Aggregator struct {
+	SignerIDs party.IDSlice
+	Message   []byte
+	GroupKey  eddsa.PublicKey
+	Signers   map[party.ID]*signer
+	// Request is the SignatureRequest the signers were initialized with, if any.
+	Request *SignatureRequest
+	// RFC9591 must be set if the signers were initialized with SignInitRFC9591.
+	RFC9591 bool
+	// Prehash must be set if the signers were initialized with SignInitPrehashed,
+	// in which case Message is the digest.
+	Prehash bool
+	// SessionID must be set if the signers were initialized with SignInitWithSession.
+	SessionID SessionID
+	// C = H(R, GroupKey, Message)
+	C ristretto.Scalar
+	// R = ∑ Ri
+	R ristretto.Element
+
+	commitments []*Message
+}
+
+// NewAggregator returns an Aggregator for a signing session between signerIDs.
+func NewAggregator(signerIDs party.IDSlice, shares *eddsa.Public, message []byte) (*Aggregator, error) {
+	signerIDs = signerIDs.Sorted()
+	if err := signerIDs.Validate(); err != nil {
+		return nil, fmt.Errorf("Aggregator: %w", err)
+	}
+	if !signerIDs.IsSubsetOf(shares.PartyIDs) {
+		return nil, fmt.Errorf("Aggregator: partyIDs %v are not a subset of shares.PartyIDs %v", signerIDs, shares.PartyIDs)
+	}
+
+	a := &Aggregator{
+		SignerIDs: signerIDs,
+		Message:   message,
+		GroupKey:  *shares.GroupKey,
+		Signers:   make(map[party.ID]*signer, signerIDs.N()),
+		R:         *ristretto.NewIdentityElement(),
+	}
+
+	for _, id := range signerIDs {
+		lagrange, err := id.Lagrange(signerIDs)
+		if err != nil {
+			return nil, fmt.Errorf("Aggregator: %w", err)
+		}
+		s := NewSigner()
+		s.Public.ScalarMult(lagrange, shares.Shares[id])
+		a.Signers[id] = s
+	}
+
+	return a, nil
+}
+
+// AddCommitments processes the Sign1 messages of all signers.
+// It returns the messages that must be forwarded to every signer.
+func (a *Aggregator) AddCommitments(inputMsgs []*Message) ([]*Message, error) {
+	var received Received
+	for _, msg := range inputMsgs {
+		if err := a.checkCommitment(msg); err != nil {
+			return nil, fmt.Errorf("Aggregator: %w", err)
+		}
+		if err := received.add(MessageTypeSign1, msg.From); err != nil {
+			return nil, fmt.Errorf("Aggregator: %w", err)
+		}
+		s := a.Signers[msg.From]
+		s.Di.Set(&msg.Sign1.Di)
+		s.Ei.Set(&msg.Sign1.Ei)
+	}
+	if err := received.complete(MessageTypeSign1, a.SignerIDs, 0); err != nil {
+		return nil, fmt.Errorf("Aggregator: %w", err)
+	}
+
+	if a.RFC9591 {
+		computeRhosRFC9591(a.SignerIDs, a.Signers, &a.GroupKey, a.Message)
+	} else {
+		computeRhos(a.SignerIDs, a.Signers, a.Message, a.Request)
+	}
+	computeGroupCommitment(a.SignerIDs, a.Signers, &a.R)
+	a.C.Set(computeChallenge(&a.R, &a.GroupKey, a.Message, a.Prehash))
+
+	a.commitments = inputMsgs
+	return inputMsgs, nil
+}
+
+// checkCommitment checks that msg is a valid Sign1 message of a signer, with
+// commitments other than the identity.
+func (a *Aggregator) checkCommitment(msg *Message) error {
+	if err := msg.Validate(MessageTypeSign1, a.SessionID, 0); err != nil {
+		return err
+	}
+	if _, ok := a.Signers[msg.From]; !ok {
+		return &ErrUnknownParty{ID: msg.From}
+	}
+	if msg.Sign1.Di.Equal(ristretto.NewIdentityElement()) == 1 || msg.Sign1.Ei.Equal(ristretto.NewIdentityElement()) == 1 {
+		return fmt.Errorf("commitment Ei or Di of party %d was the identity: %w", msg.From, ErrInvalidMessage)
+	}
+	return nil
+}
+
+// Aggregate verifies the Sign2 messages of all signers and returns the final signature.
+func (a *Aggregator) Aggregate(inputMsgs []*Message) (*eddsa.Signature, error) {
+	if a.commitments == nil {
+		return nil, errors.New("Aggregator: commitments have not been added")
+	}
+
+	var received Received
+	for _, msg := range inputMsgs {
+		if err := msg.Validate(MessageTypeSign2, a.SessionID, 0); err != nil {
+			return nil, fmt.Errorf("Aggregator: %w", err)
+		}
+		if _, ok := a.Signers[msg.From]; !ok {
+			return nil, fmt.Errorf("Aggregator: %w", &ErrUnknownParty{ID: msg.From})
+		}
+		if err := received.add(MessageTypeSign2, msg.From); err != nil {
+			return nil, fmt.Errorf("Aggregator: %w", err)
+		}
+	}
+	for _, msg := range inputMsgs {
+		a.Signers[msg.From].Zi.Set(&msg.Sign2.Zi)
+	}
+	// verify all shares at once, and one by one only to find the culprit
+	if !verifyShares(&a.C, a.Signers, received[MessageTypeSign2]) {
+		for _, msg := range inputMsgs {
+			if !a.Signers[msg.From].verifyShare(&a.C, &msg.Sign2.Zi) {
+				return nil, fmt.Errorf("Aggregator: %w", a.abortError(msg))
+			}
+		}
+	}
+	if err := received.complete(MessageTypeSign2, a.SignerIDs, 0); err != nil {
+		return nil, fmt.Errorf("Aggregator: %w", err)
+	}
+
+	// S = ∑ sᵢ
+	var S ristretto.Scalar
+	for _, id := range a.SignerIDs {
+		S.Add(&S, &a.Signers[id].Zi)
+	}
+
+	sig := &eddsa.Signature{
+		R: a.R,
+		S: S,
+	}
+
+	if !verifySignature(&a.GroupKey, a.Message, sig, a.Prehash) {
+		return nil, fmt.Errorf("Aggregator: full signature is invalid: %w", ErrInvalidShare)
+	}
+
+	return sig, nil
+}
+
+// VerifyPartial checks the signature share in sign2 against the commitments
+// of the signers in sign1s, without any secret, e.g. for a coordinator that
+// forwards the messages, or for an auditor. sign1s must hold the Sign1 message
+// of every signer of the session; the session ID is that of sign2. An invalid
+// share is reported as an *AbortError, with the evidence against its sender.
+//
+// Sessions started with SignInitPrehashed are checked with
+// VerifyPartialPrehashed. Sessions started with a SignatureRequest or
+// SignInitRFC9591 bind other values into the binding factors, and are checked
+// with an Aggregator.
+func VerifyPartial(public *eddsa.Public, signerIDs party.IDSlice, message []byte, sign1s []*Message, sign2 *Message) error {
+	return verifyPartial(public, signerIDs, message, false, sign1s, sign2)
+}
+
+// verifyPartial is VerifyPartial, with the challenge of Ed25519ph if prehash
+// is set.
+func verifyPartial(public *eddsa.Public, signerIDs party.IDSlice, message []byte, prehash bool, sign1s []*Message, sign2 *Message) error {
+	if sign2 == nil || sign2.Type != MessageTypeSign2 || sign2.Sign2 == nil {
+		return errors.New("VerifyPartial: invalid message type for signature share")
+	}
+	a, err := NewAggregator(signerIDs, public, message)
+	if err != nil {
+		return err
+	}
+	a.Prehash = prehash
+	a.SessionID = sign2.SessionID
+	if _, err := a.AddCommitments(sign1s); err != nil {
+		return err
+	}
+	if err := sign2.Validate(MessageTypeSign2, a.SessionID, 0); err != nil {
+		return fmt.Errorf("VerifyPartial: %w", err)
+	}
+	s, ok := a.Signers[sign2.From]
+	if !ok {
+		return fmt.Errorf("VerifyPartial: %w", &ErrUnknownParty{ID: sign2.From})
+	}
+	if !s.verifyShare(&a.C, &sign2.Sign2.Zi) {
+		return a.abortError(sign2)
+	}
+	return nil
+}
+
+// abortError returns the AbortError for the invalid share in msg.
+func (a *Aggregator) abortError(msg *Message) *AbortError {
+	return newAbortError(msg.From, &msg.Sign2.Zi, a.SignerIDs, a.Signers, a.Message, a.Request, a.RFC9591, a.Prehash)
+}
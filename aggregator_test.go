@@ -0,0 +1,114 @@
+package frost
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregator(t *testing.T) {
+	public, secrets := generateKeys(t, 5, 2)
+	signers := party.IDSlice{2, 3, 5}
+	message := []byte("aggregated")
+
+	agg, err := NewAggregator(signers, public, message)
+	require.NoError(t, err)
+
+	states := make(map[party.ID]*SignerState)
+	var commitments []*Message
+	for _, id := range signers {
+		msg, state, err := SignInit(signers, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		commitments = append(commitments, msg)
+	}
+
+	forwarded, err := agg.AddCommitments(commitments)
+	require.NoError(t, err)
+
+	var shares []*Message
+	for _, id := range signers {
+		msg, _, err := SignRound1(states[id], forwarded)
+		require.NoError(t, err)
+		shares = append(shares, msg)
+	}
+
+	sig, err := agg.Aggregate(shares)
+	require.NoError(t, err)
+	assert.True(t, public.GroupKey.Verify(message, sig))
+
+	// a corrupted share is detected and attributed
+	shares[1] = NewSign2(shares[1].From, scalar.NewScalarRandom())
+	_, err = agg.Aggregate(shares)
+	assert.Error(t, err)
+
+	// missing shares are rejected
+	_, err = agg.Aggregate(shares[:2])
+	assert.Error(t, err)
+}
+
+func TestAggregator_Session(t *testing.T) {
+	session, err := NewSessionID()
+	require.NoError(t, err)
+	public, states, commitments := signSession(t, session)
+
+	agg, err := NewAggregator(states[1].SignerIDs, public, states[1].Message)
+	require.NoError(t, err)
+	_, err = agg.AddCommitments(commitments)
+	assert.True(t, errors.Is(err, ErrWrongSession))
+
+	agg.SessionID = session
+	forwarded, err := agg.AddCommitments(commitments)
+	require.NoError(t, err)
+	var shares []*Message
+	for _, state := range states {
+		msg, _, err := SignRound1(state, forwarded)
+		require.NoError(t, err)
+		shares = append(shares, msg)
+	}
+	sig, err := agg.Aggregate(shares)
+	require.NoError(t, err)
+	assert.True(t, public.GroupKey.Verify(states[1].Message, sig))
+}
+
+func TestVerifyPartial(t *testing.T) {
+	public, secrets := generateKeys(t, 4, 1)
+	signers := party.IDSlice{1, 4}
+	message := []byte("audited")
+
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInit(signers, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	shares := make(map[party.ID]*Message)
+	for _, id := range signers {
+		msg, _, err := SignRound1(states[id], round1)
+		require.NoError(t, err)
+		shares[id] = msg
+	}
+
+	for _, id := range signers {
+		assert.NoError(t, VerifyPartial(public, signers, message, round1, shares[id]))
+	}
+
+	// a share for another message is attributed to its sender
+	err := VerifyPartial(public, signers, []byte("other"), round1, shares[4])
+	var abortErr *AbortError
+	require.True(t, errors.As(err, &abortErr))
+	assert.Equal(t, party.ID(4), abortErr.Culprit)
+	culprit, err := VerifyAbortEvidence(public, abortErr.Evidence)
+	require.NoError(t, err)
+	assert.Equal(t, party.ID(4), culprit)
+
+	assert.Error(t, VerifyPartial(public, signers, message, round1[:1], shares[1]))
+	assert.Error(t, VerifyPartial(public, signers, message, round1, round1[0]))
+	assert.Error(t, VerifyPartial(public, party.IDSlice{1, 2}, message, round1, shares[1]))
+}
@@ -0,0 +1,191 @@
+// Package approval holds sign requests until an operator approves them, so
+// that every shareholder keeps a veto over what its share signs.
+//
+// A Queue is a policy.Policy. It approves a request at once if its
+// AutoApprove policy allows it, and otherwise holds it as pending until an
+// operator approves or denies it, the request is canceled or the Timeout
+// passes. Handler serves the pending requests over HTTP, on a unix socket only
+// the operator can connect to, and Client lists, approves and denies them, as
+// frost approve does.
+package approval
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/manifest"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/policy"
+)
+
+// ErrUnknownRequest is returned for a request that is not pending, e.g.
+// because it was decided or canceled already.
+var ErrUnknownRequest = errors.New("approval: unknown request")
+
+// Pending is a request waiting for the decision of an operator.
+type Pending struct {
+	// ID identifies the request to Approve and Deny.
+	ID string `json:"id"`
+	// Group is the fingerprint of the group key, see manifest.Fingerprint.
+	Group   string        `json:"group,omitempty"`
+	Party   party.ID      `json:"party,omitempty"`
+	Signers party.IDSlice `json:"signers"`
+	// Message is the message to sign, or its SHA-512 digest if Prehash is
+	// set, and MessageDigest its hex encoded SHA-256.
+	Message       []byte    `json:"message"`
+	MessageDigest string    `json:"message_digest"`
+	Prehash       bool      `json:"prehash,omitempty"`
+	Received      time.Time `json:"received"`
+}
+
+// Queue is a Policy that holds the requests AutoApprove does not allow for an
+// operator. A Queue must not be copied after first use.
+type Queue struct {
+	// AutoApprove, if set, approves the requests it allows without an
+	// operator. The requests it denies are held.
+	AutoApprove policy.Policy
+
+	// Timeout bounds the wait for a decision, after which the request is
+	// denied. Zero means the request waits until it is canceled, e.g. by the
+	// timeout of the session.
+	Timeout time.Duration
+
+	// Notify, if set, is called when a request starts waiting, e.g. to alert an operator.
+	Notify func(*Pending)
+
+	// Clock times the requests, it defaults to the real time.
+	Clock clock.Clock
+
+	mu      sync.Mutex
+	pending map[string]*waiting
+}
+
+type waiting struct {
+	Pending
+	decided chan error
+}
+
+// Allow implements policy.Policy.
+func (q *Queue) Allow(ctx context.Context, req *policy.Request) error {
+	if q.AutoApprove != nil {
+		err := q.AutoApprove.Allow(ctx, req)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, policy.ErrDenied) {
+			return err
+		}
+	}
+
+	w, err := q.hold(req)
+	if err != nil {
+		return err
+	}
+	var timeout <-chan time.Time
+	if q.Timeout > 0 {
+		t := clock.OrReal(q.Clock).NewTimer(q.Timeout)
+		defer t.Stop()
+		timeout = t.C()
+	}
+	if q.Notify != nil {
+		q.Notify(&w.Pending)
+	}
+	select {
+	case err := <-w.decided:
+		return err
+	case <-timeout:
+		err = policy.Deny("request %s was not approved within %s", w.ID, q.Timeout)
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if q.decide(w.ID, nil) != nil {
+		// decided in the meantime
+		return <-w.decided
+	}
+	return err
+}
+
+// hold adds req to the pending requests.
+func (q *Queue) hold(req *policy.Request) (*waiting, error) {
+	var id [6]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, fmt.Errorf("approval: %w", err)
+	}
+	digest := sha256.Sum256(req.Message)
+	w := &waiting{
+		Pending: Pending{
+			ID:            hex.EncodeToString(id[:]),
+			Party:         req.Party,
+			Signers:       req.Signers,
+			Message:       req.Message,
+			MessageDigest: hex.EncodeToString(digest[:]),
+			Prehash:       req.Prehash,
+			Received:      clock.OrReal(q.Clock).Now().UTC(),
+		},
+		decided: make(chan error, 1),
+	}
+	if req.GroupKey != nil {
+		w.Group = manifest.Fingerprint(req.GroupKey.ToEd25519())
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending == nil {
+		q.pending = make(map[string]*waiting)
+	}
+	q.pending[w.ID] = w
+	return w, nil
+}
+
+// decide removes the request id from the pending requests, and hands err to
+// its Allow.
+func (q *Queue) decide(id string, err error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	w, ok := q.pending[id]
+	if !ok {
+		return ErrUnknownRequest
+	}
+	delete(q.pending, id)
+	w.decided <- err
+	return nil
+}
+
+// Pending returns the pending requests, oldest first.
+func (q *Queue) Pending() []*Pending {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	list := make([]*Pending, 0, len(q.pending))
+	for _, w := range q.pending {
+		p := w.Pending
+		list = append(list, &p)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Received.Equal(list[j].Received) {
+			return list[i].Received.Before(list[j].Received)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// Approve lets the pending request id be signed.
+func (q *Queue) Approve(id string) error {
+	return q.decide(id, nil)
+}
+
+// Deny refuses the pending request id for the reason.
+func (q *Queue) Deny(id, reason string) error {
+	if reason == "" {
+		reason = "no reason given"
+	}
+	return q.decide(id, policy.Deny("request %s was denied by the operator: %s", id, reason))
+}
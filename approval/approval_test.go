@@ -0,0 +1,117 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/policy"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func request(message string) *policy.Request {
+	key := eddsa.NewPublicKeyFromPoint(new(ristretto.Element).ScalarBaseMult(scalar.NewScalarRandom()))
+	return &policy.Request{GroupKey: key, Party: 1, Signers: party.IDSlice{1, 2}, Message: []byte(message)}
+}
+
+// allow runs q.Allow in the background, and returns its result once the
+// request is pending.
+func allow(t *testing.T, ctx context.Context, q *Queue, req *policy.Request) (*Pending, <-chan error) {
+	pending := make(chan *Pending, 1)
+	q.Notify = func(p *Pending) { pending <- p }
+	result := make(chan error, 1)
+	go func() { result <- q.Allow(ctx, req) }()
+	select {
+	case p := <-pending:
+		return p, result
+	case err := <-result:
+		t.Fatalf("request was not held: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not held")
+	}
+	return nil, nil
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	auto, err := policy.Parse([]byte(`{"rules": [{"message_prefixes": ["heartbeat "]}]}`))
+	require.NoError(t, err)
+	q := &Queue{AutoApprove: auto}
+
+	assert.NoError(t, q.Allow(ctx, request("heartbeat 1")))
+	assert.Empty(t, q.Pending())
+
+	p, result := allow(t, ctx, q, request("release v1"))
+	assert.Equal(t, []byte("release v1"), p.Message)
+	assert.Len(t, q.Pending(), 1)
+	require.NoError(t, q.Approve(p.ID))
+	assert.NoError(t, <-result)
+	assert.Empty(t, q.Pending())
+	assert.Equal(t, ErrUnknownRequest, q.Approve(p.ID))
+
+	p, result = allow(t, ctx, q, request("release v2"))
+	require.NoError(t, q.Deny(p.ID, "not scheduled"))
+	err = <-result
+	assert.True(t, errors.Is(err, policy.ErrDenied))
+	assert.Contains(t, err.Error(), "not scheduled")
+}
+
+func TestQueue_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue{}
+	_, result := allow(t, ctx, q, request("release v1"))
+	cancel()
+	assert.Equal(t, context.Canceled, <-result)
+	assert.Empty(t, q.Pending())
+}
+
+func TestQueue_Timeout(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	q := &Queue{Timeout: time.Minute, Clock: fake}
+	p, result := allow(t, context.Background(), q, request("release v1"))
+	fake.Advance(time.Minute)
+	err := <-result
+	assert.True(t, errors.Is(err, policy.ErrDenied))
+	assert.Equal(t, ErrUnknownRequest, q.Approve(p.ID))
+}
+
+func TestHandler(t *testing.T) {
+	q := &Queue{}
+	path := filepath.Join(t.TempDir(), "approval.sock")
+	lis, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	srv := &http.Server{Handler: Handler(q)}
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(func() { srv.Close() })
+
+	ctx := context.Background()
+	c := NewUnixClient(path)
+	list, err := c.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	p, result := allow(t, ctx, q, request("release v1"))
+	list, err = c.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, p.ID, list[0].ID)
+	assert.Equal(t, p.MessageDigest, list[0].MessageDigest)
+	assert.Equal(t, party.IDSlice{1, 2}, list[0].Signers)
+	require.NoError(t, c.Approve(ctx, p.ID))
+	assert.NoError(t, <-result)
+
+	p, result = allow(t, ctx, q, request("release v2"))
+	require.NoError(t, c.Deny(ctx, p.ID, "wrong version"))
+	assert.Contains(t, (<-result).Error(), "wrong version")
+	assert.Equal(t, ErrUnknownRequest, c.Approve(ctx, p.ID))
+}
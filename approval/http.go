@@ -0,0 +1,146 @@
+package approval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Handler serves the pending requests of q:
+//
+//	GET  /v1/pending               lists the pending requests
+//	POST /v1/pending/<id>/approve  approves a request
+//	POST /v1/pending/<id>/deny     denies a request, with an optional {"reason": "..."}
+//
+// It does not authenticate its clients, so it must only be served where
+// operators alone can connect, such as a unix socket with restricted permissions.
+func Handler(q *Queue) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/pending" {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(q.Pending())
+			return
+		}
+
+		rest, ok := strings.CutPrefix(r.URL.Path, "/v1/pending/")
+		id, action, found := strings.Cut(rest, "/")
+		if !ok || !found || id == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var err error
+		switch action {
+		case "approve":
+			err = q.Approve(id)
+		case "deny":
+			var body struct {
+				Reason string `json:"reason"`
+			}
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+				http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			err = q.Deny(id, body.Reason)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// Client decides the pending requests served by a Handler.
+type Client struct {
+	// URL is the base URL of the Handler.
+	URL string
+	// HTTPClient is used for the requests, http.DefaultClient if it is nil.
+	HTTPClient *http.Client
+}
+
+// NewUnixClient returns a Client of the Handler served on the unix socket at path.
+func NewUnixClient(path string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}
+	return &Client{URL: "http://unix", HTTPClient: &http.Client{Transport: transport}}
+}
+
+// List returns the pending requests.
+func (c *Client) List(ctx context.Context) ([]*Pending, error) {
+	data, err := c.do(ctx, http.MethodGet, "/v1/pending", nil)
+	if err != nil {
+		return nil, err
+	}
+	var list []*Pending
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("approval: %w", err)
+	}
+	return list, nil
+}
+
+// Approve approves the pending request id.
+func (c *Client) Approve(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodPost, "/v1/pending/"+id+"/approve", nil)
+	return err
+}
+
+// Deny denies the pending request id for the reason.
+func (c *Client) Deny(ctx context.Context, id, reason string) error {
+	body, err := json.Marshal(map[string]string{"reason": reason})
+	if err != nil {
+		return err
+	}
+	_, err = c.do(ctx, http.MethodPost, "/v1/pending/"+id+"/deny", body)
+	return err
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.URL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("approval: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, fmt.Errorf("approval: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound && strings.HasPrefix(path, "/v1/pending/"):
+		return nil, ErrUnknownRequest
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("approval: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
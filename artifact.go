@@ -0,0 +1,169 @@
+package frost
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bartke/frost/eddsa"
+)
+
+// ArtifactKind is the type of a persisted artifact.
+type ArtifactKind string
+
+const (
+	ArtifactSecretShare ArtifactKind = "secret-share"
+	ArtifactPublic      ArtifactKind = "public"
+	ArtifactKeygenState ArtifactKind = "keygen-state"
+	ArtifactSignerState ArtifactKind = "signer-state"
+	ArtifactMessage     ArtifactKind = "message"
+)
+
+// ArtifactVersion is the format version of the artifacts written by this
+// release, the first one to prefix them with a header:
+//
+//	"FROST-ARTIFACT" ∥ " " ∥ kind ∥ " " ∥ version ∥ "\n" ∥ payload
+//
+// where the version is in decimal, and the payload is the encoding of the
+// artifact that was written without the header before, e.g. the JSON of an
+// eddsa.Public or the binary encoding of an eddsa.SecretShare.
+//
+// Artifacts without a header were written by earlier releases: version 1
+// introduced the current encodings, and version 2 added the session and round
+// to messages and the session to keygen states. Version 0 stands for the
+// layouts of the releases before, which the migrate package converts.
+const ArtifactVersion = 3
+
+const (
+	artifactMagic = "FROST-ARTIFACT "
+	// maxArtifactHeader bounds the length of a header line.
+	maxArtifactHeader = 64
+)
+
+// ErrUnknownArtifact is returned by DetectVersion when data is not an artifact it knows.
+var ErrUnknownArtifact = errors.New("frost: unknown artifact format")
+
+// EncodeArtifact returns payload, the encoding of an artifact of the given
+// kind, prefixed with the header of ArtifactVersion.
+func EncodeArtifact(kind ArtifactKind, payload []byte) []byte {
+	header := fmt.Sprintf("%s%s %d\n", artifactMagic, kind, ArtifactVersion)
+	return append([]byte(header), payload...)
+}
+
+// DecodeArtifact returns the payload of data, an artifact of the given kind
+// written by EncodeArtifact. Artifacts of earlier releases, which have no
+// header, are returned as they are, since their payload is encoded the same.
+// It returns an error if the header is of another kind, or of a version newer
+// than ArtifactVersion.
+func DecodeArtifact(kind ArtifactKind, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(artifactMagic)) {
+		return data, nil
+	}
+	headerKind, version, payload, err := splitArtifact(data)
+	if err != nil {
+		return nil, fmt.Errorf("DecodeArtifact: %w", err)
+	}
+	if headerKind != kind {
+		return nil, fmt.Errorf("DecodeArtifact: got a %s artifact, expected a %s", headerKind, kind)
+	}
+	if version > ArtifactVersion {
+		return nil, fmt.Errorf("DecodeArtifact: version %d was written by a newer release, version %d is supported", version, ArtifactVersion)
+	}
+	return payload, nil
+}
+
+// DetectVersion returns the kind and the format version of the artifact in
+// data, see ArtifactVersion. For an artifact without a header, it returns the
+// earliest version that encodes the artifact as data does, since the
+// encodings of most artifacts did not change with version 2.
+func DetectVersion(data []byte) (ArtifactKind, int, error) {
+	if bytes.HasPrefix(data, []byte(artifactMagic)) {
+		kind, version, _, err := splitArtifact(data)
+		if err != nil {
+			return "", 0, fmt.Errorf("DetectVersion: %w", err)
+		}
+		return kind, version, nil
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return "", 0, ErrUnknownArtifact
+	}
+	if trimmed[0] != '{' {
+		var share eddsa.SecretShare
+		if share.UnmarshalBinary(data) == nil {
+			return ArtifactSecretShare, 1, nil
+		}
+		// binary messages carry the version of their format
+		var msg Message
+		if msg.UnmarshalBinary(data) == nil {
+			return ArtifactMessage, int(data[0]), nil
+		}
+		return "", 0, ErrUnknownArtifact
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &fields); err != nil {
+		return "", 0, ErrUnknownArtifact
+	}
+	has := func(m map[string]json.RawMessage, keys ...string) bool {
+		for _, key := range keys {
+			if _, ok := m[key]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case has(fields, "header"):
+		var header map[string]json.RawMessage
+		if err := json.Unmarshal(fields["header"], &header); err != nil {
+			return "", 0, ErrUnknownArtifact
+		}
+		for _, key := range []string{"type", "from", "to"} {
+			// the fields were plain integers before they were encoded in base64
+			if v := header[key]; len(v) > 0 && v[0] != '"' {
+				return ArtifactMessage, 0, nil
+			}
+		}
+		if has(header, "round", "session") {
+			return ArtifactMessage, 2, nil
+		}
+		return ArtifactMessage, 1, nil
+	case has(fields, "signers") && has(fields, "signer_ids"):
+		return ArtifactSignerState, 1, nil
+	case has(fields, "commitments_sum", "polynomial"):
+		if has(fields, "session") {
+			return ArtifactKeygenState, 2, nil
+		}
+		return ArtifactKeygenState, 1, nil
+	case has(fields, "shares"):
+		if has(fields, "threshold", "group_key") {
+			return ArtifactPublic, 0, nil
+		}
+		return ArtifactPublic, 1, nil
+	case has(fields, "secret") && has(fields, "id"):
+		return ArtifactSecretShare, 1, nil
+	}
+	return "", 0, ErrUnknownArtifact
+}
+
+// splitArtifact parses the header of data, which starts with artifactMagic.
+func splitArtifact(data []byte) (ArtifactKind, int, []byte, error) {
+	end := bytes.IndexByte(data, '\n')
+	if end < 0 || end > maxArtifactHeader {
+		return "", 0, nil, errors.New("artifact header without end of line")
+	}
+	fields := strings.Fields(string(data[len(artifactMagic):end]))
+	if len(fields) != 2 {
+		return "", 0, nil, fmt.Errorf("invalid artifact header %q", data[:end])
+	}
+	version, err := strconv.Atoi(fields[1])
+	if err != nil || version < 1 {
+		return "", 0, nil, fmt.Errorf("invalid artifact version %q", fields[1])
+	}
+	return ArtifactKind(fields[0]), version, data[end+1:], nil
+}
@@ -0,0 +1,122 @@
+package frost
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtifact(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	publicData, err := json.Marshal(public)
+	require.NoError(t, err)
+
+	data := EncodeArtifact(ArtifactPublic, publicData)
+	kind, version, err := DetectVersion(data)
+	require.NoError(t, err)
+	assert.Equal(t, ArtifactPublic, kind)
+	assert.Equal(t, ArtifactVersion, version)
+
+	payload, err := DecodeArtifact(ArtifactPublic, data)
+	require.NoError(t, err)
+	assert.Equal(t, publicData, payload)
+
+	// artifacts of earlier releases are read as they are
+	payload, err = DecodeArtifact(ArtifactPublic, publicData)
+	require.NoError(t, err)
+	assert.Equal(t, publicData, payload)
+
+	_, err = DecodeArtifact(ArtifactSecretShare, data)
+	assert.Error(t, err)
+
+	for _, header := range []string{
+		"FROST-ARTIFACT public 4\n",
+		"FROST-ARTIFACT public\n",
+		"FROST-ARTIFACT public 0\n",
+		"FROST-ARTIFACT public three\n",
+		"FROST-ARTIFACT public 3",
+	} {
+		_, err := DecodeArtifact(ArtifactPublic, append([]byte(header), publicData...))
+		assert.Error(t, err, header)
+	}
+
+	secretData, err := secrets[2].MarshalBinary()
+	require.NoError(t, err)
+	payload, err = DecodeArtifact(ArtifactSecretShare, EncodeArtifact(ArtifactSecretShare, secretData))
+	require.NoError(t, err)
+	assert.Equal(t, secretData, payload)
+}
+
+func TestDetectVersion(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	publicData, err := json.Marshal(public)
+	require.NoError(t, err)
+	secretData, err := secrets[1].MarshalBinary()
+	require.NoError(t, err)
+	secretJSON, err := json.Marshal(secrets[1])
+	require.NoError(t, err)
+
+	_, state, err := KeygenInit(1, 3, 1)
+	require.NoError(t, err)
+	stateData, err := json.Marshal(state)
+	require.NoError(t, err)
+	session, err := NewSessionID()
+	require.NoError(t, err)
+	_, state, err = KeygenInitWithSession(session, 1, 3, 1)
+	require.NoError(t, err)
+	sessionStateData, err := json.Marshal(state)
+	require.NoError(t, err)
+
+	_, signer, err := SignInit(party.IDSlice{1, 2}, secrets[1], public, []byte("message"))
+	require.NoError(t, err)
+	signerData, err := json.Marshal(signer)
+	require.NoError(t, err)
+
+	msg := NewSign2(2, scalar.NewScalarRandom())
+	msg.SessionID = session
+	msgJSON, err := msg.MarshalJSON()
+	require.NoError(t, err)
+	msgBinary, err := msg.MarshalBinary()
+	require.NoError(t, err)
+	sign2 := mustJSONField(t, msgJSON, "sign2")
+
+	for _, tc := range []struct {
+		name    string
+		data    []byte
+		kind    ArtifactKind
+		version int
+	}{
+		{"public", publicData, ArtifactPublic, 1},
+		{"legacy public", []byte(`{"threshold":1,"shares":{}}`), ArtifactPublic, 0},
+		{"secret share", secretData, ArtifactSecretShare, 1},
+		{"secret share JSON", secretJSON, ArtifactSecretShare, 1},
+		{"keygen state", stateData, ArtifactKeygenState, 1},
+		{"keygen state with session", sessionStateData, ArtifactKeygenState, 2},
+		{"signer state", signerData, ArtifactSignerState, 1},
+		{"message", msgJSON, ArtifactMessage, 2},
+		{"binary message", msgBinary, ArtifactMessage, int(MessageFormatVersion)},
+		{"message without session", []byte(`{"header":{"type":"BA==","from":"AAI=","to":"AAA="},"sign2":` + string(sign2) + `}`), ArtifactMessage, 1},
+		{"legacy message", []byte(`{"header":{"type":4,"from":2,"to":0},"sign2":` + string(sign2) + `}`), ArtifactMessage, 0},
+		{"header", EncodeArtifact(ArtifactSignerState, signerData), ArtifactSignerState, ArtifactVersion},
+	} {
+		kind, version, err := DetectVersion(tc.data)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.kind, kind, tc.name)
+		assert.Equal(t, tc.version, version, tc.name)
+	}
+
+	for _, data := range [][]byte{nil, []byte("not an artifact"), []byte(`{"foo":1}`), []byte("FROST-ARTIFACT public x\n{}")} {
+		_, _, err := DetectVersion(data)
+		assert.Error(t, err, string(data))
+	}
+}
+
+func mustJSONField(t *testing.T, data []byte, key string) json.RawMessage {
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &fields))
+	return fields[key]
+}
@@ -0,0 +1,206 @@
+// Package attest implements compact signed attestations of single files.
+//
+// An attestation binds the SHA-512 digest of a file to a point in time and is
+// signed with the group key. It is armored as a few lines of text that can be
+// stored next to the file, and only needs the group key to be verified, which
+// makes it a lightweight alternative to minisign or PGP signatures.
+package attest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bartke/frost/manifest"
+)
+
+// Version is the current attestation format version.
+const Version = 1
+
+const (
+	bodyHeader  = "frost-attestation-v1\n"
+	armorBegin  = "-----BEGIN FROST ATTESTATION-----"
+	armorEnd    = "-----END FROST ATTESTATION-----"
+	armorWidth  = 64
+	fingerprint = 16
+	// encodedSize is the size of the binary encoding:
+	// version ∥ fingerprint ∥ digest ∥ time ∥ signature
+	encodedSize = 1 + fingerprint + sha512.Size + 8 + ed25519.SignatureSize
+)
+
+var (
+	// ErrDigestMismatch is returned by Verify if the content differs from the attested file.
+	ErrDigestMismatch = errors.New("attest: file digest does not match")
+	// ErrWrongKey is returned by Verify if the attestation was made by a different group key.
+	ErrWrongKey = errors.New("attest: signed by a different group key")
+)
+
+// Attestation states that a group attested a file with the given digest at Time.
+type Attestation struct {
+	// Fingerprint identifies the group key, see manifest.Fingerprint.
+	Fingerprint [fingerprint]byte
+	// Digest is the SHA-512 of the file.
+	Digest [sha512.Size]byte
+	// Time is stored with a precision of one second.
+	Time time.Time
+	// Signature is the ed25519 signature over Body, nil until attached.
+	Signature []byte
+}
+
+// New returns an unsigned attestation of the content read from r, for the group key pub.
+func New(pub ed25519.PublicKey, r io.Reader, t time.Time) (*Attestation, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, errors.New("attest: invalid group key")
+	}
+	a := &Attestation{Time: t.UTC().Truncate(time.Second)}
+	h := sha512.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, fmt.Errorf("attest: %w", err)
+	}
+	copy(a.Digest[:], h.Sum(nil))
+	fp, _ := hex.DecodeString(manifest.Fingerprint(pub))
+	copy(a.Fingerprint[:], fp)
+	return a, nil
+}
+
+// Body returns the canonical bytes that are signed.
+func (a *Attestation) Body() []byte {
+	var b bytes.Buffer
+	b.WriteString(bodyHeader)
+	b.WriteString(hex.EncodeToString(a.Fingerprint[:]))
+	b.WriteByte('\n')
+	b.WriteString(hex.EncodeToString(a.Digest[:]))
+	b.WriteByte('\n')
+	b.WriteString(strconv.FormatInt(a.Time.Unix(), 10))
+	b.WriteByte('\n')
+	return b.Bytes()
+}
+
+// ParseBody reconstructs an unsigned attestation from its canonical body.
+func ParseBody(body []byte) (*Attestation, error) {
+	text := string(body)
+	if !strings.HasPrefix(text, bodyHeader) {
+		return nil, errors.New("attest: not an attestation body")
+	}
+	lines := strings.Split(strings.TrimSuffix(text[len(bodyHeader):], "\n"), "\n")
+	if len(lines) != 3 {
+		return nil, errors.New("attest: malformed body")
+	}
+	a := &Attestation{}
+	if err := decodeHex(a.Fingerprint[:], lines[0]); err != nil {
+		return nil, fmt.Errorf("attest: malformed fingerprint: %w", err)
+	}
+	if err := decodeHex(a.Digest[:], lines[1]); err != nil {
+		return nil, fmt.Errorf("attest: malformed digest: %w", err)
+	}
+	unix, err := strconv.ParseInt(lines[2], 10, 64)
+	if err != nil {
+		return nil, errors.New("attest: malformed time")
+	}
+	a.Time = time.Unix(unix, 0).UTC()
+	return a, nil
+}
+
+func decodeHex(dst []byte, s string) error {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	if len(b) != len(dst) {
+		return fmt.Errorf("expected %d bytes, got %d", len(dst), len(b))
+	}
+	copy(dst, b)
+	return nil
+}
+
+// Attach sets the signature over Body.
+func (a *Attestation) Attach(signature []byte) error {
+	if len(signature) != ed25519.SignatureSize {
+		return errors.New("attest: invalid signature length")
+	}
+	a.Signature = append([]byte(nil), signature...)
+	return nil
+}
+
+// Verify checks that the attestation was signed by pub and that content is the attested file.
+func (a *Attestation) Verify(pub ed25519.PublicKey, content io.Reader) error {
+	if len(pub) != ed25519.PublicKeySize {
+		return errors.New("attest: invalid group key")
+	}
+	if manifest.Fingerprint(pub) != hex.EncodeToString(a.Fingerprint[:]) {
+		return ErrWrongKey
+	}
+	if len(a.Signature) != ed25519.SignatureSize || !ed25519.Verify(pub, a.Body(), a.Signature) {
+		return errors.New("attest: invalid signature")
+	}
+	h := sha512.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return fmt.Errorf("attest: %w", err)
+	}
+	if !bytes.Equal(h.Sum(nil), a.Digest[:]) {
+		return ErrDigestMismatch
+	}
+	return nil
+}
+
+// MarshalText returns the armored attestation. It must be signed.
+func (a *Attestation) MarshalText() ([]byte, error) {
+	if len(a.Signature) != ed25519.SignatureSize {
+		return nil, errors.New("attest: attestation is not signed")
+	}
+	raw := make([]byte, 0, encodedSize)
+	raw = append(raw, Version)
+	raw = append(raw, a.Fingerprint[:]...)
+	raw = append(raw, a.Digest[:]...)
+	raw = binary.BigEndian.AppendUint64(raw, uint64(a.Time.Unix()))
+	raw = append(raw, a.Signature...)
+	encoded := base64.StdEncoding.EncodeToString(raw)
+
+	var b bytes.Buffer
+	b.WriteString(armorBegin)
+	b.WriteByte('\n')
+	for len(encoded) > armorWidth {
+		b.WriteString(encoded[:armorWidth])
+		b.WriteByte('\n')
+		encoded = encoded[armorWidth:]
+	}
+	b.WriteString(encoded)
+	b.WriteByte('\n')
+	b.WriteString(armorEnd)
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// UnmarshalText parses an armored attestation.
+func (a *Attestation) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	if !strings.HasPrefix(s, armorBegin) || !strings.HasSuffix(s, armorEnd) {
+		return errors.New("attest: missing armor")
+	}
+	s = strings.Join(strings.Fields(s[len(armorBegin):len(s)-len(armorEnd)]), "")
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("attest: %w", err)
+	}
+	if len(raw) != encodedSize {
+		return errors.New("attest: invalid length")
+	}
+	if raw[0] != Version {
+		return fmt.Errorf("attest: unsupported version %d", raw[0])
+	}
+	raw = raw[1:]
+	raw = raw[copy(a.Fingerprint[:], raw):]
+	raw = raw[copy(a.Digest[:], raw):]
+	a.Time = time.Unix(int64(binary.BigEndian.Uint64(raw)), 0).UTC()
+	a.Signature = append([]byte(nil), raw[8:]...)
+	return nil
+}
@@ -0,0 +1,72 @@
+package attest
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost/frostclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttestation(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	pub := group.Public.GroupKey.ToEd25519()
+
+	content := []byte("release artifact")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+	a, err := New(pub, bytes.NewReader(content), now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Truncate(time.Second), a.Time)
+
+	// the body travels through the signing rounds as the message
+	unsigned, err := ParseBody(a.Body())
+	require.NoError(t, err)
+	assert.Equal(t, a, unsigned)
+
+	_, err = a.MarshalText()
+	assert.Error(t, err, "unsigned attestations cannot be armored")
+
+	sig, err := frostclient.Sign(context.Background(), group, a.Body())
+	require.NoError(t, err)
+	require.NoError(t, a.Attach(sig.ToEd25519()))
+	require.NoError(t, a.Verify(pub, bytes.NewReader(content)))
+
+	text, err := a.MarshalText()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(text), armorBegin+"\n"))
+
+	var parsed Attestation
+	require.NoError(t, parsed.UnmarshalText(text))
+	assert.Equal(t, a, &parsed)
+	require.NoError(t, parsed.Verify(pub, bytes.NewReader(content)))
+
+	err = parsed.Verify(pub, strings.NewReader("tampered"))
+	assert.True(t, errors.Is(err, ErrDigestMismatch))
+
+	other, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	err = parsed.Verify(other, bytes.NewReader(content))
+	assert.True(t, errors.Is(err, ErrWrongKey))
+
+	parsed.Time = parsed.Time.Add(time.Second)
+	assert.Error(t, parsed.Verify(pub, bytes.NewReader(content)), "the time is signed")
+}
+
+func TestUnmarshalText_Invalid(t *testing.T) {
+	var a Attestation
+	for _, text := range []string{
+		"",
+		armorBegin + "\n" + armorEnd,
+		armorBegin + "\n!!!\n" + armorEnd,
+		"garbage",
+	} {
+		assert.Error(t, a.UnmarshalText([]byte(text)), text)
+	}
+}
@@ -0,0 +1,338 @@
+// Package audit keeps an append-only, hash-chained log of the custody of a
+// threshold key: every key generation, signing session and share refresh a
+// party or coordinator takes part in, with the digest of the message, the
+// signers and the outcome of every signing session.
+//
+// The log is a file with one JSON record per line, holding an entry and its
+// hash. The hash is the SHA-256 of the entry as written, which includes the
+// hash of the entry before it, so no entry can be altered, removed or inserted
+// without breaking the chain from there on. Verify checks the whole chain. The
+// hash of the last entry, the head, commits to the entire log: publishing it,
+// e.g. by having the group sign it, lets auditors detect a log that was
+// rewritten from scratch.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/manifest"
+	"github.com/bartke/frost/party"
+)
+
+// hashContext separates the hashes of entries from other SHA-256 hashes.
+const hashContext = "frost-audit-v1\x00"
+
+// Genesis is the Prev hash of the first entry.
+var Genesis = strings.Repeat("0", 2*sha256.Size)
+
+// ErrBrokenChain is returned by Verify and Open if an entry was altered,
+// removed or inserted.
+var ErrBrokenChain = errors.New("audit: broken hash chain")
+
+// Kind is the kind of session an event records.
+type Kind string
+
+const (
+	KindKeygen  Kind = "keygen"
+	KindSign    Kind = "sign"
+	KindRefresh Kind = "refresh"
+)
+
+// Outcome is the outcome of a session.
+type Outcome string
+
+const (
+	OutcomeSucceeded Outcome = "succeeded"
+	OutcomeFailed    Outcome = "failed"
+)
+
+// Event is a session recorded in the log.
+type Event struct {
+	Kind    Kind    `json:"kind"`
+	Outcome Outcome `json:"outcome"`
+	// Group is the fingerprint of the group key, see manifest.Fingerprint. It
+	// is empty for a key generation that failed.
+	Group string `json:"group,omitempty"`
+	// Party is the party that recorded the event, or 0 for a coordinator.
+	Party party.ID `json:"party,omitempty"`
+	// Parties are the signers of a signing session, or the parties of a key
+	// generation or refresh.
+	Parties party.IDSlice `json:"parties,omitempty"`
+	// Threshold is the threshold of a key generation or refresh.
+	Threshold party.Size `json:"threshold,omitempty"`
+	// MessageDigest is the hex encoded SHA-256 of the message signed, which is
+	// the SHA-512 digest of the original message if Prehash is set.
+	MessageDigest string `json:"message_digest,omitempty"`
+	Prehash       bool   `json:"prehash,omitempty"`
+	// Signature is the signature of a signing session that succeeded.
+	Signature *eddsa.Signature `json:"signature,omitempty"`
+	// Error is the reason a session failed, and Culprit the party to blame, if any.
+	Error   string   `json:"error,omitempty"`
+	Culprit party.ID `json:"culprit,omitempty"`
+}
+
+// Entry is an event in the log.
+type Entry struct {
+	// Seq is the position of the entry in the log, starting at 1.
+	Seq  uint64    `json:"seq"`
+	Time time.Time `json:"time"`
+	Event
+	// Prev is the Hash of the entry before, or Genesis for the first one.
+	Prev string `json:"prev"`
+	// Hash is the hex encoded hash of the entry. It is not part of the encoded entry.
+	Hash string `json:"-"`
+}
+
+// record is a line of the log.
+type record struct {
+	Entry json.RawMessage `json:"entry"`
+	Hash  string          `json:"hash"`
+}
+
+// hashEntry returns the hash of the encoded entry.
+func hashEntry(encoded []byte) string {
+	h := sha256.New()
+	h.Write([]byte(hashContext))
+	h.Write(encoded)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Log is an audit log backed by an append-only file. Every entry is synced to
+// disk before Append returns.
+type Log struct {
+	// Clock is the time of the entries, it defaults to the real time.
+	Clock clock.Clock
+
+	mu   sync.Mutex
+	file *os.File
+	last Entry
+}
+
+// Open opens or creates the log stored at path, and verifies its chain.
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	l := &Log{file: f, last: Entry{Hash: Genesis}}
+	err = verify(f, func(e *Entry) error {
+		l.last = *e
+		return nil
+	})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Head returns the sequence number and hash of the last entry, or 0 and
+// Genesis if the log is empty.
+func (l *Log) Head() (uint64, string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.last.Seq, l.last.Hash
+}
+
+// Append records the event, and returns its entry.
+func (l *Log) Append(event Event) (*Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := &Entry{
+		Seq:   l.last.Seq + 1,
+		Time:  clock.OrReal(l.Clock).Now().UTC(),
+		Event: event,
+		Prev:  l.last.Hash,
+	}
+	encoded, err := json.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	e.Hash = hashEntry(encoded)
+	line, err := json.Marshal(&record{Entry: encoded, Hash: e.Hash})
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	l.last = *e
+	return e, nil
+}
+
+// Close closes the underlying file.
+func (l *Log) Close() error {
+	return l.file.Close()
+}
+
+// Verify reads the log from r and checks its chain. It returns the entries,
+// or an error wrapping ErrBrokenChain naming the first entry that does not
+// verify.
+func Verify(r io.Reader) ([]*Entry, error) {
+	var entries []*Entry
+	err := verify(r, func(e *Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	return entries, err
+}
+
+// VerifyFile is Verify of the log stored at path.
+func VerifyFile(path string) ([]*Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	defer f.Close()
+	return Verify(f)
+}
+
+// verify calls fn with every entry of the log read from r, after checking it
+// against the one before.
+func verify(r io.Reader, fn func(*Entry) error) error {
+	reader := bufio.NewReader(r)
+	prev := Entry{Hash: Genesis}
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				return fmt.Errorf("%w: truncated entry %d", ErrBrokenChain, prev.Seq+1)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("audit: %w", err)
+		}
+
+		var rec record
+		var e Entry
+		if err := json.Unmarshal(bytes.TrimSuffix(line, []byte("\n")), &rec); err != nil {
+			return fmt.Errorf("%w: malformed entry %d", ErrBrokenChain, prev.Seq+1)
+		}
+		if err := json.Unmarshal(rec.Entry, &e); err != nil {
+			return fmt.Errorf("%w: malformed entry %d", ErrBrokenChain, prev.Seq+1)
+		}
+		switch {
+		case e.Seq != prev.Seq+1:
+			return fmt.Errorf("%w: entry %d follows entry %d", ErrBrokenChain, e.Seq, prev.Seq)
+		case e.Prev != prev.Hash:
+			return fmt.Errorf("%w: entry %d does not follow the hash of entry %d", ErrBrokenChain, e.Seq, prev.Seq)
+		case hashEntry(rec.Entry) != rec.Hash:
+			return fmt.Errorf("%w: hash mismatch of entry %d", ErrBrokenChain, e.Seq)
+		}
+		e.Hash = rec.Hash
+		if err := fn(&e); err != nil {
+			return err
+		}
+		prev = e
+	}
+}
+
+// KeygenEvent returns the event of a key generation of self with the parties
+// 1..n and threshold, which resulted in public or failed with err.
+func KeygenEvent(self party.ID, n, threshold party.Size, public *eddsa.Public, err error) Event {
+	e := Event{Kind: KindKeygen, Party: self, Parties: make(party.IDSlice, 0, n), Threshold: threshold}
+	for id := party.ID(1); id <= n; id++ {
+		e.Parties = append(e.Parties, id)
+	}
+	if public != nil {
+		e.Group = manifest.Fingerprint(public.GroupKey.ToEd25519())
+	}
+	e.setOutcome(err)
+	return e
+}
+
+// SignEvent returns the event of a signing session of self with the signers
+// of groupKey, over message, which resulted in sig or failed with err.
+// message is the SHA-512 digest of the original message if prehash is set.
+func SignEvent(self party.ID, signers party.IDSlice, groupKey *eddsa.PublicKey, message []byte, prehash bool, sig *eddsa.Signature, err error) Event {
+	digest := sha256.Sum256(message)
+	e := Event{
+		Kind:          KindSign,
+		Group:         manifest.Fingerprint(groupKey.ToEd25519()),
+		Party:         self,
+		Parties:       signers,
+		MessageDigest: hex.EncodeToString(digest[:]),
+		Prehash:       prehash,
+	}
+	if err == nil {
+		e.Signature = sig
+	}
+	e.setOutcome(err)
+	return e
+}
+
+// RefreshEvent returns the event of a refresh of the shares of public by
+// self, which failed if err is set.
+func RefreshEvent(self party.ID, public *eddsa.Public, err error) Event {
+	e := Event{
+		Kind:      KindRefresh,
+		Group:     manifest.Fingerprint(public.GroupKey.ToEd25519()),
+		Party:     self,
+		Parties:   public.PartyIDs,
+		Threshold: public.Threshold,
+	}
+	e.setOutcome(err)
+	return e
+}
+
+// setOutcome sets the outcome of the event to that of a session which
+// returned err, blaming the culprit of an *frost.AbortError.
+func (e *Event) setOutcome(err error) {
+	if err == nil {
+		e.Outcome = OutcomeSucceeded
+		return
+	}
+	e.Outcome = OutcomeFailed
+	e.Error = err.Error()
+	var abort *frost.AbortError
+	if errors.As(err, &abort) {
+		e.Culprit = abort.Culprit
+	}
+}
+
+// VerifySignature checks that the entry records a signature of message by the
+// group key pub, proving that the quorum signed it. For an entry with Prehash
+// set, message is the original message.
+func (e *Entry) VerifySignature(pub ed25519.PublicKey, message []byte) error {
+	switch {
+	case e.Kind != KindSign || e.Outcome != OutcomeSucceeded || e.Signature == nil:
+		return fmt.Errorf("audit: entry %d records no signature", e.Seq)
+	case e.Group != manifest.Fingerprint(pub):
+		return fmt.Errorf("audit: entry %d is of group %s", e.Seq, e.Group)
+	}
+	opts := &ed25519.Options{}
+	if e.Prehash {
+		digest := sha512.Sum512(message)
+		message = digest[:]
+		opts.Hash = crypto.SHA512
+	}
+	if digest := sha256.Sum256(message); hex.EncodeToString(digest[:]) != e.MessageDigest {
+		return fmt.Errorf("audit: entry %d is of another message", e.Seq)
+	}
+	if err := ed25519.VerifyWithOptions(pub, message, e.Signature.ToEd25519(), opts); err != nil {
+		return fmt.Errorf("audit: entry %d: %w", e.Seq, err)
+	}
+	return nil
+}
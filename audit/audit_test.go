@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKey splits a fresh Ed25519 key, so that its signatures can be produced
+// without running the protocol.
+func testKey(t *testing.T) (ed25519.PrivateKey, *eddsa.Public) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	public, _, err := frost.SplitEd25519(priv, 3, 1)
+	require.NoError(t, err)
+	return priv, public
+}
+
+func signature(t *testing.T, sig []byte) *eddsa.Signature {
+	var s eddsa.Signature
+	require.NoError(t, s.UnmarshalText([]byte(hex.EncodeToString(sig))))
+	return &s
+}
+
+// writeLog records a keygen, a signing session that failed and one that
+// succeeded, and returns the path of the log.
+func writeLog(t *testing.T, priv ed25519.PrivateKey, public *eddsa.Public, message []byte) string {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := Open(path)
+	require.NoError(t, err)
+	l.Clock = clock.NewFake(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	_, err = l.Append(KeygenEvent(1, public.PartyIDs.N(), public.Threshold, public, nil))
+	require.NoError(t, err)
+	_, err = l.Append(SignEvent(1, party.IDSlice{1, 2}, public.GroupKey, message, false, nil, &frost.AbortError{Culprit: 2}))
+	require.NoError(t, err)
+	sig := signature(t, ed25519.Sign(priv, message))
+	e, err := l.Append(SignEvent(1, party.IDSlice{1, 3}, public.GroupKey, message, false, sig, nil))
+	require.NoError(t, err)
+
+	seq, head := l.Head()
+	assert.Equal(t, uint64(3), seq)
+	assert.Equal(t, e.Hash, head)
+	require.NoError(t, l.Close())
+	return path
+}
+
+func TestLog(t *testing.T) {
+	priv, public := testKey(t)
+	message := []byte("pay 1 BTC")
+	path := writeLog(t, priv, public, message)
+
+	entries, err := VerifyFile(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, Genesis, entries[0].Prev)
+	for i, e := range entries {
+		assert.Equal(t, uint64(i+1), e.Seq)
+		assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), e.Time)
+		if i > 0 {
+			assert.Equal(t, entries[i-1].Hash, e.Prev)
+		}
+	}
+	assert.Equal(t, KindKeygen, entries[0].Kind)
+	assert.Equal(t, party.IDSlice{1, 2, 3}, entries[0].Parties)
+	assert.Equal(t, OutcomeFailed, entries[1].Outcome)
+	assert.Equal(t, party.ID(2), entries[1].Culprit)
+	assert.Nil(t, entries[1].Signature)
+	assert.Equal(t, party.IDSlice{1, 3}, entries[2].Parties)
+
+	// the log continues where it stopped
+	l, err := Open(path)
+	require.NoError(t, err)
+	e, err := l.Append(RefreshEvent(1, public, nil))
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+	assert.Equal(t, uint64(4), e.Seq)
+	assert.Equal(t, entries[2].Hash, e.Prev)
+	entries, err = VerifyFile(path)
+	require.NoError(t, err)
+	assert.Len(t, entries, 4)
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	priv, public := testKey(t)
+	path := writeLog(t, priv, public, []byte("pay 1 BTC"))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.SplitAfter(string(data), "\n")
+
+	for name, tampered := range map[string]string{
+		"altered":   strings.Replace(string(data), `"outcome":"failed"`, `"outcome":"succeeded"`, 1),
+		"removed":   lines[0] + lines[2],
+		"reordered": lines[0] + lines[2] + lines[1],
+		"truncated": string(data[:len(data)-10]),
+		"garbage":   string(data) + "not json\n",
+	} {
+		_, err := Verify(strings.NewReader(tampered))
+		assert.True(t, errors.Is(err, ErrBrokenChain), name)
+
+		require.NoError(t, os.WriteFile(path, []byte(tampered), 0600))
+		_, err = Open(path)
+		assert.True(t, errors.Is(err, ErrBrokenChain), name)
+	}
+
+	// dropping the last entries leaves a valid chain, which only the head reveals
+	entries, err := Verify(strings.NewReader(lines[0] + lines[1]))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestEntry_VerifySignature(t *testing.T) {
+	priv, public := testKey(t)
+	message := []byte("pay 1 BTC")
+	entries, err := VerifyFile(writeLog(t, priv, public, message))
+	require.NoError(t, err)
+
+	assert.NoError(t, entries[2].VerifySignature(public.GroupKey.ToEd25519(), message))
+	assert.Error(t, entries[2].VerifySignature(public.GroupKey.ToEd25519(), []byte("pay 2 BTC")))
+	assert.Error(t, entries[1].VerifySignature(public.GroupKey.ToEd25519(), message), "failed session")
+	_, other := testKey(t)
+	assert.Error(t, entries[2].VerifySignature(other.GroupKey.ToEd25519(), message))
+
+	// a prehashed session records the digest, but is checked against the message
+	digest := sha512.Sum512(message)
+	sig, err := priv.Sign(nil, digest[:], &ed25519.Options{Hash: crypto.SHA512})
+	require.NoError(t, err)
+	e := &Entry{Seq: 1, Event: SignEvent(0, party.IDSlice{1, 2}, public.GroupKey, digest[:], true, signature(t, sig), nil)}
+	assert.NoError(t, e.VerifySignature(public.GroupKey.ToEd25519(), message))
+	assert.Error(t, e.VerifySignature(public.GroupKey.ToEd25519(), digest[:]))
+}
@@ -0,0 +1,346 @@
+// Package bundle exports the public information of a group as a read-only
+// verification bundle, for third parties such as exchanges and counterparties
+// that must validate a custody setup without taking part in it.
+//
+// A bundle holds the group key, the threshold and the public shares of all
+// parties, and optionally the KeyGen1 broadcasts of the key generation. It is
+// signed with the group key, like a manifest or an attestation. Verify checks
+// the signature, that the public shares interpolate to the group key, and, if
+// the broadcasts are included, that they prove every party contributed to the
+// key and that the shares follow from their commitments.
+//
+// The bundle only proves that whoever controls the group key produced it; the
+// group key itself must be compared with one obtained from a trusted source,
+// e.g. by its fingerprint.
+package bundle
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/manifest"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/polynomial"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/zk"
+)
+
+// Version is the current bundle format version.
+const Version = 1
+
+const bodyHeader = "frost-bundle-v1\n"
+
+// Bundle is the verification bundle of a group.
+type Bundle struct {
+	Public *eddsa.Public
+	// Ceremony holds the KeyGen1 broadcasts of the key generation, sorted by
+	// party. It is empty if the bundle does not attest the ceremony.
+	Ceremony []*frost.Message
+	Created  time.Time
+	// Signature is the signature of the group over Body.
+	Signature []byte
+}
+
+// New returns an unsigned bundle of public. The ceremony messages are optional;
+// if given, they must be the KeyGen1 broadcasts of all parties.
+func New(public *eddsa.Public, ceremony []*frost.Message, created time.Time) (*Bundle, error) {
+	b := &Bundle{Public: public, Created: created.UTC().Truncate(time.Second)}
+	for _, msg := range ceremony {
+		if msg.Type != frost.MessageTypeKeyGen1 || msg.KeyGen1 == nil {
+			return nil, fmt.Errorf("bundle: message of party %d is not a KeyGen1 broadcast", msg.From)
+		}
+		b.Ceremony = append(b.Ceremony, msg)
+	}
+	sort.Slice(b.Ceremony, func(i, j int) bool { return b.Ceremony[i].From < b.Ceremony[j].From })
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GroupKey returns the group key as an ed25519 public key.
+func (b *Bundle) GroupKey() ed25519.PublicKey {
+	return b.Public.GroupKey.ToEd25519()
+}
+
+// Fingerprint returns the fingerprint of the group key, to be compared out of band.
+func (b *Bundle) Fingerprint() string {
+	return manifest.Fingerprint(b.GroupKey())
+}
+
+// Body returns the canonical bytes that are signed:
+//
+//	frost-bundle-v1
+//	group <hex ed25519 group key>
+//	threshold <t>
+//	created <unix seconds>
+//	share <id> <hex public share>             for every party, by ID
+//	keygen1 <id> <hex commitments> <hex proof> for every broadcast, by ID
+func (b *Bundle) Body() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(bodyHeader)
+	fmt.Fprintf(&buf, "group %x\n", b.GroupKey())
+	fmt.Fprintf(&buf, "threshold %d\n", b.Public.Threshold)
+	fmt.Fprintf(&buf, "created %d\n", b.Created.Unix())
+	for _, id := range b.Public.PartyIDs {
+		fmt.Fprintf(&buf, "share %d %x\n", id, b.Public.Shares[id].Bytes())
+	}
+	for _, msg := range b.Ceremony {
+		commitments, _ := msg.KeyGen1.Commitments.MarshalBinary()
+		proof, _ := msg.KeyGen1.Proof.MarshalBinary()
+		fmt.Fprintf(&buf, "keygen1 %d %x %x\n", msg.From, commitments, proof)
+	}
+	return buf.Bytes()
+}
+
+// ParseBody reconstructs an unsigned bundle from its canonical body, and checks
+// its public shares and ceremony.
+func ParseBody(body []byte) (*Bundle, error) {
+	text := string(body)
+	if !strings.HasPrefix(text, bodyHeader) || !strings.HasSuffix(text, "\n") {
+		return nil, errors.New("bundle: not a bundle body")
+	}
+	var (
+		groupKey  []byte
+		threshold = -1
+		created   time.Time
+		shares    = make(map[party.ID]*ristretto.Element)
+		ceremony  []*frost.Message
+	)
+	for _, line := range strings.Split(strings.TrimSuffix(text[len(bodyHeader):], "\n"), "\n") {
+		fields := strings.Split(line, " ")
+		var err error
+		switch {
+		case fields[0] == "group" && len(fields) == 2 && groupKey == nil:
+			groupKey, err = hex.DecodeString(fields[1])
+		case fields[0] == "threshold" && len(fields) == 2 && threshold < 0:
+			threshold, err = strconv.Atoi(fields[1])
+		case fields[0] == "created" && len(fields) == 2 && created.IsZero():
+			var unix int64
+			unix, err = strconv.ParseInt(fields[1], 10, 64)
+			created = time.Unix(unix, 0).UTC()
+		case fields[0] == "share" && len(fields) == 3:
+			var id party.ID
+			var data []byte
+			if id, err = party.FromString(fields[1]); err != nil {
+				break
+			}
+			if _, ok := shares[id]; ok {
+				return nil, fmt.Errorf("bundle: duplicate share of party %d", id)
+			}
+			if data, err = hex.DecodeString(fields[2]); err != nil {
+				break
+			}
+			var share ristretto.Element
+			if _, err = share.SetCanonicalBytes(data); err == nil {
+				shares[id] = &share
+			}
+		case fields[0] == "keygen1" && len(fields) == 4:
+			var msg *frost.Message
+			if msg, err = parseKeyGen1(fields[1:]); err == nil {
+				ceremony = append(ceremony, msg)
+			}
+		default:
+			return nil, fmt.Errorf("bundle: unexpected line %q", line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bundle: malformed line %q: %w", line, err)
+		}
+	}
+	if len(groupKey) != ed25519.PublicKeySize || threshold < 0 || created.IsZero() {
+		return nil, errors.New("bundle: incomplete body")
+	}
+
+	public, err := eddsa.NewPublic(shares, party.Size(threshold))
+	if err != nil {
+		return nil, fmt.Errorf("bundle: %w", err)
+	}
+	b := &Bundle{Public: public, Ceremony: ceremony, Created: created}
+	if !bytes.Equal(b.GroupKey(), groupKey) {
+		return nil, errors.New("bundle: the shares do not interpolate to the group key")
+	}
+	if !bytes.Equal(b.Body(), body) {
+		return nil, errors.New("bundle: body is not canonical")
+	}
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func parseKeyGen1(fields []string) (*frost.Message, error) {
+	id, err := party.FromString(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	data, err := hex.DecodeString(fields[1])
+	if err != nil {
+		return nil, err
+	}
+	var commitments polynomial.Exponent
+	if err := commitments.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	if data, err = hex.DecodeString(fields[2]); err != nil {
+		return nil, err
+	}
+	var proof zk.Schnorr
+	if err := proof.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return frost.NewKeyGen1(id, &proof, &commitments), nil
+}
+
+// Attach records the signature of the group over Body.
+func (b *Bundle) Attach(signature []byte) {
+	b.Signature = signature
+}
+
+// Verify checks the bundle. If pub is not nil, the bundle must be for that group key.
+func (b *Bundle) Verify(pub ed25519.PublicKey) error {
+	if pub != nil && !bytes.Equal(pub, b.GroupKey()) {
+		return errors.New("bundle: for a different group key")
+	}
+	if len(b.Signature) != ed25519.SignatureSize || !ed25519.Verify(b.GroupKey(), b.Body(), b.Signature) {
+		return errors.New("bundle: invalid signature")
+	}
+	return b.check()
+}
+
+// Import decodes a bundle encoded by MarshalJSON and verifies it, for systems
+// that only verify the signatures of the group. If pub is not nil, the bundle
+// must be for that group key.
+func Import(data []byte, pub ed25519.PublicKey) (*Bundle, error) {
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	if err := b.Verify(pub); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// VerifySignature returns true if sig is an ed25519 signature of message by
+// the group key of the bundle.
+func (b *Bundle) VerifySignature(message, sig []byte) bool {
+	return len(sig) == ed25519.SignatureSize && ed25519.Verify(b.GroupKey(), message, sig)
+}
+
+// IsQuorum returns true if signers are distinct parties of the group, and
+// more than the threshold of them, so that they can sign together.
+func (b *Bundle) IsQuorum(signers party.IDSlice) bool {
+	ids := party.NewIDSlice(signers)
+	return len(ids) == len(signers) && ids.N() > b.Public.Threshold && ids.IsSubsetOf(b.Public.PartyIDs)
+}
+
+// check verifies the public shares and the ceremony.
+func (b *Bundle) check() error {
+	public := b.Public
+	if public == nil || public.GroupKey == nil {
+		return errors.New("bundle: missing public shares")
+	}
+	if public.Threshold+1 > public.PartyIDs.N() {
+		return errors.New("bundle: threshold must be less than the number of parties")
+	}
+	// eddsa.NewPublic interpolates the group key from the shares
+	interpolated, err := eddsa.NewPublic(public.Shares, public.Threshold)
+	if err != nil {
+		return fmt.Errorf("bundle: %w", err)
+	}
+	if !interpolated.GroupKey.Equal(public.GroupKey) || !interpolated.PartyIDs.Equal(public.PartyIDs) {
+		return errors.New("bundle: the shares do not interpolate to the group key")
+	}
+	if len(b.Ceremony) == 0 {
+		return nil
+	}
+	return checkCeremony(public, b.Ceremony)
+}
+
+// checkCeremony checks that msgs are the KeyGen1 broadcasts of all parties of
+// public, with valid proofs of knowledge, and that the public shares and the
+// group key are the sum of the committed polynomials.
+func checkCeremony(public *eddsa.Public, msgs []*frost.Message) error {
+	if len(msgs) != len(public.PartyIDs) {
+		return fmt.Errorf("bundle: %d ceremony messages for %d parties", len(msgs), len(public.PartyIDs))
+	}
+	var sum *polynomial.Exponent
+	for i, msg := range msgs {
+		if msg.From != public.PartyIDs[i] {
+			return fmt.Errorf("bundle: no ceremony message of party %d", public.PartyIDs[i])
+		}
+		c := msg.KeyGen1.Commitments
+		if c.Degree() != public.Threshold {
+			return fmt.Errorf("bundle: commitments of party %d have degree %d", msg.From, c.Degree())
+		}
+		// the proofs of ceremonies with a proof context cannot be verified
+		// without it, see frost.KeygenInitWithProofContext
+		if !msg.KeyGen1.Proof.Verify(msg.From, c.Constant(), frost.KeygenProofContext(msg.SessionID, nil)) {
+			return fmt.Errorf("bundle: invalid proof of knowledge of party %d", msg.From)
+		}
+		if sum == nil {
+			sum = c.Copy()
+		} else if err := sum.Add(c); err != nil {
+			return fmt.Errorf("bundle: %w", err)
+		}
+	}
+	if !eddsa.NewPublicKeyFromPoint(sum.Constant()).Equal(public.GroupKey) {
+		return errors.New("bundle: the ceremony did not produce the group key")
+	}
+	for _, id := range public.PartyIDs {
+		if sum.EvaluateID(id).Equal(public.Shares[id]) != 1 {
+			return fmt.Errorf("bundle: the ceremony did not produce the public share of party %d", id)
+		}
+	}
+	return nil
+}
+
+type bundleJSON struct {
+	Version     int    `json:"version"`
+	Fingerprint string `json:"fingerprint"`
+	Body        string `json:"body"`
+	Signature   string `json:"signature"`
+}
+
+// MarshalJSON encodes the bundle as its version, fingerprint, body and hex encoded signature.
+func (b *Bundle) MarshalJSON() ([]byte, error) {
+	return json.Marshal(bundleJSON{
+		Version:     Version,
+		Fingerprint: b.Fingerprint(),
+		Body:        string(b.Body()),
+		Signature:   hex.EncodeToString(b.Signature),
+	})
+}
+
+// UnmarshalJSON decodes a bundle encoded by MarshalJSON. It does not verify the signature.
+func (b *Bundle) UnmarshalJSON(data []byte) error {
+	var v bundleJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Version != Version {
+		return fmt.Errorf("bundle: unsupported version %d", v.Version)
+	}
+	parsed, err := ParseBody([]byte(v.Body))
+	if err != nil {
+		return err
+	}
+	if v.Fingerprint != parsed.Fingerprint() {
+		return errors.New("bundle: fingerprint does not match group key")
+	}
+	if parsed.Signature, err = hex.DecodeString(v.Signature); err != nil {
+		return errors.New("bundle: invalid signature encoding")
+	}
+	*b = *parsed
+	return nil
+}
@@ -0,0 +1,162 @@
+package bundle
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/frostclient"
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keygen runs the key generation and returns its KeyGen1 broadcasts.
+func keygen(t *testing.T, n, threshold party.Size) (*frostclient.Group, []*frost.Message) {
+	states := make(map[party.ID]*frost.KeygenState)
+	var round1 []*frost.Message
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, err := frost.KeygenInit(id, n, threshold)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	round2 := make(map[party.ID][]*frost.Message)
+	for _, state := range states {
+		msgs, _, err := frost.KeygenRound1(state, round1)
+		require.NoError(t, err)
+		for _, msg := range msgs {
+			round2[msg.To] = append(round2[msg.To], msg)
+		}
+	}
+	group := &frostclient.Group{Shares: make(map[party.ID]*eddsa.SecretShare)}
+	for id, state := range states {
+		public, secret, err := frost.KeygenRound2(state, round2[id])
+		require.NoError(t, err)
+		group.Public = public
+		group.Shares[id] = secret
+	}
+	group.Signers = group.Public.PartyIDs[:threshold+1]
+	return group, round1
+}
+
+func sign(t *testing.T, group *frostclient.Group, b *Bundle) {
+	sig, err := frostclient.Sign(context.Background(), group, b.Body())
+	require.NoError(t, err)
+	b.Attach(sig.ToEd25519())
+}
+
+func TestBundle(t *testing.T) {
+	group, ceremony := keygen(t, 4, 2)
+	created := time.Unix(1700000000, 0)
+
+	// in any order
+	shuffled := []*frost.Message{ceremony[2], ceremony[0], ceremony[3], ceremony[1]}
+	b, err := New(group.Public, shuffled, created)
+	require.NoError(t, err)
+	sign(t, group, b)
+	require.NoError(t, b.Verify(nil))
+	require.NoError(t, b.Verify(group.Public.GroupKey.ToEd25519()))
+
+	data, err := json.Marshal(b)
+	require.NoError(t, err)
+	var decoded Bundle
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.NoError(t, decoded.Verify(group.Public.GroupKey.ToEd25519()))
+	assert.True(t, decoded.Public.Equal(group.Public))
+	assert.Len(t, decoded.Ceremony, 4)
+	assert.Equal(t, created.UTC(), decoded.Created)
+
+	other, _ := keygen(t, 3, 1)
+	assert.Error(t, decoded.Verify(other.Public.GroupKey.ToEd25519()))
+
+	decoded.Signature[0] ^= 1
+	assert.Error(t, decoded.Verify(nil))
+
+	// without the ceremony
+	b, err = New(group.Public, nil, created)
+	require.NoError(t, err)
+	sign(t, group, b)
+	require.NoError(t, b.Verify(nil))
+}
+
+func TestBundle_Ceremony(t *testing.T) {
+	group, ceremony := keygen(t, 3, 1)
+	_, foreign := keygen(t, 3, 1)
+
+	_, err := New(group.Public, ceremony[:2], time.Now())
+	assert.Error(t, err, "all parties must be included")
+	_, err = New(group.Public, []*frost.Message{ceremony[0], ceremony[1], foreign[2]}, time.Now())
+	assert.Error(t, err, "the broadcasts must produce the shares")
+	_, err = New(group.Public, []*frost.Message{ceremony[0], ceremony[1], ceremony[1]}, time.Now())
+	assert.Error(t, err)
+
+	// a valid proof of another party
+	forged := frost.NewKeyGen1(3, ceremony[1].KeyGen1.Proof, ceremony[2].KeyGen1.Commitments)
+	_, err = New(group.Public, []*frost.Message{ceremony[0], ceremony[1], forged}, time.Now())
+	assert.Error(t, err)
+}
+
+func TestParseBody(t *testing.T) {
+	group, ceremony := keygen(t, 3, 1)
+	b, err := New(group.Public, ceremony, time.Now())
+	require.NoError(t, err)
+	body := string(b.Body())
+
+	parsed, err := ParseBody([]byte(body))
+	require.NoError(t, err)
+	assert.Equal(t, b.Body(), parsed.Body())
+
+	for _, bad := range []string{
+		strings.Replace(body, "threshold 1", "threshold 01", 1),
+		strings.Replace(body, "threshold 1", "threshold 2", 1),
+		strings.Replace(body, "frost-bundle-v1", "frost-bundle-v2", 1),
+		body + "share 4 00\n",
+		strings.TrimSuffix(body, "\n"),
+		body[:strings.Index(body, "share 1")],
+	} {
+		_, err := ParseBody([]byte(bad))
+		assert.Error(t, err)
+	}
+
+	// a bundle for another key does not verify
+	var pub ed25519.PublicKey = make([]byte, ed25519.PublicKeySize)
+	assert.Error(t, parsed.Verify(pub))
+}
+
+func TestImport(t *testing.T) {
+	group, _ := keygen(t, 4, 1)
+	b, err := New(group.Public, nil, time.Now())
+	require.NoError(t, err)
+	sign(t, group, b)
+	data, err := json.Marshal(b)
+	require.NoError(t, err)
+
+	imported, err := Import(data, group.Public.GroupKey.ToEd25519())
+	require.NoError(t, err)
+	sig, err := frostclient.Sign(context.Background(), group, []byte("release"))
+	require.NoError(t, err)
+	assert.True(t, imported.VerifySignature([]byte("release"), sig.ToEd25519()))
+	assert.False(t, imported.VerifySignature([]byte("other"), sig.ToEd25519()))
+	assert.False(t, imported.VerifySignature([]byte("release"), sig.ToEd25519()[:10]))
+
+	assert.True(t, imported.IsQuorum(party.IDSlice{1, 4}))
+	assert.True(t, imported.IsQuorum(party.IDSlice{4, 2, 3}))
+	assert.False(t, imported.IsQuorum(party.IDSlice{2}))
+	assert.False(t, imported.IsQuorum(party.IDSlice{2, 2}))
+	assert.False(t, imported.IsQuorum(party.IDSlice{2, 5}))
+
+	other, _ := keygen(t, 3, 1)
+	_, err = Import(data, other.Public.GroupKey.ToEd25519())
+	assert.Error(t, err)
+	b.Signature[0] ^= 1
+	data, err = json.Marshal(b)
+	require.NoError(t, err)
+	_, err = Import(data, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,967 @@
+package frost
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bartke/frost/cbor"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/polynomial"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/zk"
+)
+
+// Messages and states can be encoded as CBOR maps with small integer keys, for
+// constrained signers and peers in other languages. Key 0 always holds the
+// version, CBORVersion. Points are 32 byte ristretto encodings, scalars 32 byte
+// little-endian encodings, IDs unsigned integers, and polynomials arrays of
+// their coefficients, starting with the constant. Keys of fields that are
+// empty are omitted, unknown keys are ignored.
+//
+// Message:
+//
+//	1 type, 2 from, 3 to,
+//	4 proof (KeyGen1), 5 commitments (KeyGen1, Reshare1),
+//	6 share (KeyGen2, Reshare2, Repair1, Repair2), 7 Di, 8 Ei (Sign1), 9 Zi (Sign2),
+//	10 round, 11 session ID (32 bytes), 12 digest (Sign0, KeyGenEcho, 64 bytes),
+//	13 nonces (SignBatch1): array of Di ∥ Ei (64 bytes), 14 shares (SignBatch2): array of Zi
+//
+// SignerState:
+//
+//	1 self id, 2 signer ids, 3 message, 4 group key (ed25519 encoding),
+//	5 secret key share, 6 e, 7 d, 8 c, 9 R,
+//	10 signers: map from id to {1 public, 2 Di, 3 Ei, 4 Ri, 5 Pi, 6 Zi},
+//	11 request: {1 id, 2 message, 3 requester, 4 purpose, 5 expiry (RFC 3339),
+//	6 format, 7 chain, 8 metadata}, 12 RFC 9591 mode, 13 session ID,
+//	14 prehash mode, 15 received: map from message type to ids
+//
+// KeygenState:
+//
+//	1 self id, 2 party ids, 3 threshold, 4 polynomial, 5 secret,
+//	6 commitments: map from id to polynomial, 7 commitments sum, 8 session ID,
+//	9 identities: map from id to ed25519 public key,
+//	10 received: map from message type to ids, 11 proof context
+const CBORVersion = 1
+
+// MarshalCBOR returns the CBOR encoding of m.
+func (m *Message) MarshalCBOR() ([]byte, error) {
+	e := cbor.NewEncoder(make([]byte, 0, 16+m.Size()))
+	n := 4
+	switch {
+	case m.Type == MessageTypeKeyGen1 && m.KeyGen1 != nil, m.Type == MessageTypeSign1 && m.Sign1 != nil:
+		n += 2
+	case m.Type == MessageTypeKeyGen2 && m.KeyGen2 != nil, m.Type == MessageTypeSign2 && m.Sign2 != nil,
+		m.Type == MessageTypeReshare1 && m.Reshare1 != nil, m.Type == MessageTypeReshare2 && m.Reshare2 != nil,
+		m.Type == MessageTypeRepair1 && m.Repair1 != nil, m.Type == MessageTypeRepair2 && m.Repair2 != nil,
+		m.Type == MessageTypeSign0 && m.Sign0 != nil, m.Type == MessageTypeKeyGenEcho && m.KeyGenEcho != nil,
+		m.Type == MessageTypeSignBatch1 && m.SignBatch1 != nil, m.Type == MessageTypeSignBatch2 && m.SignBatch2 != nil:
+		n++
+	default:
+		return nil, fmt.Errorf("message: no payload for type %s: %w", m.Type, ErrInvalidMessage)
+	}
+	if m.Round != 0 {
+		n++
+	}
+	if !m.SessionID.IsZero() {
+		n++
+	}
+	e.Map(n)
+	e.Uint(0)
+	e.Uint(CBORVersion)
+	e.Uint(1)
+	e.Uint(uint64(m.Type))
+	e.Uint(2)
+	e.Uint(uint64(m.From))
+	e.Uint(3)
+	e.Uint(uint64(m.To))
+
+	switch m.Type {
+	case MessageTypeKeyGen1:
+		proof, err := m.KeyGen1.Proof.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		e.Uint(4)
+		e.ByteString(proof)
+		e.Uint(5)
+		if err := encodeCoefficients(e, m.KeyGen1.Commitments); err != nil {
+			return nil, err
+		}
+	case MessageTypeKeyGen2:
+		e.Uint(6)
+		e.ByteString(m.KeyGen2.Share.Bytes())
+	case MessageTypeSign1:
+		e.Uint(7)
+		e.ByteString(m.Sign1.Di.Bytes())
+		e.Uint(8)
+		e.ByteString(m.Sign1.Ei.Bytes())
+	case MessageTypeSign2:
+		e.Uint(9)
+		e.ByteString(m.Sign2.Zi.Bytes())
+	case MessageTypeReshare1:
+		e.Uint(5)
+		if err := encodeCoefficients(e, m.Reshare1.Commitments); err != nil {
+			return nil, err
+		}
+	case MessageTypeReshare2:
+		e.Uint(6)
+		e.ByteString(m.Reshare2.Share.Bytes())
+	case MessageTypeRepair1:
+		e.Uint(6)
+		e.ByteString(m.Repair1.Delta.Bytes())
+	case MessageTypeRepair2:
+		e.Uint(6)
+		e.ByteString(m.Repair2.Sigma.Bytes())
+	}
+
+	if m.Round != 0 {
+		e.Uint(10)
+		e.Uint(uint64(m.Round))
+	}
+	if !m.SessionID.IsZero() {
+		e.Uint(11)
+		e.ByteString(m.SessionID[:])
+	}
+	switch m.Type {
+	case MessageTypeSign0:
+		e.Uint(12)
+		e.ByteString(m.Sign0.Digest[:])
+	case MessageTypeKeyGenEcho:
+		e.Uint(12)
+		e.ByteString(m.KeyGenEcho.Digest[:])
+	case MessageTypeSignBatch1:
+		e.Uint(13)
+		e.Array(len(m.SignBatch1.Nonces))
+		for i := range m.SignBatch1.Nonces {
+			nonces, _ := m.SignBatch1.Nonces[i].MarshalBinary()
+			e.ByteString(nonces)
+		}
+	case MessageTypeSignBatch2:
+		e.Uint(14)
+		e.Array(len(m.SignBatch2.Shares))
+		for i := range m.SignBatch2.Shares {
+			e.ByteString(m.SignBatch2.Shares[i].Bytes())
+		}
+	}
+	return e.Bytes(), nil
+}
+
+// UnmarshalCBOR sets m to the message encoded by MarshalCBOR.
+func (m *Message) UnmarshalCBOR(data []byte) error {
+	var (
+		msg     Message
+		proof   *zk.Schnorr
+		commits *polynomial.Exponent
+		share   *ristretto.Scalar
+		di, ei  *ristretto.Element
+		zi      *ristretto.Scalar
+		// sign0 holds the digest of Sign0 and KeyGenEcho
+		sign0  *Sign0
+		batch1 *SignBatch1
+		batch2 *SignBatch2
+	)
+	d := cbor.NewDecoder(data)
+	err := decodeMap(d, func(key uint64) error {
+		var err error
+		switch key {
+		case 1:
+			var t uint64
+			if t, err = d.Uint(); err == nil && t > 0xff {
+				err = fmt.Errorf("invalid type %d", t)
+			}
+			msg.Type = MessageType(t)
+		case 2:
+			msg.From, err = decodeID(d)
+		case 3:
+			msg.To, err = decodeID(d)
+		case 4:
+			proof = &zk.Schnorr{}
+			err = decodeBinary(d, proof, proof.Size())
+		case 5:
+			commits = &polynomial.Exponent{}
+			err = decodeCoefficients(d, commits)
+		case 6:
+			share, err = decodeScalarCBOR(d)
+		case 7:
+			di, err = decodeElement(d)
+		case 8:
+			ei, err = decodeElement(d)
+		case 9:
+			zi, err = decodeScalarCBOR(d)
+		case 10:
+			var r uint64
+			if r, err = d.Uint(); err == nil && r > 0xff {
+				err = fmt.Errorf("invalid round %d", r)
+			}
+			msg.Round = uint8(r)
+		case 11:
+			err = decodeSessionID(d, &msg.SessionID)
+		case 12:
+			sign0 = &Sign0{}
+			err = decodeBinary(d, sign0, sign0.Size())
+		case 13:
+			var n int
+			if n, err = decodeBatchSize(d); err == nil {
+				batch1 = &SignBatch1{Nonces: make([]Sign1, n)}
+				for i := 0; i < n && err == nil; i++ {
+					err = decodeBinary(d, &batch1.Nonces[i], batch1.Nonces[i].Size())
+				}
+			}
+		case 14:
+			var n int
+			if n, err = decodeBatchSize(d); err == nil {
+				batch2 = &SignBatch2{Shares: make([]ristretto.Scalar, n)}
+				for i := 0; i < n && err == nil; i++ {
+					err = decodeScalarInto(d, &batch2.Shares[i])
+				}
+			}
+		default:
+			err = d.Skip()
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("message: %w", err)
+	}
+	if msg.Round == 0 {
+		msg.Round = msg.Type.Round()
+	}
+
+	missing := false
+	switch msg.Type {
+	case MessageTypeKeyGen1:
+		missing = proof == nil || commits == nil
+		msg.KeyGen1 = &KeyGen1{Proof: proof, Commitments: commits}
+	case MessageTypeKeyGen2:
+		missing = share == nil
+		if !missing {
+			msg.KeyGen2 = &KeyGen2{Share: *share}
+		}
+	case MessageTypeSign1:
+		missing = di == nil || ei == nil
+		if !missing {
+			msg.Sign1 = &Sign1{Di: *di, Ei: *ei}
+		}
+	case MessageTypeSign2:
+		missing = zi == nil
+		if !missing {
+			msg.Sign2 = &Sign2{Zi: *zi}
+		}
+	case MessageTypeReshare1:
+		missing = commits == nil
+		msg.Reshare1 = &Reshare1{Commitments: commits}
+	case MessageTypeReshare2:
+		missing = share == nil
+		if !missing {
+			msg.Reshare2 = &Reshare2{Share: *share}
+		}
+	case MessageTypeRepair1:
+		missing = share == nil
+		if !missing {
+			msg.Repair1 = &Repair1{Delta: *share}
+		}
+	case MessageTypeRepair2:
+		missing = share == nil
+		if !missing {
+			msg.Repair2 = &Repair2{Sigma: *share}
+		}
+	case MessageTypeSign0:
+		missing = sign0 == nil
+		msg.Sign0 = sign0
+	case MessageTypeKeyGenEcho:
+		missing = sign0 == nil
+		if !missing {
+			msg.KeyGenEcho = &KeyGenEcho{Digest: sign0.Digest}
+		}
+	case MessageTypeSignBatch1:
+		missing = batch1 == nil || len(batch1.Nonces) == 0
+		msg.SignBatch1 = batch1
+	case MessageTypeSignBatch2:
+		missing = batch2 == nil || len(batch2.Shares) == 0
+		msg.SignBatch2 = batch2
+	default:
+		return fmt.Errorf("message: unknown type %d: %w", msg.Type, ErrInvalidMessage)
+	}
+	if missing {
+		return fmt.Errorf("message %s: missing payload: %w", msg.Type, ErrInvalidMessage)
+	}
+	*m = msg
+	return nil
+}
+
+// MarshalCBOR returns the CBOR encoding of s.
+func (s *SignerState) MarshalCBOR() ([]byte, error) {
+	e := cbor.NewEncoder(nil)
+	n := 11
+	if s.Request != nil {
+		n++
+	}
+	if s.RFC9591 {
+		n++
+	}
+	if !s.SessionID.IsZero() {
+		n++
+	}
+	if s.Prehash {
+		n++
+	}
+	if len(s.Received) > 0 {
+		n++
+	}
+	e.Map(n)
+	e.Uint(0)
+	e.Uint(CBORVersion)
+	e.Uint(1)
+	e.Uint(uint64(s.SelfID))
+	e.Uint(2)
+	encodeIDs(e, s.SignerIDs)
+	e.Uint(3)
+	e.ByteString(s.Message)
+	e.Uint(4)
+	e.ByteString(s.GroupKey.ToEd25519())
+	e.Uint(5)
+	e.ByteString(s.SecretKeyShare.Bytes())
+	e.Uint(6)
+	e.ByteString(s.E.Bytes())
+	e.Uint(7)
+	e.ByteString(s.D.Bytes())
+	e.Uint(8)
+	e.ByteString(s.C.Bytes())
+	e.Uint(9)
+	e.ByteString(s.R.Bytes())
+
+	e.Uint(10)
+	ids := make([]party.ID, 0, len(s.Signers))
+	for id := range s.Signers {
+		ids = append(ids, id)
+	}
+	ids = party.NewIDSlice(ids)
+	e.Map(len(ids))
+	for _, id := range ids {
+		p := s.Signers[id]
+		e.Uint(uint64(id))
+		e.Map(6)
+		for i, b := range [][]byte{p.Public.Bytes(), p.Di.Bytes(), p.Ei.Bytes(), p.Ri.Bytes(), p.Pi.Bytes(), p.Zi.Bytes()} {
+			e.Uint(uint64(i + 1))
+			e.ByteString(b)
+		}
+	}
+
+	if s.Request != nil {
+		e.Uint(11)
+		encodeRequest(e, s.Request)
+	}
+	if s.RFC9591 {
+		e.Uint(12)
+		e.Bool(true)
+	}
+	if !s.SessionID.IsZero() {
+		e.Uint(13)
+		e.ByteString(s.SessionID[:])
+	}
+	if s.Prehash {
+		e.Uint(14)
+		e.Bool(true)
+	}
+	if len(s.Received) > 0 {
+		e.Uint(15)
+		encodeReceived(e, s.Received)
+	}
+	return e.Bytes(), nil
+}
+
+// UnmarshalCBOR sets s to the state encoded by MarshalCBOR.
+func (s *SignerState) UnmarshalCBOR(data []byte) error {
+	state := SignerState{R: *ristretto.NewIdentityElement()}
+	hasGroupKey := false
+	d := cbor.NewDecoder(data)
+	err := decodeMap(d, func(key uint64) error {
+		var err error
+		switch key {
+		case 1:
+			state.SelfID, err = decodeID(d)
+		case 2:
+			state.SignerIDs, err = decodeIDs(d)
+		case 3:
+			var b []byte
+			if b, err = d.ByteString(); err == nil {
+				state.Message = append([]byte{}, b...)
+			}
+		case 4:
+			var b []byte
+			if b, err = d.ByteString(); err == nil {
+				err = state.GroupKey.Scan(b)
+				hasGroupKey = err == nil
+			}
+		case 5:
+			err = decodeScalarInto(d, &state.SecretKeyShare)
+		case 6:
+			err = decodeScalarInto(d, &state.E)
+		case 7:
+			err = decodeScalarInto(d, &state.D)
+		case 8:
+			err = decodeScalarInto(d, &state.C)
+		case 9:
+			err = decodeElementInto(d, &state.R)
+		case 10:
+			state.Signers, err = decodeSigners(d)
+		case 11:
+			state.Request, err = decodeRequest(d)
+		case 12:
+			state.RFC9591, err = d.Bool()
+		case 13:
+			err = decodeSessionID(d, &state.SessionID)
+		case 14:
+			state.Prehash, err = d.Bool()
+		case 15:
+			state.Received, err = decodeReceived(d)
+		default:
+			err = d.Skip()
+		}
+		return err
+	})
+	if err == nil && !hasGroupKey {
+		err = errors.New("missing group key")
+	}
+	if err != nil {
+		return fmt.Errorf("SignerState: %w", err)
+	}
+	*s = state
+	return nil
+}
+
+// MarshalCBOR returns the CBOR encoding of s.
+func (s *KeygenState) MarshalCBOR() ([]byte, error) {
+	e := cbor.NewEncoder(nil)
+	n := 7
+	if s.CommitmentsSum != nil {
+		n++
+	}
+	if !s.SessionID.IsZero() {
+		n++
+	}
+	if s.Identities != nil {
+		n++
+	}
+	if len(s.Received) > 0 {
+		n++
+	}
+	if len(s.ProofContext) > 0 {
+		n++
+	}
+	e.Map(n)
+	e.Uint(0)
+	e.Uint(CBORVersion)
+	e.Uint(1)
+	e.Uint(uint64(s.SelfID))
+	e.Uint(2)
+	encodeIDs(e, s.PartyIDs)
+	e.Uint(3)
+	e.Uint(uint64(s.Threshold))
+	e.Uint(4)
+	if err := encodeCoefficients(e, s.Polynomial); err != nil {
+		return nil, err
+	}
+	e.Uint(5)
+	e.ByteString(s.Secret.Bytes())
+
+	e.Uint(6)
+	ids := make([]party.ID, 0, len(s.Commitments))
+	for id := range s.Commitments {
+		ids = append(ids, id)
+	}
+	ids = party.NewIDSlice(ids)
+	e.Map(len(ids))
+	for _, id := range ids {
+		e.Uint(uint64(id))
+		if err := encodeCoefficients(e, s.Commitments[id]); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.CommitmentsSum != nil {
+		e.Uint(7)
+		if err := encodeCoefficients(e, s.CommitmentsSum); err != nil {
+			return nil, err
+		}
+	}
+	if !s.SessionID.IsZero() {
+		e.Uint(8)
+		e.ByteString(s.SessionID[:])
+	}
+	if s.Identities != nil {
+		e.Uint(9)
+		ids := make([]party.ID, 0, len(s.Identities))
+		for id := range s.Identities {
+			ids = append(ids, id)
+		}
+		ids = party.NewIDSlice(ids)
+		e.Map(len(ids))
+		for _, id := range ids {
+			e.Uint(uint64(id))
+			e.ByteString(s.Identities[id])
+		}
+	}
+	if len(s.Received) > 0 {
+		e.Uint(10)
+		encodeReceived(e, s.Received)
+	}
+	if len(s.ProofContext) > 0 {
+		e.Uint(11)
+		e.ByteString(s.ProofContext)
+	}
+	return e.Bytes(), nil
+}
+
+// UnmarshalCBOR sets s to the state encoded by MarshalCBOR.
+func (s *KeygenState) UnmarshalCBOR(data []byte) error {
+	state := KeygenState{Commitments: make(map[party.ID]*polynomial.Exponent)}
+	d := cbor.NewDecoder(data)
+	err := decodeMap(d, func(key uint64) error {
+		var err error
+		switch key {
+		case 1:
+			state.SelfID, err = decodeID(d)
+		case 2:
+			state.PartyIDs, err = decodeIDs(d)
+		case 3:
+			state.Threshold, err = decodeID(d)
+		case 4:
+			state.Polynomial = &polynomial.Polynomial{}
+			err = decodeCoefficients(d, state.Polynomial)
+		case 5:
+			err = decodeScalarInto(d, &state.Secret)
+		case 6:
+			var n int
+			if n, err = d.Map(); err != nil {
+				return err
+			}
+			for i := 0; i < n; i++ {
+				id, err := decodeID(d)
+				if err != nil {
+					return err
+				}
+				if _, ok := state.Commitments[id]; ok {
+					return fmt.Errorf("duplicate commitments of party %d", id)
+				}
+				state.Commitments[id] = &polynomial.Exponent{}
+				if err := decodeCoefficients(d, state.Commitments[id]); err != nil {
+					return err
+				}
+			}
+		case 7:
+			state.CommitmentsSum = &polynomial.Exponent{}
+			err = decodeCoefficients(d, state.CommitmentsSum)
+		case 8:
+			err = decodeSessionID(d, &state.SessionID)
+		case 9:
+			var n int
+			if n, err = d.Map(); err != nil {
+				return err
+			}
+			state.Identities = make(Identities, n)
+			for i := 0; i < n; i++ {
+				id, err := decodeID(d)
+				if err != nil {
+					return err
+				}
+				key, err := d.ByteString()
+				if err != nil {
+					return err
+				}
+				if _, ok := state.Identities[id]; ok {
+					return fmt.Errorf("duplicate identity of party %d", id)
+				}
+				state.Identities[id] = append([]byte{}, key...)
+			}
+		case 10:
+			state.Received, err = decodeReceived(d)
+		case 11:
+			var ctx []byte
+			if ctx, err = d.ByteString(); err == nil {
+				state.ProofContext = append([]byte{}, ctx...)
+			}
+		default:
+			err = d.Skip()
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("KeygenState: %w", err)
+	}
+	if state.Polynomial == nil {
+		return errors.New("KeygenState: missing polynomial")
+	}
+	if err := state.Identities.validate(state.PartyIDs); err != nil {
+		return fmt.Errorf("KeygenState: %w", err)
+	}
+	*s = state
+	return nil
+}
+
+// decodeMap reads a map with ascending integer keys and a version in key 0,
+// and calls value for the other keys, which must read the value.
+func decodeMap(d *cbor.Decoder, value func(key uint64) error) error {
+	n, err := d.Map()
+	if err != nil {
+		return err
+	}
+	var last uint64
+	for i := 0; i < n; i++ {
+		key, err := d.Uint()
+		if err != nil {
+			return err
+		}
+		if i > 0 && key <= last {
+			return fmt.Errorf("%w: key %d is duplicate or out of order", cbor.ErrInvalid, key)
+		}
+		last = key
+		if key == 0 {
+			version, err := d.Uint()
+			if err != nil {
+				return err
+			}
+			if version != CBORVersion {
+				return fmt.Errorf("unsupported version %d", version)
+			}
+			continue
+		}
+		if i == 0 {
+			return errors.New("missing version")
+		}
+		if err := value(key); err != nil {
+			return err
+		}
+	}
+	if n == 0 {
+		return errors.New("missing version")
+	}
+	return d.Done()
+}
+
+func decodeID(d *cbor.Decoder) (party.ID, error) {
+	v, err := d.Uint()
+	if err != nil {
+		return 0, err
+	}
+	if v > 0xffff {
+		return 0, fmt.Errorf("invalid id %d", v)
+	}
+	return party.ID(v), nil
+}
+
+func encodeIDs(e *cbor.Encoder, ids party.IDSlice) {
+	e.Array(len(ids))
+	for _, id := range ids {
+		e.Uint(uint64(id))
+	}
+}
+
+// encodeReceived writes r as a map from the message types, in ascending order,
+// to their IDs.
+func encodeReceived(e *cbor.Encoder, r Received) {
+	types := make([]MessageType, 0, len(r))
+	for t := range r {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	e.Map(len(types))
+	for _, t := range types {
+		e.Uint(uint64(t))
+		encodeIDs(e, r[t])
+	}
+}
+
+func decodeReceived(d *cbor.Decoder) (Received, error) {
+	n, err := d.Map()
+	if err != nil {
+		return nil, err
+	}
+	r := make(Received, n)
+	for i := 0; i < n; i++ {
+		t, err := d.Uint()
+		if err != nil {
+			return nil, err
+		}
+		if t > 0xff {
+			return nil, fmt.Errorf("%w: message type %d", cbor.ErrInvalid, t)
+		}
+		if _, ok := r[MessageType(t)]; ok {
+			return nil, fmt.Errorf("duplicate received message type %d", t)
+		}
+		ids, err := decodeIDs(d)
+		if err != nil {
+			return nil, err
+		}
+		r[MessageType(t)] = party.NewIDSlice(ids)
+	}
+	return r, nil
+}
+
+func decodeIDs(d *cbor.Decoder) (party.IDSlice, error) {
+	n, err := d.Array()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(party.IDSlice, n)
+	for i := range ids {
+		if ids[i], err = decodeID(d); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// decodeBatchSize reads the head of the array of a batch, of at most
+// MaxBatchSize items, and returns the number of its items.
+func decodeBatchSize(d *cbor.Decoder) (int, error) {
+	n, err := d.Array()
+	if err != nil {
+		return 0, err
+	}
+	if n > MaxBatchSize {
+		return 0, fmt.Errorf("%w: batch of %d items", cbor.ErrInvalid, n)
+	}
+	return n, nil
+}
+
+// decodeBinary reads a byte string of size bytes into v.
+func decodeBinary(d *cbor.Decoder, v encoding.BinaryUnmarshaler, size int) error {
+	b, err := d.ByteString()
+	if err != nil {
+		return err
+	}
+	if len(b) != size {
+		return fmt.Errorf("%w: %d bytes, expected %d", cbor.ErrInvalid, len(b), size)
+	}
+	return v.UnmarshalBinary(b)
+}
+
+func decodeSessionID(d *cbor.Decoder, id *SessionID) error {
+	b, err := d.ByteString()
+	if err != nil {
+		return err
+	}
+	if len(b) != len(id) {
+		return fmt.Errorf("session ID of %d bytes", len(b))
+	}
+	copy(id[:], b)
+	return nil
+}
+
+func decodeScalarInto(d *cbor.Decoder, s *ristretto.Scalar) error {
+	b, err := d.ByteString()
+	if err != nil {
+		return err
+	}
+	_, err = s.SetCanonicalBytes(b)
+	return err
+}
+
+func decodeScalarCBOR(d *cbor.Decoder) (*ristretto.Scalar, error) {
+	var s ristretto.Scalar
+	return &s, decodeScalarInto(d, &s)
+}
+
+func decodeElementInto(d *cbor.Decoder, p *ristretto.Element) error {
+	b, err := d.ByteString()
+	if err != nil {
+		return err
+	}
+	_, err = p.SetCanonicalBytes(b)
+	return err
+}
+
+func decodeElement(d *cbor.Decoder) (*ristretto.Element, error) {
+	var p ristretto.Element
+	return &p, decodeElementInto(d, &p)
+}
+
+// encodeCoefficients writes the coefficients of a Polynomial or Exponent, whose
+// binary encoding is the degree followed by 32 bytes per coefficient, as an array.
+func encodeCoefficients(e *cbor.Encoder, p encoding.BinaryMarshaler) error {
+	data, err := p.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	coefficients := data[party.IDByteSize:]
+	e.Array(len(coefficients) / 32)
+	for i := 0; i < len(coefficients); i += 32 {
+		e.ByteString(coefficients[i : i+32])
+	}
+	return nil
+}
+
+// decodeCoefficients reads an array written by encodeCoefficients into p.
+func decodeCoefficients(d *cbor.Decoder, p encoding.BinaryUnmarshaler) error {
+	n, err := d.Array()
+	if err != nil {
+		return err
+	}
+	if n == 0 || n > int(polynomial.MaxDegree)+1 {
+		return fmt.Errorf("invalid number of coefficients %d", n)
+	}
+	data := make([]byte, 0, party.IDByteSize+32*n)
+	data = append(data, party.Size(n-1).Bytes()...)
+	for i := 0; i < n; i++ {
+		b, err := d.ByteString()
+		if err != nil {
+			return err
+		}
+		if len(b) != 32 {
+			return fmt.Errorf("%w: coefficient of %d bytes", cbor.ErrInvalid, len(b))
+		}
+		data = append(data, b...)
+	}
+	return p.UnmarshalBinary(data)
+}
+
+func decodeSigners(d *cbor.Decoder) (map[party.ID]*signer, error) {
+	n, err := d.Map()
+	if err != nil {
+		return nil, err
+	}
+	signers := make(map[party.ID]*signer, n)
+	for i := 0; i < n; i++ {
+		id, err := decodeID(d)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := signers[id]; ok {
+			return nil, fmt.Errorf("duplicate signer %d", id)
+		}
+		p := NewSigner()
+		m, err := d.Map()
+		if err != nil {
+			return nil, err
+		}
+		for j := 0; j < m; j++ {
+			key, err := d.Uint()
+			if err != nil {
+				return nil, err
+			}
+			switch key {
+			case 1:
+				err = decodeElementInto(d, &p.Public)
+			case 2:
+				err = decodeElementInto(d, &p.Di)
+			case 3:
+				err = decodeElementInto(d, &p.Ei)
+			case 4:
+				err = decodeElementInto(d, &p.Ri)
+			case 5:
+				err = decodeScalarInto(d, &p.Pi)
+			case 6:
+				err = decodeScalarInto(d, &p.Zi)
+			default:
+				err = d.Skip()
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		signers[id] = p
+	}
+	return signers, nil
+}
+
+func encodeRequest(e *cbor.Encoder, r *SignatureRequest) {
+	var expiry string
+	if !r.Expiry.IsZero() {
+		expiry = r.Expiry.UTC().Format(time.RFC3339Nano)
+	}
+	texts := map[uint64]string{1: r.ID, 3: r.Requester, 4: r.Purpose, 5: expiry, 6: r.Format, 7: r.Chain}
+	n := 1
+	for _, v := range texts {
+		if v != "" {
+			n++
+		}
+	}
+	if len(r.Metadata) > 0 {
+		n++
+	}
+
+	e.Map(n)
+	for key := uint64(1); key <= 7; key++ {
+		if key == 2 {
+			e.Uint(2)
+			e.ByteString(r.Message)
+		} else if texts[key] != "" {
+			e.Uint(key)
+			e.Text(texts[key])
+		}
+	}
+	if len(r.Metadata) > 0 {
+		keys := make([]string, 0, len(r.Metadata))
+		for k := range r.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		e.Uint(8)
+		e.Map(len(keys))
+		for _, k := range keys {
+			e.Text(k)
+			e.Text(r.Metadata[k])
+		}
+	}
+}
+
+func decodeRequest(d *cbor.Decoder) (*SignatureRequest, error) {
+	n, err := d.Map()
+	if err != nil {
+		return nil, err
+	}
+	r := &SignatureRequest{}
+	for i := 0; i < n; i++ {
+		key, err := d.Uint()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case 1:
+			r.ID, err = d.Text()
+		case 2:
+			var b []byte
+			if b, err = d.ByteString(); err == nil {
+				r.Message = append([]byte{}, b...)
+			}
+		case 3:
+			r.Requester, err = d.Text()
+		case 4:
+			r.Purpose, err = d.Text()
+		case 5:
+			var t string
+			if t, err = d.Text(); err == nil {
+				r.Expiry, err = time.Parse(time.RFC3339Nano, t)
+			}
+		case 6:
+			r.Format, err = d.Text()
+		case 7:
+			r.Chain, err = d.Text()
+		case 8:
+			var m int
+			if m, err = d.Map(); err != nil {
+				return nil, err
+			}
+			r.Metadata = make(map[string]string, m)
+			for j := 0; j < m; j++ {
+				k, err := d.Text()
+				if err != nil {
+					return nil, err
+				}
+				if r.Metadata[k], err = d.Text(); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			err = d.Skip()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,308 @@
+// Package cbor implements the subset of CBOR (RFC 8949) used to encode the
+// messages and states of the frost package: unsigned integers, byte and text
+// strings, arrays, maps and booleans.
+//
+// Encodings are deterministic as in section 4.2 of the RFC: lengths are
+// definite and all integers use their shortest form. The Decoder rejects
+// anything else, so that every value has exactly one encoding. Map keys are
+// written in the order the caller chooses, which should be ascending.
+package cbor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Major types of RFC 8949.
+const (
+	majorUint   = 0
+	majorNegint = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+)
+
+const (
+	simpleFalse = 20
+	simpleTrue  = 21
+)
+
+// maxDepth bounds the nesting of values skipped by the Decoder.
+const maxDepth = 16
+
+// ErrInvalid is returned for data that is not a deterministic encoding of the expected value.
+var ErrInvalid = errors.New("cbor: invalid encoding")
+
+// Encoder appends CBOR values to a buffer.
+type Encoder struct {
+	buf []byte
+}
+
+// NewEncoder returns an Encoder appending to buf, which may be nil.
+func NewEncoder(buf []byte) *Encoder {
+	return &Encoder{buf: buf}
+}
+
+// Bytes returns the encoded values.
+func (e *Encoder) Bytes() []byte {
+	return e.buf
+}
+
+func (e *Encoder) head(major byte, v uint64) {
+	switch {
+	case v < 24:
+		e.buf = append(e.buf, major<<5|byte(v))
+	case v <= 0xff:
+		e.buf = append(e.buf, major<<5|24, byte(v))
+	case v <= 0xffff:
+		e.buf = append(e.buf, major<<5|25)
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(v))
+	case v <= 0xffffffff:
+		e.buf = append(e.buf, major<<5|26)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(v))
+	default:
+		e.buf = append(e.buf, major<<5|27)
+		e.buf = binary.BigEndian.AppendUint64(e.buf, v)
+	}
+}
+
+// Uint appends an unsigned integer.
+func (e *Encoder) Uint(v uint64) {
+	e.head(majorUint, v)
+}
+
+// ByteString appends a byte string.
+func (e *Encoder) ByteString(b []byte) {
+	e.head(majorBytes, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// Text appends a text string.
+func (e *Encoder) Text(s string) {
+	e.head(majorText, uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// Bool appends true or false.
+func (e *Encoder) Bool(b bool) {
+	if b {
+		e.buf = append(e.buf, majorSimple<<5|simpleTrue)
+	} else {
+		e.buf = append(e.buf, majorSimple<<5|simpleFalse)
+	}
+}
+
+// Array appends the header of an array of n values, which must follow.
+func (e *Encoder) Array(n int) {
+	e.head(majorArray, uint64(n))
+}
+
+// Map appends the header of a map of n pairs, whose keys and values must follow.
+func (e *Encoder) Map(n int) {
+	e.head(majorMap, uint64(n))
+}
+
+// Decoder reads CBOR values from a buffer.
+type Decoder struct {
+	data []byte
+	off  int
+}
+
+// NewDecoder returns a Decoder reading data.
+func NewDecoder(data []byte) *Decoder {
+	return &Decoder{data: data}
+}
+
+// Done returns an error if data remains after the values read so far.
+func (d *Decoder) Done() error {
+	if d.off != len(d.data) {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalid, len(d.data)-d.off)
+	}
+	return nil
+}
+
+// head reads the major type and argument of the next value.
+func (d *Decoder) head() (byte, uint64, error) {
+	if d.off >= len(d.data) {
+		return 0, 0, fmt.Errorf("%w: unexpected end of data", ErrInvalid)
+	}
+	initial := d.data[d.off]
+	major, info := initial>>5, initial&0x1f
+	d.off++
+
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		// reserved values and indefinite lengths
+		return 0, 0, fmt.Errorf("%w: unsupported additional information %d", ErrInvalid, info)
+	}
+	if len(d.data)-d.off < size {
+		return 0, 0, fmt.Errorf("%w: unexpected end of data", ErrInvalid)
+	}
+	var v uint64
+	for _, b := range d.data[d.off : d.off+size] {
+		v = v<<8 | uint64(b)
+	}
+	d.off += size
+
+	if major == majorSimple {
+		// floats are not used, and simple values must use the short form
+		return 0, 0, fmt.Errorf("%w: unsupported simple value", ErrInvalid)
+	}
+	if (size == 1 && v < 24) || (size > 1 && v>>(4*size) == 0) {
+		return 0, 0, fmt.Errorf("%w: integer not in its shortest form", ErrInvalid)
+	}
+	return major, v, nil
+}
+
+func (d *Decoder) expect(major byte) (uint64, error) {
+	m, v, err := d.head()
+	if err != nil {
+		return 0, err
+	}
+	if m != major {
+		return 0, fmt.Errorf("%w: major type %d, expected %d", ErrInvalid, m, major)
+	}
+	return v, nil
+}
+
+// Uint reads an unsigned integer.
+func (d *Decoder) Uint() (uint64, error) {
+	return d.expect(majorUint)
+}
+
+// length reads the header of a string, array or map whose n elements take at least n bytes.
+func (d *Decoder) length(major byte) (int, error) {
+	n, err := d.expect(major)
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(len(d.data)-d.off) {
+		return 0, fmt.Errorf("%w: length %d exceeds the data", ErrInvalid, n)
+	}
+	return int(n), nil
+}
+
+// ByteString reads a byte string. The result aliases the data of the Decoder.
+func (d *Decoder) ByteString() ([]byte, error) {
+	n, err := d.length(majorBytes)
+	if err != nil {
+		return nil, err
+	}
+	b := d.data[d.off : d.off+n : d.off+n]
+	d.off += n
+	return b, nil
+}
+
+// Text reads a text string, which must be valid UTF-8.
+func (d *Decoder) Text() (string, error) {
+	n, err := d.length(majorText)
+	if err != nil {
+		return "", err
+	}
+	s := string(d.data[d.off : d.off+n])
+	d.off += n
+	if !utf8.ValidString(s) {
+		return "", fmt.Errorf("%w: text is not valid UTF-8", ErrInvalid)
+	}
+	return s, nil
+}
+
+// Bool reads true or false.
+func (d *Decoder) Bool() (bool, error) {
+	if d.off >= len(d.data) {
+		return false, fmt.Errorf("%w: unexpected end of data", ErrInvalid)
+	}
+	switch d.data[d.off] {
+	case majorSimple<<5 | simpleFalse:
+		d.off++
+		return false, nil
+	case majorSimple<<5 | simpleTrue:
+		d.off++
+		return true, nil
+	}
+	return false, fmt.Errorf("%w: expected a boolean", ErrInvalid)
+}
+
+// Array reads the header of an array and returns its length.
+func (d *Decoder) Array() (int, error) {
+	return d.length(majorArray)
+}
+
+// Map reads the header of a map and returns its number of pairs.
+func (d *Decoder) Map() (int, error) {
+	n, err := d.length(majorMap)
+	if err != nil {
+		return 0, err
+	}
+	if 2*n > len(d.data)-d.off {
+		return 0, fmt.Errorf("%w: length %d exceeds the data", ErrInvalid, n)
+	}
+	return n, nil
+}
+
+// Skip skips the next value, e.g. the value of an unknown map key.
+func (d *Decoder) Skip() error {
+	return d.skip(0)
+}
+
+func (d *Decoder) skip(depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("%w: nested too deeply", ErrInvalid)
+	}
+	if d.off < len(d.data) {
+		if b := d.data[d.off]; b == majorSimple<<5|simpleFalse || b == majorSimple<<5|simpleTrue {
+			d.off++
+			return nil
+		}
+	}
+	start := d.off
+	major, v, err := d.head()
+	if err != nil {
+		return err
+	}
+	switch major {
+	case majorUint, majorNegint:
+		return nil
+	case majorBytes, majorText:
+		d.off = start
+		_, err := d.length(major)
+		if err != nil {
+			return err
+		}
+		d.off += int(v)
+		return nil
+	case majorArray, majorMap:
+		n := v
+		if major == majorMap {
+			n *= 2
+		}
+		if n > uint64(len(d.data)-d.off) {
+			return fmt.Errorf("%w: length %d exceeds the data", ErrInvalid, v)
+		}
+		for i := uint64(0); i < n; i++ {
+			if err := d.skip(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case majorTag:
+		return d.skip(depth + 1)
+	}
+	return fmt.Errorf("%w: unsupported major type %d", ErrInvalid, major)
+}
@@ -0,0 +1,119 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoder(t *testing.T) {
+	// examples from appendix A of RFC 8949
+	for _, tc := range []struct {
+		encode func(e *Encoder)
+		hex    string
+	}{
+		{func(e *Encoder) { e.Uint(0) }, "00"},
+		{func(e *Encoder) { e.Uint(23) }, "17"},
+		{func(e *Encoder) { e.Uint(24) }, "1818"},
+		{func(e *Encoder) { e.Uint(1000) }, "1903e8"},
+		{func(e *Encoder) { e.Uint(1000000) }, "1a000f4240"},
+		{func(e *Encoder) { e.Uint(1000000000000) }, "1b000000e8d4a51000"},
+		{func(e *Encoder) { e.ByteString([]byte{1, 2, 3, 4}) }, "4401020304"},
+		{func(e *Encoder) { e.Text("IETF") }, "6449455446"},
+		{func(e *Encoder) { e.Bool(false) }, "f4"},
+		{func(e *Encoder) { e.Bool(true) }, "f5"},
+		{func(e *Encoder) { e.Array(2); e.Uint(1); e.Array(0) }, "820180"},
+		{func(e *Encoder) { e.Map(2); e.Uint(1); e.Uint(2); e.Uint(3); e.Uint(4) }, "a201020304"},
+	} {
+		e := NewEncoder(nil)
+		tc.encode(e)
+		assert.Equal(t, tc.hex, hex.EncodeToString(e.Bytes()))
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	e := NewEncoder(nil)
+	e.Map(3)
+	e.Uint(1)
+	e.ByteString([]byte("bytes"))
+	e.Uint(2)
+	e.Array(2)
+	e.Text("text")
+	e.Bool(true)
+	e.Uint(70000)
+	e.Uint(1 << 40)
+
+	d := NewDecoder(e.Bytes())
+	n, err := d.Map()
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	key, err := d.Uint()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), key)
+	b, err := d.ByteString()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("bytes"), b)
+	_, err = d.Uint()
+	require.NoError(t, err)
+	n, err = d.Array()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	s, err := d.Text()
+	require.NoError(t, err)
+	assert.Equal(t, "text", s)
+	v, err := d.Bool()
+	require.NoError(t, err)
+	assert.True(t, v)
+	// skip the last pair
+	require.NoError(t, d.Skip())
+	require.NoError(t, d.Skip())
+	require.NoError(t, d.Done())
+
+	// skip nested values at once
+	d = NewDecoder(e.Bytes())
+	require.NoError(t, d.Skip())
+	require.NoError(t, d.Done())
+}
+
+func TestDecoder_Invalid(t *testing.T) {
+	for _, h := range []string{
+		"",
+		"1817",               // not the shortest form
+		"190017",             // not the shortest form
+		"1a0000ffff",         // not the shortest form
+		"1b00000000ffffffff", // not the shortest form
+		"5f",                 // indefinite length
+		"1c",                 // reserved
+		"4501020304",         // too short
+		"f6",                 // null
+		"fb3ff199999999999a", // float
+		"9b000000000000000100",
+	} {
+		data, err := hex.DecodeString(h)
+		require.NoError(t, err)
+		err = NewDecoder(data).Skip()
+		assert.True(t, errors.Is(err, ErrInvalid), h)
+	}
+
+	data, _ := hex.DecodeString("62c328")
+	_, err := NewDecoder(data).Text()
+	assert.Error(t, err, "invalid UTF-8")
+
+	_, err = NewDecoder([]byte{0x01}).ByteString()
+	assert.Error(t, err, "wrong major type")
+
+	d := NewDecoder([]byte{0x01, 0x02})
+	_, err = d.Uint()
+	require.NoError(t, err)
+	assert.Error(t, d.Done())
+
+	// deeply nested arrays
+	nested := make([]byte, 100)
+	for i := range nested {
+		nested[i] = 0x81
+	}
+	assert.Error(t, NewDecoder(append(nested, 0)).Skip())
+}
@@ -0,0 +1,118 @@
+package frost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage_CBOR(t *testing.T) {
+	for _, msg := range testMessages(t) {
+		data, err := msg.MarshalCBOR()
+		require.NoError(t, err, msg.Type)
+		var decoded Message
+		require.NoError(t, decoded.UnmarshalCBOR(data), msg.Type)
+		assert.Equal(t, msg.Header, decoded.Header)
+		assert.True(t, equalPayloads(msg, &decoded), msg.Type)
+
+		// the encoding is deterministic, and smaller than JSON
+		again, err := decoded.MarshalCBOR()
+		require.NoError(t, err)
+		assert.Equal(t, data, again)
+		jsonData, err := msg.MarshalJSON()
+		require.NoError(t, err)
+		assert.Less(t, len(data), len(jsonData))
+
+		assert.Error(t, decoded.UnmarshalCBOR(data[:len(data)-1]))
+		assert.Error(t, decoded.UnmarshalCBOR(append(data, 0)))
+	}
+
+	var decoded Message
+	// version 2, type Sign2, no payload
+	assert.Error(t, decoded.UnmarshalCBOR([]byte{0xa2, 0x00, 0x02, 0x01, 0x04}))
+	assert.Error(t, decoded.UnmarshalCBOR([]byte{0xa2, 0x00, 0x01, 0x01, 0x04}))
+	// keys out of order
+	assert.Error(t, decoded.UnmarshalCBOR([]byte{0xa3, 0x00, 0x01, 0x02, 0x01, 0x01, 0x04}))
+}
+
+func TestSignerState_CBOR(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	signers := party.IDSlice{1, 3}
+	req := &SignatureRequest{
+		ID:       "req-1",
+		Message:  []byte("cbor"),
+		Purpose:  "test",
+		Expiry:   time.Now().Add(time.Hour),
+		Metadata: map[string]string{"b": "2", "a": "1"},
+	}
+
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInitWithRequest(signers, secrets[id], public, req)
+		require.NoError(t, err)
+		state.RFC9591 = id == 3
+
+		data, err := state.MarshalCBOR()
+		require.NoError(t, err)
+		var decoded SignerState
+		require.NoError(t, decoded.UnmarshalCBOR(data))
+		again, err := decoded.MarshalCBOR()
+		require.NoError(t, err)
+		assert.Equal(t, data, again)
+		assert.Equal(t, req.Digest(), decoded.Request.Digest())
+		assert.Equal(t, state.RFC9591, decoded.RFC9591)
+
+		decoded.RFC9591 = false
+		states[id] = &decoded
+		round1 = append(round1, msg)
+	}
+
+	sigs, err := runSignRounds(states, round1)
+	require.NoError(t, err)
+	for _, sig := range sigs {
+		assert.True(t, public.GroupKey.Verify(req.Message, sig))
+	}
+}
+
+func TestKeygenState_CBOR(t *testing.T) {
+	const n, threshold = 3, 1
+	states := make(map[party.ID]*KeygenState)
+	var round1 []*Message
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, err := KeygenInit(id, n, threshold)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+
+	round2 := make(map[party.ID][]*Message)
+	for id, state := range states {
+		msgs, _, err := KeygenRound1(state, round1)
+		require.NoError(t, err)
+		for _, msg := range msgs {
+			round2[msg.To] = append(round2[msg.To], msg)
+		}
+
+		data, err := state.MarshalCBOR()
+		require.NoError(t, err)
+		var decoded KeygenState
+		require.NoError(t, decoded.UnmarshalCBOR(data))
+		assert.Len(t, decoded.Commitments, n-1)
+		states[id] = &decoded
+	}
+
+	var public []byte
+	for id, state := range states {
+		pub, sec, err := KeygenRound2(state, round2[id])
+		require.NoError(t, err)
+		assert.True(t, pub.Shares[id].Equal(&sec.Public) == 1)
+		if public != nil {
+			assert.Equal(t, public, []byte(pub.GroupKey.ToEd25519()))
+		}
+		public = pub.GroupKey.ToEd25519()
+	}
+}
@@ -0,0 +1,147 @@
+// Package ciphersuite implements FROST over any group of package curve, with
+// the ciphersuites of RFC 9591:
+//
+//	FROST(ristretto255, SHA-512)  Ristretto255SHA512
+//	FROST(secp256k1, SHA-256)     Secp256k1SHA256
+//	FROST(P-256, SHA-256)         P256SHA256
+//
+// The rounds of the key generation and signing mirror those of package frost:
+// KeygenInit, KeygenRound1 and KeygenRound2 run a distributed key generation
+// with verifiable secret sharing, and SignInit, SignRound1 and SignRound2 run
+// the two rounds of signing, in which the binding factors, the group
+// commitment and the challenge are computed as in RFC 9591. Package frost
+// stays the implementation of choice for ed25519 signatures; this package is
+// for the groups it does not support. Its messages are plain Go values, which
+// the caller delivers between the parties.
+//
+// See package curve on which of the groups are constant time.
+package ciphersuite
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+
+	"github.com/bartke/frost/curve"
+)
+
+// Ciphersuite is a prime order group with the hash functions of RFC 9591,
+// Section 6. Every hash is domain separated by the context string of the
+// suite and a tag.
+type Ciphersuite interface {
+	// Group returns the group of the suite.
+	Group() curve.Group
+	// ContextString returns the context string, e.g. "FROST-P256-SHA256-v1".
+	ContextString() string
+
+	// H1 hashes to the binding factors.
+	H1(m []byte) curve.Scalar
+	// H2 hashes to the challenge.
+	H2(m []byte) curve.Scalar
+	// H3 hashes to the nonces.
+	H3(m []byte) curve.Scalar
+	// H4 hashes the message.
+	H4(m []byte) []byte
+	// H5 hashes the encoded commitment list.
+	H5(m []byte) []byte
+	// HDKG hashes to the challenge of the proofs of knowledge of the key
+	// generation, which RFC 9591 leaves to the implementation.
+	HDKG(m []byte) curve.Scalar
+}
+
+type suite struct {
+	group   curve.Group
+	context string
+	newHash func() hash.Hash
+	// xmd is set for the suites that hash to scalars with hash_to_field of
+	// RFC 9380, rather than by reducing a wide hash.
+	xmd bool
+}
+
+// Ristretto255SHA512 returns FROST(ristretto255, SHA-512).
+func Ristretto255SHA512() Ciphersuite {
+	return &suite{group: curve.Ristretto255(), context: "FROST-RISTRETTO255-SHA512-v1", newHash: sha512.New}
+}
+
+// Secp256k1SHA256 returns FROST(secp256k1, SHA-256).
+func Secp256k1SHA256() Ciphersuite {
+	return &suite{group: curve.Secp256k1(), context: "FROST-secp256k1-SHA256-v1", newHash: sha256.New, xmd: true}
+}
+
+// P256SHA256 returns FROST(P-256, SHA-256).
+func P256SHA256() Ciphersuite {
+	return &suite{group: curve.P256(), context: "FROST-P256-SHA256-v1", newHash: sha256.New, xmd: true}
+}
+
+func (s *suite) Group() curve.Group    { return s.group }
+func (s *suite) ContextString() string { return s.context }
+
+func (s *suite) H1(m []byte) curve.Scalar   { return s.hashToScalar("rho", m) }
+func (s *suite) H2(m []byte) curve.Scalar   { return s.hashToScalar("chal", m) }
+func (s *suite) H3(m []byte) curve.Scalar   { return s.hashToScalar("nonce", m) }
+func (s *suite) H4(m []byte) []byte         { return s.hash("msg", m) }
+func (s *suite) H5(m []byte) []byte         { return s.hash("com", m) }
+func (s *suite) HDKG(m []byte) curve.Scalar { return s.hashToScalar("dkg", m) }
+
+// hash returns H(contextString ∥ tag ∥ m).
+func (s *suite) hash(tag string, m []byte) []byte {
+	h := s.newHash()
+	h.Write([]byte(s.context))
+	h.Write([]byte(tag))
+	h.Write(m)
+	return h.Sum(nil)
+}
+
+func (s *suite) hashToScalar(tag string, m []byte) curve.Scalar {
+	var wide []byte
+	if s.xmd {
+		// hash_to_field with L = 48 for a 128 bit security level
+		wide = expandMessageXMD(s.newHash, m, []byte(s.context+tag), 48)
+	} else {
+		wide = s.hash(tag, m)
+	}
+	scalar, err := s.group.NewScalar().SetWideBytes(wide)
+	if err != nil {
+		panic(err)
+	}
+	return scalar
+}
+
+// expandMessageXMD is expand_message_xmd of RFC 9380, Section 5.3.1.
+func expandMessageXMD(newHash func() hash.Hash, msg, dst []byte, length int) []byte {
+	h := newHash()
+	size, block := h.Size(), h.BlockSize()
+	ell := (length + size - 1) / size
+	if ell > 255 || length > 65535 || len(dst) > 255 {
+		panic("ciphersuite: invalid expand_message_xmd parameters")
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	h.Write(make([]byte, block))
+	h.Write(msg)
+	h.Write([]byte{byte(length >> 8), byte(length), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	h.Reset()
+	h.Write(b0)
+	h.Write([]byte{1})
+	h.Write(dstPrime)
+	bi := h.Sum(nil)
+
+	out := make([]byte, 0, ell*size)
+	out = append(out, bi...)
+	for i := 2; i <= ell; i++ {
+		xored := make([]byte, size)
+		for j := range xored {
+			xored[j] = b0[j] ^ bi[j]
+		}
+		h.Reset()
+		h.Write(xored)
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		bi = h.Sum(nil)
+		out = append(out, bi...)
+	}
+	return out[:length]
+}
@@ -0,0 +1,153 @@
+package ciphersuite
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var suites = []Ciphersuite{Ristretto255SHA512(), Secp256k1SHA256(), P256SHA256()}
+
+// generateKeys runs the key generation of n parties with threshold t.
+func generateKeys(t *testing.T, cs Ciphersuite, n, threshold party.Size) (*Public, map[party.ID]*SecretShare) {
+	states := make(map[party.ID]*KeygenState, n)
+	var round1 []*KeyGen1
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, err := KeygenInit(cs, id, n, threshold)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+
+	round2 := make(map[party.ID][]*KeyGen2, n)
+	for _, state := range states {
+		msgs, err := KeygenRound1(state, round1)
+		require.NoError(t, err)
+		for _, msg := range msgs {
+			round2[msg.To] = append(round2[msg.To], msg)
+		}
+	}
+
+	var public *Public
+	secrets := make(map[party.ID]*SecretShare, n)
+	for id, state := range states {
+		p, secret, err := KeygenRound2(state, round2[id])
+		require.NoError(t, err)
+		if public != nil {
+			require.True(t, public.GroupKey.Equal(p.GroupKey))
+		}
+		public = p
+		require.True(t, public.Shares[id].Equal(secret.Public))
+		secrets[id] = secret
+	}
+	return public, secrets
+}
+
+// sign runs both signing rounds with signerIDs.
+func sign(t *testing.T, cs Ciphersuite, public *Public, secrets map[party.ID]*SecretShare, signerIDs party.IDSlice, message []byte) (map[party.ID]*SignerState, []*Sign2) {
+	states := make(map[party.ID]*SignerState, len(signerIDs))
+	var round1 []*Sign1
+	for _, id := range signerIDs {
+		msg, state, err := SignInit(cs, signerIDs, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	var round2 []*Sign2
+	for _, id := range signerIDs {
+		msg, err := SignRound1(states[id], round1)
+		require.NoError(t, err)
+		round2 = append(round2, msg)
+	}
+	return states, round2
+}
+
+func TestSign(t *testing.T) {
+	message := []byte("hello")
+	for _, cs := range suites {
+		t.Run(cs.ContextString(), func(t *testing.T) {
+			public, secrets := generateKeys(t, cs, 5, 2)
+			for _, signerIDs := range []party.IDSlice{{1, 2, 3}, {5, 3, 1}, {1, 2, 3, 4, 5}} {
+				states, round2 := sign(t, cs, public, secrets, signerIDs, message)
+				for _, state := range states {
+					sig, err := SignRound2(state, round2)
+					require.NoError(t, err)
+					assert.True(t, Verify(cs, public.GroupKey, message, sig))
+					assert.False(t, Verify(cs, public.GroupKey, []byte("other"), sig))
+
+					decoded, err := ParseSignature(cs, sig.Bytes())
+					require.NoError(t, err)
+					assert.True(t, Verify(cs, public.GroupKey, message, decoded))
+				}
+			}
+
+			// a wrong share is detected
+			states, round2 := sign(t, cs, public, secrets, party.IDSlice{1, 2, 3}, message)
+			round2[1].Z.Add(round2[1].Z, cs.Group().NewScalar().SetUint64(1))
+			_, err := SignRound2(states[1], round2)
+			assert.Error(t, err)
+
+			// the nonces are used only once
+			_, err = SignRound1(states[1], nil)
+			assert.Error(t, err)
+
+			// too few signers
+			_, _, err = SignInit(cs, party.IDSlice{1, 2}, secrets[1], public, message)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestKeygen_InvalidShare(t *testing.T) {
+	for _, cs := range suites {
+		t.Run(cs.ContextString(), func(t *testing.T) {
+			const n = 3
+			states := make(map[party.ID]*KeygenState, n)
+			var round1 []*KeyGen1
+			for id := party.ID(1); id <= n; id++ {
+				msg, state, err := KeygenInit(cs, id, n, 1)
+				require.NoError(t, err)
+				states[id] = state
+				round1 = append(round1, msg)
+			}
+
+			// a proof for another party does not verify
+			forged := *round1[1]
+			forged.From = 3
+			_, err := KeygenRound1(states[1], []*KeyGen1{round1[0], &forged})
+			assert.Error(t, err)
+
+			var round2 []*KeyGen2
+			for id := party.ID(2); id <= n; id++ {
+				msgs, err := KeygenRound1(states[id], round1)
+				require.NoError(t, err)
+				for _, msg := range msgs {
+					if msg.To == 1 {
+						round2 = append(round2, msg)
+					}
+				}
+			}
+			_, err = KeygenRound1(states[1], round1)
+			require.NoError(t, err)
+
+			round2[0].Share.Add(round2[0].Share, cs.Group().NewScalar().SetUint64(1))
+			_, _, err = KeygenRound2(states[1], round2)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestExpandMessageXMD(t *testing.T) {
+	// RFC 9380, Appendix K.1
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	for msg, expected := range map[string]string{
+		"":    "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235",
+		"abc": "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615",
+	} {
+		assert.Equal(t, expected, hex.EncodeToString(expandMessageXMD(sha256.New, []byte(msg), dst, 32)), msg)
+	}
+}
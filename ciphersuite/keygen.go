@@ -0,0 +1,237 @@
+package ciphersuite
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/bartke/frost/curve"
+	"github.com/bartke/frost/party"
+)
+
+// Public is the public information of a group after the key generation.
+type Public struct {
+	PartyIDs  party.IDSlice
+	Threshold party.Size
+	// Shares are the public shares [sᵢ]B of the parties.
+	Shares   map[party.ID]curve.Point
+	GroupKey curve.Point
+}
+
+// SecretShare is the share of the group secret of one party.
+type SecretShare struct {
+	ID     party.ID
+	Secret curve.Scalar
+	Public curve.Point
+}
+
+// KeyGen1 is the broadcast of the first round of the key generation: the
+// commitments to the coefficients of the polynomial of the sender, and a
+// proof of knowledge of its constant term.
+type KeyGen1 struct {
+	From        party.ID
+	Commitments []curve.Point
+	ProofR      curve.Point
+	ProofZ      curve.Scalar
+}
+
+// KeyGen2 is the share of the sender's polynomial for the receiver.
+type KeyGen2 struct {
+	From, To party.ID
+	Share    curve.Scalar
+}
+
+// KeygenState is the state of one party during the key generation.
+type KeygenState struct {
+	Suite     Ciphersuite
+	SelfID    party.ID
+	PartyIDs  party.IDSlice
+	Threshold party.Size
+
+	coefficients []curve.Scalar
+	commitments  map[party.ID][]curve.Point
+	secret       curve.Scalar
+}
+
+// KeygenInit starts the key generation of party selfID in a group of n parties,
+// of which more than t are needed to sign.
+func KeygenInit(cs Ciphersuite, selfID party.ID, n, t party.Size) (*KeyGen1, *KeygenState, error) {
+	if t >= n {
+		return nil, nil, fmt.Errorf("KeygenInit: threshold %d must be less than the number of parties %d", t, n)
+	}
+	if selfID == 0 || selfID > n {
+		return nil, nil, fmt.Errorf("KeygenInit: party %d is not in 1..%d", selfID, n)
+	}
+	g := cs.Group()
+	partyIDs := make([]party.ID, 0, n)
+	for id := party.ID(1); id <= n; id++ {
+		partyIDs = append(partyIDs, id)
+	}
+	state := &KeygenState{
+		Suite:        cs,
+		SelfID:       selfID,
+		PartyIDs:     partyIDs,
+		Threshold:    t,
+		coefficients: make([]curve.Scalar, t+1),
+		commitments:  make(map[party.ID][]curve.Point, n),
+	}
+	commitments := make([]curve.Point, t+1)
+	for i := range state.coefficients {
+		a, err := g.RandomScalar(rand.Reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("KeygenInit: %w", err)
+		}
+		state.coefficients[i] = a
+		commitments[i] = g.NewPoint().ScalarBaseMult(a)
+	}
+	state.commitments[selfID] = commitments
+
+	k, err := g.RandomScalar(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("KeygenInit: %w", err)
+	}
+	R := g.NewPoint().ScalarBaseMult(k)
+	c := proofChallenge(cs, selfID, commitments[0], R)
+	z := g.NewScalar().Add(k, g.NewScalar().Multiply(state.coefficients[0], c))
+
+	msg := &KeyGen1{From: selfID, Commitments: commitments, ProofR: R, ProofZ: z}
+	return msg, state, nil
+}
+
+// proofChallenge returns HDKG(id ∥ φ ∥ R) for the proof of knowledge of the
+// secret of φ by party id.
+func proofChallenge(cs Ciphersuite, id party.ID, phi, R curve.Point) curve.Scalar {
+	data := identifier(cs.Group(), id).Bytes()
+	data = append(data, phi.Bytes()...)
+	data = append(data, R.Bytes()...)
+	return cs.HDKG(data)
+}
+
+// KeygenRound1 verifies the KeyGen1 messages of the other parties and returns
+// the shares for them.
+func KeygenRound1(state *KeygenState, inputMsgs []*KeyGen1) ([]*KeyGen2, error) {
+	g := state.Suite.Group()
+	for _, msg := range inputMsgs {
+		id := msg.From
+		if id == state.SelfID {
+			continue
+		}
+		if !state.PartyIDs.Contains(id) {
+			return nil, fmt.Errorf("KeygenRound1: message from unknown party %d", id)
+		}
+		if len(msg.Commitments) != int(state.Threshold)+1 {
+			return nil, fmt.Errorf("KeygenRound1: party %d committed to %d coefficients", id, len(msg.Commitments))
+		}
+		for _, C := range msg.Commitments {
+			if C == nil || C.IsIdentity() {
+				return nil, fmt.Errorf("KeygenRound1: invalid commitment of party %d", id)
+			}
+		}
+		if msg.ProofR == nil || msg.ProofZ == nil {
+			return nil, fmt.Errorf("KeygenRound1: missing proof of party %d", id)
+		}
+		// [z]B - [c]φ = R
+		c := proofChallenge(state.Suite, id, msg.Commitments[0], msg.ProofR)
+		R := g.NewPoint().Subtract(curve.PublicScalarBaseMult(g.NewPoint(), msg.ProofZ), curve.PublicScalarMult(g.NewPoint(), c, msg.Commitments[0]))
+		if !R.Equal(msg.ProofR) {
+			return nil, fmt.Errorf("KeygenRound1: proof of knowledge of party %d failed", id)
+		}
+		state.commitments[id] = msg.Commitments
+	}
+	if len(state.commitments) != len(state.PartyIDs) {
+		return nil, fmt.Errorf("KeygenRound1: got commitments of %d of %d parties", len(state.commitments), len(state.PartyIDs))
+	}
+
+	msgsOut := make([]*KeyGen2, 0, len(state.PartyIDs)-1)
+	for _, id := range state.PartyIDs {
+		if id == state.SelfID {
+			continue
+		}
+		share := evaluate(g, state.coefficients, identifier(g, id))
+		msgsOut = append(msgsOut, &KeyGen2{From: state.SelfID, To: id, Share: share})
+	}
+	state.secret = evaluate(g, state.coefficients, identifier(g, state.SelfID))
+	return msgsOut, nil
+}
+
+// KeygenRound2 verifies the shares of the other parties against their
+// commitments, and returns the public information of the group and the
+// secret share of the party.
+func KeygenRound2(state *KeygenState, inputMsgs []*KeyGen2) (*Public, *SecretShare, error) {
+	if state.secret == nil {
+		return nil, nil, errors.New("KeygenRound2: KeygenRound1 was not run")
+	}
+	g := state.Suite.Group()
+	self := identifier(g, state.SelfID)
+	received := make(map[party.ID]bool, len(inputMsgs))
+	for _, msg := range inputMsgs {
+		id := msg.From
+		if id == state.SelfID || msg.To != state.SelfID || received[id] {
+			return nil, nil, fmt.Errorf("KeygenRound2: unexpected share from party %d to party %d", id, msg.To)
+		}
+		commitments, ok := state.commitments[id]
+		if !ok {
+			return nil, nil, fmt.Errorf("KeygenRound2: missing commitment for party %d", id)
+		}
+		if msg.Share == nil || !g.NewPoint().ScalarBaseMult(msg.Share).Equal(evaluateCommitments(g, commitments, self)) {
+			return nil, nil, fmt.Errorf("KeygenRound2: VSS validation of the share of party %d failed", id)
+		}
+		received[id] = true
+		state.secret.Add(state.secret, msg.Share)
+	}
+	if len(received) != len(state.PartyIDs)-1 {
+		return nil, nil, fmt.Errorf("KeygenRound2: got shares of %d of %d parties", len(received), len(state.PartyIDs)-1)
+	}
+
+	sum := make([]curve.Point, state.Threshold+1)
+	for i := range sum {
+		sum[i] = g.NewPoint()
+		for _, commitments := range state.commitments {
+			sum[i].Add(sum[i], commitments[i])
+		}
+	}
+	public := &Public{
+		PartyIDs:  state.PartyIDs,
+		Threshold: state.Threshold,
+		Shares:    make(map[party.ID]curve.Point, len(state.PartyIDs)),
+		GroupKey:  sum[0],
+	}
+	for _, id := range state.PartyIDs {
+		public.Shares[id] = evaluateCommitments(g, sum, identifier(g, id))
+	}
+	secret := &SecretShare{
+		ID:     state.SelfID,
+		Secret: state.secret,
+		Public: g.NewPoint().ScalarBaseMult(state.secret),
+	}
+	for i := range state.coefficients {
+		state.coefficients[i] = g.NewScalar()
+	}
+	return public, secret, nil
+}
+
+// identifier returns the scalar of party id.
+func identifier(g curve.Group, id party.ID) curve.Scalar {
+	return g.NewScalar().SetUint64(uint64(id))
+}
+
+// evaluate returns f(x) for the polynomial with the given coefficients, by Horner's rule.
+func evaluate(g curve.Group, coefficients []curve.Scalar, x curve.Scalar) curve.Scalar {
+	result := g.NewScalar()
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result.Multiply(result, x)
+		result.Add(result, coefficients[i])
+	}
+	return result
+}
+
+// evaluateCommitments returns [f(x)]B for the polynomial f with the given
+// commitments to its coefficients.
+func evaluateCommitments(g curve.Group, commitments []curve.Point, x curve.Scalar) curve.Point {
+	result := g.NewPoint()
+	for i := len(commitments) - 1; i >= 0; i-- {
+		curve.PublicScalarMult(result, x, result)
+		result.Add(result, commitments[i])
+	}
+	return result
+}
@@ -0,0 +1,181 @@
+package ciphersuite
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/bartke/frost/curve"
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc9591Participant is a signer of a test vector of RFC 9591.
+type rfc9591Participant struct {
+	hidingRandomness, bindingRandomness string
+	hidingNonce, bindingNonce           string
+	hidingCommitment, bindingCommitment string
+	bindingFactor                       string
+	sigShare                            string
+}
+
+// rfc9591Vector is a test vector of RFC 9591, Appendix E, of a group of three
+// parties with MIN_PARTICIPANTS = 2, in which participants 1 and 3 sign "test".
+type rfc9591Vector struct {
+	suite        Ciphersuite
+	groupSecret  string
+	groupKey     string
+	coefficient  string
+	shares       map[party.ID]string
+	participants map[party.ID]rfc9591Participant
+	// signature is empty if the vector stops after the first round.
+	signature string
+}
+
+var rfc9591Vectors = []rfc9591Vector{
+	{
+		// Appendix E.4, FROST(P-256, SHA-256)
+		suite:       P256SHA256(),
+		groupSecret: "8ba9bba2e0fd8c4767154d35a0b7562244a4aaf6f36c8fb8735fa48b301bd8de",
+		groupKey:    "023a309ad94e9fe8a7ba45dfc58f38bf091959d3c99cfbd02b4dc00585ec45ab70",
+		coefficient: "80f25e6c0709353e46bfbe882a11bdbb1f8097e46340eb8673b7e14556e6c3a4",
+		shares: map[party.ID]string{
+			1: "0c9c1a0fe806c184add50bbdcac913dda73e482daf95dcb9f35dbb0d8a9f7731",
+			2: "8d8e787bef0ff6c2f494ca45f4dad198c6bee01212d6c84067159c52e1863ad5",
+			3: "0e80d6e8f6192c003b5488ce1eec8f5429587d48cf001541e713b2d53c09d928",
+		},
+		participants: map[party.ID]rfc9591Participant{
+			1: {
+				hidingRandomness:  "ec4c891c85fee802a9d757a67d1252e7f4e5efb8a538991ac18fbd0e06fb6fd3",
+				bindingRandomness: "9334e29d09061223f69a09421715a347e4e6deba77444c8f42b0c833f80f4ef9",
+				hidingNonce:       "9f0542a5ba879a58f255c09f06da7102ef6a2dec6279700c656d58394d8facd4",
+				bindingNonce:      "6513dfe7429aa2fc972c69bb495b27118c45bbc6e654bb9dc9be55385b55c0d7",
+				hidingCommitment:  "0213b3e6298bf8ad46fd5e9389519a8665d63d98f4ec6a1fcca434e809d2d8070e",
+				bindingCommitment: "02188ff1390bf69374d7b272e454b1878ef10a6b6ea3ff36f114b300b4dbd5233b",
+				bindingFactor:     "7925f0d4693f204e6e59233e92227c7124664a99739d2c06b81cf64ddf90559e",
+				sigShare:          "400308eaed7a2ddee02a265abe6a1cfe04d946ee8720768899619cfabe7a3aeb",
+			},
+			3: {
+				hidingRandomness:  "c0451c5a0a5480d6c1f860e5db7d655233dca2669fd90ff048454b8ce983367b",
+				bindingRandomness: "2ba5f7793ae700e40e78937a82f407dd35e847e33d1e607b5c7eb6ed2a8ed799",
+				hidingNonce:       "f73444a8972bcda9e506bbca3d2b1c083c10facdf4bb5d47fef7c2dc1d9f2a0d",
+				bindingNonce:      "44c6a29075d6e7e4f8b97796205f9e22062e7835141470afe9417fd317c1c303",
+				hidingCommitment:  "033ac9a5fe4a8b57316ba1c34e8a6de453033b750e8984924a984eb67a11e73a3f",
+				bindingCommitment: "03a7a2480ee16199262e648aea3acab628a53e9b8c1945078f2ddfbdc98b7df369",
+				bindingFactor:     "e10d24a8a403723bcb6f9bb4c537f316593683b472f7a89f166630dde11822c4",
+				sigShare:          "561da3c179edbb0502d941bb3e3ace3c37d122aaa46fb54499f15f3a3331de44",
+			},
+		},
+		signature: "026d8d434874f87bdb7bc0dfd239b2c00639044f9dcb195e9a04426f70bfa4b70d" +
+			"9620acac6767e8e3e3036815fca4eb3a3caa69992b902bcd3352fc34f1ac192f",
+	},
+	{
+		// Appendix E.5, FROST(secp256k1, SHA-256), of which only the key
+		// generation and the first round of participant 1 are checked
+		suite:       Secp256k1SHA256(),
+		groupSecret: "0d004150d27c3bf2a42f312683d35fac7394b1e9e318249c1bfe7f0795a83114",
+		groupKey:    "02f37c34b66ced1fb51c34a90bdae006901f10625cc06c4f64663b0eae87d87b4f",
+		coefficient: "fbf85eadae3058ea14f19148bb72b45e4399c0b16028acaf0395c9b03c823579",
+		shares: map[party.ID]string{
+			1: "08f89ffe80ac94dcb920c26f3f46140bfc7f95b493f8310f5fc1ea2b01f4254c",
+			2: "04f0feac2edcedc6ce1253b7fab8c86b856a797f44d83d82a385554e6e401984",
+			3: "00e95d59dd0d46b0e303e500b62b7ccb0e555d49f5b849f5e748c071da8c0dbc",
+		},
+		participants: map[party.ID]rfc9591Participant{
+			1: {
+				hidingRandomness:  "7ea5ed09af19f6ff21040c07ec2d2adbd35b759da5a401d4c99dd26b82391cb2",
+				bindingRandomness: "47acab018f116020c10cb9b9abdc7ac10aae1b48ca6e36dc15acb6ec9be5cdc5",
+				hidingNonce:       "841d3a6450d7580b4da83c8e618414d0f024391f2aeb511d7579224420aa81f0",
+				bindingNonce:      "8d2624f532af631377f33cf44b5ac5f849067cae2eacb88680a31e77c79b5a80",
+				hidingCommitment:  "03c699af97d26bb4d3f05232ec5e1938c12f1e6ae97643c8f8f11c9820303f1904",
+				bindingCommitment: "02fa2aaccd51b948c9dc1a325d77226e98a5a3fe65fe9ba213761a60123040a45e",
+			},
+		},
+	},
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func hexScalar(t *testing.T, g curve.Group, s string) curve.Scalar {
+	x, err := g.NewScalar().SetCanonicalBytes(decodeHex(t, s))
+	require.NoError(t, err)
+	return x
+}
+
+// TestRFC9591Vectors signs with the shares and the nonce randomness of the
+// test vectors, and checks every intermediate value and the signature.
+func TestRFC9591Vectors(t *testing.T) {
+	message := []byte("test")
+	signers := party.IDSlice{1, 3}
+	for _, v := range rfc9591Vectors {
+		t.Run(v.suite.ContextString(), func(t *testing.T) {
+			cs := v.suite
+			g := cs.Group()
+
+			// the shares are those of the polynomial of the group secret
+			groupSecret := hexScalar(t, g, v.groupSecret)
+			coefficients := []curve.Scalar{groupSecret, hexScalar(t, g, v.coefficient)}
+			public := &Public{
+				PartyIDs:  party.IDSlice{1, 2, 3},
+				Threshold: 1,
+				Shares:    make(map[party.ID]curve.Point),
+				GroupKey:  g.NewPoint().ScalarBaseMult(groupSecret),
+			}
+			assert.Equal(t, v.groupKey, hex.EncodeToString(public.GroupKey.Bytes()))
+			secrets := make(map[party.ID]*SecretShare)
+			for id, s := range v.shares {
+				share := hexScalar(t, g, s)
+				assert.True(t, evaluate(g, coefficients, identifier(g, id)).Equal(share), "share of %d", id)
+				public.Shares[id] = g.NewPoint().ScalarBaseMult(share)
+				secrets[id] = &SecretShare{ID: id, Secret: share, Public: public.Shares[id]}
+			}
+
+			states := make(map[party.ID]*SignerState)
+			var round1 []*Sign1
+			for _, id := range signers {
+				p, ok := v.participants[id]
+				if !ok {
+					continue
+				}
+				rng := bytes.NewReader(append(decodeHex(t, p.hidingRandomness), decodeHex(t, p.bindingRandomness)...))
+				msg, state, err := signInit(cs, signers, secrets[id], public, message, rng)
+				require.NoError(t, err)
+				assert.Equal(t, p.hidingNonce, hex.EncodeToString(state.d.Bytes()))
+				assert.Equal(t, p.bindingNonce, hex.EncodeToString(state.e.Bytes()))
+				assert.Equal(t, p.hidingCommitment, hex.EncodeToString(msg.D.Bytes()))
+				assert.Equal(t, p.bindingCommitment, hex.EncodeToString(msg.E.Bytes()))
+				states[id] = state
+				round1 = append(round1, msg)
+			}
+
+			if v.signature == "" {
+				return
+			}
+
+			var round2 []*Sign2
+			for _, id := range signers {
+				msg, err := SignRound1(states[id], round1)
+				require.NoError(t, err)
+				for _, j := range signers {
+					assert.Equal(t, v.participants[j].bindingFactor, hex.EncodeToString(states[id].factors[j].Bytes()))
+				}
+				assert.Equal(t, v.participants[id].sigShare, hex.EncodeToString(msg.Z.Bytes()))
+				round2 = append(round2, msg)
+			}
+
+			for _, id := range signers {
+				sig, err := SignRound2(states[id], round2)
+				require.NoError(t, err)
+				assert.Equal(t, v.signature, hex.EncodeToString(sig.Bytes()))
+			}
+			sig, err := ParseSignature(cs, decodeHex(t, v.signature))
+			require.NoError(t, err)
+			assert.True(t, Verify(cs, public.GroupKey, message, sig))
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package ciphersuite
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bartke/frost/curve"
+	"github.com/bartke/frost/party"
+)
+
+// Sign1 is the broadcast of the first round of signing: the commitments to the
+// nonces of the sender.
+type Sign1 struct {
+	From party.ID
+	D, E curve.Point
+}
+
+// Sign2 is the signature share of the sender.
+type Sign2 struct {
+	From party.ID
+	Z    curve.Scalar
+}
+
+// Signature is a Schnorr signature (R, z) as in RFC 9591.
+type Signature struct {
+	R curve.Point
+	Z curve.Scalar
+}
+
+// SignerState is the state of one signer during signing.
+type SignerState struct {
+	Suite     Ciphersuite
+	SelfID    party.ID
+	SignerIDs party.IDSlice
+	Public    *Public
+	Message   []byte
+
+	secret      curve.Scalar
+	d, e        curve.Scalar
+	commitments map[party.ID]*Sign1
+	factors     map[party.ID]curve.Scalar
+	r           curve.Point
+	c           curve.Scalar
+}
+
+// SignInit starts signing message by the party of secret, together with the
+// other signerIDs, and returns the commitments to its nonces.
+func SignInit(cs Ciphersuite, signerIDs party.IDSlice, secret *SecretShare, public *Public, message []byte) (*Sign1, *SignerState, error) {
+	return signInit(cs, signerIDs, secret, public, message, rand.Reader)
+}
+
+// signInit is SignInit with the randomness of the nonces read from rng.
+func signInit(cs Ciphersuite, signerIDs party.IDSlice, secret *SecretShare, public *Public, message []byte, rng io.Reader) (*Sign1, *SignerState, error) {
+	signerIDs = party.NewIDSlice(signerIDs)
+	if len(signerIDs) <= int(public.Threshold) {
+		return nil, nil, fmt.Errorf("SignInit: %d signers for a threshold of %d", len(signerIDs), public.Threshold)
+	}
+	for i, id := range signerIDs {
+		if i > 0 && signerIDs[i-1] == id {
+			return nil, nil, fmt.Errorf("SignInit: duplicate signer %d", id)
+		}
+		if _, ok := public.Shares[id]; !ok {
+			return nil, nil, fmt.Errorf("SignInit: signer %d is not in the group", id)
+		}
+	}
+	if !signerIDs.Contains(secret.ID) {
+		return nil, nil, fmt.Errorf("SignInit: party %d is not one of the signers", secret.ID)
+	}
+	d, err := nonce(cs, secret.Secret, rng)
+	if err != nil {
+		return nil, nil, fmt.Errorf("SignInit: %w", err)
+	}
+	e, err := nonce(cs, secret.Secret, rng)
+	if err != nil {
+		return nil, nil, fmt.Errorf("SignInit: %w", err)
+	}
+	g := cs.Group()
+	msg := &Sign1{From: secret.ID, D: g.NewPoint().ScalarBaseMult(d), E: g.NewPoint().ScalarBaseMult(e)}
+	state := &SignerState{
+		Suite:       cs,
+		SelfID:      secret.ID,
+		SignerIDs:   signerIDs,
+		Public:      public,
+		Message:     message,
+		secret:      secret.Secret,
+		d:           d,
+		e:           e,
+		commitments: map[party.ID]*Sign1{secret.ID: msg},
+	}
+	return msg, state, nil
+}
+
+// nonce is nonce_generate of RFC 9591: H3(random_bytes(32) ∥ SerializeScalar(secret)).
+func nonce(cs Ciphersuite, secret curve.Scalar, rng io.Reader) (curve.Scalar, error) {
+	data := make([]byte, 32, 32+cs.Group().ScalarSize())
+	if _, err := io.ReadFull(rng, data); err != nil {
+		return nil, err
+	}
+	return cs.H3(append(data, secret.Bytes()...)), nil
+}
+
+// SignRound1 processes the commitments of the other signers and returns the
+// signature share of the party.
+func SignRound1(state *SignerState, inputMsgs []*Sign1) (*Sign2, error) {
+	if state.d == nil {
+		return nil, errors.New("SignRound1: the nonces were used already")
+	}
+	for _, msg := range inputMsgs {
+		if msg.From == state.SelfID {
+			continue
+		}
+		if !state.SignerIDs.Contains(msg.From) {
+			return nil, fmt.Errorf("SignRound1: message from party %d, which is not a signer", msg.From)
+		}
+		if msg.D == nil || msg.E == nil || msg.D.IsIdentity() || msg.E.IsIdentity() {
+			return nil, fmt.Errorf("SignRound1: invalid commitment of party %d", msg.From)
+		}
+		state.commitments[msg.From] = msg
+	}
+	if len(state.commitments) != len(state.SignerIDs) {
+		return nil, fmt.Errorf("SignRound1: got commitments of %d of %d signers", len(state.commitments), len(state.SignerIDs))
+	}
+
+	g := state.Suite.Group()
+	state.factors = bindingFactors(state.Suite, state.SignerIDs, state.commitments, state.Public.GroupKey, state.Message)
+	state.r = groupCommitment(g, state.SignerIDs, state.commitments, state.factors)
+	state.c = challenge(state.Suite, state.r, state.Public.GroupKey, state.Message)
+
+	// z = d + (e • ρ) + λ • s • c
+	lambda := lagrange(g, state.SignerIDs, state.SelfID)
+	z := g.NewScalar().Multiply(state.e, state.factors[state.SelfID])
+	z.Add(z, state.d)
+	z.Add(z, g.NewScalar().Multiply(g.NewScalar().Multiply(lambda, state.secret), state.c))
+
+	// the nonces must never be used twice
+	state.d, state.e = nil, nil
+	return &Sign2{From: state.SelfID, Z: z}, nil
+}
+
+// SignRound2 verifies the signature shares of the other signers, and returns
+// the signature.
+func SignRound2(state *SignerState, inputMsgs []*Sign2) (*Signature, error) {
+	if state.r == nil {
+		return nil, errors.New("SignRound2: SignRound1 was not run")
+	}
+	g := state.Suite.Group()
+	shares := make(map[party.ID]curve.Scalar, len(state.SignerIDs))
+	for _, msg := range inputMsgs {
+		if !state.SignerIDs.Contains(msg.From) || msg.Z == nil {
+			return nil, fmt.Errorf("SignRound2: unexpected share of party %d", msg.From)
+		}
+		shares[msg.From] = msg.Z
+	}
+	z := g.NewScalar()
+	for _, id := range state.SignerIDs {
+		share, ok := shares[id]
+		if !ok {
+			return nil, fmt.Errorf("SignRound2: missing share of party %d", id)
+		}
+		if !verifyShare(state, id, share) {
+			return nil, fmt.Errorf("SignRound2: invalid signature share of party %d", id)
+		}
+		z.Add(z, share)
+	}
+	sig := &Signature{R: state.r, Z: z}
+	if !Verify(state.Suite, state.Public.GroupKey, state.Message, sig) {
+		return nil, errors.New("SignRound2: the signature does not verify")
+	}
+	return sig, nil
+}
+
+// verifyShare checks [zᵢ]B = Dᵢ + [ρᵢ]Eᵢ + [c • λᵢ]Yᵢ.
+func verifyShare(state *SignerState, id party.ID, z curve.Scalar) bool {
+	g := state.Suite.Group()
+	commitment := state.commitments[id]
+	expected := curve.PublicScalarMult(g.NewPoint(), state.factors[id], commitment.E)
+	expected.Add(expected, commitment.D)
+	cLambda := g.NewScalar().Multiply(state.c, lagrange(g, state.SignerIDs, id))
+	expected.Add(expected, curve.PublicScalarMult(g.NewPoint(), cLambda, state.Public.Shares[id]))
+	return curve.PublicScalarBaseMult(g.NewPoint(), z).Equal(expected)
+}
+
+// bindingFactors returns the binding factors ρᵢ = H1(Y ∥ H4(m) ∥ H5(commitments) ∥ i)
+// of RFC 9591, Section 4.4.
+func bindingFactors(cs Ciphersuite, signerIDs party.IDSlice, commitments map[party.ID]*Sign1, groupKey curve.Point, message []byte) map[party.ID]curve.Scalar {
+	g := cs.Group()
+	var encoded []byte
+	for _, id := range signerIDs {
+		encoded = append(encoded, identifier(g, id).Bytes()...)
+		encoded = append(encoded, commitments[id].D.Bytes()...)
+		encoded = append(encoded, commitments[id].E.Bytes()...)
+	}
+	prefix := groupKey.Bytes()
+	prefix = append(prefix, cs.H4(message)...)
+	prefix = append(prefix, cs.H5(encoded)...)
+
+	factors := make(map[party.ID]curve.Scalar, len(signerIDs))
+	for _, id := range signerIDs {
+		input := append(append([]byte{}, prefix...), identifier(g, id).Bytes()...)
+		factors[id] = cs.H1(input)
+	}
+	return factors
+}
+
+// groupCommitment returns R = ∑ Dᵢ + [ρᵢ]Eᵢ.
+func groupCommitment(g curve.Group, signerIDs party.IDSlice, commitments map[party.ID]*Sign1, factors map[party.ID]curve.Scalar) curve.Point {
+	R := g.NewPoint()
+	for _, id := range signerIDs {
+		R.Add(R, commitments[id].D)
+		R.Add(R, curve.PublicScalarMult(g.NewPoint(), factors[id], commitments[id].E))
+	}
+	return R
+}
+
+// challenge returns c = H2(R ∥ Y ∥ m).
+func challenge(cs Ciphersuite, R, groupKey curve.Point, message []byte) curve.Scalar {
+	data := R.Bytes()
+	data = append(data, groupKey.Bytes()...)
+	data = append(data, message...)
+	return cs.H2(data)
+}
+
+// lagrange returns the Lagrange coefficient of id at 0 over signerIDs.
+func lagrange(g curve.Group, signerIDs party.IDSlice, id party.ID) curve.Scalar {
+	num, den := g.NewScalar().SetUint64(1), g.NewScalar().SetUint64(1)
+	x := identifier(g, id)
+	for _, j := range signerIDs {
+		if j == id {
+			continue
+		}
+		xj := identifier(g, j)
+		num.Multiply(num, xj)
+		den.Multiply(den, g.NewScalar().Subtract(xj, x))
+	}
+	return num.Multiply(num, g.NewScalar().Invert(den))
+}
+
+// Verify reports whether sig is a valid signature of message by groupKey:
+// [z]B = R + [c]Y.
+func Verify(cs Ciphersuite, groupKey curve.Point, message []byte, sig *Signature) bool {
+	if sig == nil || sig.R == nil || sig.Z == nil || groupKey == nil || groupKey.IsIdentity() {
+		return false
+	}
+	g := cs.Group()
+	c := challenge(cs, sig.R, groupKey, message)
+	expected := g.NewPoint().Add(sig.R, curve.PublicScalarMult(g.NewPoint(), c, groupKey))
+	return curve.PublicScalarBaseMult(g.NewPoint(), sig.Z).Equal(expected)
+}
+
+// Bytes returns the encoding of sig of RFC 9591: SerializeElement(R) ∥ SerializeScalar(z).
+func (sig *Signature) Bytes() []byte {
+	return append(sig.R.Bytes(), sig.Z.Bytes()...)
+}
+
+// ParseSignature decodes a signature of cs encoded by Bytes.
+func ParseSignature(cs Ciphersuite, data []byte) (*Signature, error) {
+	g := cs.Group()
+	if len(data) != g.PointSize()+g.ScalarSize() {
+		return nil, fmt.Errorf("ParseSignature: signature of %d bytes", len(data))
+	}
+	R, err := g.NewPoint().SetCanonicalBytes(data[:g.PointSize()])
+	if err != nil {
+		return nil, fmt.Errorf("ParseSignature: %w", err)
+	}
+	z, err := g.NewScalar().SetCanonicalBytes(data[g.PointSize():])
+	if err != nil {
+		return nil, fmt.Errorf("ParseSignature: %w", err)
+	}
+	return &Signature{R: R, Z: z}, nil
+}
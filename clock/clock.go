@@ -0,0 +1,86 @@
+// Package clock abstracts the time source used for expiries, deadlines,
+// retries and timestamps, so that timing dependent behavior can be tested
+// with a Fake clock, and hosts without synchronized time can correct theirs.
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time and creates timers.
+type Clock interface {
+	Now() time.Time
+	// NewTimer returns a timer that fires once after d.
+	NewTimer(d time.Duration) Timer
+	// NewTicker returns a ticker that fires every d.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is the interface of time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	// Stop prevents the timer from firing, and returns false if it already fired or was stopped.
+	Stop() bool
+}
+
+// Ticker is the interface of time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the system clock.
+var Real Clock = realClock{}
+
+// OrReal returns c, or Real if c is nil. It lets structs leave their clock unset.
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+func (t realTimer) Stop() bool          { return t.t.Stop() }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+func (t realTicker) Stop()               { t.t.Stop() }
+
+// Offset returns a clock that is ahead of c by d, or behind it if d is negative.
+// It corrects the wall time of hosts whose offset to a reference clock is known,
+// timers are not affected.
+func Offset(c Clock, d time.Duration) Clock {
+	return offsetClock{Clock: c, d: d}
+}
+
+type offsetClock struct {
+	Clock
+	d time.Duration
+}
+
+func (c offsetClock) Now() time.Time { return c.Clock.Now().Add(c.d) }
+
+// Sleep waits for d on c, or until ctx is done.
+func Sleep(ctx context.Context, c Clock, d time.Duration) error {
+	t := c.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C():
+		return nil
+	}
+}
@@ -0,0 +1,104 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func fired(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-c:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFake_Timer(t *testing.T) {
+	f := NewFake(epoch)
+	a := f.NewTimer(2 * time.Second)
+	b := f.NewTimer(time.Second)
+	stopped := f.NewTimer(time.Second)
+	assert.Equal(t, 3, f.Waiters())
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+
+	f.Advance(999 * time.Millisecond)
+	_, ok := fired(b.C())
+	assert.False(t, ok)
+
+	f.Advance(time.Millisecond)
+	at, ok := fired(b.C())
+	require.True(t, ok)
+	assert.Equal(t, epoch.Add(time.Second), at)
+	_, ok = fired(a.C())
+	assert.False(t, ok)
+
+	f.Advance(time.Hour)
+	at, ok = fired(a.C())
+	require.True(t, ok)
+	assert.Equal(t, epoch.Add(2*time.Second), at, "timers fire at their deadline")
+	assert.Equal(t, epoch.Add(time.Hour+time.Second), f.Now())
+	assert.False(t, a.Stop())
+	assert.Equal(t, 0, f.Waiters())
+
+	_, ok = fired(f.NewTimer(0).C())
+	assert.True(t, ok, "timers without delay fire immediately")
+}
+
+func TestFake_Ticker(t *testing.T) {
+	f := NewFake(epoch)
+	tick := f.NewTicker(time.Minute)
+
+	f.Advance(time.Minute)
+	at, ok := fired(tick.C())
+	require.True(t, ok)
+	assert.Equal(t, epoch.Add(time.Minute), at)
+
+	// ticks that are not received are dropped
+	f.Advance(3 * time.Minute)
+	at, ok = fired(tick.C())
+	require.True(t, ok)
+	assert.Equal(t, epoch.Add(2*time.Minute), at)
+	_, ok = fired(tick.C())
+	assert.False(t, ok)
+
+	tick.Stop()
+	f.Advance(time.Hour)
+	_, ok = fired(tick.C())
+	assert.False(t, ok)
+}
+
+func TestSleep(t *testing.T) {
+	f := NewFake(epoch)
+	done := make(chan error)
+	go func() {
+		done <- Sleep(context.Background(), f, time.Hour)
+	}()
+	f.BlockUntil(1)
+	f.Advance(time.Hour)
+	assert.NoError(t, <-done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		done <- Sleep(ctx, f, time.Hour)
+	}()
+	f.BlockUntil(1)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	assert.Equal(t, 0, f.Waiters(), "the timer is stopped")
+}
+
+func TestOffset(t *testing.T) {
+	f := NewFake(epoch)
+	c := Offset(f, -time.Minute)
+	assert.Equal(t, epoch.Add(-time.Minute), c.Now())
+	assert.Equal(t, Real, OrReal(nil))
+	assert.Equal(t, c, OrReal(c))
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bartke/frost/governance"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/retire"
+)
+
+// errUnsupported is returned for approved changes this service cannot carry out.
+var errUnsupported = errors.New("not supported by frost-jwks")
+
+// handleChange applies a change approved by the group. The approval is
+// verified and recorded in the ledger before the change takes effect, so that
+// it cannot be replayed.
+func (s *server) handleChange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var a governance.Approval
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&a); err != nil {
+		http.Error(w, "invalid approval: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	c, err := s.ledger.Verify(&a, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err := s.checkChange(c); err != nil {
+		status := http.StatusUnprocessableEntity
+		if errors.Is(err, errUnsupported) {
+			status = http.StatusNotImplemented
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if c, err = s.ledger.Record(&a, now); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if c.Kind == governance.KindRetire && s.tombstones != nil {
+		// the ledger already holds the change, the key is retired even if this fails
+		if err := s.bury(&a, now); err != nil {
+			log.Printf("Failed to record tombstone: %v", err)
+		}
+	}
+	s.applyChange(c)
+	log.Printf("Applied change %d: %s", c.Sequence, c.Kind)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sequence": c.Sequence, "kind": c.Kind})
+}
+
+// checkChange returns an error if c cannot be applied to the current configuration.
+func (s *server) checkChange(c *governance.Change) error {
+	switch c.Kind {
+	case governance.KindSetPeers:
+		s.mu.Lock()
+		peers := s.peersAfter(c)
+		s.mu.Unlock()
+		for _, id := range s.signers {
+			if _, ok := peers[id]; !ok && id != s.secret.ID {
+				return fmt.Errorf("the change removes signer %d of the quorum", id)
+			}
+		}
+		for _, u := range peers {
+			if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+				return fmt.Errorf("invalid peer URL %q", u)
+			}
+		}
+		return nil
+	case governance.KindRetire:
+		return nil
+	default:
+		return fmt.Errorf("%s is %w", c.Kind, errUnsupported)
+	}
+}
+
+// peersAfter returns the peer URLs resulting from the set-peers change c. s.mu must be held.
+func (s *server) peersAfter(c *governance.Change) map[party.ID]string {
+	peers := make(map[party.ID]string, len(s.peers))
+	for id, u := range s.peers {
+		peers[id] = u
+	}
+	for k, u := range c.Params {
+		id, _ := party.FromString(k)
+		if u == "" {
+			delete(peers, id)
+		} else {
+			peers[id] = strings.TrimRight(u, "/")
+		}
+	}
+	return peers
+}
+
+// applyChange carries out a recorded change.
+func (s *server) applyChange(c *governance.Change) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch c.Kind {
+	case governance.KindSetPeers:
+		s.peers = s.peersAfter(c)
+	case governance.KindRetire:
+		s.retired = true
+	}
+}
+
+// bury records the tombstone of the key retired by a.
+func (s *server) bury(a *governance.Approval, now time.Time) error {
+	ts, err := retire.New(s.public.GroupKey.ToEd25519(), a, now)
+	if err != nil {
+		return err
+	}
+	if s.retireGrace > 0 {
+		ts.Schedule([]string{s.secretFile}, s.retireGrace)
+	}
+	return s.tombstones.Record(ts)
+}
+
+func (s *server) isRetired() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.retired
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bartke/frost/jose"
+)
+
+// signingInput returns the JWS signing input "header.payload" for claims.
+func signingInput(kid string, claims map[string]interface{}) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	return jose.SigningInput(jose.Header{Alg: jose.Algorithm, Kid: kid, Typ: "JWT"}, payload)
+}
+
+// tokenPolicy are the checks a signer applies before it contributes to a token.
+type tokenPolicy struct {
+	Kid    string
+	Issuer string
+	MaxTTL time.Duration
+}
+
+// check parses a signing input proposed by another signer, and returns an error
+// if it is not a JWT for our key that satisfies the policy.
+func (p tokenPolicy) check(input string, now time.Time) error {
+	header, payload, err := jose.ParseSigningInput(input)
+	if err != nil {
+		return err
+	}
+	if header.Typ != "JWT" || header.Kid != p.Kid {
+		return errors.New("unexpected header")
+	}
+
+	var claims struct {
+		Iss string   `json:"iss"`
+		Exp *float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return fmt.Errorf("payload: %w", err)
+	}
+	if p.Issuer != "" && claims.Iss != p.Issuer {
+		return fmt.Errorf("unexpected issuer %q", claims.Iss)
+	}
+	if claims.Exp == nil {
+		return errors.New("missing exp claim")
+	}
+	exp := time.Unix(int64(*claims.Exp), 0)
+	if exp.Before(now) || exp.After(now.Add(p.MaxTTL+time.Minute)) {
+		return fmt.Errorf("exp %s is outside of the allowed lifetime", exp.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
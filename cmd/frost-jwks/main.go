@@ -0,0 +1,215 @@
+// Command frost-jwks is a JWT signing service backed by a threshold key.
+//
+// Every signer runs one instance with its own secret share. Any instance
+// accepts token requests on /v1/tokens, and signs them together with the other
+// instances of its quorum, which each check the token against their own policy
+// before contributing. The group key is published on /.well-known/jwks.json.
+//
+// The status of the group, including which signers proved possession of their
+// share recently, is reported on /v1/status.
+//
+// With --attempts, a token whose signing fails because peers are unreachable
+// or too slow is signed again, with those peers replaced by other signers that
+// have an endpoint, see package retry. Peers that refuse a token are not replaced.
+//
+// The endpoints of the other signers can be changed, and the key retired, by
+// posting a governance.Approval signed by the group to /v1/admin/changes.
+//
+// With --tombstones, a retirement is also recorded as a tombstone, see package
+// retire, which cmd/sign and other tools sharing the directory honor as well.
+// --retire-grace schedules the deletion of the secret share file by
+// frost retire sweep once the grace period after the retirement has passed.
+//
+// A signer started with --standing only contributes to tokens covered by one
+// of its standing instructions, see package standing. Instructions are added
+// by posting a grant made with the share of the signer to /v1/standing.
+//
+// The token API and the peer endpoints are not authenticated, so instances
+// must only be reachable by the identity provider and by each other.
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/governance"
+	"github.com/bartke/frost/health"
+	"github.com/bartke/frost/jose"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/retire"
+	"github.com/bartke/frost/retry"
+	"github.com/bartke/frost/standing"
+)
+
+func main() {
+	var (
+		listen       = flag.String("listen", "127.0.0.1:8080", "Address to listen on")
+		secretFile   = flag.String("secret", "", "Secret share file of this signer")
+		sharesFile   = flag.String("shares", "", "Public shares file of the group")
+		peers        = flag.String("peers", "", "Comma separated list of id=url of the signers, entries for this signer are ignored")
+		signers      = flag.String("signers", "", "Comma separated IDs of the quorum signing tokens issued by this instance (default: this signer and the first t peers)")
+		issuer       = flag.String("issuer", "", "Issuer of the tokens, set as iss and required by the policy")
+		ttl          = flag.Duration("ttl", time.Hour, "Default lifetime of tokens")
+		maxTTL       = flag.Duration("max-ttl", 24*time.Hour, "Maximum lifetime of tokens this signer agrees to sign")
+		timeout      = flag.Duration("timeout", 30*time.Second, "Timeout of a signing session")
+		attempts     = flag.Int("attempts", 1, "Signing attempts per token; unreachable or slow peers are replaced by other signers with an endpoint")
+		ledgerFile   = flag.String("ledger", "", "File recording the changes approved by the group (default: kept in memory)")
+		probe        = flag.Duration("probe-interval", time.Minute, "Interval between proofs of possession requested from the other signers")
+		standingFile = flag.String("standing", "", "File of the standing instructions of this signer; if set, only tokens covered by one are signed")
+		tombstoneDir = flag.String("tombstones", "", "Directory of tombstones of retired keys; the key is refused if it has one")
+		retireGrace  = flag.Duration("retire-grace", 0, "Schedule the deletion of the secret share file this long after the key is retired (default: keep it)")
+		backups      = flag.String("backups", "", "Comma separated backup files of the secret share to verify in the status; sealed backups are opened with $FROST_BACKUP_PASSPHRASE")
+	)
+	flag.Parse()
+
+	if *secretFile == "" || *sharesFile == "" {
+		fmt.Println("--secret and --shares are required")
+		os.Exit(1)
+	}
+
+	secretData, err := os.ReadFile(*secretFile)
+	if err != nil {
+		log.Fatalf("Failed to read secret share: %v", err)
+	}
+	if secretData, err = frost.DecodeArtifact(frost.ArtifactSecretShare, secretData); err != nil {
+		log.Fatalf("Failed to decode secret share: %v", err)
+	}
+	var secret eddsa.SecretShare
+	if err := secret.UnmarshalBinary(secretData); err != nil {
+		log.Fatalf("Failed to decode secret share: %v", err)
+	}
+
+	sharesData, err := os.ReadFile(*sharesFile)
+	if err != nil {
+		log.Fatalf("Failed to read public shares: %v", err)
+	}
+	if sharesData, err = frost.DecodeArtifact(frost.ArtifactPublic, sharesData); err != nil {
+		log.Fatalf("Failed to decode public shares: %v", err)
+	}
+	var public eddsa.Public
+	if err := json.Unmarshal(sharesData, &public); err != nil {
+		log.Fatalf("Failed to decode public shares: %v", err)
+	}
+
+	peerURLs := make(map[party.ID]string)
+	if *peers != "" {
+		for _, peer := range strings.Split(*peers, ",") {
+			parts := strings.SplitN(peer, "=", 2)
+			if len(parts) != 2 {
+				log.Fatalf("Invalid peer %q, expected id=url", peer)
+			}
+			id, err := party.FromString(strings.TrimSpace(parts[0]))
+			if err != nil {
+				log.Fatalf("Invalid peer ID: %v", err)
+			}
+			peerURLs[id] = strings.TrimRight(strings.TrimSpace(parts[1]), "/")
+		}
+	}
+
+	groupKey := ed25519.PublicKey(public.GroupKey.ToEd25519())
+	ledger, err := governance.OpenLedger(groupKey, *ledgerFile)
+	if err != nil {
+		log.Fatalf("Failed to open ledger: %v", err)
+	}
+
+	var book *standing.Book
+	if *standingFile != "" {
+		if book, err = standing.OpenBook(&public, secret.ID, *standingFile); err != nil {
+			log.Fatalf("Failed to open standing instructions: %v", err)
+		}
+	}
+
+	var tombstones *retire.Store
+	if *tombstoneDir != "" {
+		if tombstones, err = retire.OpenStore(*tombstoneDir); err != nil {
+			log.Fatalf("Failed to open tombstones: %v", err)
+		}
+	}
+
+	jwk := jose.NewJWK(groupKey)
+	s := &server{
+		secret:   &secret,
+		public:   &public,
+		peers:    peerURLs,
+		jwk:      jwk,
+		policy:   tokenPolicy{Kid: jwk.Kid, Issuer: *issuer, MaxTTL: *maxTTL},
+		ttl:      *ttl,
+		timeout:  *timeout,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		retry:    retry.DefaultPolicy,
+		ledger:   ledger,
+		book:     book,
+		sessions: make(map[string]*entry),
+
+		tombstones:  tombstones,
+		secretFile:  *secretFile,
+		retireGrace: *retireGrace,
+	}
+	s.retry.MaxAttempts = *attempts
+	s.monitor = health.NewMonitor(&public, &secret, s.probe)
+	if *backups != "" {
+		s.backups = strings.Split(*backups, ",")
+	}
+	if p, ok := os.LookupEnv("FROST_BACKUP_PASSPHRASE"); ok {
+		s.backupPassphrase = []byte(p)
+	}
+	// changes approved before a restart take precedence over the flags
+	for _, c := range ledger.Changes() {
+		s.applyChange(c)
+	}
+	if tombstones != nil {
+		err := tombstones.Check(groupKey)
+		if errors.Is(err, retire.ErrRetired) {
+			s.retired = true
+		} else if err != nil {
+			log.Fatalf("Failed to check tombstones: %v", err)
+		}
+	}
+	if s.retired {
+		log.Printf("The key was retired, tokens are no longer signed")
+	}
+
+	quorum := party.IDSlice{secret.ID}
+	if *signers != "" {
+		quorum = nil
+		for _, id := range strings.Split(*signers, ",") {
+			id, err := party.FromString(strings.TrimSpace(id))
+			if err != nil {
+				log.Fatalf("Invalid signer ID: %v", err)
+			}
+			quorum = append(quorum, id)
+		}
+	} else {
+		for _, id := range public.PartyIDs {
+			if _, ok := s.peers[id]; ok && id != secret.ID && quorum.N() <= public.Threshold {
+				quorum = append(quorum, id)
+			}
+		}
+	}
+	quorum = party.NewIDSlice(quorum)
+	if quorum.N() <= public.Threshold || !quorum.Contains(secret.ID) {
+		log.Fatalf("The quorum %v must contain this signer and at least %d parties", quorum, public.Threshold+1)
+	}
+	for _, id := range quorum {
+		if _, ok := s.peers[id]; !ok && id != secret.ID {
+			log.Fatalf("No URL for signer %d", id)
+		}
+	}
+	s.signers = quorum
+
+	go s.monitor.Run(context.Background(), *probe)
+
+	log.Printf("Signer %d serving key %s on %s, quorum %v", secret.ID, jwk.Kid, *listen, quorum)
+	log.Fatal(http.ListenAndServe(*listen, s.routes()))
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/governance"
+	"github.com/bartke/frost/health"
+	"github.com/bartke/frost/jose"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/retire"
+	"github.com/bartke/frost/retry"
+	"github.com/bartke/frost/router"
+	"github.com/bartke/frost/standing"
+)
+
+// sessionHeader carries the ID of the signing session a message belongs to.
+const sessionHeader = "X-Frost-Session"
+
+// proposal asks a signer to join the signing of a token.
+type proposal struct {
+	Session      string        `json:"session"`
+	Signers      party.IDSlice `json:"signers"`
+	SigningInput string        `json:"signing_input"`
+}
+
+// entry is a signing session, or a placeholder for one whose proposal has not arrived yet.
+type entry struct {
+	ready   chan struct{}
+	session *frost.Session
+}
+
+type server struct {
+	secret  *eddsa.SecretShare
+	public  *eddsa.Public
+	peers   map[party.ID]string
+	signers party.IDSlice
+	jwk     jose.JWK
+	policy  tokenPolicy
+	ttl     time.Duration
+	timeout time.Duration
+	client  *http.Client
+	// retry is applied to the tokens requested from this instance; peers that
+	// fail are replaced by other signers with an endpoint
+	retry   retry.Policy
+	ledger  *governance.Ledger
+	monitor *health.Monitor
+	// book holds the standing instructions, if only pre-approved tokens are signed
+	book *standing.Book
+
+	// tombstones, if set, records the retirement of the key, and the secret
+	// share file is scheduled for deletion retireGrace after it
+	tombstones  *retire.Store
+	secretFile  string
+	retireGrace time.Duration
+
+	// backups are checked against our share on every status request
+	backups          []string
+	backupPassphrase []byte
+
+	// mu guards sessions, and peers and retired which approved changes modify
+	mu       sync.Mutex
+	sessions map[string]*entry
+	retired  bool
+}
+
+func (s *server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/jwks.json", s.handleJWKS)
+	mux.HandleFunc("/v1/tokens", s.handleToken)
+	mux.HandleFunc("/v1/sessions", s.handleProposal)
+	mux.HandleFunc("/v1/messages", s.handleMessage)
+	mux.HandleFunc("/v1/admin/changes", s.handleChange)
+	mux.HandleFunc("/v1/proof", s.handleProof)
+	mux.HandleFunc("/v1/status", s.handleStatus)
+	mux.HandleFunc("/v1/standing", s.handleStanding)
+	return mux
+}
+
+func (s *server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, map[string][]jose.JWK{"keys": {s.jwk}})
+}
+
+// handleToken signs the posted claims together with the other signers.
+// iss, iat and exp are set if they are missing.
+func (s *server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.isRetired() {
+		http.Error(w, "the key is retired", http.StatusGone)
+		return
+	}
+	var claims map[string]interface{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&claims); err != nil {
+		http.Error(w, "invalid claims: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	if _, ok := claims["iss"]; !ok && s.policy.Issuer != "" {
+		claims["iss"] = s.policy.Issuer
+	}
+	if _, ok := claims["iat"]; !ok {
+		claims["iat"] = now.Unix()
+	}
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = now.Add(s.ttl).Unix()
+	}
+
+	input, err := signingInput(s.jwk.Kid, claims)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// check our own policy before asking the others
+	if err := s.policy.check(input, now); err != nil {
+		http.Error(w, "rejected: "+err.Error(), http.StatusForbidden)
+		return
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	session := hex.EncodeToString(id)
+	if err := s.authorize(session, input); err != nil {
+		http.Error(w, "rejected: "+err.Error(), http.StatusForbidden)
+		return
+	}
+
+	var sig *eddsa.Signature
+	attempt := 0
+	err = s.retry.Run(r.Context(), s.quorum(), func(ctx context.Context, signers party.IDSlice) error {
+		// every attempt is a new session for the peers
+		id := session
+		if attempt++; attempt > 1 {
+			id = fmt.Sprintf("%s-%d", session, attempt)
+			log.Printf("session %s: retrying with signers %v", session, signers)
+		}
+		var err error
+		sig, err = s.sign(ctx, id, signers, input)
+		return err
+	})
+	if err != nil {
+		log.Println("signing failed:", err)
+		http.Error(w, "signing failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"token": jose.Attach(input, sig.ToEd25519()),
+	})
+}
+
+// quorum returns the signers to choose from: the configured ones first, then
+// all other parties with an endpoint.
+func (s *server) quorum() retry.Quorum {
+	q := retry.Quorum{Candidates: s.signers.Copy(), Required: party.IDSlice{s.secret.ID}, Size: len(s.signers)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range s.public.PartyIDs {
+		if _, ok := s.peers[id]; ok && !s.signers.Contains(id) {
+			q.Candidates = append(q.Candidates, id)
+		}
+	}
+	return q
+}
+
+// sign runs the signing session id for input with signers as the initiator.
+// Peers that refuse the proposal or cannot be reached are reported as a retry.Abort.
+func (s *server) sign(ctx context.Context, id string, signers party.IDSlice, input string) (*eddsa.Signature, error) {
+	p := proposal{Session: id, Signers: signers, SigningInput: input}
+
+	// our session must exist before the others send their first messages
+	session, cancel, err := s.start(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed = make(map[party.ID]error)
+	)
+	for _, id := range p.Signers {
+		if id == s.secret.ID {
+			continue
+		}
+		wg.Add(1)
+		go func(id party.ID) {
+			defer wg.Done()
+			if err := s.post(ctx, id, "/v1/sessions", "", p); err != nil {
+				mu.Lock()
+				failed[id] = err
+				mu.Unlock()
+			}
+		}(id)
+	}
+	wg.Wait()
+	if len(failed) > 0 {
+		cancel()
+		return nil, proposalAbort(failed)
+	}
+
+	select {
+	case <-session.Done():
+	case <-ctx.Done():
+		cancel()
+		return nil, ctx.Err()
+	}
+	res, err := session.Result()
+	if err != nil {
+		return nil, err
+	}
+	return res.Signature, nil
+}
+
+// proposalAbort classifies the peers that did not join a session. A denial
+// by any of them is final, unreachable peers can be replaced.
+func proposalAbort(failed map[party.ID]error) *retry.Abort {
+	class := retry.Unreachable
+	ids := make([]party.ID, 0, len(failed))
+	var errs []string
+	for id, err := range failed {
+		var status *statusError
+		if errors.As(err, &status) {
+			if status.code == http.StatusForbidden {
+				class = retry.Denied
+			} else if class == retry.Unreachable {
+				class = retry.Other
+			}
+		}
+		ids = append(ids, id)
+		errs = append(errs, fmt.Sprintf("party %d: %v", id, err))
+	}
+	sort.Strings(errs)
+	return retry.NewAbort(class, ids, errors.New(strings.Join(errs, "; ")))
+}
+
+// statusError is returned by post when a peer answers with an error status.
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (e *statusError) Error() string {
+	return e.msg
+}
+
+// handleProposal joins a signing session started by another signer,
+// if the token satisfies our policy.
+func (s *server) handleProposal(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var p proposal
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&p); err != nil || p.Session == "" {
+		http.Error(w, "invalid proposal", http.StatusBadRequest)
+		return
+	}
+	if s.isRetired() {
+		http.Error(w, "the key is retired", http.StatusGone)
+		return
+	}
+	if !p.Signers.Contains(s.secret.ID) {
+		http.Error(w, "not a signer of this session", http.StatusBadRequest)
+		return
+	}
+	if err := s.policy.check(p.SigningInput, time.Now()); err != nil {
+		log.Printf("rejected session %s: %v", p.Session, err)
+		http.Error(w, "rejected: "+err.Error(), http.StatusForbidden)
+		return
+	}
+	if err := s.authorize(p.Session, p.SigningInput); err != nil {
+		log.Printf("rejected session %s: %v", p.Session, err)
+		http.Error(w, "rejected: "+err.Error(), http.StatusForbidden)
+		return
+	}
+
+	session, _, err := s.start(p)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	go func() {
+		if _, err := session.Result(); err != nil {
+			log.Printf("session %s failed: %v", p.Session, err)
+		}
+	}()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// start creates the local signing session of p, registers it, and forwards its
+// outgoing messages to the other signers. The session is not bound to the request
+// that started it: it runs until it ends, the timeout expires or cancel is called,
+// and its last messages are delivered even after its result is available.
+func (s *server) start(p proposal) (*frost.Session, context.CancelFunc, error) {
+	e := s.entry(p.Session)
+	s.mu.Lock()
+	if e.session != nil {
+		s.mu.Unlock()
+		return nil, nil, errors.New("duplicate session")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	session := frost.NewSignSession(ctx, p.Signers, s.secret, s.public, []byte(p.SigningInput))
+	e.session = session
+	close(e.ready)
+	s.mu.Unlock()
+
+	rt := router.New(s.secret.ID, router.Config{})
+	for _, id := range p.Signers {
+		if id != s.secret.ID {
+			id := id
+			rt.AddRoute(id, router.EndpointFunc(func(ctx context.Context, msg *frost.Message) error {
+				return s.post(ctx, id, "/v1/messages", p.Session, msg)
+			}))
+		}
+	}
+
+	go func() {
+		defer cancel()
+		for msg := range session.Out() {
+			var sendErr *router.SendError
+			if err := rt.Send(ctx, msg); errors.As(err, &sendErr) {
+				for id, err := range sendErr.Failed {
+					log.Printf("session %s: %s to party %d: %v", p.Session, msg.Type, id, err)
+				}
+			} else if err != nil {
+				log.Printf("session %s: %v", p.Session, err)
+			}
+		}
+		s.mu.Lock()
+		delete(s.sessions, p.Session)
+		s.mu.Unlock()
+	}()
+	return session, cancel, nil
+}
+
+// entry returns the entry of the session id, creating a placeholder if needed.
+func (s *server) entry(id string) *entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.sessions[id]
+	if !ok {
+		e = &entry{ready: make(chan struct{})}
+		s.sessions[id] = e
+	}
+	return e
+}
+
+// handleMessage delivers a protocol message to its session. Messages may arrive
+// before the proposal of their session, so we wait for it for a little while.
+func (s *server) handleMessage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := r.Header.Get(sessionHeader)
+	var msg frost.Message
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&msg); err != nil || id == "" {
+		http.Error(w, "invalid message", http.StatusBadRequest)
+		return
+	}
+
+	e := s.entry(id)
+	select {
+	case <-e.ready:
+	case <-time.After(5 * time.Second):
+		s.mu.Lock()
+		if s.sessions[id] == e {
+			delete(s.sessions, id)
+		}
+		s.mu.Unlock()
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	case <-r.Context().Done():
+		return
+	}
+
+	select {
+	case e.session.In() <- &msg:
+		w.WriteHeader(http.StatusAccepted)
+	case <-e.session.Done():
+		http.Error(w, "session ended", http.StatusGone)
+	case <-r.Context().Done():
+	}
+}
+
+// post sends v as JSON to the endpoint path of the party id.
+func (s *server) post(ctx context.Context, id party.ID, path, session string, v interface{}) error {
+	s.mu.Lock()
+	base, ok := s.peers[id]
+	s.mu.Unlock()
+	if !ok {
+		return router.ErrNoRoute
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if session != "" {
+		req.Header.Set(sessionHeader, session)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		var msg bytes.Buffer
+		_, _ = msg.ReadFrom(resp.Body)
+		return &statusError{code: resp.StatusCode, msg: resp.Status + ": " + string(bytes.TrimSpace(msg.Bytes()))}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/jose"
+	"github.com/bartke/frost/standing"
+)
+
+// tokenRequest describes the token with the signing input for standing instructions.
+// The claims are the metadata of the request, numbers and strings as they
+// appear in the token, other values JSON encoded.
+func tokenRequest(session, input string) (*frost.SignatureRequest, error) {
+	_, payload, err := jose.ParseSigningInput(input)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var claims map[string]interface{}
+	if err := dec.Decode(&claims); err != nil {
+		return nil, err
+	}
+
+	req := &frost.SignatureRequest{
+		ID:       session,
+		Message:  []byte(input),
+		Format:   "jwt",
+		Metadata: make(map[string]string, len(claims)),
+	}
+	for k, v := range claims {
+		switch v := v.(type) {
+		case string:
+			req.Metadata[k] = v
+		case json.Number:
+			req.Metadata[k] = v.String()
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			req.Metadata[k] = string(data)
+		}
+	}
+	return req, nil
+}
+
+// authorize checks that a standing instruction covers the token, if this signer
+// only signs tokens it pre-approved. This service has no interactive approval,
+// so other tokens are rejected.
+func (s *server) authorize(session, input string) error {
+	if s.book == nil {
+		return nil
+	}
+	req, err := tokenRequest(session, input)
+	if err != nil {
+		return err
+	}
+	_, err = s.book.Authorize(req, time.Now())
+	return err
+}
+
+// handleStanding lists the standing instructions of this signer, adds a
+// grant, or revokes the instruction with the given id. Grants carry a proof
+// made with our share, so only its owner can add instructions.
+func (s *server) handleStanding(w http.ResponseWriter, r *http.Request) {
+	if s.book == nil {
+		http.Error(w, "standing instructions are not enabled", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.book.Instructions())
+	case http.MethodPost:
+		var g standing.Grant
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&g); err != nil {
+			http.Error(w, "invalid grant: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.book.Add(&g); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		writeJSON(w, http.StatusCreated, g.Instruction)
+	case http.MethodDelete:
+		if err := s.book.Revoke(r.URL.Query().Get("id")); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
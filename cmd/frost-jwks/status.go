@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bartke/frost/health"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/zk"
+)
+
+// handleProof proves possession of our share for the challenge of another party.
+func (s *server) handleProof(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	challenge, err := hex.DecodeString(r.URL.Query().Get("challenge"))
+	if err != nil || len(challenge) != health.ChallengeSize {
+		http.Error(w, "invalid challenge", http.StatusBadRequest)
+		return
+	}
+	proof, err := health.Prove(s.secret, s.public, challenge)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, err := proof.MarshalBinary()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"proof": hex.EncodeToString(data)})
+}
+
+// probe asks the party id for a proof of possession, see health.Prober.
+func (s *server) probe(ctx context.Context, id party.ID, challenge []byte) (*zk.Schnorr, error) {
+	s.mu.Lock()
+	base, ok := s.peers[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, errors.New("no endpoint")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/v1/proof?challenge="+hex.EncodeToString(challenge), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status)
+	}
+	var body struct {
+		Proof string `json:"proof"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	data, err := hex.DecodeString(body.Proof)
+	if err != nil {
+		return nil, err
+	}
+	var proof zk.Schnorr
+	if err := proof.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+// handleStatus reports the status of the group as seen by this signer.
+func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	status := s.monitor.Status()
+
+	s.mu.Lock()
+	for i := range status.Parties {
+		status.Parties[i].Endpoint = s.peers[status.Parties[i].ID]
+	}
+	status.PendingSessions = len(s.sessions)
+	s.mu.Unlock()
+
+	for _, path := range s.backups {
+		status.Backups = append(status.Backups, health.CheckBackup(path, s.secret, s.backupPassphrase, time.Now()))
+	}
+	writeJSON(w, http.StatusOK, status)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bartke/frost/approval"
+)
+
+// approveCmd decides the sign requests a frostd started with
+// --approval-socket holds:
+//
+//	frost approve --socket /run/frostd/approval.sock
+//	frost approve --socket /run/frostd/approval.sock 3f9a0c12e4b7
+//	frost approve --socket /run/frostd/approval.sock --deny --reason "not scheduled" 3f9a0c12e4b7
+//
+// Without a request ID, the pending requests are listed.
+func approveCmd(args []string) {
+	fs := flag.NewFlagSet("approve", flag.ExitOnError)
+	var (
+		socket = fs.String("socket", "frostd-approval.sock", "Approval socket of frostd")
+		deny   = fs.Bool("deny", false, "Deny the request instead")
+		reason = fs.String("reason", "", "Reason of a denial, passed on to the coordinator")
+	)
+	fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := approval.NewUnixClient(*socket)
+
+	if fs.NArg() == 0 {
+		pending, err := c.List(ctx)
+		if err != nil {
+			fmt.Println("Error listing requests:", err)
+			os.Exit(1)
+		}
+		if len(pending) == 0 {
+			fmt.Println("No pending requests")
+			return
+		}
+		for _, p := range pending {
+			printPending(p)
+		}
+		return
+	}
+
+	for _, id := range fs.Args() {
+		var err error
+		if *deny {
+			err = c.Deny(ctx, id, *reason)
+		} else {
+			err = c.Approve(ctx, id)
+		}
+		if err != nil {
+			fmt.Printf("Error deciding request %s: %v\n", id, err)
+			os.Exit(1)
+		}
+		if *deny {
+			fmt.Printf("Denied request %s\n", id)
+		} else {
+			fmt.Printf("Approved request %s\n", id)
+		}
+	}
+}
+
+// printPending prints a pending request, with its message if it is short text.
+func printPending(p *approval.Pending) {
+	fmt.Printf("%s  received %s  signers %v", p.ID, p.Received.Local().Format(time.RFC3339), p.Signers)
+	if p.Group != "" {
+		fmt.Printf("  group %s", p.Group)
+	}
+	fmt.Println()
+	fmt.Printf("    message SHA-256 %s", p.MessageDigest)
+	switch {
+	case p.Prehash:
+		fmt.Print(" (of the SHA-512 digest of a prehashed message)")
+	case utf8.Valid(p.Message) && len(p.Message) <= 200:
+		fmt.Printf(": %s", strconv.Quote(string(p.Message)))
+	default:
+		fmt.Printf(" (%d bytes)", len(p.Message))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/attest"
+	"github.com/bartke/frost/eddsa"
+)
+
+// The attest workflow has three steps:
+//
+//	frost attest prepare --file F --shares public.json --out F.body
+//	    (sign F.body with cmd/sign --message F.body)
+//	frost attest finish --body F.body --signature <hex> --out F.att
+//	frost attest verify --file F --attestation F.att --shares public.json
+func attestCmd(args []string) {
+	usage := func() {
+		fmt.Println("Usage: frost attest prepare|finish|verify [flags]")
+	}
+	if len(args) == 0 {
+		usage()
+		return
+	}
+	switch args[0] {
+	case "prepare":
+		attestPrepare(args[1:])
+	case "finish":
+		attestFinish(args[1:])
+	case "verify":
+		attestVerify(args[1:])
+	default:
+		usage()
+	}
+}
+
+// groupKey returns the group key from the public shares file, or from the hex encoded key.
+func groupKey(sharesFile, hexKey string) (ed25519.PublicKey, error) {
+	if hexKey != "" {
+		key, err := hex.DecodeString(hexKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key %q", hexKey)
+		}
+		return key, nil
+	}
+	if sharesFile == "" {
+		return nil, fmt.Errorf("--shares or --pubkey is required")
+	}
+	data, err := readArtifact(sharesFile, frost.ArtifactPublic)
+	if err != nil {
+		return nil, err
+	}
+	var public eddsa.Public
+	if err := json.Unmarshal(data, &public); err != nil {
+		return nil, err
+	}
+	return public.GroupKey.ToEd25519(), nil
+}
+
+func attestPrepare(args []string) {
+	fs := flag.NewFlagSet("attest prepare", flag.ExitOnError)
+	var (
+		file       = fs.String("file", "", "File to attest")
+		sharesFile = fs.String("shares", "", "Public shares file of the group")
+		pubKey     = fs.String("pubkey", "", "Hex encoded group key, instead of --shares")
+		at         = fs.String("time", "", "Time of the attestation in RFC 3339 (default: now)")
+		out        = fs.String("out", "", "Output file of the body to sign (default: <file>.body)")
+	)
+	fs.Parse(args)
+
+	if *file == "" {
+		fmt.Println("--file is required")
+		os.Exit(1)
+	}
+	pub, err := groupKey(*sharesFile, *pubKey)
+	if err != nil {
+		fmt.Println("Error reading group key:", err)
+		os.Exit(1)
+	}
+	t := time.Now()
+	if *at != "" {
+		if t, err = time.Parse(time.RFC3339, *at); err != nil {
+			fmt.Println("Error parsing time:", err)
+			os.Exit(1)
+		}
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		fmt.Println("Error opening file:", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	a, err := attest.New(pub, f, t)
+	if err != nil {
+		fmt.Println("Error attesting file:", err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		*out = *file + ".body"
+	}
+	if err := writeFile(*out, a.Body()); err != nil {
+		fmt.Println("Error writing body:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Sign %s as the message, then run frost attest finish --body %s --signature <hex>\n", *out, *out)
+}
+
+func attestFinish(args []string) {
+	fs := flag.NewFlagSet("attest finish", flag.ExitOnError)
+	var (
+		bodyFile  = fs.String("body", "", "Body written by frost attest prepare")
+		signature = fs.String("signature", "", "Hex encoded signature over the body")
+		out       = fs.String("out", "", "Output file of the attestation (default: the body file with .att instead of .body)")
+	)
+	fs.Parse(args)
+
+	if *bodyFile == "" || *signature == "" {
+		fmt.Println("--body and --signature are required")
+		os.Exit(1)
+	}
+	body, err := readFile(*bodyFile)
+	if err != nil {
+		fmt.Println("Error reading body:", err)
+		os.Exit(1)
+	}
+	a, err := attest.ParseBody(body)
+	if err != nil {
+		fmt.Println("Error parsing body:", err)
+		os.Exit(1)
+	}
+	sig, err := hex.DecodeString(strings.TrimSpace(*signature))
+	if err != nil {
+		fmt.Println("Error decoding signature:", err)
+		os.Exit(1)
+	}
+	if err := a.Attach(sig); err != nil {
+		fmt.Println("Error attaching signature:", err)
+		os.Exit(1)
+	}
+	text, err := a.MarshalText()
+	if err != nil {
+		fmt.Println("Error encoding attestation:", err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		*out = strings.TrimSuffix(*bodyFile, ".body") + ".att"
+	}
+	if err := writeFile(*out, text); err != nil {
+		fmt.Println("Error writing attestation:", err)
+		os.Exit(1)
+	}
+	fmt.Println("Attestation written to", *out)
+}
+
+func attestVerify(args []string) {
+	fs := flag.NewFlagSet("attest verify", flag.ExitOnError)
+	var (
+		file        = fs.String("file", "", "Attested file")
+		attestation = fs.String("attestation", "", "Attestation file (default: <file>.att)")
+		sharesFile  = fs.String("shares", "", "Public shares file of the group")
+		pubKey      = fs.String("pubkey", "", "Hex encoded group key, instead of --shares")
+	)
+	fs.Parse(args)
+
+	if *file == "" {
+		fmt.Println("--file is required")
+		os.Exit(1)
+	}
+	if *attestation == "" {
+		*attestation = *file + ".att"
+	}
+	pub, err := groupKey(*sharesFile, *pubKey)
+	if err != nil {
+		fmt.Println("Error reading group key:", err)
+		os.Exit(1)
+	}
+	text, err := readFile(*attestation)
+	if err != nil {
+		fmt.Println("Error reading attestation:", err)
+		os.Exit(1)
+	}
+	var a attest.Attestation
+	if err := a.UnmarshalText(text); err != nil {
+		fmt.Println("Error parsing attestation:", err)
+		os.Exit(1)
+	}
+	content, err := readFile(*file)
+	if err != nil {
+		fmt.Println("Error reading file:", err)
+		os.Exit(1)
+	}
+	if err := a.Verify(pub, bytes.NewReader(content)); err != nil {
+		fmt.Println("Attestation is invalid:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Attestation is valid, attested at %s.\n", a.Time.Format(time.RFC3339))
+}
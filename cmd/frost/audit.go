@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bartke/frost/audit"
+)
+
+// auditCmd checks the hash-chained audit logs written by frostd, and by
+// cmd/keygen and cmd/sign with --audit-log:
+//
+//	frost audit verify --log audit.log --head 5b1c...
+//	frost audit prove --log audit.log --shares public.json --message release.tar.gz
+//
+// verify checks the chain and lists the entries. As a log cut short is still a
+// valid chain, --head compares the last entry with a head published earlier.
+// prove lists the entries that record a signature of the message by the group.
+func auditCmd(args []string) {
+	usage := func() {
+		fmt.Println("Usage: frost audit verify|prove [flags]")
+	}
+	if len(args) == 0 {
+		usage()
+		return
+	}
+	switch args[0] {
+	case "verify":
+		auditVerify(args[1:])
+	case "prove":
+		auditProve(args[1:])
+	default:
+		usage()
+	}
+}
+
+func auditVerify(args []string) {
+	fs := flag.NewFlagSet("audit verify", flag.ExitOnError)
+	var (
+		logFile = fs.String("log", "audit.log", "Audit log")
+		head    = fs.String("head", "", "Expected hash of the last entry")
+		quiet   = fs.Bool("quiet", false, "Do not list the entries")
+	)
+	fs.Parse(args)
+
+	entries, err := audit.VerifyFile(*logFile)
+	if err != nil {
+		fmt.Println("Invalid audit log:", err)
+		os.Exit(1)
+	}
+	last := audit.Genesis
+	for _, e := range entries {
+		if !*quiet {
+			printEntry(e)
+		}
+		last = e.Hash
+	}
+	fmt.Printf("%d entries, head %s\n", len(entries), last)
+	if *head != "" && *head != last {
+		fmt.Println("The head does not match, the log was cut short or rewritten")
+		os.Exit(1)
+	}
+}
+
+func auditProve(args []string) {
+	fs := flag.NewFlagSet("audit prove", flag.ExitOnError)
+	var (
+		logFile     = fs.String("log", "audit.log", "Audit log")
+		sharesFile  = fs.String("shares", "", "Public shares file of the group")
+		pubKey      = fs.String("pubkey", "", "Hex encoded group key, instead of --shares")
+		messageFile = fs.String("message", "", "Signed message")
+	)
+	fs.Parse(args)
+
+	pub, err := groupKey(*sharesFile, *pubKey)
+	if err != nil {
+		fmt.Println("Error reading group key:", err)
+		os.Exit(1)
+	}
+	message, err := readFile(*messageFile)
+	if err != nil {
+		fmt.Println("Error reading message:", err)
+		os.Exit(1)
+	}
+	entries, err := audit.VerifyFile(*logFile)
+	if err != nil {
+		fmt.Println("Invalid audit log:", err)
+		os.Exit(1)
+	}
+	found := 0
+	for _, e := range entries {
+		if e.VerifySignature(pub, message) == nil {
+			printEntry(e)
+			found++
+		}
+	}
+	if found == 0 {
+		fmt.Println("The log records no signature of the message by the group")
+		os.Exit(1)
+	}
+}
+
+// printEntry prints a line summarizing the entry.
+func printEntry(e *audit.Entry) {
+	fmt.Printf("%6d  %s  %-7s  %-9s", e.Seq, e.Time.Format(time.RFC3339), e.Kind, e.Outcome)
+	if e.Group != "" {
+		fmt.Printf("  group %s", e.Group)
+	}
+	if e.Party != 0 {
+		fmt.Printf("  party %s", e.Party)
+	}
+	if len(e.Parties) > 0 {
+		fmt.Printf("  parties %v", e.Parties)
+	}
+	if e.MessageDigest != "" {
+		fmt.Printf("  message %s", e.MessageDigest)
+		if e.Prehash {
+			fmt.Print(" (prehashed)")
+		}
+	}
+	if e.Culprit != 0 {
+		fmt.Printf("  culprit %s", e.Culprit)
+	}
+	if e.Error != "" {
+		fmt.Printf("  error %q", e.Error)
+	}
+	fmt.Println()
+}
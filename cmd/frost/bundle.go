@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/bundle"
+	"github.com/bartke/frost/eddsa"
+)
+
+// The bundle workflow mirrors attest:
+//
+//	frost bundle prepare --shares public.json --ceremony round0_out_1.json,round0_out_2.json,... --out bundle.body
+//	    (sign bundle.body with cmd/sign --message bundle.body)
+//	frost bundle finish --body bundle.body --signature <hex> --out bundle.json
+//	frost bundle verify --bundle bundle.json --fingerprint <fingerprint>
+func bundleCmd(args []string) {
+	usage := func() {
+		fmt.Println("Usage: frost bundle prepare|finish|verify [flags]")
+	}
+	if len(args) == 0 {
+		usage()
+		return
+	}
+	switch args[0] {
+	case "prepare":
+		bundlePrepare(args[1:])
+	case "finish":
+		bundleFinish(args[1:])
+	case "verify":
+		bundleVerify(args[1:])
+	default:
+		usage()
+	}
+}
+
+func bundlePrepare(args []string) {
+	fs := flag.NewFlagSet("bundle prepare", flag.ExitOnError)
+	var (
+		sharesFile = fs.String("shares", "", "Public shares file of the group")
+		ceremony   = fs.String("ceremony", "", "Comma separated KeyGen1 message files of all parties (optional)")
+		out        = fs.String("out", "bundle.body", "Output file of the body to sign")
+	)
+	fs.Parse(args)
+
+	if *sharesFile == "" {
+		fmt.Println("--shares is required")
+		os.Exit(1)
+	}
+	data, err := readArtifact(*sharesFile, frost.ArtifactPublic)
+	if err != nil {
+		fmt.Println("Error reading shares:", err)
+		os.Exit(1)
+	}
+	var public eddsa.Public
+	if err := json.Unmarshal(data, &public); err != nil {
+		fmt.Println("Error decoding shares:", err)
+		os.Exit(1)
+	}
+	var msgs []*frost.Message
+	if *ceremony != "" {
+		for _, file := range strings.Split(*ceremony, ",") {
+			data, err := readArtifact(file, frost.ArtifactMessage)
+			if err != nil {
+				fmt.Println("Error reading ceremony message:", err)
+				os.Exit(1)
+			}
+			var msg frost.Message
+			if err := msg.UnmarshalJSON(data); err != nil {
+				fmt.Printf("Error decoding %s: %v\n", file, err)
+				os.Exit(1)
+			}
+			msgs = append(msgs, &msg)
+		}
+	}
+
+	b, err := bundle.New(&public, msgs, time.Now())
+	if err != nil {
+		fmt.Println("Invalid bundle:", err)
+		os.Exit(1)
+	}
+	if err := writeFile(*out, b.Body()); err != nil {
+		fmt.Println("Error writing body:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Sign %s as the message, then run frost bundle finish --body %s --signature <hex>\n", *out, *out)
+}
+
+func bundleFinish(args []string) {
+	fs := flag.NewFlagSet("bundle finish", flag.ExitOnError)
+	var (
+		bodyFile  = fs.String("body", "bundle.body", "Body written by frost bundle prepare")
+		signature = fs.String("signature", "", "Hex encoded signature over the body")
+		out       = fs.String("out", "bundle.json", "Output file of the bundle")
+	)
+	fs.Parse(args)
+
+	body, err := readFile(*bodyFile)
+	if err != nil {
+		fmt.Println("Error reading body:", err)
+		os.Exit(1)
+	}
+	b, err := bundle.ParseBody(body)
+	if err != nil {
+		fmt.Println("Error parsing body:", err)
+		os.Exit(1)
+	}
+	sig, err := hex.DecodeString(strings.TrimSpace(*signature))
+	if err != nil {
+		fmt.Println("Error decoding signature:", err)
+		os.Exit(1)
+	}
+	b.Attach(sig)
+	if err := b.Verify(nil); err != nil {
+		fmt.Println("Invalid bundle:", err)
+		os.Exit(1)
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		fmt.Println("Error encoding bundle:", err)
+		os.Exit(1)
+	}
+	if err := writeFile(*out, data); err != nil {
+		fmt.Println("Error writing bundle:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Bundle of group %s written to %s\n", b.Fingerprint(), *out)
+}
+
+func bundleVerify(args []string) {
+	fs := flag.NewFlagSet("bundle verify", flag.ExitOnError)
+	var (
+		bundleFile  = fs.String("bundle", "bundle.json", "Bundle file")
+		pubKey      = fs.String("pubkey", "", "Hex encoded group key the bundle must be for (optional)")
+		fingerprint = fs.String("fingerprint", "", "Fingerprint of the group key the bundle must be for (optional)")
+	)
+	fs.Parse(args)
+
+	data, err := readFile(*bundleFile)
+	if err != nil {
+		fmt.Println("Error reading bundle:", err)
+		os.Exit(1)
+	}
+	var b bundle.Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		fmt.Println("Invalid bundle:", err)
+		os.Exit(1)
+	}
+	var pub []byte
+	if *pubKey != "" {
+		if pub, err = groupKey("", *pubKey); err != nil {
+			fmt.Println("Error reading group key:", err)
+			os.Exit(1)
+		}
+	}
+	if err := b.Verify(pub); err != nil {
+		fmt.Println("Invalid bundle:", err)
+		os.Exit(1)
+	}
+	if *fingerprint != "" && *fingerprint != b.Fingerprint() {
+		fmt.Printf("Invalid bundle: for group %s, expected %s\n", b.Fingerprint(), *fingerprint)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Group key:   %x\n", b.GroupKey())
+	fmt.Printf("Fingerprint: %s\n", b.Fingerprint())
+	fmt.Printf("Threshold:   %d of %d parties (%d signers needed)\n", b.Public.Threshold, b.Public.PartyIDs.N(), b.Public.Threshold+1)
+	fmt.Printf("Parties:     %v\n", b.Public.PartyIDs)
+	fmt.Printf("Created:     %s\n", b.Created.Format(time.RFC3339))
+	if len(b.Ceremony) > 0 {
+		fmt.Println("Ceremony:    verified, every party proved its contribution to the key")
+	} else {
+		fmt.Println("Ceremony:    not included")
+	}
+	if *pubKey == "" && *fingerprint == "" {
+		fmt.Println("Compare the fingerprint with one from a trusted source, or pass --fingerprint")
+	}
+}
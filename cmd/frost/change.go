@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bartke/frost/governance"
+	"github.com/bartke/frost/manifest"
+)
+
+// params collects repeated key=value flags.
+type params map[string]string
+
+func (p params) String() string {
+	return fmt.Sprint(map[string]string(p))
+}
+
+func (p params) Set(s string) error {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+	p[kv[0]] = kv[1]
+	return nil
+}
+
+// The change workflow mirrors attest:
+//
+//	frost change prepare --shares public.json --sequence 1 --kind set-peers --param 2=https://b.example
+//	    (sign change.body with cmd/sign --message change.body)
+//	frost change finish --body change.body --signature <hex> --out approval.json
+func changeCmd(args []string) {
+	usage := func() {
+		fmt.Println("Usage: frost change prepare|finish [flags]")
+	}
+	if len(args) == 0 {
+		usage()
+		return
+	}
+	switch args[0] {
+	case "prepare":
+		changePrepare(args[1:])
+	case "finish":
+		changeFinish(args[1:])
+	default:
+		usage()
+	}
+}
+
+func changePrepare(args []string) {
+	fs := flag.NewFlagSet("change prepare", flag.ExitOnError)
+	p := make(params)
+	var (
+		sharesFile = fs.String("shares", "", "Public shares file of the group")
+		pubKey     = fs.String("pubkey", "", "Hex encoded group key, instead of --shares")
+		sequence   = fs.Uint64("sequence", 0, "Sequence number of the change, one more than the last applied change")
+		kind       = fs.String("kind", "", "Kind of change: set-peers, reshare or retire")
+		ttl        = fs.Duration("ttl", 24*time.Hour, "Time until the change expires")
+		out        = fs.String("out", "change.body", "Output file of the body to sign")
+	)
+	fs.Var(p, "param", "Parameter of the change as key=value, may be repeated")
+	fs.Parse(args)
+
+	pub, err := groupKey(*sharesFile, *pubKey)
+	if err != nil {
+		fmt.Println("Error reading group key:", err)
+		os.Exit(1)
+	}
+	c := &governance.Change{
+		Group:    manifest.Fingerprint(pub),
+		Sequence: *sequence,
+		Kind:     governance.Kind(*kind),
+		Params:   p,
+		Expires:  time.Now().Add(*ttl).Truncate(time.Second),
+	}
+	if err := c.Validate(); err != nil {
+		fmt.Println("Invalid change:", err)
+		os.Exit(1)
+	}
+	if err := writeFile(*out, c.Body()); err != nil {
+		fmt.Println("Error writing body:", err)
+		os.Exit(1)
+	}
+	fmt.Print(string(c.Body()))
+	fmt.Printf("Sign %s as the message, then run frost change finish --body %s --signature <hex>\n", *out, *out)
+}
+
+func changeFinish(args []string) {
+	fs := flag.NewFlagSet("change finish", flag.ExitOnError)
+	var (
+		bodyFile  = fs.String("body", "change.body", "Body written by frost change prepare")
+		signature = fs.String("signature", "", "Hex encoded signature over the body")
+		out       = fs.String("out", "approval.json", "Output file of the approval")
+		shares    = fs.String("shares", "", "Public shares file of the group, to verify the approval (optional)")
+		pubKey    = fs.String("pubkey", "", "Hex encoded group key, instead of --shares (optional)")
+	)
+	fs.Parse(args)
+
+	body, err := readFile(*bodyFile)
+	if err != nil {
+		fmt.Println("Error reading body:", err)
+		os.Exit(1)
+	}
+	c, err := governance.ParseBody(body)
+	if err != nil {
+		fmt.Println("Error parsing body:", err)
+		os.Exit(1)
+	}
+	sig, err := hex.DecodeString(strings.TrimSpace(*signature))
+	if err != nil {
+		fmt.Println("Error decoding signature:", err)
+		os.Exit(1)
+	}
+	a := &governance.Approval{Body: body, Signature: sig}
+	if *shares != "" || *pubKey != "" {
+		pub, err := groupKey(*shares, *pubKey)
+		if err != nil {
+			fmt.Println("Error reading group key:", err)
+			os.Exit(1)
+		}
+		if _, err := a.Verify(pub, time.Now()); err != nil {
+			fmt.Println("Invalid approval:", err)
+			os.Exit(1)
+		}
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		fmt.Println("Error encoding approval:", err)
+		os.Exit(1)
+	}
+	if err := writeFile(*out, data); err != nil {
+		fmt.Println("Error writing approval:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Approval of change %d (%s) written to %s\n", c.Sequence, c.Kind, *out)
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/keywrap"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/progress"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/sealed"
+)
+
+func inspectCmd(args []string) {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	var (
+		graph   = fs.Bool("graph", false, "Render the message flow of the session")
+		format  = fs.String("format", "dot", "Graph format, dot or mermaid")
+		parties = fs.String("parties", "", "Comma separated IDs of the session's parties (default: all senders and recipients)")
+		pass    = fs.String("passphrase-file", "", "File holding the passphrase of sealed state files")
+		wrap    = fs.String("wrap", "", "KMS key or age identity of wrapped state files: awskms:<key>, gcpkms:<key name> or age:<identity file>")
+	)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fmt.Println("Usage: frost inspect [--passphrase-file <file>] [--wrap <provider>:<key>] <state or message file>...")
+		fmt.Println("       frost inspect --graph [--format dot|mermaid] [--parties 1,2,3] <message file or directory>...")
+		return
+	}
+	if !*graph {
+		var passphrase []byte
+		if *pass != "" {
+			data, err := readFile(*pass)
+			if err != nil {
+				fmt.Println("Error reading passphrase:", err)
+				os.Exit(1)
+			}
+			passphrase = bytes.TrimRight(data, "\r\n")
+		}
+		var wrapper keywrap.Wrapper
+		if *wrap != "" {
+			var err error
+			if wrapper, err = keywrap.Parse(*wrap); err != nil {
+				fmt.Println("Error:", err)
+				os.Exit(1)
+			}
+		}
+		failed := false
+		for _, file := range fs.Args() {
+			if err := describeFile(os.Stdout, file, passphrase, wrapper); err != nil {
+				fmt.Printf("%s: %v\n", file, err)
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+		return
+	}
+
+	msgs, err := readMessages(fs.Args())
+	if err != nil {
+		fmt.Println("Error reading messages:", err)
+		os.Exit(1)
+	}
+
+	var partyIDs party.IDSlice
+	if *parties != "" {
+		if partyIDs, err = parseIDs(*parties); err != nil {
+			fmt.Println("Error parsing party IDs:", err)
+			os.Exit(1)
+		}
+	} else {
+		for _, msg := range msgs {
+			partyIDs = append(partyIDs, msg.From)
+			if !msg.IsBroadcast() {
+				partyIDs = append(partyIDs, msg.To)
+			}
+		}
+	}
+
+	p := progress.New(partyIDs)
+	for _, msg := range msgs {
+		p.Add(msg)
+	}
+
+	switch *format {
+	case "dot":
+		fmt.Print(p.DOT())
+	case "mermaid":
+		fmt.Print(p.Mermaid())
+	default:
+		fmt.Println("Unknown format:", *format)
+		os.Exit(1)
+	}
+	fmt.Fprint(os.Stderr, p.Summary())
+}
+
+// readMessages decodes the message files in paths. Directories are searched
+// for .json files, and files that are not messages are skipped.
+func readMessages(paths []string) ([]*frost.Message, error) {
+	var msgs []*frost.Message
+	for _, path := range paths {
+		files := []string{path}
+		if info, err := os.Stat(path); err != nil {
+			return nil, err
+		} else if info.IsDir() {
+			if files, err = filepath.Glob(filepath.Join(path, "*.json")); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, file := range files {
+			data, err := readArtifact(file, frost.ArtifactMessage)
+			if err != nil {
+				return nil, err
+			}
+			var msg frost.Message
+			if err := msg.UnmarshalJSON(data); err != nil || msg.Type == frost.MessageTypeNone {
+				continue
+			}
+			msgs = append(msgs, &msg)
+		}
+	}
+	return msgs, nil
+}
+
+// describeFile prints a summary of the key generation state, signer state or
+// message in file. Secrets are never printed, and public values are shown as
+// fingerprints that can be compared across the files of the parties.
+func describeFile(w io.Writer, file string, passphrase []byte, wrapper keywrap.Wrapper) error {
+	data, err := readFile(file)
+	if err != nil {
+		return err
+	}
+	if keywrap.IsWrapped(data) {
+		if wrapper == nil {
+			return errors.New("wrapped, use --wrap")
+		}
+		if data, err = keywrap.Open(context.Background(), data, wrapper); err != nil {
+			return err
+		}
+	}
+	if sealed.IsSealed(data) {
+		if passphrase == nil {
+			return errors.New("sealed, use --passphrase-file")
+		}
+		if data, err = sealed.Open(data, passphrase); err != nil {
+			return err
+		}
+	}
+	if kind, _, err := frost.DetectVersion(data); err == nil {
+		if data, err = frost.DecodeArtifact(kind, data); err != nil {
+			return err
+		}
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("not a JSON state or message: %w", err)
+	}
+	switch {
+	case fields["polynomial"] != nil:
+		var state frost.KeygenState
+		if err := state.UnmarshalJSON(data); err != nil {
+			return err
+		}
+		describeKeygenState(w, file, &state)
+	case fields["signer_ids"] != nil:
+		var state frost.SignerState
+		if err := state.UnmarshalJSON(data); err != nil {
+			return err
+		}
+		describeSignerState(w, file, &state)
+	case fields["header"] != nil:
+		var msg frost.Message
+		if err := msg.UnmarshalJSON(data); err != nil {
+			return err
+		}
+		describeMessage(w, file, &msg)
+	default:
+		return errors.New("not a key generation state, signer state or message")
+	}
+	return nil
+}
+
+func describeKeygenState(w io.Writer, file string, s *frost.KeygenState) {
+	others := without(s.PartyIDs, s.SelfID)
+	var absorbed, missing party.IDSlice
+	for _, id := range others {
+		if _, ok := s.Commitments[id]; ok {
+			absorbed = append(absorbed, id)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	fmt.Fprintf(w, "%s: key generation state of party %d\n", file, s.SelfID)
+	field(w, "parties", "%s, threshold %d", listIDs(s.PartyIDs), s.Threshold)
+	field(w, "session", "%s", session(s.SessionID))
+	switch {
+	case s.Secret.Equal(ristretto.NewScalar()) == 1:
+		field(w, "round", "done, the secrets are zeroized")
+	case len(absorbed) == 0:
+		field(w, "round", "1, waiting for the KeyGen1 messages of %s", listIDs(others))
+	default:
+		field(w, "round", "2, waiting for the KeyGen2 messages of %s", listIDs(others))
+	}
+	if len(absorbed) > 0 {
+		field(w, "absorbed", "KeyGen1 of %s", listIDs(absorbed))
+		if len(missing) > 0 {
+			field(w, "missing", "KeyGen1 of %s, the shares will not match", listIDs(missing))
+		}
+	}
+	for _, id := range absorbed {
+		data, _ := s.Commitments[id].MarshalBinary()
+		field(w, "commitments", "party %d %s", id, fingerprint(data))
+	}
+	if len(absorbed) > 0 && len(missing) == 0 {
+		field(w, "group key", "%x", s.CommitmentsSum.Constant().BytesEd25519())
+	}
+	field(w, "secrets", "polynomial and share redacted")
+}
+
+func describeSignerState(w io.Writer, file string, s *frost.SignerState) {
+	others := without(s.SignerIDs, s.SelfID)
+	identity := ristretto.NewIdentityElement()
+	var absorbed, missing party.IDSlice
+	for _, id := range others {
+		if signer, ok := s.Signers[id]; ok && signer.Di.Equal(identity) != 1 {
+			absorbed = append(absorbed, id)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	fmt.Fprintf(w, "%s: signer state of party %d\n", file, s.SelfID)
+	field(w, "signers", "%s", listIDs(s.SignerIDs))
+	field(w, "session", "%s", session(s.SessionID))
+	field(w, "group key", "%x", s.GroupKey.ToEd25519())
+	message := fmt.Sprintf("%d bytes, sha256 %s", len(s.Message), fingerprint(s.Message))
+	if s.Prehash {
+		message += ", SHA-512 digest signed with Ed25519ph"
+	}
+	field(w, "message", "%s", message)
+	if s.Request != nil {
+		field(w, "request", "%q", s.Request.ID)
+	}
+	switch {
+	case s.SecretKeyShare.Equal(ristretto.NewScalar()) == 1:
+		field(w, "round", "done or aborted, the secrets are zeroized")
+	case s.R.Equal(identity) == 1:
+		field(w, "round", "1, waiting for the Sign1 messages of %s", listIDs(others))
+	default:
+		field(w, "round", "2, waiting for the Sign2 messages of %s", listIDs(others))
+		field(w, "absorbed", "Sign1 of %s", listIDs(absorbed))
+		if len(missing) > 0 {
+			field(w, "missing", "Sign1 of %s, the signature will not verify", listIDs(missing))
+		}
+		field(w, "R", "%s", fingerprint(s.R.Bytes()))
+	}
+	for _, id := range s.SignerIDs {
+		if signer, ok := s.Signers[id]; ok && signer.Di.Equal(identity) != 1 {
+			field(w, "nonces", "party %d %s", id, fingerprint(append(signer.Di.Bytes(), signer.Ei.Bytes()...)))
+		}
+	}
+	field(w, "secrets", "share and nonces redacted")
+}
+
+func describeMessage(w io.Writer, file string, msg *frost.Message) {
+	to := "all parties"
+	if !msg.IsBroadcast() {
+		to = fmt.Sprintf("party %d", msg.To)
+	}
+	fmt.Fprintf(w, "%s: %s message from party %d to %s\n", file, msg.Type, msg.From, to)
+	round := msg.Round
+	if round == 0 {
+		round = msg.Type.Round()
+	}
+	field(w, "round", "%d", round)
+	field(w, "session", "%s", session(msg.SessionID))
+	switch {
+	case msg.KeyGen1 != nil:
+		data, _ := msg.KeyGen1.Commitments.MarshalBinary()
+		field(w, "commitments", "%s, threshold %d", fingerprint(data), msg.KeyGen1.Commitments.Degree())
+	case msg.Sign1 != nil:
+		field(w, "nonces", "%s", fingerprint(append(msg.Sign1.Di.Bytes(), msg.Sign1.Ei.Bytes()...)))
+	case msg.Sign2 != nil:
+		field(w, "share", "%s", fingerprint(msg.Sign2.Zi.Bytes()))
+	case msg.Sign0 != nil:
+		field(w, "digest", "%x", msg.Sign0.Digest[:8])
+	case msg.SignBatch1 != nil:
+		data, _ := msg.SignBatch1.MarshalBinary()
+		field(w, "nonces", "%s, %d messages", fingerprint(data), len(msg.SignBatch1.Nonces))
+	case msg.SignBatch2 != nil:
+		data, _ := msg.SignBatch2.MarshalBinary()
+		field(w, "shares", "%s, %d messages", fingerprint(data), len(msg.SignBatch2.Shares))
+	case msg.KeyGenEcho != nil:
+		field(w, "digest", "%x", msg.KeyGenEcho.Digest[:8])
+	case msg.Reshare1 != nil:
+		data, _ := msg.Reshare1.Commitments.MarshalBinary()
+		field(w, "commitments", "%s, threshold %d", fingerprint(data), msg.Reshare1.Commitments.Degree())
+	case msg.KeyGen2 != nil, msg.Reshare2 != nil, msg.Repair1 != nil, msg.Repair2 != nil:
+		field(w, "share", "redacted")
+	}
+}
+
+// field prints a line of a summary.
+func field(w io.Writer, name, format string, args ...interface{}) {
+	fmt.Fprintf(w, "  %-12s %s\n", name, fmt.Sprintf(format, args...))
+}
+
+// fingerprint returns the first 8 bytes of the SHA-256 of data in hex.
+func fingerprint(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// session returns the session ID in hex, or "none".
+func session(id frost.SessionID) string {
+	if id.IsZero() {
+		return "none"
+	}
+	return id.String()
+}
+
+// without returns ids without id.
+func without(ids party.IDSlice, id party.ID) party.IDSlice {
+	out := make(party.IDSlice, 0, len(ids))
+	for _, other := range ids {
+		if other != id {
+			out = append(out, other)
+		}
+	}
+	return out
+}
+
+// listIDs returns ids as a comma separated list.
+func listIDs(ids party.IDSlice) string {
+	if len(ids) == 0 {
+		return "none"
+	}
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = id.String()
+	}
+	return strings.Join(s, ", ")
+}
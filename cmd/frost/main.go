@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/party"
+)
+
+func writeFile(filename string, data []byte) error {
+	return os.WriteFile(filename, data, 0644)
+}
+
+// writeAtomic replaces filename with data by renaming a temporary file with
+// the permissions perm over it, so that a crash leaves the old or the new
+// content, and the data is never readable with wider permissions.
+func writeAtomic(filename string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	err = f.Chmod(perm)
+	if err == nil {
+		_, err = f.Write(data)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, filename)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+func readFile(filename string) ([]byte, error) {
+	return os.ReadFile(filename)
+}
+
+// readArtifact reads an artifact of the given kind from filename, and returns
+// its payload, see frost.DecodeArtifact.
+func readArtifact(filename string, kind frost.ArtifactKind) ([]byte, error) {
+	data, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return frost.DecodeArtifact(kind, data)
+}
+
+// parseIDs parses a comma separated list of party IDs.
+func parseIDs(s string) (party.IDSlice, error) {
+	var ids []party.ID
+	for _, field := range strings.Split(s, ",") {
+		id, err := party.FromString(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return party.NewIDSlice(ids), nil
+}
+
+// commands maps subcommand names to their implementation.
+// Each command receives the arguments following its name.
+var commands = map[string]func(args []string){
+	"approve":  approveCmd,
+	"attest":   attestCmd,
+	"audit":    auditCmd,
+	"bundle":   bundleCmd,
+	"change":   changeCmd,
+	"inspect":  inspectCmd,
+	"migrate":  migrateCmd,
+	"pubkey":   pubkeyCmd,
+	"retire":   retireCmd,
+	"sshca":    sshcaCmd,
+	"standing": standingCmd,
+	"status":   statusCmd,
+	"vectors":  vectorsCmd,
+	"x509ca":   x509caCmd,
+}
+
+func usage() {
+	fmt.Println("Usage: frost <command> [flags]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  approve   list, approve and deny the sign requests a frostd holds")
+	fmt.Println("  attest    create and verify signed attestations of files")
+	fmt.Println("  audit     verify hash-chained audit logs and prove what the group signed")
+	fmt.Println("  bundle    export a signed verification bundle of a group for third parties")
+	fmt.Println("  change    prepare changes of a group that are approved with its key")
+	fmt.Println("  inspect   summarize state and message files, or graph the progress of a session")
+	fmt.Println("  migrate   convert artifacts from earlier formats to the current ones")
+	fmt.Println("  pubkey    print the group key as PEM, an authorized_keys line or hex")
+	fmt.Println("  retire    record retired keys, refuse them and delete their shares")
+	fmt.Println("  sshca     issue OpenSSH user and host certificates under the group key")
+	fmt.Println("  standing  pre-approve requests of a party that match a policy")
+	fmt.Println("  status    show the health of a group, or the share files in a directory")
+	fmt.Println("  vectors   export test vectors from a seeded run")
+	fmt.Println("  x509ca    issue X.509 certificates and requests under the group key")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		return
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Println("Unknown command:", os.Args[1])
+		usage()
+		return
+	}
+	cmd(os.Args[2:])
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/migrate"
+)
+
+// secretKinds are the kinds of artifacts that hold secret shares or nonces.
+var secretKinds = map[migrate.Kind]bool{
+	migrate.KindSecretShareBinary: true,
+	migrate.KindSecretShareJSON:   true,
+	migrate.KindKeygenState:       true,
+	migrate.KindSignerState:       true,
+}
+
+func migrateCmd(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	var (
+		inPlace = fs.Bool("in-place", false, "Overwrite the input files, keeping a .bak copy with the permissions of the output")
+		dryRun  = fs.Bool("dry-run", false, "Only report the detected format of each file")
+		suffix  = fs.String("suffix", ".migrated", "Suffix of the output files when not migrating in place")
+	)
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fmt.Println("Usage: frost migrate [--in-place] [--dry-run] <file>...")
+		return
+	}
+
+	for _, file := range fs.Args() {
+		data, err := readFile(file)
+		if err != nil {
+			fmt.Println("Error reading file:", err)
+			continue
+		}
+
+		res, err := migrate.Migrate(data)
+		if err != nil {
+			fmt.Printf("%s: %v\n", file, err)
+			continue
+		}
+
+		status := "current"
+		switch {
+		case res.Legacy:
+			status = "legacy"
+		case res.Version < frost.ArtifactVersion:
+			status = fmt.Sprintf("version %d", res.Version)
+		}
+		fmt.Printf("%s: %s (%s)\n", file, res.Kind, status)
+		if *dryRun {
+			continue
+		}
+
+		// secret shares and states, and their backups, are readable by the owner only
+		perm := os.FileMode(0644)
+		if secretKinds[res.Kind] {
+			perm = 0600
+		}
+		write := func(filename string, data []byte) error { return writeAtomic(filename, data, perm) }
+		output := file + *suffix
+		if *inPlace {
+			if err := write(file+".bak", data); err != nil {
+				fmt.Println("Error writing backup:", err)
+				continue
+			}
+			output = file
+		}
+		if err := write(output, res.Artifact()); err != nil {
+			fmt.Println("Error writing file:", err)
+			continue
+		}
+		fmt.Printf("  written to %s\n", output)
+	}
+}
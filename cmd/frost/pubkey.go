@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+)
+
+// pubkeyCmd prints the group key of a public shares file in a standard format:
+//
+//	frost pubkey --shares public.json --format pem|ssh|hex [--comment frost-ca]
+func pubkeyCmd(args []string) {
+	fs := flag.NewFlagSet("pubkey", flag.ExitOnError)
+	var (
+		sharesFile = fs.String("shares", "", "Public shares file of the group")
+		format     = fs.String("format", "pem", "Output format: pem (PKIX), ssh (authorized_keys) or hex")
+		comment    = fs.String("comment", "", "Comment of the authorized_keys line")
+	)
+	fs.Parse(args)
+
+	if *sharesFile == "" {
+		fmt.Println("Usage: frost pubkey --shares public.json [--format pem|ssh|hex] [--comment <comment>]")
+		os.Exit(1)
+	}
+	data, err := readArtifact(*sharesFile, frost.ArtifactPublic)
+	if err != nil {
+		fmt.Println("Error reading shares:", err)
+		os.Exit(1)
+	}
+	var public eddsa.Public
+	if err := json.Unmarshal(data, &public); err != nil {
+		fmt.Println("Error decoding shares:", err)
+		os.Exit(1)
+	}
+
+	switch *format {
+	case "pem":
+		out, err := public.GroupKey.MarshalPEM()
+		if err != nil {
+			fmt.Println("Error encoding key:", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(out)
+	case "ssh":
+		os.Stdout.Write(public.GroupKey.MarshalOpenSSH(*comment))
+	case "hex":
+		fmt.Printf("%x\n", public.GroupKey.ToEd25519())
+	default:
+		fmt.Println("Unknown format:", *format)
+		os.Exit(1)
+	}
+}
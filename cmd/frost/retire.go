@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bartke/frost/governance"
+	"github.com/bartke/frost/retire"
+)
+
+// retireCmd keeps the tombstones of retired keys. The approval is a retire
+// change prepared and finished with frost change:
+//
+//	frost retire record --approval approval.json --shares public.json --dir tombstones \
+//	    --delete secret1.dat --grace 720h
+//	frost retire check --shares public.json --dir tombstones
+//	frost retire sweep --dir tombstones
+//
+// cmd/sign refuses to sign with keys that have a tombstone in its --tombstones directory.
+func retireCmd(args []string) {
+	usage := func() {
+		fmt.Println("Usage: frost retire record|check|sweep [flags]")
+	}
+	if len(args) == 0 {
+		usage()
+		return
+	}
+	switch args[0] {
+	case "record":
+		retireRecord(args[1:])
+	case "check":
+		retireCheck(args[1:])
+	case "sweep":
+		retireSweep(args[1:])
+	default:
+		usage()
+	}
+}
+
+func retireRecord(args []string) {
+	fs := flag.NewFlagSet("retire record", flag.ExitOnError)
+	var (
+		approvalFile = fs.String("approval", "approval.json", "Approval of the retire change")
+		sharesFile   = fs.String("shares", "", "Public shares file of the group")
+		pubKey       = fs.String("pubkey", "", "Hex encoded group key, instead of --shares")
+		dir          = fs.String("dir", "tombstones", "Directory of the tombstones")
+		del          = fs.String("delete", "", "Comma separated share files to delete after the grace period")
+		grace        = fs.Duration("grace", 30*24*time.Hour, "Time the share files are kept after the retirement")
+	)
+	fs.Parse(args)
+
+	pub, err := groupKey(*sharesFile, *pubKey)
+	if err != nil {
+		fmt.Println("Error reading group key:", err)
+		os.Exit(1)
+	}
+	data, err := readFile(*approvalFile)
+	if err != nil {
+		fmt.Println("Error reading approval:", err)
+		os.Exit(1)
+	}
+	var a governance.Approval
+	if err := json.Unmarshal(data, &a); err != nil {
+		fmt.Println("Error decoding approval:", err)
+		os.Exit(1)
+	}
+	ts, err := retire.New(pub, &a, time.Now())
+	if err != nil {
+		fmt.Println("Invalid retirement:", err)
+		os.Exit(1)
+	}
+	if *del != "" {
+		ts.Schedule(strings.Split(*del, ","), *grace)
+	}
+
+	s, err := retire.OpenStore(*dir)
+	if err != nil {
+		fmt.Println("Error opening tombstones:", err)
+		os.Exit(1)
+	}
+	if err := s.Record(ts); err != nil {
+		fmt.Println("Error recording tombstone:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Key %s retired\n", ts.Group)
+	if len(ts.Shares) > 0 {
+		fmt.Printf("%d share files are deleted by frost retire sweep after %s\n", len(ts.Shares), ts.DeleteAfter.Format(time.RFC3339))
+	}
+}
+
+func retireCheck(args []string) {
+	fs := flag.NewFlagSet("retire check", flag.ExitOnError)
+	var (
+		sharesFile = fs.String("shares", "", "Public shares file of the group")
+		pubKey     = fs.String("pubkey", "", "Hex encoded group key, instead of --shares")
+		dir        = fs.String("dir", "tombstones", "Directory of the tombstones")
+	)
+	fs.Parse(args)
+
+	pub, err := groupKey(*sharesFile, *pubKey)
+	if err != nil {
+		fmt.Println("Error reading group key:", err)
+		os.Exit(1)
+	}
+	s, err := retire.OpenStore(*dir)
+	if err != nil {
+		fmt.Println("Error opening tombstones:", err)
+		os.Exit(1)
+	}
+	ts, ok, err := s.Lookup(pub)
+	if err != nil {
+		fmt.Println("Invalid tombstone:", err)
+		os.Exit(1)
+	}
+	if !ok {
+		fmt.Println("The key is not retired")
+		return
+	}
+	c, _ := governance.ParseBody(ts.Approval.Body)
+	fmt.Printf("The key was retired by change %d on %s\n", c.Sequence, ts.RetiredAt.Format(time.RFC3339))
+	switch {
+	case ts.Deleted:
+		fmt.Println("Its share files were deleted")
+	case len(ts.Shares) > 0:
+		fmt.Printf("Its share files are deleted after %s\n", ts.DeleteAfter.Format(time.RFC3339))
+	}
+	os.Exit(2)
+}
+
+func retireSweep(args []string) {
+	fs := flag.NewFlagSet("retire sweep", flag.ExitOnError)
+	dir := fs.String("dir", "tombstones", "Directory of the tombstones")
+	fs.Parse(args)
+
+	s, err := retire.OpenStore(*dir)
+	if err != nil {
+		fmt.Println("Error opening tombstones:", err)
+		os.Exit(1)
+	}
+	deleted, err := s.Sweep(time.Now())
+	for _, path := range deleted {
+		fmt.Println("Deleted", path)
+	}
+	if err != nil {
+		fmt.Println("Error deleting shares:", err)
+		os.Exit(1)
+	}
+	if len(deleted) == 0 {
+		fmt.Println("Nothing to delete")
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/sshca"
+	"golang.org/x/crypto/ssh"
+)
+
+// The SSH CA workflow mirrors attest:
+//
+//	frost sshca prepare --shares public.json --key id_ed25519.pub --principals alice --validity 24h --out cert.body
+//	    (sign cert.body with cmd/sign --message cert.body)
+//	frost sshca finish --body cert.body --signature <hex> --out id_ed25519-cert.pub
+func sshcaCmd(args []string) {
+	usage := func() {
+		fmt.Println("Usage: frost sshca prepare|finish [flags]")
+	}
+	if len(args) == 0 {
+		usage()
+		return
+	}
+	switch args[0] {
+	case "prepare":
+		sshcaPrepare(args[1:])
+	case "finish":
+		sshcaFinish(args[1:])
+	default:
+		usage()
+	}
+}
+
+func sshcaPrepare(args []string) {
+	fs := flag.NewFlagSet("sshca prepare", flag.ExitOnError)
+	var (
+		sharesFile = fs.String("shares", "", "Public shares file of the group, whose key is the CA key")
+		keyFile    = fs.String("key", "", "OpenSSH public key file to certify")
+		host       = fs.Bool("host", false, "Issue a host certificate instead of a user certificate")
+		keyID      = fs.String("id", "", "Key ID of the certificate (default: the comment of the key)")
+		principals = fs.String("principals", "", "Comma separated user or host names the certificate is valid for")
+		validity   = fs.Duration("validity", 24*time.Hour, "Validity of the certificate from now, 0 for forever")
+		serial     = fs.Uint64("serial", 0, "Serial number of the certificate")
+		out        = fs.String("out", "cert.body", "Output file of the data to sign")
+	)
+	fs.Parse(args)
+
+	if *sharesFile == "" || *keyFile == "" {
+		fmt.Println("--shares and --key are required")
+		os.Exit(1)
+	}
+	data, err := readArtifact(*sharesFile, frost.ArtifactPublic)
+	if err != nil {
+		fmt.Println("Error reading shares:", err)
+		os.Exit(1)
+	}
+	var public eddsa.Public
+	if err := json.Unmarshal(data, &public); err != nil {
+		fmt.Println("Error decoding shares:", err)
+		os.Exit(1)
+	}
+	if data, err = readFile(*keyFile); err != nil {
+		fmt.Println("Error reading key:", err)
+		os.Exit(1)
+	}
+	key, comment, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		fmt.Println("Error parsing key:", err)
+		os.Exit(1)
+	}
+
+	opts := sshca.Options{
+		CertType: ssh.UserCert,
+		KeyID:    *keyID,
+		Serial:   *serial,
+		// allow for clocks of servers that are slightly behind
+		ValidAfter: time.Now().Add(-5 * time.Minute),
+	}
+	if opts.KeyID == "" {
+		opts.KeyID = comment
+	}
+	if *principals != "" {
+		opts.Principals = strings.Split(*principals, ",")
+	}
+	if *validity > 0 {
+		opts.ValidBefore = time.Now().Add(*validity)
+	}
+	if *host {
+		opts.CertType = ssh.HostCert
+	} else {
+		opts.Extensions = sshca.DefaultUserExtensions
+	}
+
+	cert, err := sshca.New(key, public.GroupKey, opts)
+	if err != nil {
+		fmt.Println("Invalid certificate:", err)
+		os.Exit(1)
+	}
+	if err := writeFile(*out, sshca.SignedData(cert)); err != nil {
+		fmt.Println("Error writing data to sign:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Sign %s as the message, then run frost sshca finish --body %s --signature <hex>\n", *out, *out)
+}
+
+func sshcaFinish(args []string) {
+	fs := flag.NewFlagSet("sshca finish", flag.ExitOnError)
+	var (
+		bodyFile  = fs.String("body", "cert.body", "Data written by frost sshca prepare")
+		signature = fs.String("signature", "", "Hex encoded signature over the data")
+		out       = fs.String("out", "id_ed25519-cert.pub", "Output file of the certificate")
+	)
+	fs.Parse(args)
+
+	body, err := readFile(*bodyFile)
+	if err != nil {
+		fmt.Println("Error reading data:", err)
+		os.Exit(1)
+	}
+	sig, err := hex.DecodeString(strings.TrimSpace(*signature))
+	if err != nil {
+		fmt.Println("Error decoding signature:", err)
+		os.Exit(1)
+	}
+	cert, err := sshca.Attach(body, sig)
+	if err != nil {
+		fmt.Println("Invalid certificate:", err)
+		os.Exit(1)
+	}
+	if err := writeFile(*out, ssh.MarshalAuthorizedKey(cert)); err != nil {
+		fmt.Println("Error writing certificate:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Certificate %q written to %s\n", cert.KeyId, *out)
+}
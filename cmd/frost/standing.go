@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/standing"
+)
+
+// standingCmd creates a standing instruction of a party, which is posted to
+// /v1/standing of its daemon:
+//
+//	frost standing grant --secret secret1.dat --shares public.json --id rent \
+//	    --chain example-1 --match to=addr1 --max amount=1000 --ttl 720h --max-uses 12
+func standingCmd(args []string) {
+	if len(args) == 0 || args[0] != "grant" {
+		fmt.Println("Usage: frost standing grant [flags]")
+		return
+	}
+
+	fs := flag.NewFlagSet("standing grant", flag.ExitOnError)
+	match, max := make(params), make(params)
+	var (
+		secretFile = fs.String("secret", "", "Secret share file of the party that pre-approves")
+		sharesFile = fs.String("shares", "", "Public shares file of the group")
+		id         = fs.String("id", "", "Name of the instruction")
+		format     = fs.String("format", "", "Required format of the requests")
+		chain      = fs.String("chain", "", "Required chain of the requests")
+		purpose    = fs.String("purpose", "", "Required purpose of the requests")
+		ttl        = fs.Duration("ttl", 24*time.Hour, "Time until the instruction expires")
+		maxUses    = fs.Uint64("max-uses", 0, "Maximum number of requests signed under the instruction (default: no limit)")
+		out        = fs.String("out", "grant.json", "Output file of the grant")
+	)
+	fs.Var(match, "match", "Required metadata of the requests as key=value, may be repeated")
+	fs.Var(max, "max", "Maximum integer metadata of the requests as key=value, may be repeated")
+	fs.Parse(args[1:])
+
+	if *secretFile == "" || *sharesFile == "" || *id == "" {
+		fmt.Println("--secret, --shares and --id are required")
+		os.Exit(1)
+	}
+	secretData, err := readArtifact(*secretFile, frost.ArtifactSecretShare)
+	if err != nil {
+		fmt.Println("Error reading secret:", err)
+		os.Exit(1)
+	}
+	var secret eddsa.SecretShare
+	if err := secret.UnmarshalBinary(secretData); err != nil {
+		fmt.Println("Error decoding secret:", err)
+		os.Exit(1)
+	}
+	sharesData, err := readArtifact(*sharesFile, frost.ArtifactPublic)
+	if err != nil {
+		fmt.Println("Error reading shares:", err)
+		os.Exit(1)
+	}
+	var public eddsa.Public
+	if err := json.Unmarshal(sharesData, &public); err != nil {
+		fmt.Println("Error decoding shares:", err)
+		os.Exit(1)
+	}
+
+	g, err := standing.NewGrant(&secret, &public, standing.Instruction{
+		ID: *id,
+		Policy: standing.Policy{
+			Format:  *format,
+			Chain:   *chain,
+			Purpose: *purpose,
+			Match:   match,
+			Max:     max,
+		},
+		Expires: time.Now().Add(*ttl).Truncate(time.Second),
+		MaxUses: *maxUses,
+	})
+	if err != nil {
+		fmt.Println("Error creating grant:", err)
+		os.Exit(1)
+	}
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		fmt.Println("Error encoding grant:", err)
+		os.Exit(1)
+	}
+	if err := writeFile(*out, data); err != nil {
+		fmt.Println("Error writing grant:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Standing instruction %q of party %d, valid until %s, written to %s\n",
+		*id, secret.ID, g.Instruction.Expires.Format(time.RFC3339), *out)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bartke/frost/health"
+)
+
+func statusCmd(args []string) {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	var (
+		url     = fs.String("url", "", "Base URL of a signer daemon, e.g. http://127.0.0.1:8081")
+		dir     = fs.String("dir", "", "Directory to search for share files")
+		maxAge  = fs.Duration("max-age", 5*time.Minute, "Maximum age of a proof of possession for a party to count as healthy")
+		jsonOut = fs.Bool("json", false, "Print the raw JSON")
+	)
+	fs.Parse(args)
+
+	switch {
+	case *url != "":
+		daemonStatus(*url, *maxAge, *jsonOut)
+	case *dir != "":
+		discoverStatus(*dir, *jsonOut)
+	default:
+		fmt.Println("Usage: frost status --url <daemon> | --dir <directory> [--json]")
+	}
+}
+
+func daemonStatus(url string, maxAge time.Duration, jsonOut bool) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimRight(url, "/") + "/v1/status")
+	if err != nil {
+		fmt.Println("Error fetching status:", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Println("Error fetching status:", resp.Status)
+		os.Exit(1)
+	}
+	var status health.GroupStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		fmt.Println("Error decoding status:", err)
+		os.Exit(1)
+	}
+	if jsonOut {
+		printJSON(status)
+		return
+	}
+
+	now := time.Now()
+	fmt.Printf("Group %s, threshold %d, reported by party %d\n", status.Group, status.Threshold, status.Self)
+	fmt.Printf("Pending sessions: %d\n", status.PendingSessions)
+	if status.LastRefresh != nil {
+		fmt.Printf("Last refresh: %s\n", status.LastRefresh.Format(time.RFC3339))
+	} else {
+		fmt.Println("Last refresh: never")
+	}
+	if status.Quorum(now, maxAge) {
+		fmt.Println("Quorum: available")
+	} else {
+		fmt.Println("Quorum: NOT available")
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PARTY\tENDPOINT\tREACHABLE\tLAST SEEN\tLAST PROOF\tERROR")
+	for _, p := range status.Parties {
+		fmt.Fprintf(w, "%d\t%s\t%t\t%s\t%s\t%s\n", p.ID, p.Endpoint, p.Reachable, ago(now, p.LastSeen), ago(now, p.LastProof), p.Error)
+	}
+	w.Flush()
+
+	if len(status.Backups) > 0 {
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "BACKUP\tVERIFIED\tERROR")
+		for _, b := range status.Backups {
+			fmt.Fprintf(w, "%s\t%t\t%s\n", b.Path, b.Verified, b.Error)
+		}
+		w.Flush()
+	}
+}
+
+func discoverStatus(dir string, jsonOut bool) {
+	files, err := health.Discover(dir)
+	if err != nil {
+		fmt.Println("Error searching share files:", err)
+		os.Exit(1)
+	}
+	if jsonOut {
+		printJSON(files)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tKIND\tPARTY\tGROUP\tVERIFIED")
+	for _, f := range files {
+		id := "-"
+		if f.ID != 0 {
+			id = f.ID.String()
+		}
+		group := f.Group
+		if group == "" {
+			group = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", f.Path, f.Kind, id, group, f.Verified)
+	}
+	w.Flush()
+}
+
+// ago formats the time since t, or "never".
+func ago(now time.Time, t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return now.Sub(*t).Truncate(time.Second).String() + " ago"
+}
+
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Println("Error encoding JSON:", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/party"
+)
+
+func vectorsCmd(args []string) {
+	if len(args) == 0 || args[0] != "export" {
+		fmt.Println("Usage: frost vectors export [flags]")
+		return
+	}
+
+	fs := flag.NewFlagSet("vectors export", flag.ExitOnError)
+	var (
+		seed      = fs.String("seed", "", "Hex encoded seed from which all randomness is derived")
+		n         = fs.Uint("n", 3, "Number of parties")
+		threshold = fs.Uint("t", 1, "Threshold, signing requires t+1 parties")
+		signers   = fs.String("signers", "", "Comma separated signer IDs (default: the first t+1 parties)")
+		message   = fs.String("message", "test", "Message to sign")
+		hexMsg    = fs.Bool("hex", false, "The message is hex encoded")
+		out       = fs.String("out", "vectors", "Output directory")
+	)
+	fs.Parse(args[1:])
+
+	seedBytes, err := hex.DecodeString(*seed)
+	if err != nil || len(seedBytes) == 0 {
+		fmt.Println("A hex encoded --seed is required")
+		os.Exit(1)
+	}
+
+	msg := []byte(*message)
+	if *hexMsg {
+		if msg, err = hex.DecodeString(*message); err != nil {
+			fmt.Println("Error decoding message:", err)
+			os.Exit(1)
+		}
+	}
+
+	var signerIDs party.IDSlice
+	if *signers == "" {
+		for id := party.ID(1); id <= party.ID(*threshold+1); id++ {
+			signerIDs = append(signerIDs, id)
+		}
+	} else if signerIDs, err = parseIDs(*signers); err != nil {
+		fmt.Println("Error parsing signer IDs:", err)
+		os.Exit(1)
+	}
+
+	v, err := frost.GenerateVectors(seedBytes, party.Size(*n), party.Size(*threshold), signerIDs, msg)
+	if err != nil {
+		fmt.Println("Error generating vectors:", err)
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		fmt.Println("Error creating output directory:", err)
+		os.Exit(1)
+	}
+
+	files := map[string]interface{}{
+		"config.json":  v.Config,
+		"keygen.json":  v.Keygen,
+		"group.json":   v.Group,
+		"signing.json": v.Signing,
+	}
+	for name, section := range files {
+		data, err := json.MarshalIndent(section, "", "  ")
+		if err != nil {
+			fmt.Println("Error encoding vectors:", err)
+			os.Exit(1)
+		}
+		if err := writeFile(filepath.Join(*out, name), append(data, '\n')); err != nil {
+			fmt.Println("Error writing file:", err)
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("Vectors written to %s\n", *out)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/x509ca"
+)
+
+// The X.509 CA workflow mirrors attest:
+//
+//	frost x509ca prepare --shares public.json --root --cn "Threshold Root CA" --out cert.tbs
+//	frost x509ca prepare --shares public.json --csr leaf.csr --parent root.pem --out cert.tbs
+//	frost x509ca prepare --shares public.json --request --cn "Threshold Intermediate CA" --out csr.tbs
+//	    (sign the .tbs file with cmd/sign --message cert.tbs)
+//	frost x509ca finish --shares public.json --tbs cert.tbs --signature <hex> [--request] --out cert.pem
+func x509caCmd(args []string) {
+	usage := func() {
+		fmt.Println("Usage: frost x509ca prepare|finish [flags]")
+	}
+	if len(args) == 0 {
+		usage()
+		return
+	}
+	switch args[0] {
+	case "prepare":
+		x509caPrepare(args[1:])
+	case "finish":
+		x509caFinish(args[1:])
+	default:
+		usage()
+	}
+}
+
+// readGroupKey returns the group key of a public shares file.
+func readGroupKey(sharesFile string) (*eddsa.PublicKey, error) {
+	data, err := readArtifact(sharesFile, frost.ArtifactPublic)
+	if err != nil {
+		return nil, err
+	}
+	var public eddsa.Public
+	if err := json.Unmarshal(data, &public); err != nil {
+		return nil, err
+	}
+	return public.GroupKey, nil
+}
+
+// readPEM returns the DER bytes of the first PEM block of type typ in file.
+func readPEM(file, typ string) ([]byte, error) {
+	data, err := readFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != typ {
+		return nil, fmt.Errorf("%s: no %s PEM block", file, typ)
+	}
+	return block.Bytes, nil
+}
+
+func x509caPrepare(args []string) {
+	fs := flag.NewFlagSet("x509ca prepare", flag.ExitOnError)
+	var (
+		sharesFile = fs.String("shares", "", "Public shares file of the group, whose key is the CA key")
+		root       = fs.Bool("root", false, "Prepare a self-signed root certificate of the group key")
+		request    = fs.Bool("request", false, "Prepare a certificate request for the group key")
+		csrFile    = fs.String("csr", "", "PEM certificate request of the key to certify")
+		parentFile = fs.String("parent", "", "PEM certificate of the group key that issues the certificate")
+		cn         = fs.String("cn", "", "Common name of the root or the request")
+		isCA       = fs.Bool("ca", false, "Issue the certificate of --csr as an intermediate CA")
+		validity   = fs.Duration("validity", 90*24*time.Hour, "Validity of the certificate from now")
+		out        = fs.String("out", "cert.tbs", "Output file of the data to sign")
+	)
+	fs.Parse(args)
+
+	if *sharesFile == "" {
+		fmt.Println("--shares is required")
+		os.Exit(1)
+	}
+	ca, err := readGroupKey(*sharesFile)
+	if err != nil {
+		fmt.Println("Error reading shares:", err)
+		os.Exit(1)
+	}
+
+	var tbs []byte
+	switch {
+	case *request:
+		template := &x509.CertificateRequest{Subject: pkix.Name{CommonName: *cn}}
+		tbs, err = x509ca.PrepareRequest(template, ca)
+	case *root:
+		template := newTemplate(pkix.Name{CommonName: *cn}, *validity)
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		template.BasicConstraintsValid, template.IsCA = true, true
+		tbs, err = x509ca.PrepareCertificate(template, template, ed25519.PublicKey(ca.ToEd25519()), ca)
+	case *csrFile != "" && *parentFile != "":
+		tbs, err = prepareFromRequest(*csrFile, *parentFile, *isCA, *validity, ca)
+	default:
+		fmt.Println("One of --root, --request, or --csr with --parent is required")
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Println("Invalid certificate:", err)
+		os.Exit(1)
+	}
+	if err := writeFile(*out, tbs); err != nil {
+		fmt.Println("Error writing data to sign:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Sign %s as the message, then run frost x509ca finish --tbs %s --signature <hex>\n", *out, *out)
+}
+
+// newTemplate returns a certificate template for subject with a random serial number.
+func newTemplate(subject pkix.Name, validity time.Duration) *x509.Certificate {
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      subject,
+		// allow for clocks that are slightly behind
+		NotBefore: now.Add(-5 * time.Minute),
+		NotAfter:  now.Add(validity),
+	}
+}
+
+// prepareFromRequest returns the TBS certificate of the key and names of the
+// request in csrFile, issued by the certificate in parentFile.
+func prepareFromRequest(csrFile, parentFile string, isCA bool, validity time.Duration, ca *eddsa.PublicKey) ([]byte, error) {
+	der, err := readPEM(csrFile, "CERTIFICATE REQUEST")
+	if err != nil {
+		return nil, err
+	}
+	req, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.CheckSignature(); err != nil {
+		return nil, err
+	}
+	if der, err = readPEM(parentFile, "CERTIFICATE"); err != nil {
+		return nil, err
+	}
+	parent, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	if !parent.IsCA {
+		return nil, errors.New("the parent certificate is not a CA")
+	}
+
+	template := newTemplate(req.Subject, validity)
+	template.DNSNames = req.DNSNames
+	template.IPAddresses = req.IPAddresses
+	template.EmailAddresses = req.EmailAddresses
+	template.URIs = req.URIs
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		template.BasicConstraintsValid, template.IsCA = true, true
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	}
+	return x509ca.PrepareCertificate(template, parent, req.PublicKey, ca)
+}
+
+func x509caFinish(args []string) {
+	fs := flag.NewFlagSet("x509ca finish", flag.ExitOnError)
+	var (
+		sharesFile = fs.String("shares", "", "Public shares file of the group")
+		tbsFile    = fs.String("tbs", "cert.tbs", "Data written by frost x509ca prepare")
+		request    = fs.Bool("request", false, "The data is that of a certificate request")
+		signature  = fs.String("signature", "", "Hex encoded signature over the data")
+		out        = fs.String("out", "cert.pem", "Output file of the PEM certificate or request")
+	)
+	fs.Parse(args)
+
+	if *sharesFile == "" {
+		fmt.Println("--shares is required")
+		os.Exit(1)
+	}
+	ca, err := readGroupKey(*sharesFile)
+	if err != nil {
+		fmt.Println("Error reading shares:", err)
+		os.Exit(1)
+	}
+	tbs, err := readFile(*tbsFile)
+	if err != nil {
+		fmt.Println("Error reading data:", err)
+		os.Exit(1)
+	}
+	sig, err := hex.DecodeString(strings.TrimSpace(*signature))
+	if err != nil {
+		fmt.Println("Error decoding signature:", err)
+		os.Exit(1)
+	}
+
+	typ, name := "CERTIFICATE", "Certificate"
+	var der []byte
+	if *request {
+		typ, name = "CERTIFICATE REQUEST", "Certificate request"
+		der, err = x509ca.AssembleRequest(tbs, sig, ca)
+	} else {
+		der, err = x509ca.AssembleCertificate(tbs, sig, ca)
+	}
+	if err != nil {
+		fmt.Println("Invalid certificate:", err)
+		os.Exit(1)
+	}
+	if err := writeFile(*out, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})); err != nil {
+		fmt.Println("Error writing certificate:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s written to %s\n", name, *out)
+}
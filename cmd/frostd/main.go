@@ -0,0 +1,544 @@
+// Command frostd is a long-running signer daemon. It holds the secret share of
+// one party and serves the Frost service of package grpcserver, so that it
+// takes part in the key generation, signing and refresh sessions a coordinator
+// such as grpcserver.Client starts, without an operator shuttling files
+// between the rounds.
+//
+// The daemon listens on a unix socket, --listen unix:/run/frostd.sock, which
+// only its user can connect to, or on TCP with TLS, --listen :7000 --tls-cert
+// cert.pem --tls-key key.pem. The flags can also be given in a JSON config
+// file with --config, such as
+//
+//	{
+//	  "id": "1",
+//	  "secret": "/var/lib/frostd/secret.dat",
+//	  "shares": "/var/lib/frostd/public.json",
+//	  "listen": "unix:/run/frostd/frostd.sock",
+//	  "passphrase_file": "/etc/frostd/passphrase",
+//	  "audit_log": "/var/lib/frostd/audit.log",
+//	  "policy": "/etc/frostd/policy.json",
+//	  "approval_socket": "/run/frostd/approval.sock",
+//	  "timeout": "1m",
+//	  "max_sessions": 64,
+//	  "max_message_size": 1048576,
+//	  "peer_rate": 10,
+//	  "peer_burst": 20
+//	}
+//
+// and flags given on the command line override the file.
+//
+// Until the secret share file exists, the daemon only takes part in a key
+// generation, and stores the new share there. A refresh replaces the files
+// with the new shares. With a passphrase, the secret share is sealed.
+//
+// With --wrap, or "wrap" in the config file, the secret share is instead
+// wrapped with a KMS key or age identity, such as awskms:alias/frostd,
+// gcpkms:projects/p/locations/l/keyRings/r/cryptoKeys/k or
+// age:/etc/frostd/identity.txt, see package keywrap. The daemon then never
+// holds the share in memory between sessions: it is unwrapped from the file at
+// the start of every sign and refresh session, and zeroized when it ends, so
+// a share of another party is only noticed then. A sealed or plaintext share
+// found at startup is wrapped in place.
+//
+// With --audit-log, or "audit_log" in the config file, every session is
+// recorded in a hash-chained audit log, see package audit, which
+// "frost audit verify" checks. The daemon refuses to start if the chain is
+// broken.
+//
+// With --policy, or "policy" in the config file, the daemon only signs the
+// requests a JSON rule set of package policy allows, such as messages with
+// given prefixes, within office hours, at a limited rate, or with required
+// co-signers. A denied request is recorded in the audit log.
+//
+// With --approval-socket, or "approval_socket" in the config file, an
+// operator keeps a veto over every signature: a sign request is held until it
+// is approved with frost approve on that socket, or denied once the session
+// timeout passes. Requests the rule set of --auto-approve, or
+// "auto_approve", allows are approved without the operator.
+//
+// The daemon bounds what a misbehaving coordinator or peer can make it spend:
+// it runs at most --max-sessions sessions at once, 64 by default, each of
+// which ends after --timeout, drops messages larger than --max-message-size,
+// 1 MiB by default, and, with --peer-rate, more than that many messages per
+// second from any one peer, see package limits. Messages from parties outside
+// a session are dropped first, and gRPC refuses requests larger than
+// --max-message-size before decoding them. A session refused or ended by
+// a limit fails with codes.ResourceExhausted.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/approval"
+	"github.com/bartke/frost/audit"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/grpcserver"
+	"github.com/bartke/frost/internal/cli"
+	"github.com/bartke/frost/keywrap"
+	"github.com/bartke/frost/limits"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/policy"
+	"github.com/bartke/frost/sealed"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// config holds the settings of the daemon, read from --config and the flags.
+// requestOverhead bounds the fields of a request around its protocol message,
+// such as the message a SignStart asks to sign, on top of --max-message-size.
+const requestOverhead = 64 << 10
+
+type config struct {
+	ID             party.ID `json:"id"`
+	Secret         string   `json:"secret"`
+	Shares         string   `json:"shares"`
+	Listen         string   `json:"listen"`
+	TLSCert        string   `json:"tls_cert"`
+	TLSKey         string   `json:"tls_key"`
+	PassphraseFile string   `json:"passphrase_file"`
+	Wrap           string   `json:"wrap"`
+	AuditLog       string   `json:"audit_log"`
+	Policy         string   `json:"policy"`
+	ApprovalSocket string   `json:"approval_socket"`
+	AutoApprove    string   `json:"auto_approve"`
+	Timeout        string   `json:"timeout"`
+	MaxSessions    int      `json:"max_sessions"`
+	MaxMessageSize int      `json:"max_message_size"`
+	PeerRate       float64  `json:"peer_rate"`
+	PeerBurst      int      `json:"peer_burst"`
+}
+
+// readConfig returns the config in file, or the defaults if file is empty.
+func readConfig(file string) (*config, error) {
+	cfg := &config{Listen: "unix:frostd.sock", Timeout: "1m", MaxSessions: 64, MaxMessageSize: 1 << 20}
+	if file == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return cfg, nil
+}
+
+func main() {
+	var (
+		configFile = flag.String("config", "", "JSON config file, overridden by the flags given")
+		id         = flag.String("id", "", "Party ID of this signer")
+		secretFile = flag.String("secret", "", "Secret share file, written by a key generation if it does not exist")
+		sharesFile = flag.String("shares", "", "Public shares file of the group, written by a key generation if it does not exist")
+		listen     = flag.String("listen", "", "unix:<path> of a socket, or TCP address to listen on with TLS (default: unix:frostd.sock)")
+		tlsCert    = flag.String("tls-cert", "", "TLS certificate file, required for TCP")
+		tlsKey     = flag.String("tls-key", "", "TLS key file, required for TCP")
+		passFile   = flag.String("passphrase-file", "", "File holding the passphrase the secret share is sealed with")
+		wrap       = flag.String("wrap", "", "Wrap the secret share with a KMS key or age identity: awskms:<key>, gcpkms:<key name> or age:<identity file>")
+		auditLog   = flag.String("audit-log", "", "Hash-chained log every session is recorded in")
+		policyFile = flag.String("policy", "", "JSON rule set of the requests to sign, see package policy")
+		approvals  = flag.String("approval-socket", "", "Unix socket on which frost approve decides the sign requests, which are held until then")
+		autoRules  = flag.String("auto-approve", "", "JSON rule set of the sign requests approved without an operator, see package policy")
+		timeout    = flag.String("timeout", "", "Maximum duration of a session (default: 1m)")
+		maxSess    = flag.Int("max-sessions", 0, "Maximum number of sessions running at once, 0 for no limit (default: 64)")
+		maxSize    = flag.Int("max-message-size", 0, "Maximum size in bytes of a protocol message, 0 for no limit (default: 1048576)")
+		peerRate   = flag.Float64("peer-rate", 0, "Maximum number of messages per second from each peer, 0 for no limit")
+		peerBurst  = flag.Int("peer-burst", 0, "Maximum number of messages a peer may send at once, with --peer-rate (default: the rate, rounded up)")
+	)
+	flag.Parse()
+
+	cfg, err := readConfig(*configFile)
+	if err != nil {
+		log.Fatalf("Failed to read config: %v", err)
+	}
+	var idErr error
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "id":
+			idErr = cfg.ID.UnmarshalText([]byte(*id))
+		case "secret":
+			cfg.Secret = *secretFile
+		case "shares":
+			cfg.Shares = *sharesFile
+		case "listen":
+			cfg.Listen = *listen
+		case "tls-cert":
+			cfg.TLSCert = *tlsCert
+		case "tls-key":
+			cfg.TLSKey = *tlsKey
+		case "passphrase-file":
+			cfg.PassphraseFile = *passFile
+		case "wrap":
+			cfg.Wrap = *wrap
+		case "audit-log":
+			cfg.AuditLog = *auditLog
+		case "policy":
+			cfg.Policy = *policyFile
+		case "approval-socket":
+			cfg.ApprovalSocket = *approvals
+		case "auto-approve":
+			cfg.AutoApprove = *autoRules
+		case "timeout":
+			cfg.Timeout = *timeout
+		case "max-sessions":
+			cfg.MaxSessions = *maxSess
+		case "max-message-size":
+			cfg.MaxMessageSize = *maxSize
+		case "peer-rate":
+			cfg.PeerRate = *peerRate
+		case "peer-burst":
+			cfg.PeerBurst = *peerBurst
+		}
+	})
+	if idErr != nil {
+		log.Fatalf("Invalid party ID: %v", idErr)
+	}
+	if cfg.ID == 0 || cfg.Secret == "" || cfg.Shares == "" {
+		fmt.Println("--id, --secret and --shares are required")
+		os.Exit(1)
+	}
+	sessionTimeout, err := time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		log.Fatalf("Invalid timeout: %v", err)
+	}
+
+	var passphrase []byte
+	if cfg.PassphraseFile != "" {
+		if passphrase, err = cli.ReadPassphrase(cfg.PassphraseFile, false); err != nil {
+			log.Fatalf("Failed to read passphrase: %v", err)
+		}
+	}
+	keys := &keyFiles{secret: cfg.Secret, shares: cfg.Shares, passphrase: passphrase}
+	if cfg.Wrap != "" {
+		if keys.wrapper, err = keywrap.Parse(cfg.Wrap); err != nil {
+			log.Fatalf("Failed to set up key wrapping: %v", err)
+		}
+	}
+	public, secret, err := keys.load()
+	if err != nil {
+		log.Fatalf("Failed to load key: %v", err)
+	}
+	if secret != nil && secret.ID != cfg.ID {
+		log.Fatalf("The secret share is that of party %d, not %d", secret.ID, cfg.ID)
+	}
+	if keys.wrapper != nil && secret != nil {
+		// a share stored before wrapping was set up
+		if err := keys.write(public, secret); err != nil {
+			log.Fatalf("Failed to wrap the secret share: %v", err)
+		}
+		log.Printf("Wrapped the secret share with %s", keys.wrapper.Provider())
+		secret.Zeroize()
+		secret = nil
+	}
+
+	s := grpcserver.NewServer(cfg.ID, public, secret)
+	s.Timeout = sessionTimeout
+	s.Limiter = limits.New(limits.Limits{
+		MaxSessions:    cfg.MaxSessions,
+		MaxMessageSize: cfg.MaxMessageSize,
+		Rate:           cfg.PeerRate,
+		Burst:          cfg.PeerBurst,
+	})
+	s.OnKeygen = keys.create
+	s.OnRefresh = keys.replace
+	if keys.wrapper != nil {
+		s.LoadSecret = keys.unwrap
+	}
+	if cfg.Policy != "" {
+		rules, err := policy.Load(cfg.Policy)
+		if err != nil {
+			log.Fatalf("Failed to load the policy: %v", err)
+		}
+		s.Policy = rules
+		log.Printf("Signing the requests allowed by %d rules of %s", len(rules.Rules), cfg.Policy)
+	}
+	if cfg.AutoApprove != "" && cfg.ApprovalSocket == "" {
+		log.Fatal("--auto-approve requires --approval-socket")
+	}
+	if cfg.ApprovalSocket != "" {
+		queue := &approval.Queue{Timeout: sessionTimeout}
+		if cfg.AutoApprove != "" {
+			rules, err := policy.Load(cfg.AutoApprove)
+			if err != nil {
+				log.Fatalf("Failed to load the auto-approve rules: %v", err)
+			}
+			queue.AutoApprove = rules
+		}
+		queue.Notify = func(p *approval.Pending) {
+			log.Printf("Sign request %s of signers %v with message SHA-256 %s is waiting for approval: frost approve --socket %s %s",
+				p.ID, p.Signers, p.MessageDigest, cfg.ApprovalSocket, p.ID)
+		}
+		lis, err := listenUnix(cfg.ApprovalSocket)
+		if err != nil {
+			log.Fatalf("Failed to listen for approvals: %v", err)
+		}
+		go func() {
+			if err := http.Serve(lis, approval.Handler(queue)); err != nil {
+				log.Printf("Stopped serving approvals: %v", err)
+			}
+		}()
+		if s.Policy != nil {
+			s.Policy = policy.All(s.Policy, queue)
+		} else {
+			s.Policy = queue
+		}
+	}
+	if cfg.AuditLog != "" {
+		if s.Audit, err = audit.Open(cfg.AuditLog); err != nil {
+			log.Fatalf("Failed to open the audit log: %v", err)
+		}
+		defer s.Audit.Close()
+		seq, head := s.Audit.Head()
+		log.Printf("Audit log %s at entry %d, head %s", cfg.AuditLog, seq, head)
+	}
+
+	lis, creds, err := listener(cfg)
+	if err != nil {
+		log.Fatalf("Failed to listen: %v", err)
+	}
+	opts := []grpc.ServerOption{grpcserver.ServerOption(), grpc.StreamInterceptor(logSessions)}
+	if cfg.MaxMessageSize > 0 {
+		// gRPC drops larger requests before they are decoded
+		opts = append(opts, grpc.MaxRecvMsgSize(cfg.MaxMessageSize+requestOverhead))
+	}
+	if creds != nil {
+		opts = append(opts, grpc.Creds(creds))
+	}
+	g := grpc.NewServer(opts...)
+	s.Register(g)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-stop
+		log.Println("Stopping after the running sessions")
+		g.GracefulStop()
+	}()
+
+	if public == nil {
+		log.Printf("Party %d waiting for a key generation on %s", cfg.ID, cfg.Listen)
+	} else {
+		log.Printf("Party %d serving group key %x on %s", cfg.ID, public.GroupKey.ToEd25519(), cfg.Listen)
+	}
+	if err := g.Serve(lis); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// listener returns the listener of cfg.Listen, and the TLS credentials to serve it with.
+func listener(cfg *config) (net.Listener, credentials.TransportCredentials, error) {
+	if path, ok := strings.CutPrefix(cfg.Listen, "unix:"); ok {
+		lis, err := listenUnix(strings.TrimPrefix(path, "//"))
+		return lis, nil, err
+	}
+
+	if cfg.TLSCert == "" || cfg.TLSKey == "" {
+		return nil, nil, errors.New("a TCP listener requires --tls-cert and --tls-key")
+	}
+	creds, err := credentials.NewServerTLSFromFile(cfg.TLSCert, cfg.TLSKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	lis, err := net.Listen("tcp", cfg.Listen)
+	if err != nil {
+		return nil, nil, err
+	}
+	return lis, creds, nil
+}
+
+// listenUnix listens on the unix socket at path, which only our own user, e.g.
+// a coordinator or operator on the same host, may connect to.
+func listenUnix(path string) (net.Listener, error) {
+	// a socket left behind by a daemon that did not stop cleanly
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		lis.Close()
+		return nil, err
+	}
+	return lis, nil
+}
+
+// logSessions logs every session with its coordinator and outcome.
+func logSessions(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	from := "the unix socket"
+	if p, ok := peer.FromContext(ss.Context()); ok && p.Addr != nil && p.Addr.Network() != "unix" {
+		from = p.Addr.String()
+	}
+	method := info.FullMethod[strings.LastIndexByte(info.FullMethod, '/')+1:]
+	start := time.Now()
+	err := handler(srv, ss)
+	if err != nil {
+		log.Printf("%s session from %s failed after %s: %v", method, from, time.Since(start).Round(time.Millisecond), err)
+	} else {
+		log.Printf("%s session from %s completed in %s", method, from, time.Since(start).Round(time.Millisecond))
+	}
+	return err
+}
+
+// keyFiles stores the shares of the party.
+type keyFiles struct {
+	secret, shares string
+	passphrase     []byte
+	// wrapper, if set, wraps the secret share, which is then only unwrapped by unwrap.
+	wrapper keywrap.Wrapper
+}
+
+// load returns the stored shares, or nil if there are none yet. A wrapped
+// secret share is not unwrapped, and returned as nil.
+func (k *keyFiles) load() (*eddsa.Public, *eddsa.SecretShare, error) {
+	data, err := os.ReadFile(k.secret)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if keywrap.IsWrapped(data) {
+		if k.wrapper == nil {
+			return nil, nil, fmt.Errorf("%s is wrapped, use --wrap", k.secret)
+		}
+		public, err := k.loadPublic()
+		return public, nil, err
+	}
+	if sealed.IsSealed(data) {
+		if k.passphrase == nil {
+			return nil, nil, fmt.Errorf("%s is sealed, use --passphrase-file", k.secret)
+		}
+		if data, err = sealed.Open(data, k.passphrase); err != nil {
+			return nil, nil, err
+		}
+	}
+	if data, err = frost.DecodeArtifact(frost.ArtifactSecretShare, data); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", k.secret, err)
+	}
+	var secret eddsa.SecretShare
+	if err := secret.UnmarshalBinary(data); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", k.secret, err)
+	}
+	public, err := k.loadPublic()
+	if err != nil {
+		return nil, nil, err
+	}
+	return public, &secret, nil
+}
+
+// loadPublic returns the stored public shares.
+func (k *keyFiles) loadPublic() (*eddsa.Public, error) {
+	data, err := os.ReadFile(k.shares)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = frost.DecodeArtifact(frost.ArtifactPublic, data); err != nil {
+		return nil, fmt.Errorf("%s: %w", k.shares, err)
+	}
+	var public eddsa.Public
+	if err := json.Unmarshal(data, &public); err != nil {
+		return nil, fmt.Errorf("%s: %w", k.shares, err)
+	}
+	return &public, nil
+}
+
+// unwrap returns the stored secret share, unwrapped with the wrapper.
+func (k *keyFiles) unwrap(ctx context.Context) (*eddsa.SecretShare, error) {
+	data, err := os.ReadFile(k.secret)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = keywrap.Open(ctx, data, k.wrapper); err != nil {
+		return nil, err
+	}
+	defer clear(data)
+	payload, err := frost.DecodeArtifact(frost.ArtifactSecretShare, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", k.secret, err)
+	}
+	var secret eddsa.SecretShare
+	if err := secret.UnmarshalBinary(payload); err != nil {
+		return nil, fmt.Errorf("%s: %w", k.secret, err)
+	}
+	return &secret, nil
+}
+
+// create stores the shares of a key generation, and refuses to replace a stored key.
+func (k *keyFiles) create(public *eddsa.Public, secret *eddsa.SecretShare) error {
+	if _, err := os.Stat(k.secret); err == nil {
+		return errors.New("refusing to replace the stored secret share with a new key")
+	}
+	if err := k.write(public, secret); err != nil {
+		return err
+	}
+	log.Printf("Stored the share of new group key %x", public.GroupKey.ToEd25519())
+	return nil
+}
+
+// replace stores the shares of a refresh in place of the old ones.
+func (k *keyFiles) replace(public *eddsa.Public, secret *eddsa.SecretShare) error {
+	if err := k.write(public, secret); err != nil {
+		return err
+	}
+	log.Println("Replaced the secret share with the refreshed one")
+	return nil
+}
+
+// write stores the shares, each file is replaced at once.
+func (k *keyFiles) write(public *eddsa.Public, secret *eddsa.SecretShare) error {
+	secretData, err := secret.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	secretData = frost.EncodeArtifact(frost.ArtifactSecretShare, secretData)
+	switch {
+	case k.wrapper != nil:
+		if secretData, err = keywrap.Seal(context.Background(), secretData, k.wrapper); err != nil {
+			return err
+		}
+	case k.passphrase != nil:
+		if secretData, err = sealed.Seal(secretData, k.passphrase, sealed.DefaultArgon2id); err != nil {
+			return err
+		}
+	}
+	publicData, err := public.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if err := writeAtomic(k.shares, frost.EncodeArtifact(frost.ArtifactPublic, publicData), 0644); err != nil {
+		return err
+	}
+	return writeAtomic(k.secret, secretData, 0600)
+}
+
+// writeAtomic replaces filename with data, by renaming a temporary file over it.
+func writeAtomic(filename string, data []byte, perm os.FileMode) error {
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
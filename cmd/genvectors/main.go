@@ -0,0 +1,120 @@
+// Command genvectors produces and checks FROST(Ed25519, SHA-512) test vectors
+// in the JSON format of the vectors of RFC 9591, see package testvectors.
+//
+//	go run ./cmd/genvectors --rfc > rfc9591.json
+//	go run ./cmd/genvectors --seed 00112233 --max 5 --min 3 --signers 1,3,5 --message 74657374 --out vector.json
+//	go run ./cmd/genvectors --verify vector.json other-implementation.json
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/testvectors"
+)
+
+func main() {
+	var (
+		rfc     = flag.Bool("rfc", false, "Print the vector of Appendix E.1 of RFC 9591")
+		verify  = flag.Bool("verify", false, "Verify the vector files given as arguments")
+		seed    = flag.String("seed", "", "Hex encoded seed from which the secrets and nonces are derived")
+		max     = flag.Uint("max", 3, "Number of parties of the group, MAX_PARTICIPANTS")
+		min     = flag.Uint("min", 2, "Number of parties needed to sign, MIN_PARTICIPANTS")
+		signers = flag.String("signers", "", "Comma separated IDs of the signers (default: the first min parties)")
+		message = flag.String("message", "74657374", "Hex encoded message to sign")
+		out     = flag.String("out", "", "Output file (default: stdout)")
+	)
+	flag.Parse()
+
+	if *verify {
+		if flag.NArg() == 0 {
+			fmt.Println("Usage: genvectors --verify <vector file>...")
+			os.Exit(1)
+		}
+		failed := false
+		for _, file := range flag.Args() {
+			if err := verifyFile(file); err != nil {
+				fmt.Printf("%s: %v\n", file, err)
+				failed = true
+			} else {
+				fmt.Printf("%s: ok\n", file)
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+		return
+	}
+
+	var v *testvectors.Vector
+	if *rfc {
+		v = testvectors.RFC9591()
+	} else {
+		seedBytes, err := hex.DecodeString(*seed)
+		if err != nil || len(seedBytes) == 0 {
+			fmt.Println("A hex encoded --seed, or --rfc, is required")
+			os.Exit(1)
+		}
+		msg, err := hex.DecodeString(*message)
+		if err != nil {
+			fmt.Println("Error decoding message:", err)
+			os.Exit(1)
+		}
+		if *max == 0 || *max > uint(^party.ID(0)) {
+			fmt.Println("Invalid --max")
+			os.Exit(1)
+		}
+		var signerIDs party.IDSlice
+		if *signers == "" {
+			for id := party.ID(1); id <= party.ID(*min); id++ {
+				signerIDs = append(signerIDs, id)
+			}
+		} else {
+			for _, field := range strings.Split(*signers, ",") {
+				id, err := party.FromString(strings.TrimSpace(field))
+				if err != nil {
+					fmt.Println("Error parsing signer IDs:", err)
+					os.Exit(1)
+				}
+				signerIDs = append(signerIDs, id)
+			}
+		}
+		if v, err = testvectors.Generate(seedBytes, party.Size(*max), party.Size(*min), signerIDs, msg); err != nil {
+			fmt.Println("Error generating vector:", err)
+			os.Exit(1)
+		}
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		fmt.Println("Error encoding vector:", err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+	if *out == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		fmt.Println("Error writing vector:", err)
+		os.Exit(1)
+	}
+}
+
+// verifyFile checks the vector in file.
+func verifyFile(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var v testvectors.Vector
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return testvectors.Verify(&v)
+}
@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/bartke/frost"
+	"github.com/bartke/frost/audit"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/internal/cli"
+	"github.com/bartke/frost/keywrap"
 	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/relay"
 )
 
 func writeFile(filename string, data []byte) error {
@@ -18,27 +26,102 @@ func readFile(filename string) ([]byte, error) {
 	return os.ReadFile(filename)
 }
 
-func initParticipant(id party.ID, n, t party.Size, outputFile, stateFile string) {
-	msg, state, err := frost.KeygenInit(id, n, t)
+// secrets seals or wraps the state and secret share files, if its passphrase
+// or wrapper is set.
+var secrets cli.Secrets
+
+// exchange posts the messages of this party to a relay and receives the
+// messages of the others from it, if the relay client is set.
+type exchange struct {
+	client *relay.Client
+	wait   time.Duration
+}
+
+func (x exchange) post(msgs ...*frost.Message) error {
+	if x.client == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), x.wait)
+	defer cancel()
+	for _, msg := range msgs {
+		if err := x.client.Post(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// receive returns the messages in files, or waits for count messages of type typ on the relay.
+func (x exchange) receive(files []string, typ frost.MessageType, count int) ([]*frost.Message, error) {
+	if x.client != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), x.wait)
+		defer cancel()
+		return x.client.Receive(ctx, typ, count)
+	}
+	msgs := make([]*frost.Message, len(files))
+	for i, file := range files {
+		data, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = frost.DecodeArtifact(frost.ArtifactMessage, data); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		var msg frost.Message
+		if err := msg.UnmarshalJSON(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		msgs[i] = &msg
+	}
+	return msgs, nil
+}
+
+func initParticipant(id party.ID, n, t party.Size, seed, context, proofContext, outputFile, stateFile string, x exchange) {
+	var (
+		msg   *frost.Message
+		state *frost.KeygenState
+		err   error
+	)
+	switch {
+	case seed != "" && proofContext != "":
+		fmt.Println("Error initializing participant: --proof-context cannot be combined with --seed")
+		return
+	case proofContext != "":
+		msg, state, err = frost.KeygenInitWithProofContext(frost.SessionID{}, []byte(proofContext), id, n, t)
+	case seed != "":
+		seedBytes, decodeErr := hex.DecodeString(seed)
+		if decodeErr != nil {
+			fmt.Println("Error decoding seed:", decodeErr)
+			return
+		}
+		msg, state, err = frost.KeygenInitDeterministic(id, n, t, seedBytes, []byte(context))
+	default:
+		msg, state, err = frost.KeygenInit(id, n, t)
+	}
 	if err != nil {
 		fmt.Println("Error initializing participant:", err)
 		return
 	}
 
 	data, _ := msg.MarshalJSON()
-	writeFile(outputFile, data)
+	writeFile(outputFile, frost.EncodeArtifact(frost.ArtifactMessage, data))
 
 	stateData, _ := state.MarshalJSON()
-	writeFile(stateFile, stateData)
+	if err := secrets.Write(stateFile, frost.EncodeArtifact(frost.ArtifactKeygenState, stateData)); err != nil {
+		fmt.Println("Error writing state:", err)
+		return
+	}
+
+	if err := x.post(msg); err != nil {
+		fmt.Println("Error posting to the relay:", err)
+	}
 }
 
-func keyGenRound1(state *frost.KeygenState, inputFiles []string, stateFile string) {
-	msgs := make([]*frost.Message, len(inputFiles))
-	for i, file := range inputFiles {
-		data, _ := readFile(file)
-		var msg frost.Message
-		msg.UnmarshalJSON(data)
-		msgs[i] = &msg
+func keyGenRound1(state *frost.KeygenState, inputFiles []string, stateFile string, x exchange) {
+	msgs, err := x.receive(inputFiles, frost.MessageTypeKeyGen1, len(state.PartyIDs)-1)
+	if err != nil {
+		fmt.Println("Error reading round 1 messages:", err)
+		return
 	}
 
 	outMsgs, state, err := frost.KeygenRound1(state, msgs)
@@ -50,34 +133,62 @@ func keyGenRound1(state *frost.KeygenState, inputFiles []string, stateFile strin
 	// Write output messages to files
 	for _, outMsg := range outMsgs {
 		data, _ := outMsg.MarshalJSON()
-		writeFile(fmt.Sprintf("round1_out_%d_%d.json", outMsg.From, outMsg.To), data)
+		writeFile(fmt.Sprintf("round1_out_%d_%d.json", outMsg.From, outMsg.To), frost.EncodeArtifact(frost.ArtifactMessage, data))
 	}
 
 	stateData, _ := state.MarshalJSON()
-	writeFile(stateFile, stateData)
+	if err := secrets.Write(stateFile, frost.EncodeArtifact(frost.ArtifactKeygenState, stateData)); err != nil {
+		fmt.Println("Error writing state:", err)
+		return
+	}
+
+	if err := x.post(outMsgs...); err != nil {
+		fmt.Println("Error posting to the relay:", err)
+	}
 }
 
-func keyGenRound2(state *frost.KeygenState, inputFiles []string, outputFile string) {
-	msgs := make([]*frost.Message, len(inputFiles))
-	for i, file := range inputFiles {
-		data, _ := readFile(file)
-		var msg frost.Message
-		msg.UnmarshalJSON(data)
-		msgs[i] = &msg
+// recordKeygen records the outcome of the key generation of state in the audit log, if any.
+func recordKeygen(auditFile string, state *frost.KeygenState, pub *eddsa.Public, sessionErr error) error {
+	if auditFile == "" {
+		return nil
+	}
+	l, err := audit.Open(auditFile)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	_, err = l.Append(audit.KeygenEvent(state.SelfID, state.PartyIDs.N(), state.Threshold, pub, sessionErr))
+	return err
+}
+
+func keyGenRound2(state *frost.KeygenState, inputFiles []string, outputFile, auditFile string, x exchange) {
+	msgs, err := x.receive(inputFiles, frost.MessageTypeKeyGen2, len(state.PartyIDs)-1)
+	if err != nil {
+		fmt.Println("Error reading round 2 messages:", err)
+		return
 	}
 
 	pub, sec, err := frost.KeygenRound2(state, msgs)
 	if err != nil {
+		if err := recordKeygen(auditFile, state, nil, err); err != nil {
+			fmt.Println("Error recording in audit log:", err)
+		}
 		fmt.Println("Error in key generation round 2:", err)
 		return
 	}
+	if err := recordKeygen(auditFile, state, pub, nil); err != nil {
+		fmt.Println("Error recording in audit log:", err)
+		return
+	}
 
 	// Write public and secret keys to files
 	pubData, _ := pub.MarshalJSON()
-	writeFile(outputFile+"_pub.json", pubData)
+	writeFile(outputFile+"_pub.json", frost.EncodeArtifact(frost.ArtifactPublic, pubData))
 
 	secData, _ := sec.MarshalBinary()
-	writeFile(outputFile+"_sec.dat", secData)
+	if err := secrets.Write(outputFile+"_sec.dat", frost.EncodeArtifact(frost.ArtifactSecretShare, secData)); err != nil {
+		fmt.Println("Error writing secret share:", err)
+	}
 }
 
 func main() {
@@ -91,6 +202,16 @@ func main() {
 		inputFiles = flag.String("input", "", "Comma-separated list of input files")
 		outputFile = flag.String("output", "", "Output file")
 		stateFile  = flag.String("state", "", "State file")
+		seed       = flag.String("seed", "", "Hex encoded seed to derive all randomness from (deterministic mode)")
+		context    = flag.String("context", "", "Context of the group, used with --seed")
+		proofCtx   = flag.String("proof-context", "", "Context the proofs of knowledge are bound to, e.g. the ceremony ID, the same for all parties")
+		relayURL   = flag.String("relay", "", "URL of a relay to exchange the messages through, instead of input files")
+		session    = flag.String("session", "", "Session ID on the relay, shared by all parties")
+		wait       = flag.Duration("wait", 10*time.Minute, "How long to wait for the messages of the other parties on the relay")
+		passFile   = flag.String("passphrase-file", "", "File holding the passphrase to seal the state and secret share files with")
+		prompt     = flag.Bool("prompt", false, "Prompt for the passphrase to seal the state and secret share files with")
+		auditLog   = flag.String("audit-log", "", "Hash-chained log the outcome of round 2 is recorded in")
+		wrap       = flag.String("wrap", "", "Wrap the state and secret share files with a KMS key or age identity: awskms:<key>, gcpkms:<key name> or age:<identity file>")
 	)
 
 	flag.Parse()
@@ -110,36 +231,72 @@ func main() {
 		return
 	}
 
+	var x exchange
+	if *relayURL != "" {
+		if *session == "" {
+			fmt.Println("Session ID is required with --relay")
+			return
+		}
+		// the token is not a flag, so that it does not show in the process list
+		x = exchange{client: relay.NewClient(*relayURL, *session, os.Getenv("FROST_RELAY_TOKEN")), wait: *wait}
+	}
+
+	var err error
+	if secrets.Passphrase, err = cli.ReadPassphrase(*passFile, *prompt); err != nil {
+		fmt.Println("Error reading passphrase:", err)
+		return
+	}
+	if *wrap != "" {
+		if secrets.Wrapper, err = keywrap.Parse(*wrap); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+	}
+
 	participantID := party.ID(*id)
 	N := party.Size(*n)
 	T := party.Size(*t)
 
 	if *init {
-		initParticipant(participantID, N, T, *outputFile, *stateFile)
+		initParticipant(participantID, N, T, *seed, *context, *proofCtx, *outputFile, *stateFile, x)
 	} else if *round1 {
-		if *inputFiles == "" {
+		if *inputFiles == "" && x.client == nil {
 			fmt.Println("Input files are required for round 1")
 			return
 		}
 		files := strings.Split(*inputFiles, ",")
 
-		stateData, _ := readFile(*stateFile)
+		stateData, err := secrets.Read(*stateFile)
+		if err == nil {
+			stateData, err = frost.DecodeArtifact(frost.ArtifactKeygenState, stateData)
+		}
+		if err != nil {
+			fmt.Println("Error reading state:", err)
+			return
+		}
 		var state frost.KeygenState
 		state.UnmarshalJSON(stateData)
 
-		keyGenRound1(&state, files, *stateFile)
+		keyGenRound1(&state, files, *stateFile, x)
 	} else if *round2 {
-		if *inputFiles == "" {
+		if *inputFiles == "" && x.client == nil {
 			fmt.Println("Input files and secret file are required for round 2")
 			return
 		}
 		files := strings.Split(*inputFiles, ",")
 
-		stateData, _ := readFile(*stateFile)
+		stateData, err := secrets.Read(*stateFile)
+		if err == nil {
+			stateData, err = frost.DecodeArtifact(frost.ArtifactKeygenState, stateData)
+		}
+		if err != nil {
+			fmt.Println("Error reading state:", err)
+			return
+		}
 		var state frost.KeygenState
 		state.UnmarshalJSON(stateData)
 
-		keyGenRound2(&state, files, *outputFile)
+		keyGenRound2(&state, files, *outputFile, *auditLog, x)
 	} else {
 		fmt.Println("Specify --init, --round1, or --round2")
 	}
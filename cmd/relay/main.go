@@ -0,0 +1,72 @@
+// Command relay runs a relay.Server, a mailbox that the parties of keygen and
+// signing sessions post their messages to and poll their messages from, so
+// that cmd/keygen and cmd/sign can run across machines with --relay. It also
+// serves a ws.Hub at /v1/sessions/{session}/ws, which pushes the messages to
+// parties connected with a ws.Client instead.
+//
+// Every party has a bearer token, configured in a JSON file such as
+//
+//	{"1": "token of party 1", "2": "token of party 2", "3": "token of party 3"}
+//
+// The relay sees every message, including the secret keygen shares, so it
+// should be run by a trusted operator, and over TLS with --tls-cert and --tls-key.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bartke/frost/relay"
+	"github.com/bartke/frost/transport/ws"
+)
+
+func main() {
+	var (
+		listen      = flag.String("listen", "127.0.0.1:8090", "Address to listen on")
+		tokensFile  = flag.String("tokens", "", "JSON file mapping party IDs to their tokens")
+		ttl         = flag.Duration("ttl", time.Hour, "How long a session is kept after its last message")
+		maxMessages = flag.Int("max-messages", 1024, "Maximum number of messages per session")
+		tlsCert     = flag.String("tls-cert", "", "TLS certificate file")
+		tlsKey      = flag.String("tls-key", "", "TLS key file")
+	)
+	flag.Parse()
+
+	if *tokensFile == "" {
+		fmt.Println("--tokens is required")
+		os.Exit(1)
+	}
+	data, err := os.ReadFile(*tokensFile)
+	if err != nil {
+		log.Fatalf("Failed to read tokens: %v", err)
+	}
+	tokens, err := relay.ParseTokens(data)
+	if err != nil {
+		log.Fatalf("Failed to parse tokens: %v", err)
+	}
+
+	s := relay.NewServer(tokens)
+	s.TTL = *ttl
+	s.MaxMessages = *maxMessages
+	hub := ws.NewHub(tokens)
+	hub.TTL = *ttl
+	hub.MaxMessages = *maxMessages
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/sessions/{session}/ws", hub)
+	mux.Handle("/", s)
+
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("Relaying messages of %d parties on %s", len(tokens), *listen)
+	if *tlsCert != "" || *tlsKey != "" {
+		log.Fatal(server.ListenAndServeTLS(*tlsCert, *tlsKey))
+	}
+	log.Fatal(server.ListenAndServe())
+}
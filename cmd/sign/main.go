@@ -1,17 +1,28 @@
 package main
 
 import (
+	"context"
+	"crypto"
 	"crypto/ed25519"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/bartke/frost"
+	"github.com/bartke/frost/audit"
 	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/internal/cli"
+	"github.com/bartke/frost/keywrap"
+	"github.com/bartke/frost/manifest"
 	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/relay"
+	"github.com/bartke/frost/retire"
+	"github.com/bartke/frost/supplychain"
 )
 
 func writeFile(filename string, data []byte) error {
@@ -22,8 +33,104 @@ func readFile(filename string) ([]byte, error) {
 	return os.ReadFile(filename)
 }
 
-func initSigner(signers party.IDSlice, secretFile, sharesFile, messageFile, outputFile, stateFile string) {
-	secretData, err := readFile(secretFile)
+// secrets seals or wraps the state files, and opens the sealed or wrapped
+// secret share and state files, if its passphrase or wrapper is set.
+var secrets cli.Secrets
+
+// prehashFile returns the SHA-512 digest of the file, read in chunks.
+func prehashFile(filename string) ([]byte, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return frost.PrehashMessage(f)
+}
+
+// exchange posts the messages of this signer to a relay and receives the
+// messages of the others from it, if the relay client is set.
+type exchange struct {
+	client *relay.Client
+	wait   time.Duration
+}
+
+func (x exchange) post(msg *frost.Message) error {
+	if x.client == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), x.wait)
+	defer cancel()
+	return x.client.Post(ctx, msg)
+}
+
+// receive returns the messages in files, or waits for count messages of type typ on the relay.
+func (x exchange) receive(files []string, typ frost.MessageType, count int) ([]*frost.Message, error) {
+	if x.client != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), x.wait)
+		defer cancel()
+		return x.client.Receive(ctx, typ, count)
+	}
+	msgs := make([]*frost.Message, len(files))
+	for i, file := range files {
+		data, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = frost.DecodeArtifact(frost.ArtifactMessage, data); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		var msg frost.Message
+		if err := msg.UnmarshalJSON(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		msgs[i] = &msg
+	}
+	return msgs, nil
+}
+
+// buildManifest hashes the tree below dir, stores the unsigned manifest in manifestFile,
+// and returns its canonical body, which is the message to sign.
+func buildManifest(dir, manifestFile string) ([]byte, error) {
+	m, err := manifest.Build(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(manifestFile, data); err != nil {
+		return nil, err
+	}
+	return m.Body(), nil
+}
+
+// signManifest attaches the signature to the manifest stored in manifestFile.
+func signManifest(manifestFile string, state *frost.SignerState, sig *eddsa.Signature) error {
+	data, err := readFile(manifestFile)
+	if err != nil {
+		return err
+	}
+	var m manifest.Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	if string(m.Body()) != string(state.Message) {
+		return errors.New("manifest does not match the signed message")
+	}
+	m.Attach(state.GroupKey.ToEd25519(), sig.ToEd25519())
+	data, err = json.MarshalIndent(&m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(manifestFile, data)
+}
+
+func initSigner(signers party.IDSlice, secretFile, sharesFile, messageFile, manifestDir, manifestFile, outputFile, stateFile, tombstoneDir string, prehash bool, x exchange) {
+	secretData, err := secrets.Read(secretFile)
+	if err == nil {
+		secretData, err = frost.DecodeArtifact(frost.ArtifactSecretShare, secretData)
+	}
 	if err != nil {
 		fmt.Println("Error reading secret:", err)
 		return
@@ -35,6 +142,9 @@ func initSigner(signers party.IDSlice, secretFile, sharesFile, messageFile, outp
 	}
 
 	sharesData, err := readFile(sharesFile)
+	if err == nil {
+		sharesData, err = frost.DecodeArtifact(frost.ArtifactPublic, sharesData)
+	}
 	if err != nil {
 		fmt.Println("Error reading shares:", err)
 		return
@@ -46,33 +156,72 @@ func initSigner(signers party.IDSlice, secretFile, sharesFile, messageFile, outp
 		return
 	}
 
-	message, err := readFile(messageFile)
-	if err != nil {
-		fmt.Println("Error reading message:", err)
-		return
+	var message []byte
+	if manifestDir != "" {
+		message, err = buildManifest(manifestDir, manifestFile)
+		if err != nil {
+			fmt.Println("Error building manifest:", err)
+			return
+		}
+	} else if prehash {
+		// the message is streamed, and only its digest is kept in the state
+		message, err = prehashFile(messageFile)
+		if err != nil {
+			fmt.Println("Error hashing message:", err)
+			return
+		}
+	} else {
+		message, err = readFile(messageFile)
+		if err != nil {
+			fmt.Println("Error reading message:", err)
+			return
+		}
 	}
 
-	msg, state, err := frost.SignInit(signers, &secret, &shares, message)
+	var (
+		msg   *frost.Message
+		state *frost.SignerState
+	)
+	signInit := frost.SignInit
+	if prehash {
+		signInit = frost.SignInitPrehashed
+	}
+	if tombstoneDir != "" {
+		var store *retire.Store
+		if store, err = retire.OpenStore(tombstoneDir); err != nil {
+			fmt.Println("Error opening tombstones:", err)
+			return
+		}
+		err = store.Check(shares.GroupKey.ToEd25519())
+	}
+	if err == nil {
+		msg, state, err = signInit(signers, &secret, &shares, message)
+	}
 	if err != nil {
 		fmt.Println("Error initializing signer:", err)
 		return
 	}
 
 	msgData, _ := msg.MarshalJSON()
-	writeFile(outputFile, msgData)
+	writeFile(outputFile, frost.EncodeArtifact(frost.ArtifactMessage, msgData))
 
 	stateData, _ := state.MarshalJSON()
-	writeFile(stateFile, stateData)
+	if err := secrets.Write(stateFile, frost.EncodeArtifact(frost.ArtifactSignerState, stateData)); err != nil {
+		fmt.Println("Error writing state:", err)
+		return
+	}
+
+	if err := x.post(msg); err != nil {
+		fmt.Println("Error posting to the relay:", err)
+	}
 }
 
 // Signing round 1
-func signRound1(state *frost.SignerState, inputFiles []string, outputFile, stateFile string) {
-	msgs := make([]*frost.Message, len(inputFiles))
-	for i, file := range inputFiles {
-		data, _ := readFile(file)
-		var msg frost.Message
-		msg.UnmarshalJSON(data)
-		msgs[i] = &msg
+func signRound1(state *frost.SignerState, inputFiles []string, outputFile, stateFile string, x exchange) {
+	msgs, err := x.receive(inputFiles, frost.MessageTypeSign1, len(state.SignerIDs)-1)
+	if err != nil {
+		fmt.Println("Error reading round 1 messages:", err)
+		return
 	}
 
 	outMsg, state, err := frost.SignRound1(state, msgs)
@@ -83,7 +232,7 @@ func signRound1(state *frost.SignerState, inputFiles []string, outputFile, state
 
 	// Write output message to file
 	outMsgData, _ := outMsg.MarshalJSON()
-	writeFile(outputFile, outMsgData)
+	writeFile(outputFile, frost.EncodeArtifact(frost.ArtifactMessage, outMsgData))
 
 	// Save state to file
 	stateData, err := state.MarshalJSON()
@@ -91,43 +240,118 @@ func signRound1(state *frost.SignerState, inputFiles []string, outputFile, state
 		fmt.Println("Error marshaling state:", err)
 		return
 	}
-	writeFile(stateFile, stateData)
+	if err := secrets.Write(stateFile, frost.EncodeArtifact(frost.ArtifactSignerState, stateData)); err != nil {
+		fmt.Println("Error writing state:", err)
+		return
+	}
+
+	if err := x.post(outMsg); err != nil {
+		fmt.Println("Error posting to the relay:", err)
+	}
+}
+
+// encodeSignature returns the Ed25519 signature sig of the session of state in
+// format: the 64 bytes, hex encoded, as the signature entry of TUF and in-toto
+// metadata, or as a Sigstore bundle of the message.
+func encodeSignature(format string, state *frost.SignerState, sig []byte) ([]byte, error) {
+	pub := ed25519.PublicKey(state.GroupKey.ToEd25519())
+	var v interface{}
+	switch format {
+	case "raw":
+		return sig, nil
+	case "hex":
+		return []byte(hex.EncodeToString(sig) + "\n"), nil
+	case "tuf":
+		v = supplychain.NewTUFSignature(pub, sig)
+	case "sigstore":
+		v = supplychain.NewSigstoreBundle(pub, state.Message, sig, state.Prehash)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// recordSign records the outcome of the session of state in the audit log, if any.
+func recordSign(auditFile string, state *frost.SignerState, sig *eddsa.Signature, sessionErr error) error {
+	if auditFile == "" {
+		return nil
+	}
+	l, err := audit.Open(auditFile)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	_, err = l.Append(audit.SignEvent(state.SelfID, state.SignerIDs, &state.GroupKey, state.Message, state.Prehash, sig, sessionErr))
+	return err
 }
 
 // Signing round 2
-func signRound2(state *frost.SignerState, inputFiles []string, outputFile string) {
-	msgs := make([]*frost.Message, len(inputFiles))
-	for i, file := range inputFiles {
-		data, _ := readFile(file)
-		var msg frost.Message
-		msg.UnmarshalJSON(data)
-		msgs[i] = &msg
+func signRound2(state *frost.SignerState, inputFiles []string, outputFile, stateFile, manifestFile, format, auditFile string, x exchange) {
+	msgs, err := x.receive(inputFiles, frost.MessageTypeSign2, len(state.SignerIDs)-1)
+	if err != nil {
+		fmt.Println("Error reading round 2 messages:", err)
+		return
 	}
 
-	sig, state, err := frost.SignRound2(state, msgs)
+	sig, signed, err := frost.SignRound2(state, msgs)
 	if err != nil {
+		if err := recordSign(auditFile, state, nil, err); err != nil {
+			fmt.Println("Error recording in audit log:", err)
+		}
 		fmt.Println("Error in signing round 2:", err)
 		return
 	}
+	state = signed
 
 	// verify also with the standard ed25519 library
 	pubkey := state.GroupKey.ToEd25519()
 	signature := sig.ToEd25519()
+	opts := &ed25519.Options{}
+	if state.Prehash {
+		opts.Hash = crypto.SHA512
+	}
 	// print hex
-	if !ed25519.Verify(pubkey, state.Message, signature) {
-		panic(errors.New("ed25519: full signature is invalid"))
+	if err := ed25519.VerifyWithOptions(pubkey, state.Message, signature, opts); err != nil {
+		panic(fmt.Errorf("ed25519: full signature is invalid: %w", err))
 	}
 
 	fmt.Printf("Public key: %x\n", pubkey)
 	fmt.Printf("Validated Signature: %x\n", signature)
 
+	// the signature is only released once it is recorded
+	if err := recordSign(auditFile, state, sig, nil); err != nil {
+		fmt.Println("Error recording in audit log:", err)
+		return
+	}
+
 	// Write signature to file
-	sigData, _ := sig.MarshalBinary()
-	writeFile(outputFile, sigData)
+	sigData, err := encodeSignature(format, state, signature)
+	if err != nil {
+		fmt.Println("Error encoding signature:", err)
+		return
+	}
+	if err := writeFile(outputFile, sigData); err != nil {
+		fmt.Println("Error writing signature:", err)
+		return
+	}
+
+	if manifestFile != "" {
+		if err := signManifest(manifestFile, state, sig); err != nil {
+			fmt.Println("Error signing manifest:", err)
+			return
+		}
+		fmt.Println("Signed manifest:", manifestFile)
+	}
 
 	// Save state to file
 	stateData, _ := state.MarshalJSON()
-	writeFile(outputFile, stateData)
+	if err := secrets.Write(stateFile, frost.EncodeArtifact(frost.ArtifactSignerState, stateData)); err != nil {
+		fmt.Println("Error writing state:", err)
+	}
 }
 
 func main() {
@@ -140,9 +364,22 @@ func main() {
 		secretFile  = flag.String("secret", "", "Secret file")
 		sharesFile  = flag.String("shares", "", "Shares file")
 		messageFile = flag.String("message", "", "Message file")
+		manifestDir = flag.String("manifest-dir", "", "Sign a manifest of this directory instead of a message file")
+		manifestOut = flag.String("manifest", "", "Manifest file, written on init and signed in round 2")
+		prehash     = flag.Bool("ph", false, "Sign the SHA-512 digest of the message file with Ed25519ph, without loading the file in memory")
 		inputFiles  = flag.String("input", "", "Comma-separated list of input files")
 		outputFile  = flag.String("output", "", "Output file")
 		stateFile   = flag.String("state", "", "State file")
+		tombstones  = flag.String("tombstones", "", "Directory of tombstones, to refuse signing with retired keys")
+		relayURL    = flag.String("relay", "", "URL of a relay to exchange the messages through, instead of input files")
+		session     = flag.String("session", "", "Session ID on the relay, shared by all signers")
+		wait        = flag.Duration("wait", 10*time.Minute, "How long to wait for the messages of the other signers on the relay")
+		ledgerFile  = flag.String("nonce-ledger", "", "File recording the nonces used in round 1, to refuse using them twice if the state file is replayed")
+		passFile    = flag.String("passphrase-file", "", "File holding the passphrase to seal the state file with, and to open sealed secret and state files")
+		format      = flag.String("format", "raw", "Format of the signature written in round 2: raw, hex, tuf or sigstore")
+		prompt      = flag.Bool("prompt", false, "Prompt for the passphrase to seal the state file with, and to open sealed secret and state files")
+		auditLog    = flag.String("audit-log", "", "Hash-chained log the outcome of round 2 is recorded in")
+		wrap        = flag.String("wrap", "", "Wrap the state file with a KMS key or age identity, and open wrapped secret and state files: awskms:<key>, gcpkms:<key name> or age:<identity file>")
 	)
 
 	flag.Parse()
@@ -151,6 +388,34 @@ func main() {
 		fmt.Println("Participant ID and output file are required")
 		return
 	}
+	switch *format {
+	case "raw", "hex", "tuf", "sigstore":
+	default:
+		fmt.Println("Format must be raw, hex, tuf or sigstore")
+		return
+	}
+
+	var err error
+	if secrets.Passphrase, err = cli.ReadPassphrase(*passFile, *prompt); err != nil {
+		fmt.Println("Error reading passphrase:", err)
+		return
+	}
+	if *wrap != "" {
+		if secrets.Wrapper, err = keywrap.Parse(*wrap); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+	}
+
+	var x exchange
+	if *relayURL != "" {
+		if *session == "" {
+			fmt.Println("Session ID is required with --relay")
+			return
+		}
+		// the token is not a flag, so that it does not show in the process list
+		x = exchange{client: relay.NewClient(*relayURL, *session, os.Getenv("FROST_RELAY_TOKEN")), wait: *wait}
+	}
 
 	if *signers == "" && *init {
 		fmt.Println("Signers are required for initialization")
@@ -158,11 +423,21 @@ func main() {
 	}
 
 	if *init {
-		if *secretFile == "" || *sharesFile == "" || *messageFile == "" {
+		if *secretFile == "" || *sharesFile == "" || (*messageFile == "" && *manifestDir == "") {
 			fmt.Println("Secret file, shares file, and message file are required for initialization")
 			return
 		}
 
+		if *manifestDir != "" && *manifestOut == "" {
+			fmt.Println("Manifest file is required when signing a directory")
+			return
+		}
+
+		if *manifestDir != "" && *prehash {
+			fmt.Println("Manifests are signed without --ph")
+			return
+		}
+
 		var signerIDs party.IDSlice
 		for _, id := range strings.Split(*signers, ",") {
 			partyID, err := party.FromString(id)
@@ -174,37 +449,72 @@ func main() {
 			signerIDs = append(signerIDs, partyID)
 		}
 
-		initSigner(signerIDs, *secretFile, *sharesFile, *messageFile, *outputFile, *stateFile)
+		initSigner(signerIDs, *secretFile, *sharesFile, *messageFile, *manifestDir, *manifestOut, *outputFile, *stateFile, *tombstones, *prehash, x)
 	} else if *round1 {
-		if *inputFiles == "" || *stateFile == "" {
+		if *inputFiles == "" && x.client == nil || *stateFile == "" {
 			fmt.Println("Input files and state file are required for round 1")
 			return
 		}
 		files := strings.Split(*inputFiles, ",")
 
-		stateData, _ := readFile(*stateFile)
+		stateData, err := secrets.Read(*stateFile)
+		if err == nil {
+			stateData, err = frost.DecodeArtifact(frost.ArtifactSignerState, stateData)
+		}
+		if err != nil {
+			fmt.Println("Error reading state:", err)
+			return
+		}
 		var state frost.SignerState
 		if err := state.UnmarshalJSON(stateData); err != nil {
 			fmt.Println("Error unmarshaling state:", err)
 			return
 		}
+		// the key may have been retired since the session was initialized
+		if *tombstones != "" {
+			store, err := retire.OpenStore(*tombstones)
+			if err == nil {
+				err = store.Check(state.GroupKey.ToEd25519())
+			}
+			if err != nil {
+				fmt.Println("Error in signing round 1:", err)
+				return
+			}
+		}
 
-		signRound1(&state, files, *outputFile, *stateFile)
+		if *ledgerFile != "" {
+			ledger, err := frost.OpenFileNonceLedger(*ledgerFile)
+			if err != nil {
+				fmt.Println("Error opening nonce ledger:", err)
+				return
+			}
+			defer ledger.Close()
+			state.Ledger = ledger
+		}
+
+		signRound1(&state, files, *outputFile, *stateFile, x)
 	} else if *round2 {
-		if *inputFiles == "" || *stateFile == "" {
+		if *inputFiles == "" && x.client == nil || *stateFile == "" {
 			fmt.Println("Input files and state file are required for round 2")
 			return
 		}
 		files := strings.Split(*inputFiles, ",")
 
-		stateData, _ := readFile(*stateFile)
+		stateData, err := secrets.Read(*stateFile)
+		if err == nil {
+			stateData, err = frost.DecodeArtifact(frost.ArtifactSignerState, stateData)
+		}
+		if err != nil {
+			fmt.Println("Error reading state:", err)
+			return
+		}
 		var state frost.SignerState
 		if err := state.UnmarshalJSON(stateData); err != nil {
 			fmt.Println("Error unmarshaling state:", err)
 			return
 		}
 
-		signRound2(&state, files, *outputFile)
+		signRound2(&state, files, *outputFile, *stateFile, *manifestOut, *format, *auditLog, x)
 	} else {
 		fmt.Println("Specify --init, --round1, or --round2")
 	}
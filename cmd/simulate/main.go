@@ -0,0 +1,308 @@
+// Command simulate runs a key generation and a signing between N local parties,
+// and prints what every round did and how long it took. Faults can be injected
+// to see how the protocol fails:
+//
+//	go run ./cmd/simulate --n 5 --t 2 --signers 1,3,5
+//	go run ./cmd/simulate --n 5 --t 2 --fault drop --fault-round keygen2 --fault-party 4
+//	go run ./cmd/simulate --n 3 --t 1 --fault corrupt --fault-round sign2
+//	go run ./cmd/simulate --n 3 --t 1 --fault wrong-message --round0
+//
+// A dropped message fails the next round of its recipients with a
+// frost.ErrMissingParties naming its sender. A corrupted share fails the
+// verification of its recipients, and a corrupted signature share names its
+// sender in a frost.AbortError. A signer signing another message produces an
+// invalid signature share, unless --round0 has the signers compare their
+// messages first.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/scalar"
+)
+
+// fault describes the misbehavior of one party.
+type fault struct {
+	kind  string
+	party party.ID
+	round frost.MessageType
+}
+
+// rounds maps the names of --fault-round to the rounds.
+var rounds = map[string]frost.MessageType{
+	"keygen1": frost.MessageTypeKeyGen1,
+	"keygen2": frost.MessageTypeKeyGen2,
+	"sign1":   frost.MessageTypeSign1,
+	"sign2":   frost.MessageTypeSign2,
+}
+
+// apply returns the messages of a round as the faulty party sends them.
+func (f fault) apply(msgs []*frost.Message) []*frost.Message {
+	if len(msgs) == 0 || msgs[0].Type != f.round {
+		return msgs
+	}
+	out := make([]*frost.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.From != f.party {
+			out = append(out, msg)
+			continue
+		}
+		switch f.kind {
+		case "drop":
+			continue
+		case "corrupt":
+			one := scalar.NewScalarUInt32(1)
+			switch msg.Type {
+			case frost.MessageTypeKeyGen2:
+				share := msg.KeyGen2.Share
+				share.Add(&share, one)
+				msg = frost.NewKeyGen2(msg.From, msg.To, &share)
+			case frost.MessageTypeSign2:
+				zi := msg.Sign2.Zi
+				zi.Add(&zi, one)
+				msg = frost.NewSign2(msg.From, &zi)
+			}
+		}
+		out = append(out, msg)
+	}
+	if len(out) < len(msgs) {
+		fmt.Printf("    ! dropped the %s messages of party %d\n", f.round, f.party)
+	} else if f.kind == "corrupt" {
+		fmt.Printf("    ! corrupted the %s messages of party %d\n", f.round, f.party)
+	}
+	return out
+}
+
+// received returns the messages of msgs that id receives.
+func received(msgs []*frost.Message, id party.ID) []*frost.Message {
+	var in []*frost.Message
+	for _, msg := range msgs {
+		if msg.From != id && (msg.IsBroadcast() || msg.To == id) {
+			in = append(in, msg)
+		}
+	}
+	return in
+}
+
+// round runs step for every party in ids, and prints its timing and the
+// parties that failed. It returns false if any party failed.
+func round(name string, ids party.IDSlice, step func(id party.ID) error) bool {
+	var (
+		total, slowest time.Duration
+		failed         int
+	)
+	errs := make(map[party.ID]error)
+	for _, id := range ids {
+		start := time.Now()
+		err := step(id)
+		d := time.Since(start)
+		total += d
+		if d > slowest {
+			slowest = d
+		}
+		if err != nil {
+			errs[id] = err
+			failed++
+		}
+	}
+	fmt.Printf("  %-8s %d parties in %s, slowest %s\n", name, ids.N(), total.Round(time.Microsecond), slowest.Round(time.Microsecond))
+	for _, id := range ids {
+		if err, ok := errs[id]; ok {
+			fmt.Printf("    party %d failed: %v\n", id, err)
+			var abort *frost.AbortError
+			if errors.As(err, &abort) {
+				fmt.Printf("    party %d names party %d as the culprit\n", id, abort.Culprit)
+			}
+		}
+	}
+	return failed == 0
+}
+
+func main() {
+	var (
+		n          = flag.Uint("n", 3, "Number of parties")
+		t          = flag.Uint("t", 1, "Threshold, t+1 parties are needed to sign")
+		signers    = flag.String("signers", "", "Comma separated IDs of the signers (default: the first t+1 parties)")
+		message    = flag.String("message", "hello FROST", "Message to sign")
+		round0     = flag.Bool("round0", false, "Have the signers compare their messages before signing")
+		faultKind  = flag.String("fault", "none", "Fault to inject: none, drop, corrupt or wrong-message")
+		faultParty = flag.Uint("fault-party", 0, "Party that misbehaves (default: the last signer)")
+		faultRound = flag.String("fault-round", "sign2", "Round of a drop or corrupt fault: keygen1, keygen2, sign1 or sign2")
+	)
+	flag.Parse()
+
+	if *n == 0 || *n > math.MaxUint16 || *t >= *n {
+		fmt.Println("Invalid group size, 0 <= t < n is required")
+		os.Exit(1)
+	}
+	var signerIDs party.IDSlice
+	if *signers != "" {
+		for _, field := range strings.Split(*signers, ",") {
+			id, err := party.FromString(strings.TrimSpace(field))
+			if err != nil {
+				fmt.Println("Error parsing signer IDs:", err)
+				os.Exit(1)
+			}
+			signerIDs = append(signerIDs, id)
+		}
+		signerIDs = party.NewIDSlice(signerIDs)
+	} else {
+		for id := party.ID(1); id <= party.ID(*t+1); id++ {
+			signerIDs = append(signerIDs, id)
+		}
+	}
+
+	f := fault{kind: *faultKind, party: party.ID(*faultParty)}
+	switch f.kind {
+	case "none", "wrong-message":
+	case "drop", "corrupt":
+		var ok bool
+		if f.round, ok = rounds[*faultRound]; !ok {
+			fmt.Println("Unknown round:", *faultRound)
+			os.Exit(1)
+		}
+		if f.kind == "corrupt" && f.round != frost.MessageTypeKeyGen2 && f.round != frost.MessageTypeSign2 {
+			fmt.Println("Shares are corrupted in keygen2 or sign2")
+			os.Exit(1)
+		}
+	default:
+		fmt.Println("Unknown fault:", f.kind)
+		os.Exit(1)
+	}
+	if f.party == 0 {
+		f.party = signerIDs[len(signerIDs)-1]
+	}
+
+	public, secrets, ok := keygen(party.Size(*n), party.Size(*t), f)
+	if !ok {
+		fmt.Println("Key generation failed")
+		return
+	}
+	if sign(signerIDs, public, secrets, []byte(*message), *round0, f) {
+		fmt.Println("Signing succeeded")
+	} else {
+		fmt.Println("Signing failed")
+	}
+}
+
+// keygen runs the key generation of parties 1..n with threshold t.
+func keygen(n, t party.Size, f fault) (*eddsa.Public, map[party.ID]*eddsa.SecretShare, bool) {
+	fmt.Printf("Key generation of %d parties with threshold %d\n", n, t)
+	ids := make(party.IDSlice, 0, n)
+	for id := party.ID(1); id <= n; id++ {
+		ids = append(ids, id)
+	}
+
+	states := make(map[party.ID]*frost.KeygenState, n)
+	var round1 []*frost.Message
+	if !round("init", ids, func(id party.ID) error {
+		msg, state, err := frost.KeygenInit(id, n, t)
+		if err != nil {
+			return err
+		}
+		states[id] = state
+		round1 = append(round1, msg)
+		return nil
+	}) {
+		return nil, nil, false
+	}
+	round1 = f.apply(round1)
+
+	var round2 []*frost.Message
+	if !round("round 1", ids, func(id party.ID) error {
+		msgs, _, err := frost.KeygenRound1(states[id], received(round1, id))
+		round2 = append(round2, msgs...)
+		return err
+	}) {
+		return nil, nil, false
+	}
+	round2 = f.apply(round2)
+
+	var public *eddsa.Public
+	secrets := make(map[party.ID]*eddsa.SecretShare, n)
+	if !round("round 2", ids, func(id party.ID) error {
+		pub, secret, err := frost.KeygenRound2(states[id], received(round2, id))
+		if err != nil {
+			return err
+		}
+		if public != nil && !public.Equal(pub) {
+			return errors.New("computed other public shares than party 1")
+		}
+		public = pub
+		secrets[id] = secret
+		return nil
+	}) {
+		return nil, nil, false
+	}
+	fmt.Printf("  group key %x\n", public.GroupKey.ToEd25519())
+	return public, secrets, true
+}
+
+// sign runs the signing of message by signerIDs.
+func sign(signerIDs party.IDSlice, public *eddsa.Public, secrets map[party.ID]*eddsa.SecretShare, message []byte, round0 bool, f fault) bool {
+	fmt.Printf("Signing %q by parties %v\n", message, signerIDs)
+	states := make(map[party.ID]*frost.SignerState, signerIDs.N())
+	var round1 []*frost.Message
+	if !round("init", signerIDs, func(id party.ID) error {
+		m := message
+		if f.kind == "wrong-message" && id == f.party {
+			m = append([]byte("not "), message...)
+			fmt.Printf("    ! party %d signs %q\n", id, m)
+		}
+		msg, state, err := frost.SignInit(signerIDs, secrets[id], public, m)
+		if err != nil {
+			return err
+		}
+		states[id] = state
+		round1 = append(round1, msg)
+		return nil
+	}) {
+		return false
+	}
+
+	if round0 {
+		var commits []*frost.Message
+		for _, id := range signerIDs {
+			commits = append(commits, frost.SignCommitMessage(states[id]))
+		}
+		if !round("round 0", signerIDs, func(id party.ID) error {
+			return frost.SignRound0(states[id], received(commits, id))
+		}) {
+			return false
+		}
+	}
+	round1 = f.apply(round1)
+
+	var round2 []*frost.Message
+	if !round("round 1", signerIDs, func(id party.ID) error {
+		msg, _, err := frost.SignRound1(states[id], received(round1, id))
+		if err != nil {
+			return err
+		}
+		round2 = append(round2, msg)
+		return nil
+	}) {
+		return false
+	}
+	round2 = f.apply(round2)
+
+	return round("round 2", signerIDs, func(id party.ID) error {
+		sig, _, err := frost.SignRound2(states[id], received(round2, id))
+		if err != nil {
+			return err
+		}
+		if !public.GroupKey.Verify(message, sig) {
+			return errors.New("the signature is invalid")
+		}
+		return nil
+	})
+}
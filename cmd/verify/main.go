@@ -1,22 +1,20 @@
 package main
 
 import (
+	"crypto"
 	"crypto/ed25519"
+	"crypto/sha512"
 	"encoding/hex"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-)
 
-func main() {
-	if len(os.Args) != 4 {
-		log.Fatalf("Usage: %s <hex-public-key> <hex-signature> <file>\n", os.Args[0])
-	}
-
-	hexPubKey := os.Args[1]
-	hexSignature := os.Args[2]
-	filePath := os.Args[3]
+	"github.com/bartke/frost/manifest"
+)
 
+func verifyFile(hexPubKey, hexSignature, filePath string, prehash bool, context string) {
 	pubKey, err := hex.DecodeString(hexPubKey)
 	if err != nil {
 		log.Fatalf("Failed to decode public key: %v\n", err)
@@ -32,9 +30,97 @@ func main() {
 		log.Fatalf("Failed to read file: %v\n", err)
 	}
 
-	if ed25519.Verify(pubKey, data, signature) {
+	// Ed25519ph signs the SHA-512 digest of the file, Ed25519ctx the file itself.
+	opts := &ed25519.Options{Context: context}
+	if prehash {
+		digest := sha512.Sum512(data)
+		data = digest[:]
+		opts.Hash = crypto.SHA512
+	}
+
+	if len(pubKey) != ed25519.PublicKeySize {
+		log.Fatalf("Public key must be %d bytes\n", ed25519.PublicKeySize)
+	}
+
+	if err := ed25519.VerifyWithOptions(pubKey, data, signature, opts); err == nil {
 		fmt.Println("Signature is valid.")
 	} else {
 		fmt.Println("Signature is invalid.")
 	}
 }
+
+func verifyManifest(manifestFile, dir, hexPubKey string, workers int) {
+	data, err := os.ReadFile(manifestFile)
+	if err != nil {
+		log.Fatalf("Failed to read manifest: %v\n", err)
+	}
+
+	var m manifest.Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		log.Fatalf("Failed to decode manifest: %v\n", err)
+	}
+
+	// the key embedded in the manifest proves nothing, anyone can sign with their own
+	if hexPubKey == "" {
+		log.Fatalf("--pubkey is required to verify a manifest\n")
+	}
+	pubKey, err := hex.DecodeString(hexPubKey)
+	if err != nil {
+		log.Fatalf("Failed to decode public key: %v\n", err)
+	}
+
+	if err := m.VerifySignature(pubKey); err != nil {
+		fmt.Println("Manifest signature is invalid:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Manifest signed by group key %s (fingerprint %s).\n", m.GroupKey, m.Fingerprint)
+
+	report, err := m.Check(dir, workers)
+	if err != nil {
+		log.Fatalf("Failed to check directory: %v\n", err)
+	}
+
+	for _, path := range report.Modified {
+		fmt.Println("MODIFIED", path)
+	}
+	for _, path := range report.Missing {
+		fmt.Println("MISSING ", path)
+	}
+	for _, path := range report.Extra {
+		fmt.Println("EXTRA   ", path)
+	}
+	fmt.Printf("%d verified, %d modified, %d missing, %d extra\n",
+		len(report.Verified), len(report.Modified), len(report.Missing), len(report.Extra))
+
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
+
+func main() {
+	var (
+		manifestFile = flag.String("manifest", "", "Signed manifest to verify a directory against")
+		dir          = flag.String("dir", ".", "Directory to verify against the manifest")
+		pubKey       = flag.String("pubkey", "", "Trusted hex group public key the manifest must be signed by (required with --manifest)")
+		workers      = flag.Int("workers", 0, "Number of parallel hashing workers (default: number of CPUs)")
+		prehash      = flag.Bool("ph", false, "Verify an Ed25519ph signature over the SHA-512 digest of the file")
+		context      = flag.String("context", "", "Context string of an Ed25519ctx or Ed25519ph signature")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [--ph] [--context <ctx>] <hex-public-key> <hex-signature> <file>\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s --manifest <manifest.json> [--dir <dir>] --pubkey <hex-public-key>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *manifestFile != "" {
+		verifyManifest(*manifestFile, *dir, *pubKey, *workers)
+		return
+	}
+
+	if flag.NArg() != 3 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	verifyFile(flag.Arg(0), flag.Arg(1), flag.Arg(2), *prehash, *context)
+}
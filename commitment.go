@@ -0,0 +1,100 @@
+package frost
+
+import (
+	"crypto/sha512"
+	"errors"
+	"fmt"
+
+	"github.com/bartke/frost/party"
+)
+
+// ErrMessageMismatch is returned by SignRound0 when another signer committed
+// to a different message, e.g. because the coordinator sent different
+// messages to different signers.
+var ErrMessageMismatch = errors.New("frost: signers were asked to sign different messages")
+
+// messageCommitmentDomain separates the digests of Sign0 from other uses of SHA-512.
+const messageCommitmentDomain = "FROST-Ed25519 message commitment v1"
+
+// SignCommitMessage returns the Sign0 message of the optional round 0 of
+// signing, to be broadcast to the other signers before the Sign1 message
+// returned by SignInit. It commits to what state was asked to sign: the
+// message, the group key, the signers, the session and the signing mode.
+//
+// Without round 0, a coordinator that hands the signers different messages
+// is only noticed in SignRound2, as invalid signature shares of honest
+// signers. With it, SignRound0 names the signers that disagree before any
+// signature share is released.
+func SignCommitMessage(state *SignerState) *Message {
+	digest := state.messageDigest()
+	msg := NewSign0(state.SelfID, &digest)
+	msg.SessionID = state.SessionID
+	return msg
+}
+
+// SignRound0 checks the Sign0 messages of the other signers against the
+// commitment of state. It must be called before SignRound1, and returns an
+// error wrapping ErrMessageMismatch with the IDs of the signers whose
+// commitment differs, or an *ErrMissingParties if a signer's Sign0 message is
+// missing.
+func SignRound0(state *SignerState, inputMsgs []*Message) (err error) {
+	// the check does not change state, so it can be repeated
+	var received Received
+	obs := observeRound(state.Observer, state.Logger, state.SelfID, received, MessageTypeSign0)
+	defer func() { obs.done(err) }()
+
+	digest := state.messageDigest()
+	var mismatch party.IDSlice
+	for _, msg := range inputMsgs {
+		if msg.From == state.SelfID {
+			continue
+		}
+		obs.from = msg.From
+		if err := msg.Validate(MessageTypeSign0, state.SessionID, state.SelfID); err != nil {
+			return fmt.Errorf("SignRound0: %w", err)
+		}
+		if _, ok := state.Signers[msg.From]; !ok {
+			return fmt.Errorf("SignRound0: %w", &ErrUnknownParty{ID: msg.From})
+		}
+		if err := received.add(MessageTypeSign0, msg.From); err != nil {
+			return fmt.Errorf("SignRound0: %w", err)
+		}
+		if msg.Sign0.Digest != digest {
+			mismatch = append(mismatch, msg.From)
+		}
+		obs.received(msg)
+	}
+	obs.from = 0
+	if len(mismatch) > 0 {
+		return fmt.Errorf("SignRound0: parties %v: %w", mismatch, ErrMessageMismatch)
+	}
+	if err := received.complete(MessageTypeSign0, state.SignerIDs, state.SelfID); err != nil {
+		return fmt.Errorf("SignRound0: %w", err)
+	}
+	return nil
+}
+
+// messageDigest returns the digest of Sign0 for state.
+func (s *SignerState) messageDigest() [64]byte {
+	var mode byte
+	if s.Prehash {
+		mode |= 1
+	}
+	if s.RFC9591 {
+		mode |= 2
+	}
+	h := sha512.New()
+	h.Write([]byte(messageCommitmentDomain))
+	h.Write(s.SessionID[:])
+	h.Write(s.GroupKey.ToEd25519())
+	h.Write(s.SignerIDs.N().Bytes())
+	for _, id := range s.SignerIDs {
+		h.Write(id.Bytes())
+	}
+	h.Write([]byte{mode})
+	h.Write(s.Message)
+
+	var digest [64]byte
+	h.Sum(digest[:0])
+	return digest
+}
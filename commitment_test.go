@@ -0,0 +1,74 @@
+package frost
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignRound0(t *testing.T) {
+	public, secrets := generateKeys(t, 4, 2)
+	signers := party.IDSlice{1, 2, 4}
+	message := []byte("pay 10 to alice")
+
+	states := make(map[party.ID]*SignerState)
+	var round0, round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInit(signers, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		round0 = append(round0, SignCommitMessage(state))
+		round1 = append(round1, msg)
+	}
+	for _, id := range signers {
+		require.NoError(t, SignRound0(states[id], round0))
+	}
+	sigs, err := runSignRounds(states, round1)
+	require.NoError(t, err)
+	assert.True(t, public.GroupKey.Verify(message, sigs[1]))
+
+	// the coordinator substitutes the message of party 4
+	_, substituted, err := SignInit(signers, secrets[4], public, []byte("pay 1000 to mallory"))
+	require.NoError(t, err)
+	round0[2] = SignCommitMessage(substituted)
+	err = SignRound0(states[1], round0)
+	require.True(t, errors.Is(err, ErrMessageMismatch))
+	assert.Contains(t, err.Error(), "[4]")
+	err = SignRound0(substituted, round0)
+	require.True(t, errors.Is(err, ErrMessageMismatch))
+	assert.Contains(t, err.Error(), "[1 2]")
+
+	// the signing mode is committed to as well
+	_, prehashed, err := SignInitPrehashed(signers, secrets[4], public, make([]byte, 64))
+	require.NoError(t, err)
+	prehashed.Message = message
+	round0[2] = SignCommitMessage(prehashed)
+	assert.True(t, errors.Is(SignRound0(states[1], round0), ErrMessageMismatch))
+
+	// every other signer must commit, once
+	round0[2] = SignCommitMessage(states[4])
+	assert.Error(t, SignRound0(states[1], round0[:2]))
+	assert.Error(t, SignRound0(states[1], append(round0, round0[1])))
+	assert.Error(t, SignRound0(states[1], append(round0[:2:2], round1[2])))
+}
+
+func TestSignRound0_Session(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	signers := party.IDSlice{1, 3}
+	message := []byte("session")
+	session, err := NewSessionID()
+	require.NoError(t, err)
+
+	_, state1, err := SignInitWithSession(session, signers, secrets[1], public, message)
+	require.NoError(t, err)
+	_, state3, err := SignInitWithSession(session, signers, secrets[3], public, message)
+	require.NoError(t, err)
+	require.NoError(t, SignRound0(state1, []*Message{SignCommitMessage(state3)}))
+
+	_, other, err := SignInit(signers, secrets[3], public, message)
+	require.NoError(t, err)
+	assert.True(t, errors.Is(SignRound0(state1, []*Message{SignCommitMessage(other)}), ErrWrongSession))
+}
@@ -0,0 +1,141 @@
+package frost
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+)
+
+// Coordinator runs a signing session between signers that only talk to it,
+// like the Aggregator, but takes their messages one at a time as they arrive.
+// It holds no secret share.
+//
+//  1. Add the Sign1 message of every signer. Once all are in, Add returns the
+//     commitments that must be sent to every signer, which passes them to SignRound1.
+//  2. Add the Sign2 message of every signer. Each share is verified as it
+//     arrives, so that an invalid one is attributed to its sender right away.
+//     Once all are in, Signature returns the signature.
+//
+// Missing returns the signers whose message for the current step has not
+// arrived, e.g. to blame them after a timeout.
+type Coordinator struct {
+	// Aggregator computes the signature. Its Request and RFC9591 must be set
+	// before the first message is added, if the signers use them.
+	Aggregator *Aggregator
+
+	commitments map[party.ID]*Message
+	forwarded   []*Message
+	shares      map[party.ID]*Message
+	signature   *eddsa.Signature
+}
+
+// NewCoordinator returns a Coordinator for a signing session between signerIDs.
+func NewCoordinator(signerIDs party.IDSlice, shares *eddsa.Public, message []byte) (*Coordinator, error) {
+	agg, err := NewAggregator(signerIDs, shares, message)
+	if err != nil {
+		return nil, err
+	}
+	return &Coordinator{
+		Aggregator:  agg,
+		commitments: make(map[party.ID]*Message, len(agg.SignerIDs)),
+		shares:      make(map[party.ID]*Message, len(agg.SignerIDs)),
+	}, nil
+}
+
+// Add processes a Sign1 or Sign2 message. When the last Sign1 message was
+// added, it returns the messages to send to every signer.
+func (c *Coordinator) Add(msg *Message) ([]*Message, error) {
+	if _, ok := c.Aggregator.Signers[msg.From]; !ok {
+		return nil, fmt.Errorf("Coordinator: party %d is not a signer", msg.From)
+	}
+	switch {
+	case msg.Type == MessageTypeSign1 && msg.Sign1 != nil:
+		return c.addCommitment(msg)
+	case msg.Type == MessageTypeSign2 && msg.Sign2 != nil:
+		return nil, c.addShare(msg)
+	default:
+		return nil, errors.New("Coordinator: invalid message type")
+	}
+}
+
+func (c *Coordinator) addCommitment(msg *Message) ([]*Message, error) {
+	if c.forwarded != nil {
+		return nil, fmt.Errorf("Coordinator: commitment of party %d after all commitments were forwarded", msg.From)
+	}
+	if _, ok := c.commitments[msg.From]; ok {
+		return nil, fmt.Errorf("Coordinator: duplicate commitment from party %d", msg.From)
+	}
+	// an invalid commitment is not stored, so that its sender can resend it
+	if err := c.Aggregator.checkCommitment(msg); err != nil {
+		return nil, fmt.Errorf("Coordinator: %w", err)
+	}
+	c.commitments[msg.From] = msg
+	if len(c.commitments) < len(c.Aggregator.SignerIDs) {
+		return nil, nil
+	}
+
+	msgs := make([]*Message, 0, len(c.commitments))
+	for _, id := range c.Aggregator.SignerIDs {
+		msgs = append(msgs, c.commitments[id])
+	}
+	forwarded, err := c.Aggregator.AddCommitments(msgs)
+	if err != nil {
+		return nil, err
+	}
+	c.forwarded = forwarded
+	return forwarded, nil
+}
+
+func (c *Coordinator) addShare(msg *Message) error {
+	if c.forwarded == nil {
+		return fmt.Errorf("Coordinator: signature share of party %d before the commitments were forwarded", msg.From)
+	}
+	if _, ok := c.shares[msg.From]; ok {
+		return fmt.Errorf("Coordinator: duplicate signature share from party %d", msg.From)
+	}
+	// an invalid share is not stored either
+	if err := msg.Validate(MessageTypeSign2, c.Aggregator.SessionID, 0); err != nil {
+		return fmt.Errorf("Coordinator: %w", err)
+	}
+	if !c.Aggregator.Signers[msg.From].verifyShare(&c.Aggregator.C, &msg.Sign2.Zi) {
+		return fmt.Errorf("Coordinator: %w", c.Aggregator.abortError(msg))
+	}
+	c.shares[msg.From] = msg
+	if len(c.shares) < len(c.Aggregator.SignerIDs) {
+		return nil
+	}
+
+	msgs := make([]*Message, 0, len(c.shares))
+	for _, id := range c.Aggregator.SignerIDs {
+		msgs = append(msgs, c.shares[id])
+	}
+	sig, err := c.Aggregator.Aggregate(msgs)
+	if err != nil {
+		return err
+	}
+	c.signature = sig
+	return nil
+}
+
+// Missing returns the signers whose commitment, or once all commitments were
+// forwarded, whose signature share has not been added.
+func (c *Coordinator) Missing() party.IDSlice {
+	received := c.commitments
+	if c.forwarded != nil {
+		received = c.shares
+	}
+	missing := make(party.IDSlice, 0, len(c.Aggregator.SignerIDs)-len(received))
+	for _, id := range c.Aggregator.SignerIDs {
+		if _, ok := received[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// Signature returns the signature, and false if not all shares were added yet.
+func (c *Coordinator) Signature() (*eddsa.Signature, bool) {
+	return c.signature, c.signature != nil
+}
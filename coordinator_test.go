@@ -0,0 +1,111 @@
+package frost
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoordinator(t *testing.T) {
+	public, secrets := generateKeys(t, 5, 2)
+	signers := party.IDSlice{1, 4, 5}
+	message := []byte("coordinated")
+
+	c, err := NewCoordinator(signers, public, message)
+	require.NoError(t, err)
+
+	states := make(map[party.ID]*SignerState)
+	var forwarded []*Message
+	for i, id := range []party.ID{5, 1, 4} {
+		assert.Len(t, c.Missing(), 3-i)
+		msg, state, err := SignInit(signers, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+
+		_, err = c.Add(NewSign2(id, scalar.NewScalarRandom()))
+		assert.Error(t, err, "shares before the commitments were forwarded")
+		forwarded, err = c.Add(msg)
+		require.NoError(t, err)
+		if i < 2 {
+			assert.Nil(t, forwarded)
+			_, err = c.Add(msg)
+			assert.Error(t, err, "duplicate")
+		}
+	}
+	require.Len(t, forwarded, 3)
+	assert.Equal(t, signers, c.Missing(), "all shares are missing")
+
+	_, err = c.Add(NewSign1(2, &states[1].Signers[1].Di, &states[1].Signers[1].Ei))
+	assert.Error(t, err, "not a signer")
+
+	for _, id := range signers {
+		msg, _, err := SignRound1(states[id], forwarded)
+		require.NoError(t, err)
+		if id == 4 {
+			// an invalid share is attributed when it arrives, the valid one can follow
+			_, err = c.Add(NewSign2(id, scalar.NewScalarRandom()))
+			assert.EqualError(t, err, "Coordinator: signature share of party 4 is invalid")
+		}
+		if id == 5 {
+			// so is a valid share sent to a single party
+			direct := *msg
+			direct.To = 1
+			_, err = c.Add(&direct)
+			assert.True(t, errors.Is(err, ErrInvalidMessage))
+		}
+		_, ok := c.Signature()
+		assert.False(t, ok)
+		_, err = c.Add(msg)
+		require.NoError(t, err)
+	}
+	assert.Empty(t, c.Missing())
+	sig, ok := c.Signature()
+	require.True(t, ok)
+	assert.True(t, public.GroupKey.Verify(message, sig))
+}
+
+func TestCoordinatorInvalidCommitment(t *testing.T) {
+	public, secrets := generateKeys(t, 5, 2)
+	signers := party.IDSlice{1, 4, 5}
+	message := []byte("coordinated")
+
+	c, err := NewCoordinator(signers, public, message)
+	require.NoError(t, err)
+
+	msgs := make(map[party.ID]*Message)
+	states := make(map[party.ID]*SignerState)
+	for _, id := range signers {
+		msgs[id], states[id], err = SignInit(signers, secrets[id], public, message)
+		require.NoError(t, err)
+	}
+
+	// the middle signer sends the identity, which is rejected on arrival
+	_, err = c.Add(msgs[1])
+	require.NoError(t, err)
+	_, err = c.Add(NewSign1(4, ristretto.NewIdentityElement(), &msgs[4].Sign1.Ei))
+	assert.True(t, errors.Is(err, ErrInvalidMessage))
+	assert.Equal(t, party.IDSlice{4, 5}, c.Missing())
+	forwarded, err := c.Add(msgs[5])
+	require.NoError(t, err)
+	assert.Nil(t, forwarded, "the commitment of party 4 is missing")
+
+	// its valid commitment is accepted when resent
+	forwarded, err = c.Add(msgs[4])
+	require.NoError(t, err)
+	require.Len(t, forwarded, 3)
+
+	for _, id := range signers {
+		msg, _, err := SignRound1(states[id], forwarded)
+		require.NoError(t, err)
+		_, err = c.Add(msg)
+		require.NoError(t, err)
+	}
+	sig, ok := c.Signature()
+	require.True(t, ok)
+	assert.True(t, public.GroupKey.Verify(message, sig))
+}
@@ -0,0 +1,297 @@
+package frost_test
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/frostclient"
+	"github.com/bartke/frost/manifest"
+	"github.com/bartke/frost/migrate"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/sealed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The golden corpus in testdata/corpus holds one directory of serialized
+// artifacts per format version. Every release must decode all of them.
+//
+// When a format changes, bump corpusVersion and run
+//
+//	go test -run TestCorpus -corpus.write .
+//
+// to add the new directory. Directories of earlier versions must never be modified.
+const corpusVersion = "v3"
+
+// corpusArtifacts maps the files of the corpus that are persisted with a
+// header since version 3 to their kind, see frost.EncodeArtifact.
+var corpusArtifacts = map[string]frost.ArtifactKind{
+	"keygen1.json":      frost.ArtifactMessage,
+	"keygen2.json":      frost.ArtifactMessage,
+	"sign1.json":        frost.ArtifactMessage,
+	"sign2.json":        frost.ArtifactMessage,
+	"keygen_state.json": frost.ArtifactKeygenState,
+	"signer_state.json": frost.ArtifactSignerState,
+	"public.json":       frost.ArtifactPublic,
+	"secret.dat":        frost.ArtifactSecretShare,
+	"secret.json":       frost.ArtifactSecretShare,
+}
+
+const (
+	corpusMessage    = "golden corpus"
+	corpusPassphrase = "corpus"
+)
+
+var writeCorpus = flag.Bool("corpus.write", false, "write the corpus of the current format version")
+
+func TestCorpus(t *testing.T) {
+	if *writeCorpus {
+		generateCorpus(t, filepath.Join("testdata", "corpus", corpusVersion))
+	}
+
+	dirs, err := filepath.Glob(filepath.Join("testdata", "corpus", "v*"))
+	require.NoError(t, err)
+	require.NotEmpty(t, dirs)
+
+	for _, dir := range dirs {
+		dir := dir
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			checkCorpus(t, dir, filepath.Base(dir) == corpusVersion)
+		})
+	}
+}
+
+// TestCorpus_Legacy checks that the artifacts of releases before the first
+// versioned format can still be converted by the migrate package.
+func TestCorpus_Legacy(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "corpus", "legacy", "*"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		res, err := migrate.Migrate(data)
+		require.NoError(t, err, file)
+		assert.True(t, res.Legacy, file)
+
+		again, err := migrate.Migrate(res.Data)
+		require.NoError(t, err, file)
+		assert.False(t, again.Legacy, file)
+	}
+}
+
+func checkCorpus(t *testing.T, dir string, current bool) {
+	read := func(name string) []byte {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return data
+	}
+	version, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "v"))
+	require.NoError(t, err)
+	// decode decodes the file into v and, for the current version, checks that
+	// encoding v again gives the same bytes. Files ending in .dat are binary, the others JSON.
+	decode := func(name string, v interface{}) {
+		data := read(name)
+		if kind, ok := corpusArtifacts[name]; ok {
+			detected, detectedVersion, err := frost.DetectVersion(data)
+			require.NoError(t, err, name)
+			assert.Equal(t, kind, detected, name)
+			assert.True(t, detectedVersion >= 1 && detectedVersion <= version, name)
+			if version >= 3 {
+				assert.Equal(t, version, detectedVersion, name)
+			}
+			data, err = frost.DecodeArtifact(kind, data)
+			require.NoError(t, err, name)
+		}
+		if filepath.Ext(name) == ".dat" {
+			require.NoError(t, v.(encoding.BinaryUnmarshaler).UnmarshalBinary(data), name)
+		} else {
+			require.NoError(t, json.Unmarshal(data, v), name)
+		}
+		if !current {
+			return
+		}
+		encoded, err := encodeCorpus(name, v)
+		require.NoError(t, err, name)
+		assert.Equal(t, string(bytes.TrimSpace(read(name))), string(encoded), name)
+	}
+
+	var public eddsa.Public
+	decode("public.json", &public)
+
+	var secret, secretJSON eddsa.SecretShare
+	decode("secret.dat", &secret)
+	decode("secret.json", &secretJSON)
+	assert.True(t, secret.Equal(&secretJSON))
+	assert.Equal(t, 1, public.Shares[secret.ID].Equal(&secret.Public))
+
+	var sig eddsa.Signature
+	decode("signature.dat", &sig)
+	assert.True(t, public.GroupKey.Verify([]byte(corpusMessage), &sig))
+
+	var sigText eddsa.Signature
+	require.NoError(t, sigText.UnmarshalText(bytes.TrimSpace(read("signature.txt"))))
+	assert.True(t, sig.Equal(&sigText))
+
+	for name, typ := range map[string]frost.MessageType{
+		"keygen1.json": frost.MessageTypeKeyGen1,
+		"keygen2.json": frost.MessageTypeKeyGen2,
+		"sign1.json":   frost.MessageTypeSign1,
+		"sign2.json":   frost.MessageTypeSign2,
+	} {
+		var msg frost.Message
+		decode(name, &msg)
+		assert.Equal(t, typ, msg.Type, name)
+	}
+
+	var keygenState frost.KeygenState
+	decode("keygen_state.json", &keygenState)
+	assert.Equal(t, public.Threshold, keygenState.Threshold)
+
+	var signerState frost.SignerState
+	decode("signer_state.json", &signerState)
+	require.NotNil(t, signerState.Request)
+	assert.True(t, signerState.GroupKey.Equal(public.GroupKey))
+
+	var request frost.SignatureRequest
+	decode("request.json", &request)
+	assert.Equal(t, signerState.Request.Digest(), request.Digest())
+
+	var m manifest.Manifest
+	decode("manifest.json", &m)
+	assert.NoError(t, m.VerifySignature(public.GroupKey.ToEd25519()))
+
+	// the secret share is sealed as cmd/keygen writes it
+	opened, err := sealed.Open(read("secret.sealed"), []byte(corpusPassphrase))
+	require.NoError(t, err)
+	assert.Equal(t, read("secret.dat"), opened)
+}
+
+// encodeCorpus encodes v in binary if name ends in .dat, as JSON otherwise,
+// with the header of its kind if it is an artifact.
+func encodeCorpus(name string, v interface{}) ([]byte, error) {
+	var data []byte
+	var err error
+	if filepath.Ext(name) == ".dat" {
+		data, err = v.(encoding.BinaryMarshaler).MarshalBinary()
+	} else {
+		data, err = json.Marshal(v)
+	}
+	if kind, ok := corpusArtifacts[name]; ok && err == nil {
+		data = frost.EncodeArtifact(kind, data)
+	}
+	return data, err
+}
+
+func generateCorpus(t *testing.T, dir string) {
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	write := func(name string, data []byte) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0644))
+	}
+	marshal := func(name string, v interface{}) {
+		data, err := encodeCorpus(name, v)
+		require.NoError(t, err)
+		write(name, data)
+	}
+
+	const n, threshold = 3, 1
+	var (
+		keygen1      []*frost.Message
+		keygenStates = make(map[party.ID]*frost.KeygenState, n)
+	)
+	session, err := frost.NewSessionID()
+	require.NoError(t, err)
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, err := frost.KeygenInitWithSession(session, id, n, threshold)
+		require.NoError(t, err)
+		keygen1 = append(keygen1, msg)
+		keygenStates[id] = state
+	}
+	marshal("keygen1.json", keygen1[0])
+
+	keygen2 := make(map[party.ID][]*frost.Message, n)
+	for id := party.ID(1); id <= n; id++ {
+		msgs, _, err := frost.KeygenRound1(keygenStates[id], keygen1)
+		require.NoError(t, err)
+		for _, msg := range msgs {
+			keygen2[msg.To] = append(keygen2[msg.To], msg)
+		}
+	}
+	marshal("keygen2.json", keygen2[1][0])
+	marshal("keygen_state.json", keygenStates[1])
+
+	group := &frostclient.Group{Shares: make(map[party.ID]*eddsa.SecretShare, n)}
+	for id := party.ID(1); id <= n; id++ {
+		public, secret, err := frost.KeygenRound2(keygenStates[id], keygen2[id])
+		require.NoError(t, err)
+		group.Public, group.Shares[id] = public, secret
+	}
+	group.Signers = party.IDSlice{1, 2}
+	marshal("public.json", group.Public)
+	marshal("secret.dat", group.Shares[1])
+	marshal("secret.json", group.Shares[1])
+
+	secretData, err := encodeCorpus("secret.dat", group.Shares[1])
+	require.NoError(t, err)
+	sealedData, err := sealed.Seal(secretData, []byte(corpusPassphrase),
+		sealed.KDFParams{Algorithm: sealed.KDFScrypt, N: sealed.MinScryptN, R: 8, P: 1})
+	require.NoError(t, err)
+	write("secret.sealed", sealedData)
+
+	request := &frost.SignatureRequest{
+		ID:        "corpus-1",
+		Message:   []byte(corpusMessage),
+		Requester: "corpus",
+		Purpose:   "golden corpus",
+		Expiry:    time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC),
+		Metadata:  map[string]string{"version": corpusVersion},
+	}
+	marshal("request.json", request)
+
+	var sign1 []*frost.Message
+	signStates := make(map[party.ID]*frost.SignerState)
+	for _, id := range group.Signers {
+		msg, state, err := frost.SignInitWithRequest(group.Signers, group.Shares[id], group.Public, request)
+		require.NoError(t, err)
+		sign1 = append(sign1, msg)
+		signStates[id] = state
+	}
+	marshal("sign1.json", sign1[0])
+
+	var sign2 []*frost.Message
+	for _, id := range group.Signers {
+		msg, _, err := frost.SignRound1(signStates[id], sign1)
+		require.NoError(t, err)
+		sign2 = append(sign2, msg)
+	}
+	marshal("sign2.json", sign2[0])
+	marshal("signer_state.json", signStates[1])
+
+	sig, _, err := frost.SignRound2(signStates[1], sign2)
+	require.NoError(t, err)
+	marshal("signature.dat", sig)
+	text, err := sig.MarshalText()
+	require.NoError(t, err)
+	write("signature.txt", text)
+
+	tree := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tree, "release.txt"), []byte(corpusMessage), 0644))
+	m, err := manifest.Build(tree, 1)
+	require.NoError(t, err)
+	manifestSig, err := frostclient.Sign(context.Background(), group, m.Body())
+	require.NoError(t, err)
+	m.Attach(group.Public.GroupKey.ToEd25519(), manifestSig.ToEd25519())
+	marshal("manifest.json", m)
+}
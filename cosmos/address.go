@@ -0,0 +1,105 @@
+package cosmos
+
+import (
+	"crypto/sha256"
+	"errors"
+	"strings"
+
+	"github.com/bartke/frost/eddsa"
+)
+
+// PubKeyTypeURL is the type URL of ed25519 public keys in transactions.
+const PubKeyTypeURL = "/cosmos.crypto.ed25519.PubKey"
+
+// Address returns the 20 byte account address of pk, the truncated SHA-256 of its Ed25519 encoding.
+func Address(pk *eddsa.PublicKey) []byte {
+	h := sha256.Sum256(pk.ToEd25519())
+	return h[:20]
+}
+
+// Bech32Address returns the address of pk with the human readable prefix of a chain, e.g. "cosmos".
+func Bech32Address(hrp string, pk *eddsa.PublicKey) (string, error) {
+	return bech32Encode(hrp, convertBits(Address(pk)))
+}
+
+// PubKeyAny returns the protobuf encoding of pk wrapped in a google.protobuf.Any,
+// as used for the signer infos in the AuthInfo of a transaction.
+func PubKeyAny(pk *eddsa.PublicKey) []byte {
+	key := appendBytesField(nil, 1, pk.ToEd25519())
+	b := appendBytesField(nil, 1, []byte(PubKeyTypeURL))
+	return appendBytesField(b, 2, key)
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32Encode encodes the 5 bit groups in data as defined in BIP-173.
+func bech32Encode(hrp string, data []byte) (string, error) {
+	if hrp == "" || len(hrp)+len(data)+7 > 90 {
+		return "", errors.New("cosmos: invalid bech32 length")
+	}
+	if strings.ToLower(hrp) != hrp {
+		return "", errors.New("cosmos: bech32 prefix must be lower case")
+	}
+	for _, c := range []byte(hrp) {
+		if c < 33 || c > 126 {
+			return "", errors.New("cosmos: invalid character in bech32 prefix")
+		}
+	}
+
+	values := make([]byte, 0, 2*len(hrp)+1+len(data)+6)
+	for _, c := range []byte(hrp) {
+		values = append(values, c>>5)
+	}
+	values = append(values, 0)
+	for _, c := range []byte(hrp) {
+		values = append(values, c&31)
+	}
+	values = append(values, data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	checksum := bech32Polymod(values) ^ 1
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range data {
+		sb.WriteByte(bech32Charset[v])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(checksum>>(5*(5-i)))&31])
+	}
+	return sb.String(), nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+// convertBits regroups the bytes of data into 5 bit groups, padding the last one with zeros.
+func convertBits(data []byte) []byte {
+	out := make([]byte, 0, (len(data)*8+4)/5)
+	var acc uint32
+	var bits uint
+	for _, b := range data {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			out = append(out, byte(acc>>bits)&31)
+		}
+	}
+	if bits > 0 {
+		out = append(out, byte(acc<<(5-bits))&31)
+	}
+	return out
+}
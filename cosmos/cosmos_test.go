@@ -0,0 +1,92 @@
+package cosmos
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bartke/frost/frostclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdSignDoc_SignBytes(t *testing.T) {
+	doc := StdSignDoc{
+		AccountNumber: 3,
+		Sequence:      6,
+		ChainID:       "test-1",
+		Memo:          "a<b",
+		Fee:           json.RawMessage(`{"gas":"100000","amount":[{"denom":"uatom","amount":"150"}]}`),
+		Msgs:          []json.RawMessage{json.RawMessage(`{"value":{"to":"x","amount":12345678901234567890},"type":"cosmos-sdk/MsgSend"}`)},
+	}
+	b, err := doc.SignBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"account_number":"3","chain_id":"test-1",`+
+		`"fee":{"amount":[{"amount":"150","denom":"uatom"}],"gas":"100000"},"memo":"a\u003cb",`+
+		`"msgs":[{"type":"cosmos-sdk/MsgSend","value":{"amount":12345678901234567890,"to":"x"}}],"sequence":"6"}`, string(b))
+
+	doc.TimeoutHeight = 7
+	b, err = doc.SignBytes()
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"timeout_height":"7"`)
+
+	_, err = StdSignDoc{ChainID: "test-1"}.SignBytes()
+	assert.Error(t, err)
+}
+
+func TestSignDoc_SignBytes(t *testing.T) {
+	doc := &SignDoc{BodyBytes: []byte{1, 2}, AuthInfoBytes: []byte{3}, ChainID: "c", AccountNumber: 300}
+	assert.Equal(t, "0a020102120103"+"1a0163"+"20ac02", hex.EncodeToString(doc.SignBytes()))
+
+	// zero values are omitted
+	assert.Empty(t, (&SignDoc{}).SignBytes())
+}
+
+func TestVoteExtensionSignBytes(t *testing.T) {
+	b := VoteExtensionSignBytes("c", 5, 1, []byte{0xaa})
+	assert.Equal(t, "18"+"0a01aa"+"110500000000000000"+"190100000000000000"+"220163", hex.EncodeToString(b))
+}
+
+func TestBech32Encode(t *testing.T) {
+	s, err := bech32Encode("a", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "a12uel5l", s)
+
+	data := make([]byte, 32)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	s, err = bech32Encode("abcdef", data)
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", s)
+
+	// P2WPKH address of BIP-173: witness version 0 followed by the program
+	program, _ := hex.DecodeString("751e76e8199196d454941c45d1b3a323f1433bd6")
+	s, err = bech32Encode("bc", append([]byte{0}, convertBits(program)...))
+	require.NoError(t, err)
+	assert.Equal(t, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", s)
+
+	_, err = bech32Encode("Cosmos", nil)
+	assert.Error(t, err)
+}
+
+func TestSign(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	pk := group.Public.GroupKey
+
+	addr, err := Bech32Address("cosmos", pk)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(addr, "cosmos1"))
+	assert.Len(t, addr, len("cosmos1")+32+6)
+	assert.Len(t, Address(pk), 20)
+	assert.Contains(t, string(PubKeyAny(pk)), PubKeyTypeURL)
+
+	doc := &SignDoc{BodyBytes: []byte("body"), AuthInfoBytes: PubKeyAny(pk), ChainID: "test-1", AccountNumber: 1}
+	sig, err := frostclient.Sign(context.Background(), group, doc.SignBytes())
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(pk.ToEd25519(), doc.SignBytes(), sig.ToEd25519()))
+}
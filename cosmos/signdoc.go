@@ -0,0 +1,120 @@
+// Package cosmos builds the bytes signed by Cosmos SDK accounts and CometBFT
+// validators, so that chains accepting ed25519 keys can be used with a group key.
+//
+// The sign bytes are produced without depending on the Cosmos SDK: they are
+// passed as the message to the signing rounds, and the resulting signature is
+// used as is, in its 64 byte Ed25519 encoding.
+package cosmos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+)
+
+// StdSignDoc is the document signed in the legacy amino JSON sign mode
+// (SIGN_MODE_LEGACY_AMINO_JSON). Fee and Msgs hold the amino JSON of the
+// fee and of the messages of the transaction.
+type StdSignDoc struct {
+	AccountNumber uint64            `json:"account_number,string"`
+	Sequence      uint64            `json:"sequence,string"`
+	TimeoutHeight uint64            `json:"timeout_height,omitempty,string"`
+	ChainID       string            `json:"chain_id"`
+	Memo          string            `json:"memo"`
+	Fee           json.RawMessage   `json:"fee"`
+	Msgs          []json.RawMessage `json:"msgs"`
+}
+
+// SignBytes returns the canonical JSON encoding of doc, with the keys of all
+// objects sorted and without whitespace, as done by the Cosmos SDK.
+func (doc StdSignDoc) SignBytes() ([]byte, error) {
+	if doc.Fee == nil {
+		return nil, errors.New("cosmos: missing fee")
+	}
+	if doc.Msgs == nil {
+		doc.Msgs = []json.RawMessage{}
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return SortJSON(data)
+}
+
+// SortJSON re-encodes the JSON data with the keys of all objects sorted.
+// Numbers are kept as they are, and <, > and & in strings are escaped like the Cosmos SDK does.
+func SortJSON(data []byte) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	if d.More() {
+		return nil, errors.New("cosmos: trailing data after JSON value")
+	}
+	// encoding/json writes map keys in sorted order
+	return json.Marshal(v)
+}
+
+// SignDoc is the document signed in the protobuf sign mode (SIGN_MODE_DIRECT).
+// BodyBytes and AuthInfoBytes are the encoded TxBody and AuthInfo of the transaction.
+type SignDoc struct {
+	BodyBytes     []byte
+	AuthInfoBytes []byte
+	ChainID       string
+	AccountNumber uint64
+}
+
+// SignBytes returns the protobuf encoding of doc.
+func (doc *SignDoc) SignBytes() []byte {
+	var b []byte
+	b = appendBytesField(b, 1, doc.BodyBytes)
+	b = appendBytesField(b, 2, doc.AuthInfoBytes)
+	b = appendBytesField(b, 3, []byte(doc.ChainID))
+	if doc.AccountNumber != 0 {
+		b = appendTag(b, 4, wireVarint)
+		b = binary.AppendUvarint(b, doc.AccountNumber)
+	}
+	return b
+}
+
+// VoteExtensionSignBytes returns the bytes a CometBFT validator signs for the
+// vote extension of its precommit at height and round: the length prefixed
+// protobuf encoding of a CanonicalVoteExtension.
+func VoteExtensionSignBytes(chainID string, height, round int64, extension []byte) []byte {
+	var b []byte
+	b = appendBytesField(b, 1, extension)
+	if height != 0 {
+		b = appendTag(b, 2, wireFixed64)
+		b = binary.LittleEndian.AppendUint64(b, uint64(height))
+	}
+	if round != 0 {
+		b = appendTag(b, 3, wireFixed64)
+		b = binary.LittleEndian.AppendUint64(b, uint64(round))
+	}
+	b = appendBytesField(b, 4, []byte(chainID))
+	return append(binary.AppendUvarint(nil, uint64(len(b))), b...)
+}
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+func appendTag(b []byte, field, wireType uint64) []byte {
+	return binary.AppendUvarint(b, field<<3|wireType)
+}
+
+// appendBytesField appends a length delimited field, omitting it if it is empty as proto3 does.
+func appendBytesField(b []byte, field uint64, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
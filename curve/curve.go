@@ -0,0 +1,99 @@
+// Package curve abstracts the prime order groups that FROST can be
+// instantiated with, so that the rounds of the protocol in package
+// ciphersuite are written once for all of them:
+//
+//	Ristretto255  the group of package ristretto, as used by package frost
+//	Secp256k1     the curve of Bitcoin and Ethereum
+//	P256          the NIST P-256 curve
+//
+// The methods of Scalar and Point follow those of ristretto.Scalar and
+// ristretto.Element: they set the receiver and return it. Mixing values of
+// different groups panics.
+//
+// The scalar arithmetic and the point multiplications of all groups run in
+// constant time, so that they can be used on secrets. PublicScalarMult and
+// PublicScalarBaseMult are for public scalars only, and may run in variable
+// time once frost.AllowVarTime is called; TimingReport lists which operations
+// of a group run in which mode.
+package curve
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrInvalidEncoding is returned when bytes do not encode a scalar or point of the group.
+var ErrInvalidEncoding = errors.New("curve: invalid encoding")
+
+// Group is a group of prime order with a fixed generator.
+type Group interface {
+	// Name returns the name of the group, e.g. "P-256".
+	Name() string
+
+	// NewScalar returns the scalar zero.
+	NewScalar() Scalar
+	// NewPoint returns the identity.
+	NewPoint() Point
+
+	// ScalarSize is the length of the encoding of a scalar.
+	ScalarSize() int
+	// PointSize is the length of the encoding of a point.
+	PointSize() int
+
+	// RandomScalar returns a uniformly random scalar read from r.
+	RandomScalar(r io.Reader) (Scalar, error)
+}
+
+// Scalar is an integer modulo the order of its group.
+type Scalar interface {
+	Add(x, y Scalar) Scalar
+	Subtract(x, y Scalar) Scalar
+	Multiply(x, y Scalar) Scalar
+	Negate(x Scalar) Scalar
+	// Invert sets s to 1/x. The inverse of zero is zero.
+	Invert(x Scalar) Scalar
+	Set(x Scalar) Scalar
+	SetUint64(v uint64) Scalar
+
+	Equal(x Scalar) bool
+	IsZero() bool
+
+	// Bytes returns the canonical encoding of s: 32 bytes little endian for
+	// Ristretto255, and 32 bytes big endian for the other groups.
+	Bytes() []byte
+	// SetCanonicalBytes sets s to the canonical encoding b.
+	SetCanonicalBytes(b []byte) (Scalar, error)
+	// SetWideBytes sets s to b reduced modulo the order, in the byte order of
+	// Bytes. Ristretto255 requires 64 bytes, the other groups accept up to 64.
+	SetWideBytes(b []byte) (Scalar, error)
+}
+
+// Point is an element of its group.
+type Point interface {
+	Add(p, q Point) Point
+	Subtract(p, q Point) Point
+	Negate(p Point) Point
+	ScalarMult(s Scalar, p Point) Point
+	ScalarBaseMult(s Scalar) Point
+	Set(p Point) Point
+
+	Equal(p Point) bool
+	IsIdentity() bool
+
+	// Bytes returns the canonical encoding of p: 32 bytes for Ristretto255,
+	// and the compressed SEC 1 encoding for the other groups, where the
+	// identity, which has none, is encoded as PointSize zero bytes.
+	Bytes() []byte
+	// SetCanonicalBytes sets p to the canonical encoding b. The identity is
+	// rejected, since no valid protocol message contains it.
+	SetCanonicalBytes(b []byte) (Point, error)
+}
+
+// randomWide reads 64 bytes from r, which reduce to a scalar with a negligible bias.
+func randomWide(g Group, r io.Reader) (Scalar, error) {
+	var b [64]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return nil, err
+	}
+	return g.NewScalar().SetWideBytes(b[:])
+}
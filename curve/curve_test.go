@@ -0,0 +1,204 @@
+package curve
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/bartke/frost/internal/vartime"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var groups = []Group{Ristretto255(), Secp256k1(), P256()}
+
+func randomScalar(t *testing.T, g Group) Scalar {
+	s, err := g.RandomScalar(rand.Reader)
+	require.NoError(t, err)
+	return s
+}
+
+func TestScalar(t *testing.T) {
+	for _, g := range groups {
+		t.Run(g.Name(), func(t *testing.T) {
+			x, y := randomScalar(t, g), randomScalar(t, g)
+
+			sum := g.NewScalar().Add(x, y)
+			assert.True(t, g.NewScalar().Subtract(sum, y).Equal(x))
+			assert.True(t, g.NewScalar().Add(x, g.NewScalar().Negate(x)).IsZero())
+
+			product := g.NewScalar().Multiply(x, y)
+			inverse := g.NewScalar().Invert(y)
+			assert.True(t, g.NewScalar().Multiply(product, inverse).Equal(x))
+			assert.True(t, g.NewScalar().Invert(g.NewScalar()).IsZero())
+
+			three := g.NewScalar().SetUint64(3)
+			one := g.NewScalar().SetUint64(1)
+			two := g.NewScalar().SetUint64(2)
+			assert.True(t, g.NewScalar().Add(one, two).Equal(three))
+
+			b := x.Bytes()
+			require.Len(t, b, g.ScalarSize())
+			decoded, err := g.NewScalar().SetCanonicalBytes(b)
+			require.NoError(t, err)
+			assert.True(t, decoded.Equal(x))
+
+			_, err = g.NewScalar().SetCanonicalBytes(b[1:])
+			assert.True(t, errors.Is(err, ErrInvalidEncoding))
+			overflow := make([]byte, g.ScalarSize())
+			for i := range overflow {
+				overflow[i] = 0xff
+			}
+			_, err = g.NewScalar().SetCanonicalBytes(overflow)
+			assert.True(t, errors.Is(err, ErrInvalidEncoding))
+		})
+	}
+}
+
+func TestPoint(t *testing.T) {
+	for _, g := range groups {
+		t.Run(g.Name(), func(t *testing.T) {
+			x, y := randomScalar(t, g), randomScalar(t, g)
+			X := g.NewPoint().ScalarBaseMult(x)
+			Y := g.NewPoint().ScalarBaseMult(y)
+
+			// [x]B + [y]B = [x+y]B
+			sum := g.NewPoint().Add(X, Y)
+			assert.True(t, sum.Equal(g.NewPoint().ScalarBaseMult(g.NewScalar().Add(x, y))))
+			assert.True(t, g.NewPoint().Subtract(sum, Y).Equal(X))
+			assert.True(t, g.NewPoint().Add(X, g.NewPoint().Negate(X)).IsIdentity())
+			assert.True(t, g.NewPoint().Add(X, g.NewPoint()).Equal(X))
+
+			// [y]([x]B) = [xy]B
+			xy := g.NewScalar().Multiply(x, y)
+			assert.True(t, g.NewPoint().ScalarMult(y, X).Equal(g.NewPoint().ScalarBaseMult(xy)))
+			assert.True(t, g.NewPoint().ScalarMult(g.NewScalar(), X).IsIdentity())
+
+			// the receiver may alias the arguments
+			aliased := g.NewPoint().Set(X)
+			aliased.Add(aliased, aliased)
+			assert.True(t, aliased.Equal(g.NewPoint().ScalarBaseMult(g.NewScalar().Add(x, x))))
+
+			b := X.Bytes()
+			require.Len(t, b, g.PointSize())
+			decoded, err := g.NewPoint().SetCanonicalBytes(b)
+			require.NoError(t, err)
+			assert.True(t, decoded.Equal(X))
+
+			identity := g.NewPoint().Bytes()
+			require.Len(t, identity, g.PointSize())
+			_, err = g.NewPoint().SetCanonicalBytes(identity)
+			assert.True(t, errors.Is(err, ErrInvalidEncoding))
+			_, err = g.NewPoint().SetCanonicalBytes(b[1:])
+			assert.True(t, errors.Is(err, ErrInvalidEncoding))
+		})
+	}
+}
+
+// TestScalarBigInt checks the constant time scalar arithmetic of the groups
+// with big endian scalars against math/big, on random and edge values.
+func TestScalarBigInt(t *testing.T) {
+	orders := map[Group]*big.Int{Secp256k1(): k1Order, P256(): p256Order}
+	for g, n := range orders {
+		t.Run(g.Name(), func(t *testing.T) {
+			toBig := func(s Scalar) string { return new(big.Int).SetBytes(s.Bytes()).String() }
+			values := []Scalar{
+				g.NewScalar(),
+				g.NewScalar().SetUint64(1),
+				g.NewScalar().Negate(g.NewScalar().SetUint64(1)),
+				randomScalar(t, g),
+				randomScalar(t, g),
+			}
+			for _, x := range values {
+				for _, y := range values {
+					X, Y := new(big.Int).SetBytes(x.Bytes()), new(big.Int).SetBytes(y.Bytes())
+					sum := new(big.Int).Add(X, Y)
+					assert.Equal(t, sum.Mod(sum, n).String(), toBig(g.NewScalar().Add(x, y)))
+					diff := new(big.Int).Sub(X, Y)
+					assert.Equal(t, diff.Mod(diff, n).String(), toBig(g.NewScalar().Subtract(x, y)))
+					product := new(big.Int).Mul(X, Y)
+					assert.Equal(t, product.Mod(product, n).String(), toBig(g.NewScalar().Multiply(x, y)))
+				}
+				if !x.IsZero() {
+					inverse := new(big.Int).ModInverse(new(big.Int).SetBytes(x.Bytes()), n)
+					assert.Equal(t, inverse.String(), toBig(g.NewScalar().Invert(x)))
+				}
+			}
+
+			wide := make([]byte, 64)
+			for _, length := range []int{0, 1, 32, 33, 64} {
+				_, err := rand.Read(wide[:length])
+				require.NoError(t, err)
+				s, err := g.NewScalar().SetWideBytes(wide[:length])
+				require.NoError(t, err)
+				expected := new(big.Int).SetBytes(wide[:length])
+				assert.Equal(t, expected.Mod(expected, n).String(), toBig(s))
+			}
+			for i := range wide {
+				wide[i] = 0xff
+			}
+			s, err := g.NewScalar().SetWideBytes(wide)
+			require.NoError(t, err)
+			expected := new(big.Int).SetBytes(wide)
+			assert.Equal(t, expected.Mod(expected, n).String(), toBig(s))
+		})
+	}
+}
+
+func TestPublicScalarMult(t *testing.T) {
+	defer vartime.Allow(vartime.Allow(false))
+	for _, g := range groups {
+		t.Run(g.Name(), func(t *testing.T) {
+			X := g.NewPoint().ScalarBaseMult(randomScalar(t, g))
+			scalars := []Scalar{
+				g.NewScalar(),
+				g.NewScalar().SetUint64(1),
+				g.NewScalar().Negate(g.NewScalar().SetUint64(1)),
+				randomScalar(t, g),
+			}
+			for _, allow := range []bool{false, true} {
+				vartime.Allow(allow)
+				for _, s := range scalars {
+					assert.True(t, PublicScalarBaseMult(g.NewPoint(), s).Equal(g.NewPoint().ScalarBaseMult(s)))
+					assert.True(t, PublicScalarMult(g.NewPoint(), s, X).Equal(g.NewPoint().ScalarMult(s, X)))
+					assert.True(t, PublicScalarMult(g.NewPoint(), s, g.NewPoint()).IsIdentity())
+					assert.True(t, g.NewPoint().ScalarMult(s, g.NewPoint()).IsIdentity())
+				}
+			}
+
+			// [-1]B = -B
+			minusOne := g.NewScalar().Negate(g.NewScalar().SetUint64(1))
+			B := g.NewPoint().ScalarBaseMult(g.NewScalar().SetUint64(1))
+			assert.True(t, g.NewPoint().ScalarBaseMult(minusOne).Equal(g.NewPoint().Negate(B)))
+		})
+	}
+}
+
+func TestTimingReport(t *testing.T) {
+	defer vartime.Allow(vartime.Allow(false))
+	for _, g := range groups {
+		t.Run(g.Name(), func(t *testing.T) {
+			for _, allow := range []bool{false, true} {
+				vartime.Allow(allow)
+				report := TimingReport(g)
+				require.NotEmpty(t, report)
+				for _, timing := range report {
+					assert.True(t, !timing.Secret || timing.ConstantTime, timing.Operation)
+				}
+			}
+		})
+	}
+	vartime.Allow(true)
+	constantTime := func(g Group) (n int) {
+		for _, timing := range TimingReport(g) {
+			if timing.ConstantTime {
+				n++
+			}
+		}
+		return n
+	}
+	assert.Less(t, constantTime(Secp256k1()), len(TimingReport(Secp256k1()))-1)
+	assert.Equal(t, len(TimingReport(Ristretto255())), constantTime(Ristretto255()))
+}
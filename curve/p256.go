@@ -0,0 +1,301 @@
+package curve
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"math/bits"
+)
+
+type p256Group struct{}
+
+// P256 returns the group of the NIST P-256 curve. Its point operations are
+// those of crypto/elliptic, and its scalar arithmetic uses fixed size limbs,
+// both in constant time.
+func P256() Group { return p256Group{} }
+
+func (p256Group) Name() string      { return "P-256" }
+func (p256Group) NewScalar() Scalar { return &p256Scalar{} }
+func (p256Group) NewPoint() Point   { return &p256Point{} }
+func (p256Group) ScalarSize() int   { return 32 }
+func (p256Group) PointSize() int    { return 33 }
+
+func (g p256Group) RandomScalar(r io.Reader) (Scalar, error) { return randomWide(g, r) }
+
+// p256Order is the order of P-256.
+var p256Order = elliptic.P256().Params().N
+
+// The constants of the Montgomery multiplication modulo the order, with R = 2²⁵⁶.
+var (
+	// p256N is the order.
+	p256N = p256Limbs(p256Order)
+	// p256NInv is -n⁻¹ mod 2⁶⁴.
+	p256NInv = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 64), new(big.Int).ModInverse(new(big.Int).SetUint64(p256N[0]), new(big.Int).Lsh(big.NewInt(1), 64))).Uint64()
+	// p256R is R mod n, and p256RR is R² mod n.
+	p256R  = p256Limbs(new(big.Int).Mod(new(big.Int).Lsh(big.NewInt(1), 256), p256Order))
+	p256RR = p256Limbs(new(big.Int).Mod(new(big.Int).Lsh(big.NewInt(1), 512), p256Order))
+)
+
+// p256Limbs returns the limbs of x < 2²⁵⁶.
+func p256Limbs(x *big.Int) [4]uint64 {
+	var b [32]byte
+	x.FillBytes(b[:])
+	return p256FromBytes(&b)
+}
+
+// p256FromBytes returns the limbs of the 32 bytes big endian value b.
+func p256FromBytes(b *[32]byte) [4]uint64 {
+	var v [4]uint64
+	for i := range v {
+		v[i] = binary.BigEndian.Uint64(b[24-8*i:])
+	}
+	return v
+}
+
+// p256Scalar is an integer modulo the order, as four little endian 64 bit
+// limbs, always reduced. Its arithmetic runs in constant time.
+type p256Scalar struct {
+	v [4]uint64
+}
+
+// p256Select sets z to x if cond is 1, and to y if it is 0.
+func p256Select(z, x, y *[4]uint64, cond uint64) {
+	mask := -cond
+	for i := range z {
+		z[i] = x[i]&mask | y[i]&^mask
+	}
+}
+
+// p256Reduce sets z to x mod n, for x < 2²⁵⁶ + n, where carry is the bit 2²⁵⁶ of x.
+func p256Reduce(z, x *[4]uint64, carry uint64) {
+	var diff [4]uint64
+	var borrow uint64
+	for i := range diff {
+		diff[i], borrow = bits.Sub64(x[i], p256N[i], borrow)
+	}
+	// x < n if the subtraction borrowed more than the carry
+	_, borrow = bits.Sub64(carry, 0, borrow)
+	p256Select(z, x, &diff, borrow)
+}
+
+// p256MontMul sets z to x•y•R⁻¹ mod n, for x < R and y < n.
+func p256MontMul(z, x, y *[4]uint64) {
+	var t [6]uint64
+	for i := range x {
+		// t += x[i] • y
+		var c uint64
+		for j := range y {
+			hi, lo := bits.Mul64(x[i], y[j])
+			var cc uint64
+			lo, cc = bits.Add64(lo, t[j], 0)
+			hi += cc
+			lo, cc = bits.Add64(lo, c, 0)
+			hi += cc
+			t[j], c = lo, hi
+		}
+		t[4], t[5] = bits.Add64(t[4], c, 0)
+
+		// t = (t + m • n) / 2⁶⁴, where m makes the lowest limb zero
+		m := t[0] * p256NInv
+		hi, lo := bits.Mul64(m, p256N[0])
+		_, cc := bits.Add64(lo, t[0], 0)
+		c = hi + cc
+		for j := 1; j < 4; j++ {
+			hi, lo = bits.Mul64(m, p256N[j])
+			lo, cc = bits.Add64(lo, t[j], 0)
+			hi += cc
+			lo, cc = bits.Add64(lo, c, 0)
+			hi += cc
+			t[j-1], c = lo, hi
+		}
+		t[3], cc = bits.Add64(t[4], c, 0)
+		t[4], t[5] = t[5]+cc, 0
+	}
+	p256Reduce(z, (*[4]uint64)(t[:4]), t[4])
+}
+
+func (s *p256Scalar) Add(x, y Scalar) Scalar {
+	a, b := &x.(*p256Scalar).v, &y.(*p256Scalar).v
+	var sum [4]uint64
+	var carry uint64
+	for i := range sum {
+		sum[i], carry = bits.Add64(a[i], b[i], carry)
+	}
+	p256Reduce(&s.v, &sum, carry)
+	return s
+}
+
+func (s *p256Scalar) Subtract(x, y Scalar) Scalar {
+	a, b := &x.(*p256Scalar).v, &y.(*p256Scalar).v
+	var diff, n [4]uint64
+	var borrow uint64
+	for i := range diff {
+		diff[i], borrow = bits.Sub64(a[i], b[i], borrow)
+	}
+	// add n back if the subtraction borrowed
+	for i := range n {
+		n[i] = p256N[i] & -borrow
+	}
+	var carry uint64
+	for i := range s.v {
+		s.v[i], carry = bits.Add64(diff[i], n[i], carry)
+	}
+	return s
+}
+
+func (s *p256Scalar) Multiply(x, y Scalar) Scalar {
+	// x•y•R⁻¹ • R²•R⁻¹ = x•y
+	p256MontMul(&s.v, &x.(*p256Scalar).v, &y.(*p256Scalar).v)
+	p256MontMul(&s.v, &s.v, &p256RR)
+	return s
+}
+
+func (s *p256Scalar) Negate(x Scalar) Scalar {
+	return s.Subtract(&p256Scalar{}, x)
+}
+
+// Invert computes x^(n-2) in the Montgomery domain, with the bits of the
+// public exponent, so that it runs in constant time in x.
+func (s *p256Scalar) Invert(x Scalar) Scalar {
+	var xR, acc [4]uint64
+	p256MontMul(&xR, &x.(*p256Scalar).v, &p256RR)
+	acc = p256R
+	exponent := new(big.Int).Sub(p256Order, big.NewInt(2))
+	for i := exponent.BitLen() - 1; i >= 0; i-- {
+		p256MontMul(&acc, &acc, &acc)
+		if exponent.Bit(i) == 1 {
+			p256MontMul(&acc, &acc, &xR)
+		}
+	}
+	p256MontMul(&s.v, &acc, &[4]uint64{1})
+	return s
+}
+
+func (s *p256Scalar) Set(x Scalar) Scalar {
+	s.v = x.(*p256Scalar).v
+	return s
+}
+
+func (s *p256Scalar) SetUint64(v uint64) Scalar {
+	s.v = [4]uint64{v}
+	return s
+}
+
+func (s *p256Scalar) Equal(x Scalar) bool {
+	var diff uint64
+	for i, v := range x.(*p256Scalar).v {
+		diff |= s.v[i] ^ v
+	}
+	return diff == 0
+}
+
+func (s *p256Scalar) IsZero() bool { return s.v[0]|s.v[1]|s.v[2]|s.v[3] == 0 }
+
+func (s *p256Scalar) Bytes() []byte {
+	b := make([]byte, 32)
+	for i, v := range s.v {
+		binary.BigEndian.PutUint64(b[24-8*i:], v)
+	}
+	return b
+}
+
+func (s *p256Scalar) SetCanonicalBytes(b []byte) (Scalar, error) {
+	if len(b) != 32 {
+		return nil, fmt.Errorf("%w: scalar of %d bytes", ErrInvalidEncoding, len(b))
+	}
+	v := p256FromBytes((*[32]byte)(b))
+	var borrow uint64
+	for i := range v {
+		_, borrow = bits.Sub64(v[i], p256N[i], borrow)
+	}
+	if borrow == 0 {
+		return nil, fmt.Errorf("%w: scalar is not reduced", ErrInvalidEncoding)
+	}
+	s.v = v
+	return s, nil
+}
+
+func (s *p256Scalar) SetWideBytes(b []byte) (Scalar, error) {
+	if len(b) > 64 {
+		return nil, fmt.Errorf("%w: %d bytes", ErrInvalidEncoding, len(b))
+	}
+	var wide [64]byte
+	copy(wide[64-len(b):], b)
+	// hi•2²⁵⁶ + lo, where hi•2²⁵⁶ = hi•R²•R⁻¹
+	hi, lo := p256FromBytes((*[32]byte)(wide[:32])), p256FromBytes((*[32]byte)(wide[32:]))
+	p256MontMul(&hi, &hi, &p256RR)
+	p256Reduce(&lo, &lo, 0)
+	return s.Add(&p256Scalar{v: hi}, &p256Scalar{v: lo}), nil
+}
+
+// p256Point is a point in affine coordinates, where (0, 0) is the identity
+// as in crypto/elliptic. The zero value is the identity.
+type p256Point struct {
+	x, y big.Int
+}
+
+func (p *p256Point) set(x, y *big.Int) Point {
+	p.x.Set(x)
+	p.y.Set(y)
+	return p
+}
+
+func (p *p256Point) Add(q, r Point) Point {
+	a, b := q.(*p256Point), r.(*p256Point)
+	return p.set(elliptic.P256().Add(&a.x, &a.y, &b.x, &b.y))
+}
+
+func (p *p256Point) Subtract(q, r Point) Point {
+	var neg p256Point
+	neg.Negate(r)
+	return p.Add(q, &neg)
+}
+
+func (p *p256Point) Negate(q Point) Point {
+	p.Set(q)
+	if !p.IsIdentity() {
+		p.y.Sub(elliptic.P256().Params().P, &p.y)
+	}
+	return p
+}
+
+func (p *p256Point) ScalarMult(s Scalar, q Point) Point {
+	a := q.(*p256Point)
+	if a.IsIdentity() {
+		return p.Set(a)
+	}
+	return p.set(elliptic.P256().ScalarMult(&a.x, &a.y, s.Bytes()))
+}
+
+func (p *p256Point) ScalarBaseMult(s Scalar) Point {
+	return p.set(elliptic.P256().ScalarBaseMult(s.Bytes()))
+}
+
+func (p *p256Point) Set(q Point) Point {
+	a := q.(*p256Point)
+	return p.set(&a.x, &a.y)
+}
+
+func (p *p256Point) Equal(q Point) bool {
+	a := q.(*p256Point)
+	return p.x.Cmp(&a.x) == 0 && p.y.Cmp(&a.y) == 0
+}
+
+func (p *p256Point) IsIdentity() bool { return p.x.Sign() == 0 && p.y.Sign() == 0 }
+
+func (p *p256Point) Bytes() []byte {
+	if p.IsIdentity() {
+		return make([]byte, 33)
+	}
+	return elliptic.MarshalCompressed(elliptic.P256(), &p.x, &p.y)
+}
+
+func (p *p256Point) SetCanonicalBytes(b []byte) (Point, error) {
+	x, y := elliptic.UnmarshalCompressed(elliptic.P256(), b)
+	if x == nil {
+		return nil, ErrInvalidEncoding
+	}
+	return p.set(x, y), nil
+}
@@ -0,0 +1,137 @@
+package curve
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/bartke/frost/ristretto"
+)
+
+type ristretto255 struct{}
+
+// Ristretto255 returns the group of package ristretto.
+func Ristretto255() Group { return ristretto255{} }
+
+func (ristretto255) Name() string      { return "ristretto255" }
+func (ristretto255) NewScalar() Scalar { return &r255Scalar{} }
+func (ristretto255) NewPoint() Point   { return &r255Point{p: *ristretto.NewIdentityElement()} }
+func (ristretto255) ScalarSize() int   { return 32 }
+func (ristretto255) PointSize() int    { return 32 }
+
+func (g ristretto255) RandomScalar(r io.Reader) (Scalar, error) { return randomWide(g, r) }
+
+type r255Scalar struct {
+	s ristretto.Scalar
+}
+
+func (s *r255Scalar) Add(x, y Scalar) Scalar {
+	s.s.Add(&x.(*r255Scalar).s, &y.(*r255Scalar).s)
+	return s
+}
+
+func (s *r255Scalar) Subtract(x, y Scalar) Scalar {
+	s.s.Subtract(&x.(*r255Scalar).s, &y.(*r255Scalar).s)
+	return s
+}
+
+func (s *r255Scalar) Multiply(x, y Scalar) Scalar {
+	s.s.Multiply(&x.(*r255Scalar).s, &y.(*r255Scalar).s)
+	return s
+}
+
+func (s *r255Scalar) Negate(x Scalar) Scalar {
+	s.s.Negate(&x.(*r255Scalar).s)
+	return s
+}
+
+func (s *r255Scalar) Invert(x Scalar) Scalar {
+	s.s.Invert(&x.(*r255Scalar).s)
+	return s
+}
+
+func (s *r255Scalar) Set(x Scalar) Scalar {
+	s.s.Set(&x.(*r255Scalar).s)
+	return s
+}
+
+func (s *r255Scalar) SetUint64(v uint64) Scalar {
+	var b [32]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	if _, err := s.s.SetCanonicalBytes(b[:]); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *r255Scalar) Equal(x Scalar) bool { return s.s.Equal(&x.(*r255Scalar).s) == 1 }
+
+func (s *r255Scalar) IsZero() bool { return s.s.Equal(ristretto.NewScalar()) == 1 }
+
+func (s *r255Scalar) Bytes() []byte { return s.s.Bytes() }
+
+func (s *r255Scalar) SetCanonicalBytes(b []byte) (Scalar, error) {
+	if _, err := s.s.SetCanonicalBytes(b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	return s, nil
+}
+
+func (s *r255Scalar) SetWideBytes(b []byte) (Scalar, error) {
+	if _, err := s.s.SetUniformBytes(b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	return s, nil
+}
+
+type r255Point struct {
+	p ristretto.Element
+}
+
+func (p *r255Point) Add(q, r Point) Point {
+	p.p.Add(&q.(*r255Point).p, &r.(*r255Point).p)
+	return p
+}
+
+func (p *r255Point) Subtract(q, r Point) Point {
+	p.p.Subtract(&q.(*r255Point).p, &r.(*r255Point).p)
+	return p
+}
+
+func (p *r255Point) Negate(q Point) Point {
+	p.p.Negate(&q.(*r255Point).p)
+	return p
+}
+
+func (p *r255Point) ScalarMult(s Scalar, q Point) Point {
+	p.p.ScalarMult(&s.(*r255Scalar).s, &q.(*r255Point).p)
+	return p
+}
+
+func (p *r255Point) ScalarBaseMult(s Scalar) Point {
+	p.p.ScalarBaseMult(&s.(*r255Scalar).s)
+	return p
+}
+
+func (p *r255Point) Set(q Point) Point {
+	p.p.Set(&q.(*r255Point).p)
+	return p
+}
+
+func (p *r255Point) Equal(q Point) bool { return p.p.Equal(&q.(*r255Point).p) == 1 }
+
+func (p *r255Point) IsIdentity() bool { return p.p.Equal(ristretto.NewIdentityElement()) == 1 }
+
+func (p *r255Point) Bytes() []byte { return p.p.Bytes() }
+
+func (p *r255Point) SetCanonicalBytes(b []byte) (Point, error) {
+	var e ristretto.Element
+	if _, err := e.SetCanonicalBytes(b); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	if e.Equal(ristretto.NewIdentityElement()) == 1 {
+		return nil, fmt.Errorf("%w: identity", ErrInvalidEncoding)
+	}
+	p.p.Set(&e)
+	return p, nil
+}
@@ -0,0 +1,211 @@
+package curve
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+type secp256k1Group struct{}
+
+// Secp256k1 returns the group of the secp256k1 curve. Its scalar arithmetic
+// and point multiplications are constant time; its point additions and
+// comparisons are variable time, and are only used on public points.
+func Secp256k1() Group { return secp256k1Group{} }
+
+func (secp256k1Group) Name() string      { return "secp256k1" }
+func (secp256k1Group) NewScalar() Scalar { return &k1Scalar{} }
+func (secp256k1Group) NewPoint() Point   { return &k1Point{} }
+func (secp256k1Group) ScalarSize() int   { return 32 }
+func (secp256k1Group) PointSize() int    { return secp256k1.PubKeyBytesLenCompressed }
+
+func (g secp256k1Group) RandomScalar(r io.Reader) (Scalar, error) { return randomWide(g, r) }
+
+// k1Order is the order of secp256k1.
+var k1Order = secp256k1.S256().N
+
+type k1Scalar struct {
+	s secp256k1.ModNScalar
+}
+
+func (s *k1Scalar) Add(x, y Scalar) Scalar {
+	s.s.Add2(&x.(*k1Scalar).s, &y.(*k1Scalar).s)
+	return s
+}
+
+func (s *k1Scalar) Subtract(x, y Scalar) Scalar {
+	var neg secp256k1.ModNScalar
+	neg.NegateVal(&y.(*k1Scalar).s)
+	s.s.Add2(&x.(*k1Scalar).s, &neg)
+	return s
+}
+
+func (s *k1Scalar) Multiply(x, y Scalar) Scalar {
+	s.s.Mul2(&x.(*k1Scalar).s, &y.(*k1Scalar).s)
+	return s
+}
+
+func (s *k1Scalar) Negate(x Scalar) Scalar {
+	s.s.NegateVal(&x.(*k1Scalar).s)
+	return s
+}
+
+// Invert computes x^(n-2), with the bits of the public exponent, since the
+// InverseValNonConst of package secp256k1 is variable time.
+func (s *k1Scalar) Invert(x Scalar) Scalar {
+	var acc secp256k1.ModNScalar
+	base := x.(*k1Scalar).s
+	acc.SetInt(1)
+	for i := k1OrderMinus2.BitLen() - 1; i >= 0; i-- {
+		acc.Square()
+		if k1OrderMinus2.Bit(i) == 1 {
+			acc.Mul(&base)
+		}
+	}
+	s.s.Set(&acc)
+	return s
+}
+
+func (s *k1Scalar) Set(x Scalar) Scalar {
+	s.s.Set(&x.(*k1Scalar).s)
+	return s
+}
+
+func (s *k1Scalar) SetUint64(v uint64) Scalar {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	s.s.SetByteSlice(b[:])
+	return s
+}
+
+func (s *k1Scalar) Equal(x Scalar) bool { return s.s.Equals(&x.(*k1Scalar).s) }
+
+func (s *k1Scalar) IsZero() bool { return s.s.IsZero() }
+
+func (s *k1Scalar) Bytes() []byte {
+	b := s.s.Bytes()
+	return b[:]
+}
+
+func (s *k1Scalar) SetCanonicalBytes(b []byte) (Scalar, error) {
+	if len(b) != 32 {
+		return nil, fmt.Errorf("%w: scalar of %d bytes", ErrInvalidEncoding, len(b))
+	}
+	var v secp256k1.ModNScalar
+	if overflow := v.SetByteSlice(b); overflow {
+		return nil, fmt.Errorf("%w: scalar is not reduced", ErrInvalidEncoding)
+	}
+	s.s.Set(&v)
+	return s, nil
+}
+
+func (s *k1Scalar) SetWideBytes(b []byte) (Scalar, error) {
+	if len(b) > 64 {
+		return nil, fmt.Errorf("%w: %d bytes", ErrInvalidEncoding, len(b))
+	}
+	var wide [64]byte
+	copy(wide[64-len(b):], b)
+	// hi•2²⁵⁶ + lo, where both halves are reduced by SetByteSlice
+	var hi, lo secp256k1.ModNScalar
+	hi.SetByteSlice(wide[:32])
+	lo.SetByteSlice(wide[32:])
+	s.s.Mul2(&hi, &k1Wide).Add(&lo)
+	return s, nil
+}
+
+// k1Point is a point in Jacobian coordinates. The zero value is the identity.
+type k1Point struct {
+	p secp256k1.JacobianPoint
+}
+
+func (p *k1Point) Add(q, r Point) Point {
+	var sum secp256k1.JacobianPoint
+	secp256k1.AddNonConst(&q.(*k1Point).p, &r.(*k1Point).p, &sum)
+	p.p.Set(&sum)
+	return p
+}
+
+func (p *k1Point) Subtract(q, r Point) Point {
+	var neg k1Point
+	neg.Negate(r)
+	return p.Add(q, &neg)
+}
+
+func (p *k1Point) Negate(q Point) Point {
+	p.p.Set(&q.(*k1Point).p)
+	if p.IsIdentity() {
+		return p
+	}
+	p.p.ToAffine()
+	p.p.Y.Negate(1).Normalize()
+	return p
+}
+
+func (p *k1Point) ScalarMult(s Scalar, q Point) Point {
+	var base, product k1Projective
+	var t k1Table
+	t.init(base.fromJacobian(&q.(*k1Point).p))
+	product.mult(&s.(*k1Scalar).s, &t).toJacobian(&p.p)
+	return p
+}
+
+func (p *k1Point) ScalarBaseMult(s Scalar) Point {
+	var product k1Projective
+	product.mult(&s.(*k1Scalar).s, k1BaseTable()).toJacobian(&p.p)
+	return p
+}
+
+// scalarMultVarTime sets p to s•q in variable time, for a public s.
+func (p *k1Point) scalarMultVarTime(s Scalar, q Point) Point {
+	var product secp256k1.JacobianPoint
+	secp256k1.ScalarMultNonConst(&s.(*k1Scalar).s, &q.(*k1Point).p, &product)
+	p.p.Set(&product)
+	return p
+}
+
+// scalarBaseMultVarTime sets p to s•B in variable time, for a public s.
+func (p *k1Point) scalarBaseMultVarTime(s Scalar) Point {
+	secp256k1.ScalarBaseMultNonConst(&s.(*k1Scalar).s, &p.p)
+	return p
+}
+
+func (p *k1Point) Set(q Point) Point {
+	p.p.Set(&q.(*k1Point).p)
+	return p
+}
+
+func (p *k1Point) Equal(q Point) bool {
+	other := q.(*k1Point)
+	if p.IsIdentity() || other.IsIdentity() {
+		return p.IsIdentity() == other.IsIdentity()
+	}
+	return p.p.EquivalentNonConst(&other.p)
+}
+
+// IsIdentity follows the conventions of the secp256k1 package for the point at infinity.
+func (p *k1Point) IsIdentity() bool {
+	return (p.p.X.IsZero() && p.p.Y.IsZero()) || p.p.Z.IsZero()
+}
+
+func (p *k1Point) Bytes() []byte {
+	if p.IsIdentity() {
+		return make([]byte, secp256k1.PubKeyBytesLenCompressed)
+	}
+	affine := p.p
+	affine.ToAffine()
+	return secp256k1.NewPublicKey(&affine.X, &affine.Y).SerializeCompressed()
+}
+
+func (p *k1Point) SetCanonicalBytes(b []byte) (Point, error) {
+	if len(b) != secp256k1.PubKeyBytesLenCompressed {
+		return nil, fmt.Errorf("%w: point of %d bytes", ErrInvalidEncoding, len(b))
+	}
+	key, err := secp256k1.ParsePubKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	key.AsJacobian(&p.p)
+	return p, nil
+}
@@ -0,0 +1,173 @@
+package curve
+
+import (
+	"crypto/subtle"
+	"math/big"
+	"sync"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// The scalar multiplications of package secp256k1 are variable time, so the
+// ones of Secp256k1 use the complete addition formulas of Renes, Costello and
+// Batina (https://eprint.iacr.org/2015/1060, algorithm 7) on projective
+// coordinates, with a fixed window and a constant time table lookup. Every
+// field value is kept normalized, so that the magnitudes never matter.
+
+// k1Projective is the point (X/Z, Y/Z), or the identity (0, 1, 0).
+type k1Projective struct {
+	X, Y, Z secp256k1.FieldVal
+}
+
+// k1Add sets r to a + b, for field values of magnitude 1.
+func k1Add(r, a, b *secp256k1.FieldVal) { r.Add2(a, b).Normalize() }
+
+// k1Sub sets r to a - b, for field values of magnitude 1.
+func k1Sub(r, a, b *secp256k1.FieldVal) {
+	var neg secp256k1.FieldVal
+	neg.NegateVal(b, 1)
+	r.Add2(a, &neg).Normalize()
+}
+
+// k1Mul sets r to a • b, for field values of magnitude 1.
+func k1Mul(r, a, b *secp256k1.FieldVal) { r.Mul2(a, b).Normalize() }
+
+// k1B3 is 3b, where y² = x³ + b.
+const k1B3 = 21
+
+// add sets p to q + r. It also doubles, and handles the identity.
+func (p *k1Projective) add(q, r *k1Projective) *k1Projective {
+	var t0, t1, t2, t3, t4, x3, y3, z3 secp256k1.FieldVal
+	k1Mul(&t0, &q.X, &r.X)
+	k1Mul(&t1, &q.Y, &r.Y)
+	k1Mul(&t2, &q.Z, &r.Z)
+	k1Add(&t3, &q.X, &q.Y)
+	k1Add(&t4, &r.X, &r.Y)
+	k1Mul(&t3, &t3, &t4)
+	k1Add(&t4, &t0, &t1)
+	k1Sub(&t3, &t3, &t4)
+	k1Add(&t4, &q.Y, &q.Z)
+	k1Add(&x3, &r.Y, &r.Z)
+	k1Mul(&t4, &t4, &x3)
+	k1Add(&x3, &t1, &t2)
+	k1Sub(&t4, &t4, &x3)
+	k1Add(&x3, &q.X, &q.Z)
+	k1Add(&y3, &r.X, &r.Z)
+	k1Mul(&x3, &x3, &y3)
+	k1Add(&y3, &t0, &t2)
+	k1Sub(&y3, &x3, &y3)
+	k1Add(&x3, &t0, &t0)
+	k1Add(&t0, &x3, &t0)
+	t2.MulInt(k1B3).Normalize()
+	k1Add(&z3, &t1, &t2)
+	k1Sub(&t1, &t1, &t2)
+	y3.MulInt(k1B3).Normalize()
+	k1Mul(&x3, &t4, &y3)
+	k1Mul(&t2, &t3, &t1)
+	k1Sub(&x3, &t2, &x3)
+	k1Mul(&y3, &y3, &t0)
+	k1Mul(&t1, &t1, &z3)
+	k1Add(&y3, &t1, &y3)
+	k1Mul(&t0, &t0, &t3)
+	k1Mul(&z3, &z3, &t4)
+	k1Add(&z3, &z3, &t0)
+	p.X, p.Y, p.Z = x3, y3, z3
+	return p
+}
+
+// fromJacobian sets p to the point (X/Z², Y/Z³) of q, as (X•Z, Y, Z³).
+func (p *k1Projective) fromJacobian(q *secp256k1.JacobianPoint) *k1Projective {
+	if (&k1Point{p: *q}).IsIdentity() {
+		p.X.SetInt(0)
+		p.Y.SetInt(1)
+		p.Z.SetInt(0)
+		return p
+	}
+	var x, y, z, z2 secp256k1.FieldVal
+	x.Set(&q.X).Normalize()
+	y.Set(&q.Y).Normalize()
+	z.Set(&q.Z).Normalize()
+	k1Mul(&p.X, &x, &z)
+	p.Y.Set(&y)
+	k1Mul(&z2, &z, &z)
+	k1Mul(&p.Z, &z2, &z)
+	return p
+}
+
+// toJacobian sets q to p as (X•Z, Y•Z², Z). The identity becomes (0, 0, 0).
+func (p *k1Projective) toJacobian(q *secp256k1.JacobianPoint) {
+	var z2 secp256k1.FieldVal
+	k1Mul(&q.X, &p.X, &p.Z)
+	k1Mul(&z2, &p.Z, &p.Z)
+	k1Mul(&q.Y, &p.Y, &z2)
+	q.Z.Set(&p.Z)
+}
+
+// k1Table holds the multiples 0•q to 15•q of a point q.
+type k1Table [16]k1Projective
+
+func (t *k1Table) init(q *k1Projective) {
+	t[0] = k1Projective{}
+	t[0].Y.SetInt(1)
+	t[1] = *q
+	for i := 2; i < len(t); i++ {
+		t[i].add(&t[i-1], q)
+	}
+}
+
+// lookup sets p to t[i] without branching or indexing on i. Each entry is
+// multiplied by 0 or 1 and summed, so that the sum has magnitude at most 16.
+func (t *k1Table) lookup(p *k1Projective, i byte) {
+	var entry k1Projective
+	p.X.Zero()
+	p.Y.Zero()
+	p.Z.Zero()
+	for j := range t {
+		bit := uint8(subtle.ConstantTimeByteEq(byte(j), i))
+		p.X.Add(entry.X.Set(&t[j].X).MulInt(bit))
+		p.Y.Add(entry.Y.Set(&t[j].Y).MulInt(bit))
+		p.Z.Add(entry.Z.Set(&t[j].Z).MulInt(bit))
+	}
+	p.X.Normalize()
+	p.Y.Normalize()
+	p.Z.Normalize()
+}
+
+// mult sets p to s•q, where t holds the multiples of q, in constant time in s.
+func (p *k1Projective) mult(s *secp256k1.ModNScalar, t *k1Table) *k1Projective {
+	var acc, entry k1Projective
+	acc.Y.SetInt(1)
+	for _, b := range s.Bytes() {
+		for _, window := range [2]byte{b >> 4, b & 0xf} {
+			for i := 0; i < 4; i++ {
+				acc.add(&acc, &acc)
+			}
+			t.lookup(&entry, window)
+			acc.add(&acc, &entry)
+		}
+	}
+	*p = acc
+	return p
+}
+
+// k1BaseTable holds the multiples of the generator.
+var k1BaseTable = sync.OnceValue(func() *k1Table {
+	var one secp256k1.ModNScalar
+	var g secp256k1.JacobianPoint
+	one.SetInt(1)
+	secp256k1.ScalarBaseMultNonConst(&one, &g)
+	var base k1Projective
+	t := new(k1Table)
+	t.init(base.fromJacobian(&g))
+	return t
+})
+
+// k1Wide is 2²⁵⁶ mod n, used to reduce 64 bytes in constant time.
+var k1Wide = func() secp256k1.ModNScalar {
+	var s secp256k1.ModNScalar
+	s.SetByteSlice(new(big.Int).Mod(new(big.Int).Lsh(big.NewInt(1), 256), k1Order).Bytes())
+	return s
+}()
+
+// k1OrderMinus2 is the exponent of the inversion, n-2.
+var k1OrderMinus2 = new(big.Int).Sub(k1Order, big.NewInt(2))
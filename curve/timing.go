@@ -0,0 +1,59 @@
+package curve
+
+import "github.com/bartke/frost/internal/vartime"
+
+// Timing is the timing of an operation of a group, as listed by TimingReport.
+type Timing = vartime.Timing
+
+// PublicScalarMult sets p to s•q and returns p, like p.ScalarMult, for a
+// public s and q. It runs in variable time if frost.AllowVarTime was called
+// and the group has a faster variable time implementation, which only
+// Secp256k1 has.
+func PublicScalarMult(p Point, s Scalar, q Point) Point {
+	if k1, ok := p.(*k1Point); ok && vartime.Allowed() {
+		return k1.scalarMultVarTime(s, q)
+	}
+	return p.ScalarMult(s, q)
+}
+
+// PublicScalarBaseMult sets p to s•B and returns p, like p.ScalarBaseMult,
+// for a public s, in variable time under the same conditions as
+// PublicScalarMult.
+func PublicScalarBaseMult(p Point, s Scalar) Point {
+	if k1, ok := p.(*k1Point); ok && vartime.Allowed() {
+		return k1.scalarBaseMultVarTime(s)
+	}
+	return p.ScalarBaseMult(s)
+}
+
+// TimingReport lists the operations of g, whether they are used on secrets,
+// and whether they run in constant time with the current setting of
+// frost.AllowVarTime. It returns nil for a group not of this package.
+func TimingReport(g Group) []Timing {
+	scalars := Timing{Operation: "scalar arithmetic, including Invert and SetWideBytes", Secret: true, ConstantTime: true}
+	mult := Timing{Operation: "Point.ScalarMult and Point.ScalarBaseMult", Secret: true, ConstantTime: true}
+	switch g.(type) {
+	case ristretto255:
+		return []Timing{
+			scalars,
+			mult,
+			{Operation: "PublicScalarMult and PublicScalarBaseMult", Secret: false, ConstantTime: true},
+			{Operation: "point addition, negation and comparison", Secret: false, ConstantTime: true},
+		}
+	case secp256k1Group:
+		return []Timing{
+			scalars,
+			mult,
+			{Operation: "PublicScalarMult and PublicScalarBaseMult", Secret: false, ConstantTime: !vartime.Allowed()},
+			{Operation: "point addition and comparison", Secret: false, ConstantTime: false},
+		}
+	case p256Group:
+		return []Timing{
+			scalars,
+			mult,
+			{Operation: "PublicScalarMult and PublicScalarBaseMult", Secret: false, ConstantTime: true},
+			{Operation: "point comparison, on math/big coordinates", Secret: false, ConstantTime: false},
+		}
+	}
+	return nil
+}
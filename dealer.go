@@ -0,0 +1,109 @@
+package frost
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/polynomial"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/scalar"
+)
+
+// DealKeys generates the shares of a group of n parties, of which more than t
+// are needed to sign, with a trusted dealer instead of the interactive key
+// generation. The randomness is read from rng, or crypto/rand if it is nil.
+//
+// The dealer knows the group secret while it deals the shares, so this is
+// meant for test rigs and for migrating keys, not for production groups. Every
+// party must receive its share over a private channel, and should load it with
+// ImportDealerShare.
+func DealKeys(n, t party.Size, rng io.Reader) (*eddsa.Public, map[party.ID]*eddsa.SecretShare, error) {
+	if rng == nil {
+		rng = rand.Reader
+	}
+	var secret ristretto.Scalar
+	scalar.SetScalarRandomFrom(&secret, rng)
+	defer secret.Set(ristretto.NewScalar())
+	public, shares, err := dealShares(&secret, n, t, rng)
+	if err != nil {
+		return nil, nil, fmt.Errorf("DealKeys: %w", err)
+	}
+	return public, shares, nil
+}
+
+// dealShares splits secret into Shamir shares for the parties 1..n with threshold t.
+func dealShares(secret *ristretto.Scalar, n, t party.Size, rng io.Reader) (*eddsa.Public, map[party.ID]*eddsa.SecretShare, error) {
+	if n == 0 || t >= n {
+		return nil, nil, fmt.Errorf("threshold %d must be less than the number of parties %d", t, n)
+	}
+	poly := polynomial.NewPolynomialFrom(t, secret, rng)
+	defer poly.Reset()
+
+	shares := make(map[party.ID]*eddsa.SecretShare, n)
+	publicShares := make(map[party.ID]*ristretto.Element, n)
+	for id := party.ID(1); id <= n; id++ {
+		shares[id] = eddsa.NewSecretShare(id, poly.Evaluate(id.Scalar()))
+		publicShares[id] = &shares[id].Public
+	}
+	public, err := eddsa.NewPublic(publicShares, t)
+	if err != nil {
+		return nil, nil, err
+	}
+	return public, shares, nil
+}
+
+// ImportDealerShare decodes a share dealt by DealKeys, in the encoding of
+// eddsa.SecretShare.MarshalBinary, and checks it against the public
+// information of the group, so that it can be passed to SignInit.
+func ImportDealerShare(data []byte, public *eddsa.Public) (*eddsa.SecretShare, error) {
+	var share eddsa.SecretShare
+	if err := share.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("ImportDealerShare: %w", err)
+	}
+	expected, ok := public.Shares[share.ID]
+	if !ok || !public.PartyIDs.Contains(share.ID) {
+		return nil, fmt.Errorf("ImportDealerShare: party %d is not in the group", share.ID)
+	}
+	if expected.Equal(&share.Public) != 1 {
+		return nil, errors.New("ImportDealerShare: the share does not match the public share of the party")
+	}
+	return &share, nil
+}
+
+// SplitEd25519 splits the existing ed25519 private key priv into the shares of
+// a group of n parties with threshold t, whose group key is the public key of
+// priv, so that a key can move to threshold custody without changing its
+// verifiers. The secret scalar is derived from the seed of priv as in RFC 8032;
+// the other half of the seed hash, which ed25519 uses to derive its nonces, is
+// not needed, since the signers sample their nonces.
+//
+// Like DealKeys, SplitEd25519 is run by a dealer who knows the key, and priv
+// must be destroyed once the shares are distributed.
+func SplitEd25519(priv ed25519.PrivateKey, n, t party.Size) (*eddsa.Public, map[party.ID]*eddsa.SecretShare, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, nil, errors.New("SplitEd25519: invalid private key")
+	}
+	h := sha512.Sum512(priv.Seed())
+	defer clear(h[:])
+	var secret ristretto.Scalar
+	if _, err := secret.SetBytesWithClamping(h[:32]); err != nil {
+		return nil, nil, fmt.Errorf("SplitEd25519: %w", err)
+	}
+	defer secret.Set(ristretto.NewScalar())
+
+	public, shares, err := dealShares(&secret, n, t, rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("SplitEd25519: %w", err)
+	}
+	if !bytes.Equal(public.GroupKey.ToEd25519(), priv.Public().(ed25519.PublicKey)) {
+		return nil, nil, errors.New("SplitEd25519: the group key does not match the public key")
+	}
+	return public, shares, nil
+}
@@ -0,0 +1,97 @@
+package frost
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/hkdf"
+)
+
+func TestDealKeys(t *testing.T) {
+	public, shares, err := DealKeys(5, 2, nil)
+	require.NoError(t, err)
+	require.Len(t, shares, 5)
+
+	signers := party.IDSlice{2, 3, 5}
+	message := []byte("dealt")
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		data, err := shares[id].MarshalBinary()
+		require.NoError(t, err)
+		secret, err := ImportDealerShare(data, public)
+		require.NoError(t, err)
+		msg, state, err := SignInit(signers, secret, public, message)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	sigs, err := runSignRounds(states, round1)
+	require.NoError(t, err)
+	for _, sig := range sigs {
+		assert.True(t, public.GroupKey.Verify(message, sig))
+	}
+
+	// a share of another group is rejected
+	other, otherShares, err := DealKeys(5, 2, nil)
+	require.NoError(t, err)
+	data, err := otherShares[1].MarshalBinary()
+	require.NoError(t, err)
+	_, err = ImportDealerShare(data, public)
+	assert.Error(t, err)
+	_, err = ImportDealerShare(data, other)
+	assert.NoError(t, err)
+
+	_, _, err = DealKeys(3, 3, nil)
+	assert.Error(t, err)
+}
+
+func TestDealKeys_Deterministic(t *testing.T) {
+	rng := func() *bytes.Reader {
+		data := make([]byte, 1024)
+		_, err := hkdf.New(sha512.New, []byte("seed"), nil, nil).Read(data)
+		require.NoError(t, err)
+		return bytes.NewReader(data)
+	}
+	public1, shares1, err := DealKeys(3, 1, rng())
+	require.NoError(t, err)
+	public2, shares2, err := DealKeys(3, 1, rng())
+	require.NoError(t, err)
+	assert.True(t, public1.Equal(public2))
+	for id, share := range shares1 {
+		assert.True(t, share.Equal(shares2[id]))
+	}
+}
+
+func TestSplitEd25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	public, shares, err := SplitEd25519(priv, 3, 1)
+	require.NoError(t, err)
+	assert.Equal(t, pub, public.GroupKey.ToEd25519())
+
+	signers := party.IDSlice{1, 3}
+	message := []byte("migrated")
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInit(signers, shares[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	sigs, err := runSignRounds(states, round1)
+	require.NoError(t, err)
+	for _, sig := range sigs {
+		// the signatures verify with the original key
+		assert.True(t, ed25519.Verify(pub, message, sig.ToEd25519()))
+	}
+
+	_, _, err = SplitEd25519(priv[:32], 3, 1)
+	assert.Error(t, err)
+}
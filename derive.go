@@ -0,0 +1,14 @@
+package frost
+
+import (
+	"github.com/bartke/frost/derive"
+	"github.com/bartke/frost/eddsa"
+)
+
+// DeriveChild returns the public information and the secret share of the
+// child key at path, see the derive package. Signing with them, e.g. with
+// SignInit, produces signatures for the child group key. secret may be nil for
+// parties that hold no share, such as an Aggregator.
+func DeriveChild(public *eddsa.Public, secret *eddsa.SecretShare, path derive.Path) (*eddsa.Public, *eddsa.SecretShare, error) {
+	return derive.Child(public, secret, path)
+}
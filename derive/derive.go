@@ -0,0 +1,148 @@
+// Package derive derives child keys from the key of a group, in the style of
+// the non-hardened derivation of BIP-32, so that a single key generation can
+// serve many addresses.
+//
+// Every step of a Path adds a tweak t, derived from the parent key, a chain
+// code and the index, to the key: the child group key is Y + [t]B, and the
+// child share of every party is sᵢ + t. Since the Lagrange coefficients of any
+// set of signers sum to one, the child shares are a sharing of x + t, and the
+// signers sign for the child key by running the usual protocol with them; the
+// challenge is computed over the child key. Anyone who knows the group key can
+// derive the child group keys, but not the shares.
+//
+// Hardened derivation needs the full secret key, so it is not supported.
+package derive
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/ristretto"
+)
+
+// HardenedOffset is the first index of hardened derivation, which is not supported.
+const HardenedOffset uint32 = 1 << 31
+
+// ErrHardened is returned for paths with a hardened index.
+var ErrHardened = errors.New("derive: hardened derivation is not supported")
+
+// Path is a sequence of child indices, starting at the group key.
+type Path []uint32
+
+// ParsePath parses a path such as "m/44/0/7". Hardened indices, marked with
+// ' or h, are rejected with ErrHardened.
+func ParsePath(s string) (Path, error) {
+	parts := strings.Split(s, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("derive: path %q does not start with m", s)
+	}
+	path := make(Path, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") {
+			return nil, ErrHardened
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("derive: invalid index %q in path %q", part, s)
+		}
+		if uint32(index) >= HardenedOffset {
+			return nil, ErrHardened
+		}
+		path = append(path, uint32(index))
+	}
+	return path, nil
+}
+
+// String returns the path in the form accepted by ParsePath.
+func (p Path) String() string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, index := range p {
+		b.WriteString("/")
+		b.WriteString(strconv.FormatUint(uint64(index), 10))
+	}
+	return b.String()
+}
+
+// Tweak returns the sum of the tweaks along path from groupKey, which is
+// added to the group secret to obtain the secret of the child key.
+func Tweak(groupKey *eddsa.PublicKey, path Path) (*ristretto.Scalar, error) {
+	var key ristretto.Element
+	if _, err := key.SetBytesEd25519(groupKey.ToEd25519()); err != nil {
+		return nil, fmt.Errorf("derive: %w", err)
+	}
+	chain := sha512.Sum512(append([]byte("FROST-DERIVE-v1 chain code"), key.BytesEd25519()...))
+	chainCode := chain[:32]
+
+	total := ristretto.NewScalar()
+	var tweak ristretto.Scalar
+	var tweakB ristretto.Element
+	for _, index := range path {
+		if index >= HardenedOffset {
+			return nil, ErrHardened
+		}
+		var i [4]byte
+		binary.BigEndian.PutUint32(i[:], index)
+		parent := key.BytesEd25519()
+
+		if _, err := tweak.SetUniformBytes(step(chainCode, 0, parent, i[:])); err != nil {
+			return nil, err
+		}
+		chainCode = step(chainCode, 1, parent, i[:])[:32]
+
+		total.Add(total, &tweak)
+		key.Add(&key, tweakB.ScalarBaseMult(&tweak))
+	}
+	return total, nil
+}
+
+// step returns HMAC-SHA512 keyed with the chain code over label ∥ parent ∥ index.
+func step(chainCode []byte, label byte, parent, index []byte) []byte {
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write([]byte{label})
+	mac.Write(parent)
+	mac.Write(index)
+	return mac.Sum(nil)
+}
+
+// PublicKey returns the child of groupKey at path.
+func PublicKey(groupKey *eddsa.PublicKey, path Path) (*eddsa.PublicKey, error) {
+	tweak, err := Tweak(groupKey, path)
+	if err != nil {
+		return nil, err
+	}
+	var key, tweakB ristretto.Element
+	if _, err := key.SetBytesEd25519(groupKey.ToEd25519()); err != nil {
+		return nil, fmt.Errorf("derive: %w", err)
+	}
+	key.Add(&key, tweakB.ScalarBaseMult(tweak))
+	return eddsa.NewPublicKeyFromPoint(&key), nil
+}
+
+// Child returns the public information and the secret share of the child at
+// path. secret may be nil, e.g. for an aggregator, in which case only the
+// public information is derived.
+func Child(public *eddsa.Public, secret *eddsa.SecretShare, path Path) (*eddsa.Public, *eddsa.SecretShare, error) {
+	tweak, err := Tweak(public.GroupKey, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	child, err := public.Tweak(tweak)
+	if err != nil {
+		return nil, nil, fmt.Errorf("derive: %w", err)
+	}
+	if secret == nil {
+		return child, nil, nil
+	}
+
+	if share, ok := public.Shares[secret.ID]; !ok || share.Equal(&secret.Public) != 1 {
+		return nil, nil, fmt.Errorf("derive: secret share of party %d does not match the public shares", secret.ID)
+	}
+	return child, secret.Tweak(tweak), nil
+}
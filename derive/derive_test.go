@@ -0,0 +1,79 @@
+package derive_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost/derive"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/frostclient"
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePath(t *testing.T) {
+	path, err := derive.ParsePath("m/44/0/7")
+	require.NoError(t, err)
+	assert.Equal(t, derive.Path{44, 0, 7}, path)
+	assert.Equal(t, "m/44/0/7", path.String())
+
+	path, err = derive.ParsePath("m")
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	for _, s := range []string{"", "44/0", "m/", "m/x", "m/-1", "m/4294967296"} {
+		_, err := derive.ParsePath(s)
+		assert.Error(t, err, s)
+	}
+	for _, s := range []string{"m/44'/0", "m/44h", "m/2147483648"} {
+		_, err := derive.ParsePath(s)
+		assert.True(t, errors.Is(err, derive.ErrHardened), s)
+	}
+}
+
+func TestChild(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 4, T: 2})
+	require.NoError(t, err)
+
+	path := derive.Path{44, 0, 7}
+	child := &frostclient.Group{Shares: make(map[party.ID]*eddsa.SecretShare), Signers: party.IDSlice{1, 3, 4}}
+	for id, secret := range group.Shares {
+		public, share, err := derive.Child(group.Public, secret, path)
+		require.NoError(t, err)
+		child.Public, child.Shares[id] = public, share
+	}
+	assert.False(t, child.Public.GroupKey.Equal(group.Public.GroupKey))
+
+	// the child key can be derived from the group key alone
+	key, err := derive.PublicKey(group.Public.GroupKey, path)
+	require.NoError(t, err)
+	assert.True(t, key.Equal(child.Public.GroupKey))
+	public, _, err := derive.Child(group.Public, nil, path)
+	require.NoError(t, err)
+	assert.True(t, public.Equal(child.Public))
+
+	other, err := derive.PublicKey(group.Public.GroupKey, derive.Path{44, 0, 8})
+	require.NoError(t, err)
+	assert.False(t, other.Equal(key))
+
+	message := []byte("derived")
+	sig, err := frostclient.Sign(context.Background(), child, message)
+	require.NoError(t, err)
+	assert.True(t, child.Public.GroupKey.Verify(message, sig))
+	assert.False(t, group.Public.GroupKey.Verify(message, sig))
+
+	// the empty path is the group key itself
+	key, err = derive.PublicKey(group.Public.GroupKey, nil)
+	require.NoError(t, err)
+	assert.True(t, key.Equal(group.Public.GroupKey))
+
+	_, _, err = derive.Child(group.Public, group.Shares[1], derive.Path{derive.HardenedOffset})
+	assert.True(t, errors.Is(err, derive.ErrHardened))
+
+	mismatched := *group.Shares[2]
+	mismatched.ID = 1
+	_, _, err = derive.Child(group.Public, &mismatched, path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,40 @@
+package frost
+
+import (
+	"testing"
+
+	"github.com/bartke/frost/derive"
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveChild(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	path := derive.Path{1, 2}
+	signers := party.IDSlice{1, 3}
+	message := []byte("child")
+
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	var childPublic = public
+	for _, id := range signers {
+		pub, secret, err := DeriveChild(public, secrets[id], path)
+		require.NoError(t, err)
+		childPublic = pub
+		msg, state, err := SignInit(signers, secret, pub, message)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+
+	key, err := derive.PublicKey(public.GroupKey, path)
+	require.NoError(t, err)
+	assert.True(t, key.Equal(childPublic.GroupKey))
+
+	sigs, err := runSignRounds(states, round1)
+	require.NoError(t, err)
+	for _, sig := range sigs {
+		assert.True(t, key.Verify(message, sig))
+	}
+}
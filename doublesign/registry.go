@@ -0,0 +1,179 @@
+// Package doublesign keeps track of which messages a party already contributed
+// signature shares for, so that validator style deployments never sign two
+// different messages for the same slot (e.g. a consensus height-round-step, or
+// a transaction nonce).
+package doublesign
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/bartke/frost"
+)
+
+// ErrEquivocation is returned when a different message was already signed for the same slot.
+var ErrEquivocation = errors.New("doublesign: a different message was already signed for this slot")
+
+// Slot identifies something that may be signed at most once.
+type Slot struct {
+	// Class groups slots of the same kind, e.g. "prevote" or "eth-nonce".
+	Class string `json:"class"`
+	// Key identifies the slot within its class, e.g. "1024/0" or "17".
+	Key string `json:"key"`
+}
+
+func (s Slot) String() string {
+	return s.Class + "/" + s.Key
+}
+
+// Registry records the digest of the message signed for each slot.
+//
+// Implementations may be local, or shared through a coordinator or a replicated log,
+// as long as Record is atomic.
+type Registry interface {
+	// Record stores digest for slot. Recording the same digest twice succeeds,
+	// while recording a different digest for an existing slot returns ErrEquivocation.
+	Record(slot Slot, digest []byte) error
+
+	// Lookup returns the digest recorded for slot, and false if there is none.
+	Lookup(slot Slot) ([]byte, bool, error)
+}
+
+// Digest returns the digest under which message is recorded.
+func Digest(message []byte) []byte {
+	d := sha512.Sum512(message)
+	return d[:]
+}
+
+// SignRound1 records the message of state for slot, and only then runs frost.SignRound1,
+// which produces this party's signature share.
+func SignRound1(reg Registry, slot Slot, state *frost.SignerState, inputMsgs []*frost.Message) (*frost.Message, *frost.SignerState, error) {
+	if err := reg.Record(slot, Digest(state.Message)); err != nil {
+		return nil, nil, err
+	}
+	return frost.SignRound1(state, inputMsgs)
+}
+
+// MemoryRegistry is a Registry kept in memory.
+type MemoryRegistry struct {
+	mu      sync.Mutex
+	digests map[Slot][]byte
+}
+
+// NewMemoryRegistry returns an empty MemoryRegistry.
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{digests: make(map[Slot][]byte)}
+}
+
+// Record implements Registry.
+func (r *MemoryRegistry) Record(slot Slot, digest []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.record(slot, digest)
+}
+
+func (r *MemoryRegistry) record(slot Slot, digest []byte) error {
+	if existing, ok := r.digests[slot]; ok {
+		if !bytes.Equal(existing, digest) {
+			return fmt.Errorf("%w: %s", ErrEquivocation, slot)
+		}
+		return nil
+	}
+	r.digests[slot] = append([]byte(nil), digest...)
+	return nil
+}
+
+// Lookup implements Registry.
+func (r *MemoryRegistry) Lookup(slot Slot) ([]byte, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.digests[slot]
+	return d, ok, nil
+}
+
+type fileEntry struct {
+	Slot   Slot   `json:"slot"`
+	Digest string `json:"digest"`
+}
+
+// FileRegistry is a Registry backed by an append-only file with one JSON entry per line.
+// Every new entry is synced to disk before Record returns.
+type FileRegistry struct {
+	mem  *MemoryRegistry
+	file *os.File
+}
+
+// OpenFileRegistry opens or creates the registry stored at path.
+func OpenFileRegistry(path string) (*FileRegistry, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("doublesign: %w", err)
+	}
+
+	mem := NewMemoryRegistry()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry fileEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("doublesign: corrupt registry entry: %w", err)
+		}
+		digest, err := hex.DecodeString(entry.Digest)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("doublesign: corrupt registry entry: %w", err)
+		}
+		if err := mem.record(entry.Slot, digest); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("doublesign: %w", err)
+	}
+
+	return &FileRegistry{mem: mem, file: f}, nil
+}
+
+// Record implements Registry.
+func (r *FileRegistry) Record(slot Slot, digest []byte) error {
+	r.mem.mu.Lock()
+	defer r.mem.mu.Unlock()
+
+	if existing, ok := r.mem.digests[slot]; ok {
+		if !bytes.Equal(existing, digest) {
+			return fmt.Errorf("%w: %s", ErrEquivocation, slot)
+		}
+		return nil
+	}
+
+	line, err := json.Marshal(fileEntry{Slot: slot, Digest: hex.EncodeToString(digest)})
+	if err != nil {
+		return err
+	}
+	if _, err := r.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("doublesign: %w", err)
+	}
+	if err := r.file.Sync(); err != nil {
+		return fmt.Errorf("doublesign: %w", err)
+	}
+	return r.mem.record(slot, digest)
+}
+
+// Lookup implements Registry.
+func (r *FileRegistry) Lookup(slot Slot) ([]byte, bool, error) {
+	return r.mem.Lookup(slot)
+}
+
+// Close closes the underlying file.
+func (r *FileRegistry) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,45 @@
+package doublesign
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRegistry(t *testing.T, reg Registry) {
+	slot := Slot{Class: "prevote", Key: "1024/0"}
+	a, b := Digest([]byte("block A")), Digest([]byte("block B"))
+
+	require.NoError(t, reg.Record(slot, a))
+	require.NoError(t, reg.Record(slot, a), "re-signing the same message is allowed")
+	assert.True(t, errors.Is(reg.Record(slot, b), ErrEquivocation))
+
+	require.NoError(t, reg.Record(Slot{Class: "prevote", Key: "1025/0"}, b))
+
+	got, ok, err := reg.Lookup(slot)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, a, got)
+}
+
+func TestMemoryRegistry(t *testing.T) {
+	testRegistry(t, NewMemoryRegistry())
+}
+
+func TestFileRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.log")
+	reg, err := OpenFileRegistry(path)
+	require.NoError(t, err)
+	testRegistry(t, reg)
+	require.NoError(t, reg.Close())
+
+	// entries survive a restart
+	reg, err = OpenFileRegistry(path)
+	require.NoError(t, err)
+	defer reg.Close()
+	err = reg.Record(Slot{Class: "prevote", Key: "1024/0"}, Digest([]byte("block B")))
+	assert.True(t, errors.Is(err, ErrEquivocation))
+}
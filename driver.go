@@ -0,0 +1,95 @@
+package frost
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+)
+
+// TimeoutError is returned by KeygenWithContext and SignWithContext when
+// their context is done while they wait for the messages of a round.
+type TimeoutError struct {
+	// Round is the type of the messages that were awaited.
+	Round MessageType
+	// Missing are the parties whose messages did not arrive.
+	Missing party.IDSlice
+	// Err is the error of the context.
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("frost: no %s message from parties %v: %v", e.Round, e.Missing, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// KeygenWithContext runs all rounds of the key generation for selfID among the
+// parties 1..n with threshold t, exchanging the messages over transport. It
+// returns once the key generation completes or fails, or ctx is done; a
+// deadline of ctx bounds the wait for slow or absent parties, which are named
+// by the returned *TimeoutError.
+func KeygenWithContext(ctx context.Context, transport Transport, selfID party.ID, n, t party.Size) (*eddsa.Public, *eddsa.SecretShare, error) {
+	result, err := drive(ctx, transport, NewKeygenMachine(selfID, n, t))
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.Public, result.SecretShare, nil
+}
+
+// SignWithContext runs all rounds of the signing of message by the owner of
+// secret, together with signerIDs, exchanging the messages over transport,
+// like KeygenWithContext.
+func SignWithContext(ctx context.Context, transport Transport, signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, message []byte) (*eddsa.Signature, error) {
+	result, err := drive(ctx, transport, NewSignMachine(signerIDs, secret, shares, message))
+	if err != nil {
+		return nil, err
+	}
+	return result.Signature, nil
+}
+
+// drive advances m with the messages received over transport, and sends its
+// output, until m ends or ctx is done.
+func drive(ctx context.Context, transport Transport, m *Machine) (*SessionResult, error) {
+	var inbox []*Message
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, timeoutError(m, err)
+		}
+		out, status := m.Advance(time.Now(), inbox)
+		inbox = inbox[:0]
+		for _, msg := range out {
+			if err := transport.Send(ctx, msg); err != nil {
+				if ctx.Err() != nil {
+					return nil, timeoutError(m, ctx.Err())
+				}
+				return nil, err
+			}
+		}
+		if status != StatusWaiting {
+			return m.Result()
+		}
+
+		msg, err := transport.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, timeoutError(m, ctx.Err())
+			}
+			return nil, err
+		}
+		inbox = append(inbox, msg)
+	}
+}
+
+// timeoutError returns the error for a context that is done while m waits.
+func timeoutError(m *Machine, err error) error {
+	typ, ok := m.Round()
+	if !ok {
+		return fmt.Errorf("%w: %w", ErrSessionClosed, err)
+	}
+	return &TimeoutError{Round: typ, Missing: m.Missing(), Err: err}
+}
@@ -0,0 +1,117 @@
+package frost
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hub delivers the messages between in-memory transports.
+type hub struct {
+	inboxes map[party.ID]chan *Message
+}
+
+func newHub(ids party.IDSlice) *hub {
+	h := &hub{inboxes: make(map[party.ID]chan *Message, len(ids))}
+	for _, id := range ids {
+		h.inboxes[id] = make(chan *Message, 4*len(ids))
+	}
+	return h
+}
+
+type hubTransport struct {
+	hub  *hub
+	self party.ID
+}
+
+func (t *hubTransport) Send(ctx context.Context, msg *Message) error {
+	for id, inbox := range t.hub.inboxes {
+		if id == t.self || !msg.IsBroadcast() && msg.To != id {
+			continue
+		}
+		inbox <- msg
+	}
+	return nil
+}
+
+func (t *hubTransport) Receive(ctx context.Context) (*Message, error) {
+	select {
+	case msg := <-t.hub.inboxes[t.self]:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestKeygenAndSignWithContext(t *testing.T) {
+	const n, threshold = 3, 1
+	ids := party.IDSlice{1, 2, 3}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	h := newHub(ids)
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		public  *eddsa.Public
+		secrets = make(map[party.ID]*eddsa.SecretShare, n)
+	)
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id party.ID) {
+			defer wg.Done()
+			p, secret, err := KeygenWithContext(ctx, &hubTransport{hub: h, self: id}, id, n, threshold)
+			assert.NoError(t, err)
+			mu.Lock()
+			defer mu.Unlock()
+			public, secrets[id] = p, secret
+		}(id)
+	}
+	wg.Wait()
+	require.Len(t, secrets, n)
+
+	signers := party.IDSlice{1, 3}
+	message := []byte("driven")
+	h = newHub(signers)
+	for _, id := range signers {
+		wg.Add(1)
+		go func(id party.ID) {
+			defer wg.Done()
+			sig, err := SignWithContext(ctx, &hubTransport{hub: h, self: id}, signers, secrets[id], public, message)
+			if assert.NoError(t, err) {
+				assert.True(t, public.GroupKey.Verify(message, sig))
+			}
+		}(id)
+	}
+	wg.Wait()
+}
+
+func TestSignWithContext_Timeout(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	signers := party.IDSlice{1, 2, 3}
+	h := newHub(signers)
+
+	// only party 1 runs
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := SignWithContext(ctx, &hubTransport{hub: h, self: 1}, signers, secrets[1], public, []byte("alone"))
+
+	var timeout *TimeoutError
+	require.True(t, errors.As(err, &timeout), err)
+	assert.Equal(t, MessageTypeSign1, timeout.Round)
+	assert.Equal(t, party.IDSlice{2, 3}, timeout.Missing)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// a canceled context stops the driver as well
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = SignWithContext(ctx, &hubTransport{hub: newHub(signers), self: 1}, signers, secrets[1], public, []byte("canceled"))
+	assert.True(t, errors.Is(err, context.Canceled))
+}
@@ -0,0 +1,78 @@
+package eddsa
+
+import (
+	"crypto/rand"
+
+	"github.com/bartke/frost/internal/vartime"
+	"github.com/bartke/frost/ristretto"
+)
+
+// VerifyBatch returns true if sigs[i] is a valid signature of msgs[i] under
+// pubs[i] for every i. It checks a random linear combination of the
+// verification equations
+//
+//	∑ zᵢ([sᵢ]B - Rᵢ - [cᵢ]Aᵢ) = 0
+//
+// with a single multi-scalar multiplication. The terms of signatures under
+// the same *PublicKey are combined, and its encoding computed once, so that
+// batches of many signatures by a few groups, as a service verifying threshold
+// signatures sees them, are verified about twice as fast as one by one. It
+// returns false if any signature is invalid, but not which one; fall back to
+// Verify to find it.
+func VerifyBatch(pubs []*PublicKey, msgs [][]byte, sigs []*Signature) bool {
+	n := len(sigs)
+	if len(pubs) != n || len(msgs) != n {
+		return false
+	}
+	if n == 0 {
+		return true
+	}
+
+	type key struct {
+		encoding []byte
+		index    int
+	}
+	keys := make(map[*PublicKey]key)
+	scalars := make([]*ristretto.Scalar, 0, 2*n+1)
+	points := make([]*ristretto.Element, 0, 2*n+1)
+	sum := ristretto.NewScalar()
+	buf := make([]byte, 32)
+	for i := range sigs {
+		if pubs[i] == nil || sigs[i] == nil {
+			return false
+		}
+		// zᵢ is a random 128 bit scalar, which is enough for a soundness error of 2⁻¹²⁸
+		if _, err := rand.Read(buf[:16]); err != nil {
+			return false
+		}
+		var z ristretto.Scalar
+		if _, err := z.SetCanonicalBytes(buf); err != nil {
+			return false
+		}
+		negZ := new(ristretto.Scalar).Negate(&z)
+
+		k, ok := keys[pubs[i]]
+		if !ok {
+			// -∑ zᵢcᵢ Aᵢ is accumulated in a single term per key
+			k = key{encoding: pubs[i].ToEd25519(), index: len(scalars)}
+			keys[pubs[i]] = k
+			scalars = append(scalars, ristretto.NewScalar())
+			points = append(points, &pubs[i].pk)
+		}
+		c := computeChallenge(nil, sigs[i].R.BytesEd25519(), k.encoding, msgs[i])
+		scalars[k.index].MultiplyAdd(c, negZ, scalars[k.index])
+
+		// -zᵢ Rᵢ
+		scalars = append(scalars, negZ)
+		points = append(points, &sigs[i].R)
+
+		// ∑ zᵢsᵢ
+		sum.MultiplyAdd(&z, &sigs[i].S, sum)
+	}
+	scalars = append(scalars, sum)
+	points = append(points, ristretto.NewGeneratorElement())
+
+	var check ristretto.Element
+	vartime.MultiScalarMult(&check, scalars, points)
+	return check.Equal(ristretto.NewIdentityElement()) == 1
+}
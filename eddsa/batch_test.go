@@ -0,0 +1,100 @@
+package eddsa
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generateBatch returns n signatures of distinct messages under distinct keys.
+func generateBatch(tb testing.TB, n int) ([]*PublicKey, [][]byte, []*Signature) {
+	pubs := make([]*PublicKey, n)
+	msgs := make([][]byte, n)
+	sigs := make([]*Signature, n)
+	for i := range sigs {
+		_, skBytes, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(tb, err)
+		sk, pk := newKeyPair(skBytes)
+		msgs[i] = []byte(fmt.Sprintf("%s %d", sampleMessage, i))
+		pubs[i], sigs[i] = pk, NewSecretShare(0, sk).sign(msgs[i])
+	}
+	return pubs, msgs, sigs
+}
+
+func TestVerifyBatch(t *testing.T) {
+	pubs, msgs, sigs := generateBatch(t, 32)
+	assert.True(t, VerifyBatch(pubs, msgs, sigs))
+	assert.True(t, VerifyBatch(pubs[:1], msgs[:1], sigs[:1]))
+	assert.True(t, VerifyBatch(nil, nil, nil))
+
+	// a single invalid signature fails the batch
+	msgs[7] = []byte("other")
+	assert.False(t, VerifyBatch(pubs, msgs, sigs))
+	msgs[7] = []byte(fmt.Sprintf("%s %d", sampleMessage, 7))
+
+	valid := sigs[3]
+	sigs[3] = &Signature{R: valid.R}
+	sigs[3].S.Add(&valid.S, scalar.NewScalarUInt32(1))
+	assert.False(t, VerifyBatch(pubs, msgs, sigs))
+	sigs[3] = valid
+
+	// swapped keys fail even though every signature is valid under some key
+	pubs[0], pubs[1] = pubs[1], pubs[0]
+	assert.False(t, VerifyBatch(pubs, msgs, sigs))
+	pubs[0], pubs[1] = pubs[1], pubs[0]
+	assert.True(t, VerifyBatch(pubs, msgs, sigs))
+
+	assert.False(t, VerifyBatch(pubs[:2], msgs, sigs))
+	assert.False(t, VerifyBatch(pubs, msgs, append(sigs[:31:31], nil)))
+}
+
+func TestVerifyBatch_SameKey(t *testing.T) {
+	_, skBytes, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sk, pk := newKeyPair(skBytes)
+	share := NewSecretShare(0, sk)
+	pubs := []*PublicKey{pk, pk, pk}
+	msgs := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+	sigs := []*Signature{share.sign(msgs[0]), share.sign(msgs[1]), share.sign(msgs[2])}
+	assert.True(t, VerifyBatch(pubs, msgs, sigs))
+
+	// the same signature may appear twice
+	assert.True(t, VerifyBatch(append(pubs, pk), append(msgs, msgs[0]), append(sigs, sigs[0])))
+
+	sigs[1], sigs[2] = sigs[2], sigs[1]
+	assert.False(t, VerifyBatch(pubs, msgs, sigs))
+}
+
+func BenchmarkVerifyBatch(b *testing.B) {
+	pubs, msgs, sigs := generateBatch(b, 256)
+	b.Run("Verify", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j := range sigs {
+				pubs[j].Verify(msgs[j], sigs[j])
+			}
+		}
+	})
+	b.Run("VerifyBatch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			VerifyBatch(pubs, msgs, sigs)
+		}
+	})
+
+	// signatures of a single group
+	_, skBytes, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(b, err)
+	sk, pk := newKeyPair(skBytes)
+	for j := range sigs {
+		pubs[j], sigs[j] = pk, NewSecretShare(0, sk).sign(msgs[j])
+	}
+	b.Run("VerifyBatch/SameKey", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			VerifyBatch(pubs, msgs, sigs)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package eddsa
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+)
+
+// sshKeyType is the OpenSSH name of ed25519 keys.
+const sshKeyType = "ssh-ed25519"
+
+// MarshalPKIX returns the DER encoding of the key as a PKIX SubjectPublicKeyInfo,
+// as used in x509 certificates and CSRs.
+func (pk *PublicKey) MarshalPKIX() ([]byte, error) {
+	return x509.MarshalPKIXPublicKey(pk.ToEd25519())
+}
+
+// MarshalPEM returns the PKIX encoding of the key in a "PUBLIC KEY" PEM block,
+// as written by openssl pkey -pubout.
+func (pk *PublicKey) MarshalPEM() ([]byte, error) {
+	der, err := pk.MarshalPKIX()
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// MarshalSSHWire returns the key in the SSH wire format of RFC 8709, the
+// string "ssh-ed25519" followed by the 32 byte key, each prefixed with its length.
+func (pk *PublicKey) MarshalSSHWire() []byte {
+	key := pk.ToEd25519()
+	b := make([]byte, 0, 8+len(sshKeyType)+len(key))
+	b = binary.BigEndian.AppendUint32(b, uint32(len(sshKeyType)))
+	b = append(b, sshKeyType...)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(key)))
+	return append(b, key...)
+}
+
+// MarshalOpenSSH returns the key as a line of an authorized_keys file, with
+// the optional comment, such as "ssh-ed25519 AAAAC3Nza... frost-group".
+func (pk *PublicKey) MarshalOpenSSH(comment string) []byte {
+	line := sshKeyType + " " + base64.StdEncoding.EncodeToString(pk.MarshalSSHWire())
+	if comment != "" {
+		line += " " + comment
+	}
+	return []byte(line + "\n")
+}
@@ -0,0 +1,46 @@
+package eddsa
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestPublicKey_Export(t *testing.T) {
+	_, skBytes, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, pk := newKeyPair(skBytes)
+
+	der, err := pk.MarshalPKIX()
+	require.NoError(t, err)
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	require.NoError(t, err)
+	assert.Equal(t, pk.ToEd25519(), parsed)
+
+	data, err := pk.MarshalPEM()
+	require.NoError(t, err)
+	block, rest := pem.Decode(data)
+	require.NotNil(t, block)
+	assert.Empty(t, rest)
+	assert.Equal(t, "PUBLIC KEY", block.Type)
+	assert.Equal(t, der, block.Bytes)
+
+	line := pk.MarshalOpenSSH("frost-group")
+	sshKey, comment, _, rest, err := ssh.ParseAuthorizedKey(line)
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+	assert.Equal(t, "frost-group", comment)
+	assert.Equal(t, ssh.KeyAlgoED25519, sshKey.Type())
+	assert.Equal(t, pk.MarshalSSHWire(), sshKey.Marshal())
+	assert.Equal(t, ed25519.PublicKey(pk.ToEd25519()), sshKey.(ssh.CryptoPublicKey).CryptoPublicKey())
+
+	_, comment, _, _, err = ssh.ParseAuthorizedKey(pk.MarshalOpenSSH(""))
+	require.NoError(t, err)
+	assert.Empty(t, comment)
+}
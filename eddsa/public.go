@@ -1,8 +1,10 @@
 package eddsa
 
 import (
+	"crypto/ed25519"
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/bartke/frost/party"
 	"github.com/bartke/frost/ristretto"
@@ -23,6 +25,10 @@ type Public struct {
 	// GroupKey is the group's public key
 	// It is the result of interpolating the Shamir shares at 0
 	GroupKey *PublicKey
+
+	// Identities are the long-lived ed25519 identity keys of the parties,
+	// which authenticate their messages, if they were registered at the key generation.
+	Identities map[party.ID]ed25519.PublicKey
 }
 
 // NewPublic creates a Public structure given a map of public key shares as ristretto.Element, the threshold used.
@@ -63,17 +69,19 @@ func computeGroupKey(partyIDs party.IDSlice, shares map[party.ID]*ristretto.Elem
 }
 
 type sharesJSON struct {
-	Threshold int                             `json:"t"`
-	GroupKey  *PublicKey                      `json:"groupkey"`
-	Shares    map[party.ID]*ristretto.Element `json:"shares"`
+	Threshold  int                             `json:"t"`
+	GroupKey   *PublicKey                      `json:"groupkey"`
+	Shares     map[party.ID]*ristretto.Element `json:"shares"`
+	Identities map[party.ID]ed25519.PublicKey  `json:"identities,omitempty"`
 }
 
 // MarshalJSON implements the json.Marshaler interface.
 func (s *Public) MarshalJSON() ([]byte, error) {
 	return json.Marshal(sharesJSON{
-		Threshold: int(s.Threshold),
-		Shares:    s.Shares,
-		GroupKey:  s.GroupKey,
+		Threshold:  int(s.Threshold),
+		Shares:     s.Shares,
+		GroupKey:   s.GroupKey,
+		Identities: s.Identities,
 	})
 }
 
@@ -95,11 +103,36 @@ func (s *Public) UnmarshalJSON(data []byte) error {
 		return errors.New("PublicShares: inconsistent group key")
 	}
 
+	for id, key := range out.Identities {
+		if _, ok := out.Shares[id]; !ok || len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("PublicShares: invalid identity of party %d", id)
+		}
+	}
+	newS.Identities = out.Identities
+
 	*s = *newS
 
 	return nil
 }
 
+// Tweak returns the public information of the group key tweaked by t: the
+// group key P + [t]B, with every public share plus [t]B. The shares of the
+// secret SecretShare.Tweak returns are a sharing of the tweaked key.
+func (s *Public) Tweak(t *ristretto.Scalar) (*Public, error) {
+	var tB ristretto.Element
+	tB.ScalarBaseMult(t)
+	shares := make(map[party.ID]*ristretto.Element, len(s.Shares))
+	for id, share := range s.Shares {
+		shares[id] = new(ristretto.Element).Add(share, &tB)
+	}
+	tweaked, err := NewPublic(shares, s.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	tweaked.Identities = s.Identities
+	return tweaked, nil
+}
+
 func (s *Public) Equal(s2 *Public) bool {
 	if len(s.Shares) != len(s2.Shares) {
 		return false
@@ -2,7 +2,12 @@ package eddsa
 
 import (
 	"crypto/ed25519"
+	"crypto/sha512"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
 
+	"github.com/bartke/frost/internal/vartime"
 	"github.com/bartke/frost/ristretto"
 )
 
@@ -18,14 +23,43 @@ func NewPublicKeyFromPoint(public *ristretto.Element) *PublicKey {
 	return &pk
 }
 
+// Verify returns true if sig is a valid (pure) Ed25519 signature on message.
 func (pk *PublicKey) Verify(message []byte, sig *Signature) bool {
-	challenge := ComputeChallenge(&sig.R, pk, message)
+	return pk.verifyChallenge(ComputeChallenge(&sig.R, pk, message), sig)
+}
+
+// VerifyCtx returns true if sig is a valid Ed25519ctx signature on message with the context ctx.
+// As with crypto/ed25519, an empty context selects pure Ed25519.
+func (pk *PublicKey) VerifyCtx(message, ctx []byte, sig *Signature) bool {
+	if len(ctx) == 0 {
+		return pk.Verify(message, sig)
+	}
+	dom, err := Dom2(0, ctx)
+	if err != nil {
+		return false
+	}
+	return pk.verifyChallenge(ComputeChallengeWithDomain(&sig.R, pk, dom, message), sig)
+}
+
+// VerifyPh returns true if sig is a valid Ed25519ph signature with the context ctx,
+// where digest is the SHA-512 hash of the signed message.
+func (pk *PublicKey) VerifyPh(digest, ctx []byte, sig *Signature) bool {
+	if len(digest) != sha512.Size {
+		return false
+	}
+	dom, err := Dom2(1, ctx)
+	if err != nil {
+		return false
+	}
+	return pk.verifyChallenge(ComputeChallengeWithDomain(&sig.R, pk, dom, digest), sig)
+}
 
+func (pk *PublicKey) verifyChallenge(challenge *ristretto.Scalar, sig *Signature) bool {
 	// Verify the full signature here too.
 	var publicNeg, RPrime ristretto.Element
 	publicNeg.Negate(&pk.pk)
 	// RPrime = [c](-A) + [s]B
-	RPrime.VarTimeDoubleScalarBaseMult(challenge, &publicNeg, &sig.S)
+	vartime.DoubleScalarBaseMult(&RPrime, challenge, &publicNeg, &sig.S)
 	return RPrime.Equal(&sig.R) == 1
 }
 
@@ -48,3 +82,49 @@ func (pk PublicKey) MarshalJSON() ([]byte, error) {
 func (pk *PublicKey) UnmarshalJSON(data []byte) error {
 	return pk.pk.UnmarshalJSON(data)
 }
+
+// MarshalText implements the encoding.TextMarshaler interface.
+// The key is hex encoded in the ed25519 format, as returned by ToEd25519.
+func (pk PublicKey) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(pk.ToEd25519())), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (pk *PublicKey) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(string(text))
+	if err != nil {
+		return fmt.Errorf("eddsa: public key: %w", err)
+	}
+	return pk.setEd25519(b)
+}
+
+// Value implements the driver.Valuer interface, storing the key as the 32 bytes returned by ToEd25519.
+func (pk PublicKey) Value() (driver.Value, error) {
+	return []byte(pk.ToEd25519()), nil
+}
+
+// Scan implements the sql.Scanner interface. It accepts the raw 32 bytes,
+// as stored by Value, or the hex text encoding.
+func (pk *PublicKey) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		if len(v) == ed25519.PublicKeySize {
+			return pk.setEd25519(v)
+		}
+		return pk.UnmarshalText(v)
+	case string:
+		return pk.UnmarshalText([]byte(v))
+	default:
+		return fmt.Errorf("eddsa: cannot scan %T into PublicKey", src)
+	}
+}
+
+func (pk *PublicKey) setEd25519(b []byte) error {
+	if len(b) != ed25519.PublicKeySize {
+		return fmt.Errorf("eddsa: public key must be %d bytes", ed25519.PublicKeySize)
+	}
+	if _, err := pk.pk.SetBytesEd25519(b); err != nil {
+		return fmt.Errorf("eddsa: public key: %w", err)
+	}
+	return nil
+}
@@ -4,10 +4,13 @@ import (
 	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
 	"testing"
 
 	"github.com/bartke/frost/ristretto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func newKeyPair(key ed25519.PrivateKey) (*ristretto.Scalar, *PublicKey) {
@@ -38,3 +41,45 @@ func TestPrivateKey_ToEd25519(t *testing.T) {
 
 	assert.Equal(t, pk.ToEd25519(), pkbytes)
 }
+
+func TestPublicKey_MarshalText(t *testing.T) {
+	pkBytes, skBytes, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, pk := newKeyPair(skBytes)
+
+	text, err := pk.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(pkBytes), string(text))
+
+	var decoded PublicKey
+	require.NoError(t, decoded.UnmarshalText(text))
+	assert.True(t, pk.Equal(&decoded))
+
+	// JSON keeps its existing encoding
+	data, err := json.Marshal(pk)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), string(text))
+
+	assert.Error(t, decoded.UnmarshalText(text[:62]))
+}
+
+func TestPublicKey_Scan(t *testing.T) {
+	_, skBytes, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, pk := newKeyPair(skBytes)
+
+	value, err := pk.Value()
+	require.NoError(t, err)
+
+	var decoded PublicKey
+	require.NoError(t, decoded.Scan(value))
+	assert.True(t, pk.Equal(&decoded))
+
+	text, _ := pk.MarshalText()
+	decoded = PublicKey{}
+	require.NoError(t, decoded.Scan(string(text)))
+	assert.True(t, pk.Equal(&decoded))
+
+	assert.Error(t, decoded.Scan(nil))
+	assert.Error(t, decoded.Scan([]byte{1, 2, 3}))
+}
@@ -75,3 +75,16 @@ func TestShares_MarshalJSON(t *testing.T) {
 		t.Error("unmarshalled is not equal")
 	}
 }
+
+func TestShares_Tweak(t *testing.T) {
+	public, secret := fakeShares(5, 2)
+	tweak := scalar.NewScalarRandom()
+
+	tweaked, err := public.Tweak(tweak)
+	assert.NoError(t, err)
+
+	var expected ristretto.Scalar
+	expected.Add(secret, tweak)
+	assert.True(t, tweaked.GroupKey.Equal(NewPublicKeyFromPoint(new(ristretto.Element).ScalarBaseMult(&expected))))
+	assert.False(t, tweaked.Equal(public))
+}
@@ -30,6 +30,24 @@ func NewSecretShare(id party.ID, secret *ristretto.Scalar) *SecretShare {
 	return &share
 }
 
+// Tweak returns the share of the group key tweaked by t, see Public.Tweak.
+// Since the Lagrange coefficients of any set of signers sum to one, adding t
+// to every share adds t to the secret they share.
+func (sk *SecretShare) Tweak(t *ristretto.Scalar) *SecretShare {
+	var s ristretto.Scalar
+	s.Add(&sk.Secret, t)
+	return NewSecretShare(sk.ID, &s)
+}
+
+// Zeroize overwrites the secret scalar with 0, so that it does not remain in
+// memory. The share cannot be used for signing afterwards.
+func (sk *SecretShare) Zeroize() {
+	if sk == nil {
+		return
+	}
+	sk.Secret.Set(ristretto.NewScalar())
+}
+
 // MarshalBinary implements the encoding.BinaryMarshaler interface.
 func (sk *SecretShare) MarshalBinary() ([]byte, error) {
 	data := make([]byte, 0, party.IDByteSize+32)
@@ -3,6 +3,7 @@ package eddsa
 import (
 	"testing"
 
+	"github.com/bartke/frost/ristretto"
 	"github.com/bartke/frost/scalar"
 )
 
@@ -57,3 +58,19 @@ func TestSecretShare_MarshalBinary(t *testing.T) {
 		t.Error("unmarshalled share is not the same")
 	}
 }
+
+func TestSecretShare_Zeroize(t *testing.T) {
+	secret := scalar.NewScalarUInt32(42)
+	s := NewSecretShare(42, secret)
+	public := s.Public
+	s.Zeroize()
+	if s.Secret.Equal(ristretto.NewScalar()) != 1 {
+		t.Error("secret was not zeroized")
+	}
+	if s.Public.Equal(&public) != 1 {
+		t.Error("public share was changed")
+	}
+	if secret.Equal(ristretto.NewScalar()) == 1 {
+		t.Error("secret passed to NewSecretShare was zeroized")
+	}
+}
@@ -2,6 +2,8 @@ package eddsa
 
 import (
 	"crypto/sha512"
+	"database/sql/driver"
+	"encoding/hex"
 	"errors"
 	"fmt"
 
@@ -30,10 +32,22 @@ func (sig *Signature) ToEd25519() []byte {
 
 // ComputeChallenge computes the value H(R, A, M), and assumes nothing about whether M is hashed.
 func ComputeChallenge(R *ristretto.Element, groupKey *PublicKey, message []byte) *ristretto.Scalar {
+	return ComputeChallengeWithDomain(R, groupKey, nil, message)
+}
+
+// ComputeChallengeWithDomain computes the value H(dom, R, A, M), where dom is a
+// domain separation prefix such as the one returned by Dom2.
+func ComputeChallengeWithDomain(R *ristretto.Element, groupKey *PublicKey, dom, message []byte) *ristretto.Scalar {
+	return computeChallenge(dom, R.BytesEd25519(), groupKey.ToEd25519(), message)
+}
+
+// computeChallenge computes H(dom, R, A, M) from the ed25519 encodings of R and A.
+func computeChallenge(dom, R, A, message []byte) *ristretto.Scalar {
 	var s ristretto.Scalar
-	data := make([]byte, 0, 64+len(message))
-	data = append(data, R.BytesEd25519()...)
-	data = append(data, groupKey.ToEd25519()...)
+	data := make([]byte, 0, len(dom)+64+len(message))
+	data = append(data, dom...)
+	data = append(data, R...)
+	data = append(data, A...)
 	data = append(data, message...)
 	digest := sha512.Sum512(data)
 	_, err := s.SetUniformBytes(digest[:])
@@ -43,6 +57,24 @@ func ComputeChallenge(R *ristretto.Element, groupKey *PublicKey, message []byte)
 	return &s
 }
 
+// ContextMaxSize is the maximum length of an Ed25519ctx or Ed25519ph context string.
+const ContextMaxSize = 255
+
+// Dom2 returns the RFC 8032 prefix dom2(phflag, ctx), which is used
+// for Ed25519ctx (phflag = 0) and Ed25519ph (phflag = 1) signatures.
+// It returns an error if ctx is longer than ContextMaxSize.
+func Dom2(phflag byte, ctx []byte) ([]byte, error) {
+	if len(ctx) > ContextMaxSize {
+		return nil, fmt.Errorf("eddsa: context is longer than %d bytes", ContextMaxSize)
+	}
+	const prefix = "SigEd25519 no Ed25519 collisions"
+	dom := make([]byte, 0, len(prefix)+2+len(ctx))
+	dom = append(dom, prefix...)
+	dom = append(dom, phflag, byte(len(ctx)))
+	dom = append(dom, ctx...)
+	return dom, nil
+}
+
 //
 // FROSTMarshaler
 //
@@ -95,3 +127,53 @@ func (sig *Signature) Equal(other interface{}) bool {
 	}
 	return true
 }
+
+// MarshalText implements the encoding.TextMarshaler interface.
+// The signature is hex encoded in the ed25519 format, as returned by ToEd25519.
+func (sig *Signature) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(sig.ToEd25519())), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (sig *Signature) UnmarshalText(text []byte) error {
+	b, err := hex.DecodeString(string(text))
+	if err != nil {
+		return fmt.Errorf("sig: %w", err)
+	}
+	return sig.setEd25519(b)
+}
+
+// Value implements the driver.Valuer interface, storing the signature as the 64 bytes returned by ToEd25519.
+func (sig *Signature) Value() (driver.Value, error) {
+	return sig.ToEd25519(), nil
+}
+
+// Scan implements the sql.Scanner interface. It accepts the raw 64 bytes,
+// as stored by Value, or the hex text encoding.
+func (sig *Signature) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		if len(v) == MessageLengthSig {
+			return sig.setEd25519(v)
+		}
+		return sig.UnmarshalText(v)
+	case string:
+		return sig.UnmarshalText([]byte(v))
+	default:
+		return fmt.Errorf("sig: cannot scan %T into Signature", src)
+	}
+}
+
+// setEd25519 decodes a signature in the format returned by ToEd25519.
+func (sig *Signature) setEd25519(b []byte) error {
+	if len(b) != MessageLengthSig {
+		return fmt.Errorf("sig: %w", ErrInvalidMessage)
+	}
+	if _, err := sig.R.SetBytesEd25519(b[:32]); err != nil {
+		return fmt.Errorf("sig.R: %w", err)
+	}
+	if _, err := sig.S.SetCanonicalBytes(b[32:]); err != nil {
+		return fmt.Errorf("sig.S: %w", err)
+	}
+	return nil
+}
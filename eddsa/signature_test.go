@@ -1,10 +1,14 @@
 package eddsa
 
 import (
+	"crypto"
 	"crypto/ed25519"
 	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
 	"testing"
 
+	"github.com/bartke/frost/scalar"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -32,3 +36,92 @@ func TestSignature_Verify(t *testing.T) {
 	// Check using ed25519.Verify
 	assert.True(t, ed25519.Verify(pk.ToEd25519(), []byte(sampleMessage), sig.ToEd25519()))
 }
+
+// signWithDomain generates an Ed25519ctx or Ed25519ph compatible signature.
+func (sk *SecretShare) signWithDomain(dom, message []byte) *Signature {
+	var sig Signature
+
+	r := scalar.NewScalarRandom()
+	sig.R.ScalarBaseMult(r)
+
+	pk := PublicKey{pk: sk.Public}
+	c := ComputeChallengeWithDomain(&sig.R, &pk, dom, message)
+	sig.S.MultiplyAdd(&sk.Secret, c, r)
+	return &sig
+}
+
+func TestSignature_VerifyCtx(t *testing.T) {
+	_, skBytes, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sk, pk := newKeyPair(skBytes)
+	share := NewSecretShare(1, sk)
+	ctx := []byte("frost-test")
+
+	dom, err := Dom2(0, ctx)
+	require.NoError(t, err)
+	sig := share.signWithDomain(dom, []byte(sampleMessage))
+
+	assert.True(t, pk.VerifyCtx([]byte(sampleMessage), ctx, sig))
+	assert.False(t, pk.VerifyCtx([]byte(sampleMessage), []byte("other"), sig))
+	assert.False(t, pk.Verify([]byte(sampleMessage), sig))
+	assert.NoError(t, ed25519.VerifyWithOptions(pk.ToEd25519(), []byte(sampleMessage), sig.ToEd25519(),
+		&ed25519.Options{Context: string(ctx)}))
+}
+
+func TestSignature_VerifyPh(t *testing.T) {
+	_, skBytes, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sk, pk := newKeyPair(skBytes)
+	share := NewSecretShare(1, sk)
+	digest := sha512.Sum512([]byte(sampleMessage))
+
+	dom, err := Dom2(1, nil)
+	require.NoError(t, err)
+	sig := share.signWithDomain(dom, digest[:])
+
+	assert.True(t, pk.VerifyPh(digest[:], nil, sig))
+	assert.False(t, pk.VerifyPh(digest[:], []byte("ctx"), sig))
+	assert.False(t, pk.VerifyPh(digest[:32], nil, sig))
+	assert.NoError(t, ed25519.VerifyWithOptions(pk.ToEd25519(), digest[:], sig.ToEd25519(),
+		&ed25519.Options{Hash: crypto.SHA512}))
+
+	_, err = Dom2(1, make([]byte, ContextMaxSize+1))
+	assert.Error(t, err)
+}
+
+func TestSignature_MarshalText(t *testing.T) {
+	sig, pk, err := generateSignature()
+	require.NoError(t, err)
+
+	text, err := sig.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(sig.ToEd25519()), string(text))
+
+	var decoded Signature
+	require.NoError(t, decoded.UnmarshalText(text))
+	assert.True(t, sig.Equal(&decoded))
+	assert.True(t, pk.Verify([]byte(sampleMessage), &decoded))
+
+	assert.Error(t, decoded.UnmarshalText(text[:64]))
+	assert.Error(t, decoded.UnmarshalText([]byte("zz")))
+}
+
+func TestSignature_Scan(t *testing.T) {
+	sig, _, err := generateSignature()
+	require.NoError(t, err)
+
+	value, err := sig.Value()
+	require.NoError(t, err)
+
+	var decoded Signature
+	require.NoError(t, decoded.Scan(value))
+	assert.True(t, sig.Equal(&decoded))
+
+	text, _ := sig.MarshalText()
+	decoded = Signature{}
+	require.NoError(t, decoded.Scan(string(text)))
+	assert.True(t, sig.Equal(&decoded))
+
+	assert.Error(t, decoded.Scan(nil))
+	assert.Error(t, decoded.Scan(int64(1)))
+}
@@ -0,0 +1,185 @@
+package frost
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bartke/frost/party"
+)
+
+// Identities maps the parties to their long-lived ed25519 identity keys, which
+// sign their messages in Envelopes. They are independent of the shares of the
+// group key, and must be exchanged over an authenticated channel, e.g. in person
+// when the group is set up, see KeygenState.RegisterIdentities.
+type Identities map[party.ID]ed25519.PublicKey
+
+// validate checks that every identity key is well formed and belongs to one of partyIDs.
+func (ids Identities) validate(partyIDs party.IDSlice) error {
+	for id, key := range ids {
+		if !partyIDs.Contains(id) {
+			return fmt.Errorf("identity of party %d, which is not in %v", id, partyIDs)
+		}
+		if len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("identity of party %d has %d bytes", id, len(key))
+		}
+	}
+	return nil
+}
+
+// ErrInvalidEnvelope is returned for an Envelope whose signature does not verify
+// with the identity key of the sender of its message.
+var ErrInvalidEnvelope = errors.New("invalid envelope")
+
+// envelopeDomain separates the signatures of envelopes from other uses of the identity keys.
+const envelopeDomain = "FROST-ENVELOPE-v1"
+
+// Envelope is a Message signed by its sender with its identity key, so that
+// a man-in-the-middle on the transport cannot forge or alter messages. The
+// signature covers the binary encoding of the message, including its session
+// and round, so an envelope cannot be replayed into another session either.
+type Envelope struct {
+	Message   *Message
+	Signature []byte
+}
+
+// SealEnvelope signs msg with key, the identity key of msg.From.
+func SealEnvelope(msg *Message, key ed25519.PrivateKey) (*Envelope, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, errors.New("SealEnvelope: invalid identity key")
+	}
+	data, err := envelopeData(msg)
+	if err != nil {
+		return nil, fmt.Errorf("SealEnvelope: %w", err)
+	}
+	return &Envelope{Message: msg, Signature: ed25519.Sign(key, data)}, nil
+}
+
+// Open verifies the signature of e with the identity key of the sender, and
+// returns the message.
+func (e *Envelope) Open(identities Identities) (*Message, error) {
+	if e.Message == nil {
+		return nil, fmt.Errorf("envelope: no message: %w", ErrInvalidEnvelope)
+	}
+	key, ok := identities[e.Message.From]
+	if !ok || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("envelope: no identity for party %d: %w", e.Message.From, ErrInvalidEnvelope)
+	}
+	data, err := envelopeData(e.Message)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: %w", err)
+	}
+	if !ed25519.Verify(key, data, e.Signature) {
+		return nil, fmt.Errorf("envelope: signature of %s from party %d: %w", e.Message.Type, e.Message.From, ErrInvalidEnvelope)
+	}
+	return e.Message, nil
+}
+
+// envelopeData returns the data the signature of an envelope of msg covers.
+func envelopeData(msg *Message) ([]byte, error) {
+	data := make([]byte, 0, len(envelopeDomain)+msg.Size())
+	data = append(data, envelopeDomain...)
+	return msg.BytesAppend(data)
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface. The
+// encoding is the signature (64 bytes) followed by the binary encoding of the message.
+func (e *Envelope) MarshalBinary() ([]byte, error) {
+	if e.Message == nil || len(e.Signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("envelope: %w", ErrInvalidEnvelope)
+	}
+	data := make([]byte, 0, ed25519.SignatureSize+e.Message.Size())
+	data = append(data, e.Signature...)
+	return e.Message.BytesAppend(data)
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (e *Envelope) UnmarshalBinary(data []byte) error {
+	if len(data) < ed25519.SignatureSize {
+		return fmt.Errorf("envelope: %w", ErrInvalidEnvelope)
+	}
+	var msg Message
+	if err := msg.UnmarshalBinary(data[ed25519.SignatureSize:]); err != nil {
+		return fmt.Errorf("envelope: %w", err)
+	}
+	e.Message = &msg
+	e.Signature = append([]byte{}, data[:ed25519.SignatureSize]...)
+	return nil
+}
+
+func (e *Envelope) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Message   *Message `json:"message"`
+		Signature string   `json:"signature"`
+	}{
+		Message:   e.Message,
+		Signature: base64.StdEncoding.EncodeToString(e.Signature),
+	})
+}
+
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	aux := &struct {
+		Message   *Message `json:"message"`
+		Signature string   `json:"signature"`
+	}{}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(aux.Signature)
+	if err != nil {
+		return err
+	}
+	if aux.Message == nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("envelope: %w", ErrInvalidEnvelope)
+	}
+	e.Message, e.Signature = aux.Message, sig
+	return nil
+}
+
+// EnvelopeTransport delivers Envelopes between the parties of a session, like
+// a Transport delivers messages.
+type EnvelopeTransport interface {
+	// Send delivers e to e.Message.To, or to every other party if it is a broadcast.
+	Send(ctx context.Context, e *Envelope) error
+
+	// Receive blocks until the next envelope addressed to this party arrives,
+	// or ctx is done.
+	Receive(ctx context.Context) (*Envelope, error)
+}
+
+// AuthenticatedTransport is a Transport that seals the messages it sends with
+// the identity key of its party, and opens the envelopes it receives with the
+// identity keys of their senders.
+type AuthenticatedTransport struct {
+	Transport  EnvelopeTransport
+	Key        ed25519.PrivateKey
+	Identities Identities
+}
+
+// NewAuthenticatedTransport returns a Transport that authenticates the messages over t.
+func NewAuthenticatedTransport(t EnvelopeTransport, key ed25519.PrivateKey, identities Identities) *AuthenticatedTransport {
+	return &AuthenticatedTransport{Transport: t, Key: key, Identities: identities}
+}
+
+// Send seals msg and sends the envelope.
+func (t *AuthenticatedTransport) Send(ctx context.Context, msg *Message) error {
+	e, err := SealEnvelope(msg, t.Key)
+	if err != nil {
+		return err
+	}
+	return t.Transport.Send(ctx, e)
+}
+
+// Receive returns the message of the next envelope. An envelope that does not
+// verify is returned as an error wrapping ErrInvalidEnvelope, and the next
+// call to Receive continues with the envelope after it.
+func (t *AuthenticatedTransport) Receive(ctx context.Context) (*Message, error) {
+	e, err := t.Transport.Receive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return e.Open(t.Identities)
+}
@@ -0,0 +1,179 @@
+package frost
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newIdentities returns identity keys for the parties 1..n.
+func newIdentities(t *testing.T, n party.Size) (Identities, map[party.ID]ed25519.PrivateKey) {
+	identities := make(Identities, n)
+	keys := make(map[party.ID]ed25519.PrivateKey, n)
+	for id := party.ID(1); id <= n; id++ {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		identities[id], keys[id] = pub, priv
+	}
+	return identities, keys
+}
+
+func TestEnvelope(t *testing.T) {
+	identities, keys := newIdentities(t, 3)
+
+	for _, msg := range testMessages(t) {
+		e, err := SealEnvelope(msg, keys[msg.From])
+		require.NoError(t, err)
+		opened, err := e.Open(identities)
+		require.NoError(t, err, msg.Type)
+		assert.Equal(t, msg, opened)
+
+		data, err := e.MarshalBinary()
+		require.NoError(t, err)
+		var decoded Envelope
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		_, err = decoded.Open(identities)
+		assert.NoError(t, err, msg.Type)
+
+		data, err = json.Marshal(e)
+		require.NoError(t, err)
+		decoded = Envelope{}
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		_, err = decoded.Open(identities)
+		assert.NoError(t, err, msg.Type)
+
+		// a message sealed by another party does not verify
+		other := msg.From%3 + 1
+		forged, err := SealEnvelope(msg, keys[other])
+		require.NoError(t, err)
+		_, err = forged.Open(identities)
+		assert.True(t, errors.Is(err, ErrInvalidEnvelope), msg.Type)
+	}
+
+	msg := NewSign2(1, scalar.NewScalarRandom())
+	e, err := SealEnvelope(msg, keys[1])
+	require.NoError(t, err)
+
+	// the signature covers the header
+	e.Message = &Message{Header: msg.Header, Sign2: msg.Sign2}
+	e.Message.SessionID[0] = 1
+	_, err = e.Open(identities)
+	assert.True(t, errors.Is(err, ErrInvalidEnvelope))
+
+	e.Message = NewSign2(1, scalar.NewScalarRandom())
+	_, err = e.Open(identities)
+	assert.True(t, errors.Is(err, ErrInvalidEnvelope))
+
+	e.Message = msg
+	_, err = e.Open(Identities{2: identities[2]})
+	assert.True(t, errors.Is(err, ErrInvalidEnvelope))
+}
+
+// envelopePipe is an EnvelopeTransport between two parties.
+type envelopePipe struct {
+	in, out chan *Envelope
+}
+
+func (p *envelopePipe) Send(ctx context.Context, e *Envelope) error {
+	select {
+	case p.out <- e:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *envelopePipe) Receive(ctx context.Context) (*Envelope, error) {
+	select {
+	case e := <-p.in:
+		return e, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestAuthenticatedTransport(t *testing.T) {
+	identities, keys := newIdentities(t, 2)
+	ab, ba := make(chan *Envelope, 1), make(chan *Envelope, 1)
+	a := NewAuthenticatedTransport(&envelopePipe{in: ba, out: ab}, keys[1], identities)
+	b := NewAuthenticatedTransport(&envelopePipe{in: ab, out: ba}, keys[2], identities)
+	ctx := context.Background()
+
+	msg := NewSign2(1, scalar.NewScalarRandom())
+	require.NoError(t, a.Send(ctx, msg))
+	received, err := b.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, msg, received)
+
+	// a message injected on the transport is rejected
+	forged, err := SealEnvelope(NewSign2(1, scalar.NewScalarRandom()), keys[2])
+	require.NoError(t, err)
+	ab <- forged
+	_, err = b.Receive(ctx)
+	assert.True(t, errors.Is(err, ErrInvalidEnvelope))
+}
+
+func TestKeygenState_RegisterIdentities(t *testing.T) {
+	const n = 3
+	identities, keys := newIdentities(t, n)
+
+	states := make(map[party.ID]*KeygenState, n)
+	var round1 []*Message
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, err := KeygenInit(id, n, 1)
+		require.NoError(t, err)
+		require.NoError(t, state.RegisterIdentities(identities))
+		states[id] = state
+
+		// the messages travel in envelopes
+		e, err := SealEnvelope(msg, keys[id])
+		require.NoError(t, err)
+		opened, err := e.Open(identities)
+		require.NoError(t, err)
+		round1 = append(round1, opened)
+	}
+
+	data, err := json.Marshal(states[1])
+	require.NoError(t, err)
+	var decoded KeygenState
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, identities, decoded.Identities)
+	data, err = states[1].MarshalCBOR()
+	require.NoError(t, err)
+	decoded = KeygenState{}
+	require.NoError(t, decoded.UnmarshalCBOR(data))
+	assert.Equal(t, identities, decoded.Identities)
+
+	round2 := make(map[party.ID][]*Message, n)
+	for _, state := range states {
+		msgs, _, err := KeygenRound1(state, round1)
+		require.NoError(t, err)
+		for _, msg := range msgs {
+			round2[msg.To] = append(round2[msg.To], msg)
+		}
+	}
+	public, _, err := KeygenRound2(states[1], round2[1])
+	require.NoError(t, err)
+	assert.Equal(t, map[party.ID]ed25519.PublicKey(identities), public.Identities)
+
+	// the identities are kept with the public information of the group
+	data, err = json.Marshal(public)
+	require.NoError(t, err)
+	var decodedPublic eddsa.Public
+	require.NoError(t, json.Unmarshal(data, &decodedPublic))
+	assert.Equal(t, public.Identities, decodedPublic.Identities)
+
+	_, state, err := KeygenInit(1, n, 1)
+	require.NoError(t, err)
+	assert.Error(t, state.RegisterIdentities(Identities{1: identities[1]}))
+	assert.Error(t, state.RegisterIdentities(Identities{1: identities[1], 2: identities[2], 4: identities[3]}))
+	assert.Error(t, state.RegisterIdentities(Identities{1: identities[1], 2: identities[2], 3: identities[3][:31]}))
+}
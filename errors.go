@@ -0,0 +1,60 @@
+package frost
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bartke/frost/party"
+)
+
+// The errors of the rounds fall into two classes. A protocol violation means
+// that a party sent something the protocol does not allow: a malformed
+// message (ErrInvalidMessage), an invalid share (ErrInvalidShare, which an
+// *AbortError naming the culprit of a signing session wraps), a proof of
+// knowledge that does not verify (ErrProofFailed), a message from a party that
+// does not take part (*ErrUnknownParty), or commitments or messages differing
+// between the parties (ErrCommitmentMismatch, ErrMessageMismatch). Running the
+// session again with the same parties is bound to fail again.
+//
+// The other errors are those of the transport or of the timing of the
+// messages, such as a *TimeoutError, ErrNeedMoreMessages, ErrSessionClosed,
+// or ErrWrongSession and ErrWrongRound for messages delivered late or twice.
+// A session that failed with one of them may succeed if it is retried.
+var (
+	// ErrInvalidShare is wrapped by the errors of a share that does not match
+	// the commitments of its sender, such as a KeyGen2 share failing the VSS
+	// check, or a signature share failing verification.
+	ErrInvalidShare = errors.New("invalid share")
+	// ErrProofFailed is wrapped by the errors of a proof of knowledge that does
+	// not verify, such as the Schnorr proof of a KeyGen1 message.
+	ErrProofFailed = errors.New("proof of knowledge failed")
+)
+
+// ErrUnknownParty is returned for a message from a party that does not take
+// part in the protocol, or in the role the message is sent in, such as a
+// Sign1 message from a party that is not a signer.
+type ErrUnknownParty struct {
+	// ID is the sender of the message.
+	ID party.ID
+}
+
+func (e *ErrUnknownParty) Error() string {
+	return fmt.Sprintf("%v: party %d", ErrUnknownSender, e.ID)
+}
+
+// Unwrap returns ErrUnknownSender.
+func (e *ErrUnknownParty) Unwrap() error {
+	return ErrUnknownSender
+}
+
+// IsProtocolViolation returns true if err is the error of a party that
+// deviated from the protocol, rather than of the transport or the timing of
+// the messages, see the errors above.
+func IsProtocolViolation(err error) bool {
+	for _, target := range []error{ErrInvalidMessage, ErrInvalidShare, ErrProofFailed, ErrUnknownSender, ErrCommitmentMismatch, ErrMessageMismatch} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
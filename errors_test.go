@@ -0,0 +1,72 @@
+package frost
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keygen1Messages returns the KeyGen1 messages of parties 1..3 with threshold 1, and their states.
+func keygen1Messages(t *testing.T) ([]*Message, []*KeygenState) {
+	msgs := make([]*Message, 4)
+	states := make([]*KeygenState, 4)
+	for id := party.ID(1); id <= 3; id++ {
+		msg, state, err := KeygenInit(id, 3, 1)
+		require.NoError(t, err)
+		msgs[id], states[id] = msg, state
+	}
+	return msgs[1:], states
+}
+
+func TestErrors_Keygen(t *testing.T) {
+	round1, states := keygen1Messages(t)
+
+	// the proof of party 2 with the commitments of party 3
+	forged := NewKeyGen1(3, round1[1].KeyGen1.Proof, round1[2].KeyGen1.Commitments)
+	_, _, err := KeygenRound1(states[1], []*Message{round1[1], forged})
+	assert.True(t, errors.Is(err, ErrProofFailed))
+	assert.True(t, IsProtocolViolation(err))
+
+	round1, states = keygen1Messages(t)
+	stranger := *round1[1]
+	stranger.From = 4
+	_, _, err = KeygenRound1(states[1], []*Message{&stranger})
+	var unknown *ErrUnknownParty
+	require.True(t, errors.As(err, &unknown))
+	assert.Equal(t, party.ID(4), unknown.ID)
+	assert.True(t, errors.Is(err, ErrUnknownSender))
+	assert.True(t, IsProtocolViolation(err))
+
+	states2, round2 := keygenRound1(t, 3, 1, nil)
+	var toParty1 []*Message
+	for _, msgs := range round2 {
+		for _, msg := range msgs {
+			if msg.To == 1 {
+				toParty1 = append(toParty1, msg)
+			}
+		}
+	}
+	toParty1[0] = NewKeyGen2(toParty1[0].From, 1, scalar.NewScalarRandom())
+	_, _, err = KeygenRound2(states2[1], toParty1)
+	assert.True(t, errors.Is(err, ErrInvalidShare))
+	assert.True(t, IsProtocolViolation(err))
+}
+
+func TestIsProtocolViolation(t *testing.T) {
+	assert.True(t, IsProtocolViolation(&AbortError{Culprit: 2}))
+	assert.True(t, errors.Is(&AbortError{Culprit: 2}, ErrInvalidShare))
+	assert.True(t, IsProtocolViolation(ErrInvalidMessage))
+	assert.True(t, IsProtocolViolation(ErrCommitmentMismatch))
+
+	// failures of the transport or the timing of the messages may go away on a retry
+	assert.False(t, IsProtocolViolation(&TimeoutError{Round: MessageTypeSign1, Missing: party.IDSlice{2}, Err: context.DeadlineExceeded}))
+	assert.False(t, IsProtocolViolation(&ErrMissingParties{Round: MessageTypeSign2, IDs: party.IDSlice{3}}))
+	assert.False(t, IsProtocolViolation(ErrWrongRound))
+	assert.False(t, IsProtocolViolation(ErrSessionClosed))
+	assert.False(t, IsProtocolViolation(nil))
+}
@@ -0,0 +1,192 @@
+// Package faultinject makes a party of a session misbehave, so that
+// integrations can test how they handle aborts and blame against realistic
+// adversaries rather than against parties that only crash.
+//
+// A Fault alters the messages one party sends, as they are delivered to each
+// recipient: the party can go silent from a given round on, send corrupted
+// commitments or shares, equivocate by sending different broadcasts to
+// different parties, or reuse the nonces of its first signing session. Network
+// connects in-memory transports of all parties, to be passed to
+// frost.KeygenWithContext, frost.SignWithContext or a frost.Machine, and
+// Fault.Endpoint wraps the endpoints of a router.Router of the faulty party,
+// so that messages sent over a real transport are altered as well.
+//
+// The faults only alter messages: the faulty party runs the protocol honestly,
+// and its own session fails or succeeds as it would otherwise. A party reusing
+// its nonces sends the commitments of its first session, but its signature
+// shares are computed with fresh nonces, so that the other signers abort
+// naming it instead of leaking its secret share.
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/polynomial"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/router"
+	"github.com/bartke/frost/scalar"
+)
+
+// Kind is the misbehavior of a faulty party.
+type Kind int
+
+const (
+	// None delivers the messages unaltered.
+	None Kind = iota
+	// Silent drops the messages of Round and of all later rounds.
+	Silent
+	// Corrupt alters the commitments or shares of the messages of Round:
+	// the coefficients of a KeyGen1 polynomial but its constant, whose proof
+	// stays valid, a KeyGen2 share, the commitment Di of a Sign1 message, or a
+	// Sign2 signature share. Other messages are delivered unaltered.
+	Corrupt
+	// Equivocate sends the broadcast of Round corrupted as with Corrupt to the
+	// Victims, and unaltered to the other parties.
+	Equivocate
+	// ReuseNonces sends the commitments of the first Sign1 message of the
+	// party in all of its later ones. Round is ignored.
+	ReuseNonces
+)
+
+func (k Kind) String() string {
+	switch k {
+	case None:
+		return "none"
+	case Silent:
+		return "silent"
+	case Corrupt:
+		return "corrupt"
+	case Equivocate:
+		return "equivocate"
+	case ReuseNonces:
+		return "reuse-nonces"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Fault is the misbehavior of one party. A Fault must not be copied after
+// first use.
+type Fault struct {
+	Kind Kind
+	// Party is the faulty party.
+	Party party.ID
+	// Round is the type of the first messages the fault alters.
+	Round frost.MessageType
+	// Victims are the parties receiving the altered broadcast of an
+	// Equivocate fault. If it is empty, the parties with odd IDs are.
+	Victims party.IDSlice
+
+	mu sync.Mutex
+	// silent is set once a Silent fault dropped the first message.
+	silent bool
+	// sent and altered are the last message of the party and its altered
+	// version, so that all recipients of a broadcast receive the same one.
+	sent, altered *frost.Message
+	// nonces is the first Sign1 message of the party.
+	nonces *frost.Message
+}
+
+// Apply returns the message that party to receives when the party of f sends
+// msg, or nil if it receives nothing. msg is not modified.
+func (f *Fault) Apply(msg *frost.Message, to party.ID) *frost.Message {
+	if msg.From != f.Party {
+		return msg
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch f.Kind {
+	case Silent:
+		if msg.Type == f.Round {
+			f.silent = true
+		}
+		if f.silent {
+			return nil
+		}
+	case Corrupt:
+		if msg.Type == f.Round {
+			return f.alter(msg, corrupt)
+		}
+	case Equivocate:
+		if msg.Type == f.Round && msg.IsBroadcast() && f.isVictim(to) {
+			return f.alter(msg, corrupt)
+		}
+	case ReuseNonces:
+		if msg.Type != frost.MessageTypeSign1 {
+			break
+		}
+		if f.nonces == nil {
+			f.nonces = msg
+		}
+		if msg != f.nonces {
+			return f.alter(msg, func(msg *frost.Message) *frost.Message {
+				return frost.NewSign1(msg.From, &f.nonces.Sign1.Di, &f.nonces.Sign1.Ei)
+			})
+		}
+	}
+	return msg
+}
+
+// Endpoint returns ep, the endpoint of party to in the router of the party of
+// f, delivering the messages as f alters them.
+func (f *Fault) Endpoint(to party.ID, ep router.Endpoint) router.Endpoint {
+	return router.EndpointFunc(func(ctx context.Context, msg *frost.Message) error {
+		if msg = f.Apply(msg, to); msg == nil {
+			return nil
+		}
+		return ep.Deliver(ctx, msg)
+	})
+}
+
+func (f *Fault) isVictim(id party.ID) bool {
+	if len(f.Victims) == 0 {
+		return id%2 == 1
+	}
+	return f.Victims.Contains(id)
+}
+
+// alter returns msg altered by fn, with the header of msg. The altered
+// version of the last message is reused.
+func (f *Fault) alter(msg *frost.Message, fn func(*frost.Message) *frost.Message) *frost.Message {
+	if msg != f.sent {
+		f.sent, f.altered = msg, fn(msg)
+		if f.altered != msg {
+			f.altered.Header = msg.Header
+		}
+	}
+	return f.altered
+}
+
+// corrupt returns a copy of msg with altered commitments or shares, or msg if
+// its type is not supported.
+func corrupt(msg *frost.Message) *frost.Message {
+	one := scalar.NewScalarUInt32(1)
+	switch {
+	case msg.KeyGen1 != nil:
+		// shift the coefficients but the constant, the proof is of the constant
+		commitments := msg.KeyGen1.Commitments.Copy()
+		shift := polynomial.NewPolynomialExponent(polynomial.NewPolynomial(commitments.Degree(), ristretto.NewScalar()))
+		if err := commitments.Add(shift); err != nil {
+			return msg
+		}
+		return frost.NewKeyGen1(msg.From, msg.KeyGen1.Proof, commitments)
+	case msg.KeyGen2 != nil:
+		var share ristretto.Scalar
+		share.Add(&msg.KeyGen2.Share, one)
+		return frost.NewKeyGen2(msg.From, msg.To, &share)
+	case msg.Sign1 != nil:
+		var di ristretto.Element
+		di.Add(&msg.Sign1.Di, new(ristretto.Element).ScalarBaseMult(one))
+		return frost.NewSign1(msg.From, &di, &msg.Sign1.Ei)
+	case msg.Sign2 != nil:
+		var zi ristretto.Scalar
+		zi.Add(&msg.Sign2.Zi, one)
+		return frost.NewSign2(msg.From, &zi)
+	}
+	return msg
+}
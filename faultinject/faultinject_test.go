@@ -0,0 +1,218 @@
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/router"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keygen runs a key generation of the parties 1..n with threshold t on net,
+// and returns the result or error of every party.
+func keygen(net *Network, n, t party.Size) (map[party.ID]*eddsa.Public, map[party.ID]*eddsa.SecretShare, map[party.ID]error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		publics = make(map[party.ID]*eddsa.Public, n)
+		secrets = make(map[party.ID]*eddsa.SecretShare, n)
+		errs    = make(map[party.ID]error, n)
+	)
+	for id := party.ID(1); id <= party.ID(n); id++ {
+		wg.Add(1)
+		go func(id party.ID) {
+			defer wg.Done()
+			public, secret, err := frost.KeygenWithContext(ctx, net.Transport(id), id, n, t)
+			mu.Lock()
+			defer mu.Unlock()
+			publics[id], secrets[id], errs[id] = public, secret, err
+		}(id)
+	}
+	wg.Wait()
+	return publics, secrets, errs
+}
+
+// sign runs a signing of message by signerIDs on net, waiting at most timeout,
+// and returns the error of every signer.
+func sign(net *Network, signerIDs party.IDSlice, public *eddsa.Public, secrets map[party.ID]*eddsa.SecretShare, message []byte, timeout time.Duration) map[party.ID]error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs = make(map[party.ID]error, len(signerIDs))
+	)
+	for _, id := range signerIDs {
+		wg.Add(1)
+		go func(id party.ID) {
+			defer wg.Done()
+			sig, err := frost.SignWithContext(ctx, net.Transport(id), signerIDs, secrets[id], public, message)
+			if err == nil && !public.GroupKey.Verify(message, sig) {
+				err = errors.New("invalid signature")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			errs[id] = err
+		}(id)
+	}
+	wg.Wait()
+	return errs
+}
+
+// newGroup runs an honest key generation of the parties 1..3 with threshold 1 on net.
+func newGroup(t *testing.T, net *Network) (*eddsa.Public, map[party.ID]*eddsa.SecretShare) {
+	publics, secrets, errs := keygen(net, 3, 1)
+	for id, err := range errs {
+		require.NoError(t, err, "party %d", id)
+	}
+	return publics[1], secrets
+}
+
+func TestNetwork(t *testing.T) {
+	net := NewNetwork(party.IDSlice{1, 2, 3})
+	public, secrets := newGroup(t, net)
+	for id, err := range sign(net, party.IDSlice{1, 3}, public, secrets, []byte("honest"), 5*time.Second) {
+		assert.NoError(t, err, "party %d", id)
+	}
+	assert.Error(t, net.Transport(1).Send(context.Background(), frost.NewSign2(2, scalar.NewScalarRandom())), "message from another party")
+}
+
+func TestFault_Silent(t *testing.T) {
+	fault := &Fault{Kind: Silent, Party: 3, Round: frost.MessageTypeSign2}
+	net := NewNetwork(party.IDSlice{1, 2, 3}, fault)
+	public, secrets := newGroup(t, net)
+
+	errs := sign(net, party.IDSlice{1, 2, 3}, public, secrets, []byte("m"), 200*time.Millisecond)
+	for _, id := range []party.ID{1, 2} {
+		var timeout *frost.TimeoutError
+		require.True(t, errors.As(errs[id], &timeout), "party %d: %v", id, errs[id])
+		assert.Equal(t, frost.MessageTypeSign2, timeout.Round)
+		assert.Equal(t, party.IDSlice{3}, timeout.Missing)
+		assert.False(t, frost.IsProtocolViolation(errs[id]))
+	}
+}
+
+func TestFault_Corrupt(t *testing.T) {
+	for _, round := range []frost.MessageType{frost.MessageTypeKeyGen1, frost.MessageTypeKeyGen2} {
+		net := NewNetwork(party.IDSlice{1, 2, 3}, &Fault{Kind: Corrupt, Party: 2, Round: round})
+		_, _, errs := keygen(net, 3, 1)
+		for _, id := range []party.ID{1, 3} {
+			assert.True(t, errors.Is(errs[id], frost.ErrInvalidShare), "%s, party %d: %v", round, id, errs[id])
+		}
+	}
+
+	for _, round := range []frost.MessageType{frost.MessageTypeSign1, frost.MessageTypeSign2} {
+		net := NewNetwork(party.IDSlice{1, 2, 3}, &Fault{Kind: Corrupt, Party: 2, Round: round})
+		public, secrets := newGroup(t, net)
+		errs := sign(net, party.IDSlice{1, 2}, public, secrets, []byte("m"), 5*time.Second)
+		var abort *frost.AbortError
+		require.True(t, errors.As(errs[1], &abort), "%s: %v", round, errs[1])
+		assert.Equal(t, party.ID(2), abort.Culprit)
+	}
+}
+
+func TestFault_Equivocate(t *testing.T) {
+	fault := &Fault{Kind: Equivocate, Party: 3, Round: frost.MessageTypeKeyGen1, Victims: party.IDSlice{1}}
+	net := NewNetwork(party.IDSlice{1, 2, 3, 4}, fault)
+
+	// the echo round reveals that parties 1 and 2 received different commitments
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errs := make([]error, 5)
+	var wg sync.WaitGroup
+	for id := party.ID(1); id <= 4; id++ {
+		wg.Add(1)
+		go func(id party.ID) {
+			defer wg.Done()
+			errs[id] = run(ctx, net.Transport(id), frost.NewKeygenMachineWithEcho(id, 4, 1))
+		}(id)
+	}
+	wg.Wait()
+	for _, id := range []party.ID{1, 2, 4} {
+		assert.True(t, errors.Is(errs[id], frost.ErrCommitmentMismatch), "party %d: %v", id, errs[id])
+	}
+
+	// without it, the victims fail their share checks
+	fault = &Fault{Kind: Equivocate, Party: 3, Round: frost.MessageTypeKeyGen1}
+	_, _, keygenErrs := keygen(NewNetwork(party.IDSlice{1, 2, 3, 4}, fault), 4, 1)
+	assert.True(t, errors.Is(keygenErrs[1], frost.ErrInvalidShare))
+	assert.NoError(t, keygenErrs[2], "not a victim")
+}
+
+// run runs m over transport until it ends.
+func run(ctx context.Context, transport frost.Transport, m *frost.Machine) error {
+	var inbox []*frost.Message
+	for {
+		out, status := m.Advance(time.Now(), inbox)
+		inbox = inbox[:0]
+		for _, msg := range out {
+			if err := transport.Send(ctx, msg); err != nil {
+				return err
+			}
+		}
+		if status != frost.StatusWaiting {
+			_, err := m.Result()
+			return err
+		}
+		msg, err := transport.Receive(ctx)
+		if err != nil {
+			return err
+		}
+		inbox = append(inbox, msg)
+	}
+}
+
+func TestFault_ReuseNonces(t *testing.T) {
+	fault := &Fault{Kind: ReuseNonces, Party: 3}
+	net := NewNetwork(party.IDSlice{1, 2, 3}, fault)
+	public, secrets := newGroup(t, net)
+
+	for id, err := range sign(net, party.IDSlice{1, 3}, public, secrets, []byte("first"), 5*time.Second) {
+		require.NoError(t, err, "party %d", id)
+	}
+	errs := sign(net, party.IDSlice{1, 3}, public, secrets, []byte("second"), 5*time.Second)
+	var abort *frost.AbortError
+	require.True(t, errors.As(errs[1], &abort), "%v", errs[1])
+	assert.Equal(t, party.ID(3), abort.Culprit)
+}
+
+func TestFault_Endpoint(t *testing.T) {
+	fault := &Fault{Kind: Corrupt, Party: 1, Round: frost.MessageTypeSign2}
+	var (
+		mu        sync.Mutex
+		delivered = make(map[party.ID]*frost.Message)
+	)
+	r := router.New(1, router.Config{})
+	for _, id := range []party.ID{2, 3} {
+		r.AddRoute(id, fault.Endpoint(id, router.EndpointFunc(func(_ context.Context, msg *frost.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			delivered[id] = msg
+			return nil
+		})))
+	}
+
+	msg := frost.NewSign2(1, scalar.NewScalarRandom())
+	msg.SessionID = frost.SessionID{7}
+	require.NoError(t, r.Send(context.Background(), msg))
+	require.Len(t, delivered, 2)
+	assert.Same(t, delivered[2], delivered[3], "the same corrupted message to all recipients")
+	assert.Equal(t, msg.Header, delivered[2].Header)
+	assert.Equal(t, 0, msg.Sign2.Zi.Equal(&delivered[2].Sign2.Zi))
+
+	// messages of other rounds and parties are delivered unaltered
+	keygen2 := frost.NewKeyGen2(1, 2, scalar.NewScalarRandom())
+	assert.Same(t, keygen2, fault.Apply(keygen2, 2))
+	other := frost.NewSign2(2, scalar.NewScalarRandom())
+	assert.Same(t, other, fault.Apply(other, 3))
+}
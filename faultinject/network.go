@@ -0,0 +1,102 @@
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/party"
+)
+
+// Network delivers the messages between in-memory transports of the parties,
+// altered by the faults of their senders.
+type Network struct {
+	faults  map[party.ID]*Fault
+	inboxes map[party.ID]*inbox
+}
+
+// inbox holds the messages delivered to a party, which its transport has not received yet.
+type inbox struct {
+	mu   sync.Mutex
+	msgs []*frost.Message
+	// notify is signaled when a message is added to msgs.
+	notify chan struct{}
+}
+
+// NewNetwork returns a Network of the parties ids, of which those of faults misbehave.
+func NewNetwork(ids party.IDSlice, faults ...*Fault) *Network {
+	n := &Network{
+		faults:  make(map[party.ID]*Fault, len(faults)),
+		inboxes: make(map[party.ID]*inbox, len(ids)),
+	}
+	for _, id := range ids {
+		n.inboxes[id] = &inbox{notify: make(chan struct{}, 1)}
+	}
+	for _, f := range faults {
+		n.faults[f.Party] = f
+	}
+	return n
+}
+
+// Transport returns the frost.Transport of party id.
+func (n *Network) Transport(id party.ID) frost.Transport {
+	return &transport{network: n, self: id}
+}
+
+type transport struct {
+	network *Network
+	self    party.ID
+}
+
+// Send implements frost.Transport.
+func (t *transport) Send(_ context.Context, msg *frost.Message) error {
+	if msg.From != t.self {
+		return fmt.Errorf("faultinject: message from party %d sent by party %d", msg.From, t.self)
+	}
+	if !msg.IsBroadcast() {
+		if _, ok := t.network.inboxes[msg.To]; !ok {
+			return fmt.Errorf("faultinject: unknown party %d", msg.To)
+		}
+	}
+	for id, in := range t.network.inboxes {
+		if id == t.self || !msg.IsBroadcast() && msg.To != id {
+			continue
+		}
+		delivered := msg
+		if f, ok := t.network.faults[t.self]; ok {
+			if delivered = f.Apply(msg, id); delivered == nil {
+				continue
+			}
+		}
+		in.mu.Lock()
+		in.msgs = append(in.msgs, delivered)
+		in.mu.Unlock()
+		select {
+		case in.notify <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Receive implements frost.Transport.
+func (t *transport) Receive(ctx context.Context) (*frost.Message, error) {
+	in := t.network.inboxes[t.self]
+	for {
+		in.mu.Lock()
+		if len(in.msgs) > 0 {
+			msg := in.msgs[0]
+			in.msgs = in.msgs[1:]
+			in.mu.Unlock()
+			return msg, nil
+		}
+		in.mu.Unlock()
+
+		select {
+		case <-in.notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
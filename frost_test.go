@@ -0,0 +1,610 @@
+package frost
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+	"testing"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/polynomial"
+	"github.com/bartke/frost/ristretto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generateKeys runs the key generation protocol for the parties 1..n in memory.
+func generateKeys(t testing.TB, n, threshold party.Size) (*eddsa.Public, map[party.ID]*eddsa.SecretShare) {
+	t.Helper()
+
+	states := make(map[party.ID]*KeygenState, n)
+	var round1 []*Message
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, err := KeygenInit(id, n, threshold)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+
+	round2 := make(map[party.ID][]*Message, n)
+	for _, state := range states {
+		msgs, _, err := KeygenRound1(state, round1)
+		require.NoError(t, err)
+		for _, msg := range msgs {
+			round2[msg.To] = append(round2[msg.To], msg)
+		}
+	}
+
+	var public *eddsa.Public
+	secrets := make(map[party.ID]*eddsa.SecretShare, n)
+	for id, state := range states {
+		pub, sec, err := KeygenRound2(state, round2[id])
+		require.NoError(t, err)
+		if public != nil {
+			require.True(t, public.Equal(pub))
+		}
+		public = pub
+		secrets[id] = sec
+	}
+	return public, secrets
+}
+
+func TestKeygenRound2_Workers(t *testing.T) {
+	const n, threshold = 7, 4
+	states := make(map[party.ID]*KeygenState, n)
+	var round1 []*Message
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, err := KeygenInit(id, n, threshold)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	round2 := make(map[party.ID][]*Message, n)
+	for _, state := range states {
+		msgs, _, err := KeygenRound1(state, round1)
+		require.NoError(t, err)
+		for _, msg := range msgs {
+			round2[msg.To] = append(round2[msg.To], msg)
+		}
+	}
+
+	// an invalid share is found by any number of workers, and the shares
+	// before it are still processed
+	for _, workers := range []int{1, 3, 0} {
+		data, err := json.Marshal(states[1])
+		require.NoError(t, err)
+		var state KeygenState
+		require.NoError(t, json.Unmarshal(data, &state))
+		state.Workers = workers
+
+		msgs := append([]*Message(nil), round2[1]...)
+		bad := *msgs[3]
+		bad.KeyGen2 = &KeyGen2{Share: *ristretto.NewScalar().Add(&msgs[3].KeyGen2.Share, party.ID(1).Scalar())}
+		msgs[3] = &bad
+		_, _, err = KeygenRound2(&state, msgs)
+		assert.Error(t, err, workers)
+		assert.Len(t, state.Received[MessageTypeKeyGen2], 3, workers)
+	}
+
+	var public *eddsa.Public
+	for id, state := range states {
+		state.Workers = int(id) % 3
+		pub, sec, err := KeygenRound2(state, round2[id])
+		require.NoError(t, err)
+		if public != nil {
+			require.True(t, public.Equal(pub))
+		}
+		public = pub
+		var expected ristretto.Element
+		assert.Equal(t, 1, expected.ScalarBaseMult(&sec.Secret).Equal(pub.Shares[id]))
+	}
+}
+
+// runSignRounds runs both signing rounds for the given initialized states,
+// and returns the signature computed by each signer.
+func runSignRounds(states map[party.ID]*SignerState, round1 []*Message) (map[party.ID]*eddsa.Signature, error) {
+	var round2 []*Message
+	for _, state := range states {
+		msg, _, err := SignRound1(state, round1)
+		if err != nil {
+			return nil, err
+		}
+		round2 = append(round2, msg)
+	}
+
+	sigs := make(map[party.ID]*eddsa.Signature, len(states))
+	for id, state := range states {
+		sig, _, err := SignRound2(state, round2)
+		if err != nil {
+			return nil, err
+		}
+		sigs[id] = sig
+	}
+	return sigs, nil
+}
+
+func TestKeygenAndSign(t *testing.T) {
+	public, secrets := generateKeys(t, 5, 2)
+	signers := party.IDSlice{4, 1, 5}
+	message := []byte("hello FROST")
+
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInit(signers, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+
+	sigs, err := runSignRounds(states, round1)
+	require.NoError(t, err)
+	for _, sig := range sigs {
+		require.True(t, public.GroupKey.Verify(message, sig))
+	}
+}
+
+func TestVerifyShares(t *testing.T) {
+	public, secrets := generateKeys(t, 7, 4)
+	signers := party.IDSlice{1, 2, 3, 5, 7}
+	message := []byte("batched shares")
+
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInit(signers, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	var round2 []*Message
+	for _, id := range signers {
+		msg, _, err := SignRound1(states[id], round1)
+		require.NoError(t, err)
+		round2 = append(round2, msg)
+	}
+
+	state := states[1]
+	// shares sets the shares of msgs in state, and returns their senders
+	shares := func(msgs []*Message) party.IDSlice {
+		var ids party.IDSlice
+		for _, msg := range msgs {
+			state.Signers[msg.From].Zi.Set(&msg.Sign2.Zi)
+			ids = append(ids, msg.From)
+		}
+		return ids
+	}
+	assert.True(t, verifyShares(&state.C, state.Signers, shares(round2)))
+	assert.True(t, verifyShares(&state.C, state.Signers, shares(round2[2:3])))
+	assert.True(t, verifyShares(&state.C, state.Signers, nil))
+
+	// shares that are swapped between signers are not valid
+	swapped := append([]*Message{}, round2...)
+	swapped[1] = NewSign2(2, &round2[2].Sign2.Zi)
+	swapped[2] = NewSign2(3, &round2[1].Sign2.Zi)
+	assert.False(t, verifyShares(&state.C, state.Signers, shares(swapped)))
+
+	// the culprit is still found by SignRound2
+	_, _, err := SignRound2(state, swapped)
+	var abortErr *AbortError
+	require.True(t, errors.As(err, &abortErr))
+	assert.Equal(t, party.ID(2), abortErr.Culprit)
+}
+
+func BenchmarkSignRound2(b *testing.B) {
+	public, secrets := generateKeys(b, 20, 14)
+	signers := public.PartyIDs[:15]
+	message := []byte("benchmark")
+
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, _ := SignInit(signers, secrets[id], public, message)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	var round2 []*Message
+	for _, id := range signers {
+		msg, _, _ := SignRound1(states[id], round1)
+		round2 = append(round2, msg)
+	}
+	saved, _ := json.Marshal(states[1])
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		var state SignerState
+		_ = json.Unmarshal(saved, &state)
+		b.StartTimer()
+		if _, _, err := SignRound2(&state, round2); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// signRoundAllocs returns the allocations of SignRound1 and SignRound2 of
+// party 1 in a session of n signers.
+func signRoundAllocs(t *testing.T, n party.Size) float64 {
+	public, secrets := generateKeys(t, n, n-1)
+	signers := public.PartyIDs
+
+	// a session for every run, and for the warm-up of AllocsPerRun
+	const runs = 10
+	type session struct {
+		state          *SignerState
+		round1, round2 []*Message
+	}
+	sessions := make([]session, runs+1)
+	for i := range sessions {
+		states := make(map[party.ID]*SignerState)
+		for _, id := range signers {
+			msg, state, err := SignInit(signers, secrets[id], public, []byte("allocs"))
+			require.NoError(t, err)
+			states[id] = state
+			sessions[i].round1 = append(sessions[i].round1, msg)
+		}
+		for _, id := range signers[1:] {
+			msg, _, err := SignRound1(states[id], sessions[i].round1)
+			require.NoError(t, err)
+			sessions[i].round2 = append(sessions[i].round2, msg)
+		}
+		sessions[i].state = states[1]
+	}
+
+	next := 0
+	return testing.AllocsPerRun(runs, func() {
+		s := sessions[next]
+		next++
+		if _, _, err := SignRound1(s.state, s.round1); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := SignRound2(s.state, s.round2); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestSignRoundsAllocs(t *testing.T) {
+	// the rounds do not allocate for every co-signer; the race detector drops
+	// some of the pooled buffers, hence the slack
+	small, large := signRoundAllocs(t, 3), signRoundAllocs(t, 15)
+	assert.Less(t, large-small, float64(15-3)/2, "%v allocations with 3 signers, %v with 15", small, large)
+}
+
+func BenchmarkSignRound1(b *testing.B) {
+	public, secrets := generateKeys(b, 20, 14)
+	signers := public.PartyIDs[:15]
+	message := []byte("benchmark")
+
+	var round1 []*Message
+	for _, id := range signers {
+		msg, _, _ := SignInit(signers, secrets[id], public, message)
+		round1 = append(round1, msg)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		_, state, _ := SignInit(signers, secrets[1], public, message)
+		round1[0] = NewSign1(1, &state.Signers[1].Di, &state.Signers[1].Ei)
+		b.StartTimer()
+		if _, _, err := SignRound1(state, round1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkKeygenRound2(b *testing.B) {
+	const n, threshold = 50, 33
+	states := make(map[party.ID]*KeygenState, n)
+	var round1 []*Message
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, _ := KeygenInit(id, n, threshold)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	if _, _, err := KeygenRound1(states[1], round1); err != nil {
+		b.Fatal(err)
+	}
+	var round2 []*Message
+	for id := party.ID(2); id <= n; id++ {
+		out, _, _ := KeygenRound1(states[id], round1)
+		for _, msg := range out {
+			if msg.To == 1 {
+				round2 = append(round2, msg)
+			}
+		}
+	}
+	saved, _ := json.Marshal(states[1])
+
+	for _, workers := range []int{1, 0} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				var state KeygenState
+				_ = json.Unmarshal(saved, &state)
+				state.Workers = workers
+				b.StartTimer()
+				if _, _, err := KeygenRound2(&state, round2); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestZeroize(t *testing.T) {
+	const n = 3
+	zero := ristretto.NewScalar()
+
+	states := make(map[party.ID]*KeygenState, n)
+	var round1 []*Message
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, err := KeygenInit(id, n, 1)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	round2 := make(map[party.ID][]*Message, n)
+	for _, state := range states {
+		msgs, _, err := KeygenRound1(state, round1)
+		require.NoError(t, err)
+		for _, msg := range msgs {
+			round2[msg.To] = append(round2[msg.To], msg)
+		}
+	}
+	public, secret, err := KeygenRound2(states[1], round2[1])
+	require.NoError(t, err)
+	assert.Equal(t, 0, secret.Secret.Equal(zero))
+	assert.Equal(t, 1, states[1].Secret.Equal(zero))
+	assert.Equal(t, 1, states[1].Polynomial.Constant().Equal(zero))
+
+	public, secrets := generateKeys(t, n, 1)
+	signers := party.IDSlice{1, 2}
+	signStates := make(map[party.ID]*SignerState)
+	var sign1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInit(signers, secrets[id], public, []byte("zeroize"))
+		require.NoError(t, err)
+		signStates[id] = state
+		sign1 = append(sign1, msg)
+	}
+	sigs, err := runSignRounds(signStates, sign1)
+	require.NoError(t, err)
+	for id, state := range signStates {
+		assert.True(t, public.GroupKey.Verify([]byte("zeroize"), sigs[id]))
+		assert.Equal(t, 1, state.SecretKeyShare.Equal(zero))
+		assert.Equal(t, 1, state.D.Equal(zero))
+		assert.Equal(t, 1, state.E.Equal(zero))
+		for _, signer := range state.Signers {
+			assert.Equal(t, 1, signer.Zi.Equal(zero))
+		}
+		// the secret share itself is left to its owner
+		assert.Equal(t, 0, secrets[id].Secret.Equal(zero))
+	}
+}
+
+func TestKeygenInitDeterministic(t *testing.T) {
+	const n, threshold = 3, 1
+	seeds := make(map[party.ID][]byte, n)
+	for id := party.ID(1); id <= n; id++ {
+		seeds[id] = bytes.Repeat([]byte{byte(id)}, MinSeedSize)
+	}
+
+	run := func(context string) (*eddsa.Public, []byte) {
+		states := make(map[party.ID]*KeygenState, n)
+		var round1 []*Message
+		var transcript []byte
+		for id := party.ID(1); id <= n; id++ {
+			msg, state, err := KeygenInitDeterministic(id, n, threshold, seeds[id], []byte(context))
+			require.NoError(t, err)
+			data, err := msg.MarshalJSON()
+			require.NoError(t, err)
+			transcript = append(transcript, data...)
+			states[id] = state
+			round1 = append(round1, msg)
+		}
+
+		round2 := make(map[party.ID][]*Message, n)
+		for id := party.ID(1); id <= n; id++ {
+			msgs, _, err := KeygenRound1(states[id], round1)
+			require.NoError(t, err)
+			for _, msg := range msgs {
+				round2[msg.To] = append(round2[msg.To], msg)
+			}
+		}
+
+		var public *eddsa.Public
+		for id := party.ID(1); id <= n; id++ {
+			pub, _, err := KeygenRound2(states[id], round2[id])
+			require.NoError(t, err)
+			public = pub
+		}
+		return public, transcript
+	}
+
+	public, transcript := run("group A")
+	again, againTranscript := run("group A")
+	assert.True(t, public.Equal(again))
+	assert.Equal(t, transcript, againTranscript)
+
+	other, _ := run("group B")
+	assert.False(t, public.GroupKey.Equal(other.GroupKey))
+
+	_, _, err := KeygenInitDeterministic(1, n, threshold, seeds[1][:MinSeedSize-1], nil)
+	assert.Error(t, err)
+}
+
+func TestKeygenInitDeterministicMaxDegree(t *testing.T) {
+	// the polynomial needs more randomness than a single HKDF reader holds
+	const threshold = polynomial.MaxDegree
+	seed := bytes.Repeat([]byte{1}, MinSeedSize)
+	msg, state, err := KeygenInitDeterministic(1, threshold+1, threshold, seed, nil)
+	require.NoError(t, err)
+	again, againState, err := KeygenInitDeterministic(1, threshold+1, threshold, seed, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, threshold, msg.KeyGen1.Commitments.Degree())
+	data, err := msg.MarshalBinary()
+	require.NoError(t, err)
+	againData, err := again.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, againData)
+	assert.Equal(t, 1, state.Secret.Equal(&againState.Secret))
+}
+
+func TestKeygenAndSignWithRand(t *testing.T) {
+	const n, threshold = 3, 1
+	signers := party.IDSlice{1, 3}
+	message := []byte("reproducible")
+	rng := func(id party.ID, round int64) *mathrand.Rand {
+		return mathrand.New(mathrand.NewSource(int64(id)<<8 | round))
+	}
+
+	run := func() (*eddsa.Public, *eddsa.Signature) {
+		states := make(map[party.ID]*KeygenState, n)
+		var round1 []*Message
+		for id := party.ID(1); id <= n; id++ {
+			msg, state, err := KeygenInitWithRand(id, n, threshold, rng(id, 1))
+			require.NoError(t, err)
+			states[id] = state
+			round1 = append(round1, msg)
+		}
+		round2 := make(map[party.ID][]*Message, n)
+		for id := party.ID(1); id <= n; id++ {
+			msgs, _, err := KeygenRound1(states[id], round1)
+			require.NoError(t, err)
+			for _, msg := range msgs {
+				round2[msg.To] = append(round2[msg.To], msg)
+			}
+		}
+		var public *eddsa.Public
+		secrets := make(map[party.ID]*eddsa.SecretShare, n)
+		for id := party.ID(1); id <= n; id++ {
+			pub, sec, err := KeygenRound2(states[id], round2[id])
+			require.NoError(t, err)
+			public, secrets[id] = pub, sec
+		}
+
+		signStates := make(map[party.ID]*SignerState, len(signers))
+		var sign1, sign2 []*Message
+		for _, id := range signers {
+			msg, state, err := SignInitWithRand(signers, secrets[id], public, message, rng(id, 2))
+			require.NoError(t, err)
+			signStates[id] = state
+			sign1 = append(sign1, msg)
+		}
+		for _, id := range signers {
+			msg, _, err := SignRound1(signStates[id], sign1)
+			require.NoError(t, err)
+			sign2 = append(sign2, msg)
+		}
+		sig, _, err := SignRound2(signStates[signers[0]], sign2)
+		require.NoError(t, err)
+		require.True(t, public.GroupKey.Verify(message, sig))
+		return public, sig
+	}
+
+	public, sig := run()
+	again, againSig := run()
+	assert.True(t, public.Equal(again))
+	assert.Equal(t, sig.ToEd25519(), againSig.ToEd25519())
+
+	// a nil rng reads crypto/rand
+	_, state, err := KeygenInitWithRand(1, n, threshold, nil)
+	require.NoError(t, err)
+	_, other, err := KeygenInitWithRand(1, n, threshold, nil)
+	require.NoError(t, err)
+	assert.False(t, state.CommitmentsSum.Equal(other.CommitmentsSum))
+}
+
+func TestKeygenInitWithIDs(t *testing.T) {
+	const threshold = 2
+	ids := party.IDSlice{4021, 17, 60000, 512}
+
+	states := make(map[party.ID]*KeygenState, len(ids))
+	var round1 []*Message
+	for _, id := range ids {
+		msg, state, err := KeygenInitWithIDs(id, ids, threshold)
+		require.NoError(t, err)
+		assert.Equal(t, party.IDSlice{17, 512, 4021, 60000}, state.PartyIDs)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+
+	round2 := make(map[party.ID][]*Message, len(ids))
+	for _, id := range ids {
+		msgs, _, err := KeygenRound1(states[id], round1)
+		require.NoError(t, err)
+		for _, msg := range msgs {
+			round2[msg.To] = append(round2[msg.To], msg)
+		}
+	}
+
+	var public *eddsa.Public
+	secrets := make(map[party.ID]*eddsa.SecretShare, len(ids))
+	for _, id := range ids {
+		pub, sec, err := KeygenRound2(states[id], round2[id])
+		require.NoError(t, err)
+		public, secrets[id] = pub, sec
+	}
+	assert.Equal(t, party.IDSlice{17, 512, 4021, 60000}, public.PartyIDs)
+
+	signers := party.IDSlice{17, 4021, 60000}
+	message := []byte("sparse IDs")
+	signStates := make(map[party.ID]*SignerState)
+	var sign1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInit(signers, secrets[id], public, message)
+		require.NoError(t, err)
+		signStates[id] = state
+		sign1 = append(sign1, msg)
+	}
+	sigs, err := runSignRounds(signStates, sign1)
+	require.NoError(t, err)
+	assert.True(t, public.GroupKey.Verify(message, sigs[17]))
+
+	// a KeyGen1 message of a party outside the group is rejected
+	outsider, _, err := KeygenInit(3, 3, threshold)
+	require.NoError(t, err)
+	_, state, err := KeygenInitWithIDs(17, ids, threshold)
+	require.NoError(t, err)
+	_, _, err = KeygenRound1(state, []*Message{outsider})
+	assert.Error(t, err)
+
+	for _, bad := range []party.IDSlice{{17, 17, 512}, {0, 17, 512}, {512, 4021, 60000}, {17, 512}} {
+		_, _, err := KeygenInitWithIDs(17, bad, threshold)
+		assert.Error(t, err, bad)
+	}
+
+	// the commitments of a larger threshold could not be decoded
+	_, _, err = KeygenInit(1, polynomial.MaxDegree+2, polynomial.MaxDegree+1)
+	assert.Error(t, err)
+}
+
+func TestKeygenInitInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		selfID party.ID
+		n, t   party.Size
+	}{
+		{"party 0", 0, 3, 1},
+		{"party outside the group", 5, 3, 1},
+		{"threshold of all parties", 1, 3, 3},
+		{"threshold above the parties", 1, 3, 5},
+		{"no parties", 1, 0, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := KeygenInit(tc.selfID, tc.n, tc.t)
+			assert.Error(t, err)
+			_, _, err = KeygenInitWithRand(tc.selfID, tc.n, tc.t, nil)
+			assert.Error(t, err)
+			_, _, err = KeygenInitDeterministic(tc.selfID, tc.n, tc.t, make([]byte, MinSeedSize), nil)
+			assert.Error(t, err)
+		})
+	}
+}
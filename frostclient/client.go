@@ -0,0 +1,139 @@
+// Package frostclient is a small facade over the round-level frost API.
+//
+// It runs key generation and signing for all parties of a group inside the
+// current process, which is what tests, demos and single-operator setups need.
+// Deployments where each share lives on a different machine should drive the
+// rounds directly, or through a frost.Transport.
+package frostclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+)
+
+// Config describes the group to create.
+type Config struct {
+	// N is the number of parties.
+	N party.Size
+	// T is the maximum number of corrupted parties, T+1 parties are needed to sign.
+	T party.Size
+}
+
+// Group holds the public information of a group and the secret shares of its parties.
+type Group struct {
+	Public *eddsa.Public
+	Shares map[party.ID]*eddsa.SecretShare
+
+	// Signers are the parties used by Sign. It defaults to the T+1 smallest IDs.
+	Signers party.IDSlice
+}
+
+// CreateGroup runs the distributed key generation for cfg.N parties.
+func CreateGroup(cfg Config) (*Group, error) {
+	if cfg.N == 0 || cfg.T >= cfg.N {
+		return nil, fmt.Errorf("frostclient: invalid group size N=%d T=%d", cfg.N, cfg.T)
+	}
+
+	states := make(map[party.ID]*frost.KeygenState, cfg.N)
+	round1 := make([]*frost.Message, 0, cfg.N)
+	for id := party.ID(1); id <= cfg.N; id++ {
+		msg, state, err := frost.KeygenInit(id, cfg.N, cfg.T)
+		if err != nil {
+			return nil, fmt.Errorf("frostclient: %w", err)
+		}
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+
+	round2 := make(map[party.ID][]*frost.Message, cfg.N)
+	for id, state := range states {
+		msgs, _, err := frost.KeygenRound1(state, round1)
+		if err != nil {
+			return nil, fmt.Errorf("frostclient: party %d: %w", id, err)
+		}
+		for _, msg := range msgs {
+			round2[msg.To] = append(round2[msg.To], msg)
+		}
+	}
+
+	group := &Group{Shares: make(map[party.ID]*eddsa.SecretShare, cfg.N)}
+	for id, state := range states {
+		public, secret, err := frost.KeygenRound2(state, round2[id])
+		if err != nil {
+			return nil, fmt.Errorf("frostclient: party %d: %w", id, err)
+		}
+		if group.Public == nil {
+			group.Public = public
+		} else if !group.Public.Equal(public) {
+			return nil, errors.New("frostclient: parties disagree on the public shares")
+		}
+		group.Shares[id] = secret
+	}
+	group.Signers = group.Public.PartyIDs[:cfg.T+1].Copy()
+
+	return group, nil
+}
+
+// Sign produces a signature on message using the shares of group.Signers.
+// ctx is checked between rounds.
+func Sign(ctx context.Context, group *Group, message []byte) (*eddsa.Signature, error) {
+	signers := party.NewIDSlice(group.Signers)
+	if signers.N() <= group.Public.Threshold {
+		return nil, fmt.Errorf("frostclient: %d signers cannot meet threshold %d", signers.N(), group.Public.Threshold)
+	}
+
+	states := make(map[party.ID]*frost.SignerState, signers.N())
+	// SignRound2 zeroizes the state of one signer only, and none if it fails
+	defer func() {
+		for _, state := range states {
+			state.Zeroize()
+		}
+	}()
+	round1 := make([]*frost.Message, 0, signers.N())
+	for _, id := range signers {
+		secret, ok := group.Shares[id]
+		if !ok {
+			return nil, fmt.Errorf("frostclient: no secret share for party %d", id)
+		}
+		msg, state, err := frost.SignInit(signers, secret, group.Public, message)
+		if err != nil {
+			return nil, fmt.Errorf("frostclient: %w", err)
+		}
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	round2 := make([]*frost.Message, 0, signers.N())
+	for _, id := range signers {
+		msg, _, err := frost.SignRound1(states[id], round1)
+		if err != nil {
+			return nil, fmt.Errorf("frostclient: party %d: %w", id, err)
+		}
+		round2 = append(round2, msg)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// every signer computes the same signature, so one of them is enough
+	sig, _, err := frost.SignRound2(states[signers[0]], round2)
+	if err != nil {
+		return nil, fmt.Errorf("frostclient: %w", err)
+	}
+	return sig, nil
+}
+
+// Verify returns true if sig is a valid signature on message under the group key pub.
+func Verify(pub *eddsa.PublicKey, message []byte, sig *eddsa.Signature) bool {
+	return pub.Verify(message, sig)
+}
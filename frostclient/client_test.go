@@ -0,0 +1,52 @@
+package frostclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateGroupAndSign(t *testing.T) {
+	group, err := CreateGroup(Config{N: 5, T: 2})
+	require.NoError(t, err)
+	assert.Len(t, group.Shares, 5)
+	assert.Equal(t, party.IDSlice{1, 2, 3}, group.Signers)
+
+	message := []byte("frostclient")
+	group.Signers = party.IDSlice{5, 2, 4}
+	sig, err := Sign(context.Background(), group, message)
+	require.NoError(t, err)
+
+	assert.True(t, Verify(group.Public.GroupKey, message, sig))
+	assert.True(t, ed25519.Verify(group.Public.GroupKey.ToEd25519(), message, sig.ToEd25519()))
+	assert.False(t, Verify(group.Public.GroupKey, []byte("other"), sig))
+
+	// zeroizing the states of the signers leaves their shares intact
+	sig, err = Sign(context.Background(), group, message)
+	require.NoError(t, err)
+	assert.True(t, Verify(group.Public.GroupKey, message, sig))
+}
+
+func TestSign_NotEnoughSigners(t *testing.T) {
+	group, err := CreateGroup(Config{N: 3, T: 1})
+	require.NoError(t, err)
+
+	group.Signers = party.IDSlice{1}
+	_, err = Sign(context.Background(), group, []byte("m"))
+	assert.Error(t, err)
+}
+
+func TestSign_Canceled(t *testing.T) {
+	group, err := CreateGroup(Config{N: 3, T: 1})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Sign(ctx, group, []byte("m"))
+	assert.True(t, errors.Is(err, context.Canceled))
+}
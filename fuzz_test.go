@@ -0,0 +1,75 @@
+package frost
+
+import (
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/require"
+)
+
+// FuzzMessageUnmarshal checks that decoding arbitrary bytes as a message in any
+// encoding returns an error instead of panicking, and that decoded messages
+// can be encoded again.
+func FuzzMessageUnmarshal(f *testing.F) {
+	for _, msg := range testMessages(f) {
+		for _, encode := range []func() ([]byte, error){msg.MarshalJSON, msg.MarshalBinary, msg.MarshalCBOR, msg.ToProto} {
+			data, err := encode()
+			require.NoError(f, err)
+			f.Add(data)
+		}
+	}
+	f.Add([]byte(`{"header":{"type":"","from":"AAE=","to":"AAA="}}`))
+	f.Add([]byte(`{"header":{"type":"Aw==","from":"AAE=","to":"AAA="},"sign1":{}}`))
+	f.Add([]byte(`{"header":{"type":"Aw==","from":"AAE=","to":"AAA="}}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var msg Message
+		if msg.UnmarshalJSON(data) == nil {
+			_, err := msg.MarshalJSON()
+			require.NoError(t, err)
+		}
+		msg = Message{}
+		if msg.UnmarshalBinary(data) == nil {
+			encoded, err := msg.MarshalBinary()
+			require.NoError(t, err)
+			var again Message
+			require.NoError(t, again.UnmarshalBinary(encoded))
+		}
+		msg = Message{}
+		if msg.UnmarshalCBOR(data) == nil {
+			_, _ = msg.MarshalCBOR()
+		}
+		msg = Message{}
+		if msg.FromProto(data) == nil {
+			encoded, err := msg.ToProto()
+			require.NoError(t, err)
+			var again Message
+			require.NoError(t, again.FromProto(encoded))
+		}
+	})
+}
+
+// FuzzSignerStateUnmarshal checks that decoding arbitrary bytes as a signer
+// state returns an error instead of panicking.
+func FuzzSignerStateUnmarshal(f *testing.F) {
+	public, secrets := generateKeys(f, 3, 1)
+	_, state, err := SignInit(party.IDSlice{1, 2}, secrets[1], public, []byte("fuzz"))
+	require.NoError(f, err)
+	for _, encode := range []func() ([]byte, error){state.MarshalJSON, state.MarshalCBOR} {
+		data, err := encode()
+		require.NoError(f, err)
+		f.Add(data)
+	}
+	f.Add([]byte(`{"self_id":"AAE=","signers":{"":{}}}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var state SignerState
+		if state.UnmarshalJSON(data) == nil {
+			_, _ = state.MarshalJSON()
+		}
+		state = SignerState{}
+		if state.UnmarshalCBOR(data) == nil {
+			_, _ = state.MarshalCBOR()
+		}
+	})
+}
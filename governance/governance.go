@@ -0,0 +1,362 @@
+// Package governance authorizes administrative changes to a group with the group's own key.
+//
+// Operations such as changing the endpoints of the signers, resharing to a new
+// threshold or retiring the key are described by a Change. The change is
+// signed like any other message, and a daemon only acts on it once the
+// resulting Approval verifies against the group key and follows the last
+// applied change in sequence, so every change needs a quorum of the group.
+package governance
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bartke/frost/manifest"
+	"github.com/bartke/frost/party"
+)
+
+const bodyHeader = "frost-change-v1\n"
+
+// Kind is the type of a change.
+type Kind string
+
+const (
+	// KindSetPeers sets the endpoints of signers. Params maps party IDs to
+	// URLs, an empty URL removes the endpoint.
+	KindSetPeers Kind = "set-peers"
+	// KindReshare redistributes the key. Params holds the new "threshold", and
+	// optionally the comma separated "parties" of the new group.
+	KindReshare Kind = "reshare"
+	// KindRetire retires the key. No change is accepted afterwards.
+	KindRetire Kind = "retire"
+)
+
+var (
+	// ErrSequence is returned if a change does not directly follow the last applied one.
+	ErrSequence = errors.New("governance: change is out of sequence")
+	// ErrExpired is returned if a change is approved after its expiry.
+	ErrExpired = errors.New("governance: change has expired")
+	// ErrRetired is returned for any change after the key was retired.
+	ErrRetired = errors.New("governance: the key is retired")
+)
+
+// Change is a canonical description of an administrative operation on a group.
+type Change struct {
+	// Group is the fingerprint of the group key, see manifest.Fingerprint.
+	Group string
+	// Sequence numbers the changes of a group, starting at 1.
+	Sequence uint64
+	Kind     Kind
+	Params   map[string]string
+	// Expires is the time after which the change can no longer be applied.
+	Expires time.Time
+}
+
+// Validate checks that the parameters of c fit its kind.
+func (c *Change) Validate() error {
+	if c.Sequence == 0 {
+		return errors.New("governance: sequence must start at 1")
+	}
+	for k, v := range c.Params {
+		if k == "" || strings.ContainsAny(k, " \n") || strings.Contains(v, "\n") {
+			return fmt.Errorf("governance: invalid parameter %q", k)
+		}
+	}
+	switch c.Kind {
+	case KindSetPeers:
+		if len(c.Params) == 0 {
+			return errors.New("governance: set-peers without peers")
+		}
+		for k := range c.Params {
+			if _, err := party.FromString(k); err != nil {
+				return fmt.Errorf("governance: invalid peer ID %q", k)
+			}
+		}
+	case KindReshare:
+		if _, err := strconv.ParseUint(c.Params["threshold"], 10, 16); err != nil {
+			return errors.New("governance: reshare requires a threshold")
+		}
+		if parties, ok := c.Params["parties"]; ok {
+			for _, s := range strings.Split(parties, ",") {
+				if _, err := party.FromString(s); err != nil {
+					return fmt.Errorf("governance: invalid party ID %q", s)
+				}
+			}
+		}
+	case KindRetire:
+		if len(c.Params) != 0 {
+			return errors.New("governance: retire takes no parameters")
+		}
+	default:
+		return fmt.Errorf("governance: unknown kind %q", c.Kind)
+	}
+	return nil
+}
+
+// Body returns the canonical bytes that are signed, one "key value" field per
+// line, with the parameters sorted by key.
+func (c *Change) Body() []byte {
+	var b bytes.Buffer
+	b.WriteString(bodyHeader)
+	fmt.Fprintf(&b, "group %s\n", c.Group)
+	fmt.Fprintf(&b, "sequence %d\n", c.Sequence)
+	fmt.Fprintf(&b, "kind %s\n", c.Kind)
+	fmt.Fprintf(&b, "expires %d\n", c.Expires.Unix())
+
+	keys := make([]string, 0, len(c.Params))
+	for k := range c.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "param %s %s\n", k, c.Params[k])
+	}
+	return b.Bytes()
+}
+
+// ParseBody reconstructs a change from its canonical body. The body must be canonical,
+// so that only one encoding of a change can carry a valid signature.
+func ParseBody(body []byte) (*Change, error) {
+	text := string(body)
+	if !strings.HasPrefix(text, bodyHeader) || !strings.HasSuffix(text, "\n") {
+		return nil, errors.New("governance: not a change body")
+	}
+	lines := strings.Split(strings.TrimSuffix(text[len(bodyHeader):], "\n"), "\n")
+	if len(lines) < 4 {
+		return nil, errors.New("governance: truncated change body")
+	}
+
+	field := func(i int, name string) (string, error) {
+		value := strings.TrimPrefix(lines[i], name+" ")
+		if value == lines[i] {
+			return "", fmt.Errorf("governance: expected %s in line %d", name, i+1)
+		}
+		return value, nil
+	}
+	c := &Change{Params: make(map[string]string)}
+	var err error
+	if c.Group, err = field(0, "group"); err != nil {
+		return nil, err
+	}
+	seq, err := field(1, "sequence")
+	if err != nil {
+		return nil, err
+	}
+	if c.Sequence, err = strconv.ParseUint(seq, 10, 64); err != nil {
+		return nil, errors.New("governance: malformed sequence")
+	}
+	kind, err := field(2, "kind")
+	if err != nil {
+		return nil, err
+	}
+	c.Kind = Kind(kind)
+	expires, err := field(3, "expires")
+	if err != nil {
+		return nil, err
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return nil, errors.New("governance: malformed expiry")
+	}
+	c.Expires = time.Unix(unix, 0).UTC()
+
+	for i := 4; i < len(lines); i++ {
+		param, err := field(i, "param")
+		if err != nil {
+			return nil, err
+		}
+		kv := strings.SplitN(param, " ", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("governance: malformed parameter %q", param)
+		}
+		c.Params[kv[0]] = kv[1]
+	}
+
+	if !bytes.Equal(c.Body(), body) {
+		return nil, errors.New("governance: change body is not canonical")
+	}
+	return c, nil
+}
+
+// Approval is a change together with the group's signature over its body.
+type Approval struct {
+	Body      []byte
+	Signature []byte
+}
+
+type approvalJSON struct {
+	Change    string `json:"change"`
+	Signature string `json:"signature"`
+}
+
+// MarshalJSON encodes the body as a string and the signature in hex.
+func (a *Approval) MarshalJSON() ([]byte, error) {
+	return json.Marshal(approvalJSON{Change: string(a.Body), Signature: hex.EncodeToString(a.Signature)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *Approval) UnmarshalJSON(data []byte) error {
+	var v approvalJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(v.Signature)
+	if err != nil {
+		return fmt.Errorf("governance: invalid signature encoding: %w", err)
+	}
+	a.Body, a.Signature = []byte(v.Change), sig
+	return nil
+}
+
+// Verify checks that the approval was signed by pub, is meant for the group of
+// pub, and has not expired at now. It returns the approved change.
+func (a *Approval) Verify(pub ed25519.PublicKey, now time.Time) (*Change, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, errors.New("governance: invalid group key")
+	}
+	if len(a.Signature) != ed25519.SignatureSize || !ed25519.Verify(pub, a.Body, a.Signature) {
+		return nil, errors.New("governance: invalid signature")
+	}
+	c, err := ParseBody(a.Body)
+	if err != nil {
+		return nil, err
+	}
+	if c.Group != manifest.Fingerprint(pub) {
+		return nil, errors.New("governance: change is for a different group")
+	}
+	if !now.Before(c.Expires) {
+		return nil, ErrExpired
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Ledger records the approvals applied to a group, in sequence.
+// Entries are appended to a file with one JSON approval per line, and synced
+// before Record returns, so that a change cannot be replayed after a restart.
+type Ledger struct {
+	pub ed25519.PublicKey
+
+	mu      sync.Mutex
+	changes []*Change
+	file    *os.File
+}
+
+// OpenLedger opens or creates the ledger of the group pub stored at path.
+// If path is empty, the ledger is only kept in memory.
+func OpenLedger(pub ed25519.PublicKey, path string) (*Ledger, error) {
+	l := &Ledger{pub: pub}
+	if path == "" {
+		return l, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("governance: %w", err)
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var a Approval
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("governance: corrupt ledger entry: %w", err)
+		}
+		// expired changes were valid when they were recorded
+		c, err := l.check(&a, time.Time{})
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("governance: corrupt ledger: %w", err)
+		}
+		l.changes = append(l.changes, c)
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("governance: %w", err)
+	}
+	l.file = f
+	return l, nil
+}
+
+// check verifies a against the ledger. A zero now never expires a change.
+func (l *Ledger) check(a *Approval, now time.Time) (*Change, error) {
+	c, err := a.Verify(l.pub, now)
+	if err != nil {
+		return nil, err
+	}
+	if n := len(l.changes); n > 0 && l.changes[n-1].Kind == KindRetire {
+		return nil, ErrRetired
+	}
+	if c.Sequence != uint64(len(l.changes))+1 {
+		return nil, fmt.Errorf("%w: got %d, expected %d", ErrSequence, c.Sequence, len(l.changes)+1)
+	}
+	return c, nil
+}
+
+// Verify checks that a can be applied next, without recording it.
+func (l *Ledger) Verify(a *Approval, now time.Time) (*Change, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.check(a, now)
+}
+
+// Record verifies a and appends it to the ledger.
+// The caller should only act on the change once Record succeeded.
+func (l *Ledger) Record(a *Approval, now time.Time) (*Change, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, err := l.check(a, now)
+	if err != nil {
+		return nil, err
+	}
+	if l.file != nil {
+		line, err := json.Marshal(a)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := l.file.Write(append(line, '\n')); err != nil {
+			return nil, fmt.Errorf("governance: %w", err)
+		}
+		if err := l.file.Sync(); err != nil {
+			return nil, fmt.Errorf("governance: %w", err)
+		}
+	}
+	l.changes = append(l.changes, c)
+	return c, nil
+}
+
+// Changes returns the recorded changes in sequence.
+func (l *Ledger) Changes() []*Change {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]*Change(nil), l.changes...)
+}
+
+// Next returns the sequence number of the next change.
+func (l *Ledger) Next() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return uint64(len(l.changes)) + 1
+}
+
+// Close closes the underlying file, if any.
+func (l *Ledger) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,125 @@
+package governance
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost/frostclient"
+	"github.com/bartke/frost/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func approve(t *testing.T, group *frostclient.Group, c *Change) *Approval {
+	sig, err := frostclient.Sign(context.Background(), group, c.Body())
+	require.NoError(t, err)
+	return &Approval{Body: c.Body(), Signature: sig.ToEd25519()}
+}
+
+func TestChange_Body(t *testing.T) {
+	c := &Change{
+		Group:    "00112233445566778899aabbccddeeff",
+		Sequence: 3,
+		Kind:     KindSetPeers,
+		Params:   map[string]string{"2": "https://b.example", "1": "https://a.example"},
+		Expires:  time.Unix(1700000000, 0).UTC(),
+	}
+	assert.Equal(t, "frost-change-v1\n"+
+		"group 00112233445566778899aabbccddeeff\n"+
+		"sequence 3\n"+
+		"kind set-peers\n"+
+		"expires 1700000000\n"+
+		"param 1 https://a.example\n"+
+		"param 2 https://b.example\n", string(c.Body()))
+
+	parsed, err := ParseBody(c.Body())
+	require.NoError(t, err)
+	assert.Equal(t, c, parsed)
+
+	_, err = ParseBody([]byte("frost-change-v1\ngroup x\nsequence 03\nkind retire\nexpires 1\n"))
+	assert.Error(t, err, "non-canonical bodies are rejected")
+	_, err = ParseBody([]byte("frost-change-v1\ngroup x\nsequence 1\nkind retire\n"))
+	assert.Error(t, err)
+}
+
+func TestChange_Validate(t *testing.T) {
+	for _, c := range []*Change{
+		{Sequence: 0, Kind: KindRetire},
+		{Sequence: 1, Kind: "rename"},
+		{Sequence: 1, Kind: KindSetPeers},
+		{Sequence: 1, Kind: KindSetPeers, Params: map[string]string{"x": "https://a.example"}},
+		{Sequence: 1, Kind: KindSetPeers, Params: map[string]string{"1": "a\nparam 2 b"}},
+		{Sequence: 1, Kind: KindReshare, Params: map[string]string{"parties": "1,2"}},
+		{Sequence: 1, Kind: KindRetire, Params: map[string]string{"now": "yes"}},
+	} {
+		assert.Error(t, c.Validate(), "%+v", c)
+	}
+	assert.NoError(t, (&Change{Sequence: 1, Kind: KindReshare, Params: map[string]string{"threshold": "2", "parties": "1,2,4"}}).Validate())
+}
+
+func TestLedger(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	pub := group.Public.GroupKey.ToEd25519()
+	fp := manifest.Fingerprint(pub)
+	now := time.Now()
+	expires := now.Add(time.Hour)
+
+	path := filepath.Join(t.TempDir(), "ledger.log")
+	l, err := OpenLedger(pub, path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), l.Next())
+
+	first := approve(t, group, &Change{Group: fp, Sequence: 1, Kind: KindSetPeers,
+		Params: map[string]string{"2": "https://b.example"}, Expires: expires})
+
+	// verifying does not record
+	_, err = l.Verify(first, now)
+	require.NoError(t, err)
+	assert.Empty(t, l.Changes())
+
+	c, err := l.Record(first, now)
+	require.NoError(t, err)
+	assert.Equal(t, "https://b.example", c.Params["2"])
+
+	_, err = l.Record(first, now)
+	assert.True(t, errors.Is(err, ErrSequence), "approvals cannot be replayed")
+
+	skipped := approve(t, group, &Change{Group: fp, Sequence: 3, Kind: KindRetire, Expires: expires})
+	_, err = l.Record(skipped, now)
+	assert.True(t, errors.Is(err, ErrSequence))
+
+	expired := approve(t, group, &Change{Group: fp, Sequence: 2, Kind: KindRetire, Expires: now.Add(-time.Second)})
+	_, err = l.Record(expired, now)
+	assert.True(t, errors.Is(err, ErrExpired))
+
+	other, otherKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	forged := &Change{Group: fp, Sequence: 2, Kind: KindRetire, Expires: expires}
+	_, err = l.Record(&Approval{Body: forged.Body(), Signature: ed25519.Sign(otherKey, forged.Body())}, now)
+	assert.Error(t, err, "changes must be signed by the group")
+	foreign := &Change{Group: manifest.Fingerprint(other), Sequence: 2, Kind: KindRetire, Expires: expires}
+	_, err = (&Approval{Body: foreign.Body(), Signature: ed25519.Sign(otherKey, foreign.Body())}).Verify(pub, now)
+	assert.Error(t, err)
+
+	retire := approve(t, group, &Change{Group: fp, Sequence: 2, Kind: KindRetire, Expires: expires})
+	_, err = l.Record(retire, now)
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+
+	// the ledger survives a restart
+	l, err = OpenLedger(pub, path)
+	require.NoError(t, err)
+	defer l.Close()
+	require.Len(t, l.Changes(), 2)
+	assert.Equal(t, KindRetire, l.Changes()[1].Kind)
+
+	after := approve(t, group, &Change{Group: fp, Sequence: 3, Kind: KindSetPeers,
+		Params: map[string]string{"2": "https://c.example"}, Expires: expires})
+	_, err = l.Record(after, now)
+	assert.True(t, errors.Is(err, ErrRetired))
+}
@@ -0,0 +1,236 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/policy"
+	"google.golang.org/grpc"
+)
+
+// Client coordinates sessions between parties that serve the Frost service.
+// It holds no secret, and only relays the messages of the parties.
+type Client struct {
+	// Parties are the connections to the Server of every party.
+	Parties map[party.ID]grpc.ClientConnInterface
+
+	// Policy, if set, decides whether Sign starts a session. The group key
+	// of its requests is nil, as the client does not know it.
+	Policy policy.Policy
+}
+
+// NewClient returns a Client for the given connections.
+func NewClient(parties map[party.ID]grpc.ClientConnInterface) *Client {
+	return &Client{Parties: parties}
+}
+
+// Keygen runs a key generation with threshold t between all parties, whose
+// IDs must be 1..N, and returns the group key they agreed on.
+func (c *Client) Keygen(ctx context.Context, t party.Size) (*eddsa.PublicKey, error) {
+	return c.keygen(ctx, &KeygenStart{Threshold: t})
+}
+
+// KeygenWithEcho is Keygen with the echo round, in which the parties compare
+// the KeyGen1 messages the client relayed to them before sending their
+// shares, so that a client or network showing different commitments to
+// different parties makes the key generation fail instead of splitting the
+// group.
+func (c *Client) KeygenWithEcho(ctx context.Context, t party.Size) (*eddsa.PublicKey, error) {
+	return c.keygen(ctx, &KeygenStart{Threshold: t, Echo: true})
+}
+
+// keygen runs the key generation started by start among all parties.
+func (c *Client) keygen(ctx context.Context, start *KeygenStart) (*eddsa.PublicKey, error) {
+	n := party.Size(len(c.Parties))
+	start.N = n
+	for id := party.ID(1); id <= n; id++ {
+		if _, ok := c.Parties[id]; !ok {
+			return nil, fmt.Errorf("grpcserver: no connection to party %d", id)
+		}
+	}
+
+	results, err := c.relay(ctx, &serviceDesc.Streams[0], party.NewIDSlice(partyIDs(c.Parties)),
+		&KeygenRequest{Start: start},
+		func(msg *frost.Message) wireMessage { return &KeygenRequest{Message: msg} },
+		func() response { return &KeygenResponse{} })
+	if err != nil {
+		return nil, err
+	}
+
+	var groupKey *eddsa.PublicKey
+	for id, r := range results {
+		key := r.(*KeygenResponse).GroupKey
+		if groupKey != nil && !groupKey.Equal(key) {
+			return nil, fmt.Errorf("grpcserver: party %d computed another group key", id)
+		}
+		groupKey = key
+	}
+	return groupKey, nil
+}
+
+// Sign runs the signing of message by signerIDs, and returns the signature
+// once every signer computed it.
+func (c *Client) Sign(ctx context.Context, signerIDs party.IDSlice, message []byte) (*eddsa.Signature, error) {
+	return c.SignWithSession(ctx, frost.SessionID{}, signerIDs, message)
+}
+
+// SignWithSession is Sign in the session with the given ID, e.g. one derived
+// with frost.DeriveSessionID.
+func (c *Client) SignWithSession(ctx context.Context, session frost.SessionID, signerIDs party.IDSlice, message []byte) (*eddsa.Signature, error) {
+	signerIDs = party.NewIDSlice(signerIDs)
+	for _, id := range signerIDs {
+		if _, ok := c.Parties[id]; !ok {
+			return nil, fmt.Errorf("grpcserver: no connection to party %d", id)
+		}
+	}
+	if c.Policy != nil {
+		if err := c.Policy.Allow(ctx, &policy.Request{Signers: signerIDs, Message: message}); err != nil {
+			return nil, fmt.Errorf("grpcserver: %w", err)
+		}
+	}
+
+	results, err := c.relay(ctx, &serviceDesc.Streams[1], signerIDs,
+		&SignRequest{Start: &SignStart{SignerIDs: signerIDs, Message: message, SessionID: session}},
+		func(msg *frost.Message) wireMessage { return &SignRequest{Message: msg} },
+		func() response { return &SignResponse{} })
+	if err != nil {
+		return nil, err
+	}
+
+	var sig *eddsa.Signature
+	for id, r := range results {
+		s := r.(*SignResponse).Signature
+		if sig != nil && !sig.Equal(s) {
+			return nil, fmt.Errorf("grpcserver: party %d computed another signature", id)
+		}
+		sig = s
+	}
+	return sig, nil
+}
+
+// Refresh runs a refresh of the shares of groupKey between all parties, which
+// must be the parties of the group, and returns once every party stored its
+// new share. The group key stays the same.
+func (c *Client) Refresh(ctx context.Context, groupKey *eddsa.PublicKey) error {
+	ids := party.NewIDSlice(partyIDs(c.Parties))
+	results, err := c.relay(ctx, &serviceDesc.Streams[2], ids,
+		&RefreshRequest{Start: &RefreshStart{PartyIDs: ids, GroupKey: groupKey}},
+		func(msg *frost.Message) wireMessage { return &RefreshRequest{Message: msg} },
+		func() response { return &RefreshResponse{} })
+	if err != nil {
+		return err
+	}
+	for id, r := range results {
+		if !groupKey.Equal(r.(*RefreshResponse).GroupKey) {
+			return fmt.Errorf("grpcserver: party %d computed another group key", id)
+		}
+	}
+	return nil
+}
+
+// response is a KeygenResponse, SignResponse or RefreshResponse.
+type response interface {
+	wireMessage
+	message() *frost.Message
+}
+
+func (r *KeygenResponse) message() *frost.Message  { return r.Message }
+func (r *SignResponse) message() *frost.Message    { return r.Message }
+func (r *RefreshResponse) message() *frost.Message { return r.Message }
+
+// relay opens a stream described by desc to every party of ids and sends start, then
+// forwards the messages received on each stream to their recipients until
+// every party sent a response without a message, which is returned.
+func (c *Client) relay(ctx context.Context, desc *grpc.StreamDesc, ids party.IDSlice, start wireMessage, newRequest func(*frost.Message) wireMessage, newResponse func() response) (map[party.ID]response, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	streams := make(map[party.ID]grpc.ClientStream, len(ids))
+	for _, id := range ids {
+		stream, err := c.Parties[id].NewStream(ctx, desc, "/"+ServiceName+"/"+desc.StreamName, grpc.ForceCodec(codec{}))
+		if err != nil {
+			return nil, fmt.Errorf("grpcserver: party %d: %w", id, err)
+		}
+		if err := stream.SendMsg(start); err != nil {
+			return nil, fmt.Errorf("grpcserver: party %d: %w", id, err)
+		}
+		streams[id] = stream
+	}
+
+	type event struct {
+		from     party.ID
+		response response
+		err      error
+	}
+	events := make(chan event)
+	for id, stream := range streams {
+		go func(id party.ID, stream grpc.ClientStream) {
+			for {
+				r := newResponse()
+				err := stream.RecvMsg(r)
+				select {
+				case events <- event{id, r, err}:
+				case <-ctx.Done():
+					return
+				}
+				if err != nil || r.message() == nil {
+					return
+				}
+			}
+		}(id, stream)
+	}
+
+	results := make(map[party.ID]response, len(ids))
+	for len(results) < len(ids) {
+		var e event
+		select {
+		case e = <-events:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if e.err != nil {
+			return nil, fmt.Errorf("grpcserver: party %d: %w", e.from, e.err)
+		}
+		msg := e.response.message()
+		if msg == nil {
+			results[e.from] = e.response
+			_ = streams[e.from].CloseSend()
+			continue
+		}
+		if msg.From != e.from {
+			return nil, fmt.Errorf("grpcserver: party %d sent a message from party %d", e.from, msg.From)
+		}
+
+		to := party.IDSlice{msg.To}
+		if msg.IsBroadcast() {
+			to = ids
+		}
+		for _, id := range to {
+			if id == e.from {
+				continue
+			}
+			stream, ok := streams[id]
+			if !ok {
+				return nil, fmt.Errorf("grpcserver: party %d sent a message to unknown party %d", e.from, id)
+			}
+			// io.EOF means the party ended its stream, whose status RecvMsg returns
+			if err := stream.SendMsg(newRequest(msg)); err != nil && !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("grpcserver: party %d: %w", id, err)
+			}
+		}
+	}
+	return results, nil
+}
+
+func partyIDs(parties map[party.ID]grpc.ClientConnInterface) []party.ID {
+	ids := make([]party.ID, 0, len(parties))
+	for id := range parties {
+		ids = append(ids, id)
+	}
+	return ids
+}
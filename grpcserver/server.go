@@ -0,0 +1,489 @@
+// Package grpcserver runs the frost protocols over gRPC.
+//
+// Every party runs a Server, which holds its secret share and serves the Frost
+// service of frost.proto. A coordinator, such as Client, opens a Keygen, Sign
+// or Refresh stream to every party, starts the session, and relays the messages
+// each party sends on its stream to the streams of their recipients. The rounds themselves
+// are run by a frost.Machine behind each stream, so a coordinator learns
+// nothing it could not learn from a broadcast channel.
+//
+// The messages are encoded by hand according to frost.proto, with the protocol
+// messages encoded by frost.Message.ToProto according to message.proto, so the
+// package needs no generated code, and peers in other languages can use code
+// generated from both files.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/audit"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/limits"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/policy"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceName is the full name of the Frost service.
+const ServiceName = "frost.v1.Frost"
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*interface{})(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Keygen",
+			Handler:       func(srv interface{}, stream grpc.ServerStream) error { return srv.(*Server).keygen(stream) },
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Sign",
+			Handler:       func(srv interface{}, stream grpc.ServerStream) error { return srv.(*Server).sign(stream) },
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Refresh",
+			Handler:       func(srv interface{}, stream grpc.ServerStream) error { return srv.(*Server).refresh(stream) },
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "frost.proto",
+}
+
+// codec encodes the messages of the service. It is named "proto" since the
+// encoding is that of frost.proto, so peers using generated code interoperate.
+type codec struct{}
+
+func (codec) Name() string { return "proto" }
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(wireMessage)
+	if !ok {
+		return nil, fmt.Errorf("grpcserver: cannot marshal %T", v)
+	}
+	return m.marshal()
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(wireMessage)
+	if !ok {
+		return fmt.Errorf("grpcserver: cannot unmarshal into %T", v)
+	}
+	return m.unmarshal(data)
+}
+
+// ServerOption returns the option that a grpc.Server serving the Frost service must be created with.
+func ServerOption() grpc.ServerOption {
+	return grpc.ForceServerCodec(codec{})
+}
+
+// Server serves the Frost service for one party.
+type Server struct {
+	SelfID party.ID
+
+	// OnKeygen is called with the result of a key generation, e.g. to persist
+	// it, before the group key is sent to the coordinator. If it returns an
+	// error, the key is discarded.
+	OnKeygen func(public *eddsa.Public, secret *eddsa.SecretShare) error
+
+	// OnRefresh is called with the new shares of a refresh, which must replace
+	// the old ones, before the group key is sent to the coordinator. If it
+	// returns an error, the new shares are discarded.
+	OnRefresh func(public *eddsa.Public, secret *eddsa.SecretShare) error
+
+	// LoadSecret, if set, returns the secret share at the start of every sign
+	// and refresh session, e.g. by unwrapping it with a KMS, so that the server
+	// holds no secret share between sessions. It must return a new share every
+	// time, which is zeroized when the session ends.
+	LoadSecret func(ctx context.Context) (*eddsa.SecretShare, error)
+
+	// Policy, if set, decides whether the server takes part in a signing
+	// session, before its secret share is loaded. A denied session fails with
+	// codes.PermissionDenied.
+	Policy policy.Policy
+
+	// Audit, if set, records every key generation, signing session and refresh
+	// the server runs. A session that succeeded fails if it cannot be recorded.
+	Audit *audit.Log
+
+	// Timeout bounds the duration of a session, it defaults to one minute.
+	Timeout time.Duration
+
+	// Limiter, if set, bounds the sessions the server runs concurrently, and
+	// the size and rate of the messages it accepts. A session refused or
+	// ended by a limit fails with codes.ResourceExhausted.
+	Limiter *limits.Limiter
+
+	mu     sync.Mutex
+	public *eddsa.Public
+	secret *eddsa.SecretShare
+}
+
+// NewServer returns a Server for selfID. public and secret may be nil until a key generation ran.
+func NewServer(selfID party.ID, public *eddsa.Public, secret *eddsa.SecretShare) *Server {
+	return &Server{SelfID: selfID, public: public, secret: secret}
+}
+
+// Register registers the Frost service of s with g, which must be created with ServerOption.
+func (s *Server) Register(g *grpc.Server) {
+	g.RegisterService(&serviceDesc, s)
+}
+
+// Key returns the key the server signs with. The secret share is nil if the
+// server has none yet, or if it is loaded with LoadSecret.
+func (s *Server) Key() (*eddsa.Public, *eddsa.SecretShare) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.public, s.secret
+}
+
+// sessionKey returns the key to run a session with. A share returned by
+// LoadSecret must be zeroized by the caller.
+func (s *Server) sessionKey(ctx context.Context) (*eddsa.Public, *eddsa.SecretShare, error) {
+	public, secret := s.Key()
+	if public == nil {
+		return nil, nil, status.Error(codes.FailedPrecondition, "grpcserver: no key")
+	}
+	if s.LoadSecret != nil {
+		loaded, err := s.LoadSecret(ctx)
+		if err != nil {
+			return nil, nil, status.Errorf(codes.Internal, "grpcserver: loading the secret share: %v", err)
+		}
+		if loaded.ID != s.SelfID {
+			loaded.Zeroize()
+			return nil, nil, status.Errorf(codes.Internal, "grpcserver: loaded the secret share of party %d", loaded.ID)
+		}
+		return public, loaded, nil
+	}
+	if secret == nil {
+		return nil, nil, status.Error(codes.FailedPrecondition, "grpcserver: no key")
+	}
+	return public, secret, nil
+}
+
+// setKey stores the key of a key generation or refresh. With LoadSecret, the
+// secret share is only stored by OnKeygen or OnRefresh, and zeroized here.
+func (s *Server) setKey(public *eddsa.Public, secret *eddsa.SecretShare) {
+	if s.LoadSecret != nil {
+		secret.Zeroize()
+		secret = nil
+	}
+	s.mu.Lock()
+	s.public, s.secret = public, secret
+	s.mu.Unlock()
+}
+
+// acquire reserves a slot for a session with the Limiter.
+func (s *Server) acquire() (func(), error) {
+	release, err := s.Limiter.Acquire()
+	if err != nil {
+		return nil, status.Errorf(codes.ResourceExhausted, "grpcserver: %v", err)
+	}
+	return release, nil
+}
+
+func (s *Server) timeout() time.Duration {
+	if s.Timeout > 0 {
+		return s.Timeout
+	}
+	return time.Minute
+}
+
+func (s *Server) keygen(stream grpc.ServerStream) error {
+	var req KeygenRequest
+	if err := stream.RecvMsg(&req); err != nil {
+		return err
+	}
+	release, err := s.acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+	start := req.Start
+	if start == nil {
+		return status.Error(codes.InvalidArgument, "grpcserver: expected a KeygenStart")
+	}
+	if s.SelfID == 0 || s.SelfID > start.N || start.Threshold >= start.N {
+		return status.Errorf(codes.InvalidArgument, "grpcserver: party %d cannot join a group of N=%d T=%d", s.SelfID, start.N, start.Threshold)
+	}
+
+	m := frost.NewKeygenMachine(s.SelfID, start.N, start.Threshold)
+	if start.Echo {
+		m = frost.NewKeygenMachineWithEcho(s.SelfID, start.N, start.Threshold)
+	}
+	partyIDs := make(party.IDSlice, 0, start.N)
+	for id := party.ID(1); id <= start.N; id++ {
+		partyIDs = append(partyIDs, id)
+	}
+	result, err := s.run(stream, m, partyIDs,
+		func() (*frost.Message, error) {
+			var req KeygenRequest
+			err := stream.RecvMsg(&req)
+			return req.Message, err
+		},
+		func(msg *frost.Message) error {
+			return stream.SendMsg(&KeygenResponse{Message: msg})
+		})
+	if err != nil {
+		_ = s.record(audit.KeygenEvent(s.SelfID, start.N, start.Threshold, nil, sessionErr(m, err)))
+		return err
+	}
+	if err := s.record(audit.KeygenEvent(s.SelfID, start.N, start.Threshold, result.Public, nil)); err != nil {
+		return err
+	}
+
+	if s.OnKeygen != nil {
+		if err := s.OnKeygen(result.Public, result.SecretShare); err != nil {
+			return status.Errorf(codes.Internal, "grpcserver: %v", err)
+		}
+	}
+	s.setKey(result.Public, result.SecretShare)
+	return stream.SendMsg(&KeygenResponse{GroupKey: result.Public.GroupKey})
+}
+
+func (s *Server) sign(stream grpc.ServerStream) error {
+	var req SignRequest
+	if err := stream.RecvMsg(&req); err != nil {
+		return err
+	}
+	release, err := s.acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+	start := req.Start
+	if start == nil {
+		return status.Error(codes.InvalidArgument, "grpcserver: expected a SignStart")
+	}
+	signerIDs := party.NewIDSlice(start.SignerIDs)
+	if !signerIDs.Contains(s.SelfID) {
+		return status.Errorf(codes.InvalidArgument, "grpcserver: party %d is not a signer", s.SelfID)
+	}
+	if err := s.allow(stream.Context(), signerIDs, start.Message); err != nil {
+		return err
+	}
+	public, secret, err := s.sessionKey(stream.Context())
+	if err != nil {
+		return err
+	}
+	if s.LoadSecret != nil {
+		defer secret.Zeroize()
+	}
+
+	m := frost.NewSignMachineWithSession(start.SessionID, signerIDs, secret, public, start.Message)
+	result, err := s.run(stream, m, signerIDs,
+		func() (*frost.Message, error) {
+			var req SignRequest
+			err := stream.RecvMsg(&req)
+			return req.Message, err
+		},
+		func(msg *frost.Message) error {
+			return stream.SendMsg(&SignResponse{Message: msg})
+		})
+	if err != nil {
+		_ = s.record(audit.SignEvent(s.SelfID, signerIDs, public.GroupKey, start.Message, false, nil, sessionErr(m, err)))
+		return err
+	}
+	if err := s.record(audit.SignEvent(s.SelfID, signerIDs, public.GroupKey, start.Message, false, result.Signature, nil)); err != nil {
+		return err
+	}
+	return stream.SendMsg(&SignResponse{Signature: result.Signature})
+}
+
+func (s *Server) refresh(stream grpc.ServerStream) error {
+	var req RefreshRequest
+	if err := stream.RecvMsg(&req); err != nil {
+		return err
+	}
+	release, err := s.acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+	start := req.Start
+	if start == nil {
+		return status.Error(codes.InvalidArgument, "grpcserver: expected a RefreshStart")
+	}
+	public, secret, err := s.sessionKey(stream.Context())
+	if err != nil {
+		return err
+	}
+	if s.LoadSecret != nil {
+		defer secret.Zeroize()
+	}
+	if !public.GroupKey.Equal(start.GroupKey) {
+		return status.Error(codes.FailedPrecondition, "grpcserver: refresh of another group key")
+	}
+	if !party.NewIDSlice(start.PartyIDs).Equal(public.PartyIDs) {
+		return status.Errorf(codes.InvalidArgument, "grpcserver: parties %v are not the parties %v of the group", start.PartyIDs, public.PartyIDs)
+	}
+
+	m := frost.NewRefreshMachine(secret, public)
+	result, err := s.run(stream, m, public.PartyIDs,
+		func() (*frost.Message, error) {
+			var req RefreshRequest
+			err := stream.RecvMsg(&req)
+			return req.Message, err
+		},
+		func(msg *frost.Message) error {
+			return stream.SendMsg(&RefreshResponse{Message: msg})
+		})
+	if err != nil {
+		_ = s.record(audit.RefreshEvent(s.SelfID, public, sessionErr(m, err)))
+		return err
+	}
+	if err := s.record(audit.RefreshEvent(s.SelfID, public, nil)); err != nil {
+		return err
+	}
+
+	if s.OnRefresh != nil {
+		if err := s.OnRefresh(result.Public, result.SecretShare); err != nil {
+			return status.Errorf(codes.Internal, "grpcserver: %v", err)
+		}
+	}
+	s.setKey(result.Public, result.SecretShare)
+	return stream.SendMsg(&RefreshResponse{GroupKey: result.Public.GroupKey})
+}
+
+// allow asks the policy, if any, whether the signers may sign message, and
+// records a denial.
+func (s *Server) allow(ctx context.Context, signerIDs party.IDSlice, message []byte) error {
+	public, _ := s.Key()
+	if s.Policy == nil || public == nil {
+		return nil
+	}
+	req := &policy.Request{GroupKey: public.GroupKey, Party: s.SelfID, Signers: signerIDs, Message: message}
+	err := s.Policy.Allow(ctx, req)
+	if err == nil {
+		return nil
+	}
+	_ = s.record(audit.SignEvent(s.SelfID, signerIDs, public.GroupKey, message, false, nil, err))
+	switch {
+	case errors.Is(err, policy.ErrDenied):
+		return status.Errorf(codes.PermissionDenied, "grpcserver: %v", err)
+	case ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	default:
+		return status.Errorf(codes.Internal, "grpcserver: %v", err)
+	}
+}
+
+// record appends the event to the audit log, if any.
+func (s *Server) record(event audit.Event) error {
+	if s.Audit == nil {
+		return nil
+	}
+	if _, err := s.Audit.Append(event); err != nil {
+		return status.Errorf(codes.Internal, "grpcserver: %v", err)
+	}
+	return nil
+}
+
+// sessionErr returns the error m failed with, which names the culprit of an
+// abort, or err if m did not fail.
+func sessionErr(m *frost.Machine, err error) error {
+	if m.Status() != frost.StatusFailed {
+		return err
+	}
+	_, err = m.Result()
+	return err
+}
+
+// run advances m with the messages of the other parties of partyIDs received
+// on the stream, and sends its output, until m ends.
+func (s *Server) run(stream grpc.ServerStream, m *frost.Machine, partyIDs party.IDSlice, recv func() (*frost.Message, error), send func(*frost.Message) error) (*frost.SessionResult, error) {
+	ctx, cancel := context.WithTimeout(stream.Context(), s.timeout())
+	from := peerName(stream.Context())
+	defer cancel()
+
+	type received struct {
+		msg *frost.Message
+		err error
+	}
+	in := make(chan received)
+	go func() {
+		for {
+			msg, err := recv()
+			select {
+			case in <- received{msg, err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var inbox []*frost.Message
+	for {
+		out, st := m.Advance(time.Now(), inbox)
+		inbox = inbox[:0]
+		for _, msg := range out {
+			if err := send(msg); err != nil {
+				return nil, err
+			}
+		}
+		if st != frost.StatusWaiting {
+			result, err := m.Result()
+			if err != nil {
+				return nil, status.Errorf(codes.Aborted, "grpcserver: %v", err)
+			}
+			return result, nil
+		}
+
+		select {
+		case r := <-in:
+			switch {
+			case r.err != nil:
+				return nil, r.err
+			case r.msg == nil:
+				return nil, status.Error(codes.InvalidArgument, "grpcserver: expected a message")
+			}
+			if r.msg.From == s.SelfID || !partyIDs.Contains(r.msg.From) {
+				return nil, status.Errorf(codes.InvalidArgument, "grpcserver: message from party %d, which is not another party of the session", r.msg.From)
+			}
+			if err := s.Limiter.Message(from, r.msg); err != nil {
+				return nil, status.Errorf(codes.ResourceExhausted, "grpcserver: %v", err)
+			}
+			inbox = append(inbox, r.msg)
+		case <-ctx.Done():
+			round, _ := m.Round()
+			return nil, status.Errorf(status.FromContextError(ctx.Err()).Code(), "grpcserver: waiting for %s messages: %v", round, ctx.Err())
+		}
+	}
+}
+
+// peerName identifies the peer of ctx for the rate limits: the subject of its
+// verified TLS client certificate, or else the host it connected from, so
+// that new connections of the same peer share its rate.
+func peerName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 && len(info.State.VerifiedChains[0]) > 0 {
+		return "tls:" + info.State.VerifiedChains[0][0].Subject.String()
+	}
+	if p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,418 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/audit"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/limits"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/policy"
+	"github.com/bartke/frost/polynomial"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// startParties serves a Server for every party 1..n over in-memory connections.
+func startParties(t *testing.T, n party.Size) (map[party.ID]*Server, *Client) {
+	servers := make(map[party.ID]*Server, n)
+	conns := make(map[party.ID]grpc.ClientConnInterface, n)
+	for id := party.ID(1); id <= n; id++ {
+		lis := bufconn.Listen(1 << 16)
+		g := grpc.NewServer(ServerOption())
+		servers[id] = NewServer(id, nil, nil)
+		servers[id].Register(g)
+		go func() { _ = g.Serve(lis) }()
+		t.Cleanup(g.Stop)
+
+		conn, err := grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		conns[id] = conn
+	}
+	return servers, NewClient(conns)
+}
+
+func TestKeygenAndSign(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	servers, client := startParties(t, 4)
+
+	var stored atomic.Int32
+	for _, s := range servers {
+		s.OnKeygen = func(*eddsa.Public, *eddsa.SecretShare) error {
+			stored.Add(1)
+			return nil
+		}
+	}
+	_, err := client.Sign(ctx, party.IDSlice{1, 2}, []byte("no key"))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	groupKey, err := client.Keygen(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), stored.Load())
+	for _, s := range servers {
+		public, secret := s.Key()
+		require.NotNil(t, secret)
+		assert.True(t, public.GroupKey.Equal(groupKey))
+	}
+
+	message := []byte("over gRPC")
+	sig, err := client.Sign(ctx, party.IDSlice{4, 2}, message)
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(groupKey.ToEd25519(), message, sig.ToEd25519()))
+
+	session := frost.DeriveSessionID(groupKey, message, party.IDSlice{1, 3})
+	sig, err = client.SignWithSession(ctx, session, party.IDSlice{3, 1}, message)
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(groupKey.ToEd25519(), message, sig.ToEd25519()))
+
+	_, err = client.Sign(ctx, party.IDSlice{2, 5}, message)
+	assert.Error(t, err, "no connection to party 5")
+
+	groupKey, err = client.KeygenWithEcho(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int32(8), stored.Load())
+	sig, err = client.Sign(ctx, party.IDSlice{1, 2, 3}, message)
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(groupKey.ToEd25519(), message, sig.ToEd25519()))
+}
+
+func TestRefresh(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	servers, client := startParties(t, 3)
+
+	groupKey, err := client.Keygen(ctx, 1)
+	require.NoError(t, err)
+	old := make(map[party.ID]*eddsa.SecretShare, len(servers))
+	var stored atomic.Int32
+	for id, s := range servers {
+		_, old[id] = s.Key()
+		s.OnRefresh = func(*eddsa.Public, *eddsa.SecretShare) error {
+			stored.Add(1)
+			return nil
+		}
+	}
+
+	err = client.Refresh(ctx, eddsa.NewPublicKeyFromPoint(randomElement()))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	require.NoError(t, client.Refresh(ctx, groupKey))
+	assert.Equal(t, int32(3), stored.Load())
+	for id, s := range servers {
+		public, secret := s.Key()
+		assert.True(t, public.GroupKey.Equal(groupKey))
+		assert.NotEqual(t, old[id].Secret.Bytes(), secret.Secret.Bytes())
+	}
+
+	message := []byte("after refresh")
+	sig, err := client.Sign(ctx, party.IDSlice{1, 3}, message)
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(groupKey.ToEd25519(), message, sig.ToEd25519()))
+
+	// all parties of the group must take part
+	delete(client.Parties, 2)
+	err = client.Refresh(ctx, groupKey)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_LoadSecret(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	servers, client := startParties(t, 3)
+
+	// the shares are only kept encoded, as a wrapped share file would be
+	var mu sync.Mutex
+	stored := make(map[party.ID][]byte, len(servers))
+	loads := make(map[party.ID]int, len(servers))
+	for id, s := range servers {
+		store := func(_ *eddsa.Public, secret *eddsa.SecretShare) error {
+			data, err := secret.MarshalBinary()
+			mu.Lock()
+			stored[id] = data
+			mu.Unlock()
+			return err
+		}
+		s.OnKeygen, s.OnRefresh = store, store
+		s.LoadSecret = func(context.Context) (*eddsa.SecretShare, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			loads[id]++
+			var secret eddsa.SecretShare
+			return &secret, secret.UnmarshalBinary(stored[id])
+		}
+	}
+
+	groupKey, err := client.Keygen(ctx, 1)
+	require.NoError(t, err)
+	for _, s := range servers {
+		_, secret := s.Key()
+		assert.Nil(t, secret)
+	}
+
+	message := []byte("loaded at sign time")
+	sig, err := client.Sign(ctx, party.IDSlice{1, 3}, message)
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(groupKey.ToEd25519(), message, sig.ToEd25519()))
+	assert.Equal(t, map[party.ID]int{1: 1, 3: 1}, loads)
+
+	require.NoError(t, client.Refresh(ctx, groupKey))
+	sig, err = client.Sign(ctx, party.IDSlice{2, 3}, message)
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(groupKey.ToEd25519(), message, sig.ToEd25519()))
+	assert.Equal(t, map[party.ID]int{1: 2, 2: 2, 3: 3}, loads)
+
+	// a failing load fails the session
+	servers[2].LoadSecret = func(context.Context) (*eddsa.SecretShare, error) {
+		return nil, errors.New("kms unavailable")
+	}
+	_, err = client.Sign(ctx, party.IDSlice{1, 2}, message)
+	assert.Error(t, err)
+}
+
+func TestServer_Audit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	servers, client := startParties(t, 3)
+
+	paths := make(map[party.ID]string, len(servers))
+	for id, s := range servers {
+		paths[id] = filepath.Join(t.TempDir(), "audit.log")
+		l, err := audit.Open(paths[id])
+		require.NoError(t, err)
+		t.Cleanup(func() { l.Close() })
+		s.Audit = l
+	}
+
+	groupKey, err := client.Keygen(ctx, 1)
+	require.NoError(t, err)
+	message := []byte("audited")
+	_, err = client.Sign(ctx, party.IDSlice{1, 3}, message)
+	require.NoError(t, err)
+	require.NoError(t, client.Refresh(ctx, groupKey))
+
+	entries, err := audit.VerifyFile(paths[3])
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, audit.KindKeygen, entries[0].Kind)
+	assert.Equal(t, party.ID(3), entries[0].Party)
+	assert.Equal(t, party.IDSlice{1, 3}, entries[1].Parties)
+	assert.NoError(t, entries[1].VerifySignature(groupKey.ToEd25519(), message))
+	assert.Equal(t, audit.KindRefresh, entries[2].Kind)
+	assert.Equal(t, audit.OutcomeSucceeded, entries[2].Outcome)
+
+	entries, err = audit.VerifyFile(paths[2])
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "party 2 did not sign")
+
+	// a session that cannot be recorded fails
+	require.NoError(t, servers[1].Audit.Close())
+	_, err = client.Sign(ctx, party.IDSlice{1, 3}, message)
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestServer_Policy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	servers, client := startParties(t, 3)
+	groupKey, err := client.Keygen(ctx, 1)
+	require.NoError(t, err)
+
+	rules, err := policy.Parse([]byte(`{"rules": [{"message_prefixes": ["release "]}]}`))
+	require.NoError(t, err)
+	servers[2].Policy = rules
+	loaded := false
+	servers[2].LoadSecret = func(context.Context) (*eddsa.SecretShare, error) {
+		loaded = true
+		return nil, errors.New("not loaded for a denied request")
+	}
+
+	_, err = client.Sign(ctx, party.IDSlice{1, 2}, []byte("deploy v1"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.False(t, loaded)
+
+	// party 3 has no policy
+	message := []byte("deploy v1")
+	sig, err := client.Sign(ctx, party.IDSlice{1, 3}, message)
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(groupKey.ToEd25519(), message, sig.ToEd25519()))
+
+	// the coordinator has its own
+	client.Policy = policy.Func(func(_ context.Context, req *policy.Request) error {
+		if !req.Signers.Contains(1) {
+			return policy.Deny("party 1 must sign")
+		}
+		return nil
+	})
+	_, err = client.Sign(ctx, party.IDSlice{2, 3}, []byte("release v1"))
+	assert.True(t, errors.Is(err, policy.ErrDenied))
+}
+
+func TestServer_InvalidStart(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, client := startParties(t, 2)
+
+	_, err := client.Keygen(ctx, 2)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_Timeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	servers, client := startParties(t, 2)
+	servers[1].Timeout = 50 * time.Millisecond
+
+	// party 1 waits in vain for the message of party 2
+	stream, err := client.Parties[1].NewStream(ctx, &serviceDesc.Streams[0], "/frost.v1.Frost/Keygen", grpc.ForceCodec(codec{}))
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(&KeygenRequest{Start: &KeygenStart{N: 2, Threshold: 1}}))
+	var resp KeygenResponse
+	require.NoError(t, stream.RecvMsg(&resp))
+	assert.Equal(t, frost.MessageTypeKeyGen1, resp.Message.Type)
+	err = stream.RecvMsg(&resp)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestServer_Limits(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	servers, client := startParties(t, 2)
+	servers[1].Limiter = limits.New(limits.Limits{MaxSessions: 1})
+
+	// a session that never finishes holds the only slot of party 1
+	stream, err := client.Parties[1].NewStream(ctx, &serviceDesc.Streams[0], "/frost.v1.Frost/Keygen", grpc.ForceCodec(codec{}))
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(&KeygenRequest{Start: &KeygenStart{N: 2, Threshold: 1}}))
+	var resp KeygenResponse
+	require.NoError(t, stream.RecvMsg(&resp))
+	_, err = client.Keygen(ctx, 1)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// until it ends
+	require.NoError(t, stream.CloseSend())
+	_ = stream.RecvMsg(&resp)
+	require.Eventually(t, func() bool { return servers[1].Limiter.Sessions() == 0 }, 5*time.Second, time.Millisecond)
+	_, err = client.Keygen(ctx, 1)
+	require.NoError(t, err)
+
+	// the messages of party 2 are larger than party 1 accepts
+	servers[1].Limiter = limits.New(limits.Limits{MaxMessageSize: 64})
+	_, err = client.Sign(ctx, party.IDSlice{1, 2}, []byte("m"))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// and more frequent
+	servers[1].Limiter = limits.New(limits.Limits{Rate: 0.001, Burst: 2})
+	_, err = client.Sign(ctx, party.IDSlice{1, 2}, []byte("m"))
+	require.NoError(t, err)
+	_, err = client.Sign(ctx, party.IDSlice{1, 2}, []byte("m"))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestServer_ForeignSender(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	servers, client := startParties(t, 2)
+	servers[1].Limiter = limits.New(limits.Limits{Rate: 0.001, Burst: 2})
+
+	// messages of a party outside the session are dropped before they count
+	// against any rate
+	for _, from := range []party.ID{3, 1} {
+		stream, err := client.Parties[1].NewStream(ctx, &serviceDesc.Streams[0], "/frost.v1.Frost/Keygen", grpc.ForceCodec(codec{}))
+		require.NoError(t, err)
+		require.NoError(t, stream.SendMsg(&KeygenRequest{Start: &KeygenStart{N: 2, Threshold: 1}}))
+		var resp KeygenResponse
+		require.NoError(t, stream.RecvMsg(&resp))
+		require.NoError(t, stream.SendMsg(&KeygenRequest{Message: frost.NewKeyGen2(from, 1, scalar.NewScalarRandom())}))
+		err = stream.RecvMsg(&resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "from %d", from)
+	}
+	_, err := client.Keygen(ctx, 1)
+	require.NoError(t, err)
+}
+
+func randomElement() *ristretto.Element {
+	return new(ristretto.Element).ScalarBaseMult(scalar.NewScalarRandom())
+}
+
+func TestMessageEncoding(t *testing.T) {
+	poly := polynomial.NewPolynomial(2, scalar.NewScalarRandom())
+	commitments := polynomial.NewPolynomialExponent(poly)
+	msgs := []*frost.Message{
+		frost.NewKeyGen2(1, 2, scalar.NewScalarRandom()),
+		frost.NewSign1(3, randomElement(), randomElement()),
+		frost.NewSign2(4, scalar.NewScalarRandom()),
+		frost.NewReshare1(5, commitments),
+		frost.NewReshare2(6, 7, scalar.NewScalarRandom()),
+		frost.NewRepair1(1, 2, scalar.NewScalarRandom()),
+		frost.NewRepair2(2, 3, scalar.NewScalarRandom()),
+		frost.NewSign0(4, &[64]byte{1, 2, 3}),
+		frost.NewSignBatch1(5, []frost.Sign1{{Di: *randomElement(), Ei: *randomElement()}, {Di: *randomElement(), Ei: *randomElement()}}),
+		frost.NewSignBatch2(6, []ristretto.Scalar{*scalar.NewScalarRandom(), *scalar.NewScalarRandom()}),
+		frost.NewKeyGenEcho(7, &[64]byte{4, 5, 6}),
+	}
+	session, err := frost.NewSessionID()
+	require.NoError(t, err)
+	msg, _, err := frost.KeygenInitWithSession(session, 2, 3, 1)
+	require.NoError(t, err)
+	msgs = append(msgs, msg)
+
+	for _, msg := range msgs {
+		req := &SignRequest{Message: msg}
+		data, err := req.marshal()
+		require.NoError(t, err)
+		var decoded SignRequest
+		require.NoError(t, decoded.unmarshal(data), msg.Type)
+		expected, _ := msg.MarshalBinary()
+		actual, err := decoded.Message.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual, msg.Type)
+
+		assert.Error(t, decoded.unmarshal(data[:len(data)-1]), msg.Type)
+	}
+
+	start := &SignRequest{Start: &SignStart{SignerIDs: party.IDSlice{1, 300}, Message: []byte("m"), SessionID: session}}
+	data, err := start.marshal()
+	require.NoError(t, err)
+	var decoded SignRequest
+	require.NoError(t, decoded.unmarshal(data))
+	assert.Equal(t, start.Start, decoded.Start)
+
+	refresh := &RefreshRequest{Start: &RefreshStart{PartyIDs: party.IDSlice{1, 2, 3}, GroupKey: eddsa.NewPublicKeyFromPoint(randomElement())}}
+	data, err = refresh.marshal()
+	require.NoError(t, err)
+	var decodedRefresh RefreshRequest
+	require.NoError(t, decodedRefresh.unmarshal(data))
+	assert.Equal(t, refresh.Start.PartyIDs, decodedRefresh.Start.PartyIDs)
+	assert.True(t, refresh.Start.GroupKey.Equal(decodedRefresh.Start.GroupKey))
+
+	keygen := &KeygenRequest{Start: &KeygenStart{N: 5, Threshold: 2, Echo: true}}
+	data, err = keygen.marshal()
+	require.NoError(t, err)
+	var decodedKeygen KeygenRequest
+	require.NoError(t, decodedKeygen.unmarshal(data))
+	assert.Equal(t, keygen.Start, decodedKeygen.Start)
+
+	// a Sign2 message with the payload of a Sign1 message
+	data = []byte{0x12, 0x06, 0x08, 0x04, 0x10, 0x01, 0x32, 0x00}
+	assert.Error(t, decoded.unmarshal(data))
+}
@@ -0,0 +1,424 @@
+package grpcserver
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ErrInvalidMessage is returned for data that is not a valid encoding of the messages in frost.proto.
+// Errors decoding a Message also wrap frost.ErrInvalidMessage.
+var ErrInvalidMessage = errors.New("grpcserver: invalid message")
+
+// KeygenStart starts a key generation between the parties 1..N.
+type KeygenStart struct {
+	N         party.Size
+	Threshold party.Size
+	// Echo adds the round in which the parties compare the KeyGen1 messages
+	// they received, see frost.NewKeygenMachineWithEcho.
+	Echo bool
+}
+
+// KeygenRequest is sent by the coordinator, Start first and then the messages of the other parties.
+type KeygenRequest struct {
+	Start   *KeygenStart
+	Message *frost.Message
+}
+
+// KeygenResponse is sent by a party, its messages and then the group key.
+type KeygenResponse struct {
+	Message  *frost.Message
+	GroupKey *eddsa.PublicKey
+}
+
+// SignStart starts the signing of Message by SignerIDs.
+type SignStart struct {
+	SignerIDs party.IDSlice
+	Message   []byte
+	// SessionID is the session of the signing, or zero for a session without one.
+	SessionID frost.SessionID
+}
+
+// SignRequest is sent by the coordinator, Start first and then the messages of the other signers.
+type SignRequest struct {
+	Start   *SignStart
+	Message *frost.Message
+}
+
+// SignResponse is sent by a signer, its messages and then the signature.
+type SignResponse struct {
+	Message   *frost.Message
+	Signature *eddsa.Signature
+}
+
+// RefreshStart starts the refresh of the shares of GroupKey by all of its parties, PartyIDs.
+type RefreshStart struct {
+	PartyIDs party.IDSlice
+	GroupKey *eddsa.PublicKey
+}
+
+// RefreshRequest is sent by the coordinator, Start first and then the messages of the other parties.
+type RefreshRequest struct {
+	Start   *RefreshStart
+	Message *frost.Message
+}
+
+// RefreshResponse is sent by a party, its messages and then the group key.
+type RefreshResponse struct {
+	Message  *frost.Message
+	GroupKey *eddsa.PublicKey
+}
+
+// wireMessage is implemented by the messages of the service.
+type wireMessage interface {
+	marshal() ([]byte, error)
+	unmarshal(b []byte) error
+}
+
+func (r *KeygenRequest) marshal() ([]byte, error) {
+	switch {
+	case r.Start != nil:
+		var start []byte
+		start = protowire.AppendTag(start, 1, protowire.VarintType)
+		start = protowire.AppendVarint(start, uint64(r.Start.N))
+		start = protowire.AppendTag(start, 2, protowire.VarintType)
+		start = protowire.AppendVarint(start, uint64(r.Start.Threshold))
+		if r.Start.Echo {
+			start = protowire.AppendTag(start, 3, protowire.VarintType)
+			start = protowire.AppendVarint(start, 1)
+		}
+		return appendField(nil, 1, start), nil
+	case r.Message != nil:
+		return appendMessageField(nil, 2, r.Message)
+	}
+	return nil, fmt.Errorf("%w: empty keygen request", ErrInvalidMessage)
+}
+
+func (r *KeygenRequest) unmarshal(b []byte) error {
+	*r = KeygenRequest{}
+	return parseFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			r.Start = &KeygenStart{}
+			return parseFields(f.bytes, func(f field) error {
+				switch f.num {
+				case 1:
+					return f.size(&r.Start.N)
+				case 2:
+					return f.size(&r.Start.Threshold)
+				case 3:
+					if f.typ != protowire.VarintType {
+						return fmt.Errorf("%w: field %d is not a varint", ErrInvalidMessage, f.num)
+					}
+					r.Start.Echo = f.varint != 0
+				}
+				return nil
+			})
+		case 2:
+			return f.message(&r.Message)
+		}
+		return nil
+	})
+}
+
+func (r *KeygenResponse) marshal() ([]byte, error) {
+	switch {
+	case r.Message != nil:
+		return appendMessageField(nil, 1, r.Message)
+	case r.GroupKey != nil:
+		return appendField(nil, 2, r.GroupKey.ToEd25519()), nil
+	}
+	return nil, fmt.Errorf("%w: empty keygen response", ErrInvalidMessage)
+}
+
+func (r *KeygenResponse) unmarshal(b []byte) error {
+	*r = KeygenResponse{}
+	return parseFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.message(&r.Message)
+		case 2:
+			r.GroupKey = &eddsa.PublicKey{}
+			if len(f.bytes) != 32 {
+				return fmt.Errorf("%w: group key of %d bytes", ErrInvalidMessage, len(f.bytes))
+			}
+			return r.GroupKey.Scan(f.bytes)
+		}
+		return nil
+	})
+}
+
+func (r *SignRequest) marshal() ([]byte, error) {
+	switch {
+	case r.Start != nil:
+		var ids []byte
+		for _, id := range r.Start.SignerIDs {
+			ids = protowire.AppendVarint(ids, uint64(id))
+		}
+		start := appendField(nil, 1, ids)
+		start = appendField(start, 2, r.Start.Message)
+		if !r.Start.SessionID.IsZero() {
+			start = appendField(start, 3, r.Start.SessionID[:])
+		}
+		return appendField(nil, 1, start), nil
+	case r.Message != nil:
+		return appendMessageField(nil, 2, r.Message)
+	}
+	return nil, fmt.Errorf("%w: empty sign request", ErrInvalidMessage)
+}
+
+func (r *SignRequest) unmarshal(b []byte) error {
+	*r = SignRequest{}
+	return parseFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			r.Start = &SignStart{Message: []byte{}}
+			return parseFields(f.bytes, func(f field) error {
+				switch f.num {
+				case 1:
+					if f.typ == protowire.BytesType {
+						return parseVarints(f.bytes, func(v uint64) error {
+							return appendID(&r.Start.SignerIDs, v)
+						})
+					}
+					// encoders may also write repeated fields unpacked
+					return appendID(&r.Start.SignerIDs, f.varint)
+				case 2:
+					r.Start.Message = append([]byte{}, f.bytes...)
+				case 3:
+					if f.typ != protowire.BytesType || len(f.bytes) != len(r.Start.SessionID) {
+						return fmt.Errorf("%w: invalid session ID", ErrInvalidMessage)
+					}
+					copy(r.Start.SessionID[:], f.bytes)
+				}
+				return nil
+			})
+		case 2:
+			return f.message(&r.Message)
+		}
+		return nil
+	})
+}
+
+func (r *SignResponse) marshal() ([]byte, error) {
+	switch {
+	case r.Message != nil:
+		return appendMessageField(nil, 1, r.Message)
+	case r.Signature != nil:
+		return appendField(nil, 2, r.Signature.ToEd25519()), nil
+	}
+	return nil, fmt.Errorf("%w: empty sign response", ErrInvalidMessage)
+}
+
+func (r *SignResponse) unmarshal(b []byte) error {
+	*r = SignResponse{}
+	return parseFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.message(&r.Message)
+		case 2:
+			r.Signature = &eddsa.Signature{}
+			if len(f.bytes) != eddsa.MessageLengthSig {
+				return fmt.Errorf("%w: signature of %d bytes", ErrInvalidMessage, len(f.bytes))
+			}
+			return r.Signature.Scan(f.bytes)
+		}
+		return nil
+	})
+}
+
+func (r *RefreshRequest) marshal() ([]byte, error) {
+	switch {
+	case r.Start != nil && r.Start.GroupKey != nil:
+		var ids []byte
+		for _, id := range r.Start.PartyIDs {
+			ids = protowire.AppendVarint(ids, uint64(id))
+		}
+		start := appendField(nil, 1, ids)
+		start = appendField(start, 2, r.Start.GroupKey.ToEd25519())
+		return appendField(nil, 1, start), nil
+	case r.Message != nil:
+		return appendMessageField(nil, 2, r.Message)
+	}
+	return nil, fmt.Errorf("%w: empty refresh request", ErrInvalidMessage)
+}
+
+func (r *RefreshRequest) unmarshal(b []byte) error {
+	*r = RefreshRequest{}
+	return parseFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			r.Start = &RefreshStart{}
+			return parseFields(f.bytes, func(f field) error {
+				switch f.num {
+				case 1:
+					if f.typ == protowire.BytesType {
+						return parseVarints(f.bytes, func(v uint64) error {
+							return appendID(&r.Start.PartyIDs, v)
+						})
+					}
+					return appendID(&r.Start.PartyIDs, f.varint)
+				case 2:
+					r.Start.GroupKey = &eddsa.PublicKey{}
+					if len(f.bytes) != 32 {
+						return fmt.Errorf("%w: group key of %d bytes", ErrInvalidMessage, len(f.bytes))
+					}
+					return r.Start.GroupKey.Scan(f.bytes)
+				}
+				return nil
+			})
+		case 2:
+			return f.message(&r.Message)
+		}
+		return nil
+	})
+}
+
+func (r *RefreshResponse) marshal() ([]byte, error) {
+	switch {
+	case r.Message != nil:
+		return appendMessageField(nil, 1, r.Message)
+	case r.GroupKey != nil:
+		return appendField(nil, 2, r.GroupKey.ToEd25519()), nil
+	}
+	return nil, fmt.Errorf("%w: empty refresh response", ErrInvalidMessage)
+}
+
+func (r *RefreshResponse) unmarshal(b []byte) error {
+	*r = RefreshResponse{}
+	return parseFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			return f.message(&r.Message)
+		case 2:
+			r.GroupKey = &eddsa.PublicKey{}
+			if len(f.bytes) != 32 {
+				return fmt.Errorf("%w: group key of %d bytes", ErrInvalidMessage, len(f.bytes))
+			}
+			return r.GroupKey.Scan(f.bytes)
+		}
+		return nil
+	})
+}
+
+// marshalMessage returns the encoding of msg as a Message of message.proto.
+func marshalMessage(msg *frost.Message) ([]byte, error) {
+	b, err := msg.ToProto()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+	return b, nil
+}
+
+// unmarshalMessage decodes a Message of message.proto.
+func unmarshalMessage(b []byte) (*frost.Message, error) {
+	var msg frost.Message
+	if err := msg.FromProto(b); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+	return &msg, nil
+}
+
+func appendField(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendMessageField(b []byte, num protowire.Number, msg *frost.Message) ([]byte, error) {
+	m, err := marshalMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+	return appendField(b, num, m), nil
+}
+
+// field is a field of a protobuf message, with the value of a varint or length delimited field.
+type field struct {
+	num    protowire.Number
+	typ    protowire.Type
+	varint uint64
+	bytes  []byte
+}
+
+// size sets v to the value of a varint field that fits in a party.ID.
+func (f field) size(v *party.ID) error {
+	if f.typ != protowire.VarintType {
+		return fmt.Errorf("%w: field %d is not a varint", ErrInvalidMessage, f.num)
+	}
+	if f.varint > 0xffff {
+		return fmt.Errorf("%w: field %d is too large", ErrInvalidMessage, f.num)
+	}
+	*v = party.ID(f.varint)
+	return nil
+}
+
+func (f field) message(msg **frost.Message) error {
+	if f.typ != protowire.BytesType {
+		return fmt.Errorf("%w: field %d is not a message", ErrInvalidMessage, f.num)
+	}
+	m, err := unmarshalMessage(f.bytes)
+	if err != nil {
+		return err
+	}
+	*msg = m
+	return nil
+}
+
+// parseFields calls fn for every field of the protobuf message b. Fields
+// other than varints and length delimited ones are skipped.
+func parseFields(b []byte, fn func(f field) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("%w: %v", ErrInvalidMessage, protowire.ParseError(n))
+		}
+		b = b[n:]
+		f := field{num: num, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.varint, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return fmt.Errorf("%w: %v", ErrInvalidMessage, protowire.ParseError(n))
+		}
+		b = b[n:]
+		if typ != protowire.VarintType && typ != protowire.BytesType {
+			continue
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseVarints calls fn for every varint of a packed repeated field.
+func parseVarints(b []byte, fn func(v uint64) error) error {
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return fmt.Errorf("%w: %v", ErrInvalidMessage, protowire.ParseError(n))
+		}
+		b = b[n:]
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendID(ids *party.IDSlice, v uint64) error {
+	if v > 0xffff {
+		return fmt.Errorf("%w: party id %d", ErrInvalidMessage, v)
+	}
+	*ids = append(*ids, party.ID(v))
+	return nil
+}
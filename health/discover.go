@@ -0,0 +1,153 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/manifest"
+	"github.com/bartke/frost/migrate"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/sealed"
+)
+
+// ShareFile is a key file found by Discover.
+type ShareFile struct {
+	Path string `json:"path"`
+	// Kind is migrate.KindPublic, one of the secret share kinds, or "sealed".
+	Kind string `json:"kind"`
+	// ID is the party of a secret share.
+	ID party.ID `json:"id,omitempty"`
+	// Group is the fingerprint of the group of a public file, or of the
+	// public file a secret share was matched with.
+	Group string `json:"group,omitempty"`
+	// Verified is true for secret shares matching their public share.
+	Verified bool `json:"verified,omitempty"`
+}
+
+// KindSealed is the kind of sealed containers, whose content cannot be inspected without the passphrase.
+const KindSealed = "sealed"
+
+// Discover finds the public and secret share files below dir, and matches every
+// secret share with the public shares of its group. Other files are ignored.
+func Discover(dir string) ([]ShareFile, error) {
+	var (
+		files   []ShareFile
+		publics []*eddsa.Public
+		secrets = make(map[int]*eddsa.SecretShare)
+	)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if sealed.IsSealed(data) {
+			files = append(files, ShareFile{Path: path, Kind: KindSealed})
+			return nil
+		}
+
+		res, err := migrate.Migrate(data)
+		if err != nil {
+			return nil
+		}
+		switch res.Kind {
+		case migrate.KindPublic:
+			var public eddsa.Public
+			if err := json.Unmarshal(res.Data, &public); err != nil {
+				return nil
+			}
+			publics = append(publics, &public)
+			files = append(files, ShareFile{Path: path, Kind: string(res.Kind), Group: manifest.Fingerprint(public.GroupKey.ToEd25519())})
+		case migrate.KindSecretShareBinary, migrate.KindSecretShareJSON:
+			var secret eddsa.SecretShare
+			if err := secret.UnmarshalBinary(res.Data); err != nil {
+				return nil
+			}
+			secrets[len(files)] = &secret
+			files = append(files, ShareFile{Path: path, Kind: string(res.Kind), ID: secret.ID})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("health: %w", err)
+	}
+
+	for i, secret := range secrets {
+		for _, public := range publics {
+			if matches(secret, public) {
+				files[i].Group = manifest.Fingerprint(public.GroupKey.ToEd25519())
+				files[i].Verified = true
+				break
+			}
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// decodeSecret decodes a secret share in the binary or a JSON format.
+func decodeSecret(data []byte) (*eddsa.SecretShare, error) {
+	res, err := migrate.Migrate(data)
+	if err != nil {
+		return nil, err
+	}
+	if res.Kind != migrate.KindSecretShareBinary && res.Kind != migrate.KindSecretShareJSON {
+		return nil, fmt.Errorf("found %s", res.Kind)
+	}
+	// secret shares are migrated to the binary form
+	var secret eddsa.SecretShare
+	if err := secret.UnmarshalBinary(res.Data); err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// matches returns true if secret is the share of its party in public.
+func matches(secret *eddsa.SecretShare, public *eddsa.Public) bool {
+	share, ok := public.Shares[secret.ID]
+	return ok && share.Equal(&secret.Public) == 1
+}
+
+// CheckBackup checks that the file at path holds a copy of secret. Sealed
+// backups are opened with passphrase; if it is nil they are reported as unverified.
+// now is reported as the time of the check.
+func CheckBackup(path string, secret *eddsa.SecretShare, passphrase []byte, now time.Time) BackupStatus {
+	status := BackupStatus{Path: path, CheckedAt: now}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	if sealed.IsSealed(data) {
+		if passphrase == nil {
+			status.Error = "sealed backup, no passphrase to open it"
+			return status
+		}
+		if data, err = sealed.Open(data, passphrase); err != nil {
+			status.Error = err.Error()
+			return status
+		}
+	}
+	backup, err := decodeSecret(data)
+	if err != nil {
+		status.Error = "not a secret share: " + err.Error()
+		return status
+	}
+	if !backup.Equal(secret) {
+		status.Error = "backup differs from the secret share"
+		return status
+	}
+	status.Verified = true
+	return status
+}
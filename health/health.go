@@ -0,0 +1,217 @@
+// Package health reports the operational state of a group: which parties are
+// reachable and still hold their shares, and whether backups can be restored.
+//
+// Possession of a share is proven with a Schnorr proof of knowledge of the
+// secret share for the public share of the party, bound to a fresh challenge of
+// the verifier. Proofs reveal nothing about the share, so they can be requested
+// as often as needed.
+package health
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/manifest"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/zk"
+)
+
+const possessionContext = "frost-possession-v1"
+
+// ChallengeSize is the size of the challenges of possession proofs.
+const ChallengeSize = 32
+
+// proofContext binds a proof to the group and the challenge.
+func proofContext(public *eddsa.Public, challenge []byte) []byte {
+	ctx := append([]byte(possessionContext), public.GroupKey.ToEd25519()...)
+	return append(ctx, challenge...)
+}
+
+// Prove returns a proof that the owner of secret holds the share of its party in public.
+func Prove(secret *eddsa.SecretShare, public *eddsa.Public, challenge []byte) (*zk.Schnorr, error) {
+	if len(challenge) < ChallengeSize {
+		return nil, errors.New("health: challenge too short")
+	}
+	share, ok := public.Shares[secret.ID]
+	if !ok {
+		return nil, errors.New("health: party is not in the group")
+	}
+	return zk.NewSchnorrProof(secret.ID, share, proofContext(public, challenge), &secret.Secret), nil
+}
+
+// Verify checks a proof returned by Prove for party id.
+func Verify(public *eddsa.Public, id party.ID, challenge []byte, proof *zk.Schnorr) bool {
+	share, ok := public.Shares[id]
+	if !ok || proof == nil {
+		return false
+	}
+	return proof.Verify(id, share, proofContext(public, challenge))
+}
+
+// PartyStatus is the state of one party as seen by the reporting party.
+type PartyStatus struct {
+	ID       party.ID `json:"id"`
+	Endpoint string   `json:"endpoint,omitempty"`
+	// Reachable is true if the last probe got an answer.
+	Reachable bool       `json:"reachable"`
+	LastSeen  *time.Time `json:"last_seen,omitempty"`
+	// LastProof is the time the party last proved possession of its share.
+	LastProof *time.Time `json:"last_proof,omitempty"`
+	// Error is the reason the last probe failed.
+	Error string `json:"error,omitempty"`
+}
+
+// BackupStatus is the result of checking a backup of a secret share.
+type BackupStatus struct {
+	Path      string    `json:"path"`
+	Verified  bool      `json:"verified"`
+	CheckedAt time.Time `json:"checked_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// GroupStatus is the overview of a group reported by one of its parties.
+type GroupStatus struct {
+	// Group is the fingerprint of the group key, see manifest.Fingerprint.
+	Group     string        `json:"group"`
+	Self      party.ID      `json:"self"`
+	Threshold party.Size    `json:"threshold"`
+	Parties   []PartyStatus `json:"parties"`
+	// PendingSessions is the number of signing sessions in progress.
+	PendingSessions int `json:"pending_sessions"`
+	// LastRefresh is the time the shares were last refreshed, nil if they never were.
+	LastRefresh *time.Time     `json:"last_refresh"`
+	Backups     []BackupStatus `json:"backups,omitempty"`
+}
+
+// Quorum returns true if enough parties proved possession of their share
+// within maxAge to sign, counting the reporting party.
+func (s *GroupStatus) Quorum(now time.Time, maxAge time.Duration) bool {
+	n := 0
+	for _, p := range s.Parties {
+		if p.Reachable && p.LastProof != nil && now.Sub(*p.LastProof) <= maxAge {
+			n++
+		}
+	}
+	return n > int(s.Threshold)
+}
+
+// Prober asks party id for a proof of possession for challenge.
+type Prober func(ctx context.Context, id party.ID, challenge []byte) (*zk.Schnorr, error)
+
+// Monitor keeps track of the status of the parties of a group by probing them.
+type Monitor struct {
+	public *eddsa.Public
+	secret *eddsa.SecretShare
+	probe  Prober
+	clock  clock.Clock
+
+	mu      sync.Mutex
+	parties map[party.ID]*PartyStatus
+}
+
+// NewMonitor returns a monitor for the group public, run by the owner of secret.
+func NewMonitor(public *eddsa.Public, secret *eddsa.SecretShare, probe Prober) *Monitor {
+	m := &Monitor{
+		public:  public,
+		secret:  secret,
+		probe:   probe,
+		clock:   clock.Real,
+		parties: make(map[party.ID]*PartyStatus, len(public.PartyIDs)),
+	}
+	for _, id := range public.PartyIDs {
+		m.parties[id] = &PartyStatus{ID: id}
+	}
+	return m
+}
+
+// SetClock sets the clock that times Run and the probe results, the default is clock.Real.
+func (m *Monitor) SetClock(c clock.Clock) {
+	m.clock = clock.OrReal(c)
+}
+
+// Probe challenges every party, including ourselves, and waits for the results.
+func (m *Monitor) Probe(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, id := range m.public.PartyIDs {
+		wg.Add(1)
+		go func(id party.ID) {
+			defer wg.Done()
+			m.probeParty(ctx, id)
+		}(id)
+	}
+	wg.Wait()
+}
+
+func (m *Monitor) probeParty(ctx context.Context, id party.ID) {
+	challenge := make([]byte, ChallengeSize)
+	_, err := rand.Read(challenge)
+
+	var proof *zk.Schnorr
+	if err == nil {
+		if id == m.secret.ID {
+			proof, err = Prove(m.secret, m.public, challenge)
+		} else {
+			proof, err = m.probe(ctx, id, challenge)
+		}
+	}
+	now := m.clock.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.parties[id]
+	if err != nil {
+		p.Reachable = false
+		p.Error = err.Error()
+		return
+	}
+	p.Reachable = true
+	p.LastSeen = &now
+	if Verify(m.public, id, challenge, proof) {
+		p.LastProof = &now
+		p.Error = ""
+	} else {
+		p.Error = "invalid proof of possession"
+	}
+}
+
+// Run probes all parties every interval until ctx is done.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := m.clock.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		m.Probe(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// Parties returns the status of all parties, ordered by ID.
+func (m *Monitor) Parties() []PartyStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]PartyStatus, 0, len(m.parties))
+	for _, p := range m.parties {
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Status returns the status of the group without the fields only known to the daemon.
+func (m *Monitor) Status() *GroupStatus {
+	return &GroupStatus{
+		Group:     manifest.Fingerprint(m.public.GroupKey.ToEd25519()),
+		Self:      m.secret.ID,
+		Threshold: m.public.Threshold,
+		Parties:   m.Parties(),
+	}
+}
@@ -0,0 +1,125 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost/frostclient"
+	"github.com/bartke/frost/manifest"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/sealed"
+	"github.com/bartke/frost/zk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProve(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	challenge := make([]byte, ChallengeSize)
+	challenge[0] = 1
+
+	proof, err := Prove(group.Shares[2], group.Public, challenge)
+	require.NoError(t, err)
+	assert.True(t, Verify(group.Public, 2, challenge, proof))
+	assert.False(t, Verify(group.Public, 3, challenge, proof), "proofs are bound to the party")
+	assert.False(t, Verify(group.Public, 2, make([]byte, ChallengeSize), proof), "proofs are bound to the challenge")
+	assert.False(t, Verify(group.Public, 2, challenge, nil))
+
+	_, err = Prove(group.Shares[2], group.Public, challenge[:8])
+	assert.Error(t, err)
+}
+
+func TestMonitor(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 4, T: 1})
+	require.NoError(t, err)
+
+	probe := func(ctx context.Context, id party.ID, challenge []byte) (*zk.Schnorr, error) {
+		switch id {
+		case 3:
+			return nil, errors.New("connection refused")
+		case 4:
+			// a party that lost its share and answers with someone else's
+			return Prove(group.Shares[2], group.Public, challenge)
+		}
+		return Prove(group.Shares[id], group.Public, challenge)
+	}
+	m := NewMonitor(group.Public, group.Shares[1], probe)
+	m.Probe(context.Background())
+
+	status := m.Status()
+	assert.Equal(t, manifest.Fingerprint(group.Public.GroupKey.ToEd25519()), status.Group)
+	require.Len(t, status.Parties, 4)
+	for _, p := range status.Parties[:2] {
+		assert.True(t, p.Reachable, p.ID)
+		assert.NotNil(t, p.LastProof, p.ID)
+		assert.Empty(t, p.Error, p.ID)
+	}
+	assert.False(t, status.Parties[2].Reachable)
+	assert.Equal(t, "connection refused", status.Parties[2].Error)
+	assert.True(t, status.Parties[3].Reachable)
+	assert.Nil(t, status.Parties[3].LastProof)
+	assert.NotEmpty(t, status.Parties[3].Error)
+
+	now := time.Now()
+	assert.True(t, status.Quorum(now, time.Minute))
+	assert.False(t, status.Quorum(now.Add(time.Hour), time.Minute), "old proofs do not count")
+}
+
+func TestDiscover(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	other, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	write := func(name string, data []byte) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0600))
+	}
+	public, err := json.Marshal(group.Public)
+	require.NoError(t, err)
+	write("public.json", public)
+	secret1, err := group.Shares[1].MarshalBinary()
+	require.NoError(t, err)
+	write("secret1.dat", secret1)
+	secret2, err := json.Marshal(group.Shares[2])
+	require.NoError(t, err)
+	write("secret2.json", secret2)
+	foreign, err := other.Shares[3].MarshalBinary()
+	require.NoError(t, err)
+	write("foreign.dat", foreign)
+	sealedData, err := sealed.Seal(secret1, []byte("backup"), sealed.KDFParams{Algorithm: sealed.KDFScrypt, N: sealed.MinScryptN, R: 8, P: 1})
+	require.NoError(t, err)
+	write("secret1.sealed", sealedData)
+	write("notes.txt", []byte("not a key"))
+
+	files, err := Discover(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 5)
+	fp := manifest.Fingerprint(group.Public.GroupKey.ToEd25519())
+
+	byName := make(map[string]ShareFile)
+	for _, f := range files {
+		byName[filepath.Base(f.Path)] = f
+	}
+	assert.Equal(t, fp, byName["public.json"].Group)
+	assert.True(t, byName["secret1.dat"].Verified)
+	assert.Equal(t, fp, byName["secret1.dat"].Group)
+	assert.True(t, byName["secret2.json"].Verified)
+	assert.Equal(t, party.ID(2), byName["secret2.json"].ID)
+	assert.False(t, byName["foreign.dat"].Verified)
+	assert.Equal(t, KindSealed, byName["secret1.sealed"].Kind)
+
+	assert.True(t, CheckBackup(filepath.Join(dir, "secret2.json"), group.Shares[2], nil, time.Now()).Verified)
+	assert.True(t, CheckBackup(filepath.Join(dir, "secret1.sealed"), group.Shares[1], []byte("backup"), time.Now()).Verified)
+	assert.False(t, CheckBackup(filepath.Join(dir, "secret1.sealed"), group.Shares[1], nil, time.Now()).Verified)
+	assert.False(t, CheckBackup(filepath.Join(dir, "secret1.sealed"), group.Shares[1], []byte("wrong"), time.Now()).Verified)
+	assert.False(t, CheckBackup(filepath.Join(dir, "secret1.dat"), group.Shares[2], nil, time.Now()).Verified)
+	assert.False(t, CheckBackup(filepath.Join(dir, "public.json"), group.Shares[1], nil, time.Now()).Verified)
+	assert.NotEmpty(t, CheckBackup(filepath.Join(dir, "missing"), group.Shares[1], nil, time.Now()).Error)
+}
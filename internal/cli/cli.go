@@ -0,0 +1,94 @@
+// Package cli holds the handling of passphrases and secret files shared by
+// the commands: secret shares and states are written sealed with a
+// passphrase or wrapped with a key of a keywrap.Wrapper, and opened with
+// either when they are read back.
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bartke/frost/keywrap"
+	"github.com/bartke/frost/sealed"
+)
+
+// ReadPassphrase returns the passphrase stored in file, or prompts for it on
+// stderr and reads it from stdin if prompt is set. It returns nil if neither
+// is set.
+func ReadPassphrase(file string, prompt bool) ([]byte, error) {
+	var data []byte
+	switch {
+	case file != "":
+		var err error
+		if data, err = os.ReadFile(file); err != nil {
+			return nil, err
+		}
+	case prompt:
+		fmt.Fprint(os.Stderr, "Passphrase: ")
+		line, err := bufio.NewReader(os.Stdin).ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		data = line
+	default:
+		return nil, nil
+	}
+	data = bytes.TrimRight(data, "\r\n")
+	if len(data) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	return data, nil
+}
+
+// Secrets writes and reads the files holding secrets.
+type Secrets struct {
+	// Passphrase, if set, seals the files written, and opens sealed files.
+	Passphrase []byte
+	// Wrapper, if set, wraps the files written instead of the passphrase, and
+	// opens wrapped files.
+	Wrapper keywrap.Wrapper
+}
+
+// Write writes data to filename, readable by the owner only, and wrapped
+// with the wrapper, or sealed with the passphrase, if either is set.
+func (s *Secrets) Write(filename string, data []byte) error {
+	var err error
+	switch {
+	case s.Wrapper != nil:
+		if data, err = keywrap.Seal(context.Background(), data, s.Wrapper); err != nil {
+			return err
+		}
+	case s.Passphrase != nil:
+		if data, err = sealed.Seal(data, s.Passphrase, sealed.DefaultArgon2id); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(filename, data, 0600)
+}
+
+// Read reads filename, and opens it with the wrapper if it is wrapped, or
+// with the passphrase if it is sealed.
+func (s *Secrets) Read(filename string) ([]byte, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case keywrap.IsWrapped(data):
+		if s.Wrapper == nil {
+			return nil, fmt.Errorf("%s is wrapped, use --wrap", filename)
+		}
+		return keywrap.Open(context.Background(), data, s.Wrapper)
+	case sealed.IsSealed(data):
+		if s.Passphrase == nil {
+			return nil, fmt.Errorf("%s is sealed, use --passphrase-file or --prompt", filename)
+		}
+		return sealed.Open(data, s.Passphrase)
+	}
+	return data, nil
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bartke/frost/keywrap"
+	"github.com/bartke/frost/sealed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPassphrase(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "passphrase")
+	require.NoError(t, os.WriteFile(file, []byte("correct horse\r\n"), 0600))
+	passphrase, err := ReadPassphrase(file, false)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("correct horse"), passphrase)
+
+	passphrase, err = ReadPassphrase("", false)
+	require.NoError(t, err)
+	assert.Nil(t, passphrase)
+
+	require.NoError(t, os.WriteFile(file, []byte("\n"), 0600))
+	_, err = ReadPassphrase(file, false)
+	assert.Error(t, err)
+}
+
+func TestSecrets(t *testing.T) {
+	identity, err := keywrap.GenerateAgeIdentity()
+	require.NoError(t, err)
+	data := []byte("secret share")
+
+	for name, secrets := range map[string]*Secrets{
+		"plain":      {},
+		"passphrase": {Passphrase: []byte("passphrase")},
+		"wrapper":    {Wrapper: identity},
+	} {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "secret")
+			require.NoError(t, secrets.Write(file, data))
+			info, err := os.Stat(file)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+			stored, err := os.ReadFile(file)
+			require.NoError(t, err)
+			assert.Equal(t, secrets.Passphrase != nil && secrets.Wrapper == nil, sealed.IsSealed(stored))
+			assert.Equal(t, secrets.Wrapper != nil, keywrap.IsWrapped(stored))
+
+			read, err := secrets.Read(file)
+			require.NoError(t, err)
+			assert.Equal(t, data, read)
+
+			if name != "plain" {
+				_, err = (&Secrets{}).Read(file)
+				assert.Error(t, err, "without the passphrase or wrapper")
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Package vartime holds the switch between the constant time and the
+// variable time implementations of the group operations, which
+// frost.AllowVarTime sets, and the operations of package ristretto that
+// choose between them. Package curve consults it as well.
+//
+// Only operations on public values, such as the verification of signatures,
+// signature shares and proofs, have variable time implementations to choose
+// from: operations on secrets always run in constant time.
+package vartime
+
+import (
+	"sync/atomic"
+
+	"github.com/bartke/frost/ristretto"
+)
+
+var allowed atomic.Bool
+
+// Allow sets whether the operations on public values may run in variable
+// time, and returns the previous setting.
+func Allow(allow bool) bool {
+	return allowed.Swap(allow)
+}
+
+// Allowed returns true if the operations on public values may run in
+// variable time.
+func Allowed() bool {
+	return allowed.Load()
+}
+
+// Timing is the timing of an operation, as listed by frost.TimingReport and
+// curve.TimingReport.
+type Timing struct {
+	// Operation names the operation and where it is used.
+	Operation string
+	// Secret is set if the operation handles secrets, such as nonces or shares.
+	Secret bool
+	// ConstantTime is set if the operation runs in constant time with the
+	// current setting of Allow.
+	ConstantTime bool
+}
+
+// DoubleScalarBaseMult sets e = [a]A + [b]B, where B is the canonical
+// generator, and returns e.
+func DoubleScalarBaseMult(e *ristretto.Element, a *ristretto.Scalar, A *ristretto.Element, b *ristretto.Scalar) *ristretto.Element {
+	if Allowed() {
+		return e.VarTimeDoubleScalarBaseMult(a, A, b)
+	}
+	return e.DoubleScalarBaseMult(a, A, b)
+}
+
+// MultiScalarMult sets e = ∑ [s[i]]p[i], and returns e.
+func MultiScalarMult(e *ristretto.Element, s []*ristretto.Scalar, p []*ristretto.Element) *ristretto.Element {
+	if Allowed() {
+		return e.VarTimeMultiScalarMult(s, p)
+	}
+	return e.MultiScalarMult(s, p)
+}
@@ -0,0 +1,185 @@
+// Package jose signs JSON Web Signatures and JSON Web Tokens with the group
+// key, as RFC 8037 EdDSA tokens that any JOSE library verifies with the JWK
+// of the group, so that an identity provider can keep its token signing key
+// under threshold control.
+//
+// Tokens are signed through a Signer, such as a frost.SignerAdapter, which runs
+// the two rounds of the protocol with the signers reached through its
+// transport. For signing in separate steps, SigningInput returns the bytes the
+// group signs, and Attach adds the signature.
+package jose
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bartke/frost/eddsa"
+)
+
+// Algorithm is the JWS algorithm of Ed25519 signatures, RFC 8037.
+const Algorithm = "EdDSA"
+
+// ErrInvalidSignature is returned by Verify if the token is not signed by the key.
+var ErrInvalidSignature = errors.New("jose: invalid signature")
+
+var b64 = base64.RawURLEncoding
+
+// JWK is an RFC 8037 OKP JSON Web Key for an Ed25519 public key.
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+}
+
+// NewJWK returns the JWK of pub, identified by its RFC 7638 thumbprint.
+func NewJWK(pub ed25519.PublicKey) JWK {
+	x := b64.EncodeToString(pub)
+	// the thumbprint is computed over the required members in lexicographic order
+	thumbprint := sha256.Sum256([]byte(`{"crv":"Ed25519","kty":"OKP","x":"` + x + `"}`))
+	return JWK{
+		Kty: "OKP",
+		Crv: "Ed25519",
+		X:   x,
+		Kid: b64.EncodeToString(thumbprint[:]),
+		Use: "sig",
+		Alg: Algorithm,
+	}
+}
+
+// PublicKey returns the Ed25519 public key of k.
+func (k JWK) PublicKey() (ed25519.PublicKey, error) {
+	if k.Kty != "OKP" || k.Crv != "Ed25519" {
+		return nil, fmt.Errorf("jose: unsupported key type %s/%s", k.Kty, k.Crv)
+	}
+	pub, err := b64.DecodeString(k.X)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, errors.New("jose: malformed Ed25519 key")
+	}
+	return pub, nil
+}
+
+// Header is the protected header of a compact JWS.
+type Header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// SigningInput returns the JWS signing input "header.payload" that the group
+// signs. An empty header.Alg is set to EdDSA, other algorithms are rejected.
+func SigningInput(header Header, payload []byte) (string, error) {
+	if header.Alg == "" {
+		header.Alg = Algorithm
+	}
+	if header.Alg != Algorithm {
+		return "", fmt.Errorf("jose: unsupported algorithm %q", header.Alg)
+	}
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("jose: %w", err)
+	}
+	return b64.EncodeToString(h) + "." + b64.EncodeToString(payload), nil
+}
+
+// ParseSigningInput returns the header and payload of a JWS signing input, e.g.
+// for a signer to check what it is asked to sign.
+func ParseSigningInput(input string) (Header, []byte, error) {
+	var header Header
+	parts := strings.Split(input, ".")
+	if len(parts) != 2 {
+		return header, nil, errors.New("jose: not a JWS signing input")
+	}
+	h, err := b64.DecodeString(parts[0])
+	if err != nil {
+		return header, nil, fmt.Errorf("jose: header: %w", err)
+	}
+	if err := json.Unmarshal(h, &header); err != nil {
+		return header, nil, fmt.Errorf("jose: header: %w", err)
+	}
+	if header.Alg != Algorithm {
+		return header, nil, fmt.Errorf("jose: unsupported algorithm %q", header.Alg)
+	}
+	payload, err := b64.DecodeString(parts[1])
+	if err != nil {
+		return header, nil, fmt.Errorf("jose: payload: %w", err)
+	}
+	return header, payload, nil
+}
+
+// Attach returns the compact JWS of input with sig, the Ed25519 signature of
+// the group over it.
+func Attach(input string, sig []byte) string {
+	return input + "." + b64.EncodeToString(sig)
+}
+
+// Signer signs messages with the group key. *frost.SignerAdapter implements it.
+type Signer interface {
+	Public() crypto.PublicKey
+	SignContext(ctx context.Context, message []byte) (*eddsa.Signature, error)
+}
+
+// Sign returns the compact JWS of payload with header, signed by s.
+func Sign(ctx context.Context, s Signer, header Header, payload []byte) (string, error) {
+	pub, ok := s.Public().(ed25519.PublicKey)
+	if !ok {
+		return "", errors.New("jose: signer has no Ed25519 key")
+	}
+	input, err := SigningInput(header, payload)
+	if err != nil {
+		return "", err
+	}
+	sig, err := s.SignContext(ctx, []byte(input))
+	if err != nil {
+		return "", fmt.Errorf("jose: %w", err)
+	}
+	token := Attach(input, sig.ToEd25519())
+	if _, _, err := Verify(token, pub); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// SignJWT returns a JWT of claims signed by s, with the JWK thumbprint of its
+// key as kid.
+func SignJWT(ctx context.Context, s Signer, claims interface{}) (string, error) {
+	pub, ok := s.Public().(ed25519.PublicKey)
+	if !ok {
+		return "", errors.New("jose: signer has no Ed25519 key")
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("jose: %w", err)
+	}
+	return Sign(ctx, s, Header{Alg: Algorithm, Kid: NewJWK(pub).Kid, Typ: "JWT"}, payload)
+}
+
+// Verify checks that token is a compact JWS signed with pub, and returns its
+// header and payload. Claims such as exp are not checked.
+func Verify(token string, pub ed25519.PublicKey) (Header, []byte, error) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return Header{}, nil, errors.New("jose: not a compact JWS")
+	}
+	header, payload, err := ParseSigningInput(token[:i])
+	if err != nil {
+		return Header{}, nil, err
+	}
+	sig, err := b64.DecodeString(token[i+1:])
+	if err != nil || len(sig) != ed25519.SignatureSize || len(pub) != ed25519.PublicKeySize {
+		return Header{}, nil, ErrInvalidSignature
+	}
+	if !ed25519.Verify(pub, []byte(token[:i]), sig) {
+		return Header{}, nil, ErrInvalidSignature
+	}
+	return header, payload, nil
+}
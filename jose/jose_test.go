@@ -0,0 +1,122 @@
+package jose
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/frostclient"
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSigner returns a SignerAdapter whose transport reaches in-process signers of group.
+func newSigner(t *testing.T, group *frostclient.Group) *frost.SignerAdapter {
+	var mu sync.Mutex
+	states := make(map[party.ID]*frost.SignerState)
+	transport := func(_ context.Context, id party.ID, message []byte, msgs []*frost.Message) (*frost.Message, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if msgs == nil {
+			msg, state, err := frost.SignInit(group.Signers, group.Shares[id], group.Public, message)
+			if err != nil {
+				return nil, err
+			}
+			states[id] = state
+			return msg, nil
+		}
+		msg, _, err := frost.SignRound1(states[id], msgs)
+		return msg, err
+	}
+	s, err := frost.NewSignerAdapter(group.Signers, group.Public, transport)
+	require.NoError(t, err)
+	return s
+}
+
+func TestSignJWT(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	s := newSigner(t, group)
+	pub := s.Public().(ed25519.PublicKey)
+
+	claims := map[string]interface{}{"iss": "https://idp.example.com", "sub": "alice", "exp": 1900000000}
+	token, err := SignJWT(context.Background(), s, claims)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(token, "."), 3)
+
+	header, payload, err := Verify(token, pub)
+	require.NoError(t, err)
+	assert.Equal(t, Header{Alg: "EdDSA", Kid: NewJWK(pub).Kid, Typ: "JWT"}, header)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	assert.Equal(t, "alice", decoded["sub"])
+
+	// the JWK of the group verifies the token
+	jwk, err := NewJWK(pub).PublicKey()
+	require.NoError(t, err)
+	_, _, err = Verify(token, jwk)
+	assert.NoError(t, err)
+
+	// a token altered, or verified with another key, is rejected
+	i := strings.IndexByte(token, '.')
+	forged, err := SigningInput(header, []byte(`{"sub":"root"}`))
+	require.NoError(t, err)
+	_, _, err = Verify(forged+token[strings.LastIndexByte(token, '.'):], pub)
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+	other, err := frostclient.CreateGroup(frostclient.Config{N: 2, T: 1})
+	require.NoError(t, err)
+	_, _, err = Verify(token, other.Public.GroupKey.ToEd25519())
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+	_, _, err = Verify(token[:i], pub)
+	assert.Error(t, err)
+}
+
+func TestSigningInput(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 2})
+	require.NoError(t, err)
+	pub := ed25519.PublicKey(group.Public.GroupKey.ToEd25519())
+
+	// signed in a separate step
+	input, err := SigningInput(Header{Typ: "JWT"}, []byte(`{"sub":"bob"}`))
+	require.NoError(t, err)
+	header, payload, err := ParseSigningInput(input)
+	require.NoError(t, err)
+	assert.Equal(t, Header{Alg: "EdDSA", Typ: "JWT"}, header)
+	assert.Equal(t, `{"sub":"bob"}`, string(payload))
+
+	sig, err := frostclient.Sign(context.Background(), group, []byte(input))
+	require.NoError(t, err)
+	_, _, err = Verify(Attach(input, sig.ToEd25519()), pub)
+	assert.NoError(t, err)
+
+	_, err = SigningInput(Header{Alg: "HS256"}, nil)
+	assert.Error(t, err)
+	_, _, err = ParseSigningInput("eyJhbGciOiJIUzI1NiJ9.e30")
+	assert.Error(t, err)
+}
+
+// TestRFC8037 checks the JWK thumbprint and signature of the examples of RFC 8037, appendix A.
+func TestRFC8037(t *testing.T) {
+	seed, err := b64.DecodeString("nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A")
+	require.NoError(t, err)
+	key := ed25519.NewKeyFromSeed(seed)
+	pub := key.Public().(ed25519.PublicKey)
+
+	jwk := NewJWK(pub)
+	assert.Equal(t, "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo", jwk.X)
+	assert.Equal(t, "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k", jwk.Kid)
+
+	input, err := SigningInput(Header{}, []byte("Example of Ed25519 signing"))
+	require.NoError(t, err)
+	assert.Equal(t, "eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc", input)
+	token := Attach(input, ed25519.Sign(key, []byte(input)))
+	assert.Equal(t, input+".hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg", token)
+	_, _, err = Verify(token, pub)
+	assert.NoError(t, err)
+}
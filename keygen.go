@@ -1,10 +1,12 @@
 package frost
 
 import (
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 
 	"github.com/bartke/frost/eddsa"
 	"github.com/bartke/frost/party"
@@ -22,6 +24,30 @@ type KeygenState struct {
 	Secret         ristretto.Scalar
 	Commitments    map[party.ID]*polynomial.Exponent
 	CommitmentsSum *polynomial.Exponent
+	// SessionID is the session the state belongs to, see KeygenInitWithSession.
+	SessionID SessionID
+	// ProofContext is bound into the proofs of knowledge together with the
+	// SessionID, see KeygenInitWithProofContext.
+	ProofContext []byte
+	// Identities are the identity keys registered with RegisterIdentities.
+	Identities Identities
+	// Received records the parties whose KeyGen1 and KeyGen2 messages were processed.
+	Received Received
+	// Observer, if set, is notified of the progress of the rounds. It is not serialized.
+	Observer Observer
+	// Logger, if set, logs the progress of the rounds at debug level. It is not serialized.
+	Logger *slog.Logger
+	// Workers bounds the goroutines KeygenRound2 checks the shares and
+	// computes the public shares on, runtime.GOMAXPROCS(0) if it is zero. It
+	// is not serialized.
+	Workers int
+}
+
+// Zeroize overwrites the secret polynomial and the share being accumulated
+// with 0. KeygenRound2 calls it once the secret share is returned.
+func (s *KeygenState) Zeroize() {
+	s.Polynomial.Zeroize()
+	s.Secret.Set(ristretto.NewScalar())
 }
 
 func (s *KeygenState) MarshalJSON() ([]byte, error) {
@@ -48,6 +74,10 @@ func (s *KeygenState) MarshalJSON() ([]byte, error) {
 		Secret         string            `json:"secret"`
 		Commitments    map[string]string `json:"commitments"`
 		CommitmentsSum string            `json:"commitments_sum"`
+		Session        string            `json:"session,omitempty"`
+		ProofContext   []byte            `json:"proof_context,omitempty"`
+		Identities     Identities        `json:"identities,omitempty"`
+		Received       Received          `json:"received,omitempty"`
 	}{
 		ID:         base64.StdEncoding.EncodeToString(idBytes),
 		PartyIDs:   s.PartyIDs,
@@ -66,6 +96,10 @@ func (s *KeygenState) MarshalJSON() ([]byte, error) {
 			return aux
 		}(),
 		CommitmentsSum: base64.StdEncoding.EncodeToString(csumbytes),
+		Session:        s.SessionID.encode(),
+		ProofContext:   s.ProofContext,
+		Identities:     s.Identities,
+		Received:       s.Received,
 	})
 }
 
@@ -78,6 +112,10 @@ func (s *KeygenState) UnmarshalJSON(data []byte) error {
 		Secret         string            `json:"secret"`
 		Commitments    map[string]string `json:"commitments"`
 		CommitmentsSum string            `json:"commitments_sum"`
+		Session        string            `json:"session,omitempty"`
+		ProofContext   []byte            `json:"proof_context,omitempty"`
+		Identities     Identities        `json:"identities,omitempty"`
+		Received       Received          `json:"received,omitempty"`
 	}{}
 
 	if err := json.Unmarshal(data, aux); err != nil {
@@ -143,60 +181,220 @@ func (s *KeygenState) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	return nil
+	if aux.Identities != nil {
+		if err := aux.Identities.validate(s.PartyIDs); err != nil {
+			return err
+		}
+		s.Identities = aux.Identities
+	}
+
+	s.Received = aux.Received
+	s.ProofContext = aux.ProofContext
+
+	return s.SessionID.decode(aux.Session)
 }
 
 // KeygenInit initializing participants.
 func KeygenInit(selfID party.ID, n, t party.Size) (*Message, *KeygenState, error) {
+	return keygenInit(SessionID{}, nil, selfID, n, t, rand.Reader)
+}
+
+// KeygenInitWithRand is KeygenInit with the secret, polynomial and proof of the
+// party sampled from rng, or crypto/rand if it is nil. A fixed rng makes the
+// key generation reproducible for tests and fuzzers; it must never be used for
+// a production group.
+func KeygenInitWithRand(selfID party.ID, n, t party.Size, rng io.Reader) (*Message, *KeygenState, error) {
+	if rng == nil {
+		rng = rand.Reader
+	}
+	return keygenInit(SessionID{}, nil, selfID, n, t, rng)
+}
+
+// KeygenInitWithSession is KeygenInit for the session with the given ID, which
+// all parties must have agreed on. The messages of the session carry the ID,
+// and KeygenRound1 and KeygenRound2 reject messages of other sessions. The ID
+// is also the context of the proof of knowledge of the secret, so that proofs
+// cannot be replayed in another session either.
+func KeygenInitWithSession(session SessionID, selfID party.ID, n, t party.Size) (*Message, *KeygenState, error) {
+	return keygenInit(session, nil, selfID, n, t, rand.Reader)
+}
+
+// MaxProofContextSize is the maximum length of the context of
+// KeygenInitWithProofContext.
+const MaxProofContextSize = 1024
+
+// KeygenInitWithProofContext is KeygenInitWithSession with the proofs of
+// knowledge of the secrets also bound to proofContext, such as the ID of the
+// ceremony, the name of the application and the time it was scheduled at.
+// All parties must pass the same context, which is not sent with the
+// messages, and KeygenRound1 rejects proofs made for any other. Without it,
+// the proofs are bound to the session ID alone, which is all zero for
+// sessions without one, so that a KeyGen1 message of one such ceremony can be
+// replayed into another.
+func KeygenInitWithProofContext(session SessionID, proofContext []byte, selfID party.ID, n, t party.Size) (*Message, *KeygenState, error) {
+	if len(proofContext) > MaxProofContextSize {
+		return nil, nil, fmt.Errorf("KeygenInitWithProofContext: context of %d bytes, at most %d are allowed", len(proofContext), MaxProofContextSize)
+	}
+	return keygenInit(session, append([]byte(nil), proofContext...), selfID, n, t, rand.Reader)
+}
+
+// KeygenProofContext returns the context the proofs of knowledge of a key
+// generation in session with the given proof context are bound to: the
+// session ID followed by the proof context. It lets third parties verify the
+// proofs of the KeyGen1 messages with zk.Schnorr.Verify.
+func KeygenProofContext(session SessionID, proofContext []byte) []byte {
+	ctx := make([]byte, 0, len(session)+len(proofContext))
+	ctx = append(ctx, session[:]...)
+	return append(ctx, proofContext...)
+}
+
+// RegisterIdentities registers the identity keys of all parties, which must
+// have been exchanged over an authenticated channel beforehand. The Public that
+// the key generation results in holds them, so that the parties can seal the
+// messages of later sessions in Envelopes. It must be called right after the
+// state was initialized, and the messages of the key generation itself should
+// be sealed with the same keys.
+func (s *KeygenState) RegisterIdentities(identities Identities) error {
+	if err := identities.validate(s.PartyIDs); err != nil {
+		return fmt.Errorf("RegisterIdentities: %w", err)
+	}
+	if len(identities) != len(s.PartyIDs) {
+		return fmt.Errorf("RegisterIdentities: got %d identities for %d parties", len(identities), len(s.PartyIDs))
+	}
+	s.Identities = identities
+	return nil
+}
+
+// MinSeedSize is the minimum length of the seed of KeygenInitDeterministic.
+const MinSeedSize = 32
+
+// KeygenInitDeterministic is KeygenInit with all randomness of the party derived
+// from seed, using HKDF-SHA512 over the seed, the party ID and context, which
+// is extended with a block counter for thresholds needing more than one HKDF
+// output.
+//
+// Running the key generation again with the same seeds, IDs and context reproduces
+// the exact same messages, shares and group key. This is meant for tests, audits,
+// and recovery schemes where the seeds are backed up; the seed must be kept as
+// secret as the share itself, and must never be reused for a different group.
+func KeygenInitDeterministic(selfID party.ID, n, t party.Size, seed, context []byte) (*Message, *KeygenState, error) {
+	if len(seed) < MinSeedSize {
+		return nil, nil, fmt.Errorf("KeygenInitDeterministic: seed must be at least %d bytes", MinSeedSize)
+	}
+	info := make([]byte, 0, party.IDByteSize+len(context))
+	info = append(info, selfID.Bytes()...)
+	info = append(info, context...)
+	rng := newSeedStream(seed, []byte("FROST-KEYGEN-SEED-v1"), info)
+	return keygenInit(SessionID{}, nil, selfID, n, t, rng)
+}
+
+// KeygenInitWithIDs is KeygenInit for the parties ids instead of 1..n, so that
+// parties can keep stable IDs, such as ones derived from their host names,
+// across key generations. The IDs must be distinct and non-zero, include
+// selfID, and be the same for all parties. The threshold t must be less than
+// the number of parties.
+func KeygenInitWithIDs(selfID party.ID, ids party.IDSlice, t party.Size) (*Message, *KeygenState, error) {
+	partyIDs := ids.Sorted()
+	if err := partyIDs.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("KeygenInitWithIDs: %w", err)
+	}
+	return keygenInitWithIDs(SessionID{}, nil, selfID, partyIDs, t, rand.Reader)
+}
+
+// keygenInit is KeygenInitWithProofContext with the secret, polynomial and proof sampled from rng.
+func keygenInit(session SessionID, proofContext []byte, selfID party.ID, n, t party.Size, rng io.Reader) (*Message, *KeygenState, error) {
 	partyIDs := make([]party.ID, 0, n)
 	for i := party.ID(1); i <= n; i++ {
 		partyIDs = append(partyIDs, i)
 	}
+	return keygenInitWithIDs(session, proofContext, selfID, partyIDs, t, rng)
+}
 
+// keygenInitWithIDs is keygenInit for the sorted partyIDs.
+func keygenInitWithIDs(session SessionID, proofContext []byte, selfID party.ID, partyIDs party.IDSlice, t party.Size, rng io.Reader) (*Message, *KeygenState, error) {
+	if err := partyIDs.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("KeygenInit: %w", err)
+	}
+	switch {
+	case !partyIDs.Contains(selfID):
+		return nil, nil, fmt.Errorf("KeygenInit: party %d is not one of the parties", selfID)
+	case t >= partyIDs.N():
+		return nil, nil, fmt.Errorf("KeygenInit: threshold %d requires more than %d parties", t, partyIDs.N())
+	}
+	if t > polynomial.MaxDegree {
+		// the other parties could not decode the commitments
+		return nil, nil, fmt.Errorf("KeygenInit: threshold %d exceeds the maximum of %d", t, polynomial.MaxDegree)
+	}
 	state := &KeygenState{
-		SelfID:    selfID,
-		PartyIDs:  partyIDs,
-		Threshold: t,
+		SelfID:       selfID,
+		PartyIDs:     partyIDs,
+		Threshold:    t,
+		Commitments:  make(map[party.ID]*polynomial.Exponent, len(partyIDs)),
+		SessionID:    session,
+		ProofContext: proofContext,
 	}
 
-	scalar.SetScalarRandom(&state.Secret)
+	scalar.SetScalarRandomFrom(&state.Secret, rng)
 
-	state.Polynomial = polynomial.NewPolynomial(t, &state.Secret)
+	state.Polynomial = polynomial.NewPolynomialFrom(t, &state.Secret, rng)
 	state.CommitmentsSum = polynomial.NewPolynomialExponent(state.Polynomial)
 
-	ctx := make([]byte, 32) // context to prevent replay attacks
 	public := state.CommitmentsSum.Constant()
-	proof := zk.NewSchnorrProof(selfID, public, ctx, &state.Secret)
+	proof := zk.NewSchnorrProofFrom(selfID, public, KeygenProofContext(state.SessionID, state.ProofContext), &state.Secret, rng)
 
 	// We use the variable Secret to hold the sum of all shares received.
 	// Therefore, we can set it to the share we would send to our selves.
 	state.Secret.Set(state.Polynomial.Evaluate(selfID.Scalar()))
 
-	return NewKeyGen1(selfID, proof, state.CommitmentsSum), state, nil
+	// CommitmentsSum is updated in place by later rounds, so we send a copy.
+	msg := NewKeyGen1(selfID, proof, state.CommitmentsSum.Copy())
+	msg.SessionID = session
+	return msg, state, nil
 }
 
-// KeygenRound1 generates KeyGen2 messages.
-func KeygenRound1(state *KeygenState, inputMsgs []*Message) ([]*Message, *KeygenState, error) {
+// KeygenRound1 processes KeyGen1 messages, and generates the KeyGen2
+// messages once those of all other parties were processed. It can be called
+// with the messages as they arrive, and returns an error wrapping
+// ErrNeedMoreMessages until then.
+func KeygenRound1(state *KeygenState, inputMsgs []*Message) (_ []*Message, _ *KeygenState, err error) {
+	obs := observeRound(state.Observer, state.Logger, state.SelfID, state.Received, MessageTypeKeyGen1)
+	defer func() { obs.done(err) }()
+
+	proofContext := KeygenProofContext(state.SessionID, state.ProofContext)
+
 	// process KeyGen1 messages
 	for _, msg := range inputMsgs {
 		id := msg.From
 		if id == state.SelfID {
 			continue
 		}
+		obs.from = id
 
-		if msg.Type != MessageTypeKeyGen1 {
-			return nil, nil, errors.New("invalid message type for round 1")
+		if err := msg.Validate(MessageTypeKeyGen1, state.SessionID, state.SelfID); err != nil {
+			return nil, nil, fmt.Errorf("KeygenRound1: %w", err)
+		}
+		if !state.PartyIDs.Contains(id) {
+			return nil, nil, fmt.Errorf("KeygenRound1: %w", &ErrUnknownParty{ID: id})
+		}
+		if degree := msg.KeyGen1.Commitments.Degree(); degree != state.Threshold {
+			return nil, nil, fmt.Errorf("KeygenRound1: polynomial of party %d has degree %d, expected %d: %w", id, degree, state.Threshold, ErrInvalidMessage)
 		}
 
 		public := msg.KeyGen1.Commitments.Constant()
-		ctx := make([]byte, 32)
-
-		if !msg.KeyGen1.Proof.Verify(id, public, ctx) {
-			return nil, nil, errors.New("ZK Schnorr verification failed")
+		if !msg.KeyGen1.Proof.Verify(id, public, proofContext) {
+			return nil, nil, fmt.Errorf("KeygenRound1: Schnorr proof of party %d: %w", id, ErrProofFailed)
 		}
 
+		if err := state.Received.add(MessageTypeKeyGen1, id); err != nil {
+			return nil, nil, fmt.Errorf("KeygenRound1: %w", err)
+		}
 		state.Commitments[id] = msg.KeyGen1.Commitments
 		state.CommitmentsSum.Add(msg.KeyGen1.Commitments)
+		obs.received(msg)
+	}
+	obs.from = 0
+	if err := state.Received.complete(MessageTypeKeyGen1, state.PartyIDs, state.SelfID); err != nil {
+		return nil, nil, fmt.Errorf("KeygenRound1: %w", err)
 	}
 
 	// generate KeyGen2 messages
@@ -208,6 +406,7 @@ func KeygenRound1(state *KeygenState, inputMsgs []*Message) ([]*Message, *Keygen
 
 		share := state.Polynomial.Evaluate(id.Scalar())
 		keygen2 := NewKeyGen2(state.SelfID, id, share)
+		keygen2.SessionID = state.SessionID
 		msgsOut = append(msgsOut, keygen2)
 	}
 
@@ -216,39 +415,75 @@ func KeygenRound1(state *KeygenState, inputMsgs []*Message) ([]*Message, *Keygen
 	return msgsOut, state, nil
 }
 
-// KeygenRound2 generates public and secret keys.
-func KeygenRound2(state *KeygenState, inputMsgs []*Message) (*eddsa.Public, *eddsa.SecretShare, error) {
-	// process KeyGen2 messages
-	for _, msg := range inputMsgs {
-		if msg.Type != MessageTypeKeyGen2 {
-			return nil, nil, errors.New("invalid message type for round 2")
+// KeygenRound2 generates public and secret keys. The secrets of state are
+// zeroized once the secret share is computed. Like KeygenRound1, it can be
+// called with the messages as they arrive.
+//
+// The shares are checked against the commitments, and the public shares of
+// all parties computed, on up to state.Workers goroutines.
+func KeygenRound2(state *KeygenState, inputMsgs []*Message) (_ *eddsa.Public, _ *eddsa.SecretShare, err error) {
+	obs := observeRound(state.Observer, state.Logger, state.SelfID, state.Received, MessageTypeKeyGen2)
+	defer func() { obs.done(err) }()
+
+	// check the shares against the commitments in parallel, the messages are
+	// then validated and processed in order as before
+	valid := make([]bool, len(inputMsgs))
+	forEach(state.Workers, len(inputMsgs), func(i int) {
+		msg := inputMsgs[i]
+		if msg.From == state.SelfID || msg.Type != MessageTypeKeyGen2 || msg.KeyGen2 == nil {
+			return
+		}
+		commitments, ok := state.Commitments[msg.From]
+		if !ok {
+			return
 		}
+		var computedShareExp ristretto.Element
+		computedShareExp.ScalarBaseMult(&msg.KeyGen2.Share)
+		valid[i] = computedShareExp.Equal(commitments.EvaluateID(state.SelfID)) == 1
+	})
 
+	// process KeyGen2 messages
+	for i, msg := range inputMsgs {
 		if msg.From == state.SelfID {
 			continue
 		}
+		obs.from = msg.From
+		if err := msg.Validate(MessageTypeKeyGen2, state.SessionID, state.SelfID); err != nil {
+			return nil, nil, fmt.Errorf("KeygenRound2: %w", err)
+		}
 
 		id := msg.From
-		var computedShareExp ristretto.Element
-		computedShareExp.ScalarBaseMult(&msg.KeyGen2.Share)
-
+		if !state.PartyIDs.Contains(id) {
+			return nil, nil, fmt.Errorf("KeygenRound2: %w", &ErrUnknownParty{ID: id})
+		}
 		if _, ok := state.Commitments[id]; !ok {
-			return nil, nil, fmt.Errorf("missing commitment for party %d", id)
+			return nil, nil, fmt.Errorf("KeygenRound2: no commitment of party %d", id)
 		}
 
-		shareExp := state.Commitments[id].Evaluate(state.SelfID.Scalar())
-		if computedShareExp.Equal(shareExp) != 1 {
+		if !valid[i] {
 			// Verifiable Secret Sharing (VSS) validation failed
-			return nil, nil, errors.New("VSS validation failed")
+			return nil, nil, fmt.Errorf("KeygenRound2: share of party %d: %w", id, ErrInvalidShare)
+		}
+		if err := state.Received.add(MessageTypeKeyGen2, id); err != nil {
+			return nil, nil, fmt.Errorf("KeygenRound2: %w", err)
 		}
 
 		state.Secret.Add(&state.Secret, &msg.KeyGen2.Share)
 		// msg.KeyGen2.Share.Set(ristretto.NewScalar())
+		obs.received(msg)
+	}
+	obs.from = 0
+	if err := state.Received.complete(MessageTypeKeyGen2, state.PartyIDs, state.SelfID); err != nil {
+		return nil, nil, fmt.Errorf("KeygenRound2: %w", err)
 	}
 
-	shares := make(map[party.ID]*ristretto.Element, len(state.Commitments))
-	for _, id := range state.PartyIDs {
-		shares[id] = state.CommitmentsSum.Evaluate(id.Scalar())
+	evaluations := make([]*ristretto.Element, len(state.PartyIDs))
+	forEach(state.Workers, len(state.PartyIDs), func(i int) {
+		evaluations[i] = state.CommitmentsSum.EvaluateID(state.PartyIDs[i])
+	})
+	shares := make(map[party.ID]*ristretto.Element, len(state.PartyIDs))
+	for i, id := range state.PartyIDs {
+		shares[id] = evaluations[i]
 	}
 
 	pub := &eddsa.Public{
@@ -257,7 +492,11 @@ func KeygenRound2(state *KeygenState, inputMsgs []*Message) (*eddsa.Public, *edd
 		Shares:    shares,
 		GroupKey:  eddsa.NewPublicKeyFromPoint(state.CommitmentsSum.Constant()),
 	}
+	if state.Identities != nil {
+		pub.Identities = state.Identities
+	}
 
 	sec := eddsa.NewSecretShare(state.SelfID, &state.Secret)
+	state.Zeroize()
 	return pub, sec, nil
 }
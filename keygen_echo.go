@@ -0,0 +1,120 @@
+package frost
+
+import (
+	"crypto/sha512"
+	"errors"
+	"fmt"
+
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/polynomial"
+	"github.com/bartke/frost/ristretto"
+)
+
+// ErrCommitmentMismatch is returned by KeygenRoundEcho when another party
+// received different KeyGen1 messages, e.g. because the network or the
+// coordinator relaying them showed different commitments to different parties.
+var ErrCommitmentMismatch = errors.New("frost: parties received different KeyGen1 messages")
+
+// keygenEchoDomain separates the digests of KeyGenEcho from other uses of SHA-512.
+const keygenEchoDomain = "FROST-Ed25519 keygen echo v1"
+
+// KeygenEchoMessage returns the KeyGenEcho message of the optional echo
+// round of the key generation, to be broadcast once KeygenRound1 processed
+// the KeyGen1 messages of all parties. It commits to the commitments of all
+// parties as state received them, its own included, and to the parties, the
+// threshold and the session.
+//
+// The key generation assumes that every party receives the same KeyGen1
+// message from each party. Without the echo round, a party that is shown
+// other commitments than the rest ends up with a share of another group key,
+// or fails the check of an honest share. With it, KeygenRoundEcho names the
+// parties that received different messages before any share is released: the
+// KeyGen2 messages returned by KeygenRound1 must only be sent once it
+// returned nil.
+func KeygenEchoMessage(state *KeygenState) (*Message, error) {
+	digest, err := state.echoDigest()
+	if err != nil {
+		return nil, fmt.Errorf("KeygenEchoMessage: %w", err)
+	}
+	msg := NewKeyGenEcho(state.SelfID, &digest)
+	msg.SessionID = state.SessionID
+	return msg, nil
+}
+
+// KeygenRoundEcho checks the KeyGenEcho messages of the other parties against
+// the KeyGen1 messages state received. It must be called after KeygenRound1
+// and before KeygenRound2, and returns an error wrapping
+// ErrCommitmentMismatch with the IDs of the parties whose digest differs, or
+// an *ErrMissingParties if a party's KeyGenEcho message is missing.
+func KeygenRoundEcho(state *KeygenState, inputMsgs []*Message) (err error) {
+	// the check does not change state, so it can be repeated
+	var received Received
+	obs := observeRound(state.Observer, state.Logger, state.SelfID, received, MessageTypeKeyGenEcho)
+	defer func() { obs.done(err) }()
+
+	digest, err := state.echoDigest()
+	if err != nil {
+		return fmt.Errorf("KeygenRoundEcho: %w", err)
+	}
+	var mismatch party.IDSlice
+	for _, msg := range inputMsgs {
+		if msg.From == state.SelfID {
+			continue
+		}
+		obs.from = msg.From
+		if err := msg.Validate(MessageTypeKeyGenEcho, state.SessionID, state.SelfID); err != nil {
+			return fmt.Errorf("KeygenRoundEcho: %w", err)
+		}
+		if !state.PartyIDs.Contains(msg.From) {
+			return fmt.Errorf("KeygenRoundEcho: %w", &ErrUnknownParty{ID: msg.From})
+		}
+		if err := received.add(MessageTypeKeyGenEcho, msg.From); err != nil {
+			return fmt.Errorf("KeygenRoundEcho: %w", err)
+		}
+		if msg.KeyGenEcho.Digest != digest {
+			mismatch = append(mismatch, msg.From)
+		}
+		obs.received(msg)
+	}
+	obs.from = 0
+	if len(mismatch) > 0 {
+		return fmt.Errorf("KeygenRoundEcho: parties %v: %w", mismatch, ErrCommitmentMismatch)
+	}
+	if err := received.complete(MessageTypeKeyGenEcho, state.PartyIDs, state.SelfID); err != nil {
+		return fmt.Errorf("KeygenRoundEcho: %w", err)
+	}
+	return nil
+}
+
+// echoDigest returns the digest of KeyGenEcho for state, once the KeyGen1
+// messages of all parties were processed and before KeygenRound2 zeroized the
+// polynomial.
+func (s *KeygenState) echoDigest() ([64]byte, error) {
+	var digest [64]byte
+	if err := s.Received.complete(MessageTypeKeyGen1, s.PartyIDs, s.SelfID); err != nil {
+		return digest, err
+	}
+	if s.Polynomial == nil || s.Polynomial.Constant().Equal(ristretto.NewScalar()) == 1 {
+		return digest, errors.New("the polynomial of the state was zeroized")
+	}
+
+	h := sha512.New()
+	h.Write([]byte(keygenEchoDomain))
+	h.Write(s.SessionID[:])
+	h.Write(s.Threshold.Bytes())
+	h.Write(s.PartyIDs.N().Bytes())
+	for _, id := range s.PartyIDs {
+		commitments := s.Commitments[id]
+		if id == s.SelfID {
+			commitments = polynomial.NewPolynomialExponent(s.Polynomial)
+		}
+		data, err := commitments.MarshalBinary()
+		if err != nil {
+			return digest, err
+		}
+		h.Write(id.Bytes())
+		h.Write(data)
+	}
+	h.Sum(digest[:0])
+	return digest, nil
+}
@@ -0,0 +1,101 @@
+package frost
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keygenRound1 runs KeygenInit and KeygenRound1 for the parties 1..n, with
+// round1 altered by alter for each receiving party if it is set, and returns
+// the states and the KeyGen2 messages of every party.
+func keygenRound1(t *testing.T, n, threshold party.Size, alter func(to party.ID, round1 []*Message) []*Message) (map[party.ID]*KeygenState, map[party.ID][]*Message) {
+	states := make(map[party.ID]*KeygenState, n)
+	var round1 []*Message
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, err := KeygenInit(id, n, threshold)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	round2 := make(map[party.ID][]*Message, n)
+	for id, state := range states {
+		msgs := round1
+		if alter != nil {
+			msgs = alter(id, append([]*Message(nil), round1...))
+		}
+		out, _, err := KeygenRound1(state, msgs)
+		require.NoError(t, err)
+		round2[id] = out
+	}
+	return states, round2
+}
+
+func TestKeygenRoundEcho(t *testing.T) {
+	states, round2 := keygenRound1(t, 3, 1, nil)
+	var echoes []*Message
+	for id := party.ID(1); id <= 3; id++ {
+		msg, err := KeygenEchoMessage(states[id])
+		require.NoError(t, err)
+		echoes = append(echoes, msg)
+	}
+	for _, state := range states {
+		require.NoError(t, KeygenRoundEcho(state, echoes))
+	}
+
+	for id, state := range states {
+		var shares []*Message
+		for _, msgs := range round2 {
+			for _, msg := range msgs {
+				if msg.To == id {
+					shares = append(shares, msg)
+				}
+			}
+		}
+		_, _, err := KeygenRound2(state, shares)
+		require.NoError(t, err)
+	}
+
+	// once the polynomial is zeroized, the digest cannot be computed
+	_, err := KeygenEchoMessage(states[1])
+	assert.Error(t, err)
+}
+
+func TestKeygenRoundEcho_Mismatch(t *testing.T) {
+	// party 3 is shown other commitments of party 1 than party 2 is
+	forged, _, err := KeygenInit(1, 3, 1)
+	require.NoError(t, err)
+	states, _ := keygenRound1(t, 3, 1, func(to party.ID, round1 []*Message) []*Message {
+		if to == 3 {
+			round1[0] = forged
+		}
+		return round1
+	})
+
+	var echoes []*Message
+	for id := party.ID(1); id <= 3; id++ {
+		msg, err := KeygenEchoMessage(states[id])
+		require.NoError(t, err)
+		echoes = append(echoes, msg)
+	}
+	err = KeygenRoundEcho(states[1], echoes)
+	require.True(t, errors.Is(err, ErrCommitmentMismatch))
+	assert.Contains(t, err.Error(), "[3]")
+	err = KeygenRoundEcho(states[3], echoes)
+	require.True(t, errors.Is(err, ErrCommitmentMismatch))
+	assert.Contains(t, err.Error(), "[1 2]")
+
+	// every other party must echo, once
+	assert.True(t, errors.Is(KeygenRoundEcho(states[1], echoes[:2]), ErrNeedMoreMessages))
+	assert.Error(t, KeygenRoundEcho(states[1], []*Message{echoes[1], echoes[1]}))
+}
+
+func TestKeygenEchoMessage_Early(t *testing.T) {
+	_, state, err := KeygenInit(1, 3, 1)
+	require.NoError(t, err)
+	_, err = KeygenEchoMessage(state)
+	assert.True(t, errors.Is(err, ErrNeedMoreMessages))
+}
@@ -0,0 +1,104 @@
+package keywrap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"filippo.io/age"
+)
+
+// ProviderAge is the provider of an AgeIdentity.
+const ProviderAge = "age"
+
+// ErrAgeNoIdentity is returned when an age file is not encrypted to the identity.
+var ErrAgeNoIdentity = errors.New("age: no matching X25519 recipient")
+
+// AgeIdentity is an age X25519 identity, such as one created by age-keygen.
+// As a Wrapper, it wraps data keys in age files encrypted to its recipient,
+// which age can decrypt with the same identity.
+type AgeIdentity struct {
+	identity *age.X25519Identity
+}
+
+// GenerateAgeIdentity returns a new random identity.
+func GenerateAgeIdentity() (*AgeIdentity, error) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, err
+	}
+	return &AgeIdentity{identity: identity}, nil
+}
+
+// ParseAgeIdentity parses an identity encoded as AGE-SECRET-KEY-1...
+func ParseAgeIdentity(s string) (*AgeIdentity, error) {
+	identity, err := age.ParseX25519Identity(s)
+	if err != nil {
+		return nil, err
+	}
+	return &AgeIdentity{identity: identity}, nil
+}
+
+// ReadAgeIdentityFile returns the first X25519 identity of file, in the
+// format written by age-keygen, whose lines starting with # are comments.
+func ReadAgeIdentityFile(file string) (*AgeIdentity, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for _, identity := range identities {
+		if identity, ok := identity.(*age.X25519Identity); ok {
+			return &AgeIdentity{identity: identity}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: no age X25519 identity", file)
+}
+
+// String returns the identity encoded as AGE-SECRET-KEY-1...
+func (i *AgeIdentity) String() string { return i.identity.String() }
+
+// Recipient returns the recipient of the identity, encoded as age1...
+func (i *AgeIdentity) Recipient() string { return i.identity.Recipient().String() }
+
+func (i *AgeIdentity) Provider() string { return ProviderAge }
+
+// KeyID returns the recipient of the identity.
+func (i *AgeIdentity) KeyID() string { return i.Recipient() }
+
+// WrapKey returns an age file of key encrypted to the recipient of i.
+func (i *AgeIdentity) WrapKey(_ context.Context, key []byte) ([]byte, error) {
+	var out bytes.Buffer
+	w, err := age.Encrypt(&out, i.identity.Recipient())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(key); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// UnwrapKey decrypts an age file created by WrapKey, or by age for the
+// recipient of i.
+func (i *AgeIdentity) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	r, err := age.Decrypt(bytes.NewReader(wrapped), i.identity)
+	var noMatch *age.NoIdentityMatchError
+	if errors.As(err, &noMatch) {
+		return nil, ErrAgeNoIdentity
+	}
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
@@ -0,0 +1,108 @@
+package keywrap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgeIdentity(t *testing.T) {
+	identity, err := GenerateAgeIdentity()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(identity.String(), "AGE-SECRET-KEY-1"))
+	assert.True(t, strings.HasPrefix(identity.Recipient(), "age1"))
+
+	parsed, err := ParseAgeIdentity(identity.String())
+	require.NoError(t, err)
+	assert.Equal(t, identity.Recipient(), parsed.Recipient())
+
+	_, err = ParseAgeIdentity(identity.Recipient())
+	assert.Error(t, err, "a recipient is not an identity")
+
+	file := filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(file, []byte("# created: now\n# public key: "+identity.Recipient()+"\n"+identity.String()+"\n"), 0600))
+	read, err := ReadAgeIdentityFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, identity.Recipient(), read.Recipient())
+}
+
+func TestAgeEncryptDecrypt(t *testing.T) {
+	identity, err := GenerateAgeIdentity()
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for _, size := range []int{0, 32, 64 * 1024, 64*1024 + 1} {
+		plaintext := bytes.Repeat([]byte{byte(size)}, size)
+		data, err := identity.WrapKey(ctx, plaintext)
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(data, []byte("age-encryption.org/v1\n-> X25519 ")))
+
+		decrypted, err := identity.UnwrapKey(ctx, data)
+		require.NoError(t, err, size)
+		assert.True(t, bytes.Equal(plaintext, decrypted))
+	}
+
+	data, err := identity.WrapKey(ctx, []byte("data key"))
+	require.NoError(t, err)
+	_, err = identity.UnwrapKey(ctx, data[:len(data)-1])
+	assert.Error(t, err, "a truncated payload")
+
+	other, err := GenerateAgeIdentity()
+	require.NoError(t, err)
+	_, err = other.UnwrapKey(ctx, data)
+	assert.True(t, errors.Is(err, ErrAgeNoIdentity))
+}
+
+// TestAgeVectors decrypts the X25519 vectors of testdata/age, each of which
+// is a header of "name: value" lines, an empty line and an age file.
+func TestAgeVectors(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "age", "x25519*"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+			r := bufio.NewReader(bytes.NewReader(data))
+			header := map[string]string{}
+			for {
+				line, err := r.ReadString('\n')
+				require.NoError(t, err)
+				line = strings.TrimSuffix(line, "\n")
+				if line == "" {
+					break
+				}
+				name, value, ok := strings.Cut(line, ": ")
+				require.True(t, ok, line)
+				header[name] = value
+			}
+			ageFile, err := io.ReadAll(r)
+			require.NoError(t, err)
+
+			identity, err := ParseAgeIdentity(header["identity"])
+			require.NoError(t, err)
+			plaintext, err := identity.UnwrapKey(context.Background(), ageFile)
+			switch header["expect"] {
+			case "success":
+				require.NoError(t, err)
+				sum := sha256.Sum256(plaintext)
+				assert.Equal(t, header["payload"], hex.EncodeToString(sum[:]))
+			case "no match":
+				assert.True(t, errors.Is(err, ErrAgeNoIdentity), "%v", err)
+			default:
+				assert.Error(t, err)
+			}
+		})
+	}
+}
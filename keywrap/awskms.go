@@ -0,0 +1,79 @@
+package keywrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// ProviderAWSKMS is the provider of AWSKMS.
+const ProviderAWSKMS = "awskms"
+
+// encryptionContext is bound to every data key wrapped with a KMS, and shows
+// up in its audit logs.
+var encryptionContext = map[string]string{"purpose": "frost-keywrap"}
+
+// AWSKMS wraps data keys with a symmetric key of the AWS Key Management
+// Service, calling its Encrypt and Decrypt actions.
+type AWSKMS struct {
+	// Key is the key ID, ARN, alias ARN or alias/<name> of the KMS key.
+	Key string
+	// Client is the KMS client of the region of the key.
+	Client *kms.Client
+}
+
+// NewAWSKMSFromEnv returns the AWSKMS of key, with the default configuration
+// of the AWS SDK: the credentials, region and endpoint are taken from the
+// environment variables such as AWS_ACCESS_KEY_ID and AWS_REGION, the shared
+// configuration files, or the instance role. The region of the key's ARN
+// takes precedence over the configured one.
+func NewAWSKMSFromEnv(key string) (*AWSKMS, error) {
+	var opts []func(*config.LoadOptions) error
+	// arn:aws:kms:<region>:<account>:key/<id>
+	if fields := strings.Split(key, ":"); len(fields) >= 6 && fields[0] == "arn" {
+		opts = append(opts, config.WithRegion(fields[3]))
+	}
+	cfg, err := config.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("awskms: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("awskms: no region, set AWS_REGION or use the ARN of the key")
+	}
+	return &AWSKMS{Key: key, Client: kms.NewFromConfig(cfg)}, nil
+}
+
+func (k *AWSKMS) Provider() string { return ProviderAWSKMS }
+
+func (k *AWSKMS) KeyID() string { return k.Key }
+
+// WrapKey encrypts key with the KMS key.
+func (k *AWSKMS) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	out, err := k.Client.Encrypt(ctx, &kms.EncryptInput{
+		KeyId:             aws.String(k.Key),
+		Plaintext:         key,
+		EncryptionContext: encryptionContext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("awskms: %w", err)
+	}
+	return out.CiphertextBlob, nil
+}
+
+// UnwrapKey decrypts a key encrypted by WrapKey, with the KMS key only.
+func (k *AWSKMS) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	out, err := k.Client.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:             aws.String(k.Key),
+		CiphertextBlob:    wrapped,
+		EncryptionContext: encryptionContext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("awskms: %w", err)
+	}
+	return out.Plaintext, nil
+}
@@ -0,0 +1,82 @@
+package keywrap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	"google.golang.org/api/option"
+)
+
+// ProviderGCPKMS is the provider of GCPKMS.
+const ProviderGCPKMS = "gcpkms"
+
+// GCPKMS wraps data keys with a symmetric key of Google Cloud KMS, calling its
+// Encrypt and Decrypt methods.
+type GCPKMS struct {
+	// Name is the resource name of the key,
+	// projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>.
+	Name string
+	// Options configure the KMS client, e.g. option.WithCredentialsFile. By
+	// default it uses the Application Default Credentials.
+	Options []option.ClientOption
+}
+
+// NewGCPKMS returns the GCPKMS of the key name, authorized with the
+// Application Default Credentials: the file named by
+// GOOGLE_APPLICATION_CREDENTIALS, those of gcloud, or the service account of
+// the instance.
+func NewGCPKMS(name string) (*GCPKMS, error) {
+	fields := strings.Split(name, "/")
+	if len(fields) != 8 || fields[0] != "projects" || fields[2] != "locations" || fields[4] != "keyRings" || fields[6] != "cryptoKeys" {
+		return nil, fmt.Errorf("gcpkms: invalid key name %q", name)
+	}
+	return &GCPKMS{Name: name}, nil
+}
+
+func (k *GCPKMS) Provider() string { return ProviderGCPKMS }
+
+func (k *GCPKMS) KeyID() string { return k.Name }
+
+// aad is the additional authenticated data of the wrapped keys.
+func (k *GCPKMS) aad() []byte {
+	return []byte(encryptionContext["purpose"])
+}
+
+// WrapKey encrypts key with the primary version of the KMS key.
+func (k *GCPKMS) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	client, err := kms.NewKeyManagementRESTClient(ctx, k.Options...)
+	if err != nil {
+		return nil, fmt.Errorf("gcpkms: %w", err)
+	}
+	defer client.Close()
+	resp, err := client.Encrypt(ctx, &kmspb.EncryptRequest{
+		Name:                        k.Name,
+		Plaintext:                   key,
+		AdditionalAuthenticatedData: k.aad(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gcpkms: %w", err)
+	}
+	return resp.Ciphertext, nil
+}
+
+// UnwrapKey decrypts a key encrypted by WrapKey.
+func (k *GCPKMS) UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error) {
+	client, err := kms.NewKeyManagementRESTClient(ctx, k.Options...)
+	if err != nil {
+		return nil, fmt.Errorf("gcpkms: %w", err)
+	}
+	defer client.Close()
+	resp, err := client.Decrypt(ctx, &kmspb.DecryptRequest{
+		Name:                        k.Name,
+		Ciphertext:                  wrapped,
+		AdditionalAuthenticatedData: k.aad(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gcpkms: %w", err)
+	}
+	return resp.Plaintext, nil
+}
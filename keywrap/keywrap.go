@@ -0,0 +1,201 @@
+// Package keywrap implements envelope encrypted containers for secret
+// artifacts, such as secret shares, whose keys are held by a key management
+// service or an age identity instead of being derived from a passphrase.
+//
+// A container is laid out as
+//
+//	magic "FROSTWRAPPED" ∥ version (1 byte) ∥ header length (2 bytes, big endian) ∥ header ∥ ciphertext
+//
+// The header is a JSON object naming the provider and key that wrapped the
+// data key, the wrapped data key itself, and the nonce. The payload is
+// encrypted with XChaCha20-Poly1305 under a random data key, using the whole
+// prefix up to the ciphertext as additional data, so the header cannot be
+// altered. Opening a container asks the provider to unwrap the data key, so a
+// share is only ever in plaintext while it is used.
+package keywrap
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	magic   = "FROSTWRAPPED"
+	version = 1
+	keySize = chacha20poly1305.KeySize
+)
+
+// ErrDecrypt is returned by Open when the container was modified, or the data
+// key was unwrapped to the wrong key.
+var ErrDecrypt = errors.New("keywrap: corrupted container")
+
+// A Wrapper encrypts the data keys of containers with a key it holds, or has
+// access to, such as a KMS key or an age identity.
+type Wrapper interface {
+	// Provider names the kind of wrapper, e.g. "awskms".
+	Provider() string
+	// KeyID names the key that wraps the data keys. It is stored in the
+	// header for reference only.
+	KeyID() string
+	// WrapKey encrypts the data key.
+	WrapKey(ctx context.Context, key []byte) ([]byte, error)
+	// UnwrapKey decrypts a data key encrypted by WrapKey.
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// Header is the unencrypted metadata of a container.
+type Header struct {
+	Provider   string `json:"provider"`
+	KeyID      string `json:"key_id"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Cipher     string `json:"cipher"`
+	Nonce      []byte `json:"nonce"`
+}
+
+// IsWrapped returns true if data starts like a container.
+func IsWrapped(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(magic))
+}
+
+// Seal encrypts plaintext under a new data key, which w wraps.
+func Seal(ctx context.Context, plaintext []byte, w Wrapper) ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("keywrap: %w", err)
+	}
+	defer clear(key)
+
+	wrapped, err := w.WrapKey(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("keywrap: wrapping the data key with %s: %w", w.Provider(), err)
+	}
+	header := Header{
+		Provider:   w.Provider(),
+		KeyID:      w.KeyID(),
+		WrappedKey: wrapped,
+		Cipher:     "xchacha20poly1305",
+		Nonce:      make([]byte, chacha20poly1305.NonceSizeX),
+	}
+	if _, err := rand.Read(header.Nonce); err != nil {
+		return nil, fmt.Errorf("keywrap: %w", err)
+	}
+
+	headerBytes, err := json.Marshal(&header)
+	if err != nil {
+		return nil, err
+	}
+	if len(headerBytes) > 0xffff {
+		return nil, errors.New("keywrap: header too large")
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(magic)+3+len(headerBytes)+len(plaintext)+aead.Overhead())
+	out = append(out, magic...)
+	out = append(out, version)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(headerBytes)))
+	out = append(out, headerBytes...)
+	return aead.Seal(out, header.Nonce, plaintext, out), nil
+}
+
+// ReadHeader parses the header of a container without decrypting it.
+func ReadHeader(data []byte) (*Header, error) {
+	header, _, err := split(data)
+	return header, err
+}
+
+// split returns the parsed header and the length of the authenticated prefix.
+func split(data []byte) (*Header, int, error) {
+	if !IsWrapped(data) {
+		return nil, 0, errors.New("keywrap: not a wrapped container")
+	}
+	rest := data[len(magic):]
+	if len(rest) < 3 {
+		return nil, 0, errors.New("keywrap: truncated container")
+	}
+	if rest[0] != version {
+		return nil, 0, fmt.Errorf("keywrap: unsupported version %d", rest[0])
+	}
+	headerLen := int(binary.BigEndian.Uint16(rest[1:3]))
+	rest = rest[3:]
+	if len(rest) < headerLen {
+		return nil, 0, errors.New("keywrap: truncated container")
+	}
+
+	var header Header
+	if err := json.Unmarshal(rest[:headerLen], &header); err != nil {
+		return nil, 0, fmt.Errorf("keywrap: invalid header: %w", err)
+	}
+	if header.Cipher != "xchacha20poly1305" || len(header.Nonce) != chacha20poly1305.NonceSizeX {
+		return nil, 0, errors.New("keywrap: unsupported cipher")
+	}
+	if len(header.WrappedKey) == 0 {
+		return nil, 0, errors.New("keywrap: missing wrapped key")
+	}
+	return &header, len(magic) + 3 + headerLen, nil
+}
+
+// Open decrypts a container created by Seal, with w unwrapping its data key.
+// w must be of the provider that wrapped it.
+func Open(ctx context.Context, data []byte, w Wrapper) ([]byte, error) {
+	header, prefixLen, err := split(data)
+	if err != nil {
+		return nil, err
+	}
+	if header.Provider != w.Provider() {
+		return nil, fmt.Errorf("keywrap: the data key was wrapped by %s, not %s", header.Provider, w.Provider())
+	}
+
+	key, err := w.UnwrapKey(ctx, header.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("keywrap: unwrapping the data key with %s: %w", w.Provider(), err)
+	}
+	defer clear(key)
+	if len(key) != keySize {
+		return nil, ErrDecrypt
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := aead.Open(nil, header.Nonce, data[prefixLen:], data[:prefixLen])
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// Parse returns the Wrapper of spec, which is one of
+//
+//	awskms:<key ID, ARN or alias>
+//	gcpkms:projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>
+//	age:<identity file>
+//
+// The KMS wrappers take their credentials from the environment, see
+// NewAWSKMSFromEnv and NewGCPKMS.
+func Parse(spec string) (Wrapper, error) {
+	provider, arg, ok := strings.Cut(spec, ":")
+	if !ok || arg == "" {
+		return nil, fmt.Errorf("keywrap: invalid wrapper %q, expected <provider>:<key>", spec)
+	}
+	switch provider {
+	case ProviderAWSKMS:
+		return NewAWSKMSFromEnv(arg)
+	case ProviderGCPKMS:
+		return NewGCPKMS(arg)
+	case ProviderAge:
+		return ReadAgeIdentityFile(arg)
+	}
+	return nil, fmt.Errorf("keywrap: unknown provider %q", provider)
+}
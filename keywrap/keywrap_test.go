@@ -0,0 +1,97 @@
+package keywrap
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	ctx := context.Background()
+	identity, err := GenerateAgeIdentity()
+	require.NoError(t, err)
+	plaintext := []byte("secret share")
+
+	data, err := Seal(ctx, plaintext, identity)
+	require.NoError(t, err)
+	assert.True(t, IsWrapped(data))
+	assert.NotContains(t, string(data), string(plaintext))
+
+	header, err := ReadHeader(data)
+	require.NoError(t, err)
+	assert.Equal(t, ProviderAge, header.Provider)
+	assert.Equal(t, identity.Recipient(), header.KeyID)
+
+	opened, err := Open(ctx, data, identity)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+
+	other, err := GenerateAgeIdentity()
+	require.NoError(t, err)
+	_, err = Open(ctx, data, other)
+	assert.True(t, errors.Is(err, ErrAgeNoIdentity))
+
+	tampered := append([]byte(nil), data...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = Open(ctx, tampered, identity)
+	assert.Equal(t, ErrDecrypt, err)
+
+	// the header is authenticated as well
+	tampered = append([]byte(nil), data...)
+	copy(tampered[len(magic)+3:], `{"provider":"age","key_id":"x"`)
+	_, err = Open(ctx, tampered, identity)
+	assert.Error(t, err)
+
+	_, err = Open(ctx, data[:len(magic)+2], identity)
+	assert.Error(t, err)
+	_, err = Open(ctx, []byte("plaintext"), identity)
+	assert.Error(t, err)
+}
+
+func TestOpen_WrongProvider(t *testing.T) {
+	ctx := context.Background()
+	identity, err := GenerateAgeIdentity()
+	require.NoError(t, err)
+	data, err := Seal(ctx, []byte("share"), identity)
+	require.NoError(t, err)
+
+	_, err = Open(ctx, data, &GCPKMS{Name: "projects/p/locations/l/keyRings/r/cryptoKeys/k"})
+	assert.EqualError(t, err, "keywrap: the data key was wrapped by age, not gcpkms")
+}
+
+func TestParse(t *testing.T) {
+	identity, err := GenerateAgeIdentity()
+	require.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "identity.txt")
+	content := "# created: 2026-01-01T00:00:00Z\n# public key: " + identity.Recipient() + "\n" + identity.String() + "\n"
+	require.NoError(t, os.WriteFile(file, []byte(content), 0600))
+
+	w, err := Parse("age:" + file)
+	require.NoError(t, err)
+	assert.Equal(t, identity.Recipient(), w.KeyID())
+
+	w, err = Parse("gcpkms:projects/p/locations/global/keyRings/r/cryptoKeys/k")
+	require.NoError(t, err)
+	assert.Equal(t, ProviderGCPKMS, w.Provider())
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	w, err = Parse("awskms:arn:aws:kms:eu-west-1:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab")
+	require.NoError(t, err)
+	assert.Equal(t, "eu-west-1", w.(*AWSKMS).Client.Options().Region)
+	_, err = Parse("awskms:alias/frost")
+	assert.Error(t, err, "no region")
+
+	for _, spec := range []string{"", "age", "age:", "vault:key", "gcpkms:projects/p"} {
+		_, err := Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
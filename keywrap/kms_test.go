@@ -0,0 +1,158 @@
+package keywrap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/googleapis/gax-go/v2/apierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/option"
+)
+
+// fakeKMS stores the keys it wraps, and hands out handles to them.
+type fakeKMS struct {
+	mu   sync.Mutex
+	keys map[string][]byte
+}
+
+func (f *fakeKMS) wrap(key []byte) []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.keys == nil {
+		f.keys = make(map[string][]byte)
+	}
+	handle := fmt.Sprintf("handle-%d", len(f.keys))
+	f.keys[handle] = key
+	return []byte(handle)
+}
+
+func (f *fakeKMS) unwrap(handle []byte) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key, ok := f.keys[string(handle)]
+	return key, ok
+}
+
+func TestAWSKMS(t *testing.T) {
+	var fake fakeKMS
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-amz-json-1.1", r.Header.Get("Content-Type"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/kms/aws4_request")
+		assert.Equal(t, "token", r.Header.Get("X-Amz-Security-Token"))
+
+		var in struct {
+			KeyId             string
+			Plaintext         []byte
+			CiphertextBlob    []byte
+			EncryptionContext map[string]string
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		assert.Equal(t, "alias/frost", in.KeyId)
+		assert.Equal(t, encryptionContext, in.EncryptionContext)
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.Encrypt":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"CiphertextBlob": fake.wrap(in.Plaintext), "KeyId": in.KeyId})
+		case "TrentService.Decrypt":
+			key, ok := fake.unwrap(in.CiphertextBlob)
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"__type":"com.amazonaws.kms#InvalidCiphertextException","message":"unknown ciphertext"}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"Plaintext": key})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	k := &AWSKMS{
+		Key: "alias/frost",
+		Client: kms.New(kms.Options{
+			Region:       "eu-west-1",
+			Credentials:  credentials.NewStaticCredentialsProvider("AKID", "secret", "token"),
+			BaseEndpoint: aws.String(srv.URL),
+		}),
+	}
+	data, err := Seal(ctx, []byte("share"), k)
+	require.NoError(t, err)
+	header, err := ReadHeader(data)
+	require.NoError(t, err)
+	assert.Equal(t, "awskms", header.Provider)
+	assert.Equal(t, "alias/frost", header.KeyID)
+
+	opened, err := Open(ctx, data, k)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("share"), opened)
+
+	_, err = k.UnwrapKey(ctx, []byte("unknown"))
+	var invalid *types.InvalidCiphertextException
+	require.True(t, errors.As(err, &invalid), "%v", err)
+	assert.Equal(t, "unknown ciphertext", invalid.ErrorMessage())
+}
+
+func TestGCPKMS(t *testing.T) {
+	const name = "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+	var fake fakeKMS
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":401,"status":"UNAUTHENTICATED","message":"invalid token"}}`))
+			return
+		}
+		var in struct {
+			Plaintext  []byte `json:"plaintext"`
+			Ciphertext []byte `json:"ciphertext"`
+			AAD        []byte `json:"additionalAuthenticatedData"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		assert.Equal(t, "frost-keywrap", string(in.AAD))
+
+		switch r.URL.Path {
+		case "/v1/" + name + ":encrypt":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": name, "ciphertext": fake.wrap(in.Plaintext)})
+		case "/v1/" + name + ":decrypt":
+			key, _ := fake.unwrap(in.Ciphertext)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"plaintext": key})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	k, err := NewGCPKMS(name)
+	require.NoError(t, err)
+	k.Options = []option.ClientOption{
+		option.WithEndpoint(srv.URL),
+		option.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"})),
+	}
+
+	data, err := Seal(ctx, []byte("share"), k)
+	require.NoError(t, err)
+	opened, err := Open(ctx, data, k)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("share"), opened)
+
+	k.Options[1] = option.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "expired"}))
+	_, err = Open(ctx, data, k)
+	var apiErr *apierror.APIError
+	require.True(t, errors.As(err, &apiErr), "%v", err)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.HTTPCode())
+}
@@ -0,0 +1,114 @@
+package frost
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/bartke/frost/ristretto"
+)
+
+// ErrNonceReuse is returned by SignRound1 when the nonces of the state were
+// already used for a signature share, e.g. because a state saved to disk was
+// replayed after a crash. Releasing two shares with the same nonces for
+// different challenges reveals the secret share.
+var ErrNonceReuse = errors.New("frost: nonces were already used")
+
+// NonceLedger records the nonce commitments (Di, Ei) of this party for which
+// a signature share was produced. Set SignerState.Ledger to have SignRound1
+// consult it; the ledger must outlive the process, so that a state restored
+// from disk cannot be signed with twice.
+type NonceLedger interface {
+	// Consume records the commitments as used. It returns ErrNonceReuse if
+	// they were recorded before, and must be atomic.
+	Consume(di, ei *ristretto.Element) error
+}
+
+// nonceKey returns the key under which the commitments are recorded.
+func nonceKey(di, ei *ristretto.Element) string {
+	return hex.EncodeToString(di.Bytes()) + hex.EncodeToString(ei.Bytes())
+}
+
+// MemoryNonceLedger is a NonceLedger kept in memory.
+type MemoryNonceLedger struct {
+	mu   sync.Mutex
+	used map[string]bool
+}
+
+// NewMemoryNonceLedger returns an empty MemoryNonceLedger.
+func NewMemoryNonceLedger() *MemoryNonceLedger {
+	return &MemoryNonceLedger{used: make(map[string]bool)}
+}
+
+// Consume implements NonceLedger.
+func (l *MemoryNonceLedger) Consume(di, ei *ristretto.Element) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.consume(nonceKey(di, ei))
+}
+
+func (l *MemoryNonceLedger) consume(key string) error {
+	if l.used[key] {
+		return ErrNonceReuse
+	}
+	l.used[key] = true
+	return nil
+}
+
+// FileNonceLedger is a NonceLedger backed by an append-only file with the
+// hex encoded commitments of one pair of nonces per line. Every new entry is
+// synced to disk before Consume returns.
+type FileNonceLedger struct {
+	mem  *MemoryNonceLedger
+	file *os.File
+}
+
+// OpenFileNonceLedger opens or creates the ledger stored at path.
+func OpenFileNonceLedger(path string) (*FileNonceLedger, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("NonceLedger: %w", err)
+	}
+
+	mem := NewMemoryNonceLedger()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if _, err := hex.DecodeString(line); err != nil || len(line) != 128 {
+			f.Close()
+			return nil, fmt.Errorf("NonceLedger: corrupt entry %q", line)
+		}
+		mem.used[line] = true
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("NonceLedger: %w", err)
+	}
+	return &FileNonceLedger{mem: mem, file: f}, nil
+}
+
+// Consume implements NonceLedger.
+func (l *FileNonceLedger) Consume(di, ei *ristretto.Element) error {
+	l.mem.mu.Lock()
+	defer l.mem.mu.Unlock()
+
+	key := nonceKey(di, ei)
+	if l.mem.used[key] {
+		return ErrNonceReuse
+	}
+	if _, err := l.file.WriteString(key + "\n"); err != nil {
+		return fmt.Errorf("NonceLedger: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("NonceLedger: %w", err)
+	}
+	return l.mem.consume(key)
+}
+
+// Close closes the underlying file.
+func (l *FileNonceLedger) Close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,80 @@
+package frost
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryNonceLedger(t *testing.T) {
+	var d, e ristretto.Element
+	d.ScalarBaseMult(scalar.NewScalarRandom())
+	e.ScalarBaseMult(scalar.NewScalarRandom())
+
+	l := NewMemoryNonceLedger()
+	require.NoError(t, l.Consume(&d, &e))
+	assert.True(t, errors.Is(l.Consume(&d, &e), ErrNonceReuse))
+	// the order of the commitments matters
+	assert.NoError(t, l.Consume(&e, &d))
+}
+
+func TestFileNonceLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonces")
+	var d, e ristretto.Element
+	d.ScalarBaseMult(scalar.NewScalarRandom())
+	e.ScalarBaseMult(scalar.NewScalarRandom())
+
+	l, err := OpenFileNonceLedger(path)
+	require.NoError(t, err)
+	require.NoError(t, l.Consume(&d, &e))
+	require.NoError(t, l.Close())
+
+	// the ledger survives a restart
+	l, err = OpenFileNonceLedger(path)
+	require.NoError(t, err)
+	assert.True(t, errors.Is(l.Consume(&d, &e), ErrNonceReuse))
+	assert.NoError(t, l.Consume(&e, &d))
+	require.NoError(t, l.Close())
+
+	require.NoError(t, os.WriteFile(path, []byte("not hex\n"), 0600))
+	_, err = OpenFileNonceLedger(path)
+	assert.Error(t, err)
+}
+
+func TestSignRound1_Ledger(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	signers := party.IDSlice{1, 3}
+	message := []byte("replayed")
+
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInit(signers, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	saved, err := json.Marshal(states[1])
+	require.NoError(t, err)
+
+	ledger := NewMemoryNonceLedger()
+	states[1].Ledger = ledger
+	sigs, err := runSignRounds(states, round1)
+	require.NoError(t, err)
+	assert.True(t, public.GroupKey.Verify(message, sigs[1]))
+
+	// the state saved before round 1 is restored after a crash
+	var restored SignerState
+	require.NoError(t, json.Unmarshal(saved, &restored))
+	restored.Ledger = ledger
+	_, _, err = SignRound1(&restored, round1)
+	assert.True(t, errors.Is(err, ErrNonceReuse))
+}
@@ -0,0 +1,173 @@
+// Package limits bounds the resources that peers can make a party spend, so
+// that a misbehaving peer or coordinator cannot exhaust its memory by opening
+// thousands of half-finished ceremonies, or flood it with messages.
+//
+// A Limiter counts the sessions a party runs concurrently, and checks every
+// protocol message it receives against a maximum size and the message rate of
+// the peer it came from. The peer is the authenticated end of the connection,
+// such as a coordinator relaying the messages of every party, and not the
+// sender a message names, which whoever sends it chooses, so callers drop the
+// messages of parties outside the session before counting them. Sessions
+// expire with the timeout of whoever runs them, such as
+// grpcserver.Server.Timeout or sessions.Manager.Timeout, and release their
+// slot then. A limit that is exceeded is reported as an *Error, which wraps
+// ErrTooManySessions, ErrMessageTooLarge or ErrRateLimited.
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/party"
+)
+
+var (
+	// ErrTooManySessions is wrapped by the error of a session refused because
+	// Limits.MaxSessions sessions are running.
+	ErrTooManySessions = errors.New("limits: too many sessions")
+	// ErrMessageTooLarge is wrapped by the error of a message larger than Limits.MaxMessageSize.
+	ErrMessageTooLarge = errors.New("limits: message too large")
+	// ErrRateLimited is wrapped by the error of a message whose sender exceeded Limits.Rate.
+	ErrRateLimited = errors.New("limits: rate limited")
+)
+
+// Error is the error of an exceeded limit.
+type Error struct {
+	// Err is ErrTooManySessions, ErrMessageTooLarge or ErrRateLimited.
+	Err error
+	// Party is the sender of the message, or 0 for ErrTooManySessions.
+	Party party.ID
+	// Peer is the peer the message came from, or "" for ErrTooManySessions.
+	Peer string
+	// Value is the number of sessions or the size of the message, and Max its limit.
+	// For ErrRateLimited, Max is the rate per second, and Value 0.
+	Value, Max float64
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Err == ErrRateLimited:
+		return fmt.Sprintf("%v: peer %s sent more than %g messages per second", e.Err, e.Peer, e.Max)
+	case e.Party != 0:
+		return fmt.Sprintf("%v: %g > %g, from party %d", e.Err, e.Value, e.Max, e.Party)
+	default:
+		return fmt.Sprintf("%v: %g > %g", e.Err, e.Value, e.Max)
+	}
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Limits are the limits of a Limiter. A zero field means no limit.
+type Limits struct {
+	// MaxSessions bounds the number of sessions running concurrently.
+	MaxSessions int `json:"max_sessions,omitempty"`
+	// MaxMessageSize bounds the size of the binary encoding of a message, see frost.Message.Size.
+	MaxMessageSize int `json:"max_message_size,omitempty"`
+	// Rate bounds the number of messages per second from each peer, over all
+	// sessions, and Burst the number of messages it may send at once. Burst
+	// defaults to Rate, rounded up.
+	Rate  float64 `json:"rate,omitempty"`
+	Burst int     `json:"burst,omitempty"`
+}
+
+// Limiter enforces Limits. A nil *Limiter enforces none. It must not be copied
+// after first use.
+type Limiter struct {
+	Limits
+	// Clock times the rate limits, clock.Real is used if it is nil.
+	Clock clock.Clock
+
+	mu       sync.Mutex
+	sessions int
+	buckets  map[string]*bucket
+}
+
+// bucket holds the messages a peer may still send at once, as of updated.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// New returns a Limiter of limits.
+func New(limits Limits) *Limiter {
+	return &Limiter{Limits: limits}
+}
+
+// Acquire reserves a slot for a session, and returns the function releasing
+// it once the session ended, which may be called more than once. It returns
+// an error wrapping ErrTooManySessions if MaxSessions sessions are running.
+func (l *Limiter) Acquire() (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.MaxSessions > 0 && l.sessions >= l.MaxSessions {
+		return nil, &Error{Err: ErrTooManySessions, Value: float64(l.sessions + 1), Max: float64(l.MaxSessions)}
+	}
+	l.sessions++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.sessions--
+			l.mu.Unlock()
+		})
+	}, nil
+}
+
+// Sessions returns the number of sessions running.
+func (l *Limiter) Sessions() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sessions
+}
+
+// Message checks a message received from peer against MaxMessageSize, and
+// counts it against the rate of peer, which identifies the authenticated
+// connection it arrived on. It returns an error wrapping ErrMessageTooLarge or
+// ErrRateLimited if msg must be dropped.
+func (l *Limiter) Message(peer string, msg *frost.Message) error {
+	if l == nil {
+		return nil
+	}
+	if size := msg.Size(); l.MaxMessageSize > 0 && size > l.MaxMessageSize {
+		return &Error{Err: ErrMessageTooLarge, Party: msg.From, Peer: peer, Value: float64(size), Max: float64(l.MaxMessageSize)}
+	}
+	if l.Rate <= 0 {
+		return nil
+	}
+
+	burst := float64(l.Burst)
+	if l.Burst <= 0 {
+		burst = math.Ceil(l.Rate)
+	}
+	now := clock.OrReal(l.Clock).Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.buckets == nil {
+		l.buckets = make(map[string]*bucket)
+	}
+	b, ok := l.buckets[peer]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		l.buckets[peer] = b
+	}
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed.Seconds()*l.Rate)
+		b.updated = now
+	}
+	if b.tokens < 1 {
+		return &Error{Err: ErrRateLimited, Party: msg.From, Peer: peer, Max: l.Rate}
+	}
+	b.tokens--
+	return nil
+}
@@ -0,0 +1,104 @@
+package limits
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/polynomial"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/scalar"
+	"github.com/bartke/frost/zk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Sessions(t *testing.T) {
+	l := New(Limits{MaxSessions: 2})
+	first, err := l.Acquire()
+	require.NoError(t, err)
+	second, err := l.Acquire()
+	require.NoError(t, err)
+	assert.Equal(t, 2, l.Sessions())
+
+	_, err = l.Acquire()
+	assert.True(t, errors.Is(err, ErrTooManySessions))
+	var limitErr *Error
+	require.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, float64(2), limitErr.Max)
+
+	// releasing twice frees one slot
+	first()
+	first()
+	assert.Equal(t, 1, l.Sessions())
+	third, err := l.Acquire()
+	require.NoError(t, err)
+	second()
+	third()
+	assert.Equal(t, 0, l.Sessions())
+}
+
+func TestLimiter_MessageSize(t *testing.T) {
+	small := frost.NewSign2(1, scalar.NewScalarRandom())
+	secret := scalar.NewScalarRandom()
+	poly := polynomial.NewPolynomial(100, secret)
+	proof := zk.NewSchnorrProof(2, new(ristretto.Element).ScalarBaseMult(secret), nil, secret)
+	large := frost.NewKeyGen1(2, proof, polynomial.NewPolynomialExponent(poly))
+	l := New(Limits{MaxMessageSize: 1024})
+
+	assert.NoError(t, l.Message("coordinator", small))
+	err := l.Message("coordinator", large)
+	assert.True(t, errors.Is(err, ErrMessageTooLarge))
+	var limitErr *Error
+	require.True(t, errors.As(err, &limitErr))
+	assert.EqualValues(t, 2, limitErr.Party)
+	assert.Equal(t, "coordinator", limitErr.Peer)
+	assert.EqualValues(t, large.Size(), limitErr.Value)
+}
+
+func TestLimiter_Rate(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	l := New(Limits{Rate: 2, Burst: 3})
+	l.Clock = fake
+	from1 := frost.NewSign2(1, scalar.NewScalarRandom())
+	from2 := frost.NewSign2(2, scalar.NewScalarRandom())
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, l.Message("a", from1))
+	}
+	err := l.Message("a", from1)
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	// the rate is that of the peer, whatever sender the message names
+	assert.Error(t, l.Message("a", from2))
+	// other peers have rates of their own
+	assert.NoError(t, l.Message("b", from1))
+
+	// two messages per second, up to the burst
+	fake.Advance(500 * time.Millisecond)
+	assert.NoError(t, l.Message("a", from1))
+	assert.Error(t, l.Message("a", from1))
+	fake.Advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, l.Message("a", from1))
+	}
+	assert.Error(t, l.Message("a", from1))
+}
+
+func TestLimiter_Nil(t *testing.T) {
+	var l *Limiter
+	release, err := l.Acquire()
+	require.NoError(t, err)
+	release()
+	assert.NoError(t, l.Message("a", frost.NewSign2(1, scalar.NewScalarRandom())))
+	assert.Equal(t, 0, l.Sessions())
+
+	// zero limits are no limits
+	l = New(Limits{})
+	for i := 0; i < 100; i++ {
+		_, err := l.Acquire()
+		require.NoError(t, err)
+		require.NoError(t, l.Message("a", frost.NewSign2(1, scalar.NewScalarRandom())))
+	}
+}
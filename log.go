@@ -0,0 +1,58 @@
+package frost
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+)
+
+// KeygenInitWithLogger is KeygenInit with the Logger of the state set to
+// logger, which logs the rounds at debug level: when they start, complete or
+// fail, and the fingerprint of every message accepted. Nothing secret is
+// logged.
+func KeygenInitWithLogger(logger *slog.Logger, selfID party.ID, n, t party.Size) (*Message, *KeygenState, error) {
+	msg, state, err := KeygenInit(selfID, n, t)
+	if err != nil {
+		return nil, nil, err
+	}
+	state.Logger = logger
+	if debugEnabled(logger) {
+		logger.Debug("frost: keygen initialized", "party", selfID, "parties", n, "threshold", t, "fingerprint", msg.fingerprint())
+	}
+	return msg, state, nil
+}
+
+// SignInitWithLogger is SignInit with the Logger of the state set to logger,
+// see KeygenInitWithLogger.
+func SignInitWithLogger(logger *slog.Logger, signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, message []byte) (*Message, *SignerState, error) {
+	msg, state, err := SignInit(signerIDs, secret, shares, message)
+	if err != nil {
+		return nil, nil, err
+	}
+	state.Logger = logger
+	if debugEnabled(logger) {
+		logger.Debug("frost: sign initialized", "party", state.SelfID, "signers", signerIDs, "fingerprint", msg.fingerprint())
+	}
+	return msg, state, nil
+}
+
+// debugEnabled returns true if logger is set and logs at debug level, so that
+// values are only computed for it when they are logged.
+func debugEnabled(logger *slog.Logger) bool {
+	return logger != nil && logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// fingerprint returns the first 8 bytes of the SHA-256 of the binary encoding
+// of m in hex, which tells messages apart in logs.
+func (m *Message) fingerprint() string {
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,87 @@
+package frost
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func debugLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func TestLogger_Keygen(t *testing.T) {
+	const n = 3
+	var buf bytes.Buffer
+	states := make(map[party.ID]*KeygenState, n)
+	var round1 []*Message
+	for id := party.ID(1); id <= n; id++ {
+		var logger *slog.Logger
+		if id == 1 {
+			logger = debugLogger(&buf)
+		}
+		msg, state, err := KeygenInitWithLogger(logger, id, n, 1)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	_, _, err := KeygenRound1(states[1], round1)
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "msg=\"frost: keygen initialized\" party=1 parties=3 threshold=1 fingerprint="+round1[0].fingerprint())
+	assert.Contains(t, out, "msg=\"frost: round started\" party=1 round=KeyGen1")
+	assert.Contains(t, out, "msg=\"frost: message accepted\" party=1 round=KeyGen1 from=2 fingerprint="+round1[1].fingerprint())
+	assert.Contains(t, out, "msg=\"frost: message accepted\" party=1 round=KeyGen1 from=3 fingerprint="+round1[2].fingerprint())
+	assert.Contains(t, out, "msg=\"frost: round complete\" party=1 round=KeyGen1")
+
+	// nothing is logged above debug level
+	buf.Reset()
+	states[2].Logger = slog.New(slog.NewTextHandler(&buf, nil))
+	_, _, err = KeygenRound1(states[2], round1)
+	require.NoError(t, err)
+	assert.Empty(t, buf.String())
+}
+
+func TestLogger_SignFailure(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	signers := party.IDSlice{1, 3}
+	message := []byte("logged")
+
+	var buf bytes.Buffer
+	var round1 []*Message
+	states := make(map[party.ID]*SignerState)
+	for _, id := range signers {
+		msg, state, err := SignInitWithLogger(debugLogger(&buf), signers, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	_, _, err := SignRound1(states[3], round1)
+	require.NoError(t, err)
+
+	// an invalid share is logged with its sender
+	buf.Reset()
+	_, _, err = SignRound2(states[3], []*Message{NewSign2(1, scalar.NewScalarRandom())})
+	require.Error(t, err)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.NotEmpty(t, lines)
+	last := lines[len(lines)-1]
+	assert.Contains(t, last, "msg=\"frost: round failed\" party=3 round=Sign2 culprit=1")
+
+	// as is a message that does not validate
+	buf.Reset()
+	_, state, err := SignInitWithLogger(debugLogger(&buf), signers, secrets[1], public, message)
+	require.NoError(t, err)
+	wrong := *round1[1]
+	wrong.To = 2
+	_, _, err = SignRound1(state, []*Message{&wrong})
+	require.Error(t, err)
+	assert.Contains(t, buf.String(), "msg=\"frost: round failed\" party=1 round=Sign1 culprit=3")
+}
@@ -0,0 +1,424 @@
+package frost
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+)
+
+// Status is the state of a Machine after a call to Advance.
+type Status int
+
+const (
+	// StatusWaiting means the machine waits for messages of the other parties.
+	StatusWaiting Status = iota
+	// StatusDone means the result is available.
+	StatusDone
+	// StatusFailed means the protocol was aborted, Result returns the error.
+	StatusFailed
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusWaiting:
+		return "waiting"
+	case StatusDone:
+		return "done"
+	case StatusFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("Status(%d)", int(s))
+	}
+}
+
+// Machine runs the rounds of a protocol for one party as a state machine,
+// without goroutines, channels or timers, for embedding in event loops and
+// foreign runtimes. The host calls Advance with the current time and the
+// messages received since the last call, in any order, and delivers the
+// returned messages to the other parties. Messages for a later round are kept
+// until that round starts, those of earlier rounds are dropped.
+//
+// A Machine is not safe for concurrent use. Session runs a Machine in a goroutine.
+type Machine struct {
+	selfID   party.ID
+	deadline time.Time
+
+	start  func(now time.Time) ([]*Message, error)
+	rounds []machineRound
+	// round is the index in rounds of the round being collected, -1 before start.
+	round    int
+	received map[party.ID]bool
+	msgs     []*Message
+	pending  []*Message
+	// spare is swapped with pending by collect, to avoid allocations
+	spare []*Message
+	out   []*Message
+
+	status Status
+	result *SessionResult
+	err    error
+	// zeroize erases the secrets of the protocol state when the machine fails.
+	zeroize  func()
+	observer Observer
+}
+
+// machineRound collects one message of type from every party in from except
+// ourselves, and then calls finish with them.
+type machineRound struct {
+	typ    MessageType
+	from   party.IDSlice
+	finish func(now time.Time, msgs []*Message) ([]*Message, *SessionResult, error)
+}
+
+// NewKeygenMachine returns the key generation for selfID among the parties 1..n with threshold t.
+func NewKeygenMachine(selfID party.ID, n, t party.Size) *Machine {
+	return newKeygenMachine(selfID, n, t, false)
+}
+
+// NewKeygenMachineWithEcho is NewKeygenMachine with the echo round, in which
+// the parties compare the KeyGen1 messages they received before sending their
+// shares, see KeygenEchoMessage. All parties must run it.
+func NewKeygenMachineWithEcho(selfID party.ID, n, t party.Size) *Machine {
+	return newKeygenMachine(selfID, n, t, true)
+}
+
+func newKeygenMachine(selfID party.ID, n, t party.Size, echo bool) *Machine {
+	var (
+		state *KeygenState
+		// shares are the KeyGen2 messages held until the echo round passed
+		shares []*Message
+	)
+	m := &Machine{selfID: selfID}
+	m.start = func(time.Time) ([]*Message, error) {
+		msg, s, err := KeygenInit(selfID, n, t)
+		if err != nil {
+			return nil, err
+		}
+		state = s
+		m.zeroize = s.Zeroize
+		for i := range m.rounds {
+			m.rounds[i].from = s.PartyIDs
+		}
+		return []*Message{msg}, nil
+	}
+	m.rounds = []machineRound{
+		{typ: MessageTypeKeyGen1, finish: func(_ time.Time, msgs []*Message) ([]*Message, *SessionResult, error) {
+			out, _, err := KeygenRound1(state, msgs)
+			if err != nil || !echo {
+				return out, nil, err
+			}
+			shares = out
+			msg, err := KeygenEchoMessage(state)
+			if err != nil {
+				return nil, nil, err
+			}
+			return []*Message{msg}, nil, nil
+		}},
+		{typ: MessageTypeKeyGen2, finish: func(_ time.Time, msgs []*Message) ([]*Message, *SessionResult, error) {
+			public, secret, err := KeygenRound2(state, msgs)
+			if err != nil {
+				return nil, nil, err
+			}
+			return nil, &SessionResult{Public: public, SecretShare: secret}, nil
+		}},
+	}
+	if echo {
+		round := machineRound{typ: MessageTypeKeyGenEcho, finish: func(_ time.Time, msgs []*Message) ([]*Message, *SessionResult, error) {
+			if err := KeygenRoundEcho(state, msgs); err != nil {
+				return nil, nil, err
+			}
+			out := shares
+			shares = nil
+			return out, nil, nil
+		}}
+		m.rounds = []machineRound{m.rounds[0], round, m.rounds[1]}
+	}
+	return m.init()
+}
+
+// NewSignMachine returns the signing of message by the owner of secret, together with signerIDs.
+// Expiries of signature requests are checked against the time passed to Advance.
+func NewSignMachine(signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, message []byte) *Machine {
+	return NewSignMachineWithSession(SessionID{}, signerIDs, secret, shares, message)
+}
+
+// NewSignMachineWithSession is NewSignMachine for the session with the given
+// ID, see SignInitWithSession.
+func NewSignMachineWithSession(session SessionID, signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, message []byte) *Machine {
+	var state *SignerState
+	m := &Machine{selfID: secret.ID}
+	m.start = func(now time.Time) ([]*Message, error) {
+		msg, s, err := SignInitWithSession(session, signerIDs, secret, shares, message)
+		if err != nil {
+			return nil, err
+		}
+		state = s
+		m.zeroize = s.Zeroize
+		m.rounds[0].from, m.rounds[1].from = s.SignerIDs, s.SignerIDs
+		return []*Message{msg}, nil
+	}
+	m.rounds = []machineRound{
+		{typ: MessageTypeSign1, finish: func(now time.Time, msgs []*Message) ([]*Message, *SessionResult, error) {
+			state.Clock = fixedClock{Clock: clock.OrReal(state.Clock), now: now}
+			msg, _, err := SignRound1(state, msgs)
+			if err != nil {
+				return nil, nil, err
+			}
+			return []*Message{msg}, nil, nil
+		}},
+		{typ: MessageTypeSign2, finish: func(_ time.Time, msgs []*Message) ([]*Message, *SessionResult, error) {
+			sig, _, err := SignRound2(state, msgs)
+			if err != nil {
+				return nil, nil, err
+			}
+			return nil, &SessionResult{Signature: sig}, nil
+		}},
+	}
+	return m.init()
+}
+
+// NewRefreshMachine returns the refresh of the shares of the group described
+// by shares: every party reshares its secret share to all parties with the
+// same threshold, see ReshareInit, so that the group key stays the same and
+// the old shares cannot be combined with the new ones.
+func NewRefreshMachine(secret *eddsa.SecretShare, shares *eddsa.Public) *Machine {
+	return NewRefreshMachineWithSession(SessionID{}, secret, shares)
+}
+
+// NewRefreshMachineWithSession is NewRefreshMachine for the session with the
+// given ID, see ReshareInitWithSession.
+func NewRefreshMachineWithSession(session SessionID, secret *eddsa.SecretShare, shares *eddsa.Public) *Machine {
+	var state *ReshareState
+	m := &Machine{selfID: secret.ID}
+	m.start = func(time.Time) ([]*Message, error) {
+		msg, s, err := ReshareInitWithSession(session, secret.ID, secret, shares, shares.PartyIDs, shares.PartyIDs, shares.Threshold)
+		if err != nil {
+			return nil, err
+		}
+		state = s
+		m.rounds[0].from, m.rounds[1].from = s.Dealers, s.Dealers
+		return []*Message{msg}, nil
+	}
+	m.rounds = []machineRound{
+		{typ: MessageTypeReshare1, finish: func(_ time.Time, msgs []*Message) ([]*Message, *SessionResult, error) {
+			out, _, err := ReshareRound1(state, msgs)
+			return out, nil, err
+		}},
+		{typ: MessageTypeReshare2, finish: func(_ time.Time, msgs []*Message) ([]*Message, *SessionResult, error) {
+			public, secret, err := ReshareRound2(state, msgs)
+			if err != nil {
+				return nil, nil, err
+			}
+			return nil, &SessionResult{Public: public, SecretShare: secret}, nil
+		}},
+	}
+	return m.init()
+}
+
+func (m *Machine) init() *Machine {
+	m.round = -1
+	m.received = make(map[party.ID]bool)
+	return m
+}
+
+// SetObserver makes the machine notify o of the start and completion of its
+// rounds, of every message it accepts, and of its failure. It must be called
+// before the first call to Advance.
+func (m *Machine) SetObserver(o Observer) {
+	m.observer = o
+}
+
+// SetDeadline makes Advance fail with ErrSessionClosed once now is after t.
+// The zero time, the default, disables the deadline.
+func (m *Machine) SetDeadline(t time.Time) {
+	m.deadline = t
+}
+
+// Advance processes inbox and returns the messages to deliver, which are only
+// valid until the next call, and the status of the machine. The first call
+// starts the protocol, and can be made with an empty inbox.
+func (m *Machine) Advance(now time.Time, inbox []*Message) ([]*Message, Status) {
+	if m.status != StatusWaiting {
+		return nil, m.status
+	}
+	m.out = m.out[:0]
+	if !m.deadline.IsZero() && now.After(m.deadline) {
+		return nil, m.fail(fmt.Errorf("%w: deadline exceeded", ErrSessionClosed), 0)
+	}
+	if m.round < 0 {
+		out, err := m.start(now)
+		if err != nil {
+			return nil, m.fail(err, 0)
+		}
+		m.out = append(m.out, out...)
+		m.round = 0
+		m.roundStart()
+	}
+	m.pending = append(m.pending, inbox...)
+
+	for m.status == StatusWaiting {
+		complete, culprit, err := m.collect()
+		if err != nil {
+			return m.out, m.fail(err, culprit)
+		}
+		if !complete {
+			break
+		}
+		out, result, err := m.rounds[m.round].finish(now, m.msgs)
+		if err != nil {
+			return m.out, m.fail(err, 0)
+		}
+		m.out = append(m.out, out...)
+		if m.observer != nil {
+			m.observer.OnRoundComplete(m.rounds[m.round].typ)
+		}
+		m.round++
+		m.msgs = nil
+		for id := range m.received {
+			delete(m.received, id)
+		}
+		if m.round == len(m.rounds) {
+			m.result, m.status = result, StatusDone
+			m.pending = nil
+		} else {
+			m.roundStart()
+		}
+	}
+	return m.out, m.status
+}
+
+// roundStart notifies the observer of the start of the current round.
+func (m *Machine) roundStart() {
+	if m.observer != nil {
+		m.observer.OnRoundStart(m.rounds[m.round].typ)
+	}
+}
+
+// collect moves the pending messages of the current round to m.msgs, and
+// returns true once a message of every other party was received. An error
+// comes with the party whose message caused it.
+func (m *Machine) collect() (bool, party.ID, error) {
+	r := m.rounds[m.round]
+	pending := m.pending
+	m.pending = m.spare[:0]
+	defer func() { m.spare = pending[:0] }()
+	if len(r.from) <= 1 {
+		m.pending = append(m.pending, pending...)
+		return true, 0, nil
+	}
+	for i, msg := range pending {
+		if msg == nil {
+			continue
+		}
+		if msg.Type != r.typ {
+			// messages of earlier rounds are late retransmissions
+			if m.later(msg.Type) {
+				m.pending = append(m.pending, msg)
+			}
+			continue
+		}
+		if msg.From == m.selfID {
+			continue
+		}
+		if !r.from.Contains(msg.From) {
+			return false, msg.From, fmt.Errorf("frost: message from unexpected party %d", msg.From)
+		}
+		if !msg.IsBroadcast() && msg.To != m.selfID {
+			return false, msg.From, fmt.Errorf("frost: message from party %d is addressed to party %d", msg.From, msg.To)
+		}
+		if m.received[msg.From] {
+			return false, msg.From, fmt.Errorf("frost: duplicate message from party %d", msg.From)
+		}
+		m.received[msg.From] = true
+		m.msgs = append(m.msgs, msg)
+		if m.observer != nil {
+			m.observer.OnMessageReceived(msg.From, r.typ)
+		}
+		if len(m.received) == len(r.from)-1 {
+			// keep the rest for the next rounds
+			m.pending = append(m.pending, pending[i+1:]...)
+			return true, 0, nil
+		}
+	}
+	return false, 0, nil
+}
+
+// later returns true if t is the type of a round after the current one.
+func (m *Machine) later(t MessageType) bool {
+	for _, r := range m.rounds[m.round+1:] {
+		if r.typ == t {
+			return true
+		}
+	}
+	return false
+}
+
+// fail ends the machine with err, caused by the message of culprit, or by
+// the culprit of an *AbortError in err if culprit is 0.
+func (m *Machine) fail(err error, culprit party.ID) Status {
+	if m.observer != nil {
+		if culprit == 0 {
+			culprit = culpritOf(err)
+		}
+		m.observer.OnAbort(err, culprit)
+	}
+	m.err, m.status = err, StatusFailed
+	m.pending, m.msgs = nil, nil
+	if m.zeroize != nil {
+		m.zeroize()
+	}
+	return m.status
+}
+
+// Status returns the status after the last call to Advance.
+func (m *Machine) Status() Status {
+	return m.status
+}
+
+// Round returns the type of the messages the machine waits for, and false if
+// it has not started or has ended.
+func (m *Machine) Round() (MessageType, bool) {
+	if m.status != StatusWaiting || m.round < 0 {
+		return 0, false
+	}
+	return m.rounds[m.round].typ, true
+}
+
+// Missing returns the parties whose message for the current round has not
+// arrived, or nil if the machine has not started or has ended.
+func (m *Machine) Missing() party.IDSlice {
+	if m.status != StatusWaiting || m.round < 0 {
+		return nil
+	}
+	var missing party.IDSlice
+	for _, id := range m.rounds[m.round].from {
+		if id != m.selfID && !m.received[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// Result returns the result of a machine that is done, or the error it failed with.
+func (m *Machine) Result() (*SessionResult, error) {
+	switch m.status {
+	case StatusDone:
+		return m.result, nil
+	case StatusFailed:
+		return nil, m.err
+	default:
+		return nil, errors.New("frost: machine has not finished")
+	}
+}
+
+// fixedClock is a clock whose Now is the time passed to Machine.Advance.
+type fixedClock struct {
+	clock.Clock
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time { return c.now }
@@ -0,0 +1,198 @@
+package frost
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runMachines advances the machines until they are all done, delivering their
+// messages in random order.
+func runMachines(t *testing.T, machines map[party.ID]*Machine, rng *rand.Rand) {
+	now := time.Now()
+	inboxes := make(map[party.ID][]*Message)
+	for steps := 0; steps < 100; steps++ {
+		done := true
+		for id, m := range machines {
+			inbox := inboxes[id]
+			rng.Shuffle(len(inbox), func(i, j int) { inbox[i], inbox[j] = inbox[j], inbox[i] })
+			inboxes[id] = nil
+			out, status := m.Advance(now, inbox)
+			require.NotEqual(t, StatusFailed, status, "party %d: %v", id, m.err)
+			for _, msg := range out {
+				for to := range machines {
+					if to != id && (msg.IsBroadcast() || msg.To == to) {
+						inboxes[to] = append(inboxes[to], msg)
+					}
+				}
+			}
+			done = done && status == StatusDone
+		}
+		if done {
+			return
+		}
+	}
+	t.Fatal("machines did not finish")
+}
+
+func TestMachine(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const n, threshold = 4, 2
+	keygen := make(map[party.ID]*Machine, n)
+	for id := party.ID(1); id <= n; id++ {
+		keygen[id] = NewKeygenMachine(id, n, threshold)
+	}
+	runMachines(t, keygen, rng)
+
+	results := make(map[party.ID]*SessionResult, n)
+	for id, m := range keygen {
+		res, err := m.Result()
+		require.NoError(t, err)
+		results[id] = res
+	}
+	public := results[1].Public
+	for _, res := range results {
+		assert.True(t, public.Equal(res.Public))
+	}
+
+	signers := party.IDSlice{1, 2, 4}
+	message := []byte("hello FROST")
+	sign := make(map[party.ID]*Machine, len(signers))
+	for _, id := range signers {
+		sign[id] = NewSignMachine(signers, results[id].SecretShare, public, message)
+	}
+	runMachines(t, sign, rng)
+	for _, m := range sign {
+		res, err := m.Result()
+		require.NoError(t, err)
+		assert.True(t, public.GroupKey.Verify(message, res.Signature))
+	}
+}
+
+func TestKeygenMachineWithEcho(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	const n, threshold = 3, 1
+	keygen := make(map[party.ID]*Machine, n)
+	for id := party.ID(1); id <= n; id++ {
+		keygen[id] = NewKeygenMachineWithEcho(id, n, threshold)
+	}
+	runMachines(t, keygen, rng)
+	res, err := keygen[1].Result()
+	require.NoError(t, err)
+	for _, m := range keygen {
+		other, err := m.Result()
+		require.NoError(t, err)
+		assert.True(t, res.Public.Equal(other.Public))
+	}
+}
+
+func TestRefreshMachine(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	public, secrets := generateKeys(t, 4, 1)
+
+	refresh := make(map[party.ID]*Machine, len(secrets))
+	for id, secret := range secrets {
+		refresh[id] = NewRefreshMachine(secret, public)
+	}
+	runMachines(t, refresh, rng)
+
+	var refreshed *eddsa.Public
+	for id, m := range refresh {
+		res, err := m.Result()
+		require.NoError(t, err)
+		assert.True(t, public.GroupKey.Equal(res.Public.GroupKey))
+		assert.NotEqual(t, secrets[id].Secret.Bytes(), res.SecretShare.Secret.Bytes())
+		if refreshed == nil {
+			refreshed = res.Public
+		}
+		assert.True(t, refreshed.Equal(res.Public))
+		secrets[id] = res.SecretShare
+	}
+
+	signers := party.IDSlice{1, 3}
+	message := []byte("refreshed")
+	sign := make(map[party.ID]*Machine, len(signers))
+	for _, id := range signers {
+		sign[id] = NewSignMachine(signers, secrets[id], refreshed, message)
+	}
+	runMachines(t, sign, rng)
+	res, err := sign[1].Result()
+	require.NoError(t, err)
+	assert.True(t, public.GroupKey.Verify(message, res.Signature))
+}
+
+func TestMachine_EarlyMessages(t *testing.T) {
+	const n, threshold = 3, 1
+	machines := make(map[party.ID]*Machine, n)
+	round1 := make(map[party.ID][]*Message)
+	for id := party.ID(1); id <= n; id++ {
+		machines[id] = NewKeygenMachine(id, n, threshold)
+		out, status := machines[id].Advance(time.Now(), nil)
+		require.Equal(t, StatusWaiting, status)
+		round1[id] = append([]*Message(nil), out...)
+	}
+
+	// party 3 completes round 1 first, and sends round 2 to party 1 before
+	// party 1 received any KeyGen1 message
+	out, status := machines[3].Advance(time.Now(), append(round1[1], round1[2]...))
+	require.Equal(t, StatusWaiting, status)
+	var early []*Message
+	for _, msg := range out {
+		if msg.To == 1 {
+			early = append(early, msg)
+		}
+	}
+	require.Len(t, early, 1)
+	_, status = machines[1].Advance(time.Now(), early)
+	require.Equal(t, StatusWaiting, status)
+	typ, ok := machines[1].Round()
+	require.True(t, ok)
+	assert.Equal(t, MessageTypeKeyGen1, typ)
+
+	_, err := machines[1].Result()
+	assert.Error(t, err, "no result before the machine is done")
+
+	_, status = machines[1].Advance(time.Now(), append(round1[2], round1[3]...))
+	require.Equal(t, StatusWaiting, status)
+	typ, _ = machines[1].Round()
+	assert.Equal(t, MessageTypeKeyGen2, typ, "the early KeyGen2 message was kept")
+}
+
+func TestMachine_Failures(t *testing.T) {
+	m := NewKeygenMachine(1, 3, 1)
+	out, _ := m.Advance(time.Now(), nil)
+	msg := out[0]
+
+	other := NewKeygenMachine(2, 3, 1)
+	out, _ = other.Advance(time.Now(), nil)
+	_, status := m.Advance(time.Now(), []*Message{out[0], out[0]})
+	assert.Equal(t, StatusFailed, status, "duplicate message")
+	_, err := m.Result()
+	assert.Error(t, err)
+
+	// a failed machine stays failed
+	_, status = m.Advance(time.Now(), nil)
+	assert.Equal(t, StatusFailed, status)
+
+	m = NewKeygenMachine(1, 3, 1)
+	now := time.Now()
+	m.SetDeadline(now.Add(time.Second))
+	_, status = m.Advance(now, nil)
+	require.Equal(t, StatusWaiting, status)
+	_, status = m.Advance(now.Add(2*time.Second), nil)
+	require.Equal(t, StatusFailed, status)
+	_, err = m.Result()
+	assert.True(t, errors.Is(err, ErrSessionClosed))
+
+	// our own messages are ignored
+	m = NewKeygenMachine(1, 3, 1)
+	_, _ = m.Advance(time.Now(), nil)
+	_, status = m.Advance(time.Now(), []*Message{msg, msg})
+	assert.Equal(t, StatusWaiting, status)
+}
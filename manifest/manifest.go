@@ -0,0 +1,352 @@
+// Package manifest implements detached signature manifests for directory trees.
+//
+// A manifest lists the SHA-512 digest and size of every file below a root
+// directory. Its canonical Body is signed once with the group key, so a single
+// signing ceremony covers an entire release, and the tree can later be checked
+// against the manifest without contacting the signers.
+package manifest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Version is the current manifest format version. Version 1 manifests, whose
+// body is not length-prefixed, can still be verified.
+const Version = 2
+
+const (
+	bodyHeaderV1 = "frost-manifest-v1\n"
+	bodyHeader   = "frost-manifest-v2\n"
+)
+
+// Entry describes one file of the tree.
+type Entry struct {
+	// Path is relative to the root, with forward slashes.
+	Path string `json:"path"`
+	// Digest is the hex encoded SHA-512 of the content.
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+}
+
+// Manifest is the list of entries of a tree, and optionally its signature.
+type Manifest struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+
+	// GroupKey is the hex encoded ed25519 key of the signing group.
+	GroupKey string `json:"group_key,omitempty"`
+	// Fingerprint identifies GroupKey, see Fingerprint.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Signature is the hex encoded ed25519 signature over Body.
+	Signature string `json:"signature,omitempty"`
+}
+
+// Fingerprint returns a short identifier for an ed25519 public key:
+// the first 16 bytes of its SHA-256, hex encoded.
+func Fingerprint(pub ed25519.PublicKey) string {
+	digest := sha256.Sum256(pub)
+	return hex.EncodeToString(digest[:16])
+}
+
+// Build hashes every regular file below root with the given number of workers.
+// If workers is 0, runtime.NumCPU() is used.
+func Build(root string, workers int) (*Manifest, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+
+	entries, errs := hashAll(root, paths, workers)
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Manifest{Version: Version, Entries: entries}, nil
+}
+
+// hashAll hashes the files at paths concurrently, preserving their order.
+func hashAll(root string, paths []string, workers int) ([]Entry, []error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	entries := make([]Entry, len(paths))
+	errs := make([]error, len(paths))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				entries[i], errs[i] = hashFile(root, paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return entries, errs
+}
+
+func hashFile(root, path string) (Entry, error) {
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(path)))
+	if err != nil {
+		return Entry{}, fmt.Errorf("manifest: %w", err)
+	}
+	defer f.Close()
+
+	h := sha512.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return Entry{}, fmt.Errorf("manifest: %s: %w", path, err)
+	}
+	return Entry{Path: path, Digest: hex.EncodeToString(h.Sum(nil)), Size: n}, nil
+}
+
+// Body returns the canonical bytes that are signed. Entries are sorted by
+// path, one per line as "<length>:<digest> <size> <length>:<path>", where the
+// lengths in bytes make the body unambiguous for any path. Version 1 manifests
+// have lines "<digest> <size> <path>" instead.
+func (m *Manifest) Body() []byte {
+	entries := make([]Entry, len(m.Entries))
+	copy(entries, m.Entries)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	var b bytes.Buffer
+	if m.Version == 1 {
+		b.WriteString(bodyHeaderV1)
+		for _, e := range entries {
+			fmt.Fprintf(&b, "%s %d %s\n", e.Digest, e.Size, e.Path)
+		}
+		return b.Bytes()
+	}
+	b.WriteString(bodyHeader)
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%d:%s %d %d:%s\n", len(e.Digest), e.Digest, e.Size, len(e.Path), e.Path)
+	}
+	return b.Bytes()
+}
+
+// ParseBody reconstructs an unsigned manifest from its canonical body.
+func ParseBody(body []byte) (*Manifest, error) {
+	text := string(body)
+	switch {
+	case strings.HasPrefix(text, bodyHeader):
+		return parseBody(text[len(bodyHeader):])
+	case strings.HasPrefix(text, bodyHeaderV1):
+		return parseBodyV1(text[len(bodyHeaderV1):])
+	default:
+		return nil, errors.New("manifest: not a manifest body")
+	}
+}
+
+func parseBody(text string) (*Manifest, error) {
+	m := &Manifest{Version: Version}
+	for text != "" {
+		digest, rest, err := cutPrefixed(text)
+		if err != nil {
+			return nil, err
+		}
+		rest, ok := strings.CutPrefix(rest, " ")
+		if !ok {
+			return nil, errors.New("manifest: malformed entry")
+		}
+		sizeText, rest, ok := strings.Cut(rest, " ")
+		if !ok {
+			return nil, errors.New("manifest: malformed entry")
+		}
+		size, err := strconv.ParseInt(sizeText, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("manifest: malformed size %q", sizeText)
+		}
+		path, rest, err := cutPrefixed(rest)
+		if err != nil {
+			return nil, err
+		}
+		if text, ok = strings.CutPrefix(rest, "\n"); !ok {
+			return nil, errors.New("manifest: malformed entry")
+		}
+		m.Entries = append(m.Entries, Entry{Path: path, Digest: digest, Size: size})
+	}
+	return m, nil
+}
+
+// cutPrefixed returns the field "<length>:<field>" at the start of text, and
+// the text after it.
+func cutPrefixed(text string) (field, rest string, err error) {
+	lengthText, rest, ok := strings.Cut(text, ":")
+	if !ok {
+		return "", "", errors.New("manifest: malformed entry")
+	}
+	length, err := strconv.Atoi(lengthText)
+	if err != nil || length < 0 || length > len(rest) {
+		return "", "", fmt.Errorf("manifest: malformed length %q", lengthText)
+	}
+	return rest[:length], rest[length:], nil
+}
+
+func parseBodyV1(text string) (*Manifest, error) {
+	m := &Manifest{Version: 1}
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, " ", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("manifest: malformed line %q", line)
+		}
+		size, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("manifest: malformed size in %q", line)
+		}
+		m.Entries = append(m.Entries, Entry{Path: parts[2], Digest: parts[0], Size: size})
+	}
+	return m, nil
+}
+
+// Attach records the group key and its signature over Body.
+func (m *Manifest) Attach(pub ed25519.PublicKey, signature []byte) {
+	m.GroupKey = hex.EncodeToString(pub)
+	m.Fingerprint = Fingerprint(pub)
+	m.Signature = hex.EncodeToString(signature)
+}
+
+// VerifySignature checks that the manifest was signed by pub, the group key
+// the verifier trusts. The key embedded in the manifest only identifies the
+// signer, since anyone can sign a manifest with a key of their own.
+func (m *Manifest) VerifySignature(pub ed25519.PublicKey) error {
+	if len(pub) != ed25519.PublicKeySize {
+		return errors.New("manifest: a trusted group key is required")
+	}
+	if err := m.validate(); err != nil {
+		return err
+	}
+	embedded, err := hex.DecodeString(m.GroupKey)
+	if err != nil || len(embedded) != ed25519.PublicKeySize {
+		return errors.New("manifest: invalid group key")
+	}
+	if !bytes.Equal(pub, embedded) {
+		return errors.New("manifest: signed by a different group key")
+	}
+	if m.Fingerprint != Fingerprint(embedded) {
+		return errors.New("manifest: fingerprint does not match group key")
+	}
+	sig, err := hex.DecodeString(m.Signature)
+	if err != nil {
+		return errors.New("manifest: invalid signature encoding")
+	}
+	if !ed25519.Verify(embedded, m.Body(), sig) {
+		return errors.New("manifest: invalid signature")
+	}
+	return nil
+}
+
+// validate checks the version and entries of the manifest, so that its body
+// encodes them unambiguously: digests are SHA-512 in lowercase hex, paths are
+// unique, and, in version 1, contain no newline.
+func (m *Manifest) validate() error {
+	if m.Version != 1 && m.Version != Version {
+		return fmt.Errorf("manifest: unsupported version %d", m.Version)
+	}
+	paths := make(map[string]bool, len(m.Entries))
+	for _, e := range m.Entries {
+		digest, err := hex.DecodeString(e.Digest)
+		switch {
+		case err != nil || len(digest) != sha512.Size || hex.EncodeToString(digest) != e.Digest:
+			return fmt.Errorf("manifest: invalid digest of %q", e.Path)
+		case e.Size < 0:
+			return fmt.Errorf("manifest: invalid size of %q", e.Path)
+		case paths[e.Path]:
+			return fmt.Errorf("manifest: duplicate path %q", e.Path)
+		case m.Version == 1 && strings.Contains(e.Path, "\n"):
+			return fmt.Errorf("manifest: path %q contains a newline", e.Path)
+		}
+		paths[e.Path] = true
+	}
+	return nil
+}
+
+// Report is the result of checking a tree against a manifest.
+type Report struct {
+	// Verified lists the files whose content matches.
+	Verified []string
+	// Modified lists the files whose digest or size differs.
+	Modified []string
+	// Missing lists the files in the manifest that are not in the tree.
+	Missing []string
+	// Extra lists the files in the tree that are not in the manifest.
+	Extra []string
+}
+
+// OK returns true if the tree matches the manifest exactly.
+func (r *Report) OK() bool {
+	return len(r.Modified) == 0 && len(r.Missing) == 0 && len(r.Extra) == 0
+}
+
+// Check hashes the tree below root and compares it with the manifest entries.
+// It does not check the signature, see VerifySignature.
+func (m *Manifest) Check(root string, workers int) (*Report, error) {
+	current, err := Build(root, workers)
+	if err != nil {
+		return nil, err
+	}
+
+	actual := make(map[string]Entry, len(current.Entries))
+	for _, e := range current.Entries {
+		actual[e.Path] = e
+	}
+
+	report := &Report{}
+	for _, want := range m.Entries {
+		got, ok := actual[want.Path]
+		switch {
+		case !ok:
+			report.Missing = append(report.Missing, want.Path)
+		case got.Digest != want.Digest || got.Size != want.Size:
+			report.Modified = append(report.Modified, want.Path)
+		default:
+			report.Verified = append(report.Verified, want.Path)
+		}
+		delete(actual, want.Path)
+	}
+	for path := range actual {
+		report.Extra = append(report.Extra, path)
+	}
+	sort.Strings(report.Extra)
+
+	return report, nil
+}
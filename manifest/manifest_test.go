@@ -0,0 +1,120 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	for path, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+	}
+}
+
+func TestManifest(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"a.txt":         "alpha",
+		"dir/b.txt":     "beta",
+		"dir/sub/c.bin": "gamma",
+	})
+
+	m, err := Build(root, 2)
+	require.NoError(t, err)
+	assert.Len(t, m.Entries, 3)
+
+	parsed, err := ParseBody(m.Body())
+	require.NoError(t, err)
+	assert.Equal(t, m.Body(), parsed.Body())
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	m.Attach(pub, ed25519.Sign(priv, m.Body()))
+	require.NoError(t, m.VerifySignature(pub))
+	assert.Error(t, m.VerifySignature(nil), "the embedded key is not trusted")
+
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	assert.Error(t, m.VerifySignature(otherPub))
+
+	report, err := m.Check(root, 0)
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Len(t, report.Verified, 3)
+
+	writeTree(t, root, map[string]string{"dir/b.txt": "changed", "new.txt": "new"})
+	require.NoError(t, os.Remove(filepath.Join(root, "a.txt")))
+
+	report, err = m.Check(root, 0)
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, []string{"dir/b.txt"}, report.Modified)
+	assert.Equal(t, []string{"a.txt"}, report.Missing)
+	assert.Equal(t, []string{"new.txt"}, report.Extra)
+
+	m.Entries[0].Size++
+	assert.Error(t, m.VerifySignature(pub))
+}
+
+func TestBodyUnambiguous(t *testing.T) {
+	digest := func(b byte) string { return hex.EncodeToString(bytes.Repeat([]byte{b}, sha512.Size)) }
+	// in a body without lengths, the newline in the path of one entry would
+	// read as a second entry
+	one := &Manifest{Version: Version, Entries: []Entry{
+		{Path: "a\n" + digest(2) + " 5 b", Digest: digest(1), Size: 3},
+	}}
+	two := &Manifest{Version: Version, Entries: []Entry{
+		{Path: "a", Digest: digest(1), Size: 3},
+		{Path: "b", Digest: digest(2), Size: 5},
+	}}
+	assert.NotEqual(t, one.Body(), two.Body())
+	for _, m := range []*Manifest{one, two} {
+		parsed, err := ParseBody(m.Body())
+		require.NoError(t, err)
+		assert.Equal(t, m.Entries, parsed.Entries)
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	one.Attach(pub, ed25519.Sign(priv, one.Body()))
+	require.NoError(t, one.VerifySignature(pub))
+
+	// version 1 manifests stay verifiable, unless a path could be misread
+	v1 := &Manifest{Version: 1, Entries: two.Entries}
+	v1.Attach(pub, ed25519.Sign(priv, v1.Body()))
+	require.NoError(t, v1.VerifySignature(pub))
+	parsed, err := ParseBody(v1.Body())
+	require.NoError(t, err)
+	assert.Equal(t, v1.Entries, parsed.Entries)
+
+	v1 = &Manifest{Version: 1, Entries: one.Entries}
+	v1.Attach(pub, ed25519.Sign(priv, v1.Body()))
+	assert.Error(t, v1.VerifySignature(pub))
+
+	for _, bad := range []Entry{
+		{Path: "a", Digest: "a b", Size: 3},
+		{Path: "a", Digest: digest(1), Size: -1},
+	} {
+		m := &Manifest{Version: Version, Entries: []Entry{bad}}
+		m.Attach(pub, ed25519.Sign(priv, m.Body()))
+		assert.Error(t, m.VerifySignature(pub))
+	}
+	duplicate := &Manifest{Version: Version, Entries: []Entry{two.Entries[0], two.Entries[0]}}
+	duplicate.Attach(pub, ed25519.Sign(priv, duplicate.Body()))
+	assert.Error(t, duplicate.VerifySignature(pub))
+
+	for _, bad := range []string{"frost-manifest-v2\n3:abc 1 1:a", "frost-manifest-v2\n9:abc 1 1:a\n", "frost-manifest-v2\nabc 1 a\n"} {
+		_, err := ParseBody([]byte(bad))
+		assert.Error(t, err, bad)
+	}
+}
@@ -1,11 +1,17 @@
 package frost
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 
 	"errors"
+	"fmt"
 
+	"github.com/bartke/frost/eddsa"
 	"github.com/bartke/frost/party"
 	"github.com/bartke/frost/polynomial"
 	"github.com/bartke/frost/ristretto"
@@ -21,10 +27,133 @@ func decodeScalar(encoded string, scalar *ristretto.Scalar) error {
 	return err
 }
 
+// SessionID identifies a run of a protocol. All parties of a session must
+// agree on it before the session starts, e.g. the coordinator samples it with
+// NewSessionID and sends it along with the request to sign. Messages carry
+// the SessionID, so that a message recorded in one session is rejected by the
+// parties of another.
+//
+// The zero SessionID is that of sessions started without one, whose messages
+// can be replayed into any other such session.
+type SessionID [32]byte
+
+// NewSessionID returns a random SessionID.
+func NewSessionID() (SessionID, error) {
+	var id SessionID
+	if _, err := rand.Read(id[:]); err != nil {
+		return SessionID{}, fmt.Errorf("NewSessionID: %w", err)
+	}
+	return id, nil
+}
+
+// sessionDomain separates the digests of derived SessionIDs from other uses of SHA-256.
+const sessionDomain = "FROST-session-v1"
+
+// GroupSessionID returns the root of the SessionIDs derived for the group of
+// groupKey, see DeriveSessionID.
+func GroupSessionID(groupKey *eddsa.PublicKey) SessionID {
+	return SessionID{}.Derive("group", groupKey.ToEd25519())
+}
+
+// Derive returns the child of id for the label and data:
+//
+//	SHA-256("FROST-session-v1" ∥ id ∥ len(label) ∥ label ∥ data)
+//
+// where len(label) is a single byte. Children of the same parent with other
+// labels or data differ, so that IDs form a hierarchy, e.g. from a group to
+// the messages it signs.
+func (id SessionID) Derive(label string, data []byte) SessionID {
+	h := sha256.New()
+	h.Write([]byte(sessionDomain))
+	h.Write(id[:])
+	h.Write([]byte{byte(len(label))})
+	h.Write([]byte(label))
+	h.Write(data)
+	var child SessionID
+	h.Sum(child[:0])
+	return child
+}
+
+// DeriveSessionID returns the SessionID of the signing of message by
+// signerIDs with the key groupKey:
+//
+//	GroupSessionID(groupKey).Derive("message", SHA-512(message)).Derive("signers", signerIDs)
+//
+// where the signers are sorted, and each encoded in party.IDByteSize bytes.
+// Every party can recompute it from the request, so a coordinator need not
+// send a random ID, and a request that is retried gets the same ID, which lets
+// a coordinator recognize it, see the sessions package. For a prehashed
+// message, pass its SHA-512 digest.
+//
+// A derived ID does not keep apart two sessions for the same request: the
+// messages of one may be replayed into the other. Sessions that must not be
+// confused with their retries are started with a random ID from NewSessionID.
+func DeriveSessionID(groupKey *eddsa.PublicKey, message []byte, signerIDs party.IDSlice) SessionID {
+	digest := sha512.Sum512(message)
+	signerIDs = party.NewIDSlice(signerIDs)
+	ids := make([]byte, 0, len(signerIDs)*party.IDByteSize)
+	for _, id := range signerIDs {
+		ids = append(ids, id.Bytes()...)
+	}
+	return GroupSessionID(groupKey).Derive("message", digest[:]).Derive("signers", ids)
+}
+
+// IsZero returns true for the SessionID of sessions started without one.
+func (id SessionID) IsZero() bool {
+	return id == SessionID{}
+}
+
+// String returns the SessionID in hex.
+func (id SessionID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// encode returns the SessionID in base64, or "" for the zero SessionID.
+func (id SessionID) encode() string {
+	if id.IsZero() {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(id[:])
+}
+
+// decode sets id to the SessionID encoded by encode.
+func (id *SessionID) decode(encoded string) error {
+	*id = SessionID{}
+	if encoded == "" {
+		return nil
+	}
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+	if len(b) != len(id) {
+		return fmt.Errorf("session ID of %d bytes: %w", len(b), ErrInvalidMessage)
+	}
+	copy(id[:], b)
+	return nil
+}
+
+var (
+	// ErrWrongSession is returned for a message of another session.
+	ErrWrongSession = errors.New("message of another session")
+	// ErrWrongRound is returned for a message of another round.
+	ErrWrongRound = errors.New("message of another round")
+	// ErrUnknownSender is returned for a message from a party that does not
+	// take part in the protocol.
+	ErrUnknownSender = errors.New("unknown sender")
+)
+
+// MaxMessageSize bounds the binary encoding of the messages Validate accepts.
+// It allows for the KeyGen1 and Reshare1 messages of thresholds of up to 65000.
+const MaxMessageSize = 1 << 21
+
 type Header struct {
 	// Type is the message type
 	Type MessageType
 
+	// Round is the round of the protocol the message was sent in, see MessageType.Round.
+	Round uint8
+
 	// From returns the party.ID of the party who sent this message.
 	// Cannot be 0
 	From party.ID
@@ -33,26 +162,84 @@ type Header struct {
 	// If the message is intended for broadcast, the ID returned is 0 (invalid),
 	// therefore, you should call IsBroadcast() first.
 	To party.ID
+
+	// SessionID is the session the message was sent in.
+	SessionID SessionID
+}
+
+// IsBroadcast returns true if the message is intended for all parties.
+func (h *Header) IsBroadcast() bool {
+	return h.To == 0
+}
+
+// verify returns an error if the message was sent in another session than
+// session, or in another round than that of t. A Round of 0 is that of Type.
+func (h *Header) verify(t MessageType, session SessionID) error {
+	if h.SessionID != session {
+		return fmt.Errorf("%s from party %d: %w", h.Type, h.From, ErrWrongSession)
+	}
+	if h.Type != t || h.Round != 0 && h.Round != t.Round() {
+		return fmt.Errorf("%s of round %d from party %d, expected %s of round %d: %w",
+			h.Type, h.round(), h.From, t, t.Round(), ErrWrongRound)
+	}
+	return nil
+}
+
+// Validate returns an error wrapping ErrInvalidMessage, ErrWrongSession or
+// ErrWrongRound, unless m is a message of type t of the given session that
+// party selfID can process: its payload is set and at most MaxMessageSize
+// long, it is from another party than selfID, and it is a broadcast if t is
+// broadcast, or addressed to selfID otherwise. A selfID of 0 is that of an
+// observer such as the Aggregator, which receives broadcasts only.
+//
+// The round functions validate every message but our own, and return
+// ErrUnknownSender for a sender that does not take part in the protocol.
+func (m *Message) Validate(t MessageType, session SessionID, selfID party.ID) error {
+	if err := m.verify(t, session); err != nil {
+		return err
+	}
+	if !m.hasPayload() {
+		return fmt.Errorf("%s from party %d: missing payload: %w", m.Type, m.From, ErrInvalidMessage)
+	}
+	if size := m.Size(); size > MaxMessageSize {
+		return fmt.Errorf("%s from party %d: %d bytes: %w", m.Type, m.From, size, ErrInvalidMessage)
+	}
+	switch {
+	case m.From == 0:
+		return fmt.Errorf("%s from party 0: %w", m.Type, ErrInvalidMessage)
+	case m.From == m.To:
+		return fmt.Errorf("%s from party %d to itself: %w", m.Type, m.From, ErrInvalidMessage)
+	case m.From == selfID:
+		return fmt.Errorf("%s from ourselves: %w", m.Type, ErrInvalidMessage)
+	case t.isBroadcast() && !m.IsBroadcast():
+		return fmt.Errorf("%s from party %d to party %d, expected a broadcast: %w", m.Type, m.From, m.To, ErrInvalidMessage)
+	case !t.isBroadcast() && (selfID == 0 || m.To != selfID):
+		return fmt.Errorf("%s from party %d to party %d, expected one to party %d: %w", m.Type, m.From, m.To, selfID, ErrInvalidMessage)
+	}
+	return nil
+}
+
+// round returns Round, or the round of Type if it is not set.
+func (h *Header) round() uint8 {
+	if h.Round == 0 {
+		return h.Type.Round()
+	}
+	return h.Round
 }
 
 func (h *Header) MarshalJSON() ([]byte, error) {
-	return json.Marshal(&struct {
-		Type string `json:"type"`
-		From string `json:"from"`
-		To   string `json:"to"`
-	}{
-		Type: base64.StdEncoding.EncodeToString([]byte{byte(h.Type)}),
-		From: base64.StdEncoding.EncodeToString(h.From.Bytes()),
-		To:   base64.StdEncoding.EncodeToString(h.To.Bytes()),
-	})
+	aux := &jsonHeader{
+		Type:  base64.StdEncoding.EncodeToString([]byte{byte(h.Type)}),
+		Round: h.Round,
+		From:  base64.StdEncoding.EncodeToString(h.From.Bytes()),
+		To:    base64.StdEncoding.EncodeToString(h.To.Bytes()),
+	}
+	aux.Session = h.SessionID.encode()
+	return json.Marshal(aux)
 }
 
 func (h *Header) UnmarshalJSON(data []byte) error {
-	aux := &struct {
-		Type string `json:"type"`
-		From string `json:"from"`
-		To   string `json:"to"`
-	}{}
+	aux := &jsonHeader{}
 	if err := json.Unmarshal(data, aux); err != nil {
 		return err
 	}
@@ -60,7 +247,15 @@ func (h *Header) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	if len(typeBytes) != 1 {
+		return fmt.Errorf("header: %w", ErrInvalidMessage)
+	}
 	h.Type = MessageType(typeBytes[0])
+	// headers encoded before the round was added have the round of their type
+	h.Round = aux.Round
+	if h.Round == 0 {
+		h.Round = h.Type.Round()
+	}
 
 	fromBytes, err := base64.StdEncoding.DecodeString(aux.From)
 	if err != nil {
@@ -76,7 +271,19 @@ func (h *Header) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	h.To, err = party.FromBytes(toBytes)
-	return err
+	if err != nil {
+		return err
+	}
+
+	return h.SessionID.decode(aux.Session)
+}
+
+type jsonHeader struct {
+	Type    string `json:"type"`
+	Round   uint8  `json:"round,omitempty"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Session string `json:"session,omitempty"`
 }
 
 type Message struct {
@@ -85,6 +292,19 @@ type Message struct {
 	KeyGen2 *KeyGen2
 	Sign1   *Sign1
 	Sign2   *Sign2
+	// Reshare1 and Reshare2 are the messages of the resharing protocol, see ReshareInit.
+	Reshare1 *Reshare1
+	Reshare2 *Reshare2
+	// Repair1 and Repair2 are the messages of the share repair protocol, see RepairInit.
+	Repair1 *Repair1
+	Repair2 *Repair2
+	// Sign0 is the commitment to the message to sign, see SignCommitMessage.
+	Sign0 *Sign0
+	// SignBatch1 and SignBatch2 are the messages of a session signing a batch of messages, see SignBatchInit.
+	SignBatch1 *SignBatch1
+	SignBatch2 *SignBatch2
+	// KeyGenEcho is the digest of the KeyGen1 messages received, see KeygenEchoMessage.
+	KeyGenEcho *KeyGenEcho
 }
 
 var ErrInvalidMessage = errors.New("invalid message")
@@ -98,31 +318,124 @@ const (
 	MessageTypeKeyGen2
 	MessageTypeSign1
 	MessageTypeSign2
+	MessageTypeReshare1
+	MessageTypeReshare2
+	MessageTypeRepair1
+	MessageTypeRepair2
+	MessageTypeSign0
+	MessageTypeSignBatch1
+	MessageTypeSignBatch2
+	MessageTypeKeyGenEcho
 )
 
+// String returns the name of the message type, such as "KeyGen1".
+func (t MessageType) String() string {
+	switch t {
+	case MessageTypeNone:
+		return "None"
+	case MessageTypeKeyGen1:
+		return "KeyGen1"
+	case MessageTypeKeyGen2:
+		return "KeyGen2"
+	case MessageTypeSign1:
+		return "Sign1"
+	case MessageTypeSign2:
+		return "Sign2"
+	case MessageTypeReshare1:
+		return "Reshare1"
+	case MessageTypeReshare2:
+		return "Reshare2"
+	case MessageTypeRepair1:
+		return "Repair1"
+	case MessageTypeRepair2:
+		return "Repair2"
+	case MessageTypeSign0:
+		return "Sign0"
+	case MessageTypeSignBatch1:
+		return "SignBatch1"
+	case MessageTypeSignBatch2:
+		return "SignBatch2"
+	case MessageTypeKeyGenEcho:
+		return "KeyGenEcho"
+	default:
+		return fmt.Sprintf("MessageType(%d)", uint8(t))
+	}
+}
+
+// Round returns the round of its protocol in which a message of type t is
+// sent, starting at 1, or 0 for MessageTypeNone and unknown types. Sign0 is
+// sent in the optional round 0 of signing, before the nonces are exchanged,
+// and KeyGenEcho in round 2 of the key generation, before the KeyGen2
+// messages.
+func (t MessageType) Round() uint8 {
+	switch t {
+	case MessageTypeKeyGen1, MessageTypeSign1, MessageTypeReshare1, MessageTypeRepair1, MessageTypeSignBatch1:
+		return 1
+	case MessageTypeKeyGen2, MessageTypeSign2, MessageTypeReshare2, MessageTypeRepair2, MessageTypeSignBatch2, MessageTypeKeyGenEcho:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// isBroadcast returns true if messages of type t are sent to all parties,
+// rather than to one party each.
+func (t MessageType) isBroadcast() bool {
+	switch t {
+	case MessageTypeKeyGen2, MessageTypeReshare2, MessageTypeRepair1, MessageTypeRepair2:
+		return false
+	default:
+		return true
+	}
+}
+
 func (m *Message) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
-		Header  Header   `json:"header"`
-		KeyGen1 *KeyGen1 `json:"keygen1,omitempty"`
-		KeyGen2 *KeyGen2 `json:"keygen2,omitempty"`
-		Sign1   *Sign1   `json:"sign1,omitempty"`
-		Sign2   *Sign2   `json:"sign2,omitempty"`
+		Header     Header      `json:"header"`
+		KeyGen1    *KeyGen1    `json:"keygen1,omitempty"`
+		KeyGen2    *KeyGen2    `json:"keygen2,omitempty"`
+		Sign1      *Sign1      `json:"sign1,omitempty"`
+		Sign2      *Sign2      `json:"sign2,omitempty"`
+		Reshare1   *Reshare1   `json:"reshare1,omitempty"`
+		Reshare2   *Reshare2   `json:"reshare2,omitempty"`
+		Repair1    *Repair1    `json:"repair1,omitempty"`
+		Repair2    *Repair2    `json:"repair2,omitempty"`
+		Sign0      *Sign0      `json:"sign0,omitempty"`
+		SignBatch1 *SignBatch1 `json:"sign_batch1,omitempty"`
+		SignBatch2 *SignBatch2 `json:"sign_batch2,omitempty"`
+		KeyGenEcho *KeyGenEcho `json:"keygen_echo,omitempty"`
 	}{
-		Header:  m.Header,
-		KeyGen1: m.KeyGen1,
-		KeyGen2: m.KeyGen2,
-		Sign1:   m.Sign1,
-		Sign2:   m.Sign2,
+		Header:     m.Header,
+		KeyGen1:    m.KeyGen1,
+		KeyGen2:    m.KeyGen2,
+		Sign1:      m.Sign1,
+		Sign2:      m.Sign2,
+		Reshare1:   m.Reshare1,
+		Reshare2:   m.Reshare2,
+		Repair1:    m.Repair1,
+		Repair2:    m.Repair2,
+		Sign0:      m.Sign0,
+		SignBatch1: m.SignBatch1,
+		SignBatch2: m.SignBatch2,
+		KeyGenEcho: m.KeyGenEcho,
 	})
 }
 
 func (m *Message) UnmarshalJSON(data []byte) error {
 	aux := &struct {
-		Header  Header   `json:"header"`
-		KeyGen1 *KeyGen1 `json:"keygen1,omitempty"`
-		KeyGen2 *KeyGen2 `json:"keygen2,omitempty"`
-		Sign1   *Sign1   `json:"sign1,omitempty"`
-		Sign2   *Sign2   `json:"sign2,omitempty"`
+		Header     Header      `json:"header"`
+		KeyGen1    *KeyGen1    `json:"keygen1,omitempty"`
+		KeyGen2    *KeyGen2    `json:"keygen2,omitempty"`
+		Sign1      *Sign1      `json:"sign1,omitempty"`
+		Sign2      *Sign2      `json:"sign2,omitempty"`
+		Reshare1   *Reshare1   `json:"reshare1,omitempty"`
+		Reshare2   *Reshare2   `json:"reshare2,omitempty"`
+		Repair1    *Repair1    `json:"repair1,omitempty"`
+		Repair2    *Repair2    `json:"repair2,omitempty"`
+		Sign0      *Sign0      `json:"sign0,omitempty"`
+		SignBatch1 *SignBatch1 `json:"sign_batch1,omitempty"`
+		SignBatch2 *SignBatch2 `json:"sign_batch2,omitempty"`
+		KeyGenEcho *KeyGenEcho `json:"keygen_echo,omitempty"`
 	}{}
 	if err := json.Unmarshal(data, aux); err != nil {
 		return err
@@ -132,10 +445,53 @@ func (m *Message) UnmarshalJSON(data []byte) error {
 	m.KeyGen2 = aux.KeyGen2
 	m.Sign1 = aux.Sign1
 	m.Sign2 = aux.Sign2
+	m.Reshare1 = aux.Reshare1
+	m.Reshare2 = aux.Reshare2
+	m.Repair1 = aux.Repair1
+	m.Repair2 = aux.Repair2
+	m.Sign0 = aux.Sign0
+	m.SignBatch1 = aux.SignBatch1
+	m.SignBatch2 = aux.SignBatch2
+	m.KeyGenEcho = aux.KeyGenEcho
 
+	if !m.hasPayload() {
+		return fmt.Errorf("message %s: missing payload: %w", m.Type, ErrInvalidMessage)
+	}
 	return nil
 }
 
+// hasPayload returns true if the payload of the message's type is set, so
+// that the rounds can use it without checking.
+func (m *Message) hasPayload() bool {
+	switch m.Type {
+	case MessageTypeKeyGen1:
+		return m.KeyGen1 != nil && m.KeyGen1.Proof != nil && m.KeyGen1.Commitments != nil
+	case MessageTypeKeyGen2:
+		return m.KeyGen2 != nil
+	case MessageTypeSign1:
+		return m.Sign1 != nil
+	case MessageTypeSign2:
+		return m.Sign2 != nil
+	case MessageTypeReshare1:
+		return m.Reshare1 != nil && m.Reshare1.Commitments != nil
+	case MessageTypeReshare2:
+		return m.Reshare2 != nil
+	case MessageTypeRepair1:
+		return m.Repair1 != nil
+	case MessageTypeRepair2:
+		return m.Repair2 != nil
+	case MessageTypeSign0:
+		return m.Sign0 != nil
+	case MessageTypeSignBatch1:
+		return m.SignBatch1 != nil && len(m.SignBatch1.Nonces) > 0
+	case MessageTypeSignBatch2:
+		return m.SignBatch2 != nil && len(m.SignBatch2.Shares) > 0
+	case MessageTypeKeyGenEcho:
+		return m.KeyGenEcho != nil
+	}
+	return false
+}
+
 type KeyGen1 struct {
 	Proof       *zk.Schnorr
 	Commitments *polynomial.Exponent
@@ -144,8 +500,9 @@ type KeyGen1 struct {
 func NewKeyGen1(from party.ID, proof *zk.Schnorr, commitments *polynomial.Exponent) *Message {
 	return &Message{
 		Header: Header{
-			Type: MessageTypeKeyGen1,
-			From: from,
+			Type:  MessageTypeKeyGen1,
+			Round: 1,
+			From:  from,
 		},
 		KeyGen1: &KeyGen1{
 			Proof:       proof,
@@ -208,9 +565,10 @@ type KeyGen2 struct {
 func NewKeyGen2(from, to party.ID, share *ristretto.Scalar) *Message {
 	return &Message{
 		Header: Header{
-			Type: MessageTypeKeyGen2,
-			From: from,
-			To:   to,
+			Type:  MessageTypeKeyGen2,
+			Round: 2,
+			From:  from,
+			To:    to,
 		},
 		KeyGen2: &KeyGen2{Share: *share},
 	}
@@ -248,8 +606,9 @@ type Sign1 struct {
 func NewSign1(from party.ID, commitmentD, commitmentE *ristretto.Element) *Message {
 	return &Message{
 		Header: Header{
-			Type: MessageTypeSign1,
-			From: from,
+			Type:  MessageTypeSign1,
+			Round: 1,
+			From:  from,
 		},
 		Sign1: &Sign1{
 			Di: *commitmentD,
@@ -270,15 +629,19 @@ func (m *Sign1) MarshalJSON() ([]byte, error) {
 
 func (m *Sign1) UnmarshalJSON(data []byte) error {
 	aux := &struct {
-		Di ristretto.Element `json:"di"`
-		Ei ristretto.Element `json:"ei"`
+		Di *ristretto.Element `json:"di"`
+		Ei *ristretto.Element `json:"ei"`
 	}{}
 	if err := json.Unmarshal(data, aux); err != nil {
 		return err
 	}
+	// a missing commitment would leave an uninitialized point, which panics when used
+	if aux.Di == nil || aux.Ei == nil {
+		return fmt.Errorf("sign1: missing commitment: %w", ErrInvalidMessage)
+	}
 
-	m.Di = aux.Di
-	m.Ei = aux.Ei
+	m.Di = *aux.Di
+	m.Ei = *aux.Ei
 
 	return nil
 }
@@ -292,8 +655,9 @@ type Sign2 struct {
 func NewSign2(from party.ID, signatureShare *ristretto.Scalar) *Message {
 	return &Message{
 		Header: Header{
-			Type: MessageTypeSign2,
-			From: from,
+			Type:  MessageTypeSign2,
+			Round: 2,
+			From:  from,
 		},
 		Sign2: &Sign2{Zi: *signatureShare},
 	}
@@ -321,3 +685,823 @@ func (m *Sign2) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+type Reshare1 struct {
+	// Commitments are the commitments to the polynomial of the dealer, whose
+	// constant is the dealer's Lagrange weighted share of the group secret.
+	Commitments *polynomial.Exponent
+}
+
+func NewReshare1(from party.ID, commitments *polynomial.Exponent) *Message {
+	return &Message{
+		Header: Header{
+			Type:  MessageTypeReshare1,
+			Round: 1,
+			From:  from,
+		},
+		Reshare1: &Reshare1{Commitments: commitments},
+	}
+}
+
+func (m *Reshare1) MarshalJSON() ([]byte, error) {
+	commitmentsBytes, err := m.Commitments.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&struct {
+		Commitments string `json:"commitments"`
+	}{
+		Commitments: base64.StdEncoding.EncodeToString(commitmentsBytes),
+	})
+}
+
+func (m *Reshare1) UnmarshalJSON(data []byte) error {
+	aux := &struct {
+		Commitments string `json:"commitments"`
+	}{}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	commitmentsBytes, err := base64.StdEncoding.DecodeString(aux.Commitments)
+	if err != nil {
+		return err
+	}
+
+	m.Commitments = &polynomial.Exponent{}
+	return m.Commitments.UnmarshalBinary(commitmentsBytes)
+}
+
+type Reshare2 struct {
+	// Share is the evaluation of the dealer's polynomial for the destination party
+	Share ristretto.Scalar
+}
+
+func NewReshare2(from, to party.ID, share *ristretto.Scalar) *Message {
+	return &Message{
+		Header: Header{
+			Type:  MessageTypeReshare2,
+			Round: 2,
+			From:  from,
+			To:    to,
+		},
+		Reshare2: &Reshare2{Share: *share},
+	}
+}
+
+func (m *Reshare2) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Share string `json:"share"`
+	}{
+		Share: base64.StdEncoding.EncodeToString(m.Share.Bytes()),
+	})
+}
+
+func (m *Reshare2) UnmarshalJSON(data []byte) error {
+	aux := &struct {
+		Share string `json:"share"`
+	}{}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	return decodeScalar(aux.Share, &m.Share)
+}
+
+type Repair1 struct {
+	// Delta is the share for the destination helper of the sender's
+	// contribution to the repaired share
+	Delta ristretto.Scalar
+}
+
+func NewRepair1(from, to party.ID, delta *ristretto.Scalar) *Message {
+	return &Message{
+		Header: Header{
+			Type:  MessageTypeRepair1,
+			Round: 1,
+			From:  from,
+			To:    to,
+		},
+		Repair1: &Repair1{Delta: *delta},
+	}
+}
+
+func (m *Repair1) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Delta string `json:"delta"`
+	}{
+		Delta: base64.StdEncoding.EncodeToString(m.Delta.Bytes()),
+	})
+}
+
+func (m *Repair1) UnmarshalJSON(data []byte) error {
+	aux := &struct {
+		Delta string `json:"delta"`
+	}{}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	return decodeScalar(aux.Delta, &m.Delta)
+}
+
+type Repair2 struct {
+	// Sigma is the sum of the Repair1 shares the sending helper received
+	Sigma ristretto.Scalar
+}
+
+func NewRepair2(from, to party.ID, sigma *ristretto.Scalar) *Message {
+	return &Message{
+		Header: Header{
+			Type:  MessageTypeRepair2,
+			Round: 2,
+			From:  from,
+			To:    to,
+		},
+		Repair2: &Repair2{Sigma: *sigma},
+	}
+}
+
+func (m *Repair2) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Sigma string `json:"sigma"`
+	}{
+		Sigma: base64.StdEncoding.EncodeToString(m.Sigma.Bytes()),
+	})
+}
+
+func (m *Repair2) UnmarshalJSON(data []byte) error {
+	aux := &struct {
+		Sigma string `json:"sigma"`
+	}{}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	return decodeScalar(aux.Sigma, &m.Sigma)
+}
+
+type Sign0 struct {
+	// Digest commits to the message the sender was asked to sign
+	Digest [64]byte
+}
+
+func NewSign0(from party.ID, digest *[64]byte) *Message {
+	return &Message{
+		Header: Header{
+			Type: MessageTypeSign0,
+			From: from,
+		},
+		Sign0: &Sign0{Digest: *digest},
+	}
+}
+
+func (m *Sign0) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Digest string `json:"digest"`
+	}{
+		Digest: base64.StdEncoding.EncodeToString(m.Digest[:]),
+	})
+}
+
+func (m *Sign0) UnmarshalJSON(data []byte) error {
+	aux := &struct {
+		Digest string `json:"digest"`
+	}{}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	digest, err := base64.StdEncoding.DecodeString(aux.Digest)
+	if err != nil {
+		return err
+	}
+	return m.UnmarshalBinary(digest)
+}
+
+type KeyGenEcho struct {
+	// Digest commits to the KeyGen1 messages the sender received
+	Digest [64]byte
+}
+
+func NewKeyGenEcho(from party.ID, digest *[64]byte) *Message {
+	return &Message{
+		Header: Header{
+			Type:  MessageTypeKeyGenEcho,
+			Round: 2,
+			From:  from,
+		},
+		KeyGenEcho: &KeyGenEcho{Digest: *digest},
+	}
+}
+
+func (m *KeyGenEcho) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Digest string `json:"digest"`
+	}{
+		Digest: base64.StdEncoding.EncodeToString(m.Digest[:]),
+	})
+}
+
+func (m *KeyGenEcho) UnmarshalJSON(data []byte) error {
+	aux := &struct {
+		Digest string `json:"digest"`
+	}{}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	digest, err := base64.StdEncoding.DecodeString(aux.Digest)
+	if err != nil {
+		return err
+	}
+	return m.UnmarshalBinary(digest)
+}
+
+type SignBatch1 struct {
+	// Nonces are the commitments Di, Ei of the sender for every message of
+	// the batch, in order.
+	Nonces []Sign1
+}
+
+func NewSignBatch1(from party.ID, nonces []Sign1) *Message {
+	return &Message{
+		Header: Header{
+			Type:  MessageTypeSignBatch1,
+			Round: 1,
+			From:  from,
+		},
+		SignBatch1: &SignBatch1{Nonces: nonces},
+	}
+}
+
+func (m *SignBatch1) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Nonces []Sign1 `json:"nonces"`
+	}{
+		Nonces: m.Nonces,
+	})
+}
+
+func (m *SignBatch1) UnmarshalJSON(data []byte) error {
+	aux := &struct {
+		Nonces []Sign1 `json:"nonces"`
+	}{}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	if len(aux.Nonces) > MaxBatchSize {
+		return fmt.Errorf("sign_batch1: %d nonces: %w", len(aux.Nonces), ErrInvalidMessage)
+	}
+	m.Nonces = aux.Nonces
+	return nil
+}
+
+type SignBatch2 struct {
+	// Shares are the signature shares Zi of the sender for every message of
+	// the batch, in order.
+	Shares []ristretto.Scalar
+}
+
+func NewSignBatch2(from party.ID, shares []ristretto.Scalar) *Message {
+	return &Message{
+		Header: Header{
+			Type:  MessageTypeSignBatch2,
+			Round: 2,
+			From:  from,
+		},
+		SignBatch2: &SignBatch2{Shares: shares},
+	}
+}
+
+func (m *SignBatch2) MarshalJSON() ([]byte, error) {
+	shares := make([]string, len(m.Shares))
+	for i := range m.Shares {
+		shares[i] = base64.StdEncoding.EncodeToString(m.Shares[i].Bytes())
+	}
+	return json.Marshal(&struct {
+		Shares []string `json:"shares"`
+	}{
+		Shares: shares,
+	})
+}
+
+func (m *SignBatch2) UnmarshalJSON(data []byte) error {
+	aux := &struct {
+		Shares []string `json:"shares"`
+	}{}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	if len(aux.Shares) > MaxBatchSize {
+		return fmt.Errorf("sign_batch2: %d shares: %w", len(aux.Shares), ErrInvalidMessage)
+	}
+	shares := make([]ristretto.Scalar, len(aux.Shares))
+	for i, share := range aux.Shares {
+		if err := decodeScalar(share, &shares[i]); err != nil {
+			return err
+		}
+	}
+	m.Shares = shares
+	return nil
+}
+
+//
+// FROSTMarshaler
+//
+
+// MessageFormatVersion is the first byte of the binary encoding of a Message.
+//
+// The encoding is: version ∥ type ∥ round ∥ from ∥ to ∥ session ∥ payload,
+// where from and to take party.IDByteSize bytes, the session 32 bytes, and
+// the payload depends on the type:
+//
+//	KeyGen1:    proof (64) ∥ commitments (degree ∥ 32 per coefficient)
+//	KeyGen2:    share (32)
+//	Sign1:      Di (32) ∥ Ei (32)
+//	Sign2:      Zi (32)
+//	Reshare1:   commitments (degree ∥ 32 per coefficient)
+//	Reshare2:   share (32)
+//	Repair1:    delta (32)
+//	Repair2:    sigma (32)
+//	Sign0:      digest (64)
+//	SignBatch1: count ∥ (Di (32) ∥ Ei (32)) for every message
+//	SignBatch2: count ∥ Zi (32) for every message
+//	KeyGenEcho: digest (64)
+//
+// count is the number of messages of the batch, in party.IDByteSize bytes.
+//
+// Messages of version 1, which were encoded as version ∥ type ∥ from ∥ to ∥
+// payload, are still decoded, with the zero SessionID and the round of their type.
+const MessageFormatVersion byte = 2
+
+const (
+	// headerSize is the size of the version, type, round, from, to and session.
+	headerSize = 3 + 2*party.IDByteSize + len(SessionID{})
+	// headerSizeV1 is the size of the version, type, from and to of version 1.
+	headerSizeV1 = 2 + 2*party.IDByteSize
+)
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (m *Message) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, m.Size())
+	return m.BytesAppend(buf)
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (m *Message) UnmarshalBinary(data []byte) error {
+	var (
+		header  Header
+		payload []byte
+	)
+	switch {
+	case len(data) == 0:
+		return fmt.Errorf("message: %w", ErrInvalidMessage)
+	case data[0] == 1:
+		if len(data) < headerSizeV1 {
+			return fmt.Errorf("message: %w", ErrInvalidMessage)
+		}
+		header.Type = MessageType(data[1])
+		header.Round = header.Type.Round()
+		header.From, _ = party.FromBytes(data[2:])
+		header.To, _ = party.FromBytes(data[2+party.IDByteSize:])
+		payload = data[headerSizeV1:]
+	case data[0] == MessageFormatVersion:
+		if len(data) < headerSize {
+			return fmt.Errorf("message: %w", ErrInvalidMessage)
+		}
+		header.Type = MessageType(data[1])
+		header.Round = data[2]
+		header.From, _ = party.FromBytes(data[3:])
+		header.To, _ = party.FromBytes(data[3+party.IDByteSize:])
+		copy(header.SessionID[:], data[3+2*party.IDByteSize:])
+		payload = data[headerSize:]
+	default:
+		return fmt.Errorf("message: unsupported format version %d", data[0])
+	}
+
+	*m = Message{Header: header}
+	var err error
+	switch header.Type {
+	case MessageTypeKeyGen1:
+		m.KeyGen1 = &KeyGen1{}
+		err = m.KeyGen1.UnmarshalBinary(payload)
+	case MessageTypeKeyGen2:
+		m.KeyGen2 = &KeyGen2{}
+		err = m.KeyGen2.UnmarshalBinary(payload)
+	case MessageTypeSign1:
+		m.Sign1 = &Sign1{}
+		err = m.Sign1.UnmarshalBinary(payload)
+	case MessageTypeSign2:
+		m.Sign2 = &Sign2{}
+		err = m.Sign2.UnmarshalBinary(payload)
+	case MessageTypeReshare1:
+		m.Reshare1 = &Reshare1{}
+		err = m.Reshare1.UnmarshalBinary(payload)
+	case MessageTypeReshare2:
+		m.Reshare2 = &Reshare2{}
+		err = m.Reshare2.UnmarshalBinary(payload)
+	case MessageTypeRepair1:
+		m.Repair1 = &Repair1{}
+		err = m.Repair1.UnmarshalBinary(payload)
+	case MessageTypeRepair2:
+		m.Repair2 = &Repair2{}
+		err = m.Repair2.UnmarshalBinary(payload)
+	case MessageTypeSign0:
+		m.Sign0 = &Sign0{}
+		err = m.Sign0.UnmarshalBinary(payload)
+	case MessageTypeSignBatch1:
+		m.SignBatch1 = &SignBatch1{}
+		err = m.SignBatch1.UnmarshalBinary(payload)
+	case MessageTypeSignBatch2:
+		m.SignBatch2 = &SignBatch2{}
+		err = m.SignBatch2.UnmarshalBinary(payload)
+	case MessageTypeKeyGenEcho:
+		m.KeyGenEcho = &KeyGenEcho{}
+		err = m.KeyGenEcho.UnmarshalBinary(payload)
+	default:
+		return fmt.Errorf("message: unknown type %d: %w", header.Type, ErrInvalidMessage)
+	}
+	if err != nil {
+		return fmt.Errorf("message %s: %w", header.Type, err)
+	}
+	return nil
+}
+
+func (m *Message) BytesAppend(existing []byte) ([]byte, error) {
+	existing = append(existing, MessageFormatVersion, byte(m.Type), m.Round)
+	existing = append(existing, m.From.Bytes()...)
+	existing = append(existing, m.To.Bytes()...)
+	existing = append(existing, m.SessionID[:]...)
+
+	switch {
+	case m.Type == MessageTypeKeyGen1 && m.KeyGen1 != nil:
+		return m.KeyGen1.BytesAppend(existing)
+	case m.Type == MessageTypeKeyGen2 && m.KeyGen2 != nil:
+		return m.KeyGen2.BytesAppend(existing)
+	case m.Type == MessageTypeSign1 && m.Sign1 != nil:
+		return m.Sign1.BytesAppend(existing)
+	case m.Type == MessageTypeSign2 && m.Sign2 != nil:
+		return m.Sign2.BytesAppend(existing)
+	case m.Type == MessageTypeReshare1 && m.Reshare1 != nil:
+		return m.Reshare1.BytesAppend(existing)
+	case m.Type == MessageTypeReshare2 && m.Reshare2 != nil:
+		return m.Reshare2.BytesAppend(existing)
+	case m.Type == MessageTypeRepair1 && m.Repair1 != nil:
+		return m.Repair1.BytesAppend(existing)
+	case m.Type == MessageTypeRepair2 && m.Repair2 != nil:
+		return m.Repair2.BytesAppend(existing)
+	case m.Type == MessageTypeSign0 && m.Sign0 != nil:
+		return m.Sign0.BytesAppend(existing)
+	case m.Type == MessageTypeSignBatch1 && m.SignBatch1 != nil:
+		return m.SignBatch1.BytesAppend(existing)
+	case m.Type == MessageTypeSignBatch2 && m.SignBatch2 != nil:
+		return m.SignBatch2.BytesAppend(existing)
+	case m.Type == MessageTypeKeyGenEcho && m.KeyGenEcho != nil:
+		return m.KeyGenEcho.BytesAppend(existing)
+	}
+	return nil, fmt.Errorf("message: no payload for type %s: %w", m.Type, ErrInvalidMessage)
+}
+
+// Size returns the length of the binary encoding of m.
+func (m *Message) Size() int {
+	size := headerSize
+	switch {
+	case m.KeyGen1 != nil:
+		size += m.KeyGen1.Size()
+	case m.KeyGen2 != nil:
+		size += m.KeyGen2.Size()
+	case m.Sign1 != nil:
+		size += m.Sign1.Size()
+	case m.Sign2 != nil:
+		size += m.Sign2.Size()
+	case m.Reshare1 != nil:
+		size += m.Reshare1.Size()
+	case m.Reshare2 != nil:
+		size += m.Reshare2.Size()
+	case m.Repair1 != nil:
+		size += m.Repair1.Size()
+	case m.Repair2 != nil:
+		size += m.Repair2.Size()
+	case m.Sign0 != nil:
+		size += m.Sign0.Size()
+	case m.SignBatch1 != nil:
+		size += m.SignBatch1.Size()
+	case m.SignBatch2 != nil:
+		size += m.SignBatch2.Size()
+	case m.KeyGenEcho != nil:
+		size += m.KeyGenEcho.Size()
+	}
+	return size
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (m *KeyGen1) MarshalBinary() ([]byte, error) {
+	return m.BytesAppend(make([]byte, 0, m.Size()))
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (m *KeyGen1) UnmarshalBinary(data []byte) error {
+	proof := &zk.Schnorr{}
+	if len(data) < proof.Size() {
+		return fmt.Errorf("keygen1: %w", ErrInvalidMessage)
+	}
+	if err := proof.UnmarshalBinary(data[:proof.Size()]); err != nil {
+		return err
+	}
+	commitments := &polynomial.Exponent{}
+	if err := commitments.UnmarshalBinary(data[proof.Size():]); err != nil {
+		return err
+	}
+	m.Proof, m.Commitments = proof, commitments
+	return nil
+}
+
+func (m *KeyGen1) BytesAppend(existing []byte) ([]byte, error) {
+	existing, err := m.Proof.BytesAppend(existing)
+	if err != nil {
+		return nil, err
+	}
+	return m.Commitments.BytesAppend(existing)
+}
+
+func (m *KeyGen1) Size() int {
+	return m.Proof.Size() + m.Commitments.Size()
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (m *KeyGen2) MarshalBinary() ([]byte, error) {
+	return m.BytesAppend(make([]byte, 0, m.Size()))
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (m *KeyGen2) UnmarshalBinary(data []byte) error {
+	return unmarshalScalar("keygen2", data, &m.Share)
+}
+
+func (m *KeyGen2) BytesAppend(existing []byte) ([]byte, error) {
+	return append(existing, m.Share.Bytes()...), nil
+}
+
+func (m *KeyGen2) Size() int {
+	return 32
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (m *Sign1) MarshalBinary() ([]byte, error) {
+	return m.BytesAppend(make([]byte, 0, m.Size()))
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (m *Sign1) UnmarshalBinary(data []byte) error {
+	if len(data) != m.Size() {
+		return fmt.Errorf("sign1: %w", ErrInvalidMessage)
+	}
+	if _, err := m.Di.SetCanonicalBytes(data[:32]); err != nil {
+		return fmt.Errorf("sign1.Di: %w", err)
+	}
+	if _, err := m.Ei.SetCanonicalBytes(data[32:]); err != nil {
+		return fmt.Errorf("sign1.Ei: %w", err)
+	}
+	return nil
+}
+
+func (m *Sign1) BytesAppend(existing []byte) ([]byte, error) {
+	existing = append(existing, m.Di.Bytes()...)
+	existing = append(existing, m.Ei.Bytes()...)
+	return existing, nil
+}
+
+func (m *Sign1) Size() int {
+	return 64
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (m *Sign2) MarshalBinary() ([]byte, error) {
+	return m.BytesAppend(make([]byte, 0, m.Size()))
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (m *Sign2) UnmarshalBinary(data []byte) error {
+	return unmarshalScalar("sign2", data, &m.Zi)
+}
+
+func (m *Sign2) BytesAppend(existing []byte) ([]byte, error) {
+	return append(existing, m.Zi.Bytes()...), nil
+}
+
+func (m *Sign2) Size() int {
+	return 32
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (m *Reshare1) MarshalBinary() ([]byte, error) {
+	return m.BytesAppend(make([]byte, 0, m.Size()))
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (m *Reshare1) UnmarshalBinary(data []byte) error {
+	commitments := &polynomial.Exponent{}
+	if err := commitments.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	m.Commitments = commitments
+	return nil
+}
+
+func (m *Reshare1) BytesAppend(existing []byte) ([]byte, error) {
+	return m.Commitments.BytesAppend(existing)
+}
+
+func (m *Reshare1) Size() int {
+	return m.Commitments.Size()
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (m *Reshare2) MarshalBinary() ([]byte, error) {
+	return m.BytesAppend(make([]byte, 0, m.Size()))
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (m *Reshare2) UnmarshalBinary(data []byte) error {
+	return unmarshalScalar("reshare2", data, &m.Share)
+}
+
+func (m *Reshare2) BytesAppend(existing []byte) ([]byte, error) {
+	return append(existing, m.Share.Bytes()...), nil
+}
+
+func (m *Reshare2) Size() int {
+	return 32
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (m *Repair1) MarshalBinary() ([]byte, error) {
+	return m.BytesAppend(make([]byte, 0, m.Size()))
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (m *Repair1) UnmarshalBinary(data []byte) error {
+	return unmarshalScalar("repair1", data, &m.Delta)
+}
+
+func (m *Repair1) BytesAppend(existing []byte) ([]byte, error) {
+	return append(existing, m.Delta.Bytes()...), nil
+}
+
+func (m *Repair1) Size() int {
+	return 32
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (m *Repair2) MarshalBinary() ([]byte, error) {
+	return m.BytesAppend(make([]byte, 0, m.Size()))
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (m *Repair2) UnmarshalBinary(data []byte) error {
+	return unmarshalScalar("repair2", data, &m.Sigma)
+}
+
+func (m *Repair2) BytesAppend(existing []byte) ([]byte, error) {
+	return append(existing, m.Sigma.Bytes()...), nil
+}
+
+func (m *Repair2) Size() int {
+	return 32
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (m *Sign0) MarshalBinary() ([]byte, error) {
+	return m.BytesAppend(make([]byte, 0, m.Size()))
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (m *Sign0) UnmarshalBinary(data []byte) error {
+	if len(data) != m.Size() {
+		return fmt.Errorf("sign0: %w", ErrInvalidMessage)
+	}
+	copy(m.Digest[:], data)
+	return nil
+}
+
+func (m *Sign0) BytesAppend(existing []byte) ([]byte, error) {
+	return append(existing, m.Digest[:]...), nil
+}
+
+func (m *Sign0) Size() int {
+	return len(m.Digest)
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (m *KeyGenEcho) MarshalBinary() ([]byte, error) {
+	return m.BytesAppend(make([]byte, 0, m.Size()))
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (m *KeyGenEcho) UnmarshalBinary(data []byte) error {
+	if len(data) != m.Size() {
+		return fmt.Errorf("keygen echo: %w", ErrInvalidMessage)
+	}
+	copy(m.Digest[:], data)
+	return nil
+}
+
+func (m *KeyGenEcho) BytesAppend(existing []byte) ([]byte, error) {
+	return append(existing, m.Digest[:]...), nil
+}
+
+func (m *KeyGenEcho) Size() int {
+	return len(m.Digest)
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (m *SignBatch1) MarshalBinary() ([]byte, error) {
+	return m.BytesAppend(make([]byte, 0, m.Size()))
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (m *SignBatch1) UnmarshalBinary(data []byte) error {
+	n, err := batchCount("sign_batch1", data, 64)
+	if err != nil {
+		return err
+	}
+	nonces := make([]Sign1, n)
+	for i := range nonces {
+		offset := party.IDByteSize + 64*i
+		if err := nonces[i].UnmarshalBinary(data[offset : offset+64]); err != nil {
+			return fmt.Errorf("sign_batch1: nonces %d: %w", i, err)
+		}
+	}
+	m.Nonces = nonces
+	return nil
+}
+
+func (m *SignBatch1) BytesAppend(existing []byte) ([]byte, error) {
+	existing = append(existing, party.Size(len(m.Nonces)).Bytes()...)
+	for i := range m.Nonces {
+		existing, _ = m.Nonces[i].BytesAppend(existing)
+	}
+	return existing, nil
+}
+
+func (m *SignBatch1) Size() int {
+	return party.IDByteSize + 64*len(m.Nonces)
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (m *SignBatch2) MarshalBinary() ([]byte, error) {
+	return m.BytesAppend(make([]byte, 0, m.Size()))
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (m *SignBatch2) UnmarshalBinary(data []byte) error {
+	n, err := batchCount("sign_batch2", data, 32)
+	if err != nil {
+		return err
+	}
+	shares := make([]ristretto.Scalar, n)
+	for i := range shares {
+		offset := party.IDByteSize + 32*i
+		if err := unmarshalScalar("sign_batch2", data[offset:offset+32], &shares[i]); err != nil {
+			return err
+		}
+	}
+	m.Shares = shares
+	return nil
+}
+
+func (m *SignBatch2) BytesAppend(existing []byte) ([]byte, error) {
+	existing = append(existing, party.Size(len(m.Shares)).Bytes()...)
+	for i := range m.Shares {
+		existing = append(existing, m.Shares[i].Bytes()...)
+	}
+	return existing, nil
+}
+
+func (m *SignBatch2) Size() int {
+	return party.IDByteSize + 32*len(m.Shares)
+}
+
+// batchCount returns the count of a batch payload in data, which must be
+// followed by exactly count items of size bytes each.
+func batchCount(name string, data []byte, size int) (int, error) {
+	if len(data) < party.IDByteSize {
+		return 0, fmt.Errorf("%s: %w", name, ErrInvalidMessage)
+	}
+	count, _ := party.FromBytes(data)
+	n := int(count)
+	if n == 0 || n > MaxBatchSize || len(data) != party.IDByteSize+size*n {
+		return 0, fmt.Errorf("%s: %w", name, ErrInvalidMessage)
+	}
+	return n, nil
+}
+
+// unmarshalScalar sets s to the canonical encoding in data, which must be exactly 32 bytes.
+func unmarshalScalar(name string, data []byte, s *ristretto.Scalar) error {
+	if len(data) != 32 {
+		return fmt.Errorf("%s: %w", name, ErrInvalidMessage)
+	}
+	if _, err := s.SetCanonicalBytes(data); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,420 @@
+package frost
+
+import (
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/polynomial"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMessages returns a message of every type.
+func testMessages(t testing.TB) []*Message {
+	public, secrets := generateKeys(t, 3, 1)
+	keygen1, _, err := KeygenInit(1, 3, 1)
+	require.NoError(t, err)
+	session, err := NewSessionID()
+	require.NoError(t, err)
+	sign1, state, err := SignInitWithSession(session, party.IDSlice{1, 2}, secrets[1], public, []byte("binary"))
+	require.NoError(t, err)
+	self := state.Signers[1]
+	batch1, _, err := SignBatchInitWithSession(session, party.IDSlice{1, 2}, secrets[1], public, [][]byte{[]byte("a"), []byte("b")})
+	require.NoError(t, err)
+
+	return []*Message{
+		keygen1,
+		NewKeyGen2(1, 2, scalar.NewScalarRandom()),
+		sign1,
+		NewSign1(2, &self.Di, &self.Ei),
+		NewSign2(2, scalar.NewScalarRandom()),
+		NewReshare1(3, polynomial.NewPolynomialExponent(polynomial.NewPolynomial(2, scalar.NewScalarRandom()))),
+		NewReshare2(3, 1, scalar.NewScalarRandom()),
+		NewRepair1(1, 2, scalar.NewScalarRandom()),
+		NewRepair2(2, 3, scalar.NewScalarRandom()),
+		SignCommitMessage(state),
+		batch1,
+		NewSignBatch2(2, []ristretto.Scalar{*scalar.NewScalarRandom(), *scalar.NewScalarRandom()}),
+		NewKeyGenEcho(3, &[64]byte{4, 5, 6}),
+	}
+}
+
+// equalPayloads compares the payloads of a and b. Elements are compared as
+// ristretto points, since their JSON encoding is not canonical.
+func equalPayloads(a, b *Message) bool {
+	switch {
+	case a.KeyGen1 != nil && b.KeyGen1 != nil:
+		return a.KeyGen1.Proof.Equal(b.KeyGen1.Proof) && a.KeyGen1.Commitments.Equal(b.KeyGen1.Commitments)
+	case a.KeyGen2 != nil && b.KeyGen2 != nil:
+		return a.KeyGen2.Share.Equal(&b.KeyGen2.Share) == 1
+	case a.Sign1 != nil && b.Sign1 != nil:
+		return a.Sign1.Di.Equal(&b.Sign1.Di) == 1 && a.Sign1.Ei.Equal(&b.Sign1.Ei) == 1
+	case a.Sign2 != nil && b.Sign2 != nil:
+		return a.Sign2.Zi.Equal(&b.Sign2.Zi) == 1
+	case a.Reshare1 != nil && b.Reshare1 != nil:
+		return a.Reshare1.Commitments.Equal(b.Reshare1.Commitments)
+	case a.Reshare2 != nil && b.Reshare2 != nil:
+		return a.Reshare2.Share.Equal(&b.Reshare2.Share) == 1
+	case a.Repair1 != nil && b.Repair1 != nil:
+		return a.Repair1.Delta.Equal(&b.Repair1.Delta) == 1
+	case a.Repair2 != nil && b.Repair2 != nil:
+		return a.Repair2.Sigma.Equal(&b.Repair2.Sigma) == 1
+	case a.Sign0 != nil && b.Sign0 != nil:
+		return a.Sign0.Digest == b.Sign0.Digest
+	case a.KeyGenEcho != nil && b.KeyGenEcho != nil:
+		return a.KeyGenEcho.Digest == b.KeyGenEcho.Digest
+	case a.SignBatch1 != nil && b.SignBatch1 != nil:
+		if len(a.SignBatch1.Nonces) != len(b.SignBatch1.Nonces) {
+			return false
+		}
+		for i := range a.SignBatch1.Nonces {
+			x, y := &a.SignBatch1.Nonces[i], &b.SignBatch1.Nonces[i]
+			if x.Di.Equal(&y.Di) != 1 || x.Ei.Equal(&y.Ei) != 1 {
+				return false
+			}
+		}
+		return true
+	case a.SignBatch2 != nil && b.SignBatch2 != nil:
+		if len(a.SignBatch2.Shares) != len(b.SignBatch2.Shares) {
+			return false
+		}
+		for i := range a.SignBatch2.Shares {
+			if a.SignBatch2.Shares[i].Equal(&b.SignBatch2.Shares[i]) != 1 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func TestMessage_Binary(t *testing.T) {
+	for _, msg := range testMessages(t) {
+		data, err := msg.MarshalBinary()
+		require.NoError(t, err, msg.Type)
+		assert.Len(t, data, msg.Size())
+		assert.Equal(t, MessageFormatVersion, data[0])
+
+		var decoded Message
+		require.NoError(t, decoded.UnmarshalBinary(data), msg.Type)
+		assert.Equal(t, msg.Header, decoded.Header)
+
+		assert.True(t, equalPayloads(msg, &decoded), msg.Type)
+
+		// both encodings hold the same message
+		expected, err := msg.MarshalJSON()
+		require.NoError(t, err)
+		var fromJSON Message
+		require.NoError(t, fromJSON.UnmarshalJSON(expected))
+		again, err := fromJSON.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, data, again, msg.Type)
+		assert.Less(t, len(data), len(expected))
+	}
+}
+
+func TestMessage_BinaryInvalid(t *testing.T) {
+	for _, msg := range testMessages(t) {
+		data, err := msg.MarshalBinary()
+		require.NoError(t, err)
+		var decoded Message
+		assert.Error(t, decoded.UnmarshalBinary(data[:len(data)-1]), msg.Type)
+		assert.Error(t, decoded.UnmarshalBinary(append(data, 0)), msg.Type)
+	}
+
+	data, err := NewSign2(1, scalar.NewScalarRandom()).MarshalBinary()
+	require.NoError(t, err)
+	var decoded Message
+	assert.NoError(t, decoded.UnmarshalBinary(data))
+
+	data[0] = MessageFormatVersion + 1
+	assert.Error(t, decoded.UnmarshalBinary(data))
+	data[0] = MessageFormatVersion
+	data[1] = 0xff
+	assert.True(t, errors.Is(decoded.UnmarshalBinary(data), ErrInvalidMessage))
+	assert.Error(t, decoded.UnmarshalBinary(data[:3]))
+
+	// the payload must match the type
+	_, err = (&Message{Header: Header{Type: MessageTypeSign1}, Sign2: &Sign2{}}).MarshalBinary()
+	assert.Error(t, err)
+}
+
+func TestMessage_BinaryV1(t *testing.T) {
+	msg := NewSign2(2, scalar.NewScalarRandom())
+	data := []byte{1, byte(MessageTypeSign2)}
+	data = append(data, msg.From.Bytes()...)
+	data = append(data, msg.To.Bytes()...)
+	data = append(data, msg.Sign2.Zi.Bytes()...)
+
+	var decoded Message
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, msg.Header, decoded.Header)
+	assert.True(t, equalPayloads(msg, &decoded))
+}
+
+func TestMessage_JSONWithoutRound(t *testing.T) {
+	var msg Message
+	data := []byte(`{"header":{"type":"BA==","from":"AAE=","to":"AAA="},"sign2":{"zi":"BfPcYDOz9YJAF+onzXepznzGy9NfcME+RNmMM0jQFws="}}`)
+	require.NoError(t, msg.UnmarshalJSON(data))
+	assert.Equal(t, uint8(2), msg.Round)
+	assert.True(t, msg.SessionID.IsZero())
+}
+
+func TestMessage_JSONInvalid(t *testing.T) {
+	var msg Message
+	// a Sign1 header without its commitments
+	assert.True(t, errors.Is(msg.UnmarshalJSON([]byte(`{"header":{"type":"Aw==","from":"AAE=","to":"AAA="}}`)), ErrInvalidMessage))
+	assert.True(t, errors.Is(msg.UnmarshalJSON([]byte(`{"header":{"type":"Aw==","from":"AAE=","to":"AAA="},"sign1":{}}`)), ErrInvalidMessage))
+	// a payload of another type
+	assert.Error(t, msg.UnmarshalJSON([]byte(`{"header":{"type":"Aw==","from":"AAE=","to":"AAA="},"sign2":{"zi":"BfPcYDOz9YJAF+onzXepznzGy9NfcME+RNmMM0jQFws="}}`)))
+}
+
+// signSession initializes the signers of a session between 1 and 2 of a new group.
+func signSession(t *testing.T, session SessionID) (*eddsa.Public, map[party.ID]*SignerState, []*Message) {
+	public, secrets := generateKeys(t, 3, 1)
+	signers := party.IDSlice{1, 2}
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInitWithSession(session, signers, secrets[id], public, []byte("session"))
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	return public, states, round1
+}
+
+func TestSignInitWithSession(t *testing.T) {
+	session, err := NewSessionID()
+	require.NoError(t, err)
+	_, states, round1 := signSession(t, session)
+	for _, msg := range round1 {
+		assert.Equal(t, session, msg.SessionID)
+	}
+	sigs, err := runSignRounds(states, round1)
+	require.NoError(t, err)
+	assert.Len(t, sigs, 2)
+
+	// the messages of a session are rejected in another one
+	other, err := NewSessionID()
+	require.NoError(t, err)
+	_, states, _ = signSession(t, other)
+	_, _, err = SignRound1(states[1], round1)
+	assert.True(t, errors.Is(err, ErrWrongSession))
+
+	// and in sessions without an ID
+	_, states, _ = signSession(t, SessionID{})
+	_, _, err = SignRound1(states[1], round1)
+	assert.True(t, errors.Is(err, ErrWrongSession))
+}
+
+func TestDeriveSessionID(t *testing.T) {
+	public, _ := generateKeys(t, 3, 1)
+	other, _ := generateKeys(t, 3, 1)
+	message := []byte("message")
+
+	id := DeriveSessionID(public.GroupKey, message, party.IDSlice{1, 3})
+	assert.False(t, id.IsZero())
+	assert.Equal(t, id, DeriveSessionID(public.GroupKey, message, party.IDSlice{3, 1}))
+	digest := sha512.Sum512(message)
+	assert.Equal(t, GroupSessionID(public.GroupKey).Derive("message", digest[:]).Derive("signers", []byte{0, 1, 0, 3}), id)
+
+	assert.NotEqual(t, id, DeriveSessionID(other.GroupKey, message, party.IDSlice{1, 3}))
+	assert.NotEqual(t, id, DeriveSessionID(public.GroupKey, []byte("other"), party.IDSlice{1, 3}))
+	assert.NotEqual(t, id, DeriveSessionID(public.GroupKey, message, party.IDSlice{1, 2}))
+
+	// the label is delimited from the data
+	assert.NotEqual(t, id.Derive("ab", []byte("c")), id.Derive("a", []byte("bc")))
+}
+
+func TestSignRound_WrongRound(t *testing.T) {
+	session, err := NewSessionID()
+	require.NoError(t, err)
+	_, states, round1 := signSession(t, session)
+
+	replayed := *round1[1]
+	replayed.Round = 2
+	_, _, err = SignRound1(states[1], []*Message{round1[0], &replayed})
+	assert.True(t, errors.Is(err, ErrWrongRound))
+
+	sign2, _, err := SignRound1(states[2], round1)
+	require.NoError(t, err)
+	_, _, err = SignRound1(states[1], []*Message{round1[0], sign2})
+	assert.True(t, errors.Is(err, ErrWrongRound))
+
+	_, _, err = SignRound1(states[1], round1)
+	require.NoError(t, err)
+	_, _, err = SignRound2(states[1], round1)
+	assert.True(t, errors.Is(err, ErrWrongRound))
+}
+
+func TestMessage_Validate(t *testing.T) {
+	for _, msg := range testMessages(t) {
+		session := msg.SessionID
+		wrongSession := session
+		wrongSession[0] ^= 1
+		to := msg.To
+		if msg.IsBroadcast() {
+			to = msg.From + 1
+		}
+		assert.NoError(t, msg.Validate(msg.Type, session, to), msg.Type)
+		assert.True(t, errors.Is(msg.Validate(msg.Type, session, msg.From), ErrInvalidMessage), msg.Type)
+
+		other := MessageTypeSign2
+		if msg.Type == other {
+			other = MessageTypeSign1
+		}
+		assert.True(t, errors.Is(msg.Validate(other, session, to), ErrWrongRound), msg.Type)
+		assert.True(t, errors.Is(msg.Validate(msg.Type, wrongSession, to), ErrWrongSession), msg.Type)
+
+		// a broadcast sent to one party, or a message to one party broadcast
+		readdressed := *msg
+		readdressed.To = to + 1
+		if !msg.IsBroadcast() {
+			readdressed.To = 0
+		}
+		assert.True(t, errors.Is(readdressed.Validate(msg.Type, session, to), ErrInvalidMessage), msg.Type)
+
+		// the payload of another type
+		mislabeled := *msg
+		mislabeled.Type, mislabeled.Round = other, 0
+		assert.True(t, errors.Is(mislabeled.Validate(other, session, to), ErrInvalidMessage), msg.Type)
+	}
+
+	zi := scalar.NewScalarRandom()
+	assert.True(t, errors.Is(NewSign2(0, zi).Validate(MessageTypeSign2, SessionID{}, 1), ErrInvalidMessage))
+	assert.True(t, errors.Is(NewKeyGen2(1, 1, zi).Validate(MessageTypeKeyGen2, SessionID{}, 2), ErrInvalidMessage))
+	// an observer receives broadcasts only
+	assert.NoError(t, NewSign2(1, zi).Validate(MessageTypeSign2, SessionID{}, 0))
+	assert.True(t, errors.Is(NewKeyGen2(1, 2, zi).Validate(MessageTypeKeyGen2, SessionID{}, 0), ErrInvalidMessage))
+}
+
+func TestSignRound_UnknownSender(t *testing.T) {
+	_, states, round1 := signSession(t, SessionID{})
+
+	stranger := *round1[1]
+	stranger.From = 3
+	_, _, err := SignRound1(states[1], []*Message{&stranger})
+	assert.True(t, errors.Is(err, ErrUnknownSender))
+
+	sign2, _, err := SignRound1(states[2], round1)
+	require.NoError(t, err)
+	_, _, err = SignRound1(states[1], round1)
+	require.NoError(t, err)
+	stranger = *sign2
+	stranger.From = 3
+	_, _, err = SignRound2(states[1], []*Message{&stranger})
+	assert.True(t, errors.Is(err, ErrUnknownSender))
+}
+
+func TestKeygenRound1_Degree(t *testing.T) {
+	msg, _, err := KeygenInit(2, 3, 2)
+	require.NoError(t, err)
+	_, state, err := KeygenInit(1, 3, 1)
+	require.NoError(t, err)
+	_, _, err = KeygenRound1(state, []*Message{msg})
+	assert.Error(t, err)
+}
+
+func TestKeygenInitWithProofContext(t *testing.T) {
+	const n = 3
+	session, err := NewSessionID()
+	require.NoError(t, err)
+	proofContext := []byte("treasury ceremony 7, 2026-10-16T12:00:00Z")
+
+	states := make(map[party.ID]*KeygenState, n)
+	var round1 []*Message
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, err := KeygenInitWithProofContext(session, proofContext, id, n, 1)
+		require.NoError(t, err)
+		assert.True(t, msg.KeyGen1.Proof.Verify(id, msg.KeyGen1.Commitments.Constant(), KeygenProofContext(session, proofContext)))
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+
+	// the context is kept by the encodings of the state
+	data, err := json.Marshal(states[2])
+	require.NoError(t, err)
+	var decoded KeygenState
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, proofContext, decoded.ProofContext)
+	data, err = states[3].MarshalCBOR()
+	require.NoError(t, err)
+	require.NoError(t, decoded.UnmarshalCBOR(data))
+	assert.Equal(t, proofContext, decoded.ProofContext)
+	states[3] = &decoded
+
+	for _, state := range states {
+		_, _, err := KeygenRound1(state, round1)
+		require.NoError(t, err)
+	}
+
+	// the proofs are rejected in a ceremony with another context, or none
+	_, other, err := KeygenInitWithProofContext(session, []byte("treasury ceremony 8"), 1, n, 1)
+	require.NoError(t, err)
+	_, _, err = KeygenRound1(other, round1[1:])
+	assert.Error(t, err)
+	_, other, err = KeygenInitWithSession(session, 1, n, 1)
+	require.NoError(t, err)
+	_, _, err = KeygenRound1(other, round1[1:])
+	assert.Error(t, err)
+
+	_, _, err = KeygenInitWithProofContext(session, make([]byte, MaxProofContextSize+1), 1, n, 1)
+	assert.Error(t, err)
+}
+
+func TestKeygenInitWithSession(t *testing.T) {
+	const n = 3
+	session, err := NewSessionID()
+	require.NoError(t, err)
+	other, err := NewSessionID()
+	require.NoError(t, err)
+
+	states := make(map[party.ID]*KeygenState, n)
+	var round1 []*Message
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, err := KeygenInitWithSession(session, id, n, 1)
+		require.NoError(t, err)
+		assert.Equal(t, session, msg.SessionID)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+
+	// a message relabeled with another session fails the proof, which is bound to the session
+	_, otherState, err := KeygenInitWithSession(other, 1, n, 1)
+	require.NoError(t, err)
+	relabeled := *round1[1]
+	relabeled.SessionID = other
+	_, _, err = KeygenRound1(otherState, []*Message{&relabeled})
+	assert.Error(t, err)
+	_, _, err = KeygenRound1(otherState, round1[1:])
+	assert.True(t, errors.Is(err, ErrWrongSession))
+
+	round2 := make(map[party.ID][]*Message, n)
+	for _, state := range states {
+		msgs, _, err := KeygenRound1(state, round1)
+		require.NoError(t, err)
+		for _, msg := range msgs {
+			assert.Equal(t, session, msg.SessionID)
+			round2[msg.To] = append(round2[msg.To], msg)
+		}
+	}
+
+	_, _, err = KeygenRound2(states[1], round1)
+	assert.Error(t, err)
+	replayed := *round2[1][0]
+	replayed.SessionID = other
+	_, _, err = KeygenRound2(states[1], []*Message{&replayed})
+	assert.True(t, errors.Is(err, ErrWrongSession))
+
+	for id, state := range states {
+		_, _, err := KeygenRound2(state, round2[id])
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,245 @@
+// Package migrate detects the artifacts written by earlier releases of this
+// package, and by the taurusgroup frost-ed25519 tooling it derives from,
+// and converts them to the formats read by the current cmd/keygen and cmd/sign.
+// Artifacts with the header of frost.EncodeArtifact are accepted as well.
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+)
+
+// Kind is the type of a detected artifact.
+type Kind string
+
+const (
+	KindUnknown           Kind = "unknown"
+	KindSecretShareBinary Kind = "secret-share"
+	KindSecretShareJSON   Kind = "secret-share-json"
+	KindPublic            Kind = "public"
+	KindKeygenState       Kind = "keygen-state"
+	KindSignerState       Kind = "signer-state"
+	KindMessage           Kind = "message"
+)
+
+// ErrUnknownFormat is returned when data does not match any known artifact.
+var ErrUnknownFormat = errors.New("migrate: unknown artifact format")
+
+// Result is the outcome of a migration.
+type Result struct {
+	// Kind is the detected type of the input.
+	Kind Kind
+	// Legacy is true if the input used an older layout.
+	Legacy bool
+	// Version is the format version of the input, see frost.DetectVersion.
+	Version int
+	// Data is the artifact in the current format, without a header.
+	Data []byte
+}
+
+// Artifact returns Data with the header of the current format version, as
+// the current cmd/keygen and cmd/sign write it.
+func (r *Result) Artifact() []byte {
+	return frost.EncodeArtifact(r.Kind.artifactKind(), r.Data)
+}
+
+// artifactKind returns the frost.ArtifactKind of k.
+func (k Kind) artifactKind() frost.ArtifactKind {
+	switch k {
+	case KindSecretShareBinary, KindSecretShareJSON:
+		return frost.ArtifactSecretShare
+	case KindPublic:
+		return frost.ArtifactPublic
+	case KindKeygenState:
+		return frost.ArtifactKeygenState
+	case KindSignerState:
+		return frost.ArtifactSignerState
+	case KindMessage:
+		return frost.ArtifactMessage
+	}
+	return ""
+}
+
+// Detect returns the kind of artifact stored in data.
+func Detect(data []byte) Kind {
+	res, err := Migrate(data)
+	if err != nil {
+		return KindUnknown
+	}
+	return res.Kind
+}
+
+// Migrate converts data to the current format of the artifact it contains.
+//
+// Secret shares are always converted to the binary form read by cmd/sign.
+// The other artifacts are normalized to their current JSON encoding.
+func Migrate(data []byte) (*Result, error) {
+	kind, version, err := frost.DetectVersion(data)
+	if err != nil {
+		return nil, ErrUnknownFormat
+	}
+	if data, err = frost.DecodeArtifact(kind, data); err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	res, err := migrate(data)
+	if res != nil {
+		res.Version = version
+	}
+	return res, err
+}
+
+// migrate is Migrate for data without a header.
+func migrate(data []byte) (*Result, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		var share eddsa.SecretShare
+		if err := share.UnmarshalBinary(data); err != nil {
+			return nil, ErrUnknownFormat
+		}
+		out, err := share.MarshalBinary()
+		return &Result{Kind: KindSecretShareBinary, Data: out}, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &fields); err != nil {
+		return nil, ErrUnknownFormat
+	}
+
+	switch {
+	case has(fields, "header"):
+		return migrateMessage(fields)
+	case has(fields, "signers") && has(fields, "signer_ids"):
+		return migrateSignerState(trimmed)
+	case has(fields, "commitments_sum") || has(fields, "polynomial"):
+		return migrateKeygenState(trimmed)
+	case has(fields, "shares"):
+		return migratePublic(fields)
+	case has(fields, "secret") && has(fields, "id"):
+		return migrateSecretShareJSON(trimmed)
+	}
+	return nil, ErrUnknownFormat
+}
+
+func has(fields map[string]json.RawMessage, key string) bool {
+	_, ok := fields[key]
+	return ok
+}
+
+// first returns the first of keys present in fields, and whether it is the preferred (first) key.
+func first(fields map[string]json.RawMessage, keys ...string) (json.RawMessage, bool) {
+	for i, k := range keys {
+		if v, ok := fields[k]; ok {
+			return v, i == 0
+		}
+	}
+	return nil, true
+}
+
+func migrateSecretShareJSON(data []byte) (*Result, error) {
+	var share eddsa.SecretShare
+	if err := share.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("migrate: secret share: %w", err)
+	}
+	out, err := share.MarshalBinary()
+	return &Result{Kind: KindSecretShareJSON, Legacy: true, Data: out}, err
+}
+
+// migratePublic accepts the current {"t", "groupkey", "shares"} layout, as well as
+// the "threshold" and "group_key" spellings used by older tooling.
+func migratePublic(fields map[string]json.RawMessage) (*Result, error) {
+	threshold, current := first(fields, "t", "threshold")
+	legacy := !current
+	groupKey, current := first(fields, "groupkey", "group_key")
+	legacy = legacy || !current
+
+	var t int
+	if err := json.Unmarshal(threshold, &t); err != nil {
+		return nil, fmt.Errorf("migrate: public threshold: %w", err)
+	}
+	var shares map[party.ID]*ristretto.Element
+	if err := json.Unmarshal(fields["shares"], &shares); err != nil {
+		return nil, fmt.Errorf("migrate: public shares: %w", err)
+	}
+
+	public, err := eddsa.NewPublic(shares, party.Size(t))
+	if err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	if groupKey != nil {
+		var pk eddsa.PublicKey
+		if err := pk.UnmarshalJSON(groupKey); err != nil {
+			return nil, fmt.Errorf("migrate: group key: %w", err)
+		}
+		if !pk.Equal(public.GroupKey) {
+			return nil, errors.New("migrate: inconsistent group key")
+		}
+	}
+
+	out, err := json.Marshal(public)
+	return &Result{Kind: KindPublic, Legacy: legacy, Data: out}, err
+}
+
+func migrateKeygenState(data []byte) (*Result, error) {
+	var state frost.KeygenState
+	if err := state.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("migrate: keygen state: %w", err)
+	}
+	out, err := state.MarshalJSON()
+	return &Result{Kind: KindKeygenState, Data: out}, err
+}
+
+func migrateSignerState(data []byte) (*Result, error) {
+	var state frost.SignerState
+	if err := state.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("migrate: signer state: %w", err)
+	}
+	out, err := state.MarshalJSON()
+	return &Result{Kind: KindSignerState, Data: out}, err
+}
+
+// migrateMessage accepts headers with base64 encoded fields, as written today,
+// and headers with plain integers, as written by earlier versions of the messages package.
+func migrateMessage(fields map[string]json.RawMessage) (*Result, error) {
+	var header map[string]json.RawMessage
+	if err := json.Unmarshal(fields["header"], &header); err != nil {
+		return nil, fmt.Errorf("migrate: message header: %w", err)
+	}
+
+	legacy := false
+	for _, key := range []string{"type", "from", "to"} {
+		v, ok := header[key]
+		if !ok || len(v) == 0 || v[0] == '"' {
+			continue
+		}
+		n, err := strconv.ParseUint(string(v), 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: message header %s: %w", key, err)
+		}
+		var encoded []byte
+		if key == "type" {
+			encoded = []byte{byte(n)}
+		} else {
+			encoded = party.ID(n).Bytes()
+		}
+		header[key], _ = json.Marshal(encoded)
+		legacy = true
+	}
+
+	fields["header"], _ = json.Marshal(header)
+	normalized, _ := json.Marshal(fields)
+
+	var msg frost.Message
+	if err := msg.UnmarshalJSON(normalized); err != nil {
+		return nil, fmt.Errorf("migrate: message: %w", err)
+	}
+	out, err := msg.MarshalJSON()
+	return &Result{Kind: KindMessage, Legacy: legacy, Data: out}, err
+}
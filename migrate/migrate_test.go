@@ -0,0 +1,111 @@
+package migrate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/frostclient"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate_SecretShare(t *testing.T) {
+	share := eddsa.NewSecretShare(7, scalar.NewScalarRandom())
+	bin, _ := share.MarshalBinary()
+	js, _ := share.MarshalJSON()
+
+	res, err := Migrate(bin)
+	require.NoError(t, err)
+	assert.Equal(t, KindSecretShareBinary, res.Kind)
+	assert.False(t, res.Legacy)
+	assert.Equal(t, bin, res.Data)
+
+	res, err = Migrate(js)
+	require.NoError(t, err)
+	assert.Equal(t, KindSecretShareJSON, res.Kind)
+	assert.True(t, res.Legacy)
+	assert.Equal(t, bin, res.Data)
+}
+
+func TestMigrate_Public(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	current, err := json.Marshal(group.Public)
+	require.NoError(t, err)
+
+	res, err := Migrate(current)
+	require.NoError(t, err)
+	assert.Equal(t, KindPublic, res.Kind)
+	assert.False(t, res.Legacy)
+
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(current, &fields))
+	fields["threshold"], fields["group_key"] = fields["t"], fields["groupkey"]
+	delete(fields, "t")
+	delete(fields, "groupkey")
+	legacy, _ := json.Marshal(fields)
+
+	res, err = Migrate(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, KindPublic, res.Kind)
+	assert.True(t, res.Legacy)
+
+	var public eddsa.Public
+	require.NoError(t, json.Unmarshal(res.Data, &public))
+	assert.True(t, public.Equal(group.Public))
+}
+
+func TestMigrate_Message(t *testing.T) {
+	msg := frost.NewSign2(3, scalar.NewScalarRandom())
+	current, _ := msg.MarshalJSON()
+
+	res, err := Migrate(current)
+	require.NoError(t, err)
+	assert.Equal(t, KindMessage, res.Kind)
+	assert.False(t, res.Legacy)
+	assert.JSONEq(t, string(current), string(res.Data))
+
+	legacy := []byte(`{"header":{"type":4,"from":3,"to":0},"sign2":` + string(mustField(t, current, "sign2")) + `}`)
+	res, err = Migrate(legacy)
+	require.NoError(t, err)
+	assert.True(t, res.Legacy)
+	assert.JSONEq(t, string(current), string(res.Data))
+}
+
+func TestMigrate_Artifact(t *testing.T) {
+	share := eddsa.NewSecretShare(7, scalar.NewScalarRandom())
+	bin, _ := share.MarshalBinary()
+
+	res, err := Migrate(bin)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Version)
+	artifact := res.Artifact()
+	assert.Equal(t, frost.EncodeArtifact(frost.ArtifactSecretShare, bin), artifact)
+
+	res, err = Migrate(artifact)
+	require.NoError(t, err)
+	assert.Equal(t, KindSecretShareBinary, res.Kind)
+	assert.False(t, res.Legacy)
+	assert.Equal(t, frost.ArtifactVersion, res.Version)
+	assert.Equal(t, bin, res.Data)
+
+	// artifacts of newer releases are rejected
+	newer := append([]byte("FROST-ARTIFACT secret-share 99\n"), bin...)
+	_, err = Migrate(newer)
+	assert.Error(t, err)
+}
+
+func TestMigrate_Unknown(t *testing.T) {
+	_, err := Migrate([]byte("not an artifact"))
+	assert.Equal(t, ErrUnknownFormat, err)
+	assert.Equal(t, KindUnknown, Detect([]byte(`{"foo": 1}`)))
+}
+
+func mustField(t *testing.T, data []byte, key string) json.RawMessage {
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &fields))
+	return fields[key]
+}
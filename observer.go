@@ -0,0 +1,139 @@
+package frost
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/bartke/frost/party"
+)
+
+// Observer is notified of the progress of a protocol, e.g. to show a ceremony
+// in a UI or to write an audit log. It is set as the Observer of a
+// KeygenState or SignerState, whose round functions call it, or on a Machine
+// with SetObserver. The methods are called synchronously and must not block.
+type Observer interface {
+	// OnRoundStart is called when the round in which messages of type round
+	// are received starts.
+	OnRoundStart(round MessageType)
+	// OnMessageReceived is called for every message of a round that was
+	// accepted.
+	OnMessageReceived(from party.ID, round MessageType)
+	// OnRoundComplete is called when the messages of all parties of a round
+	// were processed, and its output computed.
+	OnRoundComplete(round MessageType)
+	// OnAbort is called when the protocol fails. The culprit is the party
+	// whose message caused the failure, or 0 if none is to blame.
+	OnAbort(err error, culprit party.ID)
+}
+
+// ObserverFuncs is an Observer that calls those of its functions that are set.
+type ObserverFuncs struct {
+	RoundStart      func(round MessageType)
+	MessageReceived func(from party.ID, round MessageType)
+	RoundComplete   func(round MessageType)
+	Abort           func(err error, culprit party.ID)
+}
+
+func (o ObserverFuncs) OnRoundStart(round MessageType) {
+	if o.RoundStart != nil {
+		o.RoundStart(round)
+	}
+}
+
+func (o ObserverFuncs) OnMessageReceived(from party.ID, round MessageType) {
+	if o.MessageReceived != nil {
+		o.MessageReceived(from, round)
+	}
+}
+
+func (o ObserverFuncs) OnRoundComplete(round MessageType) {
+	if o.RoundComplete != nil {
+		o.RoundComplete(round)
+	}
+}
+
+func (o ObserverFuncs) OnAbort(err error, culprit party.ID) {
+	if o.Abort != nil {
+		o.Abort(err, culprit)
+	}
+}
+
+// roundObserver notifies an Observer, and logs to a Logger, either of which
+// may be nil, from a round function.
+type roundObserver struct {
+	o      Observer
+	logger *slog.Logger
+	round  MessageType
+	// from is the sender of the message being processed, blamed if it fails.
+	from party.ID
+}
+
+// observeRound returns the roundObserver of a round function of selfID, and
+// notifies o of the start of the round unless one of its messages was
+// received already, in an earlier call.
+func observeRound(o Observer, logger *slog.Logger, selfID party.ID, received Received, round MessageType) *roundObserver {
+	if debugEnabled(logger) {
+		logger = logger.With("party", selfID, "round", round.String())
+	} else {
+		logger = nil
+	}
+	if len(received[round]) == 0 {
+		if o != nil {
+			o.OnRoundStart(round)
+		}
+		if logger != nil {
+			logger.Debug("frost: round started")
+		}
+	}
+	return &roundObserver{o: o, logger: logger, round: round}
+}
+
+// received notifies the observer of the accepted message msg.
+func (r *roundObserver) received(msg *Message) {
+	if r.o != nil {
+		r.o.OnMessageReceived(msg.From, r.round)
+	}
+	if r.logger != nil {
+		r.logger.Debug("frost: message accepted", "from", msg.From, "fingerprint", msg.fingerprint())
+	}
+}
+
+// done notifies the observer of the outcome of the round function, which
+// returned err. A round that waits for more messages is neither complete nor
+// aborted.
+func (r *roundObserver) done(err error) {
+	if errors.Is(err, ErrNeedMoreMessages) {
+		if r.logger != nil {
+			r.logger.Debug("frost: round waiting", "error", err)
+		}
+		return
+	}
+	if err == nil {
+		if r.o != nil {
+			r.o.OnRoundComplete(r.round)
+		}
+		if r.logger != nil {
+			r.logger.Debug("frost: round complete")
+		}
+		return
+	}
+	culprit := r.from
+	if culprit == 0 {
+		culprit = culpritOf(err)
+	}
+	if r.o != nil {
+		r.o.OnAbort(err, culprit)
+	}
+	if r.logger != nil {
+		r.logger.Debug("frost: round failed", "culprit", culprit, "error", err)
+	}
+}
+
+// culpritOf returns the culprit of an *AbortError in err, or 0.
+func culpritOf(err error) party.ID {
+	var abort *AbortError
+	if errors.As(err, &abort) {
+		return abort.Culprit
+	}
+	return 0
+}
@@ -0,0 +1,142 @@
+package frost
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is an Observer that records its events as strings.
+type recorder struct {
+	events  []string
+	culprit party.ID
+}
+
+func (r *recorder) observer() ObserverFuncs {
+	return ObserverFuncs{
+		RoundStart: func(round MessageType) {
+			r.events = append(r.events, "start "+round.String())
+		},
+		MessageReceived: func(from party.ID, round MessageType) {
+			r.events = append(r.events, fmt.Sprintf("%s from %d", round, from))
+		},
+		RoundComplete: func(round MessageType) {
+			r.events = append(r.events, "complete "+round.String())
+		},
+		Abort: func(err error, culprit party.ID) {
+			r.events = append(r.events, "abort")
+			r.culprit = culprit
+		},
+	}
+}
+
+func TestObserver_States(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	signers := party.IDSlice{1, 3}
+	message := []byte("observed")
+
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInit(signers, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	var rec recorder
+	states[1].Observer = rec.observer()
+
+	var round2 []*Message
+	for _, id := range signers {
+		msg, _, err := SignRound1(states[id], round1)
+		require.NoError(t, err)
+		round2 = append(round2, msg)
+	}
+	_, _, err := SignRound2(states[1], round2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"start Sign1", "Sign1 from 3", "complete Sign1",
+		"start Sign2", "Sign2 from 3", "complete Sign2",
+	}, rec.events)
+
+	// an invalid share names its sender
+	rec = recorder{}
+	_, state, err := SignInit(signers, secrets[3], public, message)
+	require.NoError(t, err)
+	state.Observer = rec.observer()
+	_, _, err = SignRound1(state, round1)
+	require.NoError(t, err)
+	invalid := NewSign2(1, scalar.NewScalarRandom())
+	_, _, err = SignRound2(state, []*Message{invalid})
+	require.Error(t, err)
+	assert.Equal(t, "abort", rec.events[len(rec.events)-1])
+	assert.Equal(t, party.ID(1), rec.culprit)
+}
+
+func TestObserver_Keygen(t *testing.T) {
+	const n = 3
+	states := make(map[party.ID]*KeygenState, n)
+	var round1 []*Message
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, err := KeygenInit(id, n, 1)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	var rec recorder
+	states[2].Observer = rec.observer()
+
+	// messages arriving one by one start the round once
+	_, _, err := KeygenRound1(states[2], round1[:1])
+	require.True(t, errors.Is(err, ErrNeedMoreMessages))
+	_, _, err = KeygenRound1(states[2], round1[1:])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"start KeyGen1", "KeyGen1 from 1", "KeyGen1 from 3", "complete KeyGen1"}, rec.events)
+
+	// a message of a stranger is blamed on it
+	rec = recorder{}
+	states[1].Observer = rec.observer()
+	stranger := *round1[2]
+	stranger.From = 4
+	_, _, err = KeygenRound1(states[1], []*Message{round1[1], &stranger})
+	assert.True(t, errors.Is(err, ErrUnknownSender))
+	assert.Equal(t, []string{"start KeyGen1", "KeyGen1 from 2", "abort"}, rec.events)
+	assert.Equal(t, party.ID(4), rec.culprit)
+}
+
+func TestObserver_Machine(t *testing.T) {
+	const n = 3
+	machines := make(map[party.ID]*Machine, n)
+	for id := party.ID(1); id <= n; id++ {
+		machines[id] = NewKeygenMachine(id, n, 1)
+	}
+	var rec recorder
+	machines[1].SetObserver(rec.observer())
+	runMachines(t, machines, rand.New(rand.NewSource(1)))
+
+	require.Len(t, rec.events, 8)
+	assert.Equal(t, "start KeyGen1", rec.events[0])
+	assert.ElementsMatch(t, []string{"KeyGen1 from 2", "KeyGen1 from 3"}, rec.events[1:3])
+	assert.Equal(t, []string{"complete KeyGen1", "start KeyGen2"}, rec.events[3:5])
+	assert.ElementsMatch(t, []string{"KeyGen2 from 2", "KeyGen2 from 3"}, rec.events[5:7])
+	assert.Equal(t, "complete KeyGen2", rec.events[7])
+
+	// a message of a stranger fails the machine
+	rec = recorder{}
+	m := NewKeygenMachine(1, n, 1)
+	m.SetObserver(rec.observer())
+	out, _ := m.Advance(time.Now(), nil)
+	stranger := *out[0]
+	stranger.From = 7
+	_, status := m.Advance(time.Now(), []*Message{&stranger})
+	require.Equal(t, StatusFailed, status)
+	assert.Equal(t, []string{"start KeyGen1", "abort"}, rec.events)
+	assert.Equal(t, party.ID(7), rec.culprit)
+}
@@ -1,6 +1,7 @@
 package party
 
 import (
+	"database/sql/driver"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -100,6 +101,30 @@ func (id *ID) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// Value implements the driver.Valuer interface, storing the ID as an integer.
+func (id ID) Value() (driver.Value, error) {
+	return int64(id), nil
+}
+
+// Scan implements the sql.Scanner interface.
+// It accepts integers, and their text representation.
+func (id *ID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case int64:
+		if v < 0 || v > math.MaxUint16 {
+			return fmt.Errorf("party.ID: Scan: %d is out of range", v)
+		}
+		*id = ID(v)
+		return nil
+	case []byte:
+		return id.UnmarshalText(v)
+	case string:
+		return id.UnmarshalText([]byte(v))
+	default:
+		return fmt.Errorf("party.ID: Scan: cannot scan %T", src)
+	}
+}
+
 // Lagrange gives the Lagrange coefficient lⱼ(x) for x = 0.
 //
 // We iterate over all points in the set.
@@ -123,10 +148,23 @@ func (id *ID) UnmarshalText(text []byte) error {
 //
 // returns an error if id is not included in partyIDs
 func (id ID) Lagrange(partyIDs IDSlice) (*ristretto.Scalar, error) {
+	return id.LagrangeAt(partyIDs, 0)
+}
+
+// LagrangeAt gives the Lagrange coefficient lⱼ(x) for x the scalar of at,
+// which interpolates the polynomial at another party's point rather than at 0,
+// e.g. to repair the share of a party.
+//
+//	        (x₀ - x) ... (xₖ - x)
+//	lⱼ(x) = ---------------------
+//	        (x₀ - xⱼ) ... (xₖ - xⱼ)
+//
+// returns an error if id is not included in partyIDs
+func (id ID) LagrangeAt(partyIDs IDSlice, at ID) (*ristretto.Scalar, error) {
 	if id == 0 {
 		return nil, errors.New("party.ID: Lagrange: id was 0 (invalid)")
 	}
-	var one, num, denum, xM, xJ ristretto.Scalar
+	var one, num, denum, xM, xJ, x ristretto.Scalar
 
 	// we can't use scalar.NewScalarUInt32() since that would cause an import cycle
 	_, _ = one.SetCanonicalBytes([]byte{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
@@ -135,6 +173,7 @@ func (id ID) Lagrange(partyIDs IDSlice) (*ristretto.Scalar, error) {
 	denum.Set(&one)
 
 	xJ = *id.Scalar()
+	x = *at.Scalar()
 
 	foundSelfInIDs := false
 	for _, partyID := range partyIDs {
@@ -145,8 +184,10 @@ func (id ID) Lagrange(partyIDs IDSlice) (*ristretto.Scalar, error) {
 
 		xM = *partyID.Scalar()
 
-		// num = x₀ * ... * xₖ
-		num.Multiply(&num, &xM) // num * xM
+		// num = (x₀ - x) * ... * (xₖ - x)
+		var diff ristretto.Scalar
+		diff.Subtract(&xM, &x)
+		num.Multiply(&num, &diff)
 
 		// denum = (x₀ - xⱼ) ... (xₖ - xⱼ)
 		xM.Subtract(&xM, &xJ)       // = xM - xJ
@@ -153,6 +153,62 @@ func TestID_UnmarshalText(t *testing.T) {
 	}
 }
 
+func TestID_Scan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    ID
+		wantErr bool
+	}{
+		{"int64", int64(42), 42, false},
+		{"bytes", []byte("42"), 42, false},
+		{"string", "65535", 65535, false},
+		{"negative", int64(-1), 0, true},
+		{"overflow", int64(65536), 0, true},
+		{"nil", nil, 0, true},
+		{"float", 4.2, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var id ID
+			err := id.Scan(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if id != tt.want {
+				t.Errorf("Scan() got = %v, want %v", id, tt.want)
+			}
+			if err == nil {
+				v, _ := id.Value()
+				if v != int64(tt.want) {
+					t.Errorf("Value() got = %v, want %v", v, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestID_LagrangeAt(t *testing.T) {
+	// f(x) = 3 + 2x, interpolated at 5 from f(1) = 5 and f(2) = 7
+	partyIDs := IDSlice{1, 2}
+	values := map[ID]uint32{1: 5, 2: 7}
+	sum := ristretto.NewScalar()
+	for _, id := range partyIDs {
+		l, err := id.LagrangeAt(partyIDs, 5)
+		if err != nil {
+			t.Fatalf("LagrangeAt(): unexpected error: %v", err)
+		}
+		sum.Add(sum, l.Multiply(l, scalar.NewScalarUInt32(values[id])))
+	}
+	if scalar.NewScalarUInt32(13).Equal(sum) != 1 {
+		t.Errorf("LagrangeAt(): expected f(5) = 13")
+	}
+	if _, err := ID(3).LagrangeAt(partyIDs, 5); err == nil {
+		t.Errorf("LagrangeAt(): expected an error for an id not in partyIDs")
+	}
+}
+
 func TestID_Lagrange(t *testing.T) {
 	N := 16
 
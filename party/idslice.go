@@ -1,27 +1,81 @@
 package party
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"sort"
 )
 
-// IDSlice is an alias for []ID
+// IDSlice is an alias for []ID.
+//
+// An IDSlice created with NewIDSlice or decoded from JSON is always sorted
+// in increasing order and contains no duplicates. The methods below rely on
+// this invariant, so slices built by hand should be passed through NewIDSlice first.
 type IDSlice []ID
 
-// NewIDSlice returns an IDSlice which is the partyIDs sorted
+// NewIDSlice returns an IDSlice which is the partyIDs sorted and without duplicates
 func NewIDSlice(partyIDs []ID) IDSlice {
-	ids := IDSlice(partyIDs).Copy()
-	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
-	return ids
+	ids := IDSlice(partyIDs).Sorted()
+
+	// remove duplicates in place, since equal IDs are now adjacent
+	n := 0
+	for i, id := range ids {
+		if i > 0 && id == ids[n-1] {
+			continue
+		}
+		ids[n] = id
+		n++
+	}
+	return ids[:n]
 }
 
-// Contains returns true if id is included in the slice.
-func (ids IDSlice) Contains(id ID) bool {
-	for _, i := range ids {
-		if i == id {
-			return true
+// Sorted returns a copy of ids sorted in increasing order, which keeps any
+// duplicates. Unlike the other methods, it does not assume that ids is sorted.
+func (ids IDSlice) Sorted() IDSlice {
+	sorted := ids.Copy()
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+// Deduplicate returns a copy of ids without the repetitions of any ID, in the
+// order of their first occurrence. It does not assume that ids is sorted.
+func (ids IDSlice) Deduplicate() IDSlice {
+	seen := make(map[ID]bool, len(ids))
+	unique := make(IDSlice, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// Validate returns an error if ids contains the invalid ID 0, or is not
+// sorted in increasing order without duplicates, as the methods below assume.
+// The binding factors and Lagrange coefficients computed from a slice that
+// is not valid are wrong, so lists of parties received from callers should be
+// validated, or passed through NewIDSlice, before use.
+func (ids IDSlice) Validate() error {
+	for i, id := range ids {
+		switch {
+		case id == 0:
+			return errors.New("party.IDSlice: id 0 is not valid")
+		case i > 0 && id == ids[i-1]:
+			return fmt.Errorf("party.IDSlice: duplicate id %d", id)
+		case i > 0 && id < ids[i-1]:
+			return fmt.Errorf("party.IDSlice: id %d is out of order", id)
 		}
 	}
-	return false
+	return nil
+}
+
+// Contains returns true if id is included in the slice.
+// It performs a binary search and assumes ids is sorted.
+func (ids IDSlice) Contains(id ID) bool {
+	idx := sort.Search(len(ids), func(i int) bool { return ids[i] >= id })
+	return idx < len(ids) && ids[idx] == id
 }
 
 // N returns the number of ID s in the slice
@@ -29,12 +83,18 @@ func (ids IDSlice) N() Size {
 	return Size(len(ids))
 }
 
-// IsSubsetOf is all elements in ids are in o
+// IsSubsetOf is all elements in ids are in o.
+// Both slices are assumed sorted, so a single merge pass suffices.
 func (ids IDSlice) IsSubsetOf(o IDSlice) bool {
+	j := 0
 	for _, id := range ids {
-		if !o.Contains(id) {
+		for j < len(o) && o[j] < id {
+			j++
+		}
+		if j == len(o) || o[j] != id {
 			return false
 		}
+		j++
 	}
 	return true
 }
@@ -52,6 +112,60 @@ func (ids IDSlice) Equal(o IDSlice) bool {
 	return true
 }
 
+// Union returns the IDs that are in ids or o, both of which are assumed sorted.
+func (ids IDSlice) Union(o IDSlice) IDSlice {
+	union := make(IDSlice, 0, len(ids)+len(o))
+	i, j := 0, 0
+	for i < len(ids) && j < len(o) {
+		switch {
+		case ids[i] < o[j]:
+			union = append(union, ids[i])
+			i++
+		case ids[i] > o[j]:
+			union = append(union, o[j])
+			j++
+		default:
+			union = append(union, ids[i])
+			i++
+			j++
+		}
+	}
+	union = append(union, ids[i:]...)
+	return append(union, o[j:]...)
+}
+
+// Intersect returns the IDs that are in both ids and o, which are assumed sorted.
+func (ids IDSlice) Intersect(o IDSlice) IDSlice {
+	intersection := IDSlice{}
+	j := 0
+	for _, id := range ids {
+		for j < len(o) && o[j] < id {
+			j++
+		}
+		if j < len(o) && o[j] == id {
+			intersection = append(intersection, id)
+			j++
+		}
+	}
+	return intersection
+}
+
+// Difference returns the IDs of ids that are not in o, both of which are
+// assumed sorted.
+func (ids IDSlice) Difference(o IDSlice) IDSlice {
+	difference := IDSlice{}
+	j := 0
+	for _, id := range ids {
+		for j < len(o) && o[j] < id {
+			j++
+		}
+		if j == len(o) || o[j] != id {
+			difference = append(difference, id)
+		}
+	}
+	return difference
+}
+
 // Copy returns a deep copy of ids
 func (ids IDSlice) Copy() IDSlice {
 	n := len(ids)
@@ -59,3 +173,14 @@ func (ids IDSlice) Copy() IDSlice {
 	copy(newIds, ids)
 	return newIds
 }
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// The decoded IDs are sorted and deduplicated.
+func (ids *IDSlice) UnmarshalJSON(data []byte) error {
+	var raw []ID
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*ids = NewIDSlice(raw)
+	return nil
+}
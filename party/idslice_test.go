@@ -0,0 +1,72 @@
+package party
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIDSlice(t *testing.T) {
+	ids := NewIDSlice([]ID{5, 1, 3, 1, 5, 2})
+	assert.Equal(t, IDSlice{1, 2, 3, 5}, ids)
+
+	assert.Equal(t, IDSlice{}, NewIDSlice(nil))
+}
+
+func TestIDSlice_Contains(t *testing.T) {
+	ids := NewIDSlice([]ID{10, 2, 7})
+	for _, id := range []ID{2, 7, 10} {
+		assert.True(t, ids.Contains(id), id)
+	}
+	for _, id := range []ID{0, 1, 3, 8, 11} {
+		assert.False(t, ids.Contains(id), id)
+	}
+}
+
+func TestIDSlice_IsSubsetOf(t *testing.T) {
+	all := NewIDSlice([]ID{1, 2, 3, 4, 5})
+	assert.True(t, NewIDSlice([]ID{1, 3, 5}).IsSubsetOf(all))
+	assert.True(t, IDSlice{}.IsSubsetOf(all))
+	assert.True(t, all.IsSubsetOf(all))
+	assert.False(t, NewIDSlice([]ID{1, 6}).IsSubsetOf(all))
+	assert.False(t, all.IsSubsetOf(NewIDSlice([]ID{1, 2})))
+}
+
+func TestIDSlice_UnmarshalJSON(t *testing.T) {
+	var ids IDSlice
+	assert.NoError(t, json.Unmarshal([]byte(`["3","1","3","2"]`), &ids))
+	assert.Equal(t, IDSlice{1, 2, 3}, ids)
+}
+
+func TestIDSlice_Sorted(t *testing.T) {
+	ids := IDSlice{5, 1, 3, 1}
+	assert.Equal(t, IDSlice{1, 1, 3, 5}, ids.Sorted())
+	assert.Equal(t, IDSlice{5, 1, 3, 1}, ids)
+	assert.Equal(t, IDSlice{5, 1, 3}, ids.Deduplicate())
+	assert.Equal(t, IDSlice{1, 3, 5}, ids.Sorted().Deduplicate())
+}
+
+func TestIDSlice_Validate(t *testing.T) {
+	assert.NoError(t, IDSlice{}.Validate())
+	assert.NoError(t, IDSlice{1, 2, 0xffff}.Validate())
+	for _, ids := range []IDSlice{{0, 1}, {1, 1, 2}, {2, 1}, {1, 3, 2}} {
+		assert.Error(t, ids.Validate(), ids)
+	}
+}
+
+func TestIDSlice_SetAlgebra(t *testing.T) {
+	a := NewIDSlice([]ID{1, 2, 4, 7})
+	b := NewIDSlice([]ID{2, 3, 7, 9})
+	assert.Equal(t, IDSlice{1, 2, 3, 4, 7, 9}, a.Union(b))
+	assert.Equal(t, IDSlice{2, 7}, a.Intersect(b))
+	assert.Equal(t, IDSlice{1, 4}, a.Difference(b))
+	assert.Equal(t, IDSlice{3, 9}, b.Difference(a))
+
+	assert.Equal(t, a, a.Union(IDSlice{}))
+	assert.Equal(t, IDSlice{}, a.Intersect(nil))
+	assert.Equal(t, IDSlice{}, a.Difference(a))
+	for _, ids := range []IDSlice{a.Union(b), a.Intersect(b), a.Difference(b)} {
+		assert.NoError(t, ids.Validate())
+	}
+}
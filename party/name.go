@@ -0,0 +1,51 @@
+package party
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// nameDomain separates the hashes of FromName from other uses of SHA-512.
+const nameDomain = "FROST party name v1"
+
+// FromName returns the ID of the party with the given name, such as a host
+// name like "node-eu-1", so that deployments can refer to parties by name
+// without a table that maps names to IDs. The ID is derived from the SHA-512
+// hash of the name, is never 0, and is the same on every machine.
+//
+// IDs are 16 bit, so distinct names may map to the same ID, and a group of a
+// few dozen names does so with a probability of about 1%. Use FromNames to
+// derive the IDs of a whole group, which reports such collisions.
+func FromName(name string) ID {
+	h := sha512.New()
+	h.Write([]byte(nameDomain))
+	h.Write([]byte(name))
+	digest := h.Sum(nil)
+	return ID(1 + binary.BigEndian.Uint64(digest)%math.MaxUint16)
+}
+
+// FromNames returns FromName of each of names, in the same order. It returns
+// an error for an empty or duplicate name, or if two names map to the same
+// ID, in which case one of the parties must be renamed.
+func FromNames(names []string) ([]ID, error) {
+	ids := make([]ID, len(names))
+	byID := make(map[ID]string, len(names))
+	for i, name := range names {
+		if name == "" {
+			return nil, errors.New("party.FromNames: empty name")
+		}
+		id := FromName(name)
+		if other, ok := byID[id]; ok {
+			if other == name {
+				return nil, fmt.Errorf("party.FromNames: duplicate name %q", name)
+			}
+			return nil, fmt.Errorf("party.FromNames: %q and %q have the same ID %d", other, name, id)
+		}
+		byID[id] = name
+		ids[i] = id
+	}
+	return ids, nil
+}
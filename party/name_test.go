@@ -0,0 +1,45 @@
+package party
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromName(t *testing.T) {
+	id := FromName("node-eu-1")
+	assert.NotZero(t, id)
+	assert.Equal(t, id, FromName("node-eu-1"))
+	assert.NotEqual(t, id, FromName("node-eu-2"))
+
+	names := []string{"node-eu-1", "node-us-1", "node-ap-1"}
+	ids, err := FromNames(names)
+	require.NoError(t, err)
+	for i, name := range names {
+		assert.Equal(t, FromName(name), ids[i])
+	}
+	assert.Len(t, NewIDSlice(ids), len(names))
+
+	_, err = FromNames([]string{"node-eu-1", "node-eu-1"})
+	assert.Error(t, err)
+	_, err = FromNames([]string{"node-eu-1", ""})
+	assert.Error(t, err)
+}
+
+func TestFromNames_Collision(t *testing.T) {
+	// by the birthday bound, a collision is found among a few hundred names
+	seen := make(map[ID]string)
+	var a, b string
+	for i := 0; a == ""; i++ {
+		name := fmt.Sprintf("node-%d", i)
+		id := FromName(name)
+		if other, ok := seen[id]; ok {
+			a, b = other, name
+		}
+		seen[id] = name
+	}
+	_, err := FromNames([]string{a, "node-eu-1", b})
+	assert.Error(t, err)
+}
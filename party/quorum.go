@@ -0,0 +1,126 @@
+package party
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// Strategy decides which of the available parties sign a request, see
+// SelectQuorum.
+type Strategy interface {
+	// Pick returns n of the candidates, which are sorted and hold at least n IDs.
+	Pick(candidates IDSlice, n int) IDSlice
+}
+
+// SelectQuorum returns t+1 of the available parties chosen by strategy, the
+// signers of a request to a group with threshold t. available must be a
+// valid IDSlice, and hold at least t+1 parties. The quorum is sorted, and can
+// be passed to SignInit as is.
+func SelectQuorum(available IDSlice, t Size, strategy Strategy) (IDSlice, error) {
+	if err := available.Validate(); err != nil {
+		return nil, fmt.Errorf("party.SelectQuorum: %w", err)
+	}
+	n := int(t) + 1
+	if len(available) < n {
+		return nil, fmt.Errorf("party.SelectQuorum: %d parties available, %d are needed", len(available), n)
+	}
+	return pick(strategy, available, n), nil
+}
+
+// Reselect replaces the parties of quorum that failed, e.g. because they did
+// not answer in time, with others of the available parties chosen by
+// strategy, so that a request can be retried. The parties of quorum that did
+// not fail are kept, and the failed ones are not chosen again even if they are
+// still listed as available. To fail over repeatedly, failed should hold all
+// parties that failed so far, not only those of the last attempt.
+func Reselect(available, quorum, failed IDSlice, strategy Strategy) (IDSlice, error) {
+	for _, ids := range []IDSlice{available, quorum, failed} {
+		if err := ids.Validate(); err != nil {
+			return nil, fmt.Errorf("party.Reselect: %w", err)
+		}
+	}
+	kept := quorum.Difference(failed)
+	candidates := available.Difference(quorum).Difference(failed)
+	need := len(quorum) - len(kept)
+	if len(candidates) < need {
+		return nil, fmt.Errorf("party.Reselect: %d parties available to replace %d", len(candidates), need)
+	}
+	return kept.Union(pick(strategy, candidates, need)), nil
+}
+
+// pick calls strategy, and checks that it returned n of the candidates.
+func pick(strategy Strategy, candidates IDSlice, n int) IDSlice {
+	if n == 0 {
+		return IDSlice{}
+	}
+	picked := NewIDSlice(strategy.Pick(candidates, n))
+	if len(picked) != n || !picked.IsSubsetOf(candidates) {
+		panic(fmt.Sprintf("party: strategy picked %v instead of %d of %v", picked, n, candidates))
+	}
+	return picked
+}
+
+// ByWeight picks the parties of the lowest weight, such as their latency
+// measured by the coordinator, and those of lower ID among equal weights.
+// Parties without a weight are picked last.
+type ByWeight map[ID]float64
+
+// Pick implements Strategy.
+func (w ByWeight) Pick(candidates IDSlice, n int) IDSlice {
+	ordered := candidates.Copy()
+	sort.SliceStable(ordered, func(i, j int) bool {
+		wi, oki := w[ordered[i]]
+		wj, okj := w[ordered[j]]
+		if oki != okj {
+			return oki
+		}
+		return wi < wj
+	})
+	return ordered[:n]
+}
+
+// Random picks parties uniformly at random, using r, or the default Source of
+// math/rand if it is nil. A Random with a non-nil r is not safe for
+// concurrent use.
+type Random struct {
+	r *rand.Rand
+}
+
+// NewRandom returns a Random strategy using r.
+func NewRandom(r *rand.Rand) *Random {
+	return &Random{r: r}
+}
+
+// Pick implements Strategy.
+func (s *Random) Pick(candidates IDSlice, n int) IDSlice {
+	shuffle := rand.Shuffle
+	if s != nil && s.r != nil {
+		shuffle = s.r.Shuffle
+	}
+	shuffled := candidates.Copy()
+	shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	return shuffled[:n]
+}
+
+// RoundRobin picks the parties following the last one it picked, wrapping
+// around, so that successive requests spread over all parties. The zero value
+// starts with the lowest ID. It is safe for concurrent use.
+type RoundRobin struct {
+	mu   sync.Mutex
+	last ID
+}
+
+// Pick implements Strategy.
+func (s *RoundRobin) Pick(candidates IDSlice, n int) IDSlice {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	start := sort.Search(len(candidates), func(i int) bool { return candidates[i] > s.last })
+	picked := make(IDSlice, 0, n)
+	for i := 0; i < n; i++ {
+		picked = append(picked, candidates[(start+i)%len(candidates)])
+	}
+	s.last = picked[n-1]
+	return picked
+}
@@ -0,0 +1,92 @@
+package party
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectQuorum_ByWeight(t *testing.T) {
+	available := IDSlice{1, 2, 3, 4, 5}
+	latency := ByWeight{1: 80, 2: 15, 3: 40, 4: 15}
+
+	quorum, err := SelectQuorum(available, 2, latency)
+	require.NoError(t, err)
+	assert.Equal(t, IDSlice{2, 3, 4}, quorum)
+
+	// parties without a weight come last
+	quorum, err = SelectQuorum(available, 4, latency)
+	require.NoError(t, err)
+	assert.Equal(t, available, quorum)
+
+	_, err = SelectQuorum(available, 5, latency)
+	assert.Error(t, err)
+	_, err = SelectQuorum(IDSlice{3, 1, 2}, 1, latency)
+	assert.Error(t, err)
+}
+
+func TestSelectQuorum_Random(t *testing.T) {
+	available := IDSlice{1, 2, 3, 4, 5, 6}
+	strategy := NewRandom(rand.New(rand.NewSource(1)))
+	seen := make(map[ID]bool)
+	for i := 0; i < 20; i++ {
+		quorum, err := SelectQuorum(available, 2, strategy)
+		require.NoError(t, err)
+		assert.Len(t, quorum, 3)
+		assert.NoError(t, quorum.Validate())
+		assert.True(t, quorum.IsSubsetOf(available))
+		for _, id := range quorum {
+			seen[id] = true
+		}
+	}
+	assert.Len(t, seen, len(available))
+
+	quorum, err := SelectQuorum(available, 1, NewRandom(nil))
+	require.NoError(t, err)
+	assert.Len(t, quorum, 2)
+}
+
+func TestSelectQuorum_RoundRobin(t *testing.T) {
+	available := IDSlice{2, 4, 6, 8, 10}
+	var strategy RoundRobin
+	for _, expected := range []IDSlice{{2, 4}, {6, 8}, {2, 10}, {4, 6}} {
+		quorum, err := SelectQuorum(available, 1, &strategy)
+		require.NoError(t, err)
+		assert.Equal(t, expected, quorum)
+	}
+
+	// the rotation continues after the last party picked, even if it left
+	quorum, err := SelectQuorum(IDSlice{2, 4, 8, 10}, 1, &strategy)
+	require.NoError(t, err)
+	assert.Equal(t, IDSlice{8, 10}, quorum)
+}
+
+func TestReselect(t *testing.T) {
+	available := IDSlice{1, 2, 3, 4, 5, 6}
+	latency := ByWeight{1: 10, 2: 20, 3: 30, 4: 40, 5: 50, 6: 5}
+
+	quorum, err := SelectQuorum(available, 2, latency)
+	require.NoError(t, err)
+	assert.Equal(t, IDSlice{1, 2, 6}, quorum)
+
+	// the failed party is replaced by the best party not used yet
+	quorum, err = Reselect(available, quorum, IDSlice{6}, latency)
+	require.NoError(t, err)
+	assert.Equal(t, IDSlice{1, 2, 3}, quorum)
+
+	// failed parties are not picked again, even if still available
+	quorum, err = Reselect(available, quorum, IDSlice{1, 3, 6}, latency)
+	require.NoError(t, err)
+	assert.Equal(t, IDSlice{2, 4, 5}, quorum)
+
+	// nothing to replace
+	same, err := Reselect(available, quorum, nil, latency)
+	require.NoError(t, err)
+	assert.Equal(t, quorum, same)
+
+	// all parties that failed so far are passed
+	_, err = Reselect(available, quorum, IDSlice{1, 2, 3, 6}, latency)
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+// Package policy decides whether a party takes part in a signing session.
+//
+// A signer daemon or a coordinator asks its Policy before it contributes to a
+// signature, so that holding a share is not enough to get anything signed.
+// RuleSet is the default policy, a declarative set of rules read from JSON:
+// a request is allowed if one of the rules matches it, where a rule restricts
+// the prefixes of the message, the signers that must take part, the time
+// windows and the rate of the signatures it allows. Other policies, such as
+// asking an operator, implement the Policy interface and are combined with All.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+)
+
+// ErrDenied is wrapped by the errors of policies that refuse a request.
+var ErrDenied = errors.New("policy: denied")
+
+// Request is a signing session a party is asked to take part in.
+type Request struct {
+	// ID identifies the request, e.g. the ID of its frost.SignatureRequest. It may be empty.
+	ID string
+	// GroupKey is the key to sign with, or nil if it is not known, e.g. to a
+	// coordinator that only relays messages.
+	GroupKey *eddsa.PublicKey
+	// Party is the party evaluating the request, or 0 for a coordinator.
+	Party party.ID
+	// Signers are the parties of the session.
+	Signers party.IDSlice
+	// Message is the message to sign, or its SHA-512 digest if Prehash is set.
+	Message []byte
+	Prehash bool
+}
+
+// Policy decides whether a request may be signed.
+type Policy interface {
+	// Allow returns nil if the request may be signed, or an error wrapping
+	// ErrDenied with the reason it may not. It may block, e.g. until an
+	// operator decides, and then returns the error of ctx once it is done.
+	Allow(ctx context.Context, req *Request) error
+}
+
+// Func implements Policy with a function.
+type Func func(ctx context.Context, req *Request) error
+
+// Allow implements Policy.
+func (f Func) Allow(ctx context.Context, req *Request) error {
+	return f(ctx, req)
+}
+
+// All returns a Policy allowing the requests that all policies allow. They
+// are asked in order, and the first denial is returned.
+func All(policies ...Policy) Policy {
+	return Func(func(ctx context.Context, req *Request) error {
+		for _, p := range policies {
+			if err := p.Allow(ctx, req); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Deny returns an error wrapping ErrDenied with the reason.
+func Deny(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrDenied, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,140 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/manifest"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomKey() *eddsa.PublicKey {
+	return eddsa.NewPublicKeyFromPoint(new(ristretto.Element).ScalarBaseMult(scalar.NewScalarRandom()))
+}
+
+func TestRuleSet(t *testing.T) {
+	key := randomKey()
+	rs, err := Parse([]byte(`{"rules": [{
+		"name": "releases",
+		"groups": ["` + manifest.Fingerprint(key.ToEd25519()) + `"],
+		"message_prefixes": ["release "],
+		"message_prefixes_hex": ["ff00"],
+		"require_signers": ["1"],
+		"min_signers": 2,
+		"windows": [{"days": ["mon", "tue", "wed", "thu", "fri"], "start": "09:00", "end": "17:00"}],
+		"rate": {"max": 2, "per": "1h"}
+	}]}`))
+	require.NoError(t, err)
+	// a Monday
+	fake := clock.NewFake(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC))
+	rs.Clock = fake
+	ctx := context.Background()
+
+	req := func(message string, signers ...party.ID) *Request {
+		return &Request{GroupKey: key, Party: 1, Signers: party.NewIDSlice(signers), Message: []byte(message)}
+	}
+	assert.NoError(t, rs.Allow(ctx, req("release v1", 1, 2)))
+	assert.NoError(t, rs.Allow(ctx, req("\xff\x00binary", 1, 3)))
+
+	for name, r := range map[string]*Request{
+		"prefix":    req("deploy v1", 1, 2),
+		"signer":    req("release v1", 2, 3),
+		"min":       req("release v1", 1),
+		"group":     {GroupKey: randomKey(), Signers: party.IDSlice{1, 2}, Message: []byte("release v1")},
+		"prehashed": {GroupKey: key, Signers: party.IDSlice{1, 2}, Message: []byte("release v1"), Prehash: true},
+	} {
+		assert.True(t, errors.Is(rs.Allow(ctx, r), ErrDenied), name)
+	}
+
+	// the rate is exhausted, denials do not count
+	err = rs.Allow(ctx, req("release v2", 1, 2))
+	assert.True(t, errors.Is(err, ErrDenied))
+	assert.Contains(t, err.Error(), "rule releases: more than 2 requests per 1h0m0s")
+	fake.Advance(time.Hour)
+	assert.NoError(t, rs.Allow(ctx, req("release v2", 1, 2)))
+
+	// after hours
+	fake.Advance(7 * time.Hour)
+	assert.True(t, errors.Is(rs.Allow(ctx, req("release v3", 1, 2)), ErrDenied))
+}
+
+func TestRuleSet_FirstMatch(t *testing.T) {
+	rs, err := Parse([]byte(`{"rules": [
+		{"name": "limited", "rate": {"max": 1, "per": "24h"}},
+		{"name": "escalated", "require_signers": ["1", "2", "3"]}
+	]}`))
+	require.NoError(t, err)
+	ctx := context.Background()
+	r := &Request{GroupKey: randomKey(), Signers: party.IDSlice{1, 2}, Message: []byte("m")}
+	assert.NoError(t, rs.Allow(ctx, r))
+	err = rs.Allow(ctx, r)
+	assert.EqualError(t, err, "policy: denied: rule limited: more than 1 requests per 24h0m0s; rule escalated: party 3 does not sign")
+	r.Signers = party.IDSlice{1, 2, 3}
+	assert.NoError(t, rs.Allow(ctx, r))
+
+	empty, err := Parse([]byte(`{"rules": []}`))
+	require.NoError(t, err)
+	assert.True(t, errors.Is(empty.Allow(ctx, r), ErrDenied))
+}
+
+func TestWindow(t *testing.T) {
+	w := Window{Days: []string{"fri"}, Start: "22:00", End: "02:00", Location: "Europe/Berlin"}
+	require.NoError(t, w.compile())
+	berlin := w.loc
+	for at, in := range map[time.Time]bool{
+		time.Date(2026, 3, 6, 21, 59, 0, 0, berlin):   false,
+		time.Date(2026, 3, 6, 22, 0, 0, 0, berlin):    true,
+		time.Date(2026, 3, 7, 1, 59, 0, 0, berlin):    true, // Saturday morning, in the window of Friday
+		time.Date(2026, 3, 7, 2, 0, 0, 0, berlin):     false,
+		time.Date(2026, 3, 6, 1, 0, 0, 0, berlin):     false, // Friday morning, in the window of Thursday
+		time.Date(2026, 3, 6, 21, 30, 0, 0, time.UTC): true,
+	} {
+		assert.Equal(t, in, w.contains(at), at.String())
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, data := range []string{
+		`{"rules": [{"message_prefixes_hex": ["zz"]}]}`,
+		`{"rules": [{"windows": [{"start": "9am", "end": "17:00"}]}]}`,
+		`{"rules": [{"windows": [{"days": ["someday"], "start": "09:00", "end": "17:00"}]}]}`,
+		`{"rules": [{"windows": [{"start": "09:00", "end": "17:00", "location": "Mars/Olympus"}]}]}`,
+		`{"rules": [{"rate": {"max": 1, "per": "daily"}}]}`,
+		`{"rules": [{"prefixes": ["typo"]}]}`,
+		`{"rules": [null]}`,
+	} {
+		_, err := Parse([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestAll(t *testing.T) {
+	var asked []string
+	policy := func(name string, err error) Policy {
+		return Func(func(context.Context, *Request) error {
+			asked = append(asked, name)
+			return err
+		})
+	}
+	p := All(policy("a", nil), policy("b", Deny("by b")), policy("c", nil))
+	err := p.Allow(context.Background(), &Request{})
+	assert.EqualError(t, err, "policy: denied: by b")
+	assert.Equal(t, []string{"a", "b"}, asked)
+}
+
+func TestNewRuleSet(t *testing.T) {
+	rs, err := NewRuleSet(&Rule{Name: "any"})
+	require.NoError(t, err)
+	assert.NoError(t, rs.Allow(context.Background(), &Request{GroupKey: randomKey()}))
+
+	_, err = NewRuleSet(&Rule{Rate: &Rate{Max: 1}})
+	assert.EqualError(t, err, `policy: rule #0: invalid rate 1 per ""`)
+}
@@ -0,0 +1,318 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/manifest"
+	"github.com/bartke/frost/party"
+)
+
+// Rule allows the requests that match all of its conditions. Empty fields
+// match any request.
+type Rule struct {
+	// Name identifies the rule in denials.
+	Name string `json:"name"`
+
+	// Groups are the fingerprints of the group keys the rule applies to, see
+	// manifest.Fingerprint.
+	Groups []string `json:"groups,omitempty"`
+
+	// MessagePrefixes and MessagePrefixesHex are the prefixes one of which
+	// the message must start with, given as text or hex. A prehashed request
+	// does not match them, as only the digest of its message is known.
+	MessagePrefixes    []string `json:"message_prefixes,omitempty"`
+	MessagePrefixesHex []string `json:"message_prefixes_hex,omitempty"`
+
+	// RequireSigners are parties that must take part, e.g. so that the
+	// operators of their shares see every signature, and MinSigners is the
+	// least number of signers.
+	RequireSigners party.IDSlice `json:"require_signers,omitempty"`
+	MinSigners     int           `json:"min_signers,omitempty"`
+
+	// Windows are the times of the week one of which the request must be made in.
+	Windows []Window `json:"windows,omitempty"`
+
+	// Rate limits the number of requests the rule allows.
+	Rate *Rate `json:"rate,omitempty"`
+
+	prefixes [][]byte
+	allowed  []time.Time
+}
+
+// Window is a daily time window, such as office hours.
+type Window struct {
+	// Days are the days of the week, "mon" to "sun". Empty means every day.
+	Days []string `json:"days,omitempty"`
+	// Start and End are the times of the day, as "15:04". The window
+	// includes Start and excludes End, and spans midnight if End is before Start.
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// Location is the time zone of the window, such as "Europe/Berlin". It defaults to UTC.
+	Location string `json:"location,omitempty"`
+
+	days       [7]bool
+	start, end time.Duration
+	loc        *time.Location
+}
+
+// Rate allows at most Max requests in any period of length Per, such as "1h".
+type Rate struct {
+	Max int    `json:"max"`
+	Per string `json:"per"`
+
+	per time.Duration
+}
+
+// RuleSet is a Policy allowing the requests that one of its rules matches,
+// and denying all others. The rate limits are counted in memory, so they
+// start over when the RuleSet is loaded again.
+type RuleSet struct {
+	Rules []*Rule `json:"rules"`
+
+	// Clock is the time the requests are made at, it defaults to the real time.
+	Clock clock.Clock `json:"-"`
+
+	mu sync.Mutex
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Parse decodes and validates a JSON rule set, such as
+//
+//	{"rules": [{
+//	  "name": "releases",
+//	  "message_prefixes": ["release "],
+//	  "require_signers": ["1"],
+//	  "windows": [{"days": ["mon", "tue", "wed", "thu", "fri"], "start": "09:00", "end": "17:00", "location": "Europe/Berlin"}],
+//	  "rate": {"max": 10, "per": "24h"}
+//	}]}
+func Parse(data []byte) (*RuleSet, error) {
+	var rs RuleSet
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rs); err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	if err := rs.compile(); err != nil {
+		return nil, err
+	}
+	return &rs, nil
+}
+
+// NewRuleSet validates the rules and returns a RuleSet of them.
+func NewRuleSet(rules ...*Rule) (*RuleSet, error) {
+	rs := &RuleSet{Rules: rules}
+	if err := rs.compile(); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// Load is Parse of the file at path.
+func Load(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	return Parse(data)
+}
+
+// compile validates the rules, and prepares them for Allow.
+func (rs *RuleSet) compile() error {
+	for i, r := range rs.Rules {
+		if r == nil {
+			return fmt.Errorf("policy: rule %d is empty", i)
+		}
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("#%d", i)
+		}
+		if err := r.compile(); err != nil {
+			return fmt.Errorf("policy: rule %s: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) compile() error {
+	r.prefixes = r.prefixes[:0]
+	for _, p := range r.MessagePrefixes {
+		r.prefixes = append(r.prefixes, []byte(p))
+	}
+	for _, p := range r.MessagePrefixesHex {
+		b, err := hex.DecodeString(p)
+		if err != nil {
+			return fmt.Errorf("invalid hex prefix %q", p)
+		}
+		r.prefixes = append(r.prefixes, b)
+	}
+	r.RequireSigners = party.NewIDSlice(r.RequireSigners)
+	for i := range r.Windows {
+		if err := r.Windows[i].compile(); err != nil {
+			return err
+		}
+	}
+	if r.Rate != nil {
+		per, err := time.ParseDuration(r.Rate.Per)
+		if err != nil || per <= 0 || r.Rate.Max < 0 {
+			return fmt.Errorf("invalid rate %d per %q", r.Rate.Max, r.Rate.Per)
+		}
+		r.Rate.per = per
+	}
+	return nil
+}
+
+func (w *Window) compile() error {
+	if len(w.Days) == 0 {
+		for i := range w.days {
+			w.days[i] = true
+		}
+	}
+	for _, d := range w.Days {
+		day, ok := weekdays[strings.ToLower(d)]
+		if !ok {
+			return fmt.Errorf("invalid day %q", d)
+		}
+		w.days[day] = true
+	}
+	var err error
+	if w.start, err = timeOfDay(w.Start); err != nil {
+		return err
+	}
+	if w.end, err = timeOfDay(w.End); err != nil {
+		return err
+	}
+	if w.loc, err = time.LoadLocation(w.Location); err != nil {
+		return fmt.Errorf("invalid location %q: %w", w.Location, err)
+	}
+	return nil
+}
+
+// timeOfDay parses "15:04" into the duration since midnight.
+func timeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains returns true if t is within the window.
+func (w *Window) contains(t time.Time) bool {
+	t = t.In(w.loc)
+	// the wall clock time, which does not jump on days with daylight saving changes
+	since := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.start <= w.end {
+		return w.days[t.Weekday()] && since >= w.start && since < w.end
+	}
+	// the part after midnight belongs to the window that started the day before
+	if since >= w.start {
+		return w.days[t.Weekday()]
+	}
+	return since < w.end && w.days[(t.Weekday()+6)%7]
+}
+
+// match returns nil if the request meets the conditions of the rule other
+// than its rate, or the reason it does not.
+func (r *Rule) match(req *Request, now time.Time) error {
+	if len(r.Groups) > 0 {
+		if req.GroupKey == nil {
+			return errors.New("the group is not known")
+		}
+		group := manifest.Fingerprint(req.GroupKey.ToEd25519())
+		found := false
+		for _, g := range r.Groups {
+			found = found || g == group
+		}
+		if !found {
+			return fmt.Errorf("group %s is not allowed", group)
+		}
+	}
+	if len(r.prefixes) > 0 {
+		if req.Prehash {
+			return errors.New("the message of a prehashed request cannot be checked")
+		}
+		found := false
+		for _, p := range r.prefixes {
+			found = found || bytes.HasPrefix(req.Message, p)
+		}
+		if !found {
+			return errors.New("the message has no allowed prefix")
+		}
+	}
+	for _, id := range r.RequireSigners {
+		if !req.Signers.Contains(id) {
+			return fmt.Errorf("party %d does not sign", id)
+		}
+	}
+	if n := len(req.Signers); n < r.MinSigners {
+		return fmt.Errorf("%d signers are fewer than %d", n, r.MinSigners)
+	}
+	if len(r.Windows) > 0 {
+		found := false
+		for i := range r.Windows {
+			found = found || r.Windows[i].contains(now)
+		}
+		if !found {
+			return errors.New("outside of the allowed times")
+		}
+	}
+	return nil
+}
+
+// take counts a request at now against the rate of the rule, and returns false
+// if the rate is exhausted.
+func (r *Rule) take(now time.Time) bool {
+	if r.Rate == nil {
+		return true
+	}
+	since := now.Add(-r.Rate.per)
+	recent := r.allowed[:0]
+	for _, t := range r.allowed {
+		if t.After(since) {
+			recent = append(recent, t)
+		}
+	}
+	r.allowed = recent
+	if len(r.allowed) >= r.Rate.Max {
+		return false
+	}
+	r.allowed = append(r.allowed, now)
+	return true
+}
+
+// Allow implements Policy. A request is counted against the rate of the
+// first rule that matches it and has not exhausted its rate.
+func (rs *RuleSet) Allow(_ context.Context, req *Request) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	now := clock.OrReal(rs.Clock).Now()
+	reasons := make([]string, 0, len(rs.Rules))
+	for _, r := range rs.Rules {
+		err := r.match(req, now)
+		if err == nil && !r.take(now) {
+			err = fmt.Errorf("more than %d requests per %s", r.Rate.Max, r.Rate.per)
+		}
+		if err == nil {
+			return nil
+		}
+		reasons = append(reasons, fmt.Sprintf("rule %s: %v", r.Name, err))
+	}
+	if len(reasons) == 0 {
+		return Deny("no rules")
+	}
+	return Deny("%s", strings.Join(reasons, "; "))
+}
@@ -2,12 +2,21 @@ package polynomial
 
 import (
 	"errors"
+	"fmt"
+	"math/bits"
 
+	"github.com/bartke/frost/internal/vartime"
 	"github.com/bartke/frost/party"
 	"github.com/bartke/frost/ristretto"
 	"github.com/bartke/frost/scalar"
 )
 
+// MaxDegree is the largest degree of a Polynomial or Exponent that
+// UnmarshalBinary accepts. A group with a threshold of MaxDegree already needs
+// MaxDegree+1 signers, and the KeyGen1 message of each of its parties holds
+// 128 KiB of commitments.
+const MaxDegree party.Size = 4095
+
 // Exponent represents a polynomial whose coefficients are points on an elliptic curve.
 type Exponent struct {
 	coefficients []*ristretto.Element
@@ -49,7 +58,7 @@ func (p *Exponent) evaluateClassic(index *ristretto.Scalar, result *ristretto.El
 
 	result.Set(ristretto.NewIdentityElement())
 	for i := 0; i < len(p.coefficients); i++ {
-		tmp.VarTimeDoubleScalarBaseMult(x, p.coefficients[i], zero)
+		vartime.DoubleScalarBaseMult(&tmp, x, p.coefficients[i], zero)
 		result.Add(result, &tmp)
 
 		x.Multiply(x, index)
@@ -58,7 +67,7 @@ func (p *Exponent) evaluateClassic(index *ristretto.Scalar, result *ristretto.El
 }
 
 // evaluateVar evaluates a polynomial in a given variable index.
-// We exploit the fact that ristretto.Element.VarTimeMultiScalarMult is a lot faster
+// We exploit the fact that a multi-scalar multiplication is a lot faster
 // than other Point ops, but this requires us to have access to an array of powers of index.
 func (p *Exponent) evaluateVar(index *ristretto.Scalar, result *ristretto.Element) *ristretto.Element {
 	if index.Equal(ristretto.NewScalar()) == 1 {
@@ -77,12 +86,12 @@ func (p *Exponent) evaluateVar(index *ristretto.Scalar, result *ristretto.Elemen
 			powersPointers[i] = powers[i].Multiply(&powers[i-1], index)
 		}
 	}
-	result.VarTimeMultiScalarMult(powersPointers, p.coefficients)
+	vartime.MultiScalarMult(result, powersPointers, p.coefficients)
 	return result
 }
 
 // evaluateHorner evaluates a polynomial in a given variable index
-// We create a list of all powers of index, and use VarTimeMultiScalarMult
+// We create a list of all powers of index, and use a multi-scalar multiplication
 // to speed things up.
 func (p *Exponent) evaluateHorner(index *ristretto.Scalar, result *ristretto.Element) *ristretto.Element {
 	if index.Equal(ristretto.NewScalar()) == 1 {
@@ -95,18 +104,51 @@ func (p *Exponent) evaluateHorner(index *ristretto.Scalar, result *ristretto.Ele
 
 	for i := len(p.coefficients) - 1; i >= 0; i-- {
 		// B_n-1 = [x]B_n  + A_n-1
-		result.VarTimeDoubleScalarBaseMult(index, result, zero)
+		vartime.DoubleScalarBaseMult(result, index, result, zero)
 		result.Add(result, p.coefficients[i])
 	}
 	return result
 }
 
+// EvaluateID evaluates the polynomial in the party ID id, which must not be 0.
+//
+// Since an ID has 16 bits, Horner's rule multiplies by it with at most 16
+// doublings and additions per coefficient, which is several times faster than
+// Evaluate for the polynomials of groups of any size. It runs in variable time,
+// and so must only be used for public polynomials, such as the commitments of
+// the key generation.
+func (p *Exponent) EvaluateID(id party.ID) *ristretto.Element {
+	if id == 0 {
+		panic("you should be using .Constant() instead")
+	}
+	var result ristretto.Element
+	result.Set(p.coefficients[len(p.coefficients)-1])
+	for i := len(p.coefficients) - 2; i >= 0; i-- {
+		// B_i = [id]B_i+1 + A_i
+		mulID(&result, id)
+		result.Add(&result, p.coefficients[i])
+	}
+	return &result
+}
+
+// mulID sets e to [id]e, with the double-and-add method over the bits of id.
+func mulID(e *ristretto.Element, id party.ID) {
+	var base ristretto.Element
+	base.Set(e)
+	for bit := bits.Len16(uint16(id)) - 2; bit >= 0; bit-- {
+		e.Add(e, e)
+		if id&(1<<bit) != 0 {
+			e.Add(e, &base)
+		}
+	}
+}
+
 // EvaluateMulti evaluates a polynomial in a many given points.
 func (p *Exponent) EvaluateMulti(indices []party.ID) map[party.ID]*ristretto.Element {
 	evaluations := make(map[party.ID]*ristretto.Element, len(indices))
 
 	for _, id := range indices {
-		evaluations[id] = p.Evaluate(id.Scalar())
+		evaluations[id] = p.EvaluateID(id)
 	}
 	return evaluations
 }
@@ -130,6 +172,24 @@ func (p *Exponent) Add(q *Exponent) error {
 	return nil
 }
 
+// AddScaled sets p to p + [s]q, multiplying each coefficient of q by s, so that
+// (p + [s]q)(x) = p(x) + [s]q(x). It runs in variable time, and returns an
+// error if the length of the coefficients are different.
+func (p *Exponent) AddScaled(q *Exponent, s *ristretto.Scalar) error {
+	if len(p.coefficients) != len(q.coefficients) {
+		return errors.New("q is not the same length as p")
+	}
+
+	zero := ristretto.NewScalar()
+	var tmp ristretto.Element
+	for i := 0; i < len(p.coefficients); i++ {
+		vartime.DoubleScalarBaseMult(&tmp, s, q.coefficients[i], zero)
+		p.coefficients[i].Add(p.coefficients[i], &tmp)
+	}
+
+	return nil
+}
+
 // Sum creates a new Polynomial in the Exponent, by summing a slice of existing ones.
 func Sum(polynomials []*Exponent) (*Exponent, error) {
 	var err error
@@ -182,33 +242,40 @@ func (p *Exponent) MarshalBinary() (data []byte, err error) {
 }
 
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+// It rejects polynomials of a degree larger than MaxDegree, and leaves p
+// unchanged if data is invalid.
 func (p *Exponent) UnmarshalBinary(data []byte) error {
 	degree, err := party.FromBytes(data)
 	if err != nil {
 		return err
 	}
-	coefficientCount := degree + 1
+	if degree > MaxDegree {
+		return fmt.Errorf("degree %d exceeds the maximum of %d", degree, MaxDegree)
+	}
+	// computed as an int, since degree+1 overflows a party.Size of 0xffff
+	coefficientCount := int(degree) + 1
 	remaining := data[party.IDByteSize:]
 
 	count := len(remaining)
 	if count%32 != 0 {
 		return errors.New("length of data is wrong")
 	}
-	if count != int(coefficientCount)*32 {
+	if count != coefficientCount*32 {
 		return errors.New("wrong number of coefficients embedded")
 	}
 
 	coefficients := make([]ristretto.Element, coefficientCount)
-	p.coefficients = make([]*ristretto.Element, coefficientCount)
+	pointers := make([]*ristretto.Element, coefficientCount)
 
-	for i := 0; i < len(p.coefficients); i++ {
-		p.coefficients[i], err = coefficients[i].SetCanonicalBytes(remaining[:32])
+	for i := 0; i < len(pointers); i++ {
+		pointers[i], err = coefficients[i].SetCanonicalBytes(remaining[:32])
 		if err != nil {
 			return err
 		}
 
 		remaining = remaining[32:]
 	}
+	p.coefficients = pointers
 	return nil
 }
 
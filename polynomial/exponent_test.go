@@ -8,6 +8,7 @@ import (
 	"github.com/bartke/frost/ristretto"
 	"github.com/bartke/frost/scalar"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExponent_Evaluate(t *testing.T) {
@@ -59,6 +60,71 @@ func Benchmark_Evaluate(b *testing.B) {
 			polyExp.evaluateVar(randomIndex, &result)
 		}
 	})
+	b.Run("id", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			polyExp.EvaluateID(party.RandID())
+		}
+	})
+}
+
+func TestExponent_EvaluateID(t *testing.T) {
+	for _, degree := range []party.Size{0, 1, 2, 50} {
+		poly := NewPolynomial(degree, scalar.NewScalarRandom())
+		polyExp := NewPolynomialExponent(poly)
+		for _, id := range []party.ID{1, 2, 3, 0x8000, 0xffff, party.RandID()} {
+			var expected ristretto.Element
+			expected.ScalarBaseMult(poly.Evaluate(id.Scalar()))
+			assert.Equal(t, 1, expected.Equal(polyExp.EvaluateID(id)), "degree %d, id %d", degree, id)
+		}
+	}
+
+	polyExp := NewPolynomialExponent(NewPolynomial(2, scalar.NewScalarRandom()))
+	evaluations := polyExp.EvaluateMulti([]party.ID{4, 9})
+	assert.Len(t, evaluations, 2)
+	assert.Equal(t, 1, evaluations[9].Equal(polyExp.Evaluate(party.ID(9).Scalar())))
+	assert.Panics(t, func() { polyExp.EvaluateID(0) })
+}
+
+func TestExponent_AddScaled(t *testing.T) {
+	p := NewPolynomial(3, scalar.NewScalarRandom())
+	q := NewPolynomial(3, scalar.NewScalarRandom())
+	s := scalar.NewScalarRandom()
+	x := party.RandID().Scalar()
+
+	pExp := NewPolynomialExponent(p)
+	require.NoError(t, pExp.AddScaled(NewPolynomialExponent(q), s))
+
+	// (p + s*q)(x)•G
+	var sum ristretto.Scalar
+	sum.Multiply(s, q.Evaluate(x))
+	sum.Add(&sum, p.Evaluate(x))
+	var expected ristretto.Element
+	expected.ScalarBaseMult(&sum)
+	assert.Equal(t, 1, expected.Equal(pExp.Evaluate(x)))
+
+	assert.Error(t, pExp.AddScaled(NewPolynomialExponent(NewPolynomial(2, s)), s))
+}
+
+func TestExponent_UnmarshalBinary(t *testing.T) {
+	polyExp := NewPolynomialExponent(NewPolynomial(MaxDegree, scalar.NewScalarRandom()))
+	data, err := polyExp.MarshalBinary()
+	require.NoError(t, err)
+	var decoded Exponent
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.True(t, polyExp.Equal(&decoded))
+
+	// one coefficient more than allowed
+	tooLarge := append(party.Size(MaxDegree+1).Bytes(), data[party.IDByteSize:]...)
+	tooLarge = append(tooLarge, data[party.IDByteSize:party.IDByteSize+32]...)
+	assert.Error(t, decoded.UnmarshalBinary(tooLarge))
+
+	// an invalid last coefficient leaves the polynomial unchanged
+	invalid := append([]byte(nil), data...)
+	for i := len(invalid) - 32; i < len(invalid); i++ {
+		invalid[i] = 0xff
+	}
+	assert.Error(t, decoded.UnmarshalBinary(invalid))
+	assert.True(t, polyExp.Equal(&decoded))
 }
 
 func TestSum(t *testing.T) {
@@ -92,3 +158,28 @@ func TestSum(t *testing.T) {
 	assert.Equal(t, 1, evaluationSum.Equal(evaluationFromScalar))
 	assert.Equal(t, 1, evaluationSum.Equal(evaluationPartial))
 }
+
+func FuzzExponentUnmarshal(f *testing.F) {
+	for _, degree := range []party.Size{0, 1, 4} {
+		data, err := NewPolynomialExponent(NewPolynomial(degree, scalar.NewScalarRandom())).MarshalBinary()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte{0xff, 0xff})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var p Exponent
+		if p.UnmarshalBinary(data) != nil {
+			return
+		}
+		encoded, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, data, encoded)
+		p.Constant()
+		p.Evaluate(party.ID(1).Scalar())
+	})
+}
@@ -3,6 +3,7 @@ package polynomial
 import (
 	"crypto/rand"
 	"fmt"
+	"io"
 
 	"github.com/bartke/frost/party"
 	"github.com/bartke/frost/ristretto"
@@ -15,6 +16,15 @@ type Polynomial struct {
 // NewPolynomial generates a Polynomial f(X) = secret + a1*X + ... + at*X^t,
 // with coefficients in Z_q, and degree t.
 func NewPolynomial(degree party.Size, constant *ristretto.Scalar) *Polynomial {
+	return NewPolynomialFrom(degree, constant, rand.Reader)
+}
+
+// NewPolynomialFrom is like NewPolynomial, but samples the coefficients a1, ..., at using r,
+// or crypto/rand if it is nil.
+func NewPolynomialFrom(degree party.Size, constant *ristretto.Scalar, r io.Reader) *Polynomial {
+	if r == nil {
+		r = rand.Reader
+	}
 	var polynomial Polynomial
 	polynomial.coefficients = make([]ristretto.Scalar, degree+1)
 
@@ -24,7 +34,7 @@ func NewPolynomial(degree party.Size, constant *ristretto.Scalar) *Polynomial {
 	var err error
 	randomBytes := make([]byte, 64)
 	for i := party.Size(1); i <= degree; i++ {
-		_, err = rand.Read(randomBytes)
+		_, err = io.ReadFull(r, randomBytes)
 		if err != nil {
 			panic(fmt.Errorf("edwards25519: failed to generate random Scalar: %w", err))
 		}
@@ -69,6 +79,15 @@ func (p *Polynomial) Size() int {
 
 // Reset sets all coefficients to 0
 func (p *Polynomial) Reset() {
+	p.Zeroize()
+}
+
+// Zeroize overwrites the coefficients, including the secret constant term,
+// with 0, so that they do not remain in memory. p may be nil.
+func (p *Polynomial) Zeroize() {
+	if p == nil {
+		return
+	}
 	zero := ristretto.NewScalar()
 	for i := range p.coefficients {
 		p.coefficients[i].Set(zero)
@@ -82,29 +101,36 @@ func (p *Polynomial) MarshalBinary() (data []byte, err error) {
 }
 
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+// It rejects polynomials of a degree larger than MaxDegree, and leaves p
+// unchanged if data is invalid.
 func (p *Polynomial) UnmarshalBinary(data []byte) error {
 	degree, err := party.FromBytes(data)
 	if err != nil {
 		return err
 	}
-	coefficientCount := degree + 1
+	if degree > MaxDegree {
+		return fmt.Errorf("degree %d exceeds the maximum of %d", degree, MaxDegree)
+	}
+	// computed as an int, since degree+1 overflows a party.Size of 0xffff
+	coefficientCount := int(degree) + 1
 	remaining := data[party.IDByteSize:]
 
 	count := len(remaining)
 	if count%32 != 0 {
 		return fmt.Errorf("length of data is wrong")
 	}
-	if count != int(coefficientCount)*32 {
+	if count != coefficientCount*32 {
 		return fmt.Errorf("wrong number of coefficients embedded")
 	}
 
-	p.coefficients = make([]ristretto.Scalar, coefficientCount)
-	for i := 0; i < int(coefficientCount); i++ {
-		_, err = p.coefficients[i].SetCanonicalBytes(remaining[i*32 : (i+1)*32])
+	coefficients := make([]ristretto.Scalar, coefficientCount)
+	for i := 0; i < coefficientCount; i++ {
+		_, err = coefficients[i].SetCanonicalBytes(remaining[i*32 : (i+1)*32])
 		if err != nil {
 			return err
 		}
 	}
+	p.coefficients = coefficients
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package polynomial
 
 import (
+	"bytes"
 	"encoding/binary"
 	"testing"
 
@@ -27,3 +28,56 @@ func TestPolynomial_Evaluate(t *testing.T) {
 		}
 	}
 }
+
+func TestPolynomial_Zeroize(t *testing.T) {
+	secret := scalar.NewScalarRandom()
+	polynomial := NewPolynomial(3, secret)
+	polynomial.Zeroize()
+	zero := ristretto.NewScalar()
+	assert.Equal(t, 4, polynomial.Size())
+	for i := range polynomial.coefficients {
+		assert.Equal(t, 1, polynomial.coefficients[i].Equal(zero))
+	}
+	assert.Equal(t, 0, secret.Equal(zero), "the constant is copied")
+
+	var nilPolynomial *Polynomial
+	nilPolynomial.Zeroize()
+}
+
+func TestNewPolynomialFrom(t *testing.T) {
+	secret := scalar.NewScalarRandom()
+	p := NewPolynomialFrom(3, secret, bytes.NewReader(bytes.Repeat([]byte{7}, 3*64)))
+	q := NewPolynomialFrom(3, secret, bytes.NewReader(bytes.Repeat([]byte{7}, 3*64)))
+	x := party.RandID().Scalar()
+	assert.Equal(t, 1, p.Evaluate(x).Equal(q.Evaluate(x)))
+	assert.Equal(t, 1, p.Constant().Equal(secret))
+
+	// a nil reader reads crypto/rand
+	r := NewPolynomialFrom(3, secret, nil)
+	assert.Equal(t, 0, p.Evaluate(x).Equal(r.Evaluate(x)))
+	assert.Equal(t, 1, r.Constant().Equal(secret))
+}
+
+func FuzzPolynomialUnmarshal(f *testing.F) {
+	for _, degree := range []party.Size{0, 1, 4} {
+		data, err := NewPolynomial(degree, scalar.NewScalarRandom()).MarshalBinary()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte{0xff, 0xff})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var p Polynomial
+		if p.UnmarshalBinary(data) != nil {
+			return
+		}
+		encoded, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, data, encoded)
+		p.Constant()
+	})
+}
@@ -0,0 +1,69 @@
+package frost
+
+import (
+	"crypto/sha512"
+	"fmt"
+	"io"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+)
+
+// PrehashMessage returns the SHA-512 digest of the message read from r, for
+// SignInitPrehashed. The message is streamed, so it may be larger than memory.
+func PrehashMessage(r io.Reader) ([]byte, error) {
+	h := sha512.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, fmt.Errorf("PrehashMessage: %w", err)
+	}
+	return h.Sum(nil), nil
+}
+
+// SignInitPrehashed is like SignInit, but the signers agree on digest, the
+// SHA-512 hash of the message computed with PrehashMessage, instead of the
+// message itself. The state and the messages only hold the digest, so large
+// artifacts can be signed without keeping them in memory or in state files.
+//
+// The result is an Ed25519ph signature with an empty context, which verifies
+// with eddsa.PublicKey.VerifyPh, or ed25519.VerifyWithOptions with
+// crypto.SHA512, but not with plain ed25519.Verify. An Aggregator for the
+// session must have Prehash set, and the digest as its message.
+func SignInitPrehashed(signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, digest []byte) (*Message, *SignerState, error) {
+	if len(digest) != sha512.Size {
+		return nil, nil, fmt.Errorf("SignInitPrehashed: digest has %d bytes instead of %d", len(digest), sha512.Size)
+	}
+	msg, state, err := SignInit(signerIDs, secret, shares, digest)
+	if err != nil {
+		return nil, nil, err
+	}
+	state.Prehash = true
+	return msg, state, nil
+}
+
+// VerifyPartialPrehashed is VerifyPartial for a session started with
+// SignInitPrehashed, whose message is the digest.
+func VerifyPartialPrehashed(public *eddsa.Public, signerIDs party.IDSlice, digest []byte, sign1s []*Message, sign2 *Message) error {
+	if len(digest) != sha512.Size {
+		return fmt.Errorf("VerifyPartialPrehashed: digest has %d bytes instead of %d", len(digest), sha512.Size)
+	}
+	return verifyPartial(public, signerIDs, digest, true, sign1s, sign2)
+}
+
+// computeChallenge returns c = H(R, A, message), or the challenge of Ed25519ph
+// with an empty context if message is a prehashed digest.
+func computeChallenge(R *ristretto.Element, groupKey *eddsa.PublicKey, message []byte, prehash bool) *ristretto.Scalar {
+	if !prehash {
+		return eddsa.ComputeChallenge(R, groupKey, message)
+	}
+	dom, _ := eddsa.Dom2(1, nil)
+	return eddsa.ComputeChallengeWithDomain(R, groupKey, dom, message)
+}
+
+// verifySignature verifies sig on message, or on the prehashed digest in message.
+func verifySignature(groupKey *eddsa.PublicKey, message []byte, sig *eddsa.Signature, prehash bool) bool {
+	if !prehash {
+		return groupKey.Verify(message, sig)
+	}
+	return groupKey.VerifyPh(message, nil, sig)
+}
@@ -0,0 +1,136 @@
+package frost
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignInitPrehashed(t *testing.T) {
+	public, secrets := generateKeys(t, 4, 2)
+	signers := party.IDSlice{1, 3, 4}
+	message := bytes.Repeat([]byte("a large artifact "), 1<<12)
+	digest, err := PrehashMessage(bytes.NewReader(message))
+	require.NoError(t, err)
+
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInitPrehashed(signers, secrets[id], public, digest)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+
+	// the state holds the digest only, and keeps the mode through its encodings
+	data, err := json.Marshal(states[1])
+	require.NoError(t, err)
+	assert.Less(t, len(data), len(message))
+	var decoded SignerState
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.True(t, decoded.Prehash)
+	data, err = states[1].MarshalCBOR()
+	require.NoError(t, err)
+	decoded = SignerState{}
+	require.NoError(t, decoded.UnmarshalCBOR(data))
+	assert.True(t, decoded.Prehash)
+
+	sigs, err := runSignRounds(states, round1)
+	require.NoError(t, err)
+	pub := public.GroupKey.ToEd25519()
+	for _, sig := range sigs {
+		assert.True(t, public.GroupKey.VerifyPh(digest, nil, sig))
+		assert.NoError(t, ed25519.VerifyWithOptions(pub, digest, sig.ToEd25519(), &ed25519.Options{Hash: crypto.SHA512}))
+		assert.False(t, ed25519.Verify(pub, message, sig.ToEd25519()))
+	}
+
+	_, _, err = SignInitPrehashed(signers, secrets[1], public, message[:32])
+	assert.Error(t, err)
+}
+
+func TestAggregator_Prehash(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	signers := party.IDSlice{1, 2}
+	digest, err := PrehashMessage(bytes.NewReader([]byte("aggregated artifact")))
+	require.NoError(t, err)
+
+	agg, err := NewAggregator(signers, public, digest)
+	require.NoError(t, err)
+	agg.Prehash = true
+
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInitPrehashed(signers, secrets[id], public, digest)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	forwarded, err := agg.AddCommitments(round1)
+	require.NoError(t, err)
+	var shares []*Message
+	for _, id := range signers {
+		msg, _, err := SignRound1(states[id], forwarded)
+		require.NoError(t, err)
+		shares = append(shares, msg)
+	}
+	sig, err := agg.Aggregate(shares)
+	require.NoError(t, err)
+	assert.True(t, public.GroupKey.VerifyPh(digest, nil, sig))
+
+	// the evidence of an abort is checked in the same mode
+	shares[0] = NewSign2(shares[0].From, scalar.NewScalarRandom())
+	_, err = agg.Aggregate(shares)
+	var abortErr *AbortError
+	require.True(t, errors.As(err, &abortErr))
+	culprit, err := VerifyAbortEvidence(public, abortErr.Evidence)
+	require.NoError(t, err)
+	assert.Equal(t, shares[0].From, culprit)
+}
+
+func TestVerifyPartialPrehashed(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	signers := party.IDSlice{1, 3}
+	digest, err := PrehashMessage(bytes.NewReader([]byte("audited artifact")))
+	require.NoError(t, err)
+
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInitPrehashed(signers, secrets[id], public, digest)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	shares := make(map[party.ID]*Message)
+	for _, id := range signers {
+		msg, _, err := SignRound1(states[id], round1)
+		require.NoError(t, err)
+		shares[id] = msg
+	}
+
+	for _, id := range signers {
+		assert.NoError(t, VerifyPartialPrehashed(public, signers, digest, round1, shares[id]))
+		// the shares of Ed25519ph do not verify as those of plain Ed25519
+		assert.Error(t, VerifyPartial(public, signers, digest, round1, shares[id]))
+	}
+
+	// an invalid share is attributed to its sender, with evidence in the same mode
+	err = VerifyPartialPrehashed(public, signers, digest, round1, NewSign2(3, scalar.NewScalarRandom()))
+	var abortErr *AbortError
+	require.True(t, errors.As(err, &abortErr))
+	assert.Equal(t, party.ID(3), abortErr.Culprit)
+	culprit, err := VerifyAbortEvidence(public, abortErr.Evidence)
+	require.NoError(t, err)
+	assert.Equal(t, party.ID(3), culprit)
+
+	assert.Error(t, VerifyPartialPrehashed(public, signers, digest[:32], round1, shares[1]))
+}
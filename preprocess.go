@@ -0,0 +1,222 @@
+package frost
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/scalar"
+)
+
+// Preprocessing splits signing into an offline and an online phase, as in the
+// FROST paper:
+//
+//  1. Offline, each signer generates a batch of nonces with PreprocessNonces,
+//     persists its NoncePool, and publishes the returned commitments to the
+//     coordinator, e.g. an Aggregator.
+//  2. Online, the coordinator picks one unused commitment of every signer, and
+//     sends the message together with the resulting CommitmentList.
+//  3. Each signer calls SignPreprocessed, which consumes its nonce and returns
+//     the Sign2 message with its signature share.
+//
+// The online phase is a single round trip. A nonce must never be used twice:
+// the pool must be persisted after SignPreprocessed, and before its Sign2
+// message is sent.
+
+// NoncePool holds the nonces a signer generated ahead of signing sessions.
+type NoncePool struct {
+	SelfID party.ID
+	nonces []preprocessedNonce
+}
+
+// preprocessedNonce is a pair of nonces d and e with their commitments Di and Ei.
+type preprocessedNonce struct {
+	D, E   ristretto.Scalar
+	Di, Ei ristretto.Element
+}
+
+// NewNoncePool returns an empty pool for the signer selfID.
+func NewNoncePool(selfID party.ID) *NoncePool {
+	return &NoncePool{SelfID: selfID}
+}
+
+// PreprocessNonces adds count pairs of nonces to pool, and returns the commitments
+// to publish.
+func PreprocessNonces(pool *NoncePool, count int) ([]Commitment, error) {
+	return preprocessNonces(pool, count, rand.Reader)
+}
+
+// preprocessNonces is PreprocessNonces with the nonces sampled from rng.
+func preprocessNonces(pool *NoncePool, count int, rng io.Reader) ([]Commitment, error) {
+	if pool.SelfID == 0 {
+		return nil, errors.New("PreprocessNonces: id 0 is not valid")
+	}
+	if count <= 0 {
+		return nil, fmt.Errorf("PreprocessNonces: invalid count %d", count)
+	}
+	commitments := make([]Commitment, count)
+	for i := range commitments {
+		var n preprocessedNonce
+		scalar.SetScalarRandomFrom(&n.D, rng)
+		n.Di.ScalarBaseMult(&n.D)
+		scalar.SetScalarRandomFrom(&n.E, rng)
+		n.Ei.ScalarBaseMult(&n.E)
+		pool.nonces = append(pool.nonces, n)
+		commitments[i] = Commitment{ID: pool.SelfID, Hiding: n.Di, Binding: n.Ei}
+	}
+	return commitments, nil
+}
+
+// Len returns the number of unused nonces.
+func (p *NoncePool) Len() int {
+	return len(p.nonces)
+}
+
+// Commitments returns the commitments of the unused nonces, in the order they were generated.
+func (p *NoncePool) Commitments() []Commitment {
+	commitments := make([]Commitment, len(p.nonces))
+	for i := range p.nonces {
+		commitments[i] = Commitment{ID: p.SelfID, Hiding: p.nonces[i].Di, Binding: p.nonces[i].Ei}
+	}
+	return commitments
+}
+
+// zeroize overwrites the nonces with 0.
+func (n *preprocessedNonce) zeroize() {
+	zero := ristretto.NewScalar()
+	n.D.Set(zero)
+	n.E.Set(zero)
+}
+
+// Zeroize overwrites the unused nonces with 0 and empties the pool.
+func (p *NoncePool) Zeroize() {
+	for i := range p.nonces {
+		p.nonces[i].zeroize()
+	}
+	p.nonces = p.nonces[:0]
+}
+
+// take removes the nonce committed to by c from the pool and returns it. The
+// slot it leaves in the backing array is zeroized.
+func (p *NoncePool) take(c *Commitment) (*preprocessedNonce, error) {
+	for i := range p.nonces {
+		n := p.nonces[i]
+		if n.Di.Equal(&c.Hiding) == 1 && n.Ei.Equal(&c.Binding) == 1 {
+			last := len(p.nonces) - 1
+			copy(p.nonces[i:], p.nonces[i+1:])
+			p.nonces[last].zeroize()
+			p.nonces = p.nonces[:last]
+			return &n, nil
+		}
+	}
+	return nil, errors.New("NoncePool: commitment is unknown or was already used")
+}
+
+// SignPreprocessed runs the online phase of a session whose commitments were
+// chosen by a coordinator from the preprocessed ones. It consumes our nonce
+// from pool, and returns the Sign2 message and the state for SignRound2, or for
+// the Aggregator. The signers of the session are those of l.
+func SignPreprocessed(pool *NoncePool, l CommitmentList, secret *eddsa.SecretShare, shares *eddsa.Public, message []byte) (*Message, *SignerState, error) {
+	if pool.SelfID != secret.ID {
+		return nil, nil, fmt.Errorf("SignPreprocessed: pool of party %d used by party %d", pool.SelfID, secret.ID)
+	}
+	if err := l.validate(); err != nil {
+		return nil, nil, fmt.Errorf("SignPreprocessed: %w", err)
+	}
+	var own *Commitment
+	for i := range l {
+		if l[i].ID == secret.ID {
+			own = &l[i]
+		}
+	}
+	if own == nil {
+		return nil, nil, fmt.Errorf("SignPreprocessed: party %d is not in the commitment list", secret.ID)
+	}
+
+	group, err := NewSigningGroup(l.IDs(), shares)
+	if err != nil {
+		return nil, nil, err
+	}
+	state, err := newSignerState(group, secret, message)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, err := pool.take(own)
+	if err != nil {
+		return nil, nil, fmt.Errorf("SignPreprocessed: %w", err)
+	}
+	state.D.Set(&nonce.D)
+	state.E.Set(&nonce.E)
+	nonce.zeroize()
+	self := state.Signers[state.SelfID]
+	self.Di.Set(&nonce.Di)
+	self.Ei.Set(&nonce.Ei)
+
+	return SignRound1(state, l.Messages())
+}
+
+func (p *NoncePool) MarshalJSON() ([]byte, error) {
+	type nonce struct {
+		D  string            `json:"d"`
+		E  string            `json:"e"`
+		Di ristretto.Element `json:"di"`
+		Ei ristretto.Element `json:"ei"`
+	}
+	nonces := make([]nonce, len(p.nonces))
+	for i, n := range p.nonces {
+		nonces[i] = nonce{
+			D:  base64.StdEncoding.EncodeToString(n.D.Bytes()),
+			E:  base64.StdEncoding.EncodeToString(n.E.Bytes()),
+			Di: n.Di,
+			Ei: n.Ei,
+		}
+	}
+	return json.Marshal(&struct {
+		SelfID party.ID `json:"self_id"`
+		Nonces []nonce  `json:"nonces"`
+	}{
+		SelfID: p.SelfID,
+		Nonces: nonces,
+	})
+}
+
+func (p *NoncePool) UnmarshalJSON(data []byte) error {
+	aux := &struct {
+		SelfID party.ID `json:"self_id"`
+		Nonces []struct {
+			D  string            `json:"d"`
+			E  string            `json:"e"`
+			Di ristretto.Element `json:"di"`
+			Ei ristretto.Element `json:"ei"`
+		} `json:"nonces"`
+	}{}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	nonces := make([]preprocessedNonce, len(aux.Nonces))
+	for i, n := range aux.Nonces {
+		if err := decodeScalar(n.D, &nonces[i].D); err != nil {
+			return err
+		}
+		if err := decodeScalar(n.E, &nonces[i].E); err != nil {
+			return err
+		}
+		var Di, Ei ristretto.Element
+		Di.ScalarBaseMult(&nonces[i].D)
+		Ei.ScalarBaseMult(&nonces[i].E)
+		if Di.Equal(&n.Di) != 1 || Ei.Equal(&n.Ei) != 1 {
+			return errors.New("NoncePool: commitment does not match its nonce")
+		}
+		nonces[i].Di, nonces[i].Ei = Di, Ei
+	}
+	p.SelfID = aux.SelfID
+	p.nonces = nonces
+	return nil
+}
@@ -0,0 +1,129 @@
+package frost
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignPreprocessed(t *testing.T) {
+	public, secrets := generateKeys(t, 4, 2)
+	signers := party.IDSlice{1, 3, 4}
+
+	// offline: every signer publishes commitments to a batch of nonces
+	pools := make(map[party.ID]*NoncePool)
+	published := make(map[party.ID][]Commitment)
+	for _, id := range public.PartyIDs {
+		pools[id] = NewNoncePool(id)
+		commitments, err := PreprocessNonces(pools[id], 3)
+		require.NoError(t, err)
+		published[id] = commitments
+	}
+
+	for i, message := range [][]byte{[]byte("first"), []byte("second")} {
+		// online: the coordinator picks one unused commitment of every signer
+		var l CommitmentList
+		for _, id := range signers {
+			l = append(l, published[id][i])
+		}
+		agg, err := NewAggregator(signers, public, message)
+		require.NoError(t, err)
+		_, err = agg.AddCommitments(l.Messages())
+		require.NoError(t, err)
+
+		var shares []*Message
+		for _, id := range signers {
+			msg, _, err := SignPreprocessed(pools[id], l, secrets[id], public, message)
+			require.NoError(t, err)
+			shares = append(shares, msg)
+		}
+		sig, err := agg.Aggregate(shares)
+		require.NoError(t, err)
+		assert.True(t, public.GroupKey.Verify(message, sig))
+
+		// nonces are used only once
+		_, _, err = SignPreprocessed(pools[1], l, secrets[1], public, message)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 1, pools[1].Len())
+	assert.Equal(t, 3, pools[2].Len())
+}
+
+func TestSignPreprocessed_SignRound2(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	signers := party.IDSlice{2, 3}
+	message := []byte("peers")
+
+	var l CommitmentList
+	pools := make(map[party.ID]*NoncePool)
+	for _, id := range signers {
+		pools[id] = NewNoncePool(id)
+		commitments, err := PreprocessNonces(pools[id], 1)
+		require.NoError(t, err)
+		l = append(l, commitments[0])
+	}
+
+	states := make(map[party.ID]*SignerState)
+	var shares []*Message
+	for _, id := range signers {
+		msg, state, err := SignPreprocessed(pools[id], l, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		shares = append(shares, msg)
+	}
+	for _, id := range signers {
+		sig, _, err := SignRound2(states[id], shares)
+		require.NoError(t, err)
+		assert.True(t, public.GroupKey.Verify(message, sig))
+	}
+}
+
+func TestNoncePool(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	pool := NewNoncePool(1)
+	commitments, err := PreprocessNonces(pool, 2)
+	require.NoError(t, err)
+	assert.Equal(t, commitments, pool.Commitments())
+
+	_, err = PreprocessNonces(pool, 0)
+	assert.Error(t, err)
+
+	data, err := json.Marshal(pool)
+	require.NoError(t, err)
+	var decoded NoncePool
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, party.ID(1), decoded.SelfID)
+	assert.Equal(t, commitments, decoded.Commitments())
+
+	// the pool of another party, or a list without us, is rejected
+	other := NewNoncePool(2)
+	otherCommitments, err := PreprocessNonces(other, 1)
+	require.NoError(t, err)
+	l := CommitmentList{commitments[0], otherCommitments[0]}
+	_, _, err = SignPreprocessed(other, l, secrets[1], public, []byte("m"))
+	assert.Error(t, err)
+	_, _, err = SignPreprocessed(pool, CommitmentList{otherCommitments[0]}, secrets[1], public, []byte("m"))
+	assert.Error(t, err)
+	assert.Equal(t, 2, pool.Len())
+
+	// a used nonce does not stay behind in the pool
+	_, _, err = SignPreprocessed(pool, CommitmentList{commitments[0], otherCommitments[0]}, secrets[1], public, []byte("m"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, pool.Len())
+	assert.Equal(t, 1, pool.nonces[:2][1].D.Equal(ristretto.NewScalar()))
+	pool.Zeroize()
+	assert.Equal(t, 0, pool.Len())
+
+	// a commitment that does not match its nonce
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	nonces := raw["nonces"].([]interface{})
+	nonces[0].(map[string]interface{})["d"] = nonces[1].(map[string]interface{})["d"]
+	data, err = json.Marshal(raw)
+	require.NoError(t, err)
+	assert.Error(t, json.Unmarshal(data, &decoded))
+}
@@ -0,0 +1,237 @@
+// Package progress tracks which messages of a ceremony have been exchanged,
+// and renders the message flow as a Graphviz DOT or Mermaid graph.
+//
+// It is meant for debugging stuck sessions: every expected message that has
+// not been seen yet is reported by Missing, and drawn as a dashed edge.
+package progress
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/party"
+)
+
+// Edge is a message sent by From to To. To is 0 for broadcast messages.
+type Edge struct {
+	Type frost.MessageType
+	From party.ID
+	To   party.ID
+}
+
+// Progress records the messages of a session between a fixed set of parties.
+type Progress struct {
+	Parties party.IDSlice
+
+	seen map[Edge]bool
+}
+
+// New returns an empty Progress for a session between parties.
+func New(parties party.IDSlice) *Progress {
+	return &Progress{
+		Parties: party.NewIDSlice(parties),
+		seen:    make(map[Edge]bool),
+	}
+}
+
+// Add records msg as sent.
+func (p *Progress) Add(msg *frost.Message) {
+	p.seen[Edge{Type: msg.Type, From: msg.From, To: msg.To}] = true
+}
+
+// Rounds returns the message types of the protocol the recorded messages belong to,
+// KeyGen1 and KeyGen2, or Sign1 and Sign2. It returns nil if no message was added.
+func (p *Progress) Rounds() []frost.MessageType {
+	for e := range p.seen {
+		switch e.Type {
+		case frost.MessageTypeKeyGen1, frost.MessageTypeKeyGen2:
+			return []frost.MessageType{frost.MessageTypeKeyGen1, frost.MessageTypeKeyGen2}
+		case frost.MessageTypeSign1, frost.MessageTypeSign2:
+			return []frost.MessageType{frost.MessageTypeSign1, frost.MessageTypeSign2}
+		}
+	}
+	return nil
+}
+
+// Expected returns every message of round t, in order of sender and recipient.
+// KeyGen2 shares are sent to every other party, all other messages are broadcast.
+func (p *Progress) Expected(t frost.MessageType) []Edge {
+	var edges []Edge
+	for _, from := range p.Parties {
+		if t != frost.MessageTypeKeyGen2 {
+			edges = append(edges, Edge{Type: t, From: from})
+			continue
+		}
+		for _, to := range p.Parties {
+			if to != from {
+				edges = append(edges, Edge{Type: t, From: from, To: to})
+			}
+		}
+	}
+	return edges
+}
+
+// Missing returns the expected messages of round t that have not been added.
+func (p *Progress) Missing(t frost.MessageType) []Edge {
+	var missing []Edge
+	for _, e := range p.Expected(t) {
+		if !p.seen[e] {
+			missing = append(missing, e)
+		}
+	}
+	return missing
+}
+
+// Unexpected returns the added messages that are not part of the session,
+// such as messages from unknown parties, sorted by type, sender and recipient.
+func (p *Progress) Unexpected() []Edge {
+	expected := make(map[Edge]bool)
+	for _, t := range p.Rounds() {
+		for _, e := range p.Expected(t) {
+			expected[e] = true
+		}
+	}
+	var unexpected []Edge
+	for e := range p.seen {
+		if !expected[e] {
+			unexpected = append(unexpected, e)
+		}
+	}
+	sort.Slice(unexpected, func(i, j int) bool {
+		a, b := unexpected[i], unexpected[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return unexpected
+}
+
+// Complete returns true if no expected message is missing.
+func (p *Progress) Complete() bool {
+	rounds := p.Rounds()
+	if rounds == nil {
+		return false
+	}
+	for _, t := range rounds {
+		if len(p.Missing(t)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// edges returns all edges to draw, and whether they were seen.
+// Broadcasts are drawn to every other party.
+func (p *Progress) edges() ([]Edge, []bool) {
+	var edges []Edge
+	var seen []bool
+	add := func(e Edge, ok bool) {
+		if e.To != 0 {
+			edges, seen = append(edges, e), append(seen, ok)
+			return
+		}
+		for _, to := range p.Parties {
+			if to != e.From {
+				edges = append(edges, Edge{Type: e.Type, From: e.From, To: to})
+				seen = append(seen, ok)
+			}
+		}
+	}
+	for _, t := range p.Rounds() {
+		for _, e := range p.Expected(t) {
+			add(e, p.seen[e])
+		}
+	}
+	for _, e := range p.Unexpected() {
+		add(e, true)
+	}
+	return edges, seen
+}
+
+// DOT renders the session as a Graphviz digraph.
+// Received messages are solid edges, missing ones are dashed and red.
+func (p *Progress) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph session {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, id := range p.Parties {
+		fmt.Fprintf(&b, "  p%d [label=\"party %d\"%s];\n", id, id, p.nodeStyle(id, ", color=red"))
+	}
+	edges, seen := p.edges()
+	for i, e := range edges {
+		style := ""
+		if !seen[i] {
+			style = ", style=dashed, color=red"
+		}
+		fmt.Fprintf(&b, "  p%d -> p%d [label=\"%s\"%s];\n", e.From, e.To, e.Type, style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the session as a Mermaid flowchart.
+// Received messages are solid arrows, missing ones are dotted.
+func (p *Progress) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, id := range p.Parties {
+		fmt.Fprintf(&b, "  p%d[\"party %d\"]\n", id, id)
+	}
+	edges, seen := p.edges()
+	for i, e := range edges {
+		arrow := "-->"
+		if !seen[i] {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  p%d %s|%s| p%d\n", e.From, arrow, e.Type, e.To)
+	}
+	for _, id := range p.Parties {
+		if p.nodeStyle(id, "red") != "" {
+			fmt.Fprintf(&b, "  style p%d stroke:red\n", id)
+		}
+	}
+	return b.String()
+}
+
+// nodeStyle returns style if party id has not sent all of its messages.
+func (p *Progress) nodeStyle(id party.ID, style string) string {
+	for _, t := range p.Rounds() {
+		for _, e := range p.Missing(t) {
+			if e.From == id {
+				return style
+			}
+		}
+	}
+	return ""
+}
+
+// Summary returns one line per round, listing the parties whose messages are missing.
+func (p *Progress) Summary() string {
+	var b strings.Builder
+	for _, t := range p.Rounds() {
+		missing := p.Missing(t)
+		if len(missing) == 0 {
+			fmt.Fprintf(&b, "%s: complete\n", t)
+			continue
+		}
+		parts := make([]string, 0, len(missing))
+		for _, e := range missing {
+			if e.To == 0 {
+				parts = append(parts, e.From.String())
+			} else {
+				parts = append(parts, fmt.Sprintf("%d->%d", e.From, e.To))
+			}
+		}
+		fmt.Fprintf(&b, "%s: missing %s\n", t, strings.Join(parts, ", "))
+	}
+	for _, e := range p.Unexpected() {
+		fmt.Fprintf(&b, "unexpected %s from %d to %d\n", e.Type, e.From, e.To)
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package progress
+
+import (
+	"testing"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress_Keygen(t *testing.T) {
+	p := New(party.IDSlice{3, 1, 2})
+	assert.Nil(t, p.Rounds())
+	assert.False(t, p.Complete())
+
+	var round1 []*frost.Message
+	states := make(map[party.ID]*frost.KeygenState)
+	for id := party.ID(1); id <= 3; id++ {
+		msg, state, err := frost.KeygenInit(id, 3, 1)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+		p.Add(msg)
+	}
+	assert.Empty(t, p.Missing(frost.MessageTypeKeyGen1))
+	assert.Len(t, p.Missing(frost.MessageTypeKeyGen2), 6)
+
+	// party 3 does not send its shares
+	for id := party.ID(1); id <= 2; id++ {
+		msgs, _, err := frost.KeygenRound1(states[id], round1)
+		require.NoError(t, err)
+		for _, msg := range msgs {
+			p.Add(msg)
+		}
+	}
+	assert.False(t, p.Complete())
+	assert.Equal(t, []Edge{
+		{Type: frost.MessageTypeKeyGen2, From: 3, To: 1},
+		{Type: frost.MessageTypeKeyGen2, From: 3, To: 2},
+	}, p.Missing(frost.MessageTypeKeyGen2))
+	assert.Equal(t, "KeyGen1: complete\nKeyGen2: missing 3->1, 3->2\n", p.Summary())
+
+	dot := p.DOT()
+	assert.Contains(t, dot, "p1 -> p2 [label=\"KeyGen1\"];")
+	assert.Contains(t, dot, "p3 -> p1 [label=\"KeyGen2\", style=dashed, color=red];")
+	assert.Contains(t, dot, "p3 [label=\"party 3\", color=red];")
+
+	mermaid := p.Mermaid()
+	assert.Contains(t, mermaid, "p1 -->|KeyGen2| p3")
+	assert.Contains(t, mermaid, "p3 -.->|KeyGen2| p2")
+	assert.Contains(t, mermaid, "style p3 stroke:red")
+}
+
+func TestProgress_Sign(t *testing.T) {
+	p := New(party.IDSlice{1, 2})
+	for _, id := range []party.ID{1, 2} {
+		p.Add(&frost.Message{Header: frost.Header{Type: frost.MessageTypeSign1, From: id}})
+		p.Add(&frost.Message{Header: frost.Header{Type: frost.MessageTypeSign2, From: id}})
+	}
+	assert.True(t, p.Complete())
+	assert.Empty(t, p.Unexpected())
+
+	p.Add(&frost.Message{Header: frost.Header{Type: frost.MessageTypeSign1, From: 7}})
+	assert.Equal(t, []Edge{{Type: frost.MessageTypeSign1, From: 7}}, p.Unexpected())
+	assert.Contains(t, p.Summary(), "unexpected Sign1 from 7 to 0")
+}
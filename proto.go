@@ -0,0 +1,319 @@
+package frost
+
+import (
+	"fmt"
+
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/polynomial"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Messages can be encoded as the Message of message.proto, for coordinators
+// and peers in other languages that generate their code from it. Points are 32
+// byte ristretto encodings and scalars 32 byte little-endian encodings, as in
+// the binary encoding; polynomials are repeated fields of their coefficients,
+// starting with the constant. The payload is the field of the oneof for the
+// type of the message. Unknown fields are ignored.
+
+// ToProto returns the encoding of m as a Message of message.proto.
+func (m *Message) ToProto() ([]byte, error) {
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	payload := data[headerSize:]
+
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(m.Type))
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(m.From))
+	if m.To != 0 {
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(m.To))
+	}
+
+	var p []byte
+	switch m.Type {
+	case MessageTypeKeyGen1:
+		p = appendProtoBytes(p, 1, payload[:64])
+		p = appendProtoCoefficients(p, 2, payload[64:])
+	case MessageTypeSign1:
+		p = appendProtoBytes(p, 1, payload[:32])
+		p = appendProtoBytes(p, 2, payload[32:])
+	case MessageTypeReshare1:
+		p = appendProtoCoefficients(p, 1, payload)
+	case MessageTypeSignBatch1:
+		for c := payload[party.IDByteSize:]; len(c) >= 64; c = c[64:] {
+			p = appendProtoBytes(p, 1, c[:32])
+			p = appendProtoBytes(p, 2, c[32:64])
+		}
+	case MessageTypeSignBatch2:
+		for c := payload[party.IDByteSize:]; len(c) >= 32; c = c[32:] {
+			p = appendProtoBytes(p, 1, c[:32])
+		}
+	default:
+		p = appendProtoBytes(p, 1, payload)
+	}
+	b = appendProtoBytes(b, protoPayloadField(m.Type), p)
+
+	if m.Round != 0 {
+		b = protowire.AppendTag(b, 10, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(m.Round))
+	}
+	if !m.SessionID.IsZero() {
+		b = appendProtoBytes(b, 11, m.SessionID[:])
+	}
+	return b, nil
+}
+
+// FromProto sets m to the Message of message.proto encoded in data. A message
+// without a round gets the round of its type.
+func (m *Message) FromProto(data []byte) error {
+	var (
+		header  Header
+		payload []byte
+		num     protowire.Number
+	)
+	err := parseProto(data, func(f protoField) error {
+		switch f.num {
+		case 1, 2, 3, 10:
+			if f.typ != protowire.VarintType {
+				return fmt.Errorf("proto: field %d is not a varint: %w", f.num, ErrInvalidMessage)
+			}
+			max := uint64(0xffff)
+			if f.num == 1 || f.num == 10 {
+				max = 0xff
+			}
+			if f.varint > max {
+				return fmt.Errorf("proto: field %d is too large: %w", f.num, ErrInvalidMessage)
+			}
+			switch f.num {
+			case 1:
+				header.Type = MessageType(f.varint)
+			case 2:
+				header.From = party.ID(f.varint)
+			case 3:
+				header.To = party.ID(f.varint)
+			case 10:
+				header.Round = uint8(f.varint)
+			}
+		case 4, 5, 6, 7, 8, 9, 12, 13, 14, 15, 16, 17:
+			if f.typ != protowire.BytesType {
+				return fmt.Errorf("proto: payload is not a message: %w", ErrInvalidMessage)
+			}
+			num, payload = f.num, f.bytes
+		case 11:
+			if f.typ != protowire.BytesType || len(f.bytes) != len(header.SessionID) {
+				return fmt.Errorf("proto: session ID: %w", ErrInvalidMessage)
+			}
+			copy(header.SessionID[:], f.bytes)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if payload == nil || num != protoPayloadField(header.Type) {
+		return fmt.Errorf("proto: no payload for type %s: %w", header.Type, ErrInvalidMessage)
+	}
+	if header.Round == 0 {
+		header.Round = header.Type.Round()
+	}
+
+	// the payload messages have bytes fields 1 and 2, which may be repeated
+	var fields [3][][]byte
+	err = parseProto(payload, func(f protoField) error {
+		if f.num != 1 && f.num != 2 {
+			return nil
+		}
+		if f.typ != protowire.BytesType {
+			return fmt.Errorf("proto: %s field %d is not bytes: %w", header.Type, f.num, ErrInvalidMessage)
+		}
+		fields[f.num] = append(fields[f.num], f.bytes)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	single := func(num, size int) error {
+		if len(fields[num]) != 1 || len(fields[num][0]) != size {
+			return fmt.Errorf("proto: %s field %d is not a single value of %d bytes: %w", header.Type, num, size, ErrInvalidMessage)
+		}
+		return nil
+	}
+	repeated := func(num, max int) error {
+		if len(fields[num]) == 0 || len(fields[num]) > max {
+			return fmt.Errorf("proto: %s field %d has %d values: %w", header.Type, num, len(fields[num]), ErrInvalidMessage)
+		}
+		for _, v := range fields[num] {
+			if len(v) != 32 {
+				return fmt.Errorf("proto: %s field %d has a value of %d bytes: %w", header.Type, num, len(v), ErrInvalidMessage)
+			}
+		}
+		return nil
+	}
+
+	// rebuild the binary encoding of the message
+	b := make([]byte, 0, headerSize+len(payload))
+	b = append(b, MessageFormatVersion, byte(header.Type), header.Round)
+	b = append(b, header.From.Bytes()...)
+	b = append(b, header.To.Bytes()...)
+	b = append(b, header.SessionID[:]...)
+	switch header.Type {
+	case MessageTypeKeyGen1:
+		if err := single(1, 64); err != nil {
+			return err
+		}
+		if err := repeated(2, int(polynomial.MaxDegree)+1); err != nil {
+			return err
+		}
+		b = append(b, fields[1][0]...)
+		b = appendCoefficients(b, fields[2])
+	case MessageTypeReshare1:
+		if err := repeated(1, int(polynomial.MaxDegree)+1); err != nil {
+			return err
+		}
+		b = appendCoefficients(b, fields[1])
+	case MessageTypeSign1:
+		if err := single(1, 32); err != nil {
+			return err
+		}
+		if err := single(2, 32); err != nil {
+			return err
+		}
+		b = append(b, fields[1][0]...)
+		b = append(b, fields[2][0]...)
+	case MessageTypeSignBatch1:
+		if err := repeated(1, MaxBatchSize); err != nil {
+			return err
+		}
+		if len(fields[2]) != len(fields[1]) {
+			return fmt.Errorf("proto: %s with %d Di and %d Ei: %w", header.Type, len(fields[1]), len(fields[2]), ErrInvalidMessage)
+		}
+		if err := repeated(2, MaxBatchSize); err != nil {
+			return err
+		}
+		// the Di and Ei are interleaved
+		b = append(b, party.Size(len(fields[1])).Bytes()...)
+		for i := range fields[1] {
+			b = append(b, fields[1][i]...)
+			b = append(b, fields[2][i]...)
+		}
+	case MessageTypeSignBatch2:
+		if err := repeated(1, MaxBatchSize); err != nil {
+			return err
+		}
+		b = append(b, party.Size(len(fields[1])).Bytes()...)
+		for _, v := range fields[1] {
+			b = append(b, v...)
+		}
+	case MessageTypeSign0, MessageTypeKeyGenEcho:
+		if err := single(1, 64); err != nil {
+			return err
+		}
+		b = append(b, fields[1][0]...)
+	default:
+		if err := single(1, 32); err != nil {
+			return err
+		}
+		b = append(b, fields[1][0]...)
+	}
+	return m.UnmarshalBinary(b)
+}
+
+// protoPayloadField returns the number of the payload field of Message for t.
+func protoPayloadField(t MessageType) protowire.Number {
+	switch t {
+	case MessageTypeKeyGen1:
+		return 4
+	case MessageTypeKeyGen2:
+		return 5
+	case MessageTypeSign1:
+		return 6
+	case MessageTypeSign2:
+		return 7
+	case MessageTypeReshare1:
+		return 8
+	case MessageTypeReshare2:
+		return 9
+	case MessageTypeRepair1:
+		return 12
+	case MessageTypeRepair2:
+		return 13
+	case MessageTypeSign0:
+		return 14
+	case MessageTypeSignBatch1:
+		return 15
+	case MessageTypeSignBatch2:
+		return 16
+	case MessageTypeKeyGenEcho:
+		return 17
+	}
+	return 0
+}
+
+func appendProtoBytes(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+// appendProtoCoefficients appends the coefficients of the binary encoding of a
+// polynomial.Exponent, degree ∥ 32 bytes per coefficient, as a repeated field.
+func appendProtoCoefficients(b []byte, num protowire.Number, exponent []byte) []byte {
+	for c := exponent[party.IDByteSize:]; len(c) >= 32; c = c[32:] {
+		b = appendProtoBytes(b, num, c[:32])
+	}
+	return b
+}
+
+// appendCoefficients appends the binary encoding of the polynomial.Exponent
+// with the given coefficients.
+func appendCoefficients(b []byte, coefficients [][]byte) []byte {
+	b = append(b, party.Size(len(coefficients)-1).Bytes()...)
+	for _, c := range coefficients {
+		b = append(b, c...)
+	}
+	return b
+}
+
+// protoField is a field of a protobuf message, with the value of a varint or
+// length delimited field.
+type protoField struct {
+	num    protowire.Number
+	typ    protowire.Type
+	varint uint64
+	bytes  []byte
+}
+
+// parseProto calls fn for every field of the protobuf message b. Fields other
+// than varints and length delimited ones are skipped.
+func parseProto(b []byte, fn func(f protoField) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("proto: %v: %w", protowire.ParseError(n), ErrInvalidMessage)
+		}
+		b = b[n:]
+		f := protoField{num: num, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.varint, n = protowire.ConsumeVarint(b)
+		case protowire.BytesType:
+			f.bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return fmt.Errorf("proto: %v: %w", protowire.ParseError(n), ErrInvalidMessage)
+		}
+		b = b[n:]
+		if typ != protowire.VarintType && typ != protowire.BytesType {
+			continue
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package frost
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost/ristretto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessage_Proto(t *testing.T) {
+	for _, msg := range testMessages(t) {
+		data, err := msg.ToProto()
+		require.NoError(t, err, msg.Type)
+		var decoded Message
+		require.NoError(t, decoded.FromProto(data), msg.Type)
+		assert.Equal(t, msg.Header, decoded.Header)
+		assert.True(t, equalPayloads(msg, &decoded), msg.Type)
+
+		again, err := decoded.ToProto()
+		require.NoError(t, err)
+		assert.Equal(t, data, again)
+		jsonData, err := msg.MarshalJSON()
+		require.NoError(t, err)
+		assert.Less(t, len(data), len(jsonData))
+
+		err = decoded.FromProto(data[:len(data)-1])
+		assert.True(t, errors.Is(err, ErrInvalidMessage), msg.Type)
+		// unknown fields are skipped
+		require.NoError(t, decoded.FromProto(append(data, 0x98, 0x06, 0x01)), msg.Type)
+		assert.Equal(t, msg.Header, decoded.Header)
+	}
+
+	_, err := (&Message{Header: Header{Type: MessageTypeSign2}}).ToProto()
+	assert.Error(t, err)
+}
+
+func TestMessage_ProtoEncoding(t *testing.T) {
+	var zi ristretto.Scalar
+	_, err := zi.SetCanonicalBytes(append([]byte{7}, make([]byte, 31)...))
+	require.NoError(t, err)
+	msg := NewSign2(2, &zi)
+
+	// type 4, from 2, sign2 {zi}, round 2
+	expected := []byte{0x08, 0x04, 0x10, 0x02, 0x3a, 0x22, 0x0a, 0x20, 0x07}
+	expected = append(expected, make([]byte, 31)...)
+	expected = append(expected, 0x50, 0x02)
+	data, err := msg.ToProto()
+	require.NoError(t, err)
+	assert.Equal(t, expected, data)
+
+	// encoders of other languages may order the fields differently, and omit the round
+	reordered := append([]byte{0x3a, 0x22, 0x0a, 0x20, 0x07}, make([]byte, 31)...)
+	reordered = append(reordered, 0x10, 0x02, 0x08, 0x04)
+	var decoded Message
+	require.NoError(t, decoded.FromProto(reordered))
+	assert.Equal(t, msg.Header, decoded.Header)
+	assert.True(t, equalPayloads(msg, &decoded))
+
+	for name, data := range map[string][]byte{
+		"empty":            nil,
+		"no payload":       {0x08, 0x04, 0x10, 0x02},
+		"payload of sign1": {0x08, 0x04, 0x10, 0x02, 0x32, 0x00},
+		"short zi":         {0x08, 0x04, 0x10, 0x02, 0x3a, 0x03, 0x0a, 0x01, 0x07},
+		"large type":       {0x08, 0x80, 0x02, 0x10, 0x02, 0x3a, 0x00},
+		"short session":    append(bytes.Clone(expected), 0x5a, 0x01, 0x00),
+	} {
+		err := decoded.FromProto(data)
+		assert.True(t, errors.Is(err, ErrInvalidMessage), name)
+	}
+}
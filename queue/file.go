@@ -0,0 +1,109 @@
+package queue
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const fileSuffix = ".json"
+
+// dir is a directory of small files that are replaced atomically.
+type dir string
+
+func openDir(path string) (dir, error) {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return "", fmt.Errorf("queue: %w", err)
+	}
+	return dir(path), nil
+}
+
+// path maps an arbitrary key to a file name inside d.
+func (d dir) path(key string) string {
+	return filepath.Join(string(d), hex.EncodeToString([]byte(key))+fileSuffix)
+}
+
+// write stores data under key. The data is written to a temporary file,
+// synced, and renamed over the destination, so that a crash leaves
+// either the old or the new content but never a partial file.
+func (d dir) write(key string, data []byte) error {
+	tmp, err := os.CreateTemp(string(d), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("queue: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("queue: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), d.path(key)); err != nil {
+		return fmt.Errorf("queue: %w", err)
+	}
+	return d.sync()
+}
+
+// read returns the data stored under key, and false if there is none.
+func (d dir) read(key string) ([]byte, bool, error) {
+	data, err := os.ReadFile(d.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("queue: %w", err)
+	}
+	return data, true, nil
+}
+
+// remove deletes the data stored under key, if any.
+func (d dir) remove(key string) error {
+	err := os.Remove(d.path(key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("queue: %w", err)
+	}
+	return d.sync()
+}
+
+// keys returns all stored keys in sorted order.
+func (d dir) keys() ([]string, error) {
+	entries, err := os.ReadDir(string(d))
+	if err != nil {
+		return nil, fmt.Errorf("queue: %w", err)
+	}
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		key, err := hex.DecodeString(strings.TrimSuffix(name, fileSuffix))
+		if err != nil {
+			continue
+		}
+		keys = append(keys, string(key))
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// sync flushes the directory entry so that renames and removals are durable.
+func (d dir) sync() error {
+	f, err := os.Open(string(d))
+	if err != nil {
+		return fmt.Errorf("queue: %w", err)
+	}
+	defer f.Close()
+	// Some platforms do not support syncing directories, which is not fatal.
+	_ = f.Sync()
+	return nil
+}
@@ -0,0 +1,216 @@
+// Package queue provides crash-safe, file backed queues for protocol messages.
+//
+// A party that crashes after computing the output of a round, but before that
+// output was delivered, must re-send exactly the same messages on restart.
+// Recomputing them would sample fresh nonces, and a peer that already received
+// the first version could then combine two different answers.
+// The Outbox persists messages before they are handed to the transport, and the
+// Inbox persists received messages until the round that consumes them is done.
+package queue
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/party"
+)
+
+// ErrEquivocation is returned when a sender already has a different message
+// recorded under the same key.
+var ErrEquivocation = errors.New("queue: a different message from the same sender was already received")
+
+func encodeMessages(msgs []*frost.Message) ([]byte, error) {
+	return json.Marshal(msgs)
+}
+
+func decodeMessages(data []byte) ([]*frost.Message, error) {
+	var msgs []*frost.Message
+	if err := json.Unmarshal(data, &msgs); err != nil {
+		return nil, fmt.Errorf("queue: %w", err)
+	}
+	return msgs, nil
+}
+
+// Outbox stores the messages produced by a round under a caller chosen key,
+// for example "sign/<session>/round1".
+type Outbox struct {
+	mu  sync.Mutex
+	dir dir
+}
+
+// OpenOutbox opens or creates an Outbox in the directory path.
+func OpenOutbox(path string) (*Outbox, error) {
+	d, err := openDir(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Outbox{dir: d}, nil
+}
+
+// Put durably stores msgs under key, replacing any previous content.
+func (o *Outbox) Put(key string, msgs []*frost.Message) error {
+	data, err := encodeMessages(msgs)
+	if err != nil {
+		return err
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.dir.write(key, data)
+}
+
+// Get returns the messages stored under key, and false if there are none.
+func (o *Outbox) Get(key string) ([]*frost.Message, bool, error) {
+	o.mu.Lock()
+	data, ok, err := o.dir.read(key)
+	o.mu.Unlock()
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	msgs, err := decodeMessages(data)
+	return msgs, err == nil, err
+}
+
+// GetOrCompute returns the messages stored under key if they exist.
+// Otherwise, it calls compute and persists its result before returning it,
+// so that compute is never run twice for the same key, even across restarts.
+func (o *Outbox) GetOrCompute(key string, compute func() ([]*frost.Message, error)) ([]*frost.Message, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	data, ok, err := o.dir.read(key)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return decodeMessages(data)
+	}
+
+	msgs, err := compute()
+	if err != nil {
+		return nil, err
+	}
+	if data, err = encodeMessages(msgs); err != nil {
+		return nil, err
+	}
+	if err = o.dir.write(key, data); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// Ack removes the messages stored under key once they have been delivered.
+func (o *Outbox) Ack(key string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.dir.remove(key)
+}
+
+// Pending returns the keys of all messages that have not been acknowledged yet.
+func (o *Outbox) Pending() ([]string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.dir.keys()
+}
+
+// Inbox stores received messages under a round key until the round is processed.
+// Messages are indexed by sender, so receiving the same message twice is
+// idempotent, and a sender cannot replace its message with another one.
+type Inbox struct {
+	mu  sync.Mutex
+	dir dir
+}
+
+// OpenInbox opens or creates an Inbox in the directory path.
+func OpenInbox(path string) (*Inbox, error) {
+	d, err := openDir(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Inbox{dir: d}, nil
+}
+
+// Put durably records msg under key. A second copy of the message recorded
+// for the same sender and key is ignored, and a different message returns an
+// error wrapping ErrEquivocation, naming the sender.
+func (in *Inbox) Put(key string, msg *frost.Message) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	msgs, err := in.load(key)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range msgs {
+		if m.From != msg.From {
+			continue
+		}
+		same, err := sameMessage(m, msg)
+		if err != nil {
+			return err
+		}
+		if !same {
+			return fmt.Errorf("%w: party %d, %s", ErrEquivocation, msg.From, key)
+		}
+		return nil
+	}
+	msgs = append(msgs, msg)
+
+	data, err := encodeMessages(msgs)
+	if err != nil {
+		return err
+	}
+	return in.dir.write(key, data)
+}
+
+// Messages returns all messages recorded under key.
+func (in *Inbox) Messages(key string) ([]*frost.Message, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.load(key)
+}
+
+// Senders returns the parties from which a message was recorded under key.
+func (in *Inbox) Senders(key string) (party.IDSlice, error) {
+	msgs, err := in.Messages(key)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]party.ID, 0, len(msgs))
+	for _, m := range msgs {
+		ids = append(ids, m.From)
+	}
+	return party.NewIDSlice(ids), nil
+}
+
+// Clear removes all messages recorded under key, once the round has been processed.
+func (in *Inbox) Clear(key string) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.dir.remove(key)
+}
+
+// sameMessage returns whether a and b have the same encoding.
+func sameMessage(a, b *frost.Message) (bool, error) {
+	encodedA, err := a.MarshalBinary()
+	if err != nil {
+		return false, fmt.Errorf("queue: %w", err)
+	}
+	encodedB, err := b.MarshalBinary()
+	if err != nil {
+		return false, fmt.Errorf("queue: %w", err)
+	}
+	return bytes.Equal(encodedA, encodedB), nil
+}
+
+func (in *Inbox) load(key string) ([]*frost.Message, error) {
+	data, ok, err := in.dir.read(key)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return decodeMessages(data)
+}
@@ -0,0 +1,96 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sign2(from party.ID) *frost.Message {
+	return frost.NewSign2(from, scalar.NewScalarRandom())
+}
+
+func TestOutbox_GetOrCompute(t *testing.T) {
+	path := t.TempDir()
+	out, err := OpenOutbox(path)
+	require.NoError(t, err)
+
+	calls := 0
+	compute := func() ([]*frost.Message, error) {
+		calls++
+		return []*frost.Message{sign2(1)}, nil
+	}
+
+	first, err := out.GetOrCompute("sign/round1", compute)
+	require.NoError(t, err)
+
+	// simulate a restart
+	out, err = OpenOutbox(path)
+	require.NoError(t, err)
+	second, err := out.GetOrCompute("sign/round1", compute)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, calls)
+	require.Len(t, second, 1)
+	assert.Equal(t, 1, first[0].Sign2.Zi.Equal(&second[0].Sign2.Zi))
+
+	pending, err := out.Pending()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sign/round1"}, pending)
+
+	require.NoError(t, out.Ack("sign/round1"))
+	pending, err = out.Pending()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestOutbox_ComputeError(t *testing.T) {
+	out, err := OpenOutbox(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = out.GetOrCompute("k", func() ([]*frost.Message, error) {
+		return nil, errors.New("boom")
+	})
+	assert.Error(t, err)
+
+	_, ok, err := out.Get("k")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestInbox(t *testing.T) {
+	path := t.TempDir()
+	in, err := OpenInbox(path)
+	require.NoError(t, err)
+
+	from3 := sign2(3)
+	require.NoError(t, in.Put("r1", from3))
+	require.NoError(t, in.Put("r1", sign2(2)))
+	require.NoError(t, in.Put("r1", from3))
+
+	in, err = OpenInbox(path)
+	require.NoError(t, err)
+	senders, err := in.Senders("r1")
+	require.NoError(t, err)
+	assert.Equal(t, party.IDSlice{2, 3}, senders)
+
+	// a different message from the same sender is an equivocation, even
+	// after a restart, and does not replace the first one
+	err = in.Put("r1", sign2(3))
+	assert.True(t, errors.Is(err, ErrEquivocation))
+	msgs, err := in.Messages("r1")
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, 1, msgs[0].Sign2.Zi.Equal(&from3.Sign2.Zi))
+	require.NoError(t, in.Put("r2", sign2(3)), "under another key")
+
+	require.NoError(t, in.Clear("r1"))
+	msgs, err = in.Messages("r1")
+	require.NoError(t, err)
+	assert.Empty(t, msgs)
+}
@@ -0,0 +1,99 @@
+package frost
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/bartke/frost/party"
+)
+
+// ErrNeedMoreMessages is wrapped by the *ErrMissingParties of a round that
+// was called with only some of its messages.
+var ErrNeedMoreMessages = errors.New("need more messages")
+
+// ErrMissingParties is returned by a round that did not get the messages of
+// all parties expected in it, instead of computing a result that would not
+// match that of the other parties. The state keeps the messages the round
+// processed, so it can be called again with the messages of IDs alone, e.g.
+// once they arrive late. A network service can thus call a round with every
+// message as it arrives, until the round returns something other than
+// ErrNeedMoreMessages.
+type ErrMissingParties struct {
+	// Round is the type of the missing messages.
+	Round MessageType
+	// IDs are the parties whose messages are missing.
+	IDs party.IDSlice
+}
+
+func (e *ErrMissingParties) Error() string {
+	return fmt.Sprintf("no %s message from parties %v", e.Round, e.IDs)
+}
+
+// Unwrap returns ErrNeedMoreMessages.
+func (e *ErrMissingParties) Unwrap() error {
+	return ErrNeedMoreMessages
+}
+
+// Received records the parties whose messages of each round a state has
+// processed.
+type Received map[MessageType]party.IDSlice
+
+// add records the message of type t from id, and returns an error if a
+// message of id was recorded already.
+func (r *Received) add(t MessageType, id party.ID) error {
+	if r.Contains(t, id) {
+		return fmt.Errorf("duplicate %s message from party %d", t, id)
+	}
+	if *r == nil {
+		*r = make(Received)
+	}
+	// insert id in place, the IDs stay sorted
+	ids := (*r)[t]
+	i := sort.Search(len(ids), func(i int) bool { return ids[i] >= id })
+	ids = append(ids, 0)
+	copy(ids[i+1:], ids[i:])
+	ids[i] = id
+	(*r)[t] = ids
+	return nil
+}
+
+// reserve makes room for the messages of type t of n parties, so that add
+// does not grow the IDs as they arrive.
+func (r *Received) reserve(t MessageType, n int) {
+	if cap((*r)[t]) >= n {
+		return
+	}
+	if *r == nil {
+		*r = make(Received)
+	}
+	ids := make(party.IDSlice, len((*r)[t]), n)
+	copy(ids, (*r)[t])
+	(*r)[t] = ids
+}
+
+// Contains returns true if the message of type t from id was processed.
+func (r Received) Contains(t MessageType, id party.ID) bool {
+	return r[t].Contains(id)
+}
+
+// Missing returns the parties of expected, but selfID, whose messages of type
+// t were not processed.
+func (r Received) Missing(t MessageType, expected party.IDSlice, selfID party.ID) party.IDSlice {
+	var missing party.IDSlice
+	for _, id := range expected {
+		if id != selfID && !r.Contains(t, id) {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// complete returns an *ErrMissingParties if a message of type t of any party
+// of expected but selfID was not processed.
+func (r Received) complete(t MessageType, expected party.IDSlice, selfID party.ID) error {
+	if missing := r.Missing(t, expected, selfID); len(missing) > 0 {
+		return &ErrMissingParties{Round: t, IDs: missing}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package frost
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeygen_MissingParties(t *testing.T) {
+	const n = 3
+	states := make(map[party.ID]*KeygenState, n)
+	var round1 []*Message
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, err := KeygenInit(id, n, 1)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+
+	// the message of party 3 arrives late
+	_, _, err := KeygenRound1(states[1], round1[:2])
+	var missing *ErrMissingParties
+	require.True(t, errors.As(err, &missing))
+	assert.Equal(t, MessageTypeKeyGen1, missing.Round)
+	assert.Equal(t, party.IDSlice{3}, missing.IDs)
+	assert.True(t, errors.Is(err, ErrNeedMoreMessages))
+	assert.Equal(t, party.IDSlice{3}, states[1].Received.Missing(MessageTypeKeyGen1, states[1].PartyIDs, 1))
+
+	// the message of party 2 was processed already
+	_, _, err = KeygenRound1(states[1], round1[1:2])
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &missing))
+
+	round2 := make(map[party.ID][]*Message, n)
+	for id, state := range states {
+		msgs := round1
+		if id == 1 {
+			msgs = round1[2:]
+		}
+		out, _, err := KeygenRound1(state, msgs)
+		require.NoError(t, err)
+		for _, msg := range out {
+			round2[msg.To] = append(round2[msg.To], msg)
+		}
+	}
+
+	_, _, err = KeygenRound2(states[1], round2[1][:1])
+	require.True(t, errors.As(err, &missing))
+	assert.Equal(t, MessageTypeKeyGen2, missing.Round)
+	assert.Len(t, missing.IDs, 1)
+	_, _, err = KeygenRound2(states[1], round2[1][:1])
+	assert.Error(t, err)
+
+	public, _, err := KeygenRound2(states[1], round2[1][1:])
+	require.NoError(t, err)
+	other, _, err := KeygenRound2(states[2], round2[2])
+	require.NoError(t, err)
+	assert.True(t, public.Equal(other))
+}
+
+func TestSign_MissingParties(t *testing.T) {
+	public, secrets := generateKeys(t, 4, 2)
+	signers := party.IDSlice{1, 2, 4}
+	message := []byte("late messages")
+
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInit(signers, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+
+	_, _, err := SignRound1(states[1], round1[:2])
+	var missing *ErrMissingParties
+	require.True(t, errors.As(err, &missing))
+	assert.Equal(t, &ErrMissingParties{Round: MessageTypeSign1, IDs: party.IDSlice{4}}, missing)
+	_, _, err = SignRound1(states[1], round1[1:2])
+	assert.Error(t, err)
+
+	var round2 []*Message
+	for _, id := range signers {
+		msgs := round1
+		if id == 1 {
+			msgs = round1[2:]
+		}
+		msg, _, err := SignRound1(states[id], msgs)
+		require.NoError(t, err)
+		round2 = append(round2, msg)
+	}
+
+	_, _, err = SignRound2(states[1], round2[1:2])
+	require.True(t, errors.As(err, &missing))
+	assert.Equal(t, &ErrMissingParties{Round: MessageTypeSign2, IDs: party.IDSlice{4}}, missing)
+
+	// the state is saved and restored before the last share arrives
+	for _, encoding := range []struct {
+		marshal   func() ([]byte, error)
+		unmarshal func(*SignerState, []byte) error
+	}{
+		{states[1].MarshalJSON, (*SignerState).UnmarshalJSON},
+		{states[1].MarshalCBOR, (*SignerState).UnmarshalCBOR},
+	} {
+		data, err := encoding.marshal()
+		require.NoError(t, err)
+		var restored SignerState
+		require.NoError(t, encoding.unmarshal(&restored, data))
+		assert.Equal(t, states[1].Received, restored.Received)
+
+		sig, _, err := SignRound2(&restored, round2[2:])
+		require.NoError(t, err)
+		assert.True(t, public.GroupKey.Verify(message, sig))
+	}
+
+	_, _, err = SignRound2(states[2], append(round2, round2[0]))
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &missing))
+}
+
+func TestAggregator_MissingParties(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	signers := party.IDSlice{1, 3}
+	message := []byte("aggregated")
+
+	var round1 []*Message
+	for _, id := range signers {
+		msg, _, err := SignInit(signers, secrets[id], public, message)
+		require.NoError(t, err)
+		round1 = append(round1, msg)
+	}
+	a, err := NewAggregator(signers, public, message)
+	require.NoError(t, err)
+	_, err = a.AddCommitments(round1[1:])
+	var missing *ErrMissingParties
+	require.True(t, errors.As(err, &missing))
+	assert.Equal(t, party.IDSlice{1}, missing.IDs)
+	_, err = a.AddCommitments(append(round1, round1[0]))
+	assert.Error(t, err)
+}
+
+// TestRounds_Incremental processes every message as it arrives, as a network
+// service would.
+func TestRounds_Incremental(t *testing.T) {
+	const n = 4
+	keygens := make(map[party.ID]*KeygenState, n)
+	var round1 []*Message
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, err := KeygenInit(id, n, 2)
+		require.NoError(t, err)
+		keygens[id] = state
+		round1 = append(round1, msg)
+	}
+	round2 := make(map[party.ID][]*Message, n)
+	for id, state := range keygens {
+		var out []*Message
+		var err error
+		for _, msg := range round1 {
+			if msg.From == id {
+				continue
+			}
+			require.Nil(t, out)
+			out, _, err = KeygenRound1(state, []*Message{msg})
+			if !errors.Is(err, ErrNeedMoreMessages) {
+				require.NoError(t, err)
+			}
+		}
+		require.Len(t, out, n-1)
+		for _, msg := range out {
+			round2[msg.To] = append(round2[msg.To], msg)
+		}
+	}
+	secrets := make(map[party.ID]*eddsa.SecretShare, n)
+	var public *eddsa.Public
+	for id, state := range keygens {
+		for i, msg := range round2[id] {
+			pub, secret, err := KeygenRound2(state, []*Message{msg})
+			if i < len(round2[id])-1 {
+				require.True(t, errors.Is(err, ErrNeedMoreMessages))
+				continue
+			}
+			require.NoError(t, err)
+			public, secrets[id] = pub, secret
+		}
+	}
+
+	signers := party.IDSlice{1, 2, 4}
+	message := []byte("one message at a time")
+	states := make(map[party.ID]*SignerState)
+	var sign1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInit(signers, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		sign1 = append(sign1, msg)
+	}
+	var sign2 []*Message
+	for _, id := range signers {
+		var out *Message
+		var err error
+		for _, msg := range sign1 {
+			if msg.From == id {
+				continue
+			}
+			require.Nil(t, out)
+			out, _, err = SignRound1(states[id], []*Message{msg})
+			if !errors.Is(err, ErrNeedMoreMessages) {
+				require.NoError(t, err)
+			}
+		}
+		require.NotNil(t, out)
+		sign2 = append(sign2, out)
+	}
+	for _, id := range signers {
+		var sig *eddsa.Signature
+		var err error
+		for _, msg := range sign2 {
+			if msg.From == id {
+				continue
+			}
+			require.Nil(t, sig)
+			sig, _, err = SignRound2(states[id], []*Message{msg})
+			if !errors.Is(err, ErrNeedMoreMessages) {
+				require.NoError(t, err)
+			}
+		}
+		require.NotNil(t, sig)
+		assert.True(t, public.GroupKey.Verify(message, sig))
+	}
+}
@@ -0,0 +1,173 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/router"
+)
+
+// StatusError is returned for a request that the relay refused.
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("relay: %d %s: %s", e.Code, http.StatusText(e.Code), e.Message)
+}
+
+// temporary reports whether the request may succeed if it is retried.
+func (e *StatusError) temporary() bool {
+	return e.Code == http.StatusTooManyRequests || e.Code >= 500 && e.Code != http.StatusInsufficientStorage
+}
+
+// Client posts and receives the messages of one party in one session.
+type Client struct {
+	URL     string
+	Session string
+	Token   string
+
+	// HTTPClient is used for the requests, http.DefaultClient if it is nil.
+	HTTPClient *http.Client
+	// Backoff is the retry policy of failed requests, router.DefaultBackoff is
+	// used if MaxAttempts is 0. Requests the relay refused are not retried.
+	Backoff router.Backoff
+	// PollInterval is the wait between polls for messages, one second by default.
+	PollInterval time.Duration
+	// Clock times the delays, clock.Real is used if it is nil.
+	Clock clock.Clock
+}
+
+// NewClient returns a Client for session on the relay at baseURL.
+func NewClient(baseURL, session, token string) *Client {
+	return &Client{URL: strings.TrimRight(baseURL, "/"), Session: session, Token: token}
+}
+
+// Post posts msg.
+func (c *Client) Post(ctx context.Context, msg *frost.Message) error {
+	data, err := msg.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return c.retry(ctx, func() error {
+		_, err := c.do(ctx, http.MethodPost, c.messagesURL(), data)
+		return err
+	})
+}
+
+// Receive polls the relay until count messages of type typ from distinct
+// parties have arrived, or ctx is done.
+func (c *Client) Receive(ctx context.Context, typ frost.MessageType, count int) ([]*frost.Message, error) {
+	msgs := make([]*frost.Message, 0, count)
+	received := make(map[party.ID]bool, count)
+	next := 0
+	for {
+		var poll Poll
+		err := c.retry(ctx, func() error {
+			body, err := c.do(ctx, http.MethodGet, c.messagesURL()+"?after="+strconv.Itoa(next), nil)
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(body, &poll)
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, data := range poll.Messages {
+			var msg frost.Message
+			if err := msg.UnmarshalJSON(data); err != nil {
+				return nil, fmt.Errorf("relay: %w", err)
+			}
+			if msg.Type != typ || received[msg.From] {
+				continue
+			}
+			received[msg.From] = true
+			msgs = append(msgs, &msg)
+		}
+		next = poll.Next
+		if len(msgs) >= count {
+			return msgs, nil
+		}
+
+		interval := c.PollInterval
+		if interval <= 0 {
+			interval = time.Second
+		}
+		if err := clock.Sleep(ctx, clock.OrReal(c.Clock), interval); err != nil {
+			return nil, fmt.Errorf("relay: received %d of %d %s messages: %w", len(msgs), count, typ, err)
+		}
+	}
+}
+
+func (c *Client) messagesURL() string {
+	return c.URL + "/v1/sessions/" + url.PathEscape(c.Session) + "/messages"
+}
+
+// retry calls f until it succeeds, fails permanently, or the attempts are exhausted.
+func (c *Client) retry(ctx context.Context, f func() error) error {
+	backoff := c.Backoff
+	if backoff.MaxAttempts == 0 {
+		backoff = router.DefaultBackoff
+	}
+	clk := clock.OrReal(c.Clock)
+	rng := rand.New(rand.NewSource(clk.Now().UnixNano()))
+
+	var err error
+	for attempt := 1; attempt <= backoff.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			if err := clock.Sleep(ctx, clk, backoff.Delay(attempt-1, rng)); err != nil {
+				return err
+			}
+		}
+		if err = f(); err == nil {
+			return nil
+		}
+		var status *StatusError
+		if errors.As(err, &status) && !status.temporary() || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (c *Client) do(ctx context.Context, method, u string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, &StatusError{Code: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	}
+	return data, nil
+}
@@ -0,0 +1,252 @@
+// Package relay implements a mailbox for the messages of protocol sessions,
+// so that parties which cannot reach each other directly, such as the file
+// based cmd/keygen and cmd/sign, can run a session across machines.
+//
+// Every party posts its messages to the Server, keyed by session ID, and polls
+// the messages addressed to it. Parties authenticate with a bearer token of
+// their own, and can only post messages from themselves and read messages
+// addressed to them. The relay sees all messages, including the point to
+// point keygen shares, so it must be run by a party that is trusted with them,
+// or the shares must be encrypted end to end.
+package relay
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/party"
+)
+
+// maxMessageSize bounds the size of a posted message.
+const maxMessageSize = 1 << 20
+
+var sessionID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// Server stores the messages of sessions. It implements http.Handler:
+//
+//	POST /v1/sessions/{session}/messages         posts a message in JSON
+//	GET  /v1/sessions/{session}/messages?after=n returns the messages for the party
+//
+// A GET returns the messages with an index of at least n as a Poll, whose Next
+// is the index to pass on the next poll.
+type Server struct {
+	// TTL is how long a session is kept after its last message, one hour by default.
+	TTL time.Duration
+	// MaxMessages is the number of messages a session may hold, 1024 by default.
+	MaxMessages int
+	// Clock expires the sessions, clock.Real is used if it is nil.
+	Clock clock.Clock
+
+	tokens map[party.ID][sha256.Size]byte
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+type session struct {
+	msgs    []stored
+	updated time.Time
+}
+
+type stored struct {
+	header frost.Header
+	data   json.RawMessage
+}
+
+// Poll is the response to a GET.
+type Poll struct {
+	Messages []json.RawMessage `json:"messages"`
+	Next     int               `json:"next"`
+}
+
+// NewServer returns a Server for the parties with the given tokens.
+func NewServer(tokens map[party.ID]string) *Server {
+	s := &Server{
+		tokens:   make(map[party.ID][sha256.Size]byte, len(tokens)),
+		sessions: make(map[string]*session),
+	}
+	for id, token := range tokens {
+		s.tokens[id] = sha256.Sum256([]byte(token))
+	}
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rest, ok := strings.CutPrefix(r.URL.Path, "/v1/sessions/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	id, ok := strings.CutSuffix(rest, "/messages")
+	if !ok || !sessionID.MatchString(id) {
+		http.NotFound(w, r)
+		return
+	}
+	self, ok := s.authenticate(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s.handlePost(w, r, id, self)
+	case http.MethodGet:
+		s.handleGet(w, r, id, self)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// authenticate returns the party whose token is in the Authorization header.
+func (s *Server) authenticate(r *http.Request) (party.ID, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return 0, false
+	}
+	hash := sha256.Sum256([]byte(token))
+	var found party.ID
+	for id, h := range s.tokens {
+		// compare with every token, so that the timing does not tell which one matched
+		if subtle.ConstantTimeCompare(hash[:], h[:]) == 1 {
+			found = id
+		}
+	}
+	return found, found != 0
+}
+
+func (s *Server) handlePost(w http.ResponseWriter, r *http.Request, id string, self party.ID) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	var msg frost.Message
+	if err := msg.UnmarshalJSON(data); err != nil {
+		http.Error(w, "invalid message: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch {
+	case msg.From != self:
+		http.Error(w, fmt.Sprintf("message from party %d posted by party %d", msg.From, self), http.StatusForbidden)
+		return
+	case msg.Type == frost.MessageTypeNone || msg.To == self:
+		http.Error(w, "invalid message header", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := clock.OrReal(s.Clock).Now()
+	s.expire(now)
+	sess, ok := s.sessions[id]
+	if !ok {
+		sess = &session{}
+		s.sessions[id] = sess
+	}
+	for _, m := range sess.msgs {
+		if m.header == msg.Header {
+			// retries of a post are accepted, other messages with the same header are not
+			if string(m.data) == string(data) {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			http.Error(w, "a different message with the same header was posted", http.StatusConflict)
+			return
+		}
+	}
+	if len(sess.msgs) >= s.maxMessages() {
+		http.Error(w, "too many messages in the session", http.StatusInsufficientStorage)
+		return
+	}
+	sess.msgs = append(sess.msgs, stored{header: msg.Header, data: data})
+	sess.updated = now
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request, id string, self party.ID) {
+	after := 0
+	if v := r.URL.Query().Get("after"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid after", http.StatusBadRequest)
+			return
+		}
+		after = n
+	}
+
+	s.mu.Lock()
+	s.expire(clock.OrReal(s.Clock).Now())
+	poll := Poll{Messages: []json.RawMessage{}, Next: after}
+	if sess, ok := s.sessions[id]; ok {
+		for i := after; i < len(sess.msgs); i++ {
+			m := sess.msgs[i]
+			if m.header.From != self && (m.header.IsBroadcast() || m.header.To == self) {
+				poll.Messages = append(poll.Messages, m.data)
+			}
+		}
+		if len(sess.msgs) > after {
+			poll.Next = len(sess.msgs)
+		}
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(&poll)
+}
+
+// expire removes the sessions without messages since the TTL.
+func (s *Server) expire(now time.Time) {
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	for id, sess := range s.sessions {
+		if now.Sub(sess.updated) > ttl {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+func (s *Server) maxMessages() int {
+	if s.MaxMessages > 0 {
+		return s.MaxMessages
+	}
+	return 1024
+}
+
+// ParseTokens parses the JSON object mapping party IDs to tokens that the relay is configured with.
+func ParseTokens(data []byte) (map[party.ID]string, error) {
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("relay: tokens: %w", err)
+	}
+	tokens := make(map[party.ID]string, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for k, token := range raw {
+		id, err := party.FromString(k)
+		if err != nil || id == 0 {
+			return nil, fmt.Errorf("relay: tokens: invalid party ID %q", k)
+		}
+		if token == "" || seen[token] {
+			return nil, errors.New("relay: tokens: tokens must be distinct and not empty")
+		}
+		seen[token] = true
+		tokens[id] = token
+	}
+	return tokens, nil
+}
@@ -0,0 +1,214 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/router"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTokens = map[party.ID]string{1: "token-1", 2: "token-2", 3: "token-3"}
+
+func newTestClient(url string, id party.ID) *Client {
+	c := NewClient(url, "session-1", testTokens[id])
+	c.PollInterval = 5 * time.Millisecond
+	c.Backoff = router.Backoff{MaxAttempts: 3, Initial: time.Millisecond, Multiplier: 2}
+	return c
+}
+
+func TestRelay_KeygenAndSign(t *testing.T) {
+	srv := httptest.NewServer(NewServer(testTokens))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const n, threshold = 3, 1
+	publics := make([]*eddsa.Public, n+1)
+	secrets := make([]*eddsa.SecretShare, n+1)
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for id := party.ID(1); id <= n; id++ {
+		wg.Add(1)
+		go func(id party.ID) {
+			defer wg.Done()
+			c := newTestClient(srv.URL, id)
+			msg, state, err := frost.KeygenInit(id, n, threshold)
+			if err == nil {
+				err = c.Post(ctx, msg)
+			}
+			var msgs []*frost.Message
+			if err == nil {
+				msgs, err = c.Receive(ctx, frost.MessageTypeKeyGen1, n-1)
+			}
+			var out []*frost.Message
+			if err == nil {
+				out, state, err = frost.KeygenRound1(state, msgs)
+			}
+			for i := 0; err == nil && i < len(out); i++ {
+				err = c.Post(ctx, out[i])
+			}
+			if err == nil {
+				msgs, err = c.Receive(ctx, frost.MessageTypeKeyGen2, n-1)
+			}
+			if err == nil {
+				publics[id], secrets[id], err = frost.KeygenRound2(state, msgs)
+			}
+			errs <- err
+		}(id)
+	}
+	wg.Wait()
+	for id := 1; id <= n; id++ {
+		require.NoError(t, <-errs)
+	}
+	assert.True(t, publics[1].Equal(publics[3]))
+
+	// sign in another session
+	signers := party.IDSlice{1, 3}
+	message := []byte("relayed")
+	sigs := make(chan *eddsa.Signature, 2)
+	for _, id := range signers {
+		wg.Add(1)
+		go func(id party.ID) {
+			defer wg.Done()
+			c := newTestClient(srv.URL, id)
+			c.Session = "session-2"
+			sig, err := sign(ctx, c, signers, secrets[id], publics[id], message)
+			errs <- err
+			sigs <- sig
+		}(id)
+	}
+	wg.Wait()
+	require.NoError(t, <-errs)
+	require.NoError(t, <-errs)
+	assert.True(t, publics[1].GroupKey.Verify(message, <-sigs))
+}
+
+func sign(ctx context.Context, c *Client, signers party.IDSlice, secret *eddsa.SecretShare, public *eddsa.Public, message []byte) (*eddsa.Signature, error) {
+	msg, state, err := frost.SignInit(signers, secret, public, message)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Post(ctx, msg); err != nil {
+		return nil, err
+	}
+	msgs, err := c.Receive(ctx, frost.MessageTypeSign1, len(signers)-1)
+	if err != nil {
+		return nil, err
+	}
+	if msg, state, err = frost.SignRound1(state, msgs); err != nil {
+		return nil, err
+	}
+	if err := c.Post(ctx, msg); err != nil {
+		return nil, err
+	}
+	if msgs, err = c.Receive(ctx, frost.MessageTypeSign2, len(signers)-1); err != nil {
+		return nil, err
+	}
+	sig, _, err := frost.SignRound2(state, msgs)
+	return sig, err
+}
+
+func TestRelay_Authorization(t *testing.T) {
+	srv := httptest.NewServer(NewServer(testTokens))
+	defer srv.Close()
+	ctx := context.Background()
+
+	c := newTestClient(srv.URL, 1)
+	msg := frost.NewSign2(1, scalar.NewScalarRandom())
+	require.NoError(t, c.Post(ctx, msg))
+	require.NoError(t, c.Post(ctx, msg), "retries are accepted")
+
+	var status *StatusError
+	err := c.Post(ctx, frost.NewSign2(1, scalar.NewScalarRandom()))
+	require.True(t, errors.As(err, &status))
+	assert.Equal(t, http.StatusConflict, status.Code)
+
+	err = c.Post(ctx, frost.NewSign2(2, scalar.NewScalarRandom()))
+	require.True(t, errors.As(err, &status))
+	assert.Equal(t, http.StatusForbidden, status.Code, "posting for another party")
+
+	c.Token = "wrong"
+	err = c.Post(ctx, frost.NewSign2(1, scalar.NewScalarRandom()))
+	require.True(t, errors.As(err, &status))
+	assert.Equal(t, http.StatusUnauthorized, status.Code)
+
+	// a point to point message is only returned to its recipient
+	require.NoError(t, newTestClient(srv.URL, 1).Post(ctx, frost.NewKeyGen2(1, 2, scalar.NewScalarRandom())))
+	msgs, err := newTestClient(srv.URL, 2).Receive(ctx, frost.MessageTypeKeyGen2, 1)
+	require.NoError(t, err)
+	assert.Len(t, msgs, 1)
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = newTestClient(srv.URL, 3).Receive(short, frost.MessageTypeKeyGen2, 1)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	resp, err := http.Get(srv.URL + "/v1/sessions/a%2Fb/messages")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestRelay_Retry(t *testing.T) {
+	var failures atomic.Int32
+	failures.Store(2)
+	relay := NewServer(testTokens)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures.Add(-1) >= 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		relay.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL, 1)
+	require.NoError(t, c.Post(context.Background(), frost.NewSign2(1, scalar.NewScalarRandom())))
+
+	failures.Store(3)
+	err := c.Post(context.Background(), frost.NewSign2(1, scalar.NewScalarRandom()))
+	var status *StatusError
+	require.True(t, errors.As(err, &status))
+	assert.Equal(t, http.StatusServiceUnavailable, status.Code, "attempts exhausted")
+}
+
+func TestRelay_Expiry(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	relay := NewServer(testTokens)
+	relay.Clock = clk
+	relay.MaxMessages = 1
+	srv := httptest.NewServer(relay)
+	defer srv.Close()
+	ctx := context.Background()
+
+	c := newTestClient(srv.URL, 1)
+	require.NoError(t, c.Post(ctx, frost.NewSign2(1, scalar.NewScalarRandom())))
+	err := c.Post(ctx, frost.NewKeyGen2(1, 2, scalar.NewScalarRandom()))
+	assert.Error(t, err, "session is full")
+
+	clk.Advance(2 * time.Hour)
+	require.NoError(t, c.Post(ctx, frost.NewSign2(1, scalar.NewScalarRandom())), "the session expired")
+}
+
+func TestParseTokens(t *testing.T) {
+	tokens, err := ParseTokens([]byte(`{"1": "a", "2": "b"}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[party.ID]string{1: "a", 2: "b"}, tokens)
+
+	for _, invalid := range []string{`{"1": "a", "2": "a"}`, `{"0": "a"}`, `{"x": "a"}`, `{"1": ""}`, `[]`} {
+		_, err := ParseTokens([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
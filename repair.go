@@ -0,0 +1,244 @@
+package frost
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/scalar"
+)
+
+// Repairing re-provisions the secret share of a party that lost it, with the
+// help of at least Threshold+1 other parties, none of which learns the share.
+// The group key and the other shares stay the same.
+//
+// Since the shares lie on a polynomial f of degree Threshold, the lost share
+// f(ℓ) is the sum of the contributions lᵢ(ℓ) • sᵢ of the helpers, where lᵢ is
+// the Lagrange coefficient of helper i over the helpers at ℓ. A contribution
+// would reveal sᵢ to the lost party, so the helpers mask them first:
+//
+//  1. RepairInit splits the contribution of a helper into random additive
+//     shares, one Repair1 message for every other helper, keeping its own.
+//  2. RepairRound1 sums the shares a helper received into a Repair2 message
+//     for the lost party. The sum reveals none of the contributions.
+//  3. RepairRound2 sums the Repair2 messages into the share of the lost party,
+//     and checks it against its public share.
+//
+// The lost party must be authenticated before its share is repaired, since
+// whoever receives the Repair2 messages obtains the share.
+type RepairState struct {
+	SelfID party.ID
+	// Lost is the party whose share is repaired.
+	Lost party.ID
+	// Helpers are the parties that help with the repair.
+	Helpers party.IDSlice
+	Public  *eddsa.Public
+	// Sigma is the sum of the Repair1 shares received so far, if we are a
+	// helper, and of the Repair2 messages if we are the lost party.
+	Sigma ristretto.Scalar
+	// received are the parties whose messages were processed.
+	received map[party.ID]bool
+}
+
+// RepairInit initializes the repair of the share of party lost of the group
+// described by public, by helpers. Helpers pass their secret share and get a
+// Repair1 message for every other helper; the lost party passes nil, and gets
+// no messages.
+func RepairInit(selfID party.ID, secret *eddsa.SecretShare, public *eddsa.Public, helpers party.IDSlice, lost party.ID) ([]*Message, *RepairState, error) {
+	return repairInit(selfID, secret, public, helpers, lost, rand.Reader)
+}
+
+// repairInit is RepairInit with the masks sampled from rng.
+func repairInit(selfID party.ID, secret *eddsa.SecretShare, public *eddsa.Public, helpers party.IDSlice, lost party.ID, rng io.Reader) ([]*Message, *RepairState, error) {
+	helpers = party.NewIDSlice(helpers)
+	if !public.PartyIDs.Contains(lost) {
+		return nil, nil, fmt.Errorf("RepairInit: party %d is not in the group", lost)
+	}
+	if helpers.Contains(lost) {
+		return nil, nil, fmt.Errorf("RepairInit: party %d cannot help to repair its own share", lost)
+	}
+	if !helpers.IsSubsetOf(public.PartyIDs) {
+		return nil, nil, fmt.Errorf("RepairInit: helpers %v are not a subset of the parties %v", helpers, public.PartyIDs)
+	}
+	if helpers.N() <= public.Threshold {
+		return nil, nil, fmt.Errorf("RepairInit: %d helpers, at least %d are needed", helpers.N(), public.Threshold+1)
+	}
+	isHelper := helpers.Contains(selfID)
+	if !isHelper && selfID != lost {
+		return nil, nil, fmt.Errorf("RepairInit: party %d is neither a helper nor the lost party", selfID)
+	}
+	if isHelper != (secret != nil) {
+		return nil, nil, errors.New("RepairInit: helpers, and only helpers, must pass their secret share")
+	}
+
+	state := &RepairState{
+		SelfID:   selfID,
+		Lost:     lost,
+		Helpers:  helpers,
+		Public:   public,
+		Sigma:    *ristretto.NewScalar(),
+		received: make(map[party.ID]bool, helpers.N()),
+	}
+	if !isHelper {
+		return nil, state, nil
+	}
+
+	if secret.ID != selfID {
+		return nil, nil, fmt.Errorf("RepairInit: secret share of party %d used by party %d", secret.ID, selfID)
+	}
+	var ownPublic ristretto.Element
+	ownPublic.ScalarBaseMult(&secret.Secret)
+	if ownPublic.Equal(public.Shares[selfID]) != 1 {
+		return nil, nil, errors.New("RepairInit: secret share does not match the public share")
+	}
+
+	lagrange, err := selfID.LagrangeAt(helpers, lost)
+	if err != nil {
+		return nil, nil, fmt.Errorf("RepairInit: %w", err)
+	}
+	// δ = lᵢ(ℓ) • sᵢ, split into random shares for the other helpers, the
+	// remainder of which is our own
+	var delta ristretto.Scalar
+	delta.Multiply(lagrange, &secret.Secret)
+	msgs := make([]*Message, 0, helpers.N()-1)
+	for _, id := range helpers {
+		if id == selfID {
+			continue
+		}
+		var mask ristretto.Scalar
+		scalar.SetScalarRandomFrom(&mask, rng)
+		delta.Subtract(&delta, &mask)
+		msgs = append(msgs, NewRepair1(selfID, id, &mask))
+	}
+	state.Sigma.Set(&delta)
+	state.received[selfID] = true
+	delta.Set(ristretto.NewScalar())
+	return msgs, state, nil
+}
+
+// RepairRound1 sums the Repair1 messages of the other helpers, and returns
+// the Repair2 message for the lost party.
+func RepairRound1(state *RepairState, inputMsgs []*Message) (*Message, *RepairState, error) {
+	if !state.Helpers.Contains(state.SelfID) {
+		return nil, nil, errors.New("RepairRound1: only helpers run the first round")
+	}
+	for _, msg := range inputMsgs {
+		if err := msg.Validate(MessageTypeRepair1, SessionID{}, state.SelfID); err != nil {
+			return nil, nil, fmt.Errorf("RepairRound1: %w", err)
+		}
+		id := msg.From
+		if !state.Helpers.Contains(id) {
+			return nil, nil, fmt.Errorf("RepairRound1: %w", &ErrUnknownParty{ID: id})
+		}
+		if state.received[id] {
+			return nil, nil, fmt.Errorf("RepairRound1: duplicate message from party %d", id)
+		}
+		state.received[id] = true
+		state.Sigma.Add(&state.Sigma, &msg.Repair1.Delta)
+	}
+	if len(state.received) != len(state.Helpers) {
+		return nil, nil, fmt.Errorf("RepairRound1: got shares of %d of %d helpers", len(state.received), state.Helpers.N())
+	}
+	msg := NewRepair2(state.SelfID, state.Lost, &state.Sigma)
+	state.Sigma.Set(ristretto.NewScalar())
+	return msg, state, nil
+}
+
+// RepairRound2 sums the Repair2 messages of all helpers into the secret share
+// of the lost party, and checks it against the public share of the party.
+func RepairRound2(state *RepairState, inputMsgs []*Message) (*eddsa.SecretShare, error) {
+	if state.SelfID != state.Lost {
+		return nil, errors.New("RepairRound2: only the lost party runs the second round")
+	}
+	for _, msg := range inputMsgs {
+		if err := msg.Validate(MessageTypeRepair2, SessionID{}, state.SelfID); err != nil {
+			return nil, fmt.Errorf("RepairRound2: %w", err)
+		}
+		id := msg.From
+		if !state.Helpers.Contains(id) {
+			return nil, fmt.Errorf("RepairRound2: %w", &ErrUnknownParty{ID: id})
+		}
+		if state.received[id] {
+			return nil, fmt.Errorf("RepairRound2: duplicate message from party %d", id)
+		}
+		state.received[id] = true
+		state.Sigma.Add(&state.Sigma, &msg.Repair2.Sigma)
+	}
+	if len(state.received) != len(state.Helpers) {
+		return nil, fmt.Errorf("RepairRound2: got sums of %d of %d helpers", len(state.received), state.Helpers.N())
+	}
+	share := eddsa.NewSecretShare(state.Lost, &state.Sigma)
+	state.Sigma.Set(ristretto.NewScalar())
+	if share.Public.Equal(state.Public.Shares[state.Lost]) != 1 {
+		return nil, fmt.Errorf("RepairRound2: the repaired share does not match the public share: %w", ErrInvalidShare)
+	}
+	return share, nil
+}
+
+func (s *RepairState) MarshalJSON() ([]byte, error) {
+	received := make(party.IDSlice, 0, len(s.received))
+	for id := range s.received {
+		received = append(received, id)
+	}
+	return json.Marshal(&struct {
+		ID       string        `json:"id"`
+		Lost     party.ID      `json:"lost"`
+		Helpers  party.IDSlice `json:"helpers"`
+		Public   *eddsa.Public `json:"public"`
+		Sigma    string        `json:"sigma"`
+		Received party.IDSlice `json:"received"`
+	}{
+		ID:       base64.StdEncoding.EncodeToString(s.SelfID.Bytes()),
+		Lost:     s.Lost,
+		Helpers:  s.Helpers,
+		Public:   s.Public,
+		Sigma:    base64.StdEncoding.EncodeToString(s.Sigma.Bytes()),
+		Received: received.Sorted(),
+	})
+}
+
+func (s *RepairState) UnmarshalJSON(data []byte) error {
+	aux := &struct {
+		ID       string        `json:"id"`
+		Lost     party.ID      `json:"lost"`
+		Helpers  party.IDSlice `json:"helpers"`
+		Public   *eddsa.Public `json:"public"`
+		Sigma    string        `json:"sigma"`
+		Received party.IDSlice `json:"received"`
+	}{}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	idBytes, err := base64.StdEncoding.DecodeString(aux.ID)
+	if err != nil {
+		return err
+	}
+	if s.SelfID, err = party.FromBytes(idBytes); err != nil {
+		return err
+	}
+	if aux.Public == nil {
+		return errors.New("RepairState: missing public information")
+	}
+	s.Lost = aux.Lost
+	s.Helpers = aux.Helpers
+	s.Public = aux.Public
+	if err := decodeScalar(aux.Sigma, &s.Sigma); err != nil {
+		return err
+	}
+
+	s.received = make(map[party.ID]bool, len(s.Helpers))
+	for _, id := range aux.Received {
+		if !s.Helpers.Contains(id) {
+			return fmt.Errorf("RepairState: %w", &ErrUnknownParty{ID: id})
+		}
+		s.received[id] = true
+	}
+	return nil
+}
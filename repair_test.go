@@ -0,0 +1,128 @@
+package frost
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runRepair repairs the share of lost with helpers, and returns the repaired
+// share and the Repair2 messages the lost party received.
+func runRepair(t *testing.T, public *eddsa.Public, secrets map[party.ID]*eddsa.SecretShare, helpers party.IDSlice, lost party.ID) (*eddsa.SecretShare, []*Message) {
+	states := make(map[party.ID]*RepairState, len(helpers))
+	round1 := make(map[party.ID][]*Message, len(helpers))
+	for _, id := range helpers {
+		msgs, state, err := RepairInit(id, secrets[id], public, helpers, lost)
+		require.NoError(t, err)
+		require.Len(t, msgs, len(helpers)-1)
+		states[id] = state
+		for _, msg := range msgs {
+			round1[msg.To] = append(round1[msg.To], msg)
+		}
+	}
+	msgs, lostState, err := RepairInit(lost, nil, public, helpers, lost)
+	require.NoError(t, err)
+	assert.Empty(t, msgs)
+
+	var round2 []*Message
+	for _, id := range helpers {
+		msg, _, err := RepairRound1(states[id], round1[id])
+		require.NoError(t, err)
+		// the helpers' sums do not reveal their contributions
+		assert.NotEqual(t, 1, msg.Repair2.Sigma.Equal(&secrets[lost].Secret))
+		round2 = append(round2, msg)
+	}
+	share, err := RepairRound2(lostState, round2)
+	require.NoError(t, err)
+	return share, round2
+}
+
+func TestRepair(t *testing.T) {
+	public, secrets := generateKeys(t, 5, 2)
+
+	for lost, helpers := range map[party.ID]party.IDSlice{3: {1, 2, 4}, 4: {1, 2, 3, 5}} {
+		share, _ := runRepair(t, public, secrets, helpers, lost)
+		assert.True(t, share.Equal(secrets[lost]), helpers)
+	}
+
+	// the repaired share signs
+	repaired, round2 := runRepair(t, public, secrets, party.IDSlice{2, 3, 4}, 1)
+	signers := party.IDSlice{1, 5, 3}
+	message := []byte("repaired")
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		secret := secrets[id]
+		if id == 1 {
+			secret = repaired
+		}
+		msg, state, err := SignInit(signers, secret, public, message)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	sigs, err := runSignRounds(states, round1)
+	require.NoError(t, err)
+	for _, sig := range sigs {
+		assert.True(t, public.GroupKey.Verify(message, sig))
+	}
+
+	// a wrong sum is detected
+	_, state, err := RepairInit(1, nil, public, party.IDSlice{2, 3, 4}, 1)
+	require.NoError(t, err)
+	round2[0] = NewRepair2(2, 1, &secrets[2].Secret)
+	_, err = RepairRound2(state, round2)
+	assert.Error(t, err)
+
+	// a missing helper is detected
+	_, state, err = RepairInit(1, nil, public, party.IDSlice{2, 3, 4}, 1)
+	require.NoError(t, err)
+	_, err = RepairRound2(state, round2[1:])
+	assert.Error(t, err)
+
+	_, _, err = RepairInit(2, secrets[2], public, party.IDSlice{2, 3}, 1)
+	assert.Error(t, err, "too few helpers")
+	_, _, err = RepairInit(2, secrets[2], public, party.IDSlice{1, 2, 3}, 1)
+	assert.Error(t, err, "lost party helps")
+	_, _, err = RepairInit(2, secrets[3], public, party.IDSlice{2, 3, 4}, 1)
+	assert.Error(t, err, "wrong secret share")
+}
+
+func TestRepairState_JSON(t *testing.T) {
+	public, secrets := generateKeys(t, 5, 2)
+	helpers := party.IDSlice{1, 2, 4}
+	round1 := make(map[party.ID][]*Message, len(helpers))
+	states := make(map[party.ID]*RepairState, len(helpers))
+	for _, id := range helpers {
+		msgs, state, err := RepairInit(id, secrets[id], public, helpers, 3)
+		require.NoError(t, err)
+		states[id] = state
+		for _, msg := range msgs {
+			round1[msg.To] = append(round1[msg.To], msg)
+		}
+	}
+
+	// a helper that received some of its shares resumes from the decoded state
+	state := states[1]
+	_, _, err := RepairRound1(state, round1[1][:1])
+	assert.Error(t, err, "a share is missing")
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+	var decoded RepairState
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, state.SelfID, decoded.SelfID)
+	assert.Equal(t, state.Helpers, decoded.Helpers)
+	assert.True(t, decoded.Public.Equal(public))
+	assert.Equal(t, 1, decoded.Sigma.Equal(&state.Sigma))
+	assert.Equal(t, state.received, decoded.received)
+
+	_, _, err = RepairRound1(&decoded, round1[1][:1])
+	assert.Error(t, err, "duplicate")
+	msg, _, err := RepairRound1(&decoded, round1[1][1:])
+	require.NoError(t, err)
+	assert.Equal(t, party.ID(3), msg.To)
+}
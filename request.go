@@ -0,0 +1,118 @@
+package frost
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+)
+
+// ErrRequestExpired is returned when a SignatureRequest is used after its expiry.
+var ErrRequestExpired = errors.New("signature request expired")
+
+// SignatureRequest describes what is being signed, by whom, and why.
+//
+// When a signing session is started with SignInitWithRequest, the digest of the
+// request is bound into the binding factors of every signer. Parties that were
+// shown a different request compute different binding factors and the session
+// aborts, so an approval given for a request applies to exactly that request.
+type SignatureRequest struct {
+	// ID is a caller chosen identifier, for example a ticket or UUID.
+	ID string `json:"id"`
+
+	// Message is the data that will be signed.
+	Message []byte `json:"message"`
+
+	// Requester identifies who asked for the signature.
+	Requester string `json:"requester,omitempty"`
+
+	// Purpose is a human readable reason for the signature.
+	Purpose string `json:"purpose,omitempty"`
+
+	// Expiry is the time after which the request must not be signed.
+	// A zero value means the request does not expire.
+	Expiry time.Time `json:"expiry,omitempty"`
+
+	// Format is a hint on how Message is encoded, e.g. "raw", "jwt" or "x509".
+	Format string `json:"format,omitempty"`
+
+	// Chain is a hint on the system the signature is intended for.
+	Chain string `json:"chain,omitempty"`
+
+	// Metadata holds any additional application specific attributes.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Expired returns true if the request has an expiry which is before now.
+func (r *SignatureRequest) Expired(now time.Time) bool {
+	return !r.Expiry.IsZero() && now.After(r.Expiry)
+}
+
+// Digest returns a SHA-512 hash over a canonical encoding of all fields of the request.
+func (r *SignatureRequest) Digest() []byte {
+	h := sha512.New()
+	_, _ = h.Write([]byte("FROST-SIGNATURE-REQUEST-v1"))
+
+	writeField := func(b []byte) {
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(b)))
+		_, _ = h.Write(length[:])
+		_, _ = h.Write(b)
+	}
+
+	// the expiry with the precision Expired compares it at, as the seconds
+	// and the nanoseconds within the second
+	var expiry [12]byte
+	if !r.Expiry.IsZero() {
+		binary.BigEndian.PutUint64(expiry[:8], uint64(r.Expiry.Unix()))
+		binary.BigEndian.PutUint32(expiry[8:], uint32(r.Expiry.Nanosecond()))
+	}
+
+	writeField([]byte(r.ID))
+	writeField(r.Message)
+	writeField([]byte(r.Requester))
+	writeField([]byte(r.Purpose))
+	writeField(expiry[:])
+	writeField([]byte(r.Format))
+	writeField([]byte(r.Chain))
+
+	keys := make([]string, 0, len(r.Metadata))
+	for k := range r.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		writeField([]byte(k))
+		writeField([]byte(r.Metadata[k]))
+	}
+
+	return h.Sum(nil)
+}
+
+// SignInitWithRequest is like SignInit, but signs req.Message and binds the request into the session.
+// It returns ErrRequestExpired if the request is expired.
+func SignInitWithRequest(signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, req *SignatureRequest) (*Message, *SignerState, error) {
+	return SignInitWithRequestClock(clock.Real, signerIDs, secret, shares, req)
+}
+
+// SignInitWithRequestClock is like SignInitWithRequest, but checks the expiry of
+// req against c, here and in the following rounds.
+func SignInitWithRequestClock(c clock.Clock, signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, req *SignatureRequest) (*Message, *SignerState, error) {
+	if req.Expired(c.Now()) {
+		return nil, nil, fmt.Errorf("SignRound0: %w", ErrRequestExpired)
+	}
+
+	msg, state, err := SignInit(signerIDs, secret, shares, req.Message)
+	if err != nil {
+		return nil, nil, err
+	}
+	state.Request = req
+	state.Clock = c
+	return msg, state, nil
+}
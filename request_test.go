@@ -0,0 +1,101 @@
+package frost
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignatureRequest_Digest(t *testing.T) {
+	req := &SignatureRequest{
+		ID:       "req-1",
+		Message:  []byte("payload"),
+		Purpose:  "release",
+		Expiry:   time.Unix(1700000000, 0),
+		Metadata: map[string]string{"b": "2", "a": "1"},
+	}
+	other := *req
+	other.Purpose = "other"
+
+	assert.Equal(t, req.Digest(), req.Digest())
+	assert.NotEqual(t, req.Digest(), other.Digest())
+	// requests that expire within the same second differ as well
+	other = *req
+	other.Expiry = req.Expiry.Add(time.Millisecond)
+	assert.NotEqual(t, req.Digest(), other.Digest())
+
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	var decoded SignatureRequest
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, req.Digest(), decoded.Digest())
+}
+
+func TestSignInitWithRequest(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	signers := party.IDSlice{1, 2}
+	req := &SignatureRequest{ID: "req-1", Message: []byte("payload"), Expiry: time.Now().Add(time.Hour)}
+
+	initAll := func(reqFor func(id party.ID) *SignatureRequest) (map[party.ID]*SignerState, []*Message) {
+		states := make(map[party.ID]*SignerState)
+		var round1 []*Message
+		for _, id := range signers {
+			msg, state, err := SignInitWithRequest(signers, secrets[id], public, reqFor(id))
+			require.NoError(t, err)
+			states[id] = state
+			round1 = append(round1, msg)
+		}
+		return states, round1
+	}
+
+	states, round1 := initAll(func(party.ID) *SignatureRequest { return req })
+	sigs, err := runSignRounds(states, round1)
+	require.NoError(t, err)
+	assert.True(t, public.GroupKey.Verify(req.Message, sigs[1]))
+
+	// a signer shown a different purpose for the same message must cause an abort
+	tampered := *req
+	tampered.Purpose = "something else"
+	states, round1 = initAll(func(id party.ID) *SignatureRequest {
+		if id == 2 {
+			return &tampered
+		}
+		return req
+	})
+	_, err = runSignRounds(states, round1)
+	assert.Error(t, err)
+
+	expired := &SignatureRequest{Message: []byte("late"), Expiry: time.Now().Add(-time.Minute)}
+	_, _, err = SignInitWithRequest(signers, secrets[1], public, expired)
+	assert.True(t, errors.Is(err, ErrRequestExpired))
+}
+
+func TestSignInitWithRequestClock(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	signers := party.IDSlice{1, 2}
+	c := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	req := &SignatureRequest{ID: "req-1", Message: []byte("payload"), Expiry: c.Now().Add(time.Minute)}
+
+	var round1 []*Message
+	states := make(map[party.ID]*SignerState)
+	for _, id := range signers {
+		msg, state, err := SignInitWithRequestClock(c, signers, secrets[id], public, req)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+
+	// the request expires between the rounds
+	c.Advance(time.Minute + time.Second)
+	_, _, err := SignRound1(states[1], round1)
+	assert.True(t, errors.Is(err, ErrRequestExpired))
+
+	_, _, err = SignInitWithRequestClock(c, signers, secrets[1], public, req)
+	assert.True(t, errors.Is(err, ErrRequestExpired))
+}
@@ -0,0 +1,401 @@
+package frost
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/polynomial"
+	"github.com/bartke/frost/ristretto"
+)
+
+// Resharing moves the group secret to a new set of parties, possibly with a
+// different threshold, without reconstructing it. The group key stays the same.
+//
+// At least Threshold+1 holders of the old shares act as dealers. Each dealer
+// multiplies its share by its Lagrange coefficient for the set of dealers, so
+// that the weighted shares sum to the group secret, and deals it to the new
+// parties with a fresh polynomial of the new threshold:
+//
+//  1. ReshareInit returns the Reshare1 broadcast of a dealer with the commitments
+//     to its polynomial. Parties that only receive a share get no message.
+//  2. ReshareRound1 verifies the Reshare1 messages of all dealers: the constant of
+//     each polynomial must match the weighted public share of the dealer. Dealers
+//     then send a Reshare2 share to every new party.
+//  3. ReshareRound2 verifies the shares against the commitments and returns the
+//     new public shares and, for new parties, their secret share.
+//
+// A party can be both dealer and new party. The old shares must be deleted once
+// the new ones were stored, otherwise the old parties can still sign.
+type ReshareState struct {
+	SelfID party.ID
+	// Dealers are the old parties dealing their shares.
+	Dealers party.IDSlice
+	// PartyIDs and Threshold describe the new set of parties.
+	PartyIDs  party.IDSlice
+	Threshold party.Size
+	// Old is the public information of the old set of parties.
+	Old *eddsa.Public
+	// Polynomial is our polynomial if we are a dealer, until ReshareRound1
+	// dealt the shares of the new parties, and nil otherwise.
+	Polynomial *polynomial.Polynomial
+	// Secret is the sum of the shares received so far, if we are a new party.
+	Secret         ristretto.Scalar
+	Commitments    map[party.ID]*polynomial.Exponent
+	CommitmentsSum *polynomial.Exponent
+	// SessionID is the session the state belongs to, see ReshareInitWithSession.
+	SessionID SessionID
+}
+
+// ReshareInit initializes the resharing of the group described by old, from
+// dealers to the parties newPartyIDs with threshold newThreshold. Dealers pass
+// their old secret share, new parties that are not dealers pass nil, and get a
+// nil message.
+func ReshareInit(selfID party.ID, secret *eddsa.SecretShare, old *eddsa.Public, dealers, newPartyIDs party.IDSlice, newThreshold party.Size) (*Message, *ReshareState, error) {
+	return reshareInit(SessionID{}, selfID, secret, old, dealers, newPartyIDs, newThreshold, rand.Reader)
+}
+
+// ReshareInitWithSession is ReshareInit for the session with the given ID,
+// which all dealers and new parties must have agreed on. The messages of the
+// session carry the ID, and ReshareRound1 and ReshareRound2 reject messages of
+// other sessions.
+func ReshareInitWithSession(session SessionID, selfID party.ID, secret *eddsa.SecretShare, old *eddsa.Public, dealers, newPartyIDs party.IDSlice, newThreshold party.Size) (*Message, *ReshareState, error) {
+	return reshareInit(session, selfID, secret, old, dealers, newPartyIDs, newThreshold, rand.Reader)
+}
+
+// reshareInit is ReshareInitWithSession with the polynomial sampled from rng.
+func reshareInit(session SessionID, selfID party.ID, secret *eddsa.SecretShare, old *eddsa.Public, dealers, newPartyIDs party.IDSlice, newThreshold party.Size, rng io.Reader) (*Message, *ReshareState, error) {
+	dealers = party.NewIDSlice(dealers)
+	newPartyIDs = party.NewIDSlice(newPartyIDs)
+	if !dealers.IsSubsetOf(old.PartyIDs) {
+		return nil, nil, fmt.Errorf("ReshareInit: dealers %v are not a subset of the old parties %v", dealers, old.PartyIDs)
+	}
+	if dealers.N() <= old.Threshold {
+		return nil, nil, fmt.Errorf("ReshareInit: %d dealers, at least %d are needed", dealers.N(), old.Threshold+1)
+	}
+	if newPartyIDs.Contains(0) {
+		return nil, nil, errors.New("ReshareInit: id 0 is not valid")
+	}
+	if newThreshold >= newPartyIDs.N() {
+		return nil, nil, fmt.Errorf("ReshareInit: threshold %d must be less than the %d new parties", newThreshold, newPartyIDs.N())
+	}
+	if newThreshold > polynomial.MaxDegree {
+		return nil, nil, fmt.Errorf("ReshareInit: threshold %d exceeds the maximum of %d", newThreshold, polynomial.MaxDegree)
+	}
+	isDealer := dealers.Contains(selfID)
+	if !isDealer && !newPartyIDs.Contains(selfID) {
+		return nil, nil, fmt.Errorf("ReshareInit: party %d is neither a dealer nor a new party", selfID)
+	}
+	if isDealer != (secret != nil) {
+		return nil, nil, errors.New("ReshareInit: dealers, and only dealers, must pass their secret share")
+	}
+
+	state := &ReshareState{
+		SelfID:      selfID,
+		Dealers:     dealers,
+		PartyIDs:    newPartyIDs,
+		Threshold:   newThreshold,
+		Old:         old,
+		Commitments: make(map[party.ID]*polynomial.Exponent, dealers.N()),
+		SessionID:   session,
+	}
+	if !isDealer {
+		return nil, state, nil
+	}
+
+	if secret.ID != selfID {
+		return nil, nil, fmt.Errorf("ReshareInit: secret share of party %d used by party %d", secret.ID, selfID)
+	}
+	var public ristretto.Element
+	public.ScalarBaseMult(&secret.Secret)
+	if public.Equal(old.Shares[selfID]) != 1 {
+		return nil, nil, errors.New("ReshareInit: secret share does not match the old public share")
+	}
+
+	lagrange, err := selfID.Lagrange(dealers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ReshareInit: %w", err)
+	}
+	var weighted ristretto.Scalar
+	weighted.Multiply(lagrange, &secret.Secret)
+	state.Polynomial = polynomial.NewPolynomialFrom(newThreshold, &weighted, rng)
+	weighted.Set(ristretto.NewScalar())
+
+	commitments := polynomial.NewPolynomialExponent(state.Polynomial)
+	state.Commitments[selfID] = commitments
+	msg := NewReshare1(selfID, commitments)
+	msg.SessionID = session
+	return msg, state, nil
+}
+
+// ReshareRound1 processes the Reshare1 messages of all dealers, and returns the
+// Reshare2 messages for the new parties if we are a dealer.
+func ReshareRound1(state *ReshareState, inputMsgs []*Message) ([]*Message, *ReshareState, error) {
+	for _, msg := range inputMsgs {
+		id := msg.From
+		if id == state.SelfID {
+			continue
+		}
+		if err := msg.Validate(MessageTypeReshare1, state.SessionID, state.SelfID); err != nil {
+			return nil, nil, fmt.Errorf("ReshareRound1: %w", err)
+		}
+		if !state.Dealers.Contains(id) {
+			return nil, nil, fmt.Errorf("ReshareRound1: %w", &ErrUnknownParty{ID: id})
+		}
+		if _, ok := state.Commitments[id]; ok {
+			return nil, nil, fmt.Errorf("ReshareRound1: duplicate message from party %d", id)
+		}
+
+		commitments := msg.Reshare1.Commitments
+		if commitments.Degree() != state.Threshold {
+			return nil, nil, fmt.Errorf("ReshareRound1: polynomial of party %d has degree %d, expected %d: %w", id, commitments.Degree(), state.Threshold, ErrInvalidMessage)
+		}
+		// the constant must be the dealer's weighted old public share
+		lagrange, err := id.Lagrange(state.Dealers)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ReshareRound1: %w", err)
+		}
+		var weighted ristretto.Element
+		weighted.ScalarMult(lagrange, state.Old.Shares[id])
+		if weighted.Equal(commitments.Constant()) != 1 {
+			return nil, nil, fmt.Errorf("ReshareRound1: party %d did not deal its share: %w", id, ErrInvalidShare)
+		}
+		state.Commitments[id] = commitments
+	}
+	if party.Size(len(state.Commitments)) != state.Dealers.N() {
+		return nil, nil, fmt.Errorf("ReshareRound1: got commitments of %d of %d dealers", len(state.Commitments), state.Dealers.N())
+	}
+
+	exponents := make([]*polynomial.Exponent, 0, len(state.Commitments))
+	for _, id := range state.Dealers {
+		exponents = append(exponents, state.Commitments[id])
+	}
+	sum, err := polynomial.Sum(exponents)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ReshareRound1: %w", err)
+	}
+	if !eddsa.NewPublicKeyFromPoint(sum.Constant()).Equal(state.Old.GroupKey) {
+		return nil, nil, fmt.Errorf("ReshareRound1: commitments do not sum to the group key: %w", ErrInvalidShare)
+	}
+	state.CommitmentsSum = sum
+
+	if !state.Dealers.Contains(state.SelfID) {
+		return nil, state, nil
+	}
+	if state.Polynomial == nil {
+		return nil, nil, errors.New("ReshareRound1: the shares were dealt already")
+	}
+	msgsOut := make([]*Message, 0, state.PartyIDs.N())
+	for _, id := range state.PartyIDs {
+		share := state.Polynomial.Evaluate(id.Scalar())
+		if id == state.SelfID {
+			state.Secret.Set(share)
+			continue
+		}
+		msg := NewReshare2(state.SelfID, id, share)
+		msg.SessionID = state.SessionID
+		msgsOut = append(msgsOut, msg)
+	}
+	// the polynomial determines the weighted old share, and is not needed anymore
+	state.Polynomial.Zeroize()
+	state.Polynomial = nil
+	return msgsOut, state, nil
+}
+
+// ReshareRound2 processes the Reshare2 messages of all dealers and returns the
+// public information of the new parties, and our new secret share. Dealers that
+// are not new parties get a nil secret share.
+func ReshareRound2(state *ReshareState, inputMsgs []*Message) (*eddsa.Public, *eddsa.SecretShare, error) {
+	if state.CommitmentsSum == nil {
+		return nil, nil, errors.New("ReshareRound2: round 1 was not completed")
+	}
+	isNew := state.PartyIDs.Contains(state.SelfID)
+
+	received := make(map[party.ID]bool, state.Dealers.N())
+	if state.Dealers.Contains(state.SelfID) {
+		received[state.SelfID] = true
+	}
+	for _, msg := range inputMsgs {
+		if msg.From == state.SelfID {
+			continue
+		}
+		if !isNew {
+			return nil, nil, fmt.Errorf("ReshareRound2: party %d is not a new party", state.SelfID)
+		}
+		if err := msg.Validate(MessageTypeReshare2, state.SessionID, state.SelfID); err != nil {
+			return nil, nil, fmt.Errorf("ReshareRound2: %w", err)
+		}
+		id := msg.From
+		if !state.Dealers.Contains(id) {
+			return nil, nil, fmt.Errorf("ReshareRound2: %w", &ErrUnknownParty{ID: id})
+		}
+		commitments, ok := state.Commitments[id]
+		if !ok {
+			return nil, nil, fmt.Errorf("ReshareRound2: no commitment of party %d", id)
+		}
+		if received[id] {
+			return nil, nil, fmt.Errorf("ReshareRound2: duplicate share from party %d", id)
+		}
+
+		var computedShareExp ristretto.Element
+		computedShareExp.ScalarBaseMult(&msg.Reshare2.Share)
+		if computedShareExp.Equal(commitments.EvaluateID(state.SelfID)) != 1 {
+			// Verifiable Secret Sharing (VSS) validation failed
+			return nil, nil, fmt.Errorf("ReshareRound2: share of party %d: %w", id, ErrInvalidShare)
+		}
+		received[id] = true
+		state.Secret.Add(&state.Secret, &msg.Reshare2.Share)
+	}
+	if isNew && party.Size(len(received)) != state.Dealers.N() {
+		return nil, nil, fmt.Errorf("ReshareRound2: got shares of %d of %d dealers", len(received), state.Dealers.N())
+	}
+
+	shares := make(map[party.ID]*ristretto.Element, state.PartyIDs.N())
+	for _, id := range state.PartyIDs {
+		shares[id] = state.CommitmentsSum.EvaluateID(id)
+	}
+	pub := &eddsa.Public{
+		PartyIDs:  state.PartyIDs,
+		Threshold: state.Threshold,
+		Shares:    shares,
+		GroupKey:  eddsa.NewPublicKeyFromPoint(state.CommitmentsSum.Constant()),
+	}
+	if !isNew {
+		return pub, nil, nil
+	}
+	return pub, eddsa.NewSecretShare(state.SelfID, &state.Secret), nil
+}
+
+func (s *ReshareState) MarshalJSON() ([]byte, error) {
+	var polynomialBytes, sumBytes []byte
+	var err error
+	if s.Polynomial != nil {
+		if polynomialBytes, err = s.Polynomial.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	}
+	if s.CommitmentsSum != nil {
+		if sumBytes, err = s.CommitmentsSum.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	}
+	commitments := make(map[string]string, len(s.Commitments))
+	for id, exp := range s.Commitments {
+		expBytes, err := exp.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		commitments[base64.StdEncoding.EncodeToString(id.Bytes())] = base64.StdEncoding.EncodeToString(expBytes)
+	}
+
+	return json.Marshal(&struct {
+		ID             string            `json:"id"`
+		Dealers        party.IDSlice     `json:"dealers"`
+		PartyIDs       party.IDSlice     `json:"party_ids"`
+		Threshold      party.Size        `json:"threshold"`
+		Old            *eddsa.Public     `json:"old"`
+		Polynomial     string            `json:"polynomial,omitempty"`
+		Secret         string            `json:"secret"`
+		Commitments    map[string]string `json:"commitments"`
+		CommitmentsSum string            `json:"commitments_sum,omitempty"`
+		Session        string            `json:"session,omitempty"`
+	}{
+		ID:             base64.StdEncoding.EncodeToString(s.SelfID.Bytes()),
+		Dealers:        s.Dealers,
+		PartyIDs:       s.PartyIDs,
+		Threshold:      s.Threshold,
+		Old:            s.Old,
+		Polynomial:     base64.StdEncoding.EncodeToString(polynomialBytes),
+		Secret:         base64.StdEncoding.EncodeToString(s.Secret.Bytes()),
+		Commitments:    commitments,
+		CommitmentsSum: base64.StdEncoding.EncodeToString(sumBytes),
+		Session:        s.SessionID.encode(),
+	})
+}
+
+func (s *ReshareState) UnmarshalJSON(data []byte) error {
+	aux := &struct {
+		ID             string            `json:"id"`
+		Dealers        party.IDSlice     `json:"dealers"`
+		PartyIDs       party.IDSlice     `json:"party_ids"`
+		Threshold      party.Size        `json:"threshold"`
+		Old            *eddsa.Public     `json:"old"`
+		Polynomial     string            `json:"polynomial,omitempty"`
+		Secret         string            `json:"secret"`
+		Commitments    map[string]string `json:"commitments"`
+		CommitmentsSum string            `json:"commitments_sum,omitempty"`
+		Session        string            `json:"session,omitempty"`
+	}{}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	idBytes, err := base64.StdEncoding.DecodeString(aux.ID)
+	if err != nil {
+		return err
+	}
+	if s.SelfID, err = party.FromBytes(idBytes); err != nil {
+		return err
+	}
+	if aux.Old == nil {
+		return errors.New("ReshareState: missing old public information")
+	}
+	s.Dealers = aux.Dealers
+	s.PartyIDs = aux.PartyIDs
+	s.Threshold = aux.Threshold
+	s.Old = aux.Old
+
+	s.Polynomial = nil
+	if aux.Polynomial != "" {
+		polynomialBytes, err := base64.StdEncoding.DecodeString(aux.Polynomial)
+		if err != nil {
+			return err
+		}
+		s.Polynomial = &polynomial.Polynomial{}
+		if err := s.Polynomial.UnmarshalBinary(polynomialBytes); err != nil {
+			return err
+		}
+	}
+
+	if err := decodeScalar(aux.Secret, &s.Secret); err != nil {
+		return err
+	}
+
+	s.Commitments = make(map[party.ID]*polynomial.Exponent, len(aux.Commitments))
+	for id, exp := range aux.Commitments {
+		idBytes, err := base64.StdEncoding.DecodeString(id)
+		if err != nil {
+			return err
+		}
+		partyID, err := party.FromBytes(idBytes)
+		if err != nil {
+			return err
+		}
+		expBytes, err := base64.StdEncoding.DecodeString(exp)
+		if err != nil {
+			return err
+		}
+		s.Commitments[partyID] = &polynomial.Exponent{}
+		if err := s.Commitments[partyID].UnmarshalBinary(expBytes); err != nil {
+			return err
+		}
+	}
+
+	s.CommitmentsSum = nil
+	if aux.CommitmentsSum != "" {
+		sumBytes, err := base64.StdEncoding.DecodeString(aux.CommitmentsSum)
+		if err != nil {
+			return err
+		}
+		s.CommitmentsSum = &polynomial.Exponent{}
+		if err := s.CommitmentsSum.UnmarshalBinary(sumBytes); err != nil {
+			return err
+		}
+	}
+	return s.SessionID.decode(aux.Session)
+}
@@ -0,0 +1,219 @@
+package frost
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/polynomial"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reshare runs the resharing protocol in memory, with the states serialized between rounds.
+func reshare(t *testing.T, old *eddsa.Public, secrets map[party.ID]*eddsa.SecretShare, dealers, newParties party.IDSlice, threshold party.Size) (*eddsa.Public, map[party.ID]*eddsa.SecretShare) {
+	t.Helper()
+
+	states := make(map[party.ID]*ReshareState)
+	var round1 []*Message
+	for _, id := range append(append(party.IDSlice{}, dealers...), newParties...) {
+		if _, ok := states[id]; ok {
+			continue
+		}
+		var secret *eddsa.SecretShare
+		if dealers.Contains(id) {
+			secret = secrets[id]
+		}
+		msg, state, err := ReshareInit(id, secret, old, dealers, newParties, threshold)
+		require.NoError(t, err)
+		states[id] = state
+		if msg != nil {
+			round1 = append(round1, msg)
+		}
+	}
+
+	round2 := make(map[party.ID][]*Message)
+	for id, state := range states {
+		data, err := json.Marshal(state)
+		require.NoError(t, err)
+		var decoded ReshareState
+		require.NoError(t, json.Unmarshal(data, &decoded))
+
+		msgs, _, err := ReshareRound1(&decoded, round1)
+		require.NoError(t, err)
+		states[id] = &decoded
+		for _, msg := range msgs {
+			round2[msg.To] = append(round2[msg.To], msg)
+		}
+	}
+
+	var public *eddsa.Public
+	newSecrets := make(map[party.ID]*eddsa.SecretShare)
+	for id, state := range states {
+		pub, sec, err := ReshareRound2(state, round2[id])
+		require.NoError(t, err)
+		if public != nil {
+			require.True(t, public.Equal(pub))
+		}
+		public = pub
+		if newParties.Contains(id) {
+			require.NotNil(t, sec)
+			newSecrets[id] = sec
+		} else {
+			assert.Nil(t, sec)
+		}
+	}
+	return public, newSecrets
+}
+
+func signWith(t *testing.T, public *eddsa.Public, secrets map[party.ID]*eddsa.SecretShare, signers party.IDSlice, message []byte) bool {
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInit(signers, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	sigs, err := runSignRounds(states, round1)
+	if err != nil {
+		return false
+	}
+	for _, sig := range sigs {
+		if !public.GroupKey.Verify(message, sig) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestReshare(t *testing.T) {
+	old, secrets := generateKeys(t, 5, 2)
+	message := []byte("reshared")
+
+	// parties 1 and 5 leave, 6 and 7 join, the threshold is lowered to 1
+	newParties := party.IDSlice{2, 3, 6, 7}
+	public, newSecrets := reshare(t, old, secrets, party.IDSlice{1, 3, 5}, newParties, 1)
+	assert.True(t, public.GroupKey.Equal(old.GroupKey))
+	assert.Equal(t, newParties, public.PartyIDs)
+	assert.Equal(t, party.Size(1), public.Threshold)
+	for id, sec := range newSecrets {
+		var share ristretto.Element
+		share.ScalarBaseMult(&sec.Secret)
+		assert.Equal(t, 1, share.Equal(public.Shares[id]))
+	}
+	assert.True(t, signWith(t, public, newSecrets, party.IDSlice{3, 7}, message))
+	assert.True(t, signWith(t, public, newSecrets, party.IDSlice{2, 6}, message))
+
+	// and raised again
+	public, newSecrets = reshare(t, public, newSecrets, party.IDSlice{6, 7}, party.IDSlice{1, 2, 3, 4, 5, 6}, 3)
+	assert.True(t, public.GroupKey.Equal(old.GroupKey))
+	assert.True(t, signWith(t, public, newSecrets, party.IDSlice{1, 4, 5, 6}, message))
+}
+
+func TestReshareInit_Invalid(t *testing.T) {
+	old, secrets := generateKeys(t, 4, 2)
+	newParties := party.IDSlice{1, 5, 6}
+
+	_, _, err := ReshareInit(1, secrets[1], old, party.IDSlice{1, 2}, newParties, 1)
+	assert.Error(t, err, "too few dealers")
+	_, _, err = ReshareInit(1, secrets[1], old, party.IDSlice{1, 2, 5}, newParties, 1)
+	assert.Error(t, err, "dealers are old parties")
+	_, _, err = ReshareInit(1, secrets[1], old, party.IDSlice{1, 2, 3}, newParties, 3)
+	assert.Error(t, err, "threshold too high")
+	_, _, err = ReshareInit(4, nil, old, party.IDSlice{1, 2, 3}, newParties, 1)
+	assert.Error(t, err, "not taking part")
+	_, _, err = ReshareInit(5, secrets[1], old, party.IDSlice{1, 2, 3}, newParties, 1)
+	assert.Error(t, err, "only dealers pass a share")
+	_, _, err = ReshareInit(1, secrets[2], old, party.IDSlice{1, 2, 3}, newParties, 1)
+	assert.Error(t, err, "share of another party")
+}
+
+func TestReshare_Cheating(t *testing.T) {
+	old, secrets := generateKeys(t, 3, 1)
+	dealers := party.IDSlice{1, 2}
+	newParties := party.IDSlice{2, 3, 4}
+
+	msg1, _, err := ReshareInit(1, secrets[1], old, dealers, newParties, 1)
+	require.NoError(t, err)
+	msg2, state2, err := ReshareInit(2, secrets[2], old, dealers, newParties, 1)
+	require.NoError(t, err)
+	_, state4, err := ReshareInit(4, nil, old, dealers, newParties, 1)
+	require.NoError(t, err)
+
+	data, err := msg1.MarshalJSON()
+	require.NoError(t, err)
+	msg1 = &Message{}
+	require.NoError(t, msg1.UnmarshalJSON(data))
+	assert.Equal(t, MessageTypeReshare1, msg1.Type)
+
+	// a dealer sharing another secret is detected in round 1
+	forged := NewReshare1(1, polynomial.NewPolynomialExponent(polynomial.NewPolynomial(1, scalar.NewScalarRandom())))
+	_, _, err = ReshareRound1(state4, []*Message{forged, msg2})
+	assert.Error(t, err)
+
+	_, state4, err = ReshareInit(4, nil, old, dealers, newParties, 1)
+	require.NoError(t, err)
+	_, _, err = ReshareRound1(state4, []*Message{msg1})
+	assert.Error(t, err, "all dealers must deal")
+	_, _, err = ReshareRound1(state4, []*Message{msg2})
+	require.NoError(t, err)
+
+	// a share that does not match the commitments is detected in round 2
+	out, _, err := ReshareRound1(state2, []*Message{msg1})
+	require.NoError(t, err)
+	var to4 *Message
+	for _, msg := range out {
+		if msg.To == 4 {
+			to4 = msg
+		}
+	}
+	require.NotNil(t, to4)
+	_, _, err = ReshareRound2(state4, []*Message{NewReshare2(1, 4, scalar.NewScalarRandom()), to4})
+	assert.Error(t, err)
+}
+
+func TestReshare_Session(t *testing.T) {
+	old, secrets := generateKeys(t, 3, 1)
+	dealers := party.IDSlice{1, 2}
+	newParties := party.IDSlice{2, 3}
+	session := SessionID{1, 2, 3}
+
+	msg1, state1, err := ReshareInitWithSession(session, 1, secrets[1], old, dealers, newParties, 1)
+	require.NoError(t, err)
+	assert.Equal(t, session, msg1.SessionID)
+	msg2, _, err := ReshareInitWithSession(session, 2, secrets[2], old, dealers, newParties, 1)
+	require.NoError(t, err)
+	_, state3, err := ReshareInitWithSession(session, 3, nil, old, dealers, newParties, 1)
+	require.NoError(t, err)
+
+	// the state keeps the session across serialization
+	data, err := json.Marshal(state3)
+	require.NoError(t, err)
+	var decoded ReshareState
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, session, decoded.SessionID)
+
+	// a message of another session is rejected
+	other := *msg2
+	other.SessionID = SessionID{}
+	_, _, err = ReshareRound1(state3, []*Message{msg1, &other})
+	assert.Error(t, err)
+	_, state3, err = ReshareInitWithSession(session, 3, nil, old, dealers, newParties, 1)
+	require.NoError(t, err)
+	_, _, err = ReshareRound1(state3, []*Message{msg1, msg2})
+	require.NoError(t, err)
+
+	// the dealer's polynomial is erased once the shares were dealt
+	out, _, err := ReshareRound1(state1, []*Message{msg2})
+	require.NoError(t, err)
+	assert.Nil(t, state1.Polynomial)
+	_, _, err = ReshareRound1(state1, []*Message{msg2})
+	assert.Error(t, err)
+	for _, msg := range out {
+		assert.Equal(t, session, msg.SessionID)
+	}
+}
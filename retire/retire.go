@@ -0,0 +1,275 @@
+// Package retire manages the end of life of a group key.
+//
+// A key is retired with a governance change of kind retire, approved by a
+// quorum of the group. The approval is the signed retirement attestation: it
+// is kept as a Tombstone by every participant and coordinator, which then
+// refuse to sign with the key, and it proves to anyone holding the group key
+// that the retirement was authorized. A tombstone can schedule the deletion of
+// local share files after a grace period, during which the shares remain
+// available, e.g. to verify old backups.
+package retire
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/governance"
+	"github.com/bartke/frost/manifest"
+	"github.com/bartke/frost/party"
+)
+
+// ErrRetired is returned when a retired key is used.
+var ErrRetired = errors.New("retire: the key is retired")
+
+// Tombstone records the retirement of a group key.
+type Tombstone struct {
+	// Group is the fingerprint of the group key, see manifest.Fingerprint.
+	Group string `json:"group"`
+	// Approval is the retire change signed by the group.
+	Approval *governance.Approval `json:"approval"`
+	// RetiredAt is the time the retirement was recorded locally.
+	RetiredAt time.Time `json:"retired_at"`
+
+	// Shares are local share files to delete once DeleteAfter has passed.
+	// A zero DeleteAfter keeps them.
+	Shares      []string  `json:"shares,omitempty"`
+	DeleteAfter time.Time `json:"delete_after,omitempty"`
+	// Deleted is set once the share files were deleted.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// New returns the tombstone of the group key pub for the approval a, which must
+// approve a retire change of that group and not be expired at now.
+func New(pub ed25519.PublicKey, a *governance.Approval, now time.Time) (*Tombstone, error) {
+	c, err := a.Verify(pub, now)
+	if err != nil {
+		return nil, err
+	}
+	if c.Kind != governance.KindRetire {
+		return nil, fmt.Errorf("retire: approval is for a %s change", c.Kind)
+	}
+	return &Tombstone{Group: c.Group, Approval: a, RetiredAt: now.UTC()}, nil
+}
+
+// Schedule marks the share files at paths for deletion once grace has passed after the retirement.
+func (t *Tombstone) Schedule(paths []string, grace time.Duration) {
+	t.Shares = append(t.Shares, paths...)
+	t.DeleteAfter = t.RetiredAt.Add(grace)
+}
+
+// Verify checks that the tombstone holds a retirement of pub approved by the group.
+// The approval may have expired since it was recorded.
+func (t *Tombstone) Verify(pub ed25519.PublicKey) error {
+	if t.Approval == nil {
+		return errors.New("retire: tombstone without approval")
+	}
+	c, err := t.Approval.Verify(pub, time.Time{})
+	if err != nil {
+		return err
+	}
+	if c.Kind != governance.KindRetire || c.Group != t.Group {
+		return errors.New("retire: tombstone does not hold a retirement of the group")
+	}
+	return nil
+}
+
+// Store keeps the tombstones of retired keys in a directory, one file per group.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// OpenStore opens the store in dir, creating the directory if needed.
+func OpenStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("retire: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) path(group string) string {
+	return filepath.Join(s.dir, group+".tombstone")
+}
+
+// Record stores t. A key stays retired, recording it again only updates the
+// scheduled deletion.
+func (s *Store) Record(t *Tombstone) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(t)
+}
+
+// write replaces the tombstone file atomically. s.mu must be held.
+func (s *Store) write(t *Tombstone) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path(t.Group) + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("retire: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("retire: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("retire: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("retire: %w", err)
+	}
+	if err := os.Rename(tmp, s.path(t.Group)); err != nil {
+		return fmt.Errorf("retire: %w", err)
+	}
+	return nil
+}
+
+// Lookup returns the verified tombstone of pub, and false if the key is not retired.
+func (s *Store) Lookup(pub ed25519.PublicKey) (*Tombstone, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.read(manifest.Fingerprint(pub))
+	if err != nil || t == nil {
+		return nil, false, err
+	}
+	if err := t.Verify(pub); err != nil {
+		return nil, false, err
+	}
+	return t, true, nil
+}
+
+func (s *Store) read(group string) (*Tombstone, error) {
+	data, err := os.ReadFile(s.path(group))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("retire: %w", err)
+	}
+	var t Tombstone
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("retire: corrupt tombstone: %w", err)
+	}
+	return &t, nil
+}
+
+// Check returns ErrRetired if pub is retired. Tombstones that do not verify are
+// reported as errors too, so that a damaged store fails closed.
+func (s *Store) Check(pub ed25519.PublicKey) error {
+	t, ok, err := s.Lookup(pub)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return fmt.Errorf("%w since %s", ErrRetired, t.RetiredAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Tombstones returns all tombstones in the store, sorted by group. They are not verified.
+func (s *Store) Tombstones() ([]*Tombstone, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matches, err := filepath.Glob(filepath.Join(s.dir, "*.tombstone"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	out := make([]*Tombstone, 0, len(matches))
+	for _, m := range matches {
+		t, err := s.read(filepath.Base(m[:len(m)-len(".tombstone")]))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// Sweep deletes the share files of all tombstones whose grace period has passed
+// at now, and returns the deleted paths. Files that no longer exist are skipped.
+func (s *Store) Sweep(now time.Time) ([]string, error) {
+	tombstones, err := s.Tombstones()
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted []string
+	for _, t := range tombstones {
+		if t.Deleted || t.DeleteAfter.IsZero() || now.Before(t.DeleteAfter) {
+			continue
+		}
+		for _, path := range t.Shares {
+			err := Shred(path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return deleted, err
+			}
+			deleted = append(deleted, path)
+		}
+		t.Deleted = true
+		if err := s.write(t); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// Shred overwrites the file at path with random data, syncs it and removes it.
+// On copy-on-write or journaling file systems and on flash storage old copies
+// of the data may survive, so shares should be stored on encrypted volumes.
+func Shred(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err == nil {
+		_, err = io.CopyN(f, rand.Reader, info.Size())
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("retire: %w", err)
+	}
+	return os.Remove(path)
+}
+
+// SignInit checks that the group key of shares is not retired, and only then runs frost.SignInit.
+func SignInit(s *Store, signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, message []byte) (*frost.Message, *frost.SignerState, error) {
+	if err := s.Check(shares.GroupKey.ToEd25519()); err != nil {
+		return nil, nil, err
+	}
+	return frost.SignInit(signerIDs, secret, shares, message)
+}
+
+// NewAggregator checks that the group key of shares is not retired, and only
+// then runs frost.NewAggregator, so that coordinators refuse retired keys too.
+func NewAggregator(s *Store, signerIDs party.IDSlice, shares *eddsa.Public, message []byte) (*frost.Aggregator, error) {
+	if err := s.Check(shares.GroupKey.ToEd25519()); err != nil {
+		return nil, err
+	}
+	return frost.NewAggregator(signerIDs, shares, message)
+}
@@ -0,0 +1,110 @@
+package retire
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost/frostclient"
+	"github.com/bartke/frost/governance"
+	"github.com/bartke/frost/manifest"
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func approve(t *testing.T, group *frostclient.Group, c *governance.Change) *governance.Approval {
+	sig, err := frostclient.Sign(context.Background(), group, c.Body())
+	require.NoError(t, err)
+	return &governance.Approval{Body: c.Body(), Signature: sig.ToEd25519()}
+}
+
+func TestTombstone(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	pub := group.Public.GroupKey.ToEd25519()
+	fp := manifest.Fingerprint(pub)
+	now := time.Now()
+
+	peers := approve(t, group, &governance.Change{Group: fp, Sequence: 1, Kind: governance.KindSetPeers,
+		Params: map[string]string{"2": "https://b.example"}, Expires: now.Add(time.Hour)})
+	_, err = New(pub, peers, now)
+	assert.Error(t, err, "only retire changes make tombstones")
+
+	a := approve(t, group, &governance.Change{Group: fp, Sequence: 2, Kind: governance.KindRetire, Expires: now.Add(time.Hour)})
+	_, err = New(pub, a, now.Add(2*time.Hour))
+	assert.True(t, errors.Is(err, governance.ErrExpired))
+
+	ts, err := New(pub, a, now)
+	require.NoError(t, err)
+	assert.Equal(t, fp, ts.Group)
+	// the attestation stays valid after the approval expired
+	require.NoError(t, ts.Verify(pub))
+
+	other, err := frostclient.CreateGroup(frostclient.Config{N: 2, T: 1})
+	require.NoError(t, err)
+	assert.Error(t, ts.Verify(other.Public.GroupKey.ToEd25519()))
+	ts.Approval.Signature[0] ^= 1
+	assert.Error(t, ts.Verify(pub))
+}
+
+func TestStore(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	pub := group.Public.GroupKey.ToEd25519()
+	now := time.Now()
+	dir := t.TempDir()
+
+	s, err := OpenStore(filepath.Join(dir, "tombstones"))
+	require.NoError(t, err)
+	require.NoError(t, s.Check(pub))
+	message := []byte("m")
+	_, _, err = SignInit(s, party.IDSlice{1, 2}, group.Shares[1], group.Public, message)
+	require.NoError(t, err)
+
+	share := filepath.Join(dir, "secret1.dat")
+	require.NoError(t, os.WriteFile(share, []byte("secret share"), 0600))
+
+	a := approve(t, group, &governance.Change{Group: manifest.Fingerprint(pub), Sequence: 1,
+		Kind: governance.KindRetire, Expires: now.Add(time.Hour)})
+	ts, err := New(pub, a, now)
+	require.NoError(t, err)
+	ts.Schedule([]string{share, filepath.Join(dir, "missing.dat")}, 24*time.Hour)
+	require.NoError(t, s.Record(ts))
+
+	// participants and coordinators refuse the key
+	assert.True(t, errors.Is(s.Check(pub), ErrRetired))
+	_, _, err = SignInit(s, party.IDSlice{1, 2}, group.Shares[1], group.Public, message)
+	assert.True(t, errors.Is(err, ErrRetired))
+	_, err = NewAggregator(s, party.IDSlice{1, 2}, group.Public, message)
+	assert.True(t, errors.Is(err, ErrRetired))
+
+	// shares are kept during the grace period
+	deleted, err := s.Sweep(now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+	assert.FileExists(t, share)
+
+	deleted, err = s.Sweep(now.Add(25 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []string{share}, deleted)
+	assert.NoFileExists(t, share)
+
+	// the tombstone survives a restart
+	s, err = OpenStore(filepath.Join(dir, "tombstones"))
+	require.NoError(t, err)
+	got, ok, err := s.Lookup(pub)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.True(t, got.Deleted)
+
+	// a damaged tombstone fails closed
+	path := filepath.Join(dir, "tombstones", ts.Group+".tombstone")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+	err = s.Check(pub)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrRetired))
+}
@@ -0,0 +1,335 @@
+// Package retry runs signing attempts under a declarative abort-and-retry policy.
+//
+// An orchestrator describes the parties that may sign and a function running
+// one attempt with a given set of signers. Each failed attempt is classified
+// as an Abort: a timeout, unreachable parties, invalid shares, a policy denial,
+// or anything else. The Policy decides per class whether to try again, after
+// which delay, and whether the parties blamed for the abort are replaced by
+// other candidates, so that applications do not have to write retry loops
+// around the round functions.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/policy"
+	"github.com/bartke/frost/router"
+)
+
+// Class is the kind of failure that aborted an attempt.
+type Class int
+
+const (
+	// Other is any failure that is not classified otherwise. It is not retried by default.
+	Other Class = iota
+	// Timeout means the attempt did not complete in time, e.g. because a signer is slow or gone.
+	Timeout
+	// Unreachable means messages could not be delivered to some signers.
+	Unreachable
+	// InvalidShare means a signer sent an invalid commitment or signature share.
+	InvalidShare
+	// Denied means a signer refused the request, e.g. because of its policy.
+	Denied
+	// Canceled means the context of the orchestrator was canceled. It is never retried.
+	Canceled
+)
+
+func (c Class) String() string {
+	switch c {
+	case Timeout:
+		return "timeout"
+	case Unreachable:
+		return "unreachable"
+	case InvalidShare:
+		return "invalid-share"
+	case Denied:
+		return "denied"
+	case Canceled:
+		return "canceled"
+	default:
+		return "other"
+	}
+}
+
+// Abort is a classified failure of an attempt.
+type Abort struct {
+	Class Class
+	// Parties are the signers blamed for the failure, if known.
+	Parties party.IDSlice
+	Err     error
+}
+
+// NewAbort returns an Abort of class blaming parties for err.
+func NewAbort(class Class, parties party.IDSlice, err error) *Abort {
+	return &Abort{Class: class, Parties: party.NewIDSlice(parties), Err: err}
+}
+
+func (a *Abort) Error() string {
+	if len(a.Parties) > 0 {
+		return fmt.Sprintf("%s (parties %v): %v", a.Class, a.Parties, a.Err)
+	}
+	return fmt.Sprintf("%s: %v", a.Class, a.Err)
+}
+
+func (a *Abort) Unwrap() error {
+	return a.Err
+}
+
+// Classify is the default classification of errors returned by attempts.
+// Aborts are returned as they are, a *frost.AbortError is InvalidShare and blames
+// the culprit, a *router.SendError is Unreachable and blames the failed parties,
+// a *frost.TimeoutError is a timeout blaming the missing parties, other
+// protocol violations (see frost.IsProtocolViolation) are InvalidShare without
+// blame, a denial of a policy.Policy is Denied, and other context deadlines and
+// closed sessions are timeouts.
+func Classify(err error) *Abort {
+	var abort *Abort
+	if errors.As(err, &abort) {
+		return abort
+	}
+	var abortErr *frost.AbortError
+	if errors.As(err, &abortErr) {
+		return NewAbort(InvalidShare, party.IDSlice{abortErr.Culprit}, err)
+	}
+	var timeout *frost.TimeoutError
+	if errors.As(err, &timeout) && !errors.Is(err, context.Canceled) {
+		return NewAbort(Timeout, timeout.Missing, err)
+	}
+	var sendErr *router.SendError
+	if errors.As(err, &sendErr) {
+		ids := make([]party.ID, 0, len(sendErr.Failed))
+		for id := range sendErr.Failed {
+			ids = append(ids, id)
+		}
+		return NewAbort(Unreachable, ids, err)
+	}
+	switch {
+	case frost.IsProtocolViolation(err):
+		return NewAbort(InvalidShare, nil, err)
+	case errors.Is(err, policy.ErrDenied):
+		return NewAbort(Denied, nil, err)
+	case errors.Is(err, context.Canceled):
+		return NewAbort(Canceled, nil, err)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, frost.ErrSessionClosed):
+		return NewAbort(Timeout, nil, err)
+	}
+	return NewAbort(Other, nil, err)
+}
+
+// Substitution decides which signers take part in the next attempt.
+type Substitution int
+
+const (
+	// Keep retries with the same signers.
+	Keep Substitution = iota
+	// ReplaceBlamed replaces the parties blamed by the abort with candidates
+	// that were not used yet, and does not use them again.
+	ReplaceBlamed
+	// Rotate moves on to the next candidates, regardless of blame.
+	Rotate
+)
+
+// Rule is how aborts of one class are handled.
+type Rule struct {
+	Retry        bool
+	Substitution Substitution
+}
+
+// Policy configures the retries of an orchestrator.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// Backoff is the delay between attempts. Its MaxAttempts is ignored.
+	Backoff router.Backoff
+	// Rules maps classes of aborts to how they are handled. Classes without a
+	// rule, and Canceled, end the run.
+	Rules map[Class]Rule
+	// Classify classifies the errors of attempts, the package level Classify is used if it is nil.
+	Classify func(error) *Abort
+	// Clock times the delays, clock.Real is used if it is nil.
+	Clock clock.Clock
+}
+
+// DefaultPolicy makes up to three attempts. Timeouts, unreachable parties and
+// invalid shares are retried without the blamed parties; denials and other
+// failures are final.
+var DefaultPolicy = Policy{
+	MaxAttempts: 3,
+	Backoff: router.Backoff{
+		Initial:    500 * time.Millisecond,
+		Max:        5 * time.Second,
+		Multiplier: 2,
+		Jitter:     0.2,
+	},
+	Rules: map[Class]Rule{
+		Timeout:      {Retry: true, Substitution: ReplaceBlamed},
+		Unreachable:  {Retry: true, Substitution: ReplaceBlamed},
+		InvalidShare: {Retry: true, Substitution: ReplaceBlamed},
+	},
+}
+
+// Quorum describes the parties an orchestrator may choose signers from.
+type Quorum struct {
+	// Candidates are the parties that may sign, in order of preference.
+	Candidates party.IDSlice
+	// Required take part in every attempt, e.g. the party running the orchestrator.
+	// An attempt that blames them is not retried with substitution.
+	Required party.IDSlice
+	// Size is the number of signers of an attempt, at least the threshold plus one.
+	Size int
+}
+
+// Attempt runs one signing attempt with signers.
+type Attempt func(ctx context.Context, signers party.IDSlice) error
+
+// Error is returned by Run when no attempt succeeded.
+type Error struct {
+	// Aborts holds the classified failure of every attempt, in order.
+	Aborts []*Abort
+	// Stopped is set if the policy allowed another attempt, but it could not be
+	// made, e.g. because no candidates were left or the context was canceled.
+	Stopped error
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("retry: %d attempts failed, last: %v", len(e.Aborts), e.Aborts[len(e.Aborts)-1])
+	if e.Stopped != nil {
+		msg += "; " + e.Stopped.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the last abort.
+func (e *Error) Unwrap() error {
+	return e.Aborts[len(e.Aborts)-1]
+}
+
+// Run calls attempt until it succeeds or the policy gives up. The first attempt
+// uses the required parties and the first candidates.
+func (p Policy) Run(ctx context.Context, q Quorum, attempt Attempt) error {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+	classify := p.Classify
+	if classify == nil {
+		classify = Classify
+	}
+	c := clock.OrReal(p.Clock)
+	rng := rand.New(rand.NewSource(c.Now().UnixNano()))
+
+	q.Required = party.NewIDSlice(q.Required)
+	s := &selector{quorum: q, excluded: make(map[party.ID]bool)}
+	signers, err := s.next(nil, Keep)
+	if err != nil {
+		return err
+	}
+
+	var aborts []*Abort
+	for n := 1; ; n++ {
+		err := attempt(ctx, signers)
+		if err == nil {
+			return nil
+		}
+		abort := classify(err)
+		if ctx.Err() != nil {
+			abort = NewAbort(Canceled, nil, err)
+		}
+		aborts = append(aborts, abort)
+
+		rule, ok := p.Rules[abort.Class]
+		if !ok || !rule.Retry || abort.Class == Canceled || n >= p.MaxAttempts {
+			return &Error{Aborts: aborts}
+		}
+		if signers, err = s.next(abort.Parties, rule.Substitution); err != nil {
+			return &Error{Aborts: aborts, Stopped: err}
+		}
+		if err := clock.Sleep(ctx, c, p.Backoff.Delay(n, rng)); err != nil {
+			return &Error{Aborts: aborts, Stopped: err}
+		}
+	}
+}
+
+// selector chooses the signers of successive attempts.
+type selector struct {
+	quorum   Quorum
+	excluded map[party.ID]bool
+	current  party.IDSlice
+	// offset is the position in the optional candidates used by Rotate.
+	offset int
+}
+
+// optional returns the candidates that are not required and not excluded.
+func (s *selector) optional() []party.ID {
+	var ids []party.ID
+	for _, id := range s.quorum.Candidates {
+		if !s.quorum.Required.Contains(id) && !s.excluded[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (s *selector) next(blamed party.IDSlice, sub Substitution) (party.IDSlice, error) {
+	need := s.quorum.Size - len(s.quorum.Required)
+	if need < 0 {
+		return nil, errors.New("retry: more required parties than signers")
+	}
+	switch sub {
+	case Keep:
+		if s.current != nil {
+			return s.current, nil
+		}
+	case ReplaceBlamed:
+		for _, id := range blamed {
+			if s.quorum.Required.Contains(id) {
+				return nil, fmt.Errorf("retry: required party %d was blamed", id)
+			}
+			s.excluded[id] = true
+		}
+		if len(blamed) == 0 && s.current != nil {
+			return s.current, nil
+		}
+	case Rotate:
+		s.offset += need
+	}
+
+	optional := s.optional()
+	if len(optional) < need {
+		return nil, fmt.Errorf("retry: %d candidates left for %d signers", len(optional)+len(s.quorum.Required), s.quorum.Size)
+	}
+	ids := append([]party.ID(nil), s.quorum.Required...)
+	if sub == ReplaceBlamed && s.current != nil {
+		// keep the signers that were not blamed, and fill up with unused candidates
+		used := make(map[party.ID]bool)
+		for _, id := range s.current {
+			if !s.quorum.Required.Contains(id) && !s.excluded[id] {
+				ids = append(ids, id)
+			}
+			used[id] = true
+		}
+		for _, id := range optional {
+			if len(ids) == s.quorum.Size {
+				break
+			}
+			if !used[id] {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) < s.quorum.Size {
+			return nil, fmt.Errorf("retry: no unused candidates left for %d signers", s.quorum.Size)
+		}
+	} else {
+		for i := 0; i < need; i++ {
+			ids = append(ids, optional[(s.offset+i)%len(optional)])
+		}
+	}
+	s.current = party.NewIDSlice(ids)
+	return s.current, nil
+}
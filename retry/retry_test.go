@@ -0,0 +1,173 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/frostclient"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/policy"
+	"github.com/bartke/frost/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// immediate retries without delays.
+func immediate(rules map[Class]Rule) Policy {
+	return Policy{MaxAttempts: 4, Rules: rules}
+}
+
+func TestClassify(t *testing.T) {
+	a := Classify(fmt.Errorf("round 1: %w", &router.SendError{Failed: map[party.ID]error{3: errors.New("refused"), 2: errors.New("refused")}}))
+	assert.Equal(t, Unreachable, a.Class)
+	assert.Equal(t, party.IDSlice{2, 3}, a.Parties)
+
+	assert.Equal(t, Timeout, Classify(context.DeadlineExceeded).Class)
+	assert.Equal(t, Canceled, Classify(fmt.Errorf("x: %w", context.Canceled)).Class)
+	assert.Equal(t, Other, Classify(errors.New("boom")).Class)
+
+	invalid := Classify(fmt.Errorf("aggregate: %w", &frost.AbortError{Culprit: 5}))
+	assert.Equal(t, InvalidShare, invalid.Class)
+	assert.Equal(t, party.IDSlice{5}, invalid.Parties)
+	violation := Classify(fmt.Errorf("SignRound1: %w", &frost.ErrUnknownParty{ID: 7}))
+	assert.Equal(t, InvalidShare, violation.Class)
+	assert.Empty(t, violation.Parties)
+
+	timeout := Classify(&frost.TimeoutError{Round: frost.MessageTypeSign1, Missing: party.IDSlice{3}, Err: context.DeadlineExceeded})
+	assert.Equal(t, Timeout, timeout.Class)
+	assert.Equal(t, party.IDSlice{3}, timeout.Parties)
+	assert.Equal(t, Canceled, Classify(&frost.TimeoutError{Err: context.Canceled}).Class)
+
+	denied := NewAbort(Denied, party.IDSlice{4}, errors.New("policy"))
+	assert.Equal(t, denied, Classify(fmt.Errorf("party 4: %w", denied)))
+	assert.Equal(t, Denied, Classify(fmt.Errorf("coordinator: %w", policy.Deny("outside of the allowed times"))).Class)
+}
+
+func TestRun_ReplaceBlamed(t *testing.T) {
+	q := Quorum{Candidates: party.IDSlice{1, 2, 3, 4, 5}, Required: party.IDSlice{1}, Size: 3}
+	var attempts []party.IDSlice
+	err := immediate(DefaultPolicy.Rules).Run(context.Background(), q, func(ctx context.Context, signers party.IDSlice) error {
+		attempts = append(attempts, signers)
+		switch len(attempts) {
+		case 1:
+			return NewAbort(Unreachable, party.IDSlice{3}, errors.New("offline"))
+		case 2:
+			return NewAbort(InvalidShare, party.IDSlice{2}, errors.New("bad share"))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []party.IDSlice{{1, 2, 3}, {1, 2, 4}, {1, 4, 5}}, attempts)
+}
+
+func TestRun_Final(t *testing.T) {
+	q := Quorum{Candidates: party.IDSlice{1, 2, 3}, Size: 2}
+	n := 0
+	err := immediate(DefaultPolicy.Rules).Run(context.Background(), q, func(ctx context.Context, signers party.IDSlice) error {
+		n++
+		return NewAbort(Denied, party.IDSlice{2}, errors.New("not covered"))
+	})
+	assert.Equal(t, 1, n, "denials are not retried")
+	var retryErr *Error
+	require.True(t, errors.As(err, &retryErr))
+	require.Len(t, retryErr.Aborts, 1)
+	assert.Equal(t, Denied, retryErr.Aborts[0].Class)
+
+	// blamed parties are not used again, until no candidates are left
+	n = 0
+	err = immediate(DefaultPolicy.Rules).Run(context.Background(), q, func(ctx context.Context, signers party.IDSlice) error {
+		n++
+		return NewAbort(Unreachable, signers[1:], errors.New("offline"))
+	})
+	assert.Equal(t, 2, n)
+	require.True(t, errors.As(err, &retryErr))
+	assert.Error(t, retryErr.Stopped)
+
+	// a blamed required party ends the run
+	q.Required = party.IDSlice{1}
+	err = immediate(DefaultPolicy.Rules).Run(context.Background(), q, func(ctx context.Context, signers party.IDSlice) error {
+		return NewAbort(InvalidShare, party.IDSlice{1}, errors.New("bad share"))
+	})
+	require.True(t, errors.As(err, &retryErr))
+	assert.Len(t, retryErr.Aborts, 1)
+}
+
+func TestRun_KeepAndRotate(t *testing.T) {
+	q := Quorum{Candidates: party.IDSlice{1, 2, 3, 4}, Size: 2}
+	var attempts []party.IDSlice
+	p := immediate(map[Class]Rule{Timeout: {Retry: true, Substitution: Keep}})
+	p.MaxAttempts = 2
+	err := p.Run(context.Background(), q, func(ctx context.Context, signers party.IDSlice) error {
+		attempts = append(attempts, signers)
+		return context.DeadlineExceeded
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []party.IDSlice{{1, 2}, {1, 2}}, attempts)
+
+	attempts = nil
+	p = immediate(map[Class]Rule{Other: {Retry: true, Substitution: Rotate}})
+	p.MaxAttempts = 3
+	err = p.Run(context.Background(), q, func(ctx context.Context, signers party.IDSlice) error {
+		attempts = append(attempts, signers)
+		return errors.New("boom")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []party.IDSlice{{1, 2}, {3, 4}, {1, 2}}, attempts)
+}
+
+func TestRun_Backoff(t *testing.T) {
+	c := clock.NewFake(time.Unix(0, 0))
+	p := DefaultPolicy
+	p.Clock = c
+	p.Backoff.Jitter = 0
+
+	done := make(chan error)
+	n := 0
+	go func() {
+		done <- p.Run(context.Background(), Quorum{Candidates: party.IDSlice{1, 2, 3}, Size: 2}, func(ctx context.Context, signers party.IDSlice) error {
+			n++
+			if n == 1 {
+				return context.DeadlineExceeded
+			}
+			return nil
+		})
+	}()
+	c.BlockUntil(1)
+	c.Advance(499 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("retried before the backoff passed")
+	default:
+	}
+	c.Advance(time.Millisecond)
+	require.NoError(t, <-done)
+	assert.Equal(t, 2, n)
+}
+
+func TestRun_Sign(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 4, T: 1})
+	require.NoError(t, err)
+	offline := party.ID(2)
+
+	message := []byte("retry")
+	err = immediate(DefaultPolicy.Rules).Run(context.Background(), Quorum{Candidates: group.Public.PartyIDs, Size: 2},
+		func(ctx context.Context, signers party.IDSlice) error {
+			if signers.Contains(offline) {
+				return &router.SendError{Failed: map[party.ID]error{offline: errors.New("connection refused")}}
+			}
+			g := *group
+			g.Signers = signers
+			sig, err := frostclient.Sign(ctx, &g, message)
+			if err != nil {
+				return err
+			}
+			assert.True(t, group.Public.GroupKey.Verify(message, sig))
+			return nil
+		})
+	require.NoError(t, err)
+}
@@ -0,0 +1,297 @@
+package frost
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+)
+
+// RFC 9591 defines FROST(Ed25519, SHA-512), which produces the same Ed25519
+// signatures as this package, but derives the binding factors differently.
+// Signers and aggregators in RFC 9591 mode exchange commitment lists in the
+// encoding of the RFC and compute the binding factors and the group commitment
+// like other conforming implementations, so they can take part in their sessions.
+const rfc9591Context = "FROST-ED25519-SHA512-v1"
+
+// commitmentSize is the size of an encoded (identifier, hiding, binding) entry.
+const commitmentSize = 3 * 32
+
+// rfc9591Hash is SHA-512(contextString ∥ tag ∥ data...), which is H1, H4 and H5 of the RFC.
+func rfc9591Hash(tag string, data ...[]byte) []byte {
+	h := sha512.New()
+	h.Write([]byte(rfc9591Context))
+	h.Write([]byte(tag))
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// Commitment is the pair of nonce commitments of a signer, as it appears in an RFC 9591 commitment list.
+type Commitment struct {
+	ID party.ID
+	// Hiding is the commitment to the hiding nonce, Di.
+	Hiding ristretto.Element
+	// Binding is the commitment to the binding nonce, Ei.
+	Binding ristretto.Element
+}
+
+// CommitmentList holds the commitments of all signers of a session, sorted by ID.
+type CommitmentList []Commitment
+
+// NewCommitmentList returns the commitment list of the Sign1 messages msgs.
+func NewCommitmentList(msgs []*Message) (CommitmentList, error) {
+	l := make(CommitmentList, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.Type != MessageTypeSign1 || msg.Sign1 == nil {
+			return nil, errors.New("CommitmentList: invalid message type")
+		}
+		l = append(l, Commitment{ID: msg.From, Hiding: msg.Sign1.Di, Binding: msg.Sign1.Ei})
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].ID < l[j].ID })
+	if err := l.validate(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// validate checks that the IDs are valid and strictly increasing, and that no commitment is the identity.
+func (l CommitmentList) validate() error {
+	if len(l) == 0 {
+		return errors.New("CommitmentList: empty list")
+	}
+	for i, c := range l {
+		if c.ID == 0 {
+			return errors.New("CommitmentList: id 0 is not valid")
+		}
+		if i > 0 && c.ID <= l[i-1].ID {
+			return fmt.Errorf("CommitmentList: party %d is duplicate or out of order", c.ID)
+		}
+		if c.Hiding.Equal(ristretto.NewIdentityElement()) == 1 || c.Binding.Equal(ristretto.NewIdentityElement()) == 1 {
+			return fmt.Errorf("CommitmentList: commitment Ei or Di of party %d was the identity: %w", c.ID, ErrInvalidMessage)
+		}
+	}
+	return nil
+}
+
+// IDs returns the IDs of the signers in the list.
+func (l CommitmentList) IDs() party.IDSlice {
+	ids := make(party.IDSlice, len(l))
+	for i, c := range l {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+// Messages returns the Sign1 messages of the commitments, for signers and
+// aggregators of this package.
+func (l CommitmentList) Messages() []*Message {
+	return l.messages(SessionID{})
+}
+
+// messages returns the Sign1 messages of the commitments in session.
+func (l CommitmentList) messages(session SessionID) []*Message {
+	msgs := make([]*Message, len(l))
+	for i := range l {
+		msgs[i] = NewSign1(l[i].ID, &l[i].Hiding, &l[i].Binding)
+		msgs[i].SessionID = session
+	}
+	return msgs
+}
+
+// MarshalBinary returns encode_group_commitment_list of RFC 9591: for every
+// signer the identifier as a 32 byte little-endian scalar, followed by the
+// hiding and binding commitments as Ed25519 points.
+func (l CommitmentList) MarshalBinary() ([]byte, error) {
+	if err := l.validate(); err != nil {
+		return nil, err
+	}
+	return l.encode(), nil
+}
+
+func (l CommitmentList) encode() []byte {
+	out := make([]byte, 0, len(l)*commitmentSize)
+	for i := range l {
+		out = append(out, l[i].ID.Scalar().Bytes()...)
+		out = append(out, l[i].Hiding.BytesEd25519()...)
+		out = append(out, l[i].Binding.BytesEd25519()...)
+	}
+	return out
+}
+
+// UnmarshalBinary decodes a list encoded by MarshalBinary or another RFC 9591 implementation.
+// Identifiers must fit a party.ID, and commitments must be canonical encodings of
+// points in the prime order subgroup.
+func (l *CommitmentList) UnmarshalBinary(data []byte) error {
+	if len(data)%commitmentSize != 0 {
+		return errors.New("CommitmentList: invalid length")
+	}
+	list := make(CommitmentList, len(data)/commitmentSize)
+	for i := range list {
+		entry := data[i*commitmentSize : (i+1)*commitmentSize]
+		id, err := idFromScalarBytes(entry[:32])
+		if err != nil {
+			return err
+		}
+		list[i].ID = id
+		if err := setPrimeOrderPoint(&list[i].Hiding, entry[32:64]); err != nil {
+			return fmt.Errorf("CommitmentList: hiding commitment of party %d: %w", id, err)
+		}
+		if err := setPrimeOrderPoint(&list[i].Binding, entry[64:96]); err != nil {
+			return fmt.Errorf("CommitmentList: binding commitment of party %d: %w", id, err)
+		}
+	}
+	if err := list.validate(); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// idFromScalarBytes decodes an RFC 9591 identifier.
+func idFromScalarBytes(b []byte) (party.ID, error) {
+	for _, x := range b[2:] {
+		if x != 0 {
+			return 0, errors.New("CommitmentList: identifier does not fit a party.ID")
+		}
+	}
+	return party.ID(b[0]) | party.ID(b[1])<<8, nil
+}
+
+// setPrimeOrderPoint decodes an Ed25519 point, rejecting non-canonical encodings
+// and points with a torsion component, like DeserializeElement of the RFC.
+func setPrimeOrderPoint(e *ristretto.Element, b []byte) error {
+	if _, err := e.SetBytesEd25519(b); err != nil {
+		return err
+	}
+	if !bytes.Equal(e.BytesEd25519(), b) {
+		return errors.New("not a canonical point of the prime order subgroup")
+	}
+	return nil
+}
+
+// BindingFactors returns the binding factor of every signer in l, computed by
+// compute_binding_factors of RFC 9591:
+//
+//	ρᵢ = H1(groupKey ∥ H4(message) ∥ H5(encode_group_commitment_list(l)) ∥ i)
+func (l CommitmentList) BindingFactors(groupKey *eddsa.PublicKey, message []byte) (map[party.ID]*ristretto.Scalar, error) {
+	if err := l.validate(); err != nil {
+		return nil, err
+	}
+	return l.bindingFactors(groupKey, message), nil
+}
+
+func (l CommitmentList) bindingFactors(groupKey *eddsa.PublicKey, message []byte) map[party.ID]*ristretto.Scalar {
+	factors := make(map[party.ID]*ristretto.Scalar, len(l))
+	l.forEachBindingFactor(groupKey, message, func(i int, rho *ristretto.Scalar) {
+		factors[l[i].ID] = new(ristretto.Scalar).Set(rho)
+	})
+	return factors
+}
+
+// rfc9591RhoTag is the prefix of H1 for the binding factors.
+var rfc9591RhoTag = []byte(rfc9591Context + "rho")
+
+// forEachBindingFactor calls fn with the binding factor of every l[i], in
+// order. It does not allocate for each commitment; rho is only valid during
+// the call.
+func (l CommitmentList) forEachBindingFactor(groupKey *eddsa.PublicKey, message []byte, fn func(i int, rho *ristretto.Scalar)) {
+	sc := getScratch()
+	defer putScratch(sc)
+
+	// groupKey ∥ H4(message) ∥ H5(commitments) ∥ i, followed by room for the digest
+	const idOffset = 32 + 2*sha512.Size
+	buf := sc.bytes(idOffset + 32 + sha512.Size)
+	buf = append(buf, groupKey.ToEd25519()...)
+	buf = append(buf, rfc9591Hash("msg", message)...)
+	buf = append(buf, rfc9591Hash("com", l.encode())...)
+	buf = buf[:idOffset+32]
+	sc.buf = buf
+
+	h := sha512.New()
+	var rho ristretto.Scalar
+	for i, c := range l {
+		// i is encoded as a scalar
+		clear(buf[idOffset:])
+		binary.LittleEndian.PutUint16(buf[idOffset:], uint16(c.ID))
+
+		h.Reset()
+		h.Write(rfc9591RhoTag)
+		h.Write(buf)
+		_, _ = rho.SetUniformBytes(h.Sum(buf[len(buf):]))
+		fn(i, &rho)
+	}
+}
+
+// GroupCommitment returns R = ∑ Dᵢ + [ρᵢ] Eᵢ for the binding factors returned by BindingFactors.
+func (l CommitmentList) GroupCommitment(factors map[party.ID]*ristretto.Scalar) (*ristretto.Element, error) {
+	R := ristretto.NewIdentityElement()
+	for _, c := range l {
+		rho, ok := factors[c.ID]
+		if !ok {
+			return nil, fmt.Errorf("GroupCommitment: no binding factor for party %d", c.ID)
+		}
+		var Ri ristretto.Element
+		Ri.ScalarMult(rho, &c.Binding)
+		Ri.Add(&Ri, &c.Hiding)
+		R.Add(R, &Ri)
+	}
+	return R, nil
+}
+
+// computeRhosRFC9591 sets the binding factor Pi of every signer like BindingFactors.
+func computeRhosRFC9591(signerIDs party.IDSlice, signers map[party.ID]*signer, groupKey *eddsa.PublicKey, message []byte) {
+	l := make(CommitmentList, len(signerIDs))
+	for i, id := range signerIDs {
+		l[i] = Commitment{ID: id, Hiding: signers[id].Di, Binding: signers[id].Ei}
+	}
+	l.forEachBindingFactor(groupKey, message, func(i int, rho *ristretto.Scalar) {
+		signers[l[i].ID].Pi.Set(rho)
+	})
+}
+
+// SignInitRFC9591 is like SignInit, but the session derives its binding factors
+// as specified by RFC 9591 for FROST(Ed25519, SHA-512). All signers and the
+// aggregator of a session must use the same mode.
+func SignInitRFC9591(signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, message []byte) (*Message, *SignerState, error) {
+	msg, state, err := SignInit(signerIDs, secret, shares, message)
+	if err != nil {
+		return nil, nil, err
+	}
+	state.RFC9591 = true
+	return msg, state, nil
+}
+
+// Commitment returns the commitment of the signer, to be sent to a coordinator
+// that collects commitment lists.
+func (state *SignerState) Commitment() Commitment {
+	self := state.Signers[state.SelfID]
+	return Commitment{ID: state.SelfID, Hiding: self.Di, Binding: self.Ei}
+}
+
+// SignRound1WithCommitments is like SignRound1, but takes the commitment list
+// chosen by a coordinator. The list must contain exactly the signers of the
+// session, and our own commitment unchanged. The returned Sign2 message holds
+// the signature share, which RFC 9591 encodes as Sign2.Zi.Bytes().
+func SignRound1WithCommitments(state *SignerState, l CommitmentList) (*Message, *SignerState, error) {
+	if err := l.validate(); err != nil {
+		return nil, nil, fmt.Errorf("SignRound1: %w", err)
+	}
+	if !l.IDs().Equal(state.SignerIDs) {
+		return nil, nil, fmt.Errorf("SignRound1: commitment list for %v, expected %v", l.IDs(), state.SignerIDs)
+	}
+	own := state.Commitment()
+	for _, c := range l {
+		if c.ID == state.SelfID && (c.Hiding.Equal(&own.Hiding) != 1 || c.Binding.Equal(&own.Binding) != 1) {
+			return nil, nil, errors.New("SignRound1: commitment list does not contain our commitment")
+		}
+	}
+	return SignRound1(state, l.messages(state.SessionID))
+}
@@ -0,0 +1,174 @@
+package frost
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hexScalar(t *testing.T, s string) *ristretto.Scalar {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	var x ristretto.Scalar
+	_, err = x.SetCanonicalBytes(b)
+	require.NoError(t, err)
+	return &x
+}
+
+func hexPoint(t *testing.T, s string) *ristretto.Element {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	var e ristretto.Element
+	require.NoError(t, setPrimeOrderPoint(&e, b))
+	return &e
+}
+
+// TestRFC9591Vector checks participant 1 of the FROST(Ed25519, SHA-512) test
+// vector in Appendix E.1 of RFC 9591, signing "test" with participants 1 and 3.
+func TestRFC9591Vector(t *testing.T) {
+	shares := map[party.ID]*ristretto.Scalar{
+		1: hexScalar(t, "929dcc590407aae7d388761cddb0c0db6f5627aea8e217f4a033f2ec83d93509"),
+		2: hexScalar(t, "a91e66e012e4364ac9aaa405fcafd370402d9859f7b6685c07eed76bf409e80d"),
+		3: hexScalar(t, "d3cb090a075eb154e82fdb4b3cb507f110040905468bb9c46da8bdea643a9a02"),
+	}
+	publicShares := make(map[party.ID]*ristretto.Element)
+	for id, s := range shares {
+		publicShares[id] = new(ristretto.Element).ScalarBaseMult(s)
+	}
+	public, err := eddsa.NewPublic(publicShares, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "15d21ccd7ee42959562fc8aa63224c8851fb3ec85a3faf66040d380fb9738673", hex.EncodeToString(public.GroupKey.ToEd25519()))
+
+	message := []byte("test")
+	signers := party.IDSlice{1, 3}
+	_, state, err := SignInitRFC9591(signers, eddsa.NewSecretShare(1, shares[1]), public, message)
+	require.NoError(t, err)
+
+	// use the nonces of the vector
+	state.D.Set(hexScalar(t, "812d6104142944d5a55924de6d49940956206909f2acaeedecda2b726e630407"))
+	state.E.Set(hexScalar(t, "b1110165fc2334149750b28dd813a39244f315cff14d4e89e6142f262ed83301"))
+	state.Signers[1].Di.ScalarBaseMult(&state.D)
+	state.Signers[1].Ei.ScalarBaseMult(&state.E)
+
+	own := state.Commitment()
+	assert.Equal(t, "b5aa8ab305882a6fc69cbee9327e5a45e54c08af61ae77cb8207be3d2ce13de3", hex.EncodeToString(own.Hiding.BytesEd25519()))
+	assert.Equal(t, "67e98ab55aa310c3120418e5050c9cf76cf387cb20ac9e4b6fdb6f82a469f932", hex.EncodeToString(own.Binding.BytesEd25519()))
+
+	list := CommitmentList{own, {
+		ID:      3,
+		Hiding:  *hexPoint(t, "cfbdb165bd8aad6eb79deb8d287bcc0ab6658ae57fdcc98ed12c0669e90aec91"),
+		Binding: *hexPoint(t, "7487bc41a6e712eea2f2af24681b58b1cf1da278ea11fe4e8b78398965f13552"),
+	}}
+	factors, err := list.BindingFactors(public.GroupKey, message)
+	require.NoError(t, err)
+	R, err := list.GroupCommitment(factors)
+	require.NoError(t, err)
+	assert.Equal(t, "36282629c383bb820a88b71cae937d41f2f2adfcc3d02e55507e2fb9e2dd3cbe", hex.EncodeToString(R.BytesEd25519()))
+
+	msg, _, err := SignRound1WithCommitments(state, list)
+	require.NoError(t, err)
+	assert.Equal(t, "001719ab5a53ee1a12095cd088fd149702c0720ce5fd2f29dbecf24b7281b603", hex.EncodeToString(msg.Sign2.Zi.Bytes()))
+
+	// with the share of participant 3 from the vector
+	var S ristretto.Scalar
+	S.Add(&msg.Sign2.Zi, hexScalar(t, "bd86125de990acc5e1f13781d8e32c03a9bbd4c53539bbc106058bfd14326007"))
+	sig := (&eddsa.Signature{R: *R, S: S}).ToEd25519()
+	assert.Equal(t, "36282629c383bb820a88b71cae937d41f2f2adfcc3d02e55507e2fb9e2dd3cbe"+
+		"bd9d2b0844e49ae0f3fa935161e1419aab7b47d21a37ebeae1f17d4987b3160b", hex.EncodeToString(sig))
+	assert.True(t, ed25519.Verify(public.GroupKey.ToEd25519(), message, sig))
+}
+
+func TestCommitmentList_Encoding(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	var msgs []*Message
+	for _, id := range []party.ID{3, 1} {
+		msg, _, err := SignInitRFC9591(party.IDSlice{1, 3}, secrets[id], public, []byte("m"))
+		require.NoError(t, err)
+		msgs = append(msgs, msg)
+	}
+	list, err := NewCommitmentList(msgs)
+	require.NoError(t, err)
+	assert.Equal(t, party.IDSlice{1, 3}, list.IDs())
+
+	data, err := list.MarshalBinary()
+	require.NoError(t, err)
+	require.Len(t, data, 2*96)
+	assert.Equal(t, byte(1), data[0])
+	assert.Equal(t, byte(3), data[96])
+
+	var decoded CommitmentList
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	again, err := decoded.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	// out of order
+	swapped := append(append([]byte(nil), data[96:]...), data[:96]...)
+	assert.Error(t, decoded.UnmarshalBinary(swapped))
+	// identifier larger than a party.ID
+	large := append([]byte(nil), data...)
+	large[2] = 1
+	assert.Error(t, decoded.UnmarshalBinary(large))
+	// the point of order 2 is not in the prime order subgroup
+	torsion := append([]byte(nil), data...)
+	order2, _ := hex.DecodeString("ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f")
+	copy(torsion[32:], order2)
+	assert.Error(t, decoded.UnmarshalBinary(torsion))
+	assert.Error(t, decoded.UnmarshalBinary(data[:95]))
+}
+
+func TestSignRFC9591(t *testing.T) {
+	public, secrets := generateKeys(t, 5, 2)
+	signers := party.IDSlice{1, 2, 4}
+	message := []byte("interop")
+
+	agg, err := NewAggregator(signers, public, message)
+	require.NoError(t, err)
+	agg.RFC9591 = true
+
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInitRFC9591(signers, secrets[id], public, message)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	_, err = agg.AddCommitments(round1)
+	require.NoError(t, err)
+
+	list, err := NewCommitmentList(round1)
+	require.NoError(t, err)
+	var shares []*Message
+	for _, id := range signers {
+		msg, _, err := SignRound1WithCommitments(states[id], list)
+		require.NoError(t, err)
+		shares = append(shares, msg)
+	}
+	sig, err := agg.Aggregate(shares)
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(public.GroupKey.ToEd25519(), message, sig.ToEd25519()))
+
+	// signers that are not in RFC 9591 mode compute other binding factors
+	_, state, err := SignInit(signers, secrets[1], public, message)
+	require.NoError(t, err)
+	state.D, state.E = states[1].D, states[1].E
+	state.Signers[1].Di, state.Signers[1].Ei = states[1].Signers[1].Di, states[1].Signers[1].Ei
+	msg, _, err := SignRound1WithCommitments(state, list)
+	require.NoError(t, err)
+	assert.False(t, msg.Sign2.Zi.Equal(&shares[0].Sign2.Zi) == 1)
+
+	// the list must hold our own commitment
+	_, state, err = SignInitRFC9591(signers, secrets[1], public, message)
+	require.NoError(t, err)
+	_, _, err = SignRound1WithCommitments(state, list)
+	assert.Error(t, err)
+	_, _, err = SignRound1WithCommitments(states[2], list[:2])
+	assert.Error(t, err)
+}
@@ -374,7 +374,11 @@ func (e *Element) MultiScalarMult(s []*Scalar, p []*Element) *Element {
 	for i := range s {
 		points[i] = &p[i].r
 	}
-	e.r.MultiScalarMult(s, points)
+	// edwards25519.Point.MultiScalarMult adds the result to its receiver, which
+	// must be the identity. e may be one of p, so the sum is computed apart.
+	result := edwards25519.NewIdentityPoint()
+	result.MultiScalarMult(s, points)
+	e.r.Set(result)
 	return e
 }
 
@@ -393,6 +397,16 @@ func (e *Element) VarTimeMultiScalarMult(s []*Scalar, p []*Element) *Element {
 	return e
 }
 
+// DoubleScalarBaseMult sets e = a * A + b * B, where B is the canonical
+// generator, and returns e.
+func (e *Element) DoubleScalarBaseMult(a *Scalar, A *Element, b *Scalar) *Element {
+	var aA edwards25519.Point
+	aA.ScalarMult(a, &A.r)
+	e.r.ScalarBaseMult(b)
+	e.r.Add(&e.r, &aA)
+	return e
+}
+
 // VarTimeDoubleScalarBaseMult sets e = a * A + b * B, where B is the canonical
 // generator, and returns e.
 //
@@ -464,6 +478,18 @@ func (e *Element) BytesEd25519() []byte {
 	return p.Bytes()
 }
 
+// SetBytesEd25519 sets e to the edwards25519 point encoded in in, such as the
+// output of BytesEd25519 or an ed25519.PublicKey. If in is not a valid point
+// encoding, SetBytesEd25519 returns nil and an error and the receiver is unchanged.
+func (e *Element) SetBytesEd25519(in []byte) (*Element, error) {
+	var p edwards25519.Point
+	if _, err := p.SetBytes(in); err != nil {
+		return nil, errInvalidEncoding
+	}
+	e.r.Set(&p)
+	return e, nil
+}
+
 // MarshalJSON serializes the Element as a base64 encoded string.
 func (e *Element) MarshalJSON() ([]byte, error) {
 	encoded := base64.StdEncoding.EncodeToString(e.r.Bytes())
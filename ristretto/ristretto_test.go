@@ -329,3 +329,44 @@ func testConstant(t *testing.T, f *field.Element, decimal string) {
 		t.Errorf("expected %x", buf)
 	}
 }
+
+func TestDoubleScalarBaseMult(t *testing.T) {
+	for i := byte(0); i < 8; i++ {
+		var a, b Scalar
+		da, db := sha512.Sum512([]byte{'a', i}), sha512.Sum512([]byte{'b', i})
+		_, _ = a.SetUniformBytes(da[:])
+		_, _ = b.SetUniformBytes(db[:])
+		A := new(Element).ScalarBaseMult(&b)
+
+		want := new(Element).VarTimeDoubleScalarBaseMult(&a, A, &b)
+		if got := new(Element).DoubleScalarBaseMult(&a, A, &b); got.Equal(want) != 1 {
+			t.Errorf("[a]A + [b]B differs from the variable time result")
+		}
+		// the result may be the point A
+		if A.DoubleScalarBaseMult(&a, A, &b); A.Equal(want) != 1 {
+			t.Errorf("[a]A + [b]B in place differs from the variable time result")
+		}
+	}
+}
+
+func TestMultiScalarMult(t *testing.T) {
+	var scalars []*Scalar
+	var points []*Element
+	for i := byte(0); i < 4; i++ {
+		var s Scalar
+		d := sha512.Sum512([]byte{'s', i})
+		_, _ = s.SetUniformBytes(d[:])
+		scalars = append(scalars, &s)
+		points = append(points, new(Element).ScalarBaseMult(&s))
+	}
+	want := new(Element).VarTimeMultiScalarMult(scalars, points)
+
+	// the receiver is not the identity
+	got := NewGeneratorElement()
+	if got.MultiScalarMult(scalars, points); got.Equal(want) != 1 {
+		t.Errorf("MultiScalarMult differs from the variable time result")
+	}
+	if points[0].MultiScalarMult(scalars, points); points[0].Equal(want) != 1 {
+		t.Errorf("MultiScalarMult in place differs from the variable time result")
+	}
+}
@@ -0,0 +1,244 @@
+// Package router maps party IDs to transport endpoints and delivers protocol
+// messages to them, fanning out broadcasts and retrying failed sends.
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/party"
+)
+
+// ErrNoRoute is returned when a message is addressed to a party without an endpoint.
+var ErrNoRoute = errors.New("router: no route to party")
+
+// Endpoint delivers a single message to one remote party.
+type Endpoint interface {
+	Deliver(ctx context.Context, msg *frost.Message) error
+}
+
+// EndpointFunc adapts a function to the Endpoint interface.
+type EndpointFunc func(ctx context.Context, msg *frost.Message) error
+
+// Deliver calls f(ctx, msg).
+func (f EndpointFunc) Deliver(ctx context.Context, msg *frost.Message) error {
+	return f(ctx, msg)
+}
+
+// Backoff describes how failed deliveries are retried.
+//
+// The delay before attempt k (starting at 1 for the first retry) is
+// Initial • Multiplier^(k-1), capped at Max, and then randomized by ±Jitter.
+type Backoff struct {
+	// MaxAttempts is the total number of delivery attempts, including the first one.
+	MaxAttempts int
+	Initial     time.Duration
+	Max         time.Duration
+	Multiplier  float64
+	// Jitter is the fraction of the delay that is randomized, between 0 and 1.
+	Jitter float64
+}
+
+// DefaultBackoff retries five times, starting at 100ms and doubling up to 5s, with 20% jitter.
+var DefaultBackoff = Backoff{
+	MaxAttempts: 5,
+	Initial:     100 * time.Millisecond,
+	Max:         5 * time.Second,
+	Multiplier:  2,
+	Jitter:      0.2,
+}
+
+// Delay returns the randomized wait before the given retry (1-based).
+func (b Backoff) Delay(retry int, rng *rand.Rand) time.Duration {
+	d := float64(b.Initial)
+	for i := 1; i < retry; i++ {
+		d *= b.Multiplier
+		if b.Max > 0 && d >= float64(b.Max) {
+			break
+		}
+	}
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter > 0 && rng != nil {
+		d += d * b.Jitter * (2*rng.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// Config holds the optional parameters of a Router.
+type Config struct {
+	// Backoff is the retry policy, DefaultBackoff is used if MaxAttempts is 0.
+	Backoff Backoff
+
+	// OnUnreachable is called once a party could not be reached after all attempts.
+	// It is how the owner of a session learns that a peer should be considered offline.
+	OnUnreachable func(id party.ID, err error)
+
+	// Clock times the delays between attempts, clock.Real is used if it is nil.
+	Clock clock.Clock
+}
+
+// Router implements the sending half of frost.Transport over a set of per-party endpoints.
+type Router struct {
+	self party.ID
+	cfg  Config
+
+	mu          sync.RWMutex
+	endpoints   map[party.ID]Endpoint
+	unreachable map[party.ID]error
+
+	rngMu sync.Mutex
+	rng   *rand.Rand
+}
+
+// New returns a Router for the party self.
+func New(self party.ID, cfg Config) *Router {
+	if cfg.Backoff.MaxAttempts == 0 {
+		cfg.Backoff = DefaultBackoff
+	}
+	cfg.Clock = clock.OrReal(cfg.Clock)
+	return &Router{
+		self:        self,
+		cfg:         cfg,
+		endpoints:   make(map[party.ID]Endpoint),
+		unreachable: make(map[party.ID]error),
+		rng:         rand.New(rand.NewSource(cfg.Clock.Now().UnixNano())),
+	}
+}
+
+// AddRoute sets the endpoint for the party id, and clears its unreachable status.
+func (r *Router) AddRoute(id party.ID, ep Endpoint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.endpoints[id] = ep
+	delete(r.unreachable, id)
+}
+
+// RemoveRoute removes the endpoint for the party id.
+func (r *Router) RemoveRoute(id party.ID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.endpoints, id)
+}
+
+// Peers returns the IDs of all routed parties other than self.
+func (r *Router) Peers() party.IDSlice {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]party.ID, 0, len(r.endpoints))
+	for id := range r.endpoints {
+		if id != r.self {
+			ids = append(ids, id)
+		}
+	}
+	return party.NewIDSlice(ids)
+}
+
+// Unreachable returns the parties for which the last delivery failed
+// permanently. A party is reachable again once a message was delivered to it.
+func (r *Router) Unreachable() party.IDSlice {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]party.ID, 0, len(r.unreachable))
+	for id := range r.unreachable {
+		ids = append(ids, id)
+	}
+	return party.NewIDSlice(ids)
+}
+
+// SendError reports the parties a message could not be delivered to.
+type SendError struct {
+	Failed map[party.ID]error
+}
+
+func (e *SendError) Error() string {
+	ids := make([]party.ID, 0, len(e.Failed))
+	for id := range e.Failed {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return fmt.Sprintf("router: delivery failed for parties %v", ids)
+}
+
+// Send delivers msg to its recipient, or to all peers if it is a broadcast.
+// Broadcasts are delivered concurrently. A *SendError is returned if any delivery failed.
+func (r *Router) Send(ctx context.Context, msg *frost.Message) error {
+	var targets party.IDSlice
+	if msg.IsBroadcast() {
+		targets = r.Peers()
+	} else {
+		targets = party.IDSlice{msg.To}
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed = make(map[party.ID]error)
+	)
+	for _, id := range targets {
+		wg.Add(1)
+		go func(id party.ID) {
+			defer wg.Done()
+			if err := r.deliver(ctx, id, msg); err != nil {
+				mu.Lock()
+				failed[id] = err
+				mu.Unlock()
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return &SendError{Failed: failed}
+	}
+	return nil
+}
+
+// deliver sends msg to a single party, retrying according to the backoff policy.
+func (r *Router) deliver(ctx context.Context, id party.ID, msg *frost.Message) error {
+	r.mu.RLock()
+	ep, ok := r.endpoints[id]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w %d", ErrNoRoute, id)
+	}
+
+	var err error
+	for attempt := 1; attempt <= r.cfg.Backoff.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			r.rngMu.Lock()
+			delay := r.cfg.Backoff.Delay(attempt-1, r.rng)
+			r.rngMu.Unlock()
+
+			if err := clock.Sleep(ctx, r.cfg.Clock, delay); err != nil {
+				return err
+			}
+		}
+
+		if err = ep.Deliver(ctx, msg); err == nil {
+			r.mu.Lock()
+			delete(r.unreachable, id)
+			r.mu.Unlock()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	r.mu.Lock()
+	r.unreachable[id] = err
+	r.mu.Unlock()
+	if r.cfg.OnUnreachable != nil {
+		r.cfg.OnUnreachable(id, err)
+	}
+	return err
+}
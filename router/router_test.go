@@ -0,0 +1,140 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fastBackoff = Backoff{
+	MaxAttempts: 3,
+	Initial:     time.Millisecond,
+	Max:         4 * time.Millisecond,
+	Multiplier:  2,
+}
+
+func TestRouter_Broadcast(t *testing.T) {
+	var mu sync.Mutex
+	received := map[party.ID]int{}
+
+	r := New(1, Config{Backoff: fastBackoff})
+	for id := party.ID(1); id <= 4; id++ {
+		id := id
+		r.AddRoute(id, EndpointFunc(func(ctx context.Context, msg *frost.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received[id]++
+			return nil
+		}))
+	}
+
+	msg := &frost.Message{Header: frost.Header{Type: frost.MessageTypeSign1, From: 1}}
+	require.NoError(t, r.Send(context.Background(), msg))
+	assert.Equal(t, map[party.ID]int{2: 1, 3: 1, 4: 1}, received)
+}
+
+func TestRouter_Retry(t *testing.T) {
+	attempts := 0
+	r := New(1, Config{Backoff: fastBackoff})
+	r.AddRoute(2, EndpointFunc(func(ctx context.Context, msg *frost.Message) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	}))
+
+	msg := &frost.Message{Header: frost.Header{Type: frost.MessageTypeKeyGen2, From: 1, To: 2}}
+	require.NoError(t, r.Send(context.Background(), msg))
+	assert.Equal(t, 3, attempts)
+	assert.Empty(t, r.Unreachable())
+}
+
+func TestRouter_Unreachable(t *testing.T) {
+	var reported []party.ID
+	r := New(1, Config{
+		Backoff: fastBackoff,
+		OnUnreachable: func(id party.ID, err error) {
+			reported = append(reported, id)
+		},
+	})
+	down := true
+	r.AddRoute(3, EndpointFunc(func(ctx context.Context, msg *frost.Message) error {
+		if down {
+			return errors.New("down")
+		}
+		return nil
+	}))
+
+	msg := &frost.Message{Header: frost.Header{Type: frost.MessageTypeKeyGen2, From: 1, To: 3}}
+	err := r.Send(context.Background(), msg)
+	var sendErr *SendError
+	require.True(t, errors.As(err, &sendErr))
+	assert.Contains(t, sendErr.Failed, party.ID(3))
+	assert.Equal(t, []party.ID{3}, reported)
+	assert.Equal(t, party.IDSlice{3}, r.Unreachable())
+
+	// the party is reachable again once a message reaches it
+	down = false
+	require.NoError(t, r.Send(context.Background(), msg))
+	assert.Empty(t, r.Unreachable())
+
+	msg.To = 4
+	err = r.Send(context.Background(), msg)
+	require.True(t, errors.As(err, &sendErr))
+	assert.True(t, errors.Is(sendErr.Failed[4], ErrNoRoute))
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	b := Backoff{Initial: 10 * time.Millisecond, Max: 50 * time.Millisecond, Multiplier: 2}
+	assert.Equal(t, 10*time.Millisecond, b.Delay(1, nil))
+	assert.Equal(t, 20*time.Millisecond, b.Delay(2, nil))
+	assert.Equal(t, 40*time.Millisecond, b.Delay(3, nil))
+	assert.Equal(t, 50*time.Millisecond, b.Delay(10, nil))
+
+	b.Jitter = 0.5
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		d := b.Delay(1, rng)
+		assert.True(t, d >= 5*time.Millisecond && d <= 15*time.Millisecond, d)
+	}
+}
+
+func TestRouter_RetryClock(t *testing.T) {
+	c := clock.NewFake(time.Unix(0, 0))
+	var mu sync.Mutex
+	attempts := 0
+	r := New(1, Config{
+		Backoff: Backoff{MaxAttempts: 2, Initial: time.Hour, Max: time.Hour, Multiplier: 1},
+		Clock:   c,
+	})
+	r.AddRoute(2, EndpointFunc(func(ctx context.Context, msg *frost.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts < 2 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	}))
+
+	done := make(chan error)
+	go func() {
+		msg := &frost.Message{Header: frost.Header{Type: frost.MessageTypeKeyGen2, From: 1, To: 2}}
+		done <- r.Send(context.Background(), msg)
+	}()
+	// the retry waits for the clock, not for an hour
+	c.BlockUntil(1)
+	c.Advance(time.Hour)
+	require.NoError(t, <-done)
+	assert.Equal(t, 2, attempts)
+}
@@ -3,15 +3,25 @@ package scalar
 import (
 	"crypto/rand"
 	"fmt"
+	"io"
 
 	"github.com/bartke/frost/ristretto"
 )
 
 // SetScalarRandom sets s to a random ristretto.Scalar using the default randomness source from crypto/rand
 func SetScalarRandom(s *ristretto.Scalar) *ristretto.Scalar {
+	return SetScalarRandomFrom(s, rand.Reader)
+}
+
+// SetScalarRandomFrom sets s to a random ristretto.Scalar using 64 bytes read from r,
+// or crypto/rand if it is nil.
+func SetScalarRandomFrom(s *ristretto.Scalar, r io.Reader) *ristretto.Scalar {
+	if r == nil {
+		r = rand.Reader
+	}
 	bytes := make([]byte, 64)
 
-	_, err := rand.Reader.Read(bytes)
+	_, err := io.ReadFull(r, bytes)
 	if err != nil {
 		panic(fmt.Errorf("edwards25519: failed to generate random Scalar: %w", err))
 	}
@@ -0,0 +1,155 @@
+package sealed
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Supported key derivation functions.
+const (
+	KDFArgon2id = "argon2id"
+	KDFScrypt   = "scrypt"
+)
+
+// Bounds on the KDF parameters accepted when sealing or opening a container.
+// The lower bounds reject parameters that offer no meaningful protection,
+// the upper bounds prevent a crafted header from exhausting memory or CPU:
+// the header is only authenticated once the key was derived with them, so
+// Open spends up to 1 GiB and a few passes over it on any container.
+const (
+	MinArgon2Memory     = 8 * 1024    // KiB
+	MaxArgon2Memory     = 1024 * 1024 // KiB
+	MaxArgon2Iterations = 8
+	MinScryptN          = 1 << 14
+	MaxScryptN          = 1 << 20
+	MaxScryptR          = 32
+	MaxScryptP          = 4
+)
+
+// KDFParams are the parameters used to derive the encryption key from a passphrase.
+// They are stored in the header of every container, so that files sealed with
+// different parameters can always be opened.
+type KDFParams struct {
+	// Algorithm is KDFArgon2id or KDFScrypt.
+	Algorithm string `json:"kdf"`
+
+	// Memory is the Argon2id memory cost in KiB.
+	Memory uint32 `json:"memory,omitempty"`
+	// Iterations is the Argon2id time cost.
+	Iterations uint32 `json:"iterations,omitempty"`
+	// Parallelism is the Argon2id number of lanes.
+	Parallelism uint8 `json:"parallelism,omitempty"`
+
+	// N, R and P are the scrypt cost parameters.
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+}
+
+// DefaultArgon2id follows the second recommended option of RFC 9106: 64 MiB, 3 passes, 4 lanes.
+var DefaultArgon2id = KDFParams{
+	Algorithm:   KDFArgon2id,
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+}
+
+// DefaultScrypt are the interactive login parameters recommended by the scrypt paper, N=2¹⁵, r=8, p=1.
+var DefaultScrypt = KDFParams{
+	Algorithm: KDFScrypt,
+	N:         1 << 15,
+	R:         8,
+	P:         1,
+}
+
+// Validate returns an error if the parameters are unsupported or out of bounds.
+func (p KDFParams) Validate() error {
+	switch p.Algorithm {
+	case KDFArgon2id:
+		if p.Memory < MinArgon2Memory || p.Memory > MaxArgon2Memory {
+			return fmt.Errorf("sealed: argon2id memory must be between %d and %d KiB", MinArgon2Memory, MaxArgon2Memory)
+		}
+		if p.Iterations == 0 || p.Iterations > MaxArgon2Iterations {
+			return fmt.Errorf("sealed: argon2id iterations must be between 1 and %d", MaxArgon2Iterations)
+		}
+		if p.Parallelism == 0 {
+			return errors.New("sealed: argon2id parallelism must be at least 1")
+		}
+	case KDFScrypt:
+		if p.N < MinScryptN || p.N > MaxScryptN || p.N&(p.N-1) != 0 {
+			return fmt.Errorf("sealed: scrypt N must be a power of two between %d and %d", MinScryptN, MaxScryptN)
+		}
+		if p.R <= 0 || p.R > MaxScryptR {
+			return fmt.Errorf("sealed: scrypt r must be between 1 and %d", MaxScryptR)
+		}
+		if p.P <= 0 || p.P > MaxScryptP {
+			return fmt.Errorf("sealed: scrypt p must be between 1 and %d", MaxScryptP)
+		}
+		// scrypt uses 128•N•r bytes, bounded like the memory of Argon2id
+		if uint64(p.N)*uint64(p.R)/8 > MaxArgon2Memory {
+			return fmt.Errorf("sealed: scrypt N•r must be at most %d", MaxArgon2Memory*8)
+		}
+	default:
+		return fmt.Errorf("sealed: unknown kdf %q", p.Algorithm)
+	}
+	return nil
+}
+
+// deriveKey derives a key of keyLen bytes from passphrase and salt.
+func (p KDFParams) deriveKey(passphrase, salt []byte, keyLen int) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	switch p.Algorithm {
+	case KDFArgon2id:
+		return argon2.IDKey(passphrase, salt, p.Iterations, p.Memory, p.Parallelism, uint32(keyLen)), nil
+	default:
+		key, err := scrypt.Key(passphrase, salt, p.N, p.R, p.P, keyLen)
+		if err != nil {
+			return nil, fmt.Errorf("sealed: %w", err)
+		}
+		return key, nil
+	}
+}
+
+// CalibrateArgon2id returns Argon2id parameters with the given memory (KiB) and parallelism,
+// and the smallest number of iterations for which a key derivation on this machine takes
+// at least target. The result is capped at MaxArgon2Iterations.
+func CalibrateArgon2id(target time.Duration, memory uint32, parallelism uint8) (KDFParams, error) {
+	p := KDFParams{Algorithm: KDFArgon2id, Memory: memory, Iterations: 1, Parallelism: parallelism}
+	if err := p.Validate(); err != nil {
+		return KDFParams{}, err
+	}
+
+	salt := make([]byte, saltSize)
+	for ; p.Iterations < MaxArgon2Iterations; p.Iterations++ {
+		start := time.Now()
+		_, _ = p.deriveKey([]byte("calibration"), salt, keySize)
+		if time.Since(start) >= target {
+			break
+		}
+	}
+	return p, nil
+}
+
+// CalibrateScrypt returns scrypt parameters with r=8, p=1, and the smallest power of two N
+// for which a key derivation on this machine takes at least target. N is capped at MaxScryptN.
+func CalibrateScrypt(target time.Duration) (KDFParams, error) {
+	p := KDFParams{Algorithm: KDFScrypt, N: MinScryptN, R: 8, P: 1}
+
+	salt := make([]byte, saltSize)
+	for ; p.N < MaxScryptN; p.N <<= 1 {
+		start := time.Now()
+		if _, err := p.deriveKey([]byte("calibration"), salt, keySize); err != nil {
+			return KDFParams{}, err
+		}
+		if time.Since(start) >= target {
+			break
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,153 @@
+// Package sealed implements passphrase encrypted containers for secret
+// artifacts, such as secret shares and signer states.
+//
+// A container is laid out as
+//
+//	magic "FROSTSEALED" ∥ version (1 byte) ∥ header length (2 bytes, big endian) ∥ header ∥ ciphertext
+//
+// The header is a JSON object holding the KDF parameters, salt and nonce.
+// The key is derived from the passphrase with Argon2id or scrypt, and the
+// payload is encrypted with XChaCha20-Poly1305, using the whole prefix up to
+// the ciphertext as additional data, so the header cannot be altered.
+package sealed
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	magic    = "FROSTSEALED"
+	version  = 1
+	saltSize = 16
+	keySize  = chacha20poly1305.KeySize
+)
+
+// ErrDecrypt is returned by Open when the passphrase is wrong or the container was modified.
+var ErrDecrypt = errors.New("sealed: wrong passphrase or corrupted container")
+
+// Header is the unencrypted metadata of a container.
+type Header struct {
+	KDFParams
+	Cipher string `json:"cipher"`
+	Salt   []byte `json:"salt"`
+	Nonce  []byte `json:"nonce"`
+}
+
+// IsSealed returns true if data starts like a container.
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(magic))
+}
+
+// Seal encrypts plaintext under passphrase, deriving the key with params.
+func Seal(plaintext, passphrase []byte, params KDFParams) ([]byte, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	header := Header{
+		KDFParams: params,
+		Cipher:    "xchacha20poly1305",
+		Salt:      make([]byte, saltSize),
+		Nonce:     make([]byte, chacha20poly1305.NonceSizeX),
+	}
+	if _, err := rand.Read(header.Salt); err != nil {
+		return nil, fmt.Errorf("sealed: %w", err)
+	}
+	if _, err := rand.Read(header.Nonce); err != nil {
+		return nil, fmt.Errorf("sealed: %w", err)
+	}
+
+	headerBytes, err := json.Marshal(&header)
+	if err != nil {
+		return nil, err
+	}
+	if len(headerBytes) > 0xffff {
+		return nil, errors.New("sealed: header too large")
+	}
+
+	key, err := params.deriveKey(passphrase, header.Salt, keySize)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(magic)+3+len(headerBytes)+len(plaintext)+aead.Overhead())
+	out = append(out, magic...)
+	out = append(out, version)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(headerBytes)))
+	out = append(out, headerBytes...)
+	return aead.Seal(out, header.Nonce, plaintext, out), nil
+}
+
+// ReadHeader parses the header of a container without decrypting it.
+func ReadHeader(data []byte) (*Header, error) {
+	header, _, err := split(data)
+	return header, err
+}
+
+// split returns the parsed header and the length of the authenticated prefix.
+func split(data []byte) (*Header, int, error) {
+	if !IsSealed(data) {
+		return nil, 0, errors.New("sealed: not a sealed container")
+	}
+	rest := data[len(magic):]
+	if len(rest) < 3 {
+		return nil, 0, errors.New("sealed: truncated container")
+	}
+	if rest[0] != version {
+		return nil, 0, fmt.Errorf("sealed: unsupported version %d", rest[0])
+	}
+	headerLen := int(binary.BigEndian.Uint16(rest[1:3]))
+	rest = rest[3:]
+	if len(rest) < headerLen {
+		return nil, 0, errors.New("sealed: truncated container")
+	}
+
+	var header Header
+	if err := json.Unmarshal(rest[:headerLen], &header); err != nil {
+		return nil, 0, fmt.Errorf("sealed: invalid header: %w", err)
+	}
+	if header.Cipher != "xchacha20poly1305" || len(header.Nonce) != chacha20poly1305.NonceSizeX {
+		return nil, 0, errors.New("sealed: unsupported cipher")
+	}
+	if len(header.Salt) != saltSize {
+		return nil, 0, fmt.Errorf("sealed: salt of %d bytes, expected %d", len(header.Salt), saltSize)
+	}
+	if err := header.KDFParams.Validate(); err != nil {
+		return nil, 0, err
+	}
+	return &header, len(magic) + 3 + headerLen, nil
+}
+
+// Open decrypts a container created by Seal.
+func Open(data, passphrase []byte) ([]byte, error) {
+	header, prefixLen, err := split(data)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := header.deriveKey(passphrase, header.Salt, keySize)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := aead.Open(nil, header.Nonce, data[prefixLen:], data[:prefixLen])
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
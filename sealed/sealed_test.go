@@ -0,0 +1,150 @@
+package sealed
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testParams are cheap parameters so the tests run quickly.
+var testParams = []KDFParams{
+	{Algorithm: KDFArgon2id, Memory: MinArgon2Memory, Iterations: 1, Parallelism: 1},
+	{Algorithm: KDFScrypt, N: MinScryptN, R: 8, P: 1},
+}
+
+func TestSealOpen(t *testing.T) {
+	plaintext := []byte("secret share")
+	passphrase := []byte("correct horse battery staple")
+
+	for _, params := range testParams {
+		t.Run(params.Algorithm, func(t *testing.T) {
+			data, err := Seal(plaintext, passphrase, params)
+			require.NoError(t, err)
+			assert.True(t, IsSealed(data))
+
+			header, err := ReadHeader(data)
+			require.NoError(t, err)
+			assert.Equal(t, params, header.KDFParams)
+
+			opened, err := Open(data, passphrase)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, opened)
+
+			_, err = Open(data, []byte("wrong"))
+			assert.Equal(t, ErrDecrypt, err)
+
+			tampered := append([]byte(nil), data...)
+			tampered[len(tampered)-1] ^= 1
+			_, err = Open(tampered, passphrase)
+			assert.Equal(t, ErrDecrypt, err)
+		})
+	}
+}
+
+func TestOpen_Malformed(t *testing.T) {
+	_, err := Open([]byte("plaintext"), nil)
+	assert.Error(t, err)
+	_, err = Open([]byte(magic), nil)
+	assert.Error(t, err)
+	_, err = Open([]byte(magic+"\x01\xff\xff{}"), nil)
+	assert.Error(t, err)
+}
+
+func TestKDFParams_Validate(t *testing.T) {
+	assert.NoError(t, DefaultArgon2id.Validate())
+	assert.NoError(t, DefaultScrypt.Validate())
+
+	tooLarge := DefaultArgon2id
+	tooLarge.Memory = MaxArgon2Memory + 1
+	assert.Error(t, tooLarge.Validate())
+	tooLarge = DefaultArgon2id
+	tooLarge.Iterations = MaxArgon2Iterations + 1
+	assert.Error(t, tooLarge.Validate())
+
+	notPowerOfTwo := DefaultScrypt
+	notPowerOfTwo.N = MinScryptN + 1
+	assert.Error(t, notPowerOfTwo.Validate())
+
+	assert.Error(t, KDFParams{Algorithm: "pbkdf2"}.Validate())
+
+	for _, params := range []KDFParams{
+		{Algorithm: KDFScrypt, N: MaxScryptN, R: 8, P: MaxScryptP},
+		{Algorithm: KDFScrypt, N: MinScryptN, R: MaxScryptR, P: 1},
+	} {
+		assert.NoError(t, params.Validate(), "%+v", params)
+	}
+	for _, params := range []KDFParams{
+		{Algorithm: KDFScrypt, N: MaxScryptN << 1, R: 8, P: 1},
+		{Algorithm: KDFScrypt, N: MinScryptN, R: MaxScryptR + 1, P: 1},
+		{Algorithm: KDFScrypt, N: MinScryptN, R: 8, P: MaxScryptP + 1},
+		{Algorithm: KDFScrypt, N: MaxScryptN, R: MaxScryptR, P: 1},
+		{Algorithm: KDFScrypt, N: MinScryptN, R: 8, P: 0},
+	} {
+		assert.Error(t, params.Validate(), "%+v", params)
+	}
+}
+
+// TestOpen_OversizedScrypt opens containers whose headers ask for scrypt
+// parameters that would take gigabytes of memory or hours, which must fail
+// before any key is derived.
+func TestOpen_OversizedScrypt(t *testing.T) {
+	data, err := Seal([]byte("secret share"), []byte("passphrase"), testParams[1])
+	require.NoError(t, err)
+	header, err := ReadHeader(data)
+	require.NoError(t, err)
+
+	for _, kdf := range []KDFParams{
+		{Algorithm: KDFScrypt, N: MaxScryptN, R: 1 << 20, P: 1},
+		{Algorithm: KDFScrypt, N: MinScryptN, R: 8, P: 1 << 20},
+		{Algorithm: KDFScrypt, N: 1 << 30, R: 1, P: 1},
+		{Algorithm: KDFScrypt, N: 1 << 22, R: 8, P: 1},
+	} {
+		crafted := *header
+		crafted.KDFParams = kdf
+
+		start := time.Now()
+		_, err = Open(withHeader(t, data, &crafted), []byte("passphrase"))
+		assert.Error(t, err, "%+v", kdf)
+		assert.NotEqual(t, ErrDecrypt, err, "%+v", kdf)
+		assert.Less(t, time.Since(start), time.Second)
+	}
+}
+
+func TestOpen_InvalidSalt(t *testing.T) {
+	data, err := Seal([]byte("secret share"), []byte("passphrase"), testParams[0])
+	require.NoError(t, err)
+	header, err := ReadHeader(data)
+	require.NoError(t, err)
+
+	for _, salt := range [][]byte{nil, make([]byte, saltSize-1), make([]byte, saltSize+1)} {
+		crafted := *header
+		crafted.Salt = salt
+		_, err = Open(withHeader(t, data, &crafted), []byte("passphrase"))
+		assert.Error(t, err, "%d bytes", len(salt))
+		assert.NotEqual(t, ErrDecrypt, err, "%d bytes", len(salt))
+	}
+}
+
+// withHeader returns the container data with its header replaced by header.
+func withHeader(t *testing.T, data []byte, header *Header) []byte {
+	body := data[len(magic)+3+int(binary.BigEndian.Uint16(data[len(magic)+1:])):]
+	headerBytes, err := json.Marshal(header)
+	require.NoError(t, err)
+	out := append([]byte(magic), data[len(magic)])
+	out = binary.BigEndian.AppendUint16(out, uint16(len(headerBytes)))
+	return append(append(out, headerBytes...), body...)
+}
+
+func TestCalibrate(t *testing.T) {
+	p, err := CalibrateArgon2id(time.Millisecond, MinArgon2Memory, 1)
+	require.NoError(t, err)
+	assert.NoError(t, p.Validate())
+
+	p, err = CalibrateScrypt(time.Millisecond)
+	require.NoError(t, err)
+	assert.NoError(t, p.Validate())
+}
@@ -0,0 +1,55 @@
+package frost
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// seedStreamBlock is the most HKDF-SHA512 can expand from one key.
+const seedStreamBlock = 255 * sha512.Size
+
+// seedStreamSalt prefixes the salt of the blocks after the first, so that
+// they are separate from any first block.
+const seedStreamSalt = "FROST-SEED-STREAM-CONTINUED-v1"
+
+// seedStream is an unbounded deterministic stream derived from a seed. Its
+// first block is HKDF-SHA512(seed, salt, info), so that it starts like a
+// single HKDF reader, and block k ≥ 1 is HKDF-SHA512(seed, seedStreamSalt ∥
+// salt, info ∥ k) with k as 4 bytes big endian. A single reader could not
+// produce more than seedStreamBlock bytes.
+type seedStream struct {
+	seed, salt, info []byte
+	block            uint32
+	left             int
+	r                io.Reader
+}
+
+// newSeedStream returns the seedStream of seed, salt and info.
+func newSeedStream(seed, salt, info []byte) io.Reader {
+	return &seedStream{
+		seed: seed,
+		salt: salt,
+		info: info,
+		left: seedStreamBlock,
+		r:    hkdf.New(sha512.New, seed, salt, info),
+	}
+}
+
+func (s *seedStream) Read(p []byte) (int, error) {
+	if s.left == 0 {
+		s.block++
+		salt := append([]byte(seedStreamSalt), s.salt...)
+		info := binary.BigEndian.AppendUint32(append(make([]byte, 0, len(s.info)+4), s.info...), s.block)
+		s.r = hkdf.New(sha512.New, s.seed, salt, info)
+		s.left = seedStreamBlock
+	}
+	if len(p) > s.left {
+		p = p[:s.left]
+	}
+	n, err := s.r.Read(p)
+	s.left -= n
+	return n, err
+}
@@ -0,0 +1,41 @@
+package frost
+
+import (
+	"crypto/sha512"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/hkdf"
+)
+
+func TestSeedStream(t *testing.T) {
+	seed, salt, info := []byte("seed"), []byte("salt"), []byte("info")
+	stream := make([]byte, 3*seedStreamBlock)
+	_, err := io.ReadFull(newSeedStream(seed, salt, info), stream)
+	require.NoError(t, err)
+
+	// the first block is that of a single HKDF reader
+	first := make([]byte, seedStreamBlock)
+	_, err = io.ReadFull(hkdf.New(sha512.New, seed, salt, info), first)
+	require.NoError(t, err)
+	assert.Equal(t, first, stream[:seedStreamBlock])
+	assert.NotEqual(t, stream[:seedStreamBlock], stream[seedStreamBlock:2*seedStreamBlock])
+
+	// reads across the end of a block return the same stream
+	r := newSeedStream(seed, salt, info)
+	var chunked []byte
+	chunk := make([]byte, 1000)
+	for len(chunked) < len(stream) {
+		n, err := io.ReadFull(r, chunk)
+		require.NoError(t, err)
+		chunked = append(chunked, chunk[:n]...)
+	}
+	assert.Equal(t, stream, chunked[:len(stream)])
+
+	other := make([]byte, 2*seedStreamBlock)
+	_, err = io.ReadFull(newSeedStream(seed, nil, info), other)
+	require.NoError(t, err)
+	assert.NotEqual(t, stream[seedStreamBlock:2*seedStreamBlock], other[seedStreamBlock:])
+}
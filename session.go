@@ -0,0 +1,132 @@
+package frost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+)
+
+// ErrSessionClosed is returned by Session.Result when the session was canceled before completing.
+var ErrSessionClosed = errors.New("frost: session closed")
+
+// SessionResult is the output of a Session.
+// Keygen sessions set Public and SecretShare, signing sessions set Signature.
+type SessionResult struct {
+	Public      *eddsa.Public
+	SecretShare *eddsa.SecretShare
+	Signature   *eddsa.Signature
+}
+
+// Session runs the rounds of a protocol for one party in its own goroutine.
+//
+// Messages received from the other parties are passed to In, in any order;
+// messages for a later round are kept until that round starts. Messages to be
+// delivered to the other parties are read from Out, which is closed when the
+// session ends. Done is closed once the result is available, or the session failed,
+// or its context was canceled, so senders on In should also select on Done.
+type Session struct {
+	machine *Machine
+	in      chan *Message
+	out     chan *Message
+	done    chan struct{}
+
+	result *SessionResult
+	err    error
+}
+
+func newSession(ctx context.Context, m *Machine) *Session {
+	s := &Session{
+		machine: m,
+		in:      make(chan *Message),
+		out:     make(chan *Message),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+		defer close(s.out)
+		s.result, s.err = s.run(ctx)
+	}()
+	return s
+}
+
+// NewKeygenSession starts the key generation for selfID among the parties 1..n with threshold t.
+func NewKeygenSession(ctx context.Context, selfID party.ID, n, t party.Size) *Session {
+	return newSession(ctx, NewKeygenMachine(selfID, n, t))
+}
+
+// NewSignSession starts the signing of message by the owner of secret, together with signerIDs.
+func NewSignSession(ctx context.Context, signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, message []byte) *Session {
+	return newSession(ctx, NewSignMachine(signerIDs, secret, shares, message))
+}
+
+// run advances the machine with the messages received on In, and sends its
+// output on Out, until the machine ends or ctx is done.
+func (s *Session) run(ctx context.Context) (*SessionResult, error) {
+	var inbox []*Message
+	for {
+		out, status := s.machine.Advance(time.Now(), inbox)
+		inbox = inbox[:0]
+		for _, msg := range out {
+			if err := s.send(ctx, msg, &inbox); err != nil {
+				return nil, err
+			}
+		}
+		if status != StatusWaiting {
+			return s.machine.Result()
+		}
+		if len(inbox) > 0 {
+			continue
+		}
+		select {
+		case msg := <-s.in:
+			if msg != nil {
+				inbox = append(inbox, msg)
+			}
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %v", ErrSessionClosed, ctx.Err())
+		}
+	}
+}
+
+// In returns the channel on which messages from the other parties are delivered.
+func (s *Session) In() chan<- *Message {
+	return s.in
+}
+
+// Out returns the channel of messages to deliver to the other parties.
+// Broadcast messages have Header.To set to 0.
+func (s *Session) Out() <-chan *Message {
+	return s.out
+}
+
+// Done returns a channel that is closed when the session has ended.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Result waits for the session to end and returns its result.
+func (s *Session) Result() (*SessionResult, error) {
+	<-s.done
+	return s.result, s.err
+}
+
+// send waits until msg is read from Out. Messages received in the meantime are
+// appended to inbox, so that two sessions sending to each other cannot deadlock.
+func (s *Session) send(ctx context.Context, msg *Message, inbox *[]*Message) error {
+	for {
+		select {
+		case s.out <- msg:
+			return nil
+		case in := <-s.in:
+			if in != nil {
+				*inbox = append(*inbox, in)
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %v", ErrSessionClosed, ctx.Err())
+		}
+	}
+}
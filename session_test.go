@@ -0,0 +1,90 @@
+package frost
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// connect forwards the output of every session to the input of its recipients,
+// until all sessions are done.
+func connect(sessions map[party.ID]*Session) {
+	for from, s := range sessions {
+		go func(from party.ID, s *Session) {
+			for msg := range s.Out() {
+				for id, other := range sessions {
+					if id == from || (!msg.IsBroadcast() && msg.To != id) {
+						continue
+					}
+					select {
+					case other.In() <- msg:
+					case <-other.Done():
+					}
+				}
+			}
+		}(from, s)
+	}
+}
+
+func TestSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const n, threshold = 4, 2
+	keygen := make(map[party.ID]*Session, n)
+	for id := party.ID(1); id <= n; id++ {
+		keygen[id] = NewKeygenSession(ctx, id, n, threshold)
+	}
+	connect(keygen)
+
+	results := make(map[party.ID]*SessionResult, n)
+	for id, s := range keygen {
+		res, err := s.Result()
+		require.NoError(t, err)
+		results[id] = res
+	}
+	public := results[1].Public
+	for _, res := range results {
+		assert.True(t, public.Equal(res.Public))
+	}
+
+	signers := party.IDSlice{1, 3, 4}
+	message := []byte("hello FROST")
+	sign := make(map[party.ID]*Session, len(signers))
+	for _, id := range signers {
+		sign[id] = NewSignSession(ctx, signers, results[id].SecretShare, public, message)
+	}
+	connect(sign)
+
+	for _, s := range sign {
+		select {
+		case <-s.Done():
+		case <-ctx.Done():
+			t.Fatal("session did not complete")
+		}
+		res, err := s.Result()
+		require.NoError(t, err)
+		assert.True(t, public.GroupKey.Verify(message, res.Signature))
+	}
+}
+
+func TestSession_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewKeygenSession(ctx, 1, 3, 1)
+
+	// the first message is available, the session then waits for the other parties
+	msg := <-s.Out()
+	assert.Equal(t, MessageTypeKeyGen1, msg.Type)
+
+	cancel()
+	_, err := s.Result()
+	assert.True(t, errors.Is(err, ErrSessionClosed))
+
+	_, ok := <-s.Out()
+	assert.False(t, ok)
+}
@@ -0,0 +1,174 @@
+// Package sessions runs at most one signing ceremony per request, so that a
+// caller retrying a request, e.g. after its connection timed out, does not
+// start a second ceremony for the same payload.
+//
+// A Manager identifies a request by the SessionID frost.DeriveSessionID
+// derives from the group key, the message and the signers. Sign joins the
+// ceremony of a request that is in flight, and returns the signature of one
+// that completed within the TTL without contacting the signers again. A
+// ceremony that failed is forgotten, so that the request can be retried. A
+// Limiter bounds the ceremonies in flight, so that callers sending many
+// distinct requests cannot start an unbounded number of them.
+package sessions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/limits"
+	"github.com/bartke/frost/party"
+)
+
+const (
+	// DefaultTTL is how long a Manager returns a signature for retries of its request by default.
+	DefaultTTL = 10 * time.Minute
+	// DefaultTimeout bounds a ceremony by default.
+	DefaultTimeout = time.Minute
+)
+
+// SignFunc runs the ceremony of a request in the session id, e.g.
+// grpcserver.Client.SignWithSession.
+type SignFunc func(ctx context.Context, id frost.SessionID, signerIDs party.IDSlice, message []byte) (*eddsa.Signature, error)
+
+// Manager deduplicates the signing requests for the key GroupKey. A Manager
+// must not be copied after first use.
+type Manager struct {
+	GroupKey *eddsa.PublicKey
+	// Run runs a ceremony.
+	Run SignFunc
+
+	// TTL is how long the signature of a request is returned for its
+	// retries, DefaultTTL if it is zero.
+	TTL time.Duration
+	// Timeout bounds a ceremony, DefaultTimeout if it is zero. A ceremony is
+	// not canceled with the context of the caller that started it, so that a
+	// retry can join it.
+	Timeout time.Duration
+	// Clock times the TTL, it defaults to the real time.
+	Clock clock.Clock
+	// Limiter, if set, bounds the ceremonies in flight. A request that would
+	// start one more fails with an error wrapping limits.ErrTooManySessions,
+	// while retries still join the ceremonies in flight.
+	Limiter *limits.Limiter
+
+	mu       sync.Mutex
+	sessions map[frost.SessionID]*session
+}
+
+// session is a ceremony in flight, or completed once done is closed.
+type session struct {
+	done chan struct{}
+	sig  *eddsa.Signature
+	err  error
+	// expires is set once the ceremony succeeded.
+	expires time.Time
+}
+
+// NewManager returns a Manager running the ceremonies for groupKey with run.
+func NewManager(groupKey *eddsa.PublicKey, run SignFunc) *Manager {
+	return &Manager{GroupKey: groupKey, Run: run}
+}
+
+// Sign returns the signature of message by signerIDs. It starts a ceremony
+// unless one for the same request is in flight, whose result it waits for, or
+// completed within the TTL, whose signature it returns.
+func (m *Manager) Sign(ctx context.Context, signerIDs party.IDSlice, message []byte) (*eddsa.Signature, error) {
+	if m.GroupKey == nil || m.Run == nil {
+		return nil, errors.New("sessions: Manager without GroupKey or Run")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s, err := m.session(ctx, party.NewIDSlice(signerIDs), message)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case <-s.done:
+		return s.sig, s.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// SessionID returns the ID of the session of a request.
+func (m *Manager) SessionID(signerIDs party.IDSlice, message []byte) frost.SessionID {
+	return frost.DeriveSessionID(m.GroupKey, message, signerIDs)
+}
+
+// InFlight returns the number of ceremonies in flight.
+func (m *Manager) InFlight() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, s := range m.sessions {
+		if s.expires.IsZero() {
+			n++
+		}
+	}
+	return n
+}
+
+// session returns the session of the request, and starts its ceremony if
+// there is none and the Limiter allows it.
+func (m *Manager) session(ctx context.Context, signerIDs party.IDSlice, message []byte) (*session, error) {
+	id := m.SessionID(signerIDs, message)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := clock.OrReal(m.Clock).Now()
+	for other, s := range m.sessions {
+		if !s.expires.IsZero() && !now.Before(s.expires) {
+			delete(m.sessions, other)
+		}
+	}
+	if s, ok := m.sessions[id]; ok {
+		return s, nil
+	}
+	release, err := m.Limiter.Acquire()
+	if err != nil {
+		return nil, fmt.Errorf("sessions: %w", err)
+	}
+	if m.sessions == nil {
+		m.sessions = make(map[frost.SessionID]*session)
+	}
+	s := &session{done: make(chan struct{})}
+	m.sessions[id] = s
+	go m.run(context.WithoutCancel(ctx), id, s, signerIDs, append([]byte(nil), message...), release)
+	return s, nil
+}
+
+// run runs the ceremony of s, and keeps its signature for the TTL.
+func (m *Manager) run(ctx context.Context, id frost.SessionID, s *session, signerIDs party.IDSlice, message []byte, release func()) {
+	timeout := m.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	sig, err := m.Run(ctx, id, signerIDs, message)
+	release()
+	if err == nil && sig == nil {
+		err = errors.New("sessions: ceremony returned no signature")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.sig, s.err = sig, err
+	if err != nil {
+		delete(m.sessions, id)
+	} else {
+		ttl := m.TTL
+		if ttl == 0 {
+			ttl = DefaultTTL
+		}
+		s.expires = clock.OrReal(m.Clock).Now().Add(ttl)
+	}
+	close(s.done)
+}
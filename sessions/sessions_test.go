@@ -0,0 +1,178 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/limits"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ceremony is a SignFunc that counts its runs, and waits for release before it
+// returns the result of fail.
+type ceremony struct {
+	runs    atomic.Int32
+	release chan struct{}
+	fail    func(n int32) error
+}
+
+func (c *ceremony) run(ctx context.Context, _ frost.SessionID, _ party.IDSlice, _ []byte) (*eddsa.Signature, error) {
+	n := c.runs.Add(1)
+	if c.release != nil {
+		select {
+		case <-c.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if c.fail != nil {
+		if err := c.fail(n); err != nil {
+			return nil, err
+		}
+	}
+	return &eddsa.Signature{R: *ristretto.NewIdentityElement(), S: *scalar.NewScalarRandom()}, nil
+}
+
+func groupKey() *eddsa.PublicKey {
+	return eddsa.NewPublicKeyFromPoint(new(ristretto.Element).ScalarBaseMult(scalar.NewScalarRandom()))
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	c := &ceremony{release: make(chan struct{})}
+	fake := clock.NewFake(time.Now())
+	m := NewManager(groupKey(), c.run)
+	m.Clock = fake
+
+	type result struct {
+		sig *eddsa.Signature
+		err error
+	}
+	results := make(chan result, 2)
+	for _, signers := range []party.IDSlice{{1, 2}, {2, 1}} {
+		go func(signers party.IDSlice) {
+			sig, err := m.Sign(ctx, signers, []byte("block 7"))
+			results <- result{sig, err}
+		}(signers)
+	}
+	require.Eventually(t, func() bool { return m.InFlight() == 1 && c.runs.Load() == 1 }, 5*time.Second, time.Millisecond)
+	close(c.release)
+	first, second := <-results, <-results
+	require.NoError(t, first.err)
+	require.NoError(t, second.err)
+	assert.Same(t, first.sig, second.sig)
+
+	// a retry gets the signature without another ceremony
+	sig, err := m.Sign(ctx, party.IDSlice{1, 2}, []byte("block 7"))
+	require.NoError(t, err)
+	assert.Same(t, first.sig, sig)
+	assert.Equal(t, int32(1), c.runs.Load())
+	assert.Equal(t, 0, m.InFlight())
+
+	// other messages and signers are other requests
+	_, err = m.Sign(ctx, party.IDSlice{1, 2}, []byte("block 8"))
+	require.NoError(t, err)
+	_, err = m.Sign(ctx, party.IDSlice{1, 3}, []byte("block 7"))
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), c.runs.Load())
+
+	// the signature is forgotten after the TTL
+	fake.Advance(DefaultTTL)
+	sig, err = m.Sign(ctx, party.IDSlice{1, 2}, []byte("block 7"))
+	require.NoError(t, err)
+	assert.NotSame(t, first.sig, sig)
+	assert.Equal(t, int32(4), c.runs.Load())
+}
+
+func TestManager_Failure(t *testing.T) {
+	ctx := context.Background()
+	errTimeout := errors.New("party 2 timed out")
+	c := &ceremony{fail: func(n int32) error {
+		if n == 1 {
+			return errTimeout
+		}
+		return nil
+	}}
+	m := NewManager(groupKey(), c.run)
+
+	_, err := m.Sign(ctx, party.IDSlice{1, 2}, []byte("m"))
+	assert.Equal(t, errTimeout, err)
+	// a failed ceremony is run again
+	_, err = m.Sign(ctx, party.IDSlice{1, 2}, []byte("m"))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), c.runs.Load())
+}
+
+func TestManager_CallerCanceled(t *testing.T) {
+	c := &ceremony{release: make(chan struct{})}
+	m := NewManager(groupKey(), c.run)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := m.Sign(ctx, party.IDSlice{1, 2}, []byte("m"))
+		canceled <- err
+	}()
+	require.Eventually(t, func() bool { return c.runs.Load() == 1 }, 5*time.Second, time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-canceled)
+
+	// the ceremony goes on, and the retry joins it
+	done := make(chan error, 1)
+	go func() {
+		_, err := m.Sign(context.Background(), party.IDSlice{1, 2}, []byte("m"))
+		done <- err
+	}()
+	close(c.release)
+	assert.NoError(t, <-done)
+	assert.Equal(t, int32(1), c.runs.Load())
+}
+
+func TestManager_Limiter(t *testing.T) {
+	ctx := context.Background()
+	c := &ceremony{release: make(chan struct{})}
+	m := NewManager(groupKey(), c.run)
+	m.Limiter = limits.New(limits.Limits{MaxSessions: 1})
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := m.Sign(ctx, party.IDSlice{1, 2}, []byte("m"))
+			done <- err
+		}()
+	}
+	require.Eventually(t, func() bool { return m.InFlight() == 1 }, 5*time.Second, time.Millisecond)
+
+	// another request would start a second ceremony, a retry joins the first
+	_, err := m.Sign(ctx, party.IDSlice{1, 2}, []byte("other"))
+	assert.True(t, errors.Is(err, limits.ErrTooManySessions))
+	close(c.release)
+	assert.NoError(t, <-done)
+	assert.NoError(t, <-done)
+	assert.Equal(t, int32(1), c.runs.Load())
+
+	_, err = m.Sign(ctx, party.IDSlice{1, 2}, []byte("other"))
+	assert.NoError(t, err)
+}
+
+func TestManager_SessionID(t *testing.T) {
+	key := groupKey()
+	m := NewManager(key, nil)
+	id := m.SessionID(party.IDSlice{3, 1}, []byte("m"))
+	assert.Equal(t, frost.DeriveSessionID(key, []byte("m"), party.IDSlice{1, 3}), id)
+	assert.NotEqual(t, id, m.SessionID(party.IDSlice{1, 2}, []byte("m")))
+	assert.NotEqual(t, id, NewManager(groupKey(), nil).SessionID(party.IDSlice{1, 3}, []byte("m")))
+
+	_, err := m.Sign(context.Background(), party.IDSlice{1, 3}, []byte("m"))
+	assert.Error(t, err)
+}
@@ -1,13 +1,19 @@
 package frost
 
 import (
+	"crypto/rand"
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"sync"
 
+	"github.com/bartke/frost/clock"
 	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/internal/vartime"
 	"github.com/bartke/frost/party"
 	"github.com/bartke/frost/ristretto"
 	"github.com/bartke/frost/scalar"
@@ -50,6 +56,12 @@ func NewSigner() *signer {
 	}
 }
 
+// Zeroize overwrites the binding factor and the signature share with 0.
+func (s *signer) Zeroize() {
+	s.Pi.Set(zero)
+	s.Zi.Set(zero)
+}
+
 func (s *signer) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&struct {
 		Di     ristretto.Element `json:"di"`
@@ -76,7 +88,13 @@ func (s *signer) UnmarshalJSON(data []byte) error {
 		Ri     ristretto.Element `json:"ri"`
 		Zi     string            `json:"zi"`
 		Public ristretto.Element `json:"public"`
-	}{}
+	}{
+		// elements that are absent keep the defaults of NewSigner
+		Di:     *ristretto.NewIdentityElement(),
+		Ei:     *ristretto.NewIdentityElement(),
+		Ri:     *ristretto.NewIdentityElement(),
+		Public: *ristretto.NewIdentityElement(),
+	}
 
 	if err := json.Unmarshal(data, aux); err != nil {
 		return err
@@ -112,6 +130,38 @@ type SignerState struct {
 	C ristretto.Scalar
 	// R = ∑ Ri
 	R ristretto.Element
+	// Request is the optional request this session signs, bound into the binding factors.
+	Request *SignatureRequest
+	// Clock checks the expiry of Request, clock.Real is used if it is nil. It is not serialized.
+	Clock clock.Clock
+	// Ledger, if set, records our nonces in SignRound1 and refuses to use them twice. It is not serialized.
+	Ledger NonceLedger
+	// RFC9591 selects the binding factors of RFC 9591, see SignInitRFC9591.
+	RFC9591 bool
+	// SessionID is the session the state belongs to, see SignInitWithSession.
+	SessionID SessionID
+	// Prehash is set if Message is the SHA-512 digest of the message, which
+	// is signed with Ed25519ph, see SignInitPrehashed.
+	Prehash bool
+	// Received records the parties whose Sign1 and Sign2 messages were processed.
+	Received Received
+	// Observer, if set, is notified of the progress of the rounds. It is not serialized.
+	Observer Observer
+	// Logger, if set, logs the progress of the rounds at debug level. It is not serialized.
+	Logger *slog.Logger
+}
+
+// Zeroize overwrites the nonces, the secret share and the scalars of the
+// signers with 0, so that a long-lived process does not keep them in memory.
+// SignRound2 calls it once the signature is computed or the session aborted;
+// the state cannot be used for signing afterwards.
+func (s *SignerState) Zeroize() {
+	s.SecretKeyShare.Set(zero)
+	s.D.Set(zero)
+	s.E.Set(zero)
+	for _, signer := range s.Signers {
+		signer.Zeroize()
+	}
 }
 
 func (s *SignerState) MarshalJSON() ([]byte, error) {
@@ -130,6 +180,11 @@ func (s *SignerState) MarshalJSON() ([]byte, error) {
 		C              string             `json:"c"`
 		R              ristretto.Element  `json:"r"`
 		Signers        map[string]*signer `json:"signers"`
+		Request        *SignatureRequest  `json:"request,omitempty"`
+		RFC9591        bool               `json:"rfc9591,omitempty"`
+		Session        string             `json:"session,omitempty"`
+		Prehash        bool               `json:"prehash,omitempty"`
+		Received       Received           `json:"received,omitempty"`
 	}{
 		SelfID:         base64.StdEncoding.EncodeToString(s.SelfID.Bytes()),
 		SignerIDs:      s.SignerIDs,
@@ -141,6 +196,11 @@ func (s *SignerState) MarshalJSON() ([]byte, error) {
 		C:              base64.StdEncoding.EncodeToString(s.C.Bytes()),
 		R:              s.R,
 		Signers:        parties,
+		Request:        s.Request,
+		RFC9591:        s.RFC9591,
+		Session:        s.SessionID.encode(),
+		Prehash:        s.Prehash,
+		Received:       s.Received,
 	})
 }
 
@@ -149,14 +209,21 @@ func (s *SignerState) UnmarshalJSON(data []byte) error {
 		SelfID         string             `json:"self_id"`
 		SignerIDs      party.IDSlice      `json:"signer_ids"`
 		Message        string             `json:"message"`
-		GroupKey       eddsa.PublicKey    `json:"group_key"`
+		GroupKey       *eddsa.PublicKey   `json:"group_key"`
 		SecretKeyShare string             `json:"secret_key_share"`
 		E              string             `json:"e"`
 		D              string             `json:"d"`
 		C              string             `json:"c"`
 		R              ristretto.Element  `json:"r"`
 		Signers        map[string]*signer `json:"signers"`
-	}{}
+		Request        *SignatureRequest  `json:"request,omitempty"`
+		RFC9591        bool               `json:"rfc9591,omitempty"`
+		Session        string             `json:"session,omitempty"`
+		Prehash        bool               `json:"prehash,omitempty"`
+		Received       Received           `json:"received,omitempty"`
+	}{
+		R: *ristretto.NewIdentityElement(),
+	}
 
 	if err := json.Unmarshal(data, aux); err != nil {
 		return err
@@ -179,7 +246,10 @@ func (s *SignerState) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	s.Message = msg
-	s.GroupKey = aux.GroupKey
+	if aux.GroupKey == nil {
+		return errors.New("SignerState: missing group key")
+	}
+	s.GroupKey = *aux.GroupKey
 
 	if err := decodeScalar(aux.SecretKeyShare, &s.SecretKeyShare); err != nil {
 		return err
@@ -198,6 +268,13 @@ func (s *SignerState) UnmarshalJSON(data []byte) error {
 	}
 
 	s.R = aux.R
+	s.Request = aux.Request
+	s.RFC9591 = aux.RFC9591
+	s.Prehash = aux.Prehash
+	s.Received = aux.Received
+	if err := s.SessionID.decode(aux.Session); err != nil {
+		return err
+	}
 
 	s.Signers = make(map[party.ID]*signer, len(aux.Signers))
 	for idStr, signer := range aux.Signers {
@@ -217,109 +294,157 @@ func (s *SignerState) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// SignInit initializes the state for the signing protocol.
+// SignInit initializes the state for the signing protocol. The signerIDs may
+// be in any order, but must not contain duplicates or the invalid ID 0.
 func SignInit(signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, message []byte) (*Message, *SignerState, error) {
-	if !signerIDs.Contains(secret.ID) {
-		return nil, nil, errors.New("SignRound0: owner of SecretShare is not contained in partyIDs")
-	}
+	return signInit(signerIDs, secret, shares, message, rand.Reader)
+}
 
-	if !signerIDs.IsSubsetOf(shares.PartyIDs) {
-		return nil, nil, fmt.Errorf("SignRound0: partyIDs %v are not a subset of shares.PartyIDs %v", signerIDs, shares.PartyIDs)
+// SignInitWithRand is SignInit with the nonces d and e sampled from rng, or
+// crypto/rand if it is nil. It is meant for reproducible tests and fuzzers:
+// signing two messages with nonces from the same fixed rng reveals the secret
+// share.
+func SignInitWithRand(signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, message []byte, rng io.Reader) (*Message, *SignerState, error) {
+	if rng == nil {
+		rng = rand.Reader
 	}
+	return signInit(signerIDs, secret, shares, message, rng)
+}
 
-	state := &SignerState{
-		SelfID:    secret.ID,
-		SignerIDs: signerIDs,
-		Message:   message,
-		Signers:   make(map[party.ID]*signer, signerIDs.N()),
-		GroupKey:  *shares.GroupKey,
-		R:         *ristretto.NewIdentityElement(),
+// SignInitWithSession is SignInit for the session with the given ID, which all
+// signers and the aggregator must have agreed on. The messages of the session
+// carry the ID, and SignRound1 and SignRound2 reject messages of other sessions.
+func SignInitWithSession(session SessionID, signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, message []byte) (*Message, *SignerState, error) {
+	msg, state, err := SignInit(signerIDs, secret, shares, message)
+	if err != nil {
+		return nil, nil, err
 	}
+	state.SessionID = session
+	msg.SessionID = session
+	return msg, state, nil
+}
 
-	// Setup parties
-	for _, id := range signerIDs {
-		s := NewSigner()
-		if id == 0 {
-			return nil, nil, errors.New("SignRound0: id 0 is not valid")
-		}
-
-		originalShare, ok := shares.Shares[id]
-		if !ok {
-			return nil, nil, fmt.Errorf("SignRound0: party %d not found in shares", id)
-		}
-
-		lagrange, err := id.Lagrange(signerIDs)
-		if err != nil {
-			return nil, nil, fmt.Errorf("SignRound0: %w", err)
-		}
-		s.Public.ScalarMult(lagrange, originalShare)
-		state.Signers[id] = s
+// signInit is SignInit with the nonces d and e sampled from rng.
+func signInit(signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, message []byte, rng io.Reader) (*Message, *SignerState, error) {
+	group, err := NewSigningGroup(signerIDs, shares)
+	if err != nil {
+		return nil, nil, err
 	}
+	return signInitWithGroup(group, secret, message, rng)
+}
 
-	// Normalize secret share so that we can assume we are dealing with an additive sharing
-	lagrange, err := state.SelfID.Lagrange(signerIDs)
+// SignInitWithGroup is like SignInit, but reuses the precomputed values of group,
+// which must have been created for the same signers and public shares.
+func SignInitWithGroup(group *SigningGroup, secret *eddsa.SecretShare, message []byte) (*Message, *SignerState, error) {
+	return signInitWithGroup(group, secret, message, rand.Reader)
+}
+
+func signInitWithGroup(group *SigningGroup, secret *eddsa.SecretShare, message []byte, rng io.Reader) (*Message, *SignerState, error) {
+	state, err := newSignerState(group, secret, message)
 	if err != nil {
-		return nil, nil, fmt.Errorf("SignRound0: %w", err)
+		return nil, nil, err
 	}
-	state.SecretKeyShare.Multiply(lagrange, &secret.Secret)
 
 	// Generate first message
 	selfParty := state.Signers[state.SelfID]
 
 	// Sample dᵢ, Dᵢ = [dᵢ] B
-	scalar.SetScalarRandom(&state.D)
+	scalar.SetScalarRandomFrom(&state.D, rng)
 	selfParty.Di.ScalarBaseMult(&state.D)
 	// Sample eᵢ, Dᵢ = [eᵢ] B
-	scalar.SetScalarRandom(&state.E)
+	scalar.SetScalarRandomFrom(&state.E, rng)
 	selfParty.Ei.ScalarBaseMult(&state.E)
 
 	msg := NewSign1(state.SelfID, &selfParty.Di, &selfParty.Ei)
 	return msg, state, nil
 }
 
-// SignRound1 processes the first round of the signing protocol.
-func SignRound1(state *SignerState, inputMsgs []*Message) (*Message, *SignerState, error) {
+// newSignerState returns the state of the owner of secret, without nonces.
+func newSignerState(group *SigningGroup, secret *eddsa.SecretShare, message []byte) (*SignerState, error) {
+	lagrange, ok := group.Lagrange[secret.ID]
+	if !ok {
+		return nil, errors.New("SignRound0: owner of SecretShare is not contained in partyIDs")
+	}
+
+	state := &SignerState{
+		SelfID:    secret.ID,
+		SignerIDs: group.SignerIDs,
+		Message:   message,
+		Signers:   group.newSigners(),
+		GroupKey:  group.GroupKey,
+		R:         *ristretto.NewIdentityElement(),
+	}
+
+	// Normalize secret share so that we can assume we are dealing with an additive sharing
+	state.SecretKeyShare.Multiply(lagrange, &secret.Secret)
+	return state, nil
+}
+
+// SignRound1 processes the Sign1 messages of the first round of the signing
+// protocol, and returns our Sign2 message once those of all other signers
+// were processed. It can be called with the messages as they arrive, and
+// returns an error wrapping ErrNeedMoreMessages until then.
+func SignRound1(state *SignerState, inputMsgs []*Message) (_ *Message, _ *SignerState, err error) {
+	obs := observeRound(state.Observer, state.Logger, state.SelfID, state.Received, MessageTypeSign1)
+	defer func() { obs.done(err) }()
+
+	if state.Request != nil && state.Request.Expired(clock.OrReal(state.Clock).Now()) {
+		return nil, nil, fmt.Errorf("SignRound1: %w", ErrRequestExpired)
+	}
+
 	// Process Sign1 messages
+	state.Received.reserve(MessageTypeSign1, len(state.SignerIDs)-1)
 	for _, msg := range inputMsgs {
 		if msg.From == state.SelfID {
 			continue
 		}
+		obs.from = msg.From
+
+		if err := msg.Validate(MessageTypeSign1, state.SessionID, state.SelfID); err != nil {
+			return nil, nil, fmt.Errorf("SignRound1: %w", err)
+		}
 
 		id := msg.From
-		otherParty := state.Signers[id]
-		if msg.Sign1.Di.Equal(ristretto.NewIdentityElement()) == 1 || msg.Sign1.Ei.Equal(ristretto.NewIdentityElement()) == 1 {
-			return nil, nil, errors.New("commitment Ei or Di was the identity")
+		otherParty, ok := state.Signers[id]
+		if !ok {
+			return nil, nil, fmt.Errorf("SignRound1: %w", &ErrUnknownParty{ID: id})
+		}
+		if msg.Sign1.Di.Equal(identity) == 1 || msg.Sign1.Ei.Equal(identity) == 1 {
+			return nil, nil, fmt.Errorf("SignRound1: commitment Ei or Di of party %d was the identity: %w", id, ErrInvalidMessage)
+		}
+		if err := state.Received.add(MessageTypeSign1, id); err != nil {
+			return nil, nil, fmt.Errorf("SignRound1: %w", err)
 		}
 		otherParty.Di.Set(&msg.Sign1.Di)
 		otherParty.Ei.Set(&msg.Sign1.Ei)
+		obs.received(msg)
+	}
+	obs.from = 0
+	if err := state.Received.complete(MessageTypeSign1, state.SignerIDs, state.SelfID); err != nil {
+		return nil, nil, fmt.Errorf("SignRound1: %w", err)
 	}
 
 	// Generate Sign2 messages
 	state.computeRhos()
-
-	state.R.Set(ristretto.NewIdentityElement())
-	for _, id := range state.SignerIDs {
-		p := state.Signers[id]
-
-		// mutate Ri in place
-		// Ri = Di + [ρi] Ei
-		p.Ri.ScalarMult(&p.Pi, &p.Ei)
-		p.Ri.Add(&p.Ri, &p.Di)
-
-		// R += Ri
-		state.R.Add(&state.R, &p.Ri)
-	}
+	computeGroupCommitment(state.SignerIDs, state.Signers, &state.R)
 
 	// R must be the same for all parties, the sum of all Ri
 	// fmt.Printf("R: %v\n", state.R)
 
 	// c = H(R, GroupKey, M)
-	state.C.Set(eddsa.ComputeChallenge(&state.R, &state.GroupKey, state.Message))
+	state.C.Set(computeChallenge(&state.R, &state.GroupKey, state.Message, state.Prehash))
 
 	// the challenge c must be the same for all parties
 
 	selfParty := state.Signers[state.SelfID]
 
+	// the nonces must be recorded before the share that uses them is released
+	if state.Ledger != nil {
+		if err := state.Ledger.Consume(&selfParty.Di, &selfParty.Ei); err != nil {
+			return nil, nil, fmt.Errorf("SignRound1: %w", err)
+		}
+	}
+
 	// Compute partial signature:
 	// z = d + (e • ρ) + 𝛌 • s • c
 	// Note: since we multiply the secret by the Lagrange coefficient,
@@ -330,56 +455,73 @@ func SignRound1(state *SignerState, inputMsgs []*Message) (*Message, *SignerStat
 	secretShare.Add(secretShare, &state.D)                        // d + (e • ρ) + 𝛌 • s • c
 
 	msg := NewSign2(state.SelfID, secretShare)
+	msg.SessionID = state.SessionID
 	return msg, state, nil
 }
 
-// SignRound2 computes the final signature.
-func SignRound2(state *SignerState, inputMsgs []*Message) (*eddsa.Signature, *SignerState, error) {
-	// Process Sign2 messages
+// SignRound2 computes the final signature. The secrets of state are zeroized
+// once the signature is computed, or a party is found cheating. Like
+// SignRound1, it can be called with the messages as they arrive.
+func SignRound2(state *SignerState, inputMsgs []*Message) (_ *eddsa.Signature, _ *SignerState, err error) {
+	obs := observeRound(state.Observer, state.Logger, state.SelfID, state.Received, MessageTypeSign2)
+	defer func() { obs.done(err) }()
+
+	// Process Sign2 messages, the shares are verified once all have arrived
+	state.Received.reserve(MessageTypeSign2, len(state.SignerIDs)-1)
 	for _, msg := range inputMsgs {
 		if msg.From == state.SelfID {
 			continue
 		}
+		obs.from = msg.From
 
-		id := msg.From
-		otherParty, ok := state.Signers[id]
-		if !ok {
-			return nil, nil, fmt.Errorf("SignRound2: party %d not found in shares", id)
+		if err := msg.Validate(MessageTypeSign2, state.SessionID, state.SelfID); err != nil {
+			return nil, nil, fmt.Errorf("SignRound2: %w", err)
 		}
 
-		var publicNeg, RPrime, ZiB ristretto.Element
-		publicNeg.Negate(&otherParty.Public)
-
-		// RPrime = [c](-A) + [zi]B
-		ZiB.ScalarBaseMult(&msg.Sign2.Zi)
-		RPrime.ScalarMult(&state.C, &publicNeg)
-		RPrime.Add(&ZiB, &RPrime)
-
-		// Verify the signature share
-		if RPrime.Equal(&otherParty.Ri) != 1 {
-			fmt.Printf("222  Calculated RPrime: %v\n", RPrime)
-			return nil, nil, errors.New("signature share is invalid")
+		otherParty, ok := state.Signers[msg.From]
+		if !ok {
+			return nil, nil, fmt.Errorf("SignRound2: %w", &ErrUnknownParty{ID: msg.From})
+		}
+		if err := state.Received.add(MessageTypeSign2, msg.From); err != nil {
+			return nil, nil, fmt.Errorf("SignRound2: %w", err)
 		}
-
 		otherParty.Zi.Set(&msg.Sign2.Zi)
+		obs.received(msg)
+	}
+	obs.from = 0
+	if err := state.Received.complete(MessageTypeSign2, state.SignerIDs, state.SelfID); err != nil {
+		return nil, nil, fmt.Errorf("SignRound2: %w", err)
 	}
 
+	// Verify all signature shares at once, and one by one to find the culprit
+	// only if that fails
+	if !verifyShares(&state.C, state.Signers, state.Received[MessageTypeSign2]) {
+		for _, id := range state.Received[MessageTypeSign2] {
+			otherParty := state.Signers[id]
+			if !otherParty.verifyShare(&state.C, &otherParty.Zi) {
+				err := newAbortError(id, &otherParty.Zi, state.SignerIDs, state.Signers, state.Message, state.Request, state.RFC9591, state.Prehash)
+				state.Zeroize()
+				return nil, nil, err
+			}
+		}
+	}
 	// Generate output
 
 	// S = ∑ sᵢ
-	S := ristretto.NewScalar()
-	for _, otherParty := range state.Signers {
+	var S ristretto.Scalar
+	for _, id := range state.SignerIDs {
 		// s += sᵢ
-		S.Add(S, &otherParty.Zi)
+		S.Add(&S, &state.Signers[id].Zi)
 	}
 
 	sig := &eddsa.Signature{
 		R: state.R,
-		S: *S,
+		S: S,
 	}
+	state.Zeroize()
 
-	if !state.GroupKey.Verify(state.Message, sig) {
-		return nil, nil, errors.New("full signature is invalid")
+	if !verifySignature(&state.GroupKey, state.Message, sig, state.Prehash) {
+		return nil, nil, fmt.Errorf("SignRound2: full signature is invalid: %w", ErrInvalidShare)
 	}
 
 	return sig, state, nil
@@ -393,44 +535,184 @@ func SignRound2(state *SignerState, inputMsgs []*Message) (*eddsa.Signature, *Si
 // identity and the message, enhancing the security and integrity of the
 // threshold signing process.
 func (state *SignerState) computeRhos() {
+	if state.RFC9591 {
+		computeRhosRFC9591(state.SignerIDs, state.Signers, &state.GroupKey, state.Message)
+		return
+	}
+	computeRhos(state.SignerIDs, state.Signers, state.Message, state.Request)
+}
+
+// computeRhos sets the binding factor Pi of every signer, see SignerState.computeRhos.
+// It only uses public information, so it is shared with the Aggregator.
+func computeRhos(signerIDs party.IDSlice, signers map[party.ID]*signer, message []byte, request *SignatureRequest) {
 	var hashDomainSeparation = []byte("FROST-SHA512")
-	messageHash := sha512.Sum512(state.Message)
+	messageHash := sha512.Sum512(message)
 
-	sizeB := int(state.SignerIDs.N() * (party.IDByteSize + 32 + 32))
+	sizeB := int(signerIDs.N() * (party.IDByteSize + 32 + 32))
 	bufferHeader := len(hashDomainSeparation) + party.IDByteSize + len(messageHash)
-	sizeBuffer := bufferHeader + sizeB
+	// with room for the digest of request
+	sizeBuffer := bufferHeader + sizeB + sha512.Size
 	offsetID := len(hashDomainSeparation)
 
 	// We compute the binding factor 𝜌_{i} for each party as such:
 	//
-	//     𝜌_d = SHA-512 ("FROST-SHA512" ∥ i ∥ SHA-512(Message) ∥ B )
+	//     𝜌_d = SHA-512 ("FROST-SHA512" ∥ i ∥ SHA-512(Message) ∥ B [∥ Request] )
 	//
-	// For each party ID i.
+	// For each party ID i. Request is the digest of the SignatureRequest, if any.
 	//
 	// The list B is the concatenation of ( j ∥ Dⱼ ∥ Eⱼ ) for all signers j in sorted order.
 	//     B = (ID1 ∥ D₁ ∥ E₁) ∥ (ID_2 ∥ D₂ ∥ E₂) ∥ ... ∥ (ID_N ∥ D_N ∥ E_N)
 
 	// We compute the big buffer "FROST-SHA512" ∥ ... ∥ SHA-512(Message) ∥ B
 	// and remember the offset of ... . Later we will write the ID of each party at this place.
-	buffer := make([]byte, 0, sizeBuffer)
+	sc := getScratch()
+	defer putScratch(sc)
+	buffer := sc.bytes(sizeBuffer)
 	buffer = append(buffer, hashDomainSeparation...)
-	buffer = append(buffer, state.SelfID.Bytes()...)
+	buffer = append(buffer, signerIDs[0].Bytes()...)
 	buffer = append(buffer, messageHash[:]...)
 
 	// compute B
-	for _, id := range state.SignerIDs {
-		otherParty := state.Signers[id]
+	for _, id := range signerIDs {
+		otherParty := signers[id]
 		buffer = append(buffer, id.Bytes()...)
 		buffer = append(buffer, otherParty.Di.Bytes()...)
 		buffer = append(buffer, otherParty.Ei.Bytes()...)
 	}
 
-	for _, id := range state.SignerIDs {
+	if request != nil {
+		buffer = append(buffer, request.Digest()...)
+	}
+	sc.buf = buffer
+
+	for _, id := range signerIDs {
 		// Update the four bytes with the ID
 		copy(buffer[offsetID:], id.Bytes())
 
 		// Pi = ρ = H ("FROST-SHA512" ∥ Message ∥ B ∥ ID )
 		digest := sha512.Sum512(buffer)
-		_, _ = state.Signers[id].Pi.SetUniformBytes(digest[:])
+		_, _ = signers[id].Pi.SetUniformBytes(digest[:])
+	}
+}
+
+// computeGroupCommitment sets Ri = Di + [ρi] Ei for every signer, and R = ∑ Ri.
+// The binding factors must have been computed before.
+func computeGroupCommitment(signerIDs party.IDSlice, signers map[party.ID]*signer, R *ristretto.Element) {
+	R.Set(identity)
+	for _, id := range signerIDs {
+		p := signers[id]
+
+		// mutate Ri in place
+		// Ri = Di + [ρi] Ei
+		p.Ri.ScalarMult(&p.Pi, &p.Ei)
+		p.Ri.Add(&p.Ri, &p.Di)
+
+		// R += Ri
+		R.Add(R, &p.Ri)
+	}
+}
+
+// verifyShares checks the signature shares Zi of the signers ids with a
+// random linear combination of their equations [zi]B = Ri + [c]Ai, with a
+// single multi-scalar multiplication:
+//
+//	[∑ λᵢzᵢ]B - ∑ [λᵢ]Rᵢ - ∑ [λᵢc]Aᵢ = 0
+//
+// It returns false if any share is invalid, but not which one.
+func verifyShares(c *ristretto.Scalar, signers map[party.ID]*signer, ids party.IDSlice) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	sc := getScratch()
+	defer putScratch(sc)
+
+	// λᵢ are random 128 bit scalars, read at once
+	random := sc.bytes(16 * len(ids))[:16*len(ids)]
+	if _, err := rand.Read(random); err != nil {
+		return false
+	}
+	scalars, points := sc.terms(2*len(ids) + 1)
+	sum := &scalars[2*len(ids)]
+	sum.Set(zero)
+	var buf [32]byte
+	for i, id := range ids {
+		s := signers[id]
+		copy(buf[:16], random[16*i:])
+		l, negL, negLc := &scalars[2*i], &scalars[2*i], &scalars[2*i+1]
+		if _, err := l.SetCanonicalBytes(buf[:]); err != nil {
+			return false
+		}
+		sum.MultiplyAdd(l, &s.Zi, sum)
+		negL.Negate(l)
+		negLc.Multiply(negL, c)
+		points[2*i], points[2*i+1] = &s.Ri, &s.Public
+	}
+	points[2*len(ids)] = generator
+
+	var check ristretto.Element
+	vartime.MultiScalarMult(&check, sc.scalarPtrs, points)
+	return check.Equal(identity) == 1
+}
+
+var (
+	// identity, generator and zero must not be modified.
+	identity  = ristretto.NewIdentityElement()
+	generator = ristretto.NewGeneratorElement()
+	zero      = ristretto.NewScalar()
+)
+
+// scratch holds the buffers of computeRhos and verifyShares, so that a
+// service signing at a high rate does not allocate them in every round, nor
+// for every co-signer. They only hold public values.
+type scratch struct {
+	buf        []byte
+	scalars    []ristretto.Scalar
+	scalarPtrs []*ristretto.Scalar
+	points     []*ristretto.Element
+}
+
+var scratchPool = sync.Pool{New: func() any { return new(scratch) }}
+
+func getScratch() *scratch {
+	return scratchPool.Get().(*scratch)
+}
+
+// putScratch returns sc to the pool, without the elements it points to.
+func putScratch(sc *scratch) {
+	clear(sc.points)
+	scratchPool.Put(sc)
+}
+
+// bytes returns sc.buf emptied, with a capacity of at least n.
+func (sc *scratch) bytes(n int) []byte {
+	if cap(sc.buf) < n {
+		sc.buf = make([]byte, 0, n)
+	}
+	return sc.buf[:0]
+}
+
+// terms returns n scalars and as many points, for a multi-scalar
+// multiplication with sc.scalarPtrs, which point to the scalars.
+func (sc *scratch) terms(n int) ([]ristretto.Scalar, []*ristretto.Element) {
+	if cap(sc.scalars) < n {
+		sc.scalars = make([]ristretto.Scalar, n)
+		sc.scalarPtrs = make([]*ristretto.Scalar, n)
+		sc.points = make([]*ristretto.Element, n)
 	}
+	sc.scalars, sc.scalarPtrs, sc.points = sc.scalars[:n], sc.scalarPtrs[:n], sc.points[:n]
+	for i := range sc.scalars {
+		sc.scalarPtrs[i] = &sc.scalars[i]
+	}
+	return sc.scalars, sc.points
+}
+
+// verifyShare checks that [zi]B = Ri + [c]Ai, where Ai is the
+// Lagrange-adjusted public share of s.
+func (s *signer) verifyShare(c, zi *ristretto.Scalar) bool {
+	var publicNeg, RPrime ristretto.Element
+	publicNeg.Negate(&s.Public)
+
+	// RPrime = [c](-A) + [zi]B
+	vartime.DoubleScalarBaseMult(&RPrime, c, &publicNeg, zi)
+	return RPrime.Equal(&s.Ri) == 1
 }
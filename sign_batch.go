@@ -0,0 +1,340 @@
+package frost
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+	"github.com/bartke/frost/scalar"
+)
+
+// MaxBatchSize bounds the number of messages signed in one batch.
+const MaxBatchSize = 4096
+
+// BatchSignerState is the state of a signer in a session signing a batch of
+// messages in the two rounds of a single signature, see SignBatchInit. The
+// SignBatch1 message carries a pair of nonces for every message, and the
+// SignBatch2 message a signature share for every message.
+//
+// It holds a SignerState for every message of the batch, with the nonces,
+// binding factors and shares of that message alone. The binding factors of
+// every message are bound to its index and to all messages and nonces of the
+// batch, so that the shares of a batch cannot be combined with those of
+// another.
+type BatchSignerState struct {
+	// States are the states of the messages of the batch, in order.
+	States []*SignerState
+	// SessionID is the session the state belongs to, see SignBatchInitWithSession.
+	SessionID SessionID
+	// Received records the parties whose SignBatch1 and SignBatch2 messages were processed.
+	Received Received
+	// Ledger, if set, records our nonces in SignBatchRound1 and refuses to use them twice. It is not serialized.
+	Ledger NonceLedger
+	// Observer, if set, is notified of the progress of the rounds. It is not serialized.
+	Observer Observer
+	// Logger, if set, logs the progress of the rounds at debug level. It is not serialized.
+	Logger *slog.Logger
+}
+
+// SignBatchInit initializes the state for signing all messages with the same
+// signers in one session.
+func SignBatchInit(signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, messages [][]byte) (*Message, *BatchSignerState, error) {
+	if len(messages) == 0 || len(messages) > MaxBatchSize {
+		return nil, nil, fmt.Errorf("SignBatchInit: %d messages, expected 1 to %d", len(messages), MaxBatchSize)
+	}
+	group, err := NewSigningGroup(signerIDs, shares)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signBatchInit(group, secret, messages, rand.Reader)
+}
+
+// SignBatchInitWithSession is SignBatchInit for the session with the given ID,
+// see SignInitWithSession.
+func SignBatchInitWithSession(session SessionID, signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, messages [][]byte) (*Message, *BatchSignerState, error) {
+	msg, state, err := SignBatchInit(signerIDs, secret, shares, messages)
+	if err != nil {
+		return nil, nil, err
+	}
+	state.SessionID = session
+	for _, s := range state.States {
+		s.SessionID = session
+	}
+	msg.SessionID = session
+	return msg, state, nil
+}
+
+func signBatchInit(group *SigningGroup, secret *eddsa.SecretShare, messages [][]byte, rng io.Reader) (*Message, *BatchSignerState, error) {
+	state := &BatchSignerState{States: make([]*SignerState, len(messages))}
+	nonces := make([]Sign1, len(messages))
+	for i, message := range messages {
+		s, err := newSignerState(group, secret, message)
+		if err != nil {
+			return nil, nil, err
+		}
+		selfParty := s.Signers[s.SelfID]
+
+		// Sample dᵢ, Dᵢ = [dᵢ] B and eᵢ, Eᵢ = [eᵢ] B for every message
+		scalar.SetScalarRandomFrom(&s.D, rng)
+		selfParty.Di.ScalarBaseMult(&s.D)
+		scalar.SetScalarRandomFrom(&s.E, rng)
+		selfParty.Ei.ScalarBaseMult(&s.E)
+
+		nonces[i] = Sign1{Di: selfParty.Di, Ei: selfParty.Ei}
+		state.States[i] = s
+	}
+
+	msg := NewSignBatch1(state.selfID(), nonces)
+	return msg, state, nil
+}
+
+// selfID returns the ID of the owner of the state.
+func (state *BatchSignerState) selfID() party.ID {
+	return state.States[0].SelfID
+}
+
+// signerIDs returns the signers of the batch.
+func (state *BatchSignerState) signerIDs() party.IDSlice {
+	return state.States[0].SignerIDs
+}
+
+// Messages returns the messages of the batch, in order.
+func (state *BatchSignerState) Messages() [][]byte {
+	messages := make([][]byte, len(state.States))
+	for i, s := range state.States {
+		messages[i] = s.Message
+	}
+	return messages
+}
+
+// Zeroize zeroizes the states of all messages, see SignerState.Zeroize.
+func (state *BatchSignerState) Zeroize() {
+	for _, s := range state.States {
+		s.Zeroize()
+	}
+}
+
+// SignBatchRound1 processes the SignBatch1 messages of the other signers, and
+// returns our SignBatch2 message once those of all were processed, like
+// SignRound1.
+func SignBatchRound1(state *BatchSignerState, inputMsgs []*Message) (_ *Message, _ *BatchSignerState, err error) {
+	selfID := state.selfID()
+	obs := observeRound(state.Observer, state.Logger, selfID, state.Received, MessageTypeSignBatch1)
+	defer func() { obs.done(err) }()
+
+	for _, msg := range inputMsgs {
+		if msg.From == selfID {
+			continue
+		}
+		obs.from = msg.From
+
+		if err := msg.Validate(MessageTypeSignBatch1, state.SessionID, selfID); err != nil {
+			return nil, nil, fmt.Errorf("SignBatchRound1: %w", err)
+		}
+		if !state.signerIDs().Contains(msg.From) {
+			return nil, nil, fmt.Errorf("SignBatchRound1: %w", &ErrUnknownParty{ID: msg.From})
+		}
+		nonces := msg.SignBatch1.Nonces
+		if len(nonces) != len(state.States) {
+			return nil, nil, fmt.Errorf("SignBatchRound1: %d nonces from party %d for %d messages: %w", len(nonces), msg.From, len(state.States), ErrInvalidMessage)
+		}
+		for i := range nonces {
+			if nonces[i].Di.Equal(ristretto.NewIdentityElement()) == 1 || nonces[i].Ei.Equal(ristretto.NewIdentityElement()) == 1 {
+				return nil, nil, fmt.Errorf("SignBatchRound1: commitment Ei or Di of party %d for message %d was the identity: %w", msg.From, i, ErrInvalidMessage)
+			}
+		}
+		if err := state.Received.add(MessageTypeSignBatch1, msg.From); err != nil {
+			return nil, nil, fmt.Errorf("SignBatchRound1: %w", err)
+		}
+		for i, s := range state.States {
+			otherParty := s.Signers[msg.From]
+			otherParty.Di.Set(&nonces[i].Di)
+			otherParty.Ei.Set(&nonces[i].Ei)
+		}
+		obs.received(msg)
+	}
+	obs.from = 0
+	if err := state.Received.complete(MessageTypeSignBatch1, state.signerIDs(), selfID); err != nil {
+		return nil, nil, fmt.Errorf("SignBatchRound1: %w", err)
+	}
+
+	bindings := batchBindings(state.States)
+	for i, s := range state.States {
+		computeRhos(s.SignerIDs, s.Signers, bindings[i], nil)
+		computeGroupCommitment(s.SignerIDs, s.Signers, &s.R)
+		s.C.Set(computeChallenge(&s.R, &s.GroupKey, s.Message, false))
+	}
+
+	// the nonces of all messages must be recorded before any share is released
+	if state.Ledger != nil {
+		for _, s := range state.States {
+			selfParty := s.Signers[selfID]
+			if err := state.Ledger.Consume(&selfParty.Di, &selfParty.Ei); err != nil {
+				return nil, nil, fmt.Errorf("SignBatchRound1: %w", err)
+			}
+		}
+	}
+
+	shares := make([]ristretto.Scalar, len(state.States))
+	for i, s := range state.States {
+		selfParty := s.Signers[selfID]
+
+		// z = d + (e • ρ) + 𝛌 • s • c, with 𝛌 in the secret share
+		secretShare := &selfParty.Zi
+		secretShare.Multiply(&s.SecretKeyShare, &s.C)
+		secretShare.MultiplyAdd(&s.E, &selfParty.Pi, secretShare)
+		secretShare.Add(secretShare, &s.D)
+		shares[i].Set(secretShare)
+	}
+
+	msg := NewSignBatch2(selfID, shares)
+	msg.SessionID = state.SessionID
+	return msg, state, nil
+}
+
+// SignBatchRound2 computes the signatures of the messages of the batch, in
+// order, like SignRound2. The AbortError of a party that sent an invalid share
+// carries no Evidence, since VerifyAbortEvidence does not recompute the
+// binding factors of a batch.
+func SignBatchRound2(state *BatchSignerState, inputMsgs []*Message) (_ []*eddsa.Signature, _ *BatchSignerState, err error) {
+	selfID := state.selfID()
+	obs := observeRound(state.Observer, state.Logger, selfID, state.Received, MessageTypeSignBatch2)
+	defer func() { obs.done(err) }()
+
+	for _, msg := range inputMsgs {
+		if msg.From == selfID {
+			continue
+		}
+		obs.from = msg.From
+
+		if err := msg.Validate(MessageTypeSignBatch2, state.SessionID, selfID); err != nil {
+			return nil, nil, fmt.Errorf("SignBatchRound2: %w", err)
+		}
+		if !state.signerIDs().Contains(msg.From) {
+			return nil, nil, fmt.Errorf("SignBatchRound2: %w", &ErrUnknownParty{ID: msg.From})
+		}
+		shares := msg.SignBatch2.Shares
+		if len(shares) != len(state.States) {
+			return nil, nil, fmt.Errorf("SignBatchRound2: %d shares from party %d for %d messages: %w", len(shares), msg.From, len(state.States), ErrInvalidMessage)
+		}
+		if err := state.Received.add(MessageTypeSignBatch2, msg.From); err != nil {
+			return nil, nil, fmt.Errorf("SignBatchRound2: %w", err)
+		}
+		for i, s := range state.States {
+			s.Signers[msg.From].Zi.Set(&shares[i])
+		}
+		obs.received(msg)
+	}
+	obs.from = 0
+	if err := state.Received.complete(MessageTypeSignBatch2, state.signerIDs(), selfID); err != nil {
+		return nil, nil, fmt.Errorf("SignBatchRound2: %w", err)
+	}
+
+	sigs := make([]*eddsa.Signature, len(state.States))
+	for i, s := range state.States {
+		ids := state.Received[MessageTypeSignBatch2]
+		if !verifyShares(&s.C, s.Signers, ids) {
+			for _, id := range ids {
+				if !s.Signers[id].verifyShare(&s.C, &s.Signers[id].Zi) {
+					state.Zeroize()
+					return nil, nil, fmt.Errorf("SignBatchRound2: message %d: %w", i, &AbortError{Culprit: id})
+				}
+			}
+		}
+
+		// S = ∑ sᵢ
+		var S ristretto.Scalar
+		for _, id := range s.SignerIDs {
+			S.Add(&S, &s.Signers[id].Zi)
+		}
+		sigs[i] = &eddsa.Signature{R: s.R, S: S}
+	}
+	state.Zeroize()
+
+	for i, s := range state.States {
+		if !verifySignature(&s.GroupKey, s.Message, sigs[i], false) {
+			return nil, nil, fmt.Errorf("SignBatchRound2: full signature of message %d is invalid", i)
+		}
+	}
+	return sigs, state, nil
+}
+
+// batchBindings returns for every message of the batch of states the input of
+// its binding factors, in place of the message:
+//
+//	"FROST-batch" ∥ k ∥ j ∥ SHA-512(M₀) ∥ ... ∥ SHA-512(Mₖ₋₁) ∥ SHA-512(B₀ ∥ ... ∥ Bₖ₋₁)
+//
+// for the message j of k, where Bⱼ is the list of the commitments of message j,
+// see computeRhos. computeRhos binds the factors to Bⱼ and this input.
+func batchBindings(states []*SignerState) [][]byte {
+	var domainSeparation = []byte("FROST-batch")
+
+	commitments := sha512.New()
+	for _, s := range states {
+		for _, id := range s.SignerIDs {
+			commitments.Write(id.Bytes())
+			commitments.Write(s.Signers[id].Di.Bytes())
+			commitments.Write(s.Signers[id].Ei.Bytes())
+		}
+	}
+
+	buffer := make([]byte, 0, len(domainSeparation)+8+64*(len(states)+1))
+	buffer = append(buffer, domainSeparation...)
+	buffer = binary.BigEndian.AppendUint32(buffer, uint32(len(states)))
+	offsetIndex := len(buffer)
+	buffer = binary.BigEndian.AppendUint32(buffer, 0)
+	for _, s := range states {
+		digest := sha512.Sum512(s.Message)
+		buffer = append(buffer, digest[:]...)
+	}
+	buffer = commitments.Sum(buffer)
+
+	bindings := make([][]byte, len(states))
+	for j := range states {
+		binary.BigEndian.PutUint32(buffer[offsetIndex:], uint32(j))
+		bindings[j] = append([]byte(nil), buffer...)
+	}
+	return bindings
+}
+
+func (state *BatchSignerState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		States   []*SignerState `json:"states"`
+		Session  string         `json:"session,omitempty"`
+		Received Received       `json:"received,omitempty"`
+	}{
+		States:   state.States,
+		Session:  state.SessionID.encode(),
+		Received: state.Received,
+	})
+}
+
+func (state *BatchSignerState) UnmarshalJSON(data []byte) error {
+	aux := &struct {
+		States   []*SignerState `json:"states"`
+		Session  string         `json:"session,omitempty"`
+		Received Received       `json:"received,omitempty"`
+	}{}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	if len(aux.States) == 0 || len(aux.States) > MaxBatchSize {
+		return errors.New("BatchSignerState: invalid number of messages")
+	}
+	for _, s := range aux.States {
+		if s == nil || s.SelfID != aux.States[0].SelfID || !s.SignerIDs.Equal(aux.States[0].SignerIDs) {
+			return errors.New("BatchSignerState: states of different signers")
+		}
+	}
+	state.States = aux.States
+	state.Received = aux.Received
+	return state.SessionID.decode(aux.Session)
+}
@@ -0,0 +1,126 @@
+package frost
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signBatchSession initializes a batch session of signers for messages.
+func signBatchSession(t *testing.T, signers party.IDSlice, messages [][]byte) (*eddsa.Public, map[party.ID]*BatchSignerState, []*Message) {
+	public, secrets := generateKeys(t, 5, 2)
+	session, err := NewSessionID()
+	require.NoError(t, err)
+	states := make(map[party.ID]*BatchSignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignBatchInitWithSession(session, signers, secrets[id], public, messages)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	return public, states, round1
+}
+
+func TestSignBatch(t *testing.T) {
+	signers := party.IDSlice{1, 3, 4}
+	messages := [][]byte{[]byte("block 1"), []byte("block 2"), []byte("block 2"), {}}
+	public, states, round1 := signBatchSession(t, signers, messages)
+
+	var round2 []*Message
+	for _, id := range signers {
+		msg, _, err := SignBatchRound1(states[id], round1)
+		require.NoError(t, err)
+		assert.Len(t, msg.SignBatch2.Shares, len(messages))
+		round2 = append(round2, msg)
+	}
+
+	for _, id := range signers {
+		sigs, _, err := SignBatchRound2(states[id], round2)
+		require.NoError(t, err)
+		require.Len(t, sigs, len(messages))
+		for i, sig := range sigs {
+			assert.True(t, public.GroupKey.Verify(messages[i], sig), i)
+		}
+		// the same message is signed with other nonces
+		assert.NotEqual(t, sigs[1].R.Bytes(), sigs[2].R.Bytes())
+	}
+}
+
+func TestSignBatch_Invalid(t *testing.T) {
+	signers := party.IDSlice{1, 2, 3}
+	messages := [][]byte{[]byte("a"), []byte("b")}
+	_, states, round1 := signBatchSession(t, signers, messages)
+
+	// a message with nonces for another number of messages
+	short := NewSignBatch1(2, round1[1].SignBatch1.Nonces[:1])
+	short.SessionID = round1[1].SessionID
+	_, _, err := SignBatchRound1(states[1], []*Message{short})
+	assert.True(t, errors.Is(err, ErrInvalidMessage))
+
+	_, _, err = SignBatchRound1(states[1], round1[1:2])
+	assert.True(t, errors.Is(err, ErrNeedMoreMessages))
+
+	// the rest of the messages completes the round
+	msg, _, err := SignBatchRound1(states[1], round1[2:])
+	require.NoError(t, err)
+	round2 := []*Message{msg}
+	for _, id := range signers[1:] {
+		msg, _, err := SignBatchRound1(states[id], round1)
+		require.NoError(t, err)
+		round2 = append(round2, msg)
+	}
+
+	// a signer sends an invalid share for the second message
+	round2[2].SignBatch2.Shares[1] = *scalar.NewScalarRandom()
+	_, _, err = SignBatchRound2(states[1], round2)
+	var abortErr *AbortError
+	require.True(t, errors.As(err, &abortErr))
+	assert.Equal(t, party.ID(3), abortErr.Culprit)
+	assert.Contains(t, err.Error(), "message 1")
+
+	_, _, err = SignBatchInit(signers, nil, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestSignBatch_Bindings(t *testing.T) {
+	signers := party.IDSlice{1, 2}
+	_, states, round1 := signBatchSession(t, signers, [][]byte{[]byte("a"), []byte("b")})
+	_, _, err := SignBatchRound1(states[1], round1)
+	require.NoError(t, err)
+
+	// the binding factors of a message of a batch differ from those of a single signature
+	s := states[1].States[0]
+	batch := s.Signers[1].Pi
+	computeRhos(s.SignerIDs, s.Signers, s.Message, nil)
+	assert.NotEqual(t, batch.Bytes(), s.Signers[1].Pi.Bytes())
+
+	bindings := batchBindings(states[1].States)
+	assert.NotEqual(t, bindings[0], bindings[1])
+}
+
+func TestSignBatch_Ledger(t *testing.T) {
+	signers := party.IDSlice{1, 2}
+	_, states, round1 := signBatchSession(t, signers, [][]byte{[]byte("a"), []byte("b")})
+	data, err := json.Marshal(states[1])
+	require.NoError(t, err)
+
+	ledger := NewMemoryNonceLedger()
+	states[1].Ledger = ledger
+	_, _, err = SignBatchRound1(states[1], round1)
+	require.NoError(t, err)
+
+	// a state restored from disk cannot release its shares again
+	var restored BatchSignerState
+	require.NoError(t, json.Unmarshal(data, &restored))
+	assert.Equal(t, states[1].SessionID, restored.SessionID)
+	restored.Ledger = ledger
+	_, _, err = SignBatchRound1(&restored, round1)
+	assert.True(t, errors.Is(err, ErrNonceReuse))
+}
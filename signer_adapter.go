@@ -0,0 +1,152 @@
+package frost
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+)
+
+// SignerTransport delivers msgs to the signer id, which is asked to sign
+// message, and returns its reply.
+//
+// It is called twice per signer and signature. The first call has no msgs and
+// expects the Sign1 message of SignInit. The second call has the commitments of
+// all signers, which the signer passes to SignRound1, and expects its Sign2 message.
+// The calls for different signers of a round are made concurrently.
+type SignerTransport func(ctx context.Context, id party.ID, message []byte, msgs []*Message) (*Message, error)
+
+// SignerAdapter implements crypto.Signer for the group key, so that it can be
+// used wherever an ed25519.PrivateKey is accepted, e.g. by crypto/tls or
+// crypto/x509. Each signature runs a Coordinator session with the signers
+// reached through the transport.
+type SignerAdapter struct {
+	SignerIDs party.IDSlice
+	Shares    *eddsa.Public
+	Transport SignerTransport
+}
+
+var _ crypto.Signer = (*SignerAdapter)(nil)
+
+// NewSignerAdapter returns a SignerAdapter for the signers signerIDs of the group shares.
+func NewSignerAdapter(signerIDs party.IDSlice, shares *eddsa.Public, transport SignerTransport) (*SignerAdapter, error) {
+	signerIDs = party.NewIDSlice(signerIDs)
+	if !signerIDs.IsSubsetOf(shares.PartyIDs) {
+		return nil, fmt.Errorf("SignerAdapter: partyIDs %v are not a subset of shares.PartyIDs %v", signerIDs, shares.PartyIDs)
+	}
+	if signerIDs.N() <= shares.Threshold {
+		return nil, fmt.Errorf("SignerAdapter: %d signers cannot meet threshold %d", signerIDs.N(), shares.Threshold)
+	}
+	if transport == nil {
+		return nil, errors.New("SignerAdapter: transport is nil")
+	}
+	return &SignerAdapter{SignerIDs: signerIDs, Shares: shares, Transport: transport}, nil
+}
+
+// Public returns the group key as an ed25519.PublicKey.
+func (s *SignerAdapter) Public() crypto.PublicKey {
+	return s.Shares.GroupKey.ToEd25519()
+}
+
+// Sign signs message with the group key and returns the 64 byte Ed25519
+// signature. Like ed25519.PrivateKey, opts.HashFunc() must be zero, since
+// Ed25519 signs the message itself. Ed25519ph and Ed25519ctx are not supported.
+// rand is ignored, the signers generate their own nonces.
+func (s *SignerAdapter) Sign(_ io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != crypto.Hash(0) {
+		return nil, errors.New("SignerAdapter: expected unhashed message (opts.HashFunc() must be zero)")
+	}
+	if o, ok := opts.(*ed25519.Options); ok && o.Context != "" {
+		return nil, errors.New("SignerAdapter: Ed25519ctx is not supported")
+	}
+	sig, err := s.SignContext(context.Background(), message)
+	if err != nil {
+		return nil, err
+	}
+	return sig.ToEd25519(), nil
+}
+
+// SignContext runs a signing session for message, and stops waiting for the
+// signers once ctx is done.
+func (s *SignerAdapter) SignContext(ctx context.Context, message []byte) (*eddsa.Signature, error) {
+	c, err := NewCoordinator(s.SignerIDs, s.Shares, message)
+	if err != nil {
+		return nil, fmt.Errorf("SignerAdapter: %w", err)
+	}
+
+	commitments, err := s.round(ctx, message, nil)
+	if err != nil {
+		return nil, err
+	}
+	var forwarded []*Message
+	for _, msg := range commitments {
+		if forwarded, err = c.Add(msg); err != nil {
+			return nil, fmt.Errorf("SignerAdapter: %w", err)
+		}
+	}
+
+	shares, err := s.round(ctx, message, forwarded)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range shares {
+		if _, err = c.Add(msg); err != nil {
+			return nil, fmt.Errorf("SignerAdapter: %w", err)
+		}
+	}
+
+	sig, ok := c.Signature()
+	if !ok {
+		return nil, errors.New("SignerAdapter: missing signature shares")
+	}
+	return sig, nil
+}
+
+// round sends msgs to every signer, and returns their replies in the order of SignerIDs.
+func (s *SignerAdapter) round(ctx context.Context, message []byte, msgs []*Message) ([]*Message, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	replies := make([]*Message, len(s.SignerIDs))
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			// the other calls are canceled, their errors are not the cause
+			firstErr = err
+			cancel()
+		}
+	}
+	for i, id := range s.SignerIDs {
+		wg.Add(1)
+		go func(i int, id party.ID) {
+			defer wg.Done()
+			reply, err := s.Transport(ctx, id, message, msgs)
+			switch {
+			case err != nil:
+				fail(fmt.Errorf("SignerAdapter: party %d: %w", id, err))
+			case reply == nil || reply.From != id:
+				fail(fmt.Errorf("SignerAdapter: invalid reply from party %d", id))
+			default:
+				replies[i] = reply
+			}
+		}(i, id)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return replies, nil
+}
@@ -0,0 +1,135 @@
+package frost
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// localSigners answers the requests of a SignerAdapter with in-process signers.
+func localSigners(t *testing.T, signers party.IDSlice, public *eddsa.Public, secrets map[party.ID]*eddsa.SecretShare) SignerTransport {
+	var mu sync.Mutex
+	states := make(map[party.ID]*SignerState)
+	return func(_ context.Context, id party.ID, message []byte, msgs []*Message) (*Message, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if msgs == nil {
+			msg, state, err := SignInit(signers, secrets[id], public, message)
+			if err != nil {
+				return nil, err
+			}
+			states[id] = state
+			return msg, nil
+		}
+		msg, _, err := SignRound1(states[id], msgs)
+		return msg, err
+	}
+}
+
+func TestSignerAdapter(t *testing.T) {
+	public, secrets := generateKeys(t, 5, 2)
+	signers := party.IDSlice{2, 3, 5}
+	s, err := NewSignerAdapter(signers, public, localSigners(t, signers, public, secrets))
+	require.NoError(t, err)
+
+	pub, ok := s.Public().(ed25519.PublicKey)
+	require.True(t, ok)
+	message := []byte("crypto.Signer")
+	sig, err := s.Sign(rand.Reader, message, crypto.Hash(0))
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(pub, message, sig))
+
+	_, err = s.Sign(rand.Reader, message, crypto.SHA512)
+	assert.Error(t, err, "Ed25519ph")
+	_, err = s.Sign(rand.Reader, message, &ed25519.Options{Context: "ctx"})
+	assert.Error(t, err, "Ed25519ctx")
+
+	_, err = NewSignerAdapter(party.IDSlice{2, 3}, public, s.Transport)
+	assert.Error(t, err, "below threshold")
+	_, err = NewSignerAdapter(party.IDSlice{2, 3, 6}, public, s.Transport)
+	assert.Error(t, err, "unknown party")
+}
+
+func TestSignerAdapter_X509(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	signers := party.IDSlice{1, 3}
+	s, err := NewSignerAdapter(signers, public, localSigners(t, signers, public, secrets))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "frost"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, s.Public(), s)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	assert.NoError(t, cert.CheckSignatureFrom(cert))
+}
+
+func TestSignerAdapter_Failures(t *testing.T) {
+	public, secrets := generateKeys(t, 3, 1)
+	signers := party.IDSlice{1, 2}
+	local := localSigners(t, signers, public, secrets)
+	errOffline := errors.New("offline")
+
+	s, err := NewSignerAdapter(signers, public, func(ctx context.Context, id party.ID, message []byte, msgs []*Message) (*Message, error) {
+		if id == 2 && msgs != nil {
+			return nil, errOffline
+		}
+		return local(ctx, id, message, msgs)
+	})
+	require.NoError(t, err)
+	_, err = s.SignContext(context.Background(), []byte("message"))
+	assert.True(t, errors.Is(err, errOffline))
+
+	s.Transport = func(ctx context.Context, id party.ID, message []byte, msgs []*Message) (*Message, error) {
+		msg, err := local(ctx, id, message, msgs)
+		if id == 1 && msgs != nil {
+			msg = NewSign2(id, scalar.NewScalarRandom())
+		}
+		return msg, err
+	}
+	_, err = s.SignContext(context.Background(), []byte("message"))
+	var abort *AbortError
+	require.True(t, errors.As(err, &abort))
+	assert.Equal(t, party.ID(1), abort.Culprit)
+
+	s.Transport = func(ctx context.Context, id party.ID, message []byte, msgs []*Message) (*Message, error) {
+		msg, err := local(ctx, id, message, msgs)
+		if msg != nil {
+			msg.From = 3
+		}
+		return msg, err
+	}
+	_, err = s.SignContext(context.Background(), []byte("message"))
+	assert.Error(t, err, "reply from the wrong party")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	s.Transport = func(ctx context.Context, id party.ID, message []byte, msgs []*Message) (*Message, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	_, err = s.SignContext(ctx, []byte("message"))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
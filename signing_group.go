@@ -0,0 +1,73 @@
+package frost
+
+import (
+	"fmt"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+)
+
+// SigningGroup holds the values of a signing session that only depend on the
+// set of signers: their Lagrange coefficients, and their public shares multiplied
+// by them. When the same signers sign many messages, a SigningGroup can be created
+// once and passed to SignInitWithGroup, instead of recomputing these in every SignInit.
+//
+// A SigningGroup is not modified by SignInitWithGroup, and can be shared between sessions.
+type SigningGroup struct {
+	SignerIDs party.IDSlice
+	GroupKey  eddsa.PublicKey
+
+	// Lagrange maps every signer to its Lagrange coefficient 𝛌ᵢ for SignerIDs.
+	Lagrange map[party.ID]*ristretto.Scalar
+	// Public maps every signer to its public share multiplied by 𝛌ᵢ.
+	Public map[party.ID]*ristretto.Element
+}
+
+// NewSigningGroup precomputes the values of a signing session between signerIDs.
+func NewSigningGroup(signerIDs party.IDSlice, shares *eddsa.Public) (*SigningGroup, error) {
+	// the binding factors and Lagrange coefficients depend on the order of the
+	// signers, which may be passed in any order, but not twice
+	signerIDs = signerIDs.Sorted()
+	if err := signerIDs.Validate(); err != nil {
+		return nil, fmt.Errorf("SignRound0: %w", err)
+	}
+
+	if !signerIDs.IsSubsetOf(shares.PartyIDs) {
+		return nil, fmt.Errorf("SignRound0: partyIDs %v are not a subset of shares.PartyIDs %v", signerIDs, shares.PartyIDs)
+	}
+
+	g := &SigningGroup{
+		SignerIDs: signerIDs,
+		GroupKey:  *shares.GroupKey,
+		Lagrange:  make(map[party.ID]*ristretto.Scalar, signerIDs.N()),
+		Public:    make(map[party.ID]*ristretto.Element, signerIDs.N()),
+	}
+
+	for _, id := range signerIDs {
+		originalShare, ok := shares.Shares[id]
+		if !ok {
+			return nil, fmt.Errorf("SignRound0: party %d not found in shares", id)
+		}
+
+		lagrange, err := id.Lagrange(signerIDs)
+		if err != nil {
+			return nil, fmt.Errorf("SignRound0: %w", err)
+		}
+		g.Lagrange[id] = lagrange
+		g.Public[id] = new(ristretto.Element).ScalarMult(lagrange, originalShare)
+	}
+
+	return g, nil
+}
+
+// newSigners returns the initial state of every signer of a new session.
+func (g *SigningGroup) newSigners() map[party.ID]*signer {
+	signers := make(map[party.ID]*signer, len(g.Public))
+	for id, public := range g.Public {
+		s := NewSigner()
+		s.Public.Set(public)
+		signers[id] = s
+	}
+	return signers
+}
@@ -0,0 +1,64 @@
+package frost
+
+import (
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignInitWithGroup(t *testing.T) {
+	public, secrets := generateKeys(t, 5, 2)
+	group, err := NewSigningGroup(party.IDSlice{5, 2, 3}, public)
+	require.NoError(t, err)
+	assert.Equal(t, party.IDSlice{2, 3, 5}, group.SignerIDs)
+
+	// the group is reused for several messages
+	for _, message := range []string{"first", "second"} {
+		states := make(map[party.ID]*SignerState)
+		var round1 []*Message
+		for _, id := range group.SignerIDs {
+			msg, state, err := SignInitWithGroup(group, secrets[id], []byte(message))
+			require.NoError(t, err)
+			states[id] = state
+			round1 = append(round1, msg)
+		}
+		sigs, err := runSignRounds(states, round1)
+		require.NoError(t, err)
+		for _, sig := range sigs {
+			assert.True(t, public.GroupKey.Verify([]byte(message), sig))
+		}
+	}
+
+	_, _, err = SignInitWithGroup(group, secrets[1], []byte("not a signer"))
+	assert.Error(t, err)
+
+	_, err = NewSigningGroup(party.IDSlice{1, 6}, public)
+	assert.Error(t, err)
+
+	// a signer passed twice is rejected instead of being dropped
+	for _, signers := range []party.IDSlice{{2, 3, 2}, {0, 2, 3}} {
+		_, _, err = SignInit(signers, secrets[2], public, []byte("message"))
+		assert.Error(t, err, signers)
+		_, err = NewAggregator(signers, public, []byte("message"))
+		assert.Error(t, err, signers)
+	}
+}
+
+func BenchmarkSignInit(b *testing.B) {
+	public, secrets := generateKeys(b, 20, 9)
+	signers := public.PartyIDs[:10]
+
+	b.Run("SignInit", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _, _ = SignInit(signers, secrets[1], public, nil)
+		}
+	})
+	b.Run("SignInitWithGroup", func(b *testing.B) {
+		group, _ := NewSigningGroup(signers, public)
+		for i := 0; i < b.N; i++ {
+			_, _, _ = SignInitWithGroup(group, secrets[1], nil)
+		}
+	})
+}
@@ -0,0 +1,177 @@
+// Package sshca issues OpenSSH user and host certificates under the group key,
+// so that a threshold of parties acts as an SSH certificate authority.
+//
+// Certificates are issued in two steps, like attestations and bundles: New
+// returns the unsigned certificate, whose SignedData the group signs with the
+// usual protocol, and Attach adds the signature. Sign does both with a
+// crypto.Signer, such as a frost.SignerAdapter. Servers trust the CA with the
+// authorized_keys line of eddsa.PublicKey.MarshalOpenSSH, prefixed with
+// cert-authority, or with TrustedUserCAKeys.
+package sshca
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bartke/frost/eddsa"
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultUserExtensions are the extensions that ssh-keygen grants user
+// certificates unless told otherwise.
+var DefaultUserExtensions = map[string]string{
+	"permit-X11-forwarding":   "",
+	"permit-agent-forwarding": "",
+	"permit-port-forwarding":  "",
+	"permit-pty":              "",
+	"permit-user-rc":          "",
+}
+
+// Options are the fields of a certificate.
+type Options struct {
+	// CertType is ssh.UserCert or ssh.HostCert.
+	CertType uint32
+	// KeyID identifies the certificate in the logs of the servers.
+	KeyID string
+	// Principals are the user names, or the host names, the certificate is valid for.
+	Principals []string
+	// Serial is the serial number of the certificate, e.g. for revocation lists.
+	Serial uint64
+	// ValidAfter and ValidBefore bound the validity of the certificate. A zero
+	// ValidAfter is valid since forever, a zero ValidBefore until forever.
+	ValidAfter, ValidBefore time.Time
+	// CriticalOptions, such as "force-command" and "source-address".
+	CriticalOptions map[string]string
+	// Extensions, such as DefaultUserExtensions. Host certificates have none.
+	Extensions map[string]string
+}
+
+// New returns the certificate of key with opts, unsigned, for the CA key ca.
+func New(key ssh.PublicKey, ca *eddsa.PublicKey, opts Options) (*ssh.Certificate, error) {
+	if key == nil {
+		return nil, errors.New("sshca: no key to certify")
+	}
+	if _, ok := key.(*ssh.Certificate); ok {
+		return nil, errors.New("sshca: cannot certify a certificate")
+	}
+	switch opts.CertType {
+	case ssh.UserCert:
+	case ssh.HostCert:
+		if len(opts.Extensions) > 0 {
+			return nil, errors.New("sshca: host certificates have no extensions")
+		}
+	default:
+		return nil, fmt.Errorf("sshca: unknown certificate type %d", opts.CertType)
+	}
+	caKey, err := ssh.NewPublicKey(ed25519.PublicKey(ca.ToEd25519()))
+	if err != nil {
+		return nil, fmt.Errorf("sshca: %w", err)
+	}
+
+	cert := &ssh.Certificate{
+		Key:             key,
+		Serial:          opts.Serial,
+		CertType:        opts.CertType,
+		KeyId:           opts.KeyID,
+		ValidPrincipals: opts.Principals,
+		ValidBefore:     ssh.CertTimeInfinity,
+		Permissions: ssh.Permissions{
+			CriticalOptions: opts.CriticalOptions,
+			Extensions:      opts.Extensions,
+		},
+		SignatureKey: caKey,
+		Nonce:        make([]byte, 32),
+	}
+	if !opts.ValidAfter.IsZero() {
+		cert.ValidAfter = uint64(opts.ValidAfter.Unix())
+	}
+	if !opts.ValidBefore.IsZero() {
+		cert.ValidBefore = uint64(opts.ValidBefore.Unix())
+	}
+	if cert.ValidBefore <= cert.ValidAfter {
+		return nil, errors.New("sshca: certificate expires before it becomes valid")
+	}
+	if _, err := rand.Read(cert.Nonce); err != nil {
+		return nil, fmt.Errorf("sshca: %w", err)
+	}
+	return cert, nil
+}
+
+// SignedData returns the bytes of cert that the CA signs: its encoding up to
+// and including the CA key, without the signature.
+func SignedData(cert *ssh.Certificate) []byte {
+	unsigned := *cert
+	unsigned.Signature = nil
+	data := unsigned.Marshal()
+	// an empty signature is encoded as its length alone
+	return data[:len(data)-4]
+}
+
+// SignatureBlob returns the SSH encoding of an Ed25519 signature, the string
+// "ssh-ed25519" followed by the 64 byte signature, each prefixed with its length.
+func SignatureBlob(sig []byte) []byte {
+	return ssh.Marshal(&ssh.Signature{Format: ssh.KeyAlgoED25519, Blob: sig})
+}
+
+// Attach returns the certificate signed with sig, the Ed25519 signature of
+// the group over data, as returned by SignedData. It returns an error if the
+// signature does not verify under the CA key of the certificate.
+func Attach(data, sig []byte) (*ssh.Certificate, error) {
+	if len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("sshca: signature of %d bytes", len(sig))
+	}
+	blob := SignatureBlob(sig)
+	encoded := make([]byte, 0, len(data)+4+len(blob))
+	encoded = append(encoded, data...)
+	encoded = binary.BigEndian.AppendUint32(encoded, uint32(len(blob)))
+	encoded = append(encoded, blob...)
+
+	key, err := ssh.ParsePublicKey(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("sshca: %w", err)
+	}
+	cert, ok := key.(*ssh.Certificate)
+	if !ok {
+		return nil, errors.New("sshca: not a certificate")
+	}
+	if err := verify(cert); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// Sign signs cert with signer, which must hold the CA key of cert, e.g. a
+// frost.SignerAdapter of the group, and returns the signed certificate.
+func Sign(cert *ssh.Certificate, signer crypto.Signer) (*ssh.Certificate, error) {
+	data := SignedData(cert)
+	sig, err := signer.Sign(rand.Reader, data, crypto.Hash(0))
+	if err != nil {
+		return nil, fmt.Errorf("sshca: %w", err)
+	}
+	return Attach(data, sig)
+}
+
+// Verify checks that cert is signed by the CA key ca. It does not check the
+// validity period or the principals, which ssh.CertChecker does on use.
+func Verify(cert *ssh.Certificate, ca *eddsa.PublicKey) error {
+	caKey, ok := cert.SignatureKey.(ssh.CryptoPublicKey)
+	if !ok || !ed25519.PublicKey(ca.ToEd25519()).Equal(caKey.CryptoPublicKey()) {
+		return errors.New("sshca: certificate is signed by another CA")
+	}
+	return verify(cert)
+}
+
+func verify(cert *ssh.Certificate) error {
+	if cert.Signature == nil || cert.Signature.Format != ssh.KeyAlgoED25519 {
+		return errors.New("sshca: certificate is not signed with ed25519")
+	}
+	if err := cert.SignatureKey.Verify(SignedData(cert), cert.Signature); err != nil {
+		return fmt.Errorf("sshca: invalid signature: %w", err)
+	}
+	return nil
+}
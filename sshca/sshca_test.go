@@ -0,0 +1,148 @@
+package sshca
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost/frostclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// groupSigner implements crypto.Signer with a signing session of the group.
+type groupSigner struct {
+	group *frostclient.Group
+}
+
+func (s groupSigner) Public() crypto.PublicKey {
+	return s.group.Public.GroupKey.ToEd25519()
+}
+
+func (s groupSigner) Sign(_ io.Reader, message []byte, _ crypto.SignerOpts) ([]byte, error) {
+	sig, err := frostclient.Sign(context.Background(), s.group, message)
+	if err != nil {
+		return nil, err
+	}
+	return sig.ToEd25519(), nil
+}
+
+func userKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+	return key
+}
+
+func TestUserCertificate(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	ca := group.Public.GroupKey
+	key := userKey(t)
+	now := time.Now()
+
+	cert, err := New(key, ca, Options{
+		CertType:    ssh.UserCert,
+		KeyID:       "alice@example.com",
+		Principals:  []string{"alice"},
+		Serial:      7,
+		ValidAfter:  now.Add(-time.Minute),
+		ValidBefore: now.Add(time.Hour),
+		Extensions:  DefaultUserExtensions,
+	})
+	require.NoError(t, err)
+
+	// signed by the group in a separate step
+	data := SignedData(cert)
+	sig, err := frostclient.Sign(context.Background(), group, data)
+	require.NoError(t, err)
+	signed, err := Attach(data, sig.ToEd25519())
+	require.NoError(t, err)
+	require.NoError(t, Verify(signed, ca))
+	assert.Equal(t, "alice@example.com", signed.KeyId)
+	assert.Equal(t, uint64(7), signed.Serial)
+
+	// a server trusting the CA accepts the certificate for alice only
+	caKey, err := ssh.NewPublicKey(ed25519.PublicKey(ca.ToEd25519()))
+	require.NoError(t, err)
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return string(auth.Marshal()) == string(caKey.Marshal())
+		},
+	}
+	_, err = checker.Authenticate(connMetadata("alice"), signed)
+	assert.NoError(t, err)
+	_, err = checker.Authenticate(connMetadata("root"), signed)
+	assert.Error(t, err)
+
+	// the encoding round trips through an authorized_keys style line
+	parsed, _, _, _, err := ssh.ParseAuthorizedKey(ssh.MarshalAuthorizedKey(signed))
+	require.NoError(t, err)
+	require.NoError(t, Verify(parsed.(*ssh.Certificate), ca))
+
+	// a signature of other data is rejected
+	_, err = Attach(data, ed25519.Sign(ed25519.NewKeyFromSeed(make([]byte, 32)), data))
+	assert.Error(t, err)
+	other, err := frostclient.CreateGroup(frostclient.Config{N: 2, T: 1})
+	require.NoError(t, err)
+	assert.Error(t, Verify(signed, other.Public.GroupKey))
+}
+
+func TestHostCertificate(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	ca := group.Public.GroupKey
+
+	cert, err := New(userKey(t), ca, Options{CertType: ssh.HostCert, KeyID: "web-1", Principals: []string{"web-1.example.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(ssh.CertTimeInfinity), cert.ValidBefore)
+	signed, err := Sign(cert, groupSigner{group})
+	require.NoError(t, err)
+	require.NoError(t, Verify(signed, ca))
+
+	checker := &ssh.CertChecker{IsHostAuthority: func(ssh.PublicKey, string) bool { return true }}
+	assert.NoError(t, checker.CheckHostKey("web-1.example.com:22", &net.TCPAddr{}, signed))
+	assert.Error(t, checker.CheckHostKey("db-1.example.com:22", &net.TCPAddr{}, signed))
+
+	// a signer of another group
+	other, err := frostclient.CreateGroup(frostclient.Config{N: 2, T: 1})
+	require.NoError(t, err)
+	_, err = Sign(cert, groupSigner{other})
+	assert.Error(t, err)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 2, T: 1})
+	require.NoError(t, err)
+	ca := group.Public.GroupKey
+	key := userKey(t)
+	now := time.Now()
+
+	for _, opts := range []Options{
+		{CertType: 3},
+		{CertType: ssh.HostCert, Extensions: DefaultUserExtensions},
+		{CertType: ssh.UserCert, ValidAfter: now, ValidBefore: now.Add(-time.Second)},
+	} {
+		_, err := New(key, ca, opts)
+		assert.Error(t, err)
+	}
+	_, err = New(nil, ca, Options{CertType: ssh.UserCert})
+	assert.Error(t, err)
+}
+
+// connMetadata is the ssh.ConnMetadata of a connection of user.
+type connMetadata string
+
+func (c connMetadata) User() string          { return string(c) }
+func (c connMetadata) SessionID() []byte     { return nil }
+func (c connMetadata) ClientVersion() []byte { return nil }
+func (c connMetadata) ServerVersion() []byte { return nil }
+func (c connMetadata) RemoteAddr() net.Addr  { return &net.TCPAddr{} }
+func (c connMetadata) LocalAddr() net.Addr   { return &net.TCPAddr{} }
@@ -0,0 +1,217 @@
+package standing
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+)
+
+// record is a line of the book file, exactly one field is set.
+type record struct {
+	Grant  *Grant `json:"grant,omitempty"`
+	Use    string `json:"use,omitempty"`
+	Revoke string `json:"revoke,omitempty"`
+
+	// Request and At describe a use.
+	Request string    `json:"request,omitempty"`
+	At      time.Time `json:"at,omitempty"`
+}
+
+// Status is an instruction together with its usage.
+type Status struct {
+	Instruction Instruction `json:"instruction"`
+	Uses        uint64      `json:"uses"`
+	Revoked     bool        `json:"revoked,omitempty"`
+}
+
+// Book holds the standing instructions of one party and counts their uses.
+// Entries are appended to a file with one JSON record per line, and synced
+// before Add, Authorize or Revoke return, so that uses are not forgotten after a restart.
+type Book struct {
+	public *eddsa.Public
+	self   party.ID
+
+	mu     sync.Mutex
+	order  []string
+	status map[string]*Status
+	file   *os.File
+}
+
+// OpenBook opens or creates the book of the party self of the group public
+// stored at path. If path is empty, the book is only kept in memory.
+func OpenBook(public *eddsa.Public, self party.ID, path string) (*Book, error) {
+	b := &Book{public: public, self: self, status: make(map[string]*Status)}
+	if path == "" {
+		return b, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("standing: %w", err)
+	}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("standing: corrupt book entry: %w", err)
+		}
+		if err := b.apply(&r); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("standing: corrupt book: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("standing: %w", err)
+	}
+	b.file = f
+	return b, nil
+}
+
+// apply checks r against the book and updates the state, without writing it.
+func (b *Book) apply(r *record) error {
+	switch {
+	case r.Grant != nil:
+		if err := b.check(r.Grant); err != nil {
+			return err
+		}
+		in := r.Grant.Instruction
+		b.order = append(b.order, in.ID)
+		b.status[in.ID] = &Status{Instruction: in}
+	case r.Use != "":
+		s, ok := b.status[r.Use]
+		if !ok {
+			return fmt.Errorf("standing: unknown instruction %q", r.Use)
+		}
+		s.Uses++
+	case r.Revoke != "":
+		s, ok := b.status[r.Revoke]
+		if !ok {
+			return fmt.Errorf("standing: unknown instruction %q", r.Revoke)
+		}
+		s.Revoked = true
+	default:
+		return errors.New("standing: empty record")
+	}
+	return nil
+}
+
+// write appends r to the file and applies it.
+func (b *Book) write(r *record) error {
+	if b.file != nil {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		if _, err := b.file.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("standing: %w", err)
+		}
+		if err := b.file.Sync(); err != nil {
+			return fmt.Errorf("standing: %w", err)
+		}
+	}
+	return b.apply(r)
+}
+
+// Add verifies g and records it. The grant must be issued by the party of the book.
+func (b *Book) Add(g *Grant) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// check before writing, so that invalid grants are not persisted
+	if err := b.check(g); err != nil {
+		return err
+	}
+	return b.write(&record{Grant: g})
+}
+
+// check verifies that g can be added.
+func (b *Book) check(g *Grant) error {
+	in := g.Instruction
+	if in.Party != b.self {
+		return fmt.Errorf("standing: grant of party %d, not %d", in.Party, b.self)
+	}
+	if _, ok := b.status[in.ID]; ok {
+		return fmt.Errorf("standing: duplicate instruction %q", in.ID)
+	}
+	return g.Verify(b.public)
+}
+
+// Revoke stops honoring the instruction id. Revocation is permanent.
+func (b *Book) Revoke(id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.status[id]; !ok {
+		return fmt.Errorf("standing: unknown instruction %q", id)
+	}
+	return b.write(&record{Revoke: id})
+}
+
+// Authorize looks for an active instruction that covers req and records the use.
+// It returns the instruction, or an error wrapping ErrNotCovered with the reasons
+// why the instructions do not apply, in which case the request needs interactive approval.
+func (b *Book) Authorize(req *frost.SignatureRequest, now time.Time) (*Instruction, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var reasons []string
+	for _, id := range b.order {
+		s := b.status[id]
+		in := &s.Instruction
+		var reason string
+		switch {
+		case s.Revoked:
+			continue
+		case !in.Active(now):
+			reason = "not active"
+		case in.MaxUses != 0 && s.Uses >= in.MaxUses:
+			reason = "used up"
+		default:
+			if err := in.Policy.Covers(req); err != nil {
+				reason = err.Error()
+			}
+		}
+		if reason != "" {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", id, reason))
+			continue
+		}
+		if err := b.write(&record{Use: id, Request: req.ID, At: now.UTC()}); err != nil {
+			return nil, err
+		}
+		out := *in
+		return &out, nil
+	}
+	if len(reasons) == 0 {
+		return nil, ErrNotCovered
+	}
+	return nil, fmt.Errorf("%w (%s)", ErrNotCovered, strings.Join(reasons, "; "))
+}
+
+// Instructions returns all instructions in the order they were added.
+func (b *Book) Instructions() []Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]Status, 0, len(b.order))
+	for _, id := range b.order {
+		out = append(out, *b.status[id])
+	}
+	return out
+}
+
+// Close closes the underlying file, if any.
+func (b *Book) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	return b.file.Close()
+}
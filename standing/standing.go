@@ -0,0 +1,225 @@
+// Package standing lets a party pre-approve signatures that match a narrow
+// policy, so that its daemon can sign them without asking the operator.
+//
+// An Instruction describes the requests a party is willing to sign, such as
+// payments to one destination up to an amount, and is only valid within an
+// expiry window and for a limited number of uses. The party binds it to its
+// share with a Schnorr proof of knowledge of the secret share, so a Grant cannot
+// be created or altered without the share, and cannot be moved to another
+// party or group. Requests that no instruction covers still need interactive
+// approval.
+package standing
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/manifest"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/zk"
+)
+
+const grantContext = "frost-standing-v1"
+
+var (
+	// ErrNotCovered is returned if no instruction covers a request, which then
+	// requires interactive approval.
+	ErrNotCovered = errors.New("standing: request is not covered by a standing instruction")
+	// ErrInvalidGrant is returned if the proof of a grant does not verify.
+	ErrInvalidGrant = errors.New("standing: invalid grant")
+)
+
+// Policy restricts the requests an instruction covers. Empty fields match any request.
+type Policy struct {
+	// Format, Chain and Purpose must equal the fields of the request.
+	Format  string `json:"format,omitempty"`
+	Chain   string `json:"chain,omitempty"`
+	Purpose string `json:"purpose,omitempty"`
+
+	// Match holds metadata values the request must have, e.g. a destination address.
+	Match map[string]string `json:"match,omitempty"`
+
+	// Max caps metadata values that are decimal integers, e.g. an amount in
+	// the smallest unit of a currency. Requests without the value are not covered.
+	Max map[string]string `json:"max,omitempty"`
+}
+
+// Validate checks that all caps are decimal integers.
+func (p *Policy) Validate() error {
+	for k, v := range p.Max {
+		if _, ok := new(big.Int).SetString(v, 10); !ok {
+			return fmt.Errorf("standing: cap of %q is not an integer", k)
+		}
+	}
+	return nil
+}
+
+// Covers returns nil if req satisfies the policy, or the reason it does not.
+func (p *Policy) Covers(req *frost.SignatureRequest) error {
+	if p.Format != "" && req.Format != p.Format {
+		return fmt.Errorf("format %q is not %q", req.Format, p.Format)
+	}
+	if p.Chain != "" && req.Chain != p.Chain {
+		return fmt.Errorf("chain %q is not %q", req.Chain, p.Chain)
+	}
+	if p.Purpose != "" && req.Purpose != p.Purpose {
+		return fmt.Errorf("purpose %q is not %q", req.Purpose, p.Purpose)
+	}
+	for k, want := range p.Match {
+		if got, ok := req.Metadata[k]; !ok || got != want {
+			return fmt.Errorf("%s %q is not %q", k, got, want)
+		}
+	}
+	for k, limit := range p.Max {
+		max, ok := new(big.Int).SetString(limit, 10)
+		if !ok {
+			return fmt.Errorf("cap of %s is not an integer", k)
+		}
+		v, ok := new(big.Int).SetString(req.Metadata[k], 10)
+		if !ok {
+			return fmt.Errorf("%s %q is not an integer", k, req.Metadata[k])
+		}
+		if v.Cmp(max) > 0 {
+			return fmt.Errorf("%s %s exceeds %s", k, v, max)
+		}
+	}
+	return nil
+}
+
+// Instruction pre-approves the requests covered by Policy for one party of a group.
+type Instruction struct {
+	// ID names the instruction, it must be unique per party.
+	ID string `json:"id"`
+	// Group is the fingerprint of the group key, see manifest.Fingerprint.
+	Group string `json:"group"`
+	// Party is the party that pre-approves.
+	Party  party.ID `json:"party"`
+	Policy Policy   `json:"policy"`
+
+	// NotBefore and Expires bound the window in which the instruction is honored.
+	// A zero NotBefore means the instruction is valid immediately, Expires is required.
+	NotBefore time.Time `json:"not_before,omitempty"`
+	Expires   time.Time `json:"expires"`
+
+	// MaxUses limits the number of requests signed under the instruction, 0 means no limit.
+	MaxUses uint64 `json:"max_uses,omitempty"`
+}
+
+// Validate checks that the instruction is well formed.
+func (in *Instruction) Validate() error {
+	if in.ID == "" {
+		return errors.New("standing: instruction without ID")
+	}
+	if in.Expires.IsZero() {
+		return errors.New("standing: instruction without expiry")
+	}
+	if !in.NotBefore.IsZero() && !in.NotBefore.Before(in.Expires) {
+		return errors.New("standing: instruction expires before it is valid")
+	}
+	return in.Policy.Validate()
+}
+
+// Active returns true if now is within the window of the instruction.
+func (in *Instruction) Active(now time.Time) bool {
+	return !now.Before(in.NotBefore) && now.Before(in.Expires)
+}
+
+// proofContext binds a grant to the group and the encoding of the instruction.
+// The JSON encoding is deterministic, map keys are sorted.
+func proofContext(public *eddsa.Public, in *Instruction) ([]byte, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha512.Sum512(body)
+	ctx := append([]byte(grantContext), public.GroupKey.ToEd25519()...)
+	return append(ctx, digest[:]...), nil
+}
+
+// Grant is an instruction with the proof that the owner of the share of its party issued it.
+type Grant struct {
+	Instruction Instruction
+	Proof       *zk.Schnorr
+}
+
+// NewGrant sets the group and party of in to those of secret, and proves with
+// the share that the instruction is issued by its owner.
+func NewGrant(secret *eddsa.SecretShare, public *eddsa.Public, in Instruction) (*Grant, error) {
+	share, ok := public.Shares[secret.ID]
+	if !ok {
+		return nil, errors.New("standing: party is not in the group")
+	}
+	in.Group = manifest.Fingerprint(public.GroupKey.ToEd25519())
+	in.Party = secret.ID
+	in.NotBefore, in.Expires = in.NotBefore.UTC(), in.Expires.UTC()
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, err := proofContext(public, &in)
+	if err != nil {
+		return nil, err
+	}
+	return &Grant{Instruction: in, Proof: zk.NewSchnorrProof(secret.ID, share, ctx, &secret.Secret)}, nil
+}
+
+// Verify checks that g was issued for the group public by the owner of the share of its party.
+func (g *Grant) Verify(public *eddsa.Public) error {
+	in := &g.Instruction
+	if err := in.Validate(); err != nil {
+		return err
+	}
+	if in.Group != manifest.Fingerprint(public.GroupKey.ToEd25519()) {
+		return errors.New("standing: grant is for a different group")
+	}
+	share, ok := public.Shares[in.Party]
+	if !ok || g.Proof == nil {
+		return ErrInvalidGrant
+	}
+	ctx, err := proofContext(public, in)
+	if err != nil {
+		return err
+	}
+	if !g.Proof.Verify(in.Party, share, ctx) {
+		return ErrInvalidGrant
+	}
+	return nil
+}
+
+type grantJSON struct {
+	Instruction Instruction `json:"instruction"`
+	Proof       string      `json:"proof"`
+}
+
+// MarshalJSON encodes the proof in hex.
+func (g *Grant) MarshalJSON() ([]byte, error) {
+	proof, err := g.Proof.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(grantJSON{Instruction: g.Instruction, Proof: hex.EncodeToString(proof)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (g *Grant) UnmarshalJSON(data []byte) error {
+	var v grantJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	proof, err := hex.DecodeString(v.Proof)
+	if err != nil {
+		return fmt.Errorf("standing: invalid proof encoding: %w", err)
+	}
+	g.Proof = new(zk.Schnorr)
+	if err := g.Proof.UnmarshalBinary(proof); err != nil {
+		return fmt.Errorf("standing: invalid proof: %w", err)
+	}
+	g.Instruction = v.Instruction
+	return nil
+}
@@ -0,0 +1,127 @@
+package standing
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/frostclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func payment(id, to, amount string) *frost.SignatureRequest {
+	return &frost.SignatureRequest{
+		ID:       id,
+		Message:  []byte("tx " + id),
+		Chain:    "example-1",
+		Metadata: map[string]string{"to": to, "amount": amount},
+	}
+}
+
+func TestPolicy_Covers(t *testing.T) {
+	p := Policy{Chain: "example-1", Match: map[string]string{"to": "addr1"}, Max: map[string]string{"amount": "1000"}}
+	require.NoError(t, p.Validate())
+
+	assert.NoError(t, p.Covers(payment("1", "addr1", "1000")))
+	assert.Error(t, p.Covers(payment("2", "addr1", "1001")), "over the cap")
+	assert.Error(t, p.Covers(payment("3", "addr2", "10")), "other destination")
+	assert.Error(t, p.Covers(payment("4", "addr1", "1e3")), "not an integer")
+	assert.Error(t, p.Covers(&frost.SignatureRequest{Chain: "example-1", Metadata: map[string]string{"to": "addr1"}}), "no amount")
+
+	other := payment("5", "addr1", "10")
+	other.Chain = "example-2"
+	assert.Error(t, p.Covers(other))
+
+	assert.Error(t, (&Policy{Max: map[string]string{"amount": "ten"}}).Validate())
+}
+
+func TestGrant(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+
+	g, err := NewGrant(group.Shares[2], group.Public, Instruction{ID: "payroll", Expires: now.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, g.Instruction.Party)
+	require.NoError(t, g.Verify(group.Public))
+
+	data, err := json.Marshal(g)
+	require.NoError(t, err)
+	var decoded Grant
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.NoError(t, decoded.Verify(group.Public))
+
+	// the proof covers the whole instruction and the party
+	decoded.Instruction.Policy.Max = map[string]string{"amount": "1"}
+	assert.True(t, errors.Is(decoded.Verify(group.Public), ErrInvalidGrant))
+	decoded = *g
+	decoded.Instruction.Party = 3
+	assert.True(t, errors.Is(decoded.Verify(group.Public), ErrInvalidGrant))
+
+	other, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	assert.Error(t, g.Verify(other.Public))
+
+	_, err = NewGrant(group.Shares[2], group.Public, Instruction{ID: "forever"})
+	assert.Error(t, err, "instructions must expire")
+}
+
+func TestBook(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "standing.jsonl")
+
+	book, err := OpenBook(group.Public, 1, path)
+	require.NoError(t, err)
+
+	_, err = book.Authorize(payment("0", "addr1", "5"), now)
+	assert.True(t, errors.Is(err, ErrNotCovered))
+
+	g, err := NewGrant(group.Shares[1], group.Public, Instruction{
+		ID:        "rent",
+		Policy:    Policy{Match: map[string]string{"to": "addr1"}, Max: map[string]string{"amount": "100"}},
+		NotBefore: now,
+		Expires:   now.Add(time.Hour),
+		MaxUses:   2,
+	})
+	require.NoError(t, err)
+	require.NoError(t, book.Add(g))
+	assert.Error(t, book.Add(g), "instruction IDs are unique")
+
+	foreign, err := NewGrant(group.Shares[2], group.Public, Instruction{ID: "other", Expires: now.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Error(t, book.Add(foreign), "only our own grants are honored")
+
+	_, err = book.Authorize(payment("1", "addr1", "50"), now.Add(-time.Second))
+	assert.True(t, errors.Is(err, ErrNotCovered), "not yet valid")
+	in, err := book.Authorize(payment("2", "addr1", "50"), now)
+	require.NoError(t, err)
+	assert.Equal(t, "rent", in.ID)
+	_, err = book.Authorize(payment("3", "addr1", "500"), now)
+	assert.True(t, errors.Is(err, ErrNotCovered), "over the cap")
+	_, err = book.Authorize(payment("4", "addr2", "50"), now)
+	assert.True(t, errors.Is(err, ErrNotCovered), "other destination")
+	require.NoError(t, book.Close())
+
+	// uses are counted across restarts
+	book, err = OpenBook(group.Public, 1, path)
+	require.NoError(t, err)
+	defer book.Close()
+	require.Len(t, book.Instructions(), 1)
+	assert.EqualValues(t, 1, book.Instructions()[0].Uses)
+	_, err = book.Authorize(payment("5", "addr1", "50"), now.Add(time.Hour))
+	assert.True(t, errors.Is(err, ErrNotCovered), "expired")
+	_, err = book.Authorize(payment("6", "addr1", "50"), now)
+	require.NoError(t, err)
+	_, err = book.Authorize(payment("7", "addr1", "50"), now)
+	assert.True(t, errors.Is(err, ErrNotCovered), "used up")
+
+	require.NoError(t, book.Revoke("rent"))
+	assert.True(t, book.Instructions()[0].Revoked)
+	assert.Error(t, book.Revoke("unknown"))
+}
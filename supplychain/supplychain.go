@@ -0,0 +1,164 @@
+// Package supplychain encodes signatures of the group key in the formats that
+// supply-chain tooling consumes: the signature objects of TUF and in-toto
+// metadata, and Sigstore bundles for message signatures, so that a threshold
+// signature can be dropped into a pipeline next to single-key signatures.
+package supplychain
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidSignature is returned if a signature does not verify under the group key.
+var ErrInvalidSignature = errors.New("supplychain: invalid signature")
+
+// TUFKey is the group key as it is listed in the keys of TUF root metadata.
+type TUFKey struct {
+	KeyType string `json:"keytype"`
+	KeyVal  struct {
+		Public string `json:"public"`
+	} `json:"keyval"`
+	Scheme string `json:"scheme"`
+}
+
+// NewTUFKey returns the TUF key of pub, of type and scheme ed25519.
+func NewTUFKey(pub ed25519.PublicKey) TUFKey {
+	k := TUFKey{KeyType: "ed25519", Scheme: "ed25519"}
+	k.KeyVal.Public = hex.EncodeToString(pub)
+	return k
+}
+
+// ID returns the key ID of k, the hex encoded SHA-256 of its canonical JSON.
+func (k TUFKey) ID() string {
+	// the members are in lexicographic order, and hex strings need no escaping,
+	// so this is the canonical JSON of the key
+	data, _ := json.Marshal(k)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// TUFSignature is an entry of the signatures of TUF and in-toto metadata.
+// Sig is hex encoded, as the TUF specification requires.
+type TUFSignature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// NewTUFSignature returns the signature entry of sig, the Ed25519 signature of
+// the group with key pub over the canonical metadata.
+func NewTUFSignature(pub ed25519.PublicKey, sig []byte) TUFSignature {
+	return TUFSignature{KeyID: NewTUFKey(pub).ID(), Sig: hex.EncodeToString(sig)}
+}
+
+// Verify checks that s is a signature of message by pub.
+func (s TUFSignature) Verify(pub ed25519.PublicKey, message []byte) error {
+	if s.KeyID != NewTUFKey(pub).ID() {
+		return fmt.Errorf("supplychain: signature of key %s", s.KeyID)
+	}
+	sig, err := hex.DecodeString(s.Sig)
+	if err != nil || !verify(pub, message, sig, false) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// SigstoreMediaType is the media type of the bundles returned by NewSigstoreBundle.
+const SigstoreMediaType = "application/vnd.dev.sigstore.bundle.v0.3+json"
+
+// SigstoreBundle is a Sigstore bundle of a message signature made with a
+// public key, without transparency log entries, in its JSON encoding.
+type SigstoreBundle struct {
+	MediaType            string `json:"mediaType"`
+	VerificationMaterial struct {
+		PublicKey struct {
+			Hint string `json:"hint"`
+		} `json:"publicKey"`
+	} `json:"verificationMaterial"`
+	MessageSignature struct {
+		MessageDigest struct {
+			Algorithm string `json:"algorithm"`
+			Digest    []byte `json:"digest"`
+		} `json:"messageDigest"`
+		Signature []byte `json:"signature"`
+	} `json:"messageSignature"`
+}
+
+// SigstoreHint returns the public key hint of pub in Sigstore bundles, the
+// base64 encoded SHA-256 of its PKIX encoding, as used by cosign.
+func SigstoreHint(pub ed25519.PublicKey) string {
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+	sum := sha256.Sum256(der)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// NewSigstoreBundle returns the bundle of sig, the Ed25519 signature of the
+// group with key pub over message. If prehash is set, message is the SHA-512
+// digest that was signed with Ed25519ph, otherwise the bundle records the
+// SHA-256 digest of the message.
+func NewSigstoreBundle(pub ed25519.PublicKey, message, sig []byte, prehash bool) *SigstoreBundle {
+	b := &SigstoreBundle{MediaType: SigstoreMediaType}
+	b.VerificationMaterial.PublicKey.Hint = SigstoreHint(pub)
+	if prehash {
+		b.MessageSignature.MessageDigest.Algorithm = "SHA2_512"
+		b.MessageSignature.MessageDigest.Digest = append([]byte(nil), message...)
+	} else {
+		digest := sha256.Sum256(message)
+		b.MessageSignature.MessageDigest.Algorithm = "SHA2_256"
+		b.MessageSignature.MessageDigest.Digest = digest[:]
+	}
+	b.MessageSignature.Signature = append([]byte(nil), sig...)
+	return b
+}
+
+// Verify checks that b holds a signature of the artifact by pub.
+func (b *SigstoreBundle) Verify(pub ed25519.PublicKey, artifact []byte) error {
+	if b.MediaType != SigstoreMediaType {
+		return fmt.Errorf("supplychain: unsupported bundle type %q", b.MediaType)
+	}
+	if b.VerificationMaterial.PublicKey.Hint != SigstoreHint(pub) {
+		return errors.New("supplychain: bundle of another key")
+	}
+	md := b.MessageSignature.MessageDigest
+	switch md.Algorithm {
+	case "SHA2_256":
+		digest := sha256.Sum256(artifact)
+		if !bytes.Equal(md.Digest, digest[:]) {
+			return errors.New("supplychain: artifact digest does not match")
+		}
+		if !verify(pub, artifact, b.MessageSignature.Signature, false) {
+			return ErrInvalidSignature
+		}
+	case "SHA2_512":
+		digest := sha512.Sum512(artifact)
+		if !bytes.Equal(md.Digest, digest[:]) {
+			return errors.New("supplychain: artifact digest does not match")
+		}
+		if !verify(pub, digest[:], b.MessageSignature.Signature, true) {
+			return ErrInvalidSignature
+		}
+	default:
+		return fmt.Errorf("supplychain: unsupported digest algorithm %q", md.Algorithm)
+	}
+	return nil
+}
+
+// verify checks an Ed25519 signature, or an Ed25519ph signature of a digest if prehash is set.
+func verify(pub ed25519.PublicKey, message, sig []byte, prehash bool) bool {
+	if len(pub) != ed25519.PublicKeySize {
+		return false
+	}
+	opts := &ed25519.Options{}
+	if prehash {
+		opts.Hash = crypto.SHA512
+	}
+	return ed25519.VerifyWithOptions(pub, message, sig, opts) == nil
+}
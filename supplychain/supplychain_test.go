@@ -0,0 +1,107 @@
+package supplychain
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/frostclient"
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTUFKeyID(t *testing.T) {
+	// the public key of test 1 of RFC 8032, with the key ID computed by
+	// hashing the canonical JSON of the key independently
+	pub, _ := hex.DecodeString("d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")
+	key := NewTUFKey(pub)
+	assert.Equal(t, "74c181c7ad8a0855d4b55e44d2ba87aabdddb196832571f15f92fece332e4916", key.ID())
+
+	data, err := json.Marshal(key)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"keytype":"ed25519","scheme":"ed25519","keyval":{"public":"d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"}}`, string(data))
+}
+
+func TestTUFSignature(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	pub := ed25519.PublicKey(group.Public.GroupKey.ToEd25519())
+
+	metadata := []byte(`{"_type":"targets","spec_version":"1.0.31","version":1}`)
+	sig, err := frostclient.Sign(context.Background(), group, metadata)
+	require.NoError(t, err)
+
+	s := NewTUFSignature(pub, sig.ToEd25519())
+	assert.Equal(t, NewTUFKey(pub).ID(), s.KeyID)
+	assert.Equal(t, hex.EncodeToString(sig.ToEd25519()), s.Sig)
+	require.NoError(t, s.Verify(pub, metadata))
+
+	assert.True(t, errors.Is(s.Verify(pub, metadata[1:]), ErrInvalidSignature))
+	other, err := frostclient.CreateGroup(frostclient.Config{N: 2, T: 1})
+	require.NoError(t, err)
+	assert.Error(t, s.Verify(other.Public.GroupKey.ToEd25519(), metadata))
+}
+
+func TestSigstoreBundle(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	pub := ed25519.PublicKey(group.Public.GroupKey.ToEd25519())
+	artifact := []byte("release-v1.2.3.tar.gz")
+
+	sig, err := frostclient.Sign(context.Background(), group, artifact)
+	require.NoError(t, err)
+	bundle := NewSigstoreBundle(pub, artifact, sig.ToEd25519(), false)
+
+	// the bundle round trips through its JSON encoding, with base64 bytes
+	data, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, SigstoreMediaType, decoded["mediaType"])
+	var parsed SigstoreBundle
+	require.NoError(t, json.Unmarshal(data, &parsed))
+	require.NoError(t, parsed.Verify(pub, artifact))
+	assert.Equal(t, "SHA2_256", parsed.MessageSignature.MessageDigest.Algorithm)
+
+	assert.Error(t, parsed.Verify(pub, artifact[1:]))
+	parsed.MessageSignature.Signature[0] ^= 1
+	assert.True(t, errors.Is(parsed.Verify(pub, artifact), ErrInvalidSignature))
+}
+
+func TestSigstoreBundle_Prehash(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	pub := ed25519.PublicKey(group.Public.GroupKey.ToEd25519())
+	artifact := []byte("a large artifact")
+	digest := sha512.Sum512(artifact)
+
+	// sign the digest with Ed25519ph, as cmd/sign --ph does
+	signers := group.Signers
+	round1 := make([]*frost.Message, 0, len(signers))
+	states := make(map[party.ID]*frost.SignerState)
+	for _, id := range signers {
+		msg, state, err := frost.SignInitPrehashed(signers, group.Shares[id], group.Public, digest[:])
+		require.NoError(t, err)
+		round1 = append(round1, msg)
+		states[id] = state
+	}
+	round2 := make([]*frost.Message, 0, len(signers))
+	for _, id := range signers {
+		msg, _, err := frost.SignRound1(states[id], round1)
+		require.NoError(t, err)
+		round2 = append(round2, msg)
+	}
+	sig, _, err := frost.SignRound2(states[signers[0]], round2)
+	require.NoError(t, err)
+
+	bundle := NewSigstoreBundle(pub, digest[:], sig.ToEd25519(), true)
+	assert.Equal(t, "SHA2_512", bundle.MessageSignature.MessageDigest.Algorithm)
+	require.NoError(t, bundle.Verify(pub, artifact))
+	assert.Error(t, bundle.Verify(pub, digest[:]))
+}
@@ -0,0 +1,432 @@
+// Package testvectors produces and checks known-answer test vectors of
+// FROST(Ed25519, SHA-512), in the JSON format of the test vectors that
+// accompany RFC 9591.
+//
+// A vector fixes the group secret, the coefficients of the sharing polynomial
+// and the randomness of the nonces of every signer, so that every output, from
+// the shares to the final signature, is determined. Verify recomputes the
+// outputs of a vector with package frost in RFC 9591 mode, so vectors of other
+// implementations check the compatibility of this one, and Generate produces
+// vectors to check other implementations against. RFC9591 returns the vector
+// of Appendix E.1 of the RFC.
+package testvectors
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+	"golang.org/x/crypto/hkdf"
+)
+
+// contextString is the context string of FROST(Ed25519, SHA-512).
+const contextString = "FROST-ED25519-SHA512-v1"
+
+// Config is the configuration of a vector. The counts are decimal strings.
+type Config struct {
+	MaxParticipants string `json:"MAX_PARTICIPANTS"`
+	NumParticipants string `json:"NUM_PARTICIPANTS"`
+	MinParticipants string `json:"MIN_PARTICIPANTS"`
+	Name            string `json:"name"`
+	Group           string `json:"group"`
+	Hash            string `json:"hash"`
+}
+
+// ParticipantShare is the secret share of a participant.
+type ParticipantShare struct {
+	Identifier uint16 `json:"identifier"`
+	Share      string `json:"participant_share"`
+}
+
+// Inputs are the group secret, its sharing, the signers and the message of a
+// vector. All values are hex encoded, scalars in little endian and points in
+// the encoding of Ed25519.
+type Inputs struct {
+	ParticipantList   []uint16           `json:"participant_list"`
+	GroupSecretKey    string             `json:"group_secret_key"`
+	GroupPublicKey    string             `json:"group_public_key"`
+	Message           string             `json:"message"`
+	Coefficients      []string           `json:"share_polynomial_coefficients"`
+	ParticipantShares []ParticipantShare `json:"participant_shares"`
+}
+
+// RoundOneOutput holds the nonces of a signer, drawn from the randomness as
+// nonce_generate does, their commitments, and the binding factor.
+type RoundOneOutput struct {
+	Identifier             uint16 `json:"identifier"`
+	HidingNonceRandomness  string `json:"hiding_nonce_randomness"`
+	BindingNonceRandomness string `json:"binding_nonce_randomness"`
+	HidingNonce            string `json:"hiding_nonce"`
+	BindingNonce           string `json:"binding_nonce"`
+	HidingNonceCommitment  string `json:"hiding_nonce_commitment"`
+	BindingNonceCommitment string `json:"binding_nonce_commitment"`
+	BindingFactorInput     string `json:"binding_factor_input"`
+	BindingFactor          string `json:"binding_factor"`
+}
+
+// RoundOneOutputs are the outputs of the first round, one per signer.
+type RoundOneOutputs struct {
+	Outputs []RoundOneOutput `json:"outputs"`
+}
+
+// RoundTwoOutput is the signature share of a signer.
+type RoundTwoOutput struct {
+	Identifier uint16 `json:"identifier"`
+	SigShare   string `json:"sig_share"`
+}
+
+// RoundTwoOutputs are the outputs of the second round, one per signer.
+type RoundTwoOutputs struct {
+	Outputs []RoundTwoOutput `json:"outputs"`
+}
+
+// FinalOutput is the Ed25519 signature R ∥ S.
+type FinalOutput struct {
+	Sig string `json:"sig"`
+}
+
+// Vector is a test vector of a complete signing.
+type Vector struct {
+	Config          Config          `json:"config"`
+	Inputs          Inputs          `json:"inputs"`
+	RoundOneOutputs RoundOneOutputs `json:"round_one_outputs"`
+	RoundTwoOutputs RoundTwoOutputs `json:"round_two_outputs"`
+	FinalOutput     FinalOutput     `json:"final_output"`
+}
+
+//go:embed rfc9591_ed25519.json
+var rfc9591Vector []byte
+
+// RFC9591 returns the FROST(Ed25519, SHA-512) vector of Appendix E.1 of RFC 9591,
+// which signs "test" with participants 1 and 3 of a 2-of-3 group.
+func RFC9591() *Vector {
+	var v Vector
+	if err := json.Unmarshal(rfc9591Vector, &v); err != nil {
+		panic(fmt.Errorf("testvectors: embedded vector: %w", err))
+	}
+	return &v
+}
+
+// Generate returns a vector in which participants sign message in a group of
+// max parties, min of which are needed to sign. The group secret, polynomial
+// and randomness of the nonces are derived from seed, so the same arguments
+// always produce the same vector.
+func Generate(seed []byte, max, min party.Size, participants party.IDSlice, message []byte) (*Vector, error) {
+	if len(seed) == 0 {
+		return nil, errors.New("testvectors: empty seed")
+	}
+	if min == 0 || min > max {
+		return nil, fmt.Errorf("testvectors: %d of %d participants cannot sign", min, max)
+	}
+
+	v := &Vector{
+		Config: Config{
+			MaxParticipants: strconv.Itoa(int(max)),
+			MinParticipants: strconv.Itoa(int(min)),
+		},
+		Inputs: Inputs{
+			GroupSecretKey: hex.EncodeToString(seededScalar(seed, "secret").Bytes()),
+			Message:        hex.EncodeToString(message),
+		},
+	}
+	for k := party.Size(1); k < min; k++ {
+		v.Inputs.Coefficients = append(v.Inputs.Coefficients, hex.EncodeToString(seededScalar(seed, "coefficient-"+k.String()).Bytes()))
+	}
+	for _, id := range party.NewIDSlice(participants) {
+		v.Inputs.ParticipantList = append(v.Inputs.ParticipantList, uint16(id))
+		v.RoundOneOutputs.Outputs = append(v.RoundOneOutputs.Outputs, RoundOneOutput{
+			Identifier:             uint16(id),
+			HidingNonceRandomness:  hex.EncodeToString(seededBytes(seed, "hiding-"+id.String(), 32)),
+			BindingNonceRandomness: hex.EncodeToString(seededBytes(seed, "binding-"+id.String(), 32)),
+		})
+	}
+	return compute(v)
+}
+
+// Verify recomputes the outputs of v from its configuration, inputs and
+// randomness, and returns an error naming the first value that differs.
+func Verify(v *Vector) error {
+	computed, err := compute(v)
+	if err != nil {
+		return err
+	}
+	if v.Config != computed.Config {
+		return fmt.Errorf("testvectors: config is %+v, expected %+v", v.Config, computed.Config)
+	}
+	if err := compare("group_public_key", v.Inputs.GroupPublicKey, computed.Inputs.GroupPublicKey); err != nil {
+		return err
+	}
+	if len(v.Inputs.ParticipantShares) != len(computed.Inputs.ParticipantShares) {
+		return fmt.Errorf("testvectors: %d participant shares, expected %d", len(v.Inputs.ParticipantShares), len(computed.Inputs.ParticipantShares))
+	}
+	for i, s := range v.Inputs.ParticipantShares {
+		if s != computed.Inputs.ParticipantShares[i] {
+			return fmt.Errorf("testvectors: share of participant %d differs", s.Identifier)
+		}
+	}
+	if len(v.RoundOneOutputs.Outputs) != len(computed.RoundOneOutputs.Outputs) ||
+		len(v.RoundTwoOutputs.Outputs) != len(computed.RoundTwoOutputs.Outputs) {
+		return errors.New("testvectors: the outputs do not match the participant list")
+	}
+	for i, out := range v.RoundOneOutputs.Outputs {
+		expected := computed.RoundOneOutputs.Outputs[i]
+		for _, f := range []struct{ name, got, expected string }{
+			{"hiding_nonce", out.HidingNonce, expected.HidingNonce},
+			{"binding_nonce", out.BindingNonce, expected.BindingNonce},
+			{"hiding_nonce_commitment", out.HidingNonceCommitment, expected.HidingNonceCommitment},
+			{"binding_nonce_commitment", out.BindingNonceCommitment, expected.BindingNonceCommitment},
+			{"binding_factor_input", out.BindingFactorInput, expected.BindingFactorInput},
+			{"binding_factor", out.BindingFactor, expected.BindingFactor},
+		} {
+			if err := compare(fmt.Sprintf("%s of participant %d", f.name, out.Identifier), f.got, f.expected); err != nil {
+				return err
+			}
+		}
+	}
+	for i, out := range v.RoundTwoOutputs.Outputs {
+		expected := computed.RoundTwoOutputs.Outputs[i]
+		if out.Identifier != expected.Identifier {
+			return fmt.Errorf("testvectors: signature share of participant %d, expected %d", out.Identifier, expected.Identifier)
+		}
+		if err := compare(fmt.Sprintf("sig_share of participant %d", out.Identifier), out.SigShare, expected.SigShare); err != nil {
+			return err
+		}
+	}
+	return compare("sig", v.FinalOutput.Sig, computed.FinalOutput.Sig)
+}
+
+// compare returns an error if the hex encoded values differ.
+func compare(name, got, expected string) error {
+	a, errA := hex.DecodeString(got)
+	b, errB := hex.DecodeString(expected)
+	if errA != nil || errB != nil || !bytes.Equal(a, b) {
+		return fmt.Errorf("testvectors: %s is %q, expected %q", name, got, expected)
+	}
+	return nil
+}
+
+// compute returns a copy of v with the outputs computed from the max and min
+// participants of the config, the inputs and the randomness of the first round.
+func compute(v *Vector) (*Vector, error) {
+	max, err := parseCount("MAX_PARTICIPANTS", v.Config.MaxParticipants)
+	if err != nil {
+		return nil, err
+	}
+	min, err := parseCount("MIN_PARTICIPANTS", v.Config.MinParticipants)
+	if err != nil {
+		return nil, err
+	}
+	if min > max || len(v.Inputs.Coefficients) != int(min)-1 {
+		return nil, fmt.Errorf("testvectors: %d coefficients for %d of %d participants", len(v.Inputs.Coefficients), min, max)
+	}
+	message, err := hex.DecodeString(v.Inputs.Message)
+	if err != nil {
+		return nil, fmt.Errorf("testvectors: message: %w", err)
+	}
+
+	out := &Vector{
+		Config: Config{
+			MaxParticipants: v.Config.MaxParticipants,
+			NumParticipants: strconv.Itoa(len(v.Inputs.ParticipantList)),
+			MinParticipants: v.Config.MinParticipants,
+			Name:            "FROST(Ed25519, SHA-512)",
+			Group:           "ed25519",
+			Hash:            "SHA-512",
+		},
+		Inputs: Inputs{
+			ParticipantList: v.Inputs.ParticipantList,
+			GroupSecretKey:  v.Inputs.GroupSecretKey,
+			Message:         v.Inputs.Message,
+			Coefficients:    v.Inputs.Coefficients,
+		},
+	}
+
+	// the shares of all parties, f(i) = s + a₁ i + ... + aₜ iᵗ
+	coefficients := make([]*ristretto.Scalar, 0, min)
+	for _, c := range append([]string{v.Inputs.GroupSecretKey}, v.Inputs.Coefficients...) {
+		s, err := decodeScalar(c)
+		if err != nil {
+			return nil, err
+		}
+		coefficients = append(coefficients, s)
+	}
+	var groupKey ristretto.Element
+	groupKey.ScalarBaseMult(coefficients[0])
+	out.Inputs.GroupPublicKey = hex.EncodeToString(groupKey.BytesEd25519())
+
+	shares := make(map[party.ID]*eddsa.SecretShare, max)
+	publicShares := make(map[party.ID]*ristretto.Element, max)
+	for id := party.ID(1); id <= max; id++ {
+		share := ristretto.NewScalar()
+		for k := len(coefficients) - 1; k >= 0; k-- {
+			share.MultiplyAdd(share, id.Scalar(), coefficients[k])
+		}
+		shares[id] = eddsa.NewSecretShare(id, share)
+		publicShares[id] = new(ristretto.Element).ScalarBaseMult(share)
+		out.Inputs.ParticipantShares = append(out.Inputs.ParticipantShares, ParticipantShare{
+			Identifier: uint16(id),
+			Share:      hex.EncodeToString(share.Bytes()),
+		})
+	}
+	public, err := eddsa.NewPublic(publicShares, min-1)
+	if err != nil {
+		return nil, fmt.Errorf("testvectors: %w", err)
+	}
+
+	// round one, with the nonces of nonce_generate
+	signers := make(party.IDSlice, 0, len(v.Inputs.ParticipantList))
+	for _, id := range v.Inputs.ParticipantList {
+		signers = append(signers, party.ID(id))
+	}
+	if !party.NewIDSlice(signers).Equal(signers) || len(signers) < int(min) || signers.Contains(0) || signers[len(signers)-1] > max {
+		return nil, fmt.Errorf("testvectors: invalid participant list %v", signers)
+	}
+	if len(v.RoundOneOutputs.Outputs) != len(signers) {
+		return nil, fmt.Errorf("testvectors: %d round one outputs for %d participants", len(v.RoundOneOutputs.Outputs), len(signers))
+	}
+	states := make(map[party.ID]*frost.SignerState, len(signers))
+	var list frost.CommitmentList
+	for i, id := range signers {
+		in := v.RoundOneOutputs.Outputs[i]
+		if in.Identifier != uint16(id) {
+			return nil, fmt.Errorf("testvectors: round one output of participant %d, expected %d", in.Identifier, id)
+		}
+		secret := shares[id].Secret.Bytes()
+		hiding, err := nonceDigest(in.HidingNonceRandomness, secret)
+		if err != nil {
+			return nil, err
+		}
+		binding, err := nonceDigest(in.BindingNonceRandomness, secret)
+		if err != nil {
+			return nil, err
+		}
+		_, state, err := frost.SignInitWithRand(signers, shares[id], public, message, io.MultiReader(bytes.NewReader(hiding), bytes.NewReader(binding)))
+		if err != nil {
+			return nil, fmt.Errorf("testvectors: %w", err)
+		}
+		state.RFC9591 = true
+		states[id] = state
+		list = append(list, state.Commitment())
+
+		c := state.Commitment()
+		out.RoundOneOutputs.Outputs = append(out.RoundOneOutputs.Outputs, RoundOneOutput{
+			Identifier:             uint16(id),
+			HidingNonceRandomness:  in.HidingNonceRandomness,
+			BindingNonceRandomness: in.BindingNonceRandomness,
+			HidingNonce:            hex.EncodeToString(state.D.Bytes()),
+			BindingNonce:           hex.EncodeToString(state.E.Bytes()),
+			HidingNonceCommitment:  hex.EncodeToString(c.Hiding.BytesEd25519()),
+			BindingNonceCommitment: hex.EncodeToString(c.Binding.BytesEd25519()),
+		})
+	}
+
+	factors, err := list.BindingFactors(public.GroupKey, message)
+	if err != nil {
+		return nil, fmt.Errorf("testvectors: %w", err)
+	}
+	encodedList, err := list.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("testvectors: %w", err)
+	}
+	prefix := make([]byte, 0, 32+2*sha512.Size)
+	prefix = append(prefix, public.GroupKey.ToEd25519()...)
+	prefix = append(prefix, hash("msg", message)...)
+	prefix = append(prefix, hash("com", encodedList)...)
+	for i, id := range signers {
+		input := append(append([]byte{}, prefix...), id.Scalar().Bytes()...)
+		var rho ristretto.Scalar
+		_, _ = rho.SetUniformBytes(hash("rho", input))
+		if rho.Equal(factors[id]) != 1 {
+			return nil, fmt.Errorf("testvectors: binding factor of participant %d differs from its input", id)
+		}
+		out.RoundOneOutputs.Outputs[i].BindingFactorInput = hex.EncodeToString(input)
+		out.RoundOneOutputs.Outputs[i].BindingFactor = hex.EncodeToString(rho.Bytes())
+	}
+
+	// round two
+	sign2 := make([]*frost.Message, 0, len(signers))
+	for _, id := range signers {
+		msg, _, err := frost.SignRound1WithCommitments(states[id], list)
+		if err != nil {
+			return nil, fmt.Errorf("testvectors: %w", err)
+		}
+		sign2 = append(sign2, msg)
+		out.RoundTwoOutputs.Outputs = append(out.RoundTwoOutputs.Outputs, RoundTwoOutput{
+			Identifier: uint16(id),
+			SigShare:   hex.EncodeToString(msg.Sign2.Zi.Bytes()),
+		})
+	}
+	sig, _, err := frost.SignRound2(states[signers[0]], sign2)
+	if err != nil {
+		return nil, fmt.Errorf("testvectors: %w", err)
+	}
+	if !ed25519.Verify(public.GroupKey.ToEd25519(), message, sig.ToEd25519()) {
+		return nil, errors.New("testvectors: the signature does not verify")
+	}
+	out.FinalOutput.Sig = hex.EncodeToString(sig.ToEd25519())
+	return out, nil
+}
+
+// hash is SHA-512(contextString ∥ tag ∥ m), which is H1, H3, H4 and H5 of the
+// ciphersuite before the reduction to a scalar.
+func hash(tag string, m []byte) []byte {
+	h := sha512.New()
+	h.Write([]byte(contextString + tag))
+	h.Write(m)
+	return h.Sum(nil)
+}
+
+// nonceDigest returns H3(random ∥ secret) of nonce_generate before its
+// reduction, which frost.SignInitWithRand reduces to the nonce.
+func nonceDigest(random string, secret []byte) ([]byte, error) {
+	r, err := hex.DecodeString(random)
+	if err != nil || len(r) != 32 {
+		return nil, fmt.Errorf("testvectors: nonce randomness %q is not 32 hex encoded bytes", random)
+	}
+	return hash("nonce", append(r, secret...)), nil
+}
+
+func decodeScalar(s string) (*ristretto.Scalar, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("testvectors: scalar %q: %w", s, err)
+	}
+	var x ristretto.Scalar
+	if _, err := x.SetCanonicalBytes(b); err != nil {
+		return nil, fmt.Errorf("testvectors: scalar %q: %w", s, err)
+	}
+	return &x, nil
+}
+
+func parseCount(name, s string) (party.Size, error) {
+	n, err := strconv.ParseUint(s, 10, 16)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("testvectors: invalid %s %q", name, s)
+	}
+	return party.Size(n), nil
+}
+
+// seededBytes returns n bytes derived from seed for the given purpose.
+func seededBytes(seed []byte, purpose string, n int) []byte {
+	b := make([]byte, n)
+	_, _ = io.ReadFull(hkdf.New(sha512.New, seed, nil, []byte("FROST-TESTVECTORS-"+purpose)), b)
+	return b
+}
+
+// seededScalar returns a scalar derived from seed for the given purpose.
+func seededScalar(seed []byte, purpose string) *ristretto.Scalar {
+	var s ristretto.Scalar
+	_, _ = s.SetUniformBytes(seededBytes(seed, purpose, 64))
+	return &s
+}
@@ -0,0 +1,67 @@
+package testvectors
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRFC9591(t *testing.T) {
+	v := RFC9591()
+	require.NoError(t, Verify(v))
+	// the signature of Appendix E.1 of the RFC
+	assert.Equal(t, "36282629c383bb820a88b71cae937d41f2f2adfcc3d02e55507e2fb9e2dd3cbe"+
+		"bd9d2b0844e49ae0f3fa935161e1419aab7b47d21a37ebeae1f17d4987b3160b", v.FinalOutput.Sig)
+
+	// every changed value is reported
+	v.RoundOneOutputs.Outputs[1].BindingFactor = v.RoundOneOutputs.Outputs[0].BindingFactor
+	assert.EqualError(t, Verify(v), `testvectors: binding_factor of participant 3 is "`+
+		v.RoundOneOutputs.Outputs[0].BindingFactor+`", expected "b087686bf35a13f3dc78e780a34b0fe8a77fef1b9938c563f5573d71d8d7890f"`)
+
+	v = RFC9591()
+	v.RoundTwoOutputs.Outputs[0].SigShare = v.RoundTwoOutputs.Outputs[1].SigShare
+	assert.Error(t, Verify(v))
+
+	v = RFC9591()
+	v.RoundOneOutputs.Outputs[0].HidingNonceRandomness = v.RoundOneOutputs.Outputs[1].HidingNonceRandomness
+	assert.Error(t, Verify(v))
+
+	v = RFC9591()
+	v.Inputs.Coefficients = nil
+	assert.Error(t, Verify(v))
+}
+
+func TestGenerate(t *testing.T) {
+	seed := []byte("test vector seed")
+	v, err := Generate(seed, 5, 3, party.IDSlice{5, 2, 4}, []byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, Verify(v))
+	assert.Equal(t, []uint16{2, 4, 5}, v.Inputs.ParticipantList)
+	assert.Len(t, v.Inputs.Coefficients, 2)
+	assert.Len(t, v.Inputs.ParticipantShares, 5)
+	assert.Equal(t, "3", v.Config.NumParticipants)
+
+	// the vector survives its JSON encoding, and the same seed gives the same vector
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	var decoded Vector
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.NoError(t, Verify(&decoded))
+	again, err := Generate(seed, 5, 3, party.IDSlice{2, 4, 5}, []byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, v, again)
+
+	other, err := Generate([]byte("another seed"), 5, 3, party.IDSlice{2, 4, 5}, []byte("hello"))
+	require.NoError(t, err)
+	assert.NotEqual(t, v.FinalOutput, other.FinalOutput)
+
+	_, err = Generate(seed, 5, 3, party.IDSlice{2, 4}, []byte("hello"))
+	assert.Error(t, err)
+	_, err = Generate(seed, 3, 2, party.IDSlice{2, 4}, []byte("hello"))
+	assert.Error(t, err)
+	_, err = Generate(nil, 3, 2, party.IDSlice{1, 2}, nil)
+	assert.Error(t, err)
+}
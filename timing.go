@@ -0,0 +1,45 @@
+package frost
+
+import "github.com/bartke/frost/internal/vartime"
+
+// Timing is the timing of an operation of the protocol, as listed by
+// TimingReport.
+type Timing = vartime.Timing
+
+// AllowVarTime lets the operations on public values run in variable time,
+// which is about twice as fast: the verification of signature shares by
+// SignRound2 and the Aggregator, of the proofs and commitments of the key
+// generation, and of signatures in package eddsa. It also applies to the
+// groups of package curve. It should be called once, before any session is
+// started, by a process that does not share its hardware with an adversary
+// able to time it.
+//
+// The operations on secrets, such as the nonces and shares, always run in
+// constant time. See TimingReport for what runs in which mode.
+func AllowVarTime() {
+	vartime.Allow(true)
+}
+
+// VarTimeAllowed returns true if AllowVarTime was called.
+func VarTimeAllowed() bool {
+	return vartime.Allowed()
+}
+
+// TimingReport lists the group operations of the protocol, whether they
+// handle secrets, and whether they run in constant time with the current
+// setting of AllowVarTime.
+func TimingReport() []Timing {
+	constantTime := !vartime.Allowed()
+	return []Timing{
+		{Operation: "nonce commitments [d]B, [e]B (SignInit, PreprocessNonces)", Secret: true, ConstantTime: true},
+		{Operation: "signature share z = d + eρ + λsc (SignRound1)", Secret: true, ConstantTime: true},
+		{Operation: "polynomial shares f(j) (KeygenRound1, ReshareRound1, DealKeys)", Secret: true, ConstantTime: true},
+		{Operation: "commitments [aᵢ]B and Schnorr proof of the polynomial (KeygenInit)", Secret: true, ConstantTime: true},
+		{Operation: "VSS check of a received share [f(i)]B (KeygenRound2, ReshareRound2)", Secret: true, ConstantTime: true},
+		{Operation: "public shares [λ]Aᵢ (NewSigningGroup, Aggregator)", Secret: false, ConstantTime: true},
+		{Operation: "commitments evaluated at a party ID (KeygenRound2, ReshareRound2); variable time in the public ID only", Secret: false, ConstantTime: false},
+		{Operation: "signature share verification (SignRound2, Aggregator, VerifyPartial)", Secret: false, ConstantTime: constantTime},
+		{Operation: "Schnorr proof verification (KeygenRound1)", Secret: false, ConstantTime: constantTime},
+		{Operation: "signature verification (SignRound2, eddsa.PublicKey.Verify, eddsa.VerifyBatch)", Secret: false, ConstantTime: constantTime},
+	}
+}
@@ -0,0 +1,59 @@
+package frost
+
+import (
+	"testing"
+
+	"github.com/bartke/frost/internal/vartime"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimingReport(t *testing.T) {
+	defer vartime.Allow(vartime.Allow(false))
+
+	for _, allow := range []bool{false, true} {
+		if allow {
+			AllowVarTime()
+		}
+		assert.Equal(t, allow, VarTimeAllowed())
+
+		var varTime int
+		for _, op := range TimingReport() {
+			if op.Secret {
+				assert.True(t, op.ConstantTime, "%s handles secrets", op.Operation)
+			}
+			if !op.ConstantTime {
+				varTime++
+			}
+		}
+		if allow {
+			assert.Greater(t, varTime, 1)
+		} else {
+			assert.Equal(t, 1, varTime, "only the evaluation at public IDs")
+		}
+
+		// the signatures are the same in both modes, and invalid shares are still found
+		public, secrets := generateKeys(t, 3, 1)
+		signers := party.IDSlice{1, 3}
+		states := make(map[party.ID]*SignerState)
+		var round1 []*Message
+		for _, id := range signers {
+			msg, state, err := SignInit(signers, secrets[id], public, []byte("timing"))
+			require.NoError(t, err)
+			states[id] = state
+			round1 = append(round1, msg)
+		}
+		sigs, err := runSignRounds(states, round1)
+		require.NoError(t, err)
+		for _, sig := range sigs {
+			assert.True(t, public.GroupKey.Verify([]byte("timing"), sig))
+		}
+
+		state := states[1]
+		state.Signers[3].Zi.Set(scalar.NewScalarRandom())
+		assert.False(t, verifyShares(&state.C, state.Signers, party.IDSlice{3}))
+		assert.False(t, state.Signers[3].verifyShare(&state.C, &state.Signers[3].Zi))
+	}
+}
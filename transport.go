@@ -0,0 +1,15 @@
+package frost
+
+import "context"
+
+// Transport delivers protocol messages between the parties of a session.
+//
+// Implementations must be safe for concurrent use by one sender and one receiver.
+type Transport interface {
+	// Send delivers msg to msg.To, or to every other party if msg is a broadcast.
+	Send(ctx context.Context, msg *Message) error
+
+	// Receive blocks until the next message addressed to this party arrives,
+	// or ctx is done.
+	Receive(ctx context.Context) (*Message, error)
+}
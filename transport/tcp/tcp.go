@@ -0,0 +1,389 @@
+// Package tcp delivers the messages of protocol sessions between parties
+// connected directly over TCP, authenticated by their identity keys.
+//
+// Every party listens for the connections of the others, and connects to every
+// party it sends messages to. The connections use TLS 1.3 with mutual
+// authentication: each party presents a certificate of its long-lived ed25519
+// identity key, see frost.Identities, and accepts only peers presenting the
+// identity key of a party of the group, such as those recorded in the
+// Identities of an eddsa.Public. The KeyGen2 shares are therefore encrypted to
+// the party they are for, and a party can only send messages from itself,
+// without a VPN or a CA between the parties.
+//
+// A party confirms every connection it accepted with a single byte, 1, so that
+// the sender learns if its certificate was refused. Then messages are sent in
+// their binary encoding, prefixed with its length as a 4 byte big-endian
+// integer.
+package tcp
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/router"
+)
+
+// maxMessageSize bounds the size of a message.
+const maxMessageSize = 1 << 20
+
+// confirmation is sent by a party for every connection it accepted.
+const confirmation = 1
+
+// ErrClosed is returned by the methods of a Transport after Close.
+var ErrClosed = errors.New("tcp: transport closed")
+
+// Config holds the parameters of a Transport.
+type Config struct {
+	// Self is the party of the Transport, and Key its identity key.
+	Self party.ID
+	Key  ed25519.PrivateKey
+	// Identities are the identity keys of all parties, including Self.
+	Identities frost.Identities
+	// Addresses are the addresses of the parties, host:port. That of Self is ignored.
+	Addresses map[party.ID]string
+
+	// Backoff is the retry policy of failed sends, router.DefaultBackoff is
+	// used if MaxAttempts is 0.
+	Backoff router.Backoff
+	// Clock times the delays, clock.Real is used if it is nil.
+	Clock clock.Clock
+}
+
+// Transport is the frost.Transport of one party, which sends its messages over
+// authenticated TCP connections to the other parties, and receives theirs.
+type Transport struct {
+	self       party.ID
+	addresses  map[party.ID]string
+	peers      party.IDSlice
+	tls        *tls.Config
+	backoff    router.Backoff
+	clock      clock.Clock
+	identities frost.Identities
+
+	listener net.Listener
+	inbox    chan *frost.Message
+	closing  chan struct{}
+	wg       sync.WaitGroup
+
+	mu sync.Mutex
+	// conns are the connections to the other parties, which are only written to.
+	conns map[party.ID]net.Conn
+	// accepted are the connections of the other parties, which are only read from.
+	accepted map[net.Conn]struct{}
+	closed   bool
+}
+
+// Listen returns the Transport of config.Self, which accepts the connections
+// of the other parties on addr.
+func Listen(addr string, config *Config) (*Transport, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("tcp: %w", err)
+	}
+	t, err := New(lis, config)
+	if err != nil {
+		lis.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// New returns the Transport of config.Self, which accepts the connections of
+// the other parties on lis.
+func New(lis net.Listener, config *Config) (*Transport, error) {
+	tlsConfig, err := TLSConfig(config.Self, config.Key, config.Identities)
+	if err != nil {
+		return nil, err
+	}
+	peers := make([]party.ID, 0, len(config.Addresses))
+	for id := range config.Addresses {
+		if id == config.Self {
+			continue
+		}
+		if _, ok := config.Identities[id]; !ok {
+			return nil, fmt.Errorf("tcp: address of party %d, which has no identity", id)
+		}
+		peers = append(peers, id)
+	}
+
+	t := &Transport{
+		self:       config.Self,
+		addresses:  config.Addresses,
+		peers:      party.NewIDSlice(peers),
+		tls:        tlsConfig,
+		backoff:    config.Backoff,
+		clock:      clock.OrReal(config.Clock),
+		identities: config.Identities,
+		listener:   tls.NewListener(lis, tlsConfig),
+		inbox:      make(chan *frost.Message, 64),
+		closing:    make(chan struct{}),
+		conns:      make(map[party.ID]net.Conn),
+		accepted:   make(map[net.Conn]struct{}),
+	}
+	if t.backoff.MaxAttempts == 0 {
+		t.backoff = router.DefaultBackoff
+	}
+	t.wg.Add(1)
+	go t.accept()
+	return t, nil
+}
+
+// Addr returns the address the Transport listens on.
+func (t *Transport) Addr() net.Addr {
+	return t.listener.Addr()
+}
+
+// Send implements frost.Transport. A broadcast is sent to every other party of
+// Config.Addresses. Send returns once msg is written to the connection: a
+// message written just before the peer lost the connection is lost too, and
+// the round waiting for it times out.
+func (t *Transport) Send(ctx context.Context, msg *frost.Message) error {
+	if msg.From != t.self {
+		return fmt.Errorf("tcp: message from party %d sent by party %d", msg.From, t.self)
+	}
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	frame := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(data)), uint32(len(data)))
+	frame = append(frame, data...)
+
+	if !msg.IsBroadcast() {
+		return t.send(ctx, msg.To, frame)
+	}
+	for _, id := range t.peers {
+		if err := t.send(ctx, id, frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Receive implements frost.Transport.
+func (t *Transport) Receive(ctx context.Context) (*frost.Message, error) {
+	select {
+	case msg := <-t.inbox:
+		return msg, nil
+	case <-t.closing:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close stops listening, and closes all connections.
+func (t *Transport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	close(t.closing)
+	err := t.listener.Close()
+	for _, conn := range t.conns {
+		conn.Close()
+	}
+	for conn := range t.accepted {
+		conn.Close()
+	}
+	t.mu.Unlock()
+	t.wg.Wait()
+	return err
+}
+
+// send writes frame to the connection to party id, and retries with a new
+// connection if it fails.
+func (t *Transport) send(ctx context.Context, id party.ID, frame []byte) error {
+	if !t.peers.Contains(id) {
+		return fmt.Errorf("tcp: no address of party %d: %w", id, router.ErrNoRoute)
+	}
+	rng := rand.New(rand.NewSource(t.clock.Now().UnixNano()))
+	var err error
+	for attempt := 1; attempt <= t.backoff.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			if err := clock.Sleep(ctx, t.clock, t.backoff.Delay(attempt-1, rng)); err != nil {
+				return err
+			}
+		}
+		var conn net.Conn
+		if conn, err = t.conn(ctx, id); err != nil {
+			if errors.Is(err, ErrClosed) || errors.Is(err, ErrUnknownPeer) {
+				return err
+			}
+			continue
+		}
+		deadline, _ := ctx.Deadline()
+		_ = conn.SetWriteDeadline(deadline)
+		if _, err = conn.Write(frame); err == nil {
+			return nil
+		}
+		t.drop(id, conn)
+	}
+	return fmt.Errorf("tcp: sending to party %d: %w", id, err)
+}
+
+// conn returns the connection to party id, which it opens if needed.
+func (t *Transport) conn(ctx context.Context, id party.ID) (net.Conn, error) {
+	t.mu.Lock()
+	conn, ok := t.conns[id]
+	closed := t.closed
+	t.mu.Unlock()
+	if closed {
+		return nil, ErrClosed
+	}
+	if ok {
+		return conn, nil
+	}
+
+	dialer := tls.Dialer{Config: t.tls}
+	conn, err := dialer.DialContext(ctx, "tcp", t.addresses[id])
+	if err != nil {
+		return nil, err
+	}
+	peer, err := PeerID(conn.(*tls.Conn).ConnectionState(), t.identities)
+	if err == nil && peer != id {
+		err = fmt.Errorf("%w: party %d answered at the address of party %d", ErrUnknownPeer, peer, id)
+	}
+	if err == nil {
+		// with TLS 1.3, the peer checks our certificate after our handshake is
+		// complete, and confirms the connection only if it accepted it
+		err = readConfirmation(ctx, conn)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		conn.Close()
+		return nil, ErrClosed
+	}
+	if existing, ok := t.conns[id]; ok {
+		// another send connected first
+		conn.Close()
+		return existing, nil
+	}
+	t.conns[id] = conn
+	return conn, nil
+}
+
+// readConfirmation waits for the confirmation of conn, a connection we opened.
+func readConfirmation(ctx context.Context, conn net.Conn) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(10 * time.Second)
+	}
+	_ = conn.SetReadDeadline(deadline)
+	var b [1]byte
+	if _, err := io.ReadFull(conn, b[:]); err != nil {
+		return err
+	}
+	if b[0] != confirmation {
+		return fmt.Errorf("tcp: invalid confirmation %d", b[0])
+	}
+	return conn.SetReadDeadline(time.Time{})
+}
+
+// drop closes conn, the connection to party id, so that the next send opens another one.
+func (t *Transport) drop(id party.ID, conn net.Conn) {
+	conn.Close()
+	t.mu.Lock()
+	if t.conns[id] == conn {
+		delete(t.conns, id)
+	}
+	t.mu.Unlock()
+}
+
+// accept accepts the connections of the other parties until the listener is closed.
+func (t *Transport) accept() {
+	defer t.wg.Done()
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			var temporary interface{ Temporary() bool }
+			if errors.As(err, &temporary) && temporary.Temporary() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return
+		}
+		t.mu.Lock()
+		if t.closed {
+			t.mu.Unlock()
+			conn.Close()
+			return
+		}
+		t.accepted[conn] = struct{}{}
+		t.wg.Add(1)
+		t.mu.Unlock()
+		go t.read(conn.(*tls.Conn))
+	}
+}
+
+// read delivers the messages of conn, a connection of another party, to the
+// inbox until it fails. A message that is not from the party of the
+// connection, or not addressed to us, closes it.
+func (t *Transport) read(conn *tls.Conn) {
+	defer t.wg.Done()
+	defer func() {
+		conn.Close()
+		t.mu.Lock()
+		delete(t.accepted, conn)
+		t.mu.Unlock()
+	}()
+
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if err := conn.Handshake(); err != nil {
+		return
+	}
+	_ = conn.SetDeadline(time.Time{})
+	peer, err := PeerID(conn.ConnectionState(), t.identities)
+	if err != nil {
+		return
+	}
+	if _, err := conn.Write([]byte{confirmation}); err != nil {
+		return
+	}
+
+	var header [4]byte
+	for {
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			return
+		}
+		size := binary.BigEndian.Uint32(header[:])
+		if size > maxMessageSize {
+			return
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(conn, data); err != nil {
+			return
+		}
+		var msg frost.Message
+		if err := msg.UnmarshalBinary(data); err != nil {
+			return
+		}
+		if msg.From != peer || !msg.IsBroadcast() && msg.To != t.self {
+			return
+		}
+		select {
+		case t.inbox <- &msg:
+		case <-t.closing:
+			return
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package tcp
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/router"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testBackoff = router.Backoff{MaxAttempts: 3, Initial: time.Millisecond, Multiplier: 2}
+
+// newTransports returns the transports of parties 1..n, connected over the loopback interface.
+func newTransports(t *testing.T, n party.Size) (map[party.ID]*Transport, map[party.ID]ed25519.PrivateKey) {
+	keys := make(map[party.ID]ed25519.PrivateKey, n)
+	identities := make(frost.Identities, n)
+	listeners := make(map[party.ID]net.Listener, n)
+	addresses := make(map[party.ID]string, n)
+	for id := party.ID(1); id <= party.ID(n); id++ {
+		public, key, err := ed25519.GenerateKey(nil)
+		require.NoError(t, err)
+		keys[id], identities[id] = key, public
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		listeners[id], addresses[id] = lis, lis.Addr().String()
+	}
+
+	transports := make(map[party.ID]*Transport, n)
+	for id, lis := range listeners {
+		tr, err := New(lis, &Config{Self: id, Key: keys[id], Identities: identities, Addresses: addresses, Backoff: testBackoff})
+		require.NoError(t, err)
+		t.Cleanup(func() { tr.Close() })
+		transports[id] = tr
+	}
+	return transports, keys
+}
+
+func TestTransport_KeygenAndSign(t *testing.T) {
+	transports, _ := newTransports(t, 3)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const n, threshold = 3, 1
+	publics := make([]*eddsa.Public, n+1)
+	secrets := make([]*eddsa.SecretShare, n+1)
+	var wg sync.WaitGroup
+	for id, tr := range transports {
+		wg.Add(1)
+		go func(id party.ID, tr *Transport) {
+			defer wg.Done()
+			var err error
+			publics[id], secrets[id], err = frost.KeygenWithContext(ctx, tr, id, n, threshold)
+			assert.NoError(t, err)
+		}(id, tr)
+	}
+	wg.Wait()
+	require.NotNil(t, publics[2])
+	assert.True(t, publics[1].Equal(publics[2]))
+
+	signers := party.IDSlice{2, 3}
+	message := []byte("over tcp")
+	sigs := make([]*eddsa.Signature, n+1)
+	for _, id := range signers {
+		wg.Add(1)
+		go func(id party.ID) {
+			defer wg.Done()
+			var err error
+			sigs[id], err = frost.SignWithContext(ctx, transports[id], signers, secrets[id], publics[id], message)
+			assert.NoError(t, err)
+		}(id)
+	}
+	wg.Wait()
+	require.NotNil(t, sigs[2])
+	assert.True(t, publics[1].GroupKey.Verify(message, sigs[2]))
+}
+
+func TestTransport_Reconnect(t *testing.T) {
+	transports, _ := newTransports(t, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		msg := frost.NewKeyGen2(1, 2, scalar.NewScalarRandom())
+		require.NoError(t, transports[1].Send(ctx, msg))
+		received, err := transports[2].Receive(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, msg.Header, received.Header)
+		assert.Equal(t, 1, msg.KeyGen2.Share.Equal(&received.KeyGen2.Share))
+
+		// drop the connection, the next send opens another one
+		transports[1].mu.Lock()
+		transports[1].conns[2].Close()
+		transports[1].mu.Unlock()
+	}
+
+	assert.Error(t, transports[1].Send(ctx, frost.NewSign2(2, scalar.NewScalarRandom())), "message from another party")
+	err := transports[1].Send(ctx, frost.NewKeyGen2(1, 3, scalar.NewScalarRandom()))
+	assert.True(t, errors.Is(err, router.ErrNoRoute))
+
+	require.NoError(t, transports[2].Close())
+	_, err = transports[2].Receive(ctx)
+	assert.True(t, errors.Is(err, ErrClosed))
+}
+
+func TestTransport_UnknownPeer(t *testing.T) {
+	transports, keys := newTransports(t, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// an impostor of party 2, with a key of its own
+	public, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	impostor, err := New(lis, &Config{
+		Self:       2,
+		Key:        key,
+		Identities: frost.Identities{1: keys[1].Public().(ed25519.PublicKey), 2: public},
+		Addresses:  map[party.ID]string{1: transports[1].Addr().String()},
+		Backoff:    testBackoff,
+	})
+	require.NoError(t, err)
+	defer impostor.Close()
+
+	// party 1 does not accept its messages
+	assert.Error(t, impostor.Send(ctx, frost.NewSign2(2, scalar.NewScalarRandom())))
+	short, cancelShort := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancelShort()
+	_, err = transports[1].Receive(short)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// nor does it send to the impostor
+	transports[1].addresses[2] = impostor.Addr().String()
+	err = transports[1].Send(ctx, frost.NewKeyGen2(1, 2, scalar.NewScalarRandom()))
+	assert.True(t, errors.Is(err, ErrUnknownPeer))
+
+	_, err = TLSConfig(1, key, frost.Identities{1: keys[1].Public().(ed25519.PublicKey)})
+	assert.Error(t, err, "key of another party")
+}
@@ -0,0 +1,93 @@
+package tcp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/party"
+)
+
+// ErrUnknownPeer is returned for a connection whose peer did not present the
+// certificate of an identity key of the group.
+var ErrUnknownPeer = errors.New("tcp: unknown peer")
+
+// Certificate returns a self-signed certificate of key, the identity key of
+// party self. Peers do not check its issuer or validity, only that it is of
+// the identity key they know for the party.
+func Certificate(self party.ID, key ed25519.PrivateKey) (tls.Certificate, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return tls.Certificate{}, errors.New("tcp: invalid identity key")
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: fmt.Sprintf("frost party %d", self)},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("tcp: %w", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// TLSConfig returns the TLS configuration of the connections of party self,
+// for both ends: it presents the certificate of key, and requires the peer to
+// present the certificate of its key in identities, such as the Identities of
+// an eddsa.Public, instead of one issued by a CA. Only TLS 1.3 is accepted.
+func TLSConfig(self party.ID, key ed25519.PrivateKey, identities frost.Identities) (*tls.Config, error) {
+	cert, err := Certificate(self, key)
+	if err != nil {
+		return nil, err
+	}
+	if own, ok := identities[self]; !ok || !own.Equal(key.Public()) {
+		return nil, fmt.Errorf("tcp: key is not the identity of party %d", self)
+	}
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS13,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAnyClientCert,
+		// the chain is not verified, the peer is identified by its key below
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			id, err := PeerID(cs, identities)
+			if err == nil && id == self {
+				err = fmt.Errorf("%w: the peer presented the identity of party %d", ErrUnknownPeer, self)
+			}
+			return err
+		},
+	}, nil
+}
+
+// PeerID returns the party whose identity key is that of the certificate the
+// peer of cs presented.
+func PeerID(cs tls.ConnectionState, identities frost.Identities) (party.ID, error) {
+	if len(cs.PeerCertificates) == 0 {
+		return 0, fmt.Errorf("%w: no certificate", ErrUnknownPeer)
+	}
+	key, ok := cs.PeerCertificates[0].PublicKey.(ed25519.PublicKey)
+	if !ok {
+		return 0, fmt.Errorf("%w: not an ed25519 certificate", ErrUnknownPeer)
+	}
+	for id, identity := range identities {
+		if identity.Equal(key) {
+			return id, nil
+		}
+	}
+	return 0, ErrUnknownPeer
+}
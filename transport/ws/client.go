@@ -0,0 +1,289 @@
+package ws
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/router"
+	"golang.org/x/net/websocket"
+)
+
+// ErrClosed is returned by the methods of a Client after Close.
+var ErrClosed = errors.New("ws: client closed")
+
+// Config holds the optional parameters of a Client.
+type Config struct {
+	// Backoff is the retry policy of reconnects, router.DefaultBackoff is used
+	// if MaxAttempts is 0. Once MaxAttempts reconnects in a row failed, the
+	// Client fails.
+	Backoff router.Backoff
+	// TLSConfig is the TLS configuration of wss URLs.
+	TLSConfig *tls.Config
+	// Clock times the delays, clock.Real is used if it is nil.
+	Clock clock.Clock
+}
+
+// Client is the frost.Transport of one party in one session of a Hub. Send
+// returns once the hub stored the message, and Receive returns the messages
+// addressed to the party in the order the hub stored them. Both wait while the
+// Client reconnects.
+type Client struct {
+	url     string
+	token   string
+	backoff router.Backoff
+	tls     *tls.Config
+	clock   clock.Clock
+
+	// ctx is canceled by Close.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// done is closed when run returns.
+	done chan struct{}
+
+	mu   sync.Mutex
+	conn *websocket.Conn
+	// next is the index of the next message of the session.
+	next    int
+	seq     uint64
+	pending *outgoing
+	inbox   []*frost.Message
+	// notify is signaled when a message is added to inbox, or err is set.
+	notify chan struct{}
+	// err is set once the Client failed.
+	err error
+}
+
+// outgoing is a message that the hub did not acknowledge yet.
+type outgoing struct {
+	seq  uint64
+	data []byte
+	done chan error
+}
+
+// Dial connects to the session of the Hub at baseURL, a ws or wss URL, as the
+// party with the given token. config may be nil.
+func Dial(ctx context.Context, baseURL, session, token string, config *Config) (*Client, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	c := &Client{
+		url:     strings.TrimRight(baseURL, "/") + "/v1/sessions/" + url.PathEscape(session) + "/ws",
+		token:   token,
+		backoff: config.Backoff,
+		tls:     config.TLSConfig,
+		clock:   clock.OrReal(config.Clock),
+		done:    make(chan struct{}),
+		notify:  make(chan struct{}, 1),
+	}
+	if c.backoff.MaxAttempts == 0 {
+		c.backoff = router.DefaultBackoff
+	}
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	c.conn = conn
+	go c.run(conn)
+	return c, nil
+}
+
+// Send implements frost.Transport. It returns an error if the hub refused msg.
+func (c *Client) Send(ctx context.Context, msg *frost.Message) error {
+	data, err := msg.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.seq++
+	out := &outgoing{seq: c.seq, data: data, done: make(chan error, 1)}
+	c.pending = out
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn != nil {
+		// if the connection is lost, the message is sent again after the reconnect
+		_ = websocket.JSON.Send(conn, &frame{Seq: out.seq, Message: out.data})
+	}
+	select {
+	case err := <-out.done:
+		return err
+	case <-ctx.Done():
+		c.mu.Lock()
+		if c.pending == out {
+			c.pending = nil
+		}
+		c.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Receive implements frost.Transport.
+func (c *Client) Receive(ctx context.Context) (*frost.Message, error) {
+	for {
+		c.mu.Lock()
+		if len(c.inbox) > 0 {
+			msg := c.inbox[0]
+			c.inbox = c.inbox[1:]
+			c.mu.Unlock()
+			return msg, nil
+		}
+		err := c.err
+		c.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+
+		select {
+		case <-c.notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Close closes the connection, and stops reconnecting.
+func (c *Client) Close() error {
+	c.cancel()
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn != nil {
+		conn.Close()
+	}
+	<-c.done
+	return nil
+}
+
+// run reads from conn, and reconnects when it is lost, until the Client is
+// closed or fails.
+func (c *Client) run(conn *websocket.Conn) {
+	defer close(c.done)
+	rng := rand.New(rand.NewSource(c.clock.Now().UnixNano()))
+	for {
+		err := c.read(conn)
+		conn.Close()
+		c.mu.Lock()
+		c.conn = nil
+		c.mu.Unlock()
+		if c.ctx.Err() != nil {
+			c.fail(ErrClosed)
+			return
+		}
+		if errors.Is(err, frost.ErrInvalidMessage) {
+			c.fail(err)
+			return
+		}
+
+		conn = nil
+		for attempt := 1; conn == nil; attempt++ {
+			if attempt > c.backoff.MaxAttempts {
+				c.fail(fmt.Errorf("ws: reconnecting: %w", err))
+				return
+			}
+			if clock.Sleep(c.ctx, c.clock, c.backoff.Delay(attempt, rng)) != nil {
+				c.fail(ErrClosed)
+				return
+			}
+			conn, err = c.dial(c.ctx)
+		}
+
+		// send the message that was not acknowledged again
+		c.mu.Lock()
+		c.conn = conn
+		out := c.pending
+		c.mu.Unlock()
+		if out != nil {
+			_ = websocket.JSON.Send(conn, &frame{Seq: out.seq, Message: out.data})
+		}
+	}
+}
+
+// read handles the frames of conn until it fails.
+func (c *Client) read(conn *websocket.Conn) error {
+	for {
+		var f frame
+		if err := websocket.JSON.Receive(conn, &f); err != nil {
+			return err
+		}
+		if f.Ack != 0 {
+			c.mu.Lock()
+			if out := c.pending; out != nil && out.seq == f.Ack {
+				if f.Error != "" {
+					out.done <- fmt.Errorf("ws: message refused: %s", f.Error)
+				} else {
+					out.done <- nil
+				}
+				c.pending = nil
+			}
+			c.mu.Unlock()
+		}
+		if f.Message != nil {
+			var msg frost.Message
+			if err := msg.UnmarshalJSON(f.Message); err != nil {
+				return fmt.Errorf("ws: %w", err)
+			}
+			c.mu.Lock()
+			c.inbox = append(c.inbox, &msg)
+			c.next = f.Next
+			c.mu.Unlock()
+			c.signal()
+		}
+	}
+}
+
+// dial opens a connection, which resumes after the last message received.
+func (c *Client) dial(ctx context.Context) (*websocket.Conn, error) {
+	c.mu.Lock()
+	u := c.url + "?after=" + strconv.Itoa(c.next)
+	c.mu.Unlock()
+	origin := strings.Replace(c.url, "ws", "http", 1)
+	config, err := websocket.NewConfig(u, origin)
+	if err != nil {
+		return nil, fmt.Errorf("ws: %w", err)
+	}
+	config.Header = http.Header{"Authorization": {"Bearer " + c.token}}
+	config.TlsConfig = c.tls
+	conn, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ws: %w", err)
+	}
+	conn.MaxPayloadBytes = maxMessageSize
+	return conn, nil
+}
+
+// fail sets the error returned by Send and Receive from now on.
+func (c *Client) fail(err error) {
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = err
+	}
+	if c.pending != nil {
+		c.pending.done <- c.err
+		c.pending = nil
+	}
+	c.mu.Unlock()
+	c.signal()
+}
+
+func (c *Client) signal() {
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
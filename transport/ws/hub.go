@@ -0,0 +1,306 @@
+// Package ws connects the parties of protocol sessions to a central
+// coordinator over WebSocket, for parties that cannot accept connections,
+// such as signer daemons behind a NAT or participants in a browser.
+//
+// The Hub stores the messages of every session, like a relay.Server, and
+// pushes them to the connected parties as they arrive. A Client is the
+// frost.Transport of one party: it reconnects when its connection is lost, and
+// resumes where it left off, so that no message is lost or delivered twice.
+//
+// Every frame is a JSON text frame. A party sends its messages as
+//
+//	{"seq": s, "message": m}
+//
+// where s counts the messages it sent, and m is the JSON encoding of a
+// frost.Message, which the hub acknowledges with {"ack": s}, or with
+// {"ack": s, "error": "..."} if it refused the message. The hub sends the
+// messages addressed to the party as {"message": m, "next": n}, where n is the
+// index of the session's next message. A party that reconnects passes the last
+// n it received as the after parameter, and sends the messages that were not
+// acknowledged again; the hub accepts them again without storing them twice.
+package ws
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/clock"
+	"github.com/bartke/frost/party"
+	"golang.org/x/net/websocket"
+)
+
+// maxMessageSize bounds the size of a frame.
+const maxMessageSize = 1 << 20
+
+var sessionID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// frame is a frame of the protocol, see the package documentation.
+type frame struct {
+	Seq     uint64          `json:"seq,omitempty"`
+	Message json.RawMessage `json:"message,omitempty"`
+	Next    int             `json:"next,omitempty"`
+	Ack     uint64          `json:"ack,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// Hub stores the messages of sessions, and pushes them to the connected
+// parties. It implements http.Handler:
+//
+//	GET /v1/sessions/{session}/ws?after=n   opens the WebSocket of a party
+//
+// and pushes the messages for the party with an index of at least n. Parties
+// authenticate with a bearer token in the Authorization header, or in the
+// access_token parameter for browsers, which cannot set headers on a
+// WebSocket. A party has one connection per session: a new one closes the
+// previous one.
+type Hub struct {
+	// TTL is how long a session is kept after its last message, one hour by
+	// default. Sessions with connected parties are kept.
+	TTL time.Duration
+	// MaxMessages is the number of messages a session may hold, 1024 by default.
+	MaxMessages int
+	// Clock expires the sessions, clock.Real is used if it is nil.
+	Clock clock.Clock
+
+	tokens map[party.ID][sha256.Size]byte
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+type session struct {
+	msgs    []stored
+	updated time.Time
+	// changed is closed and replaced when a message is stored.
+	changed chan struct{}
+	conns   map[party.ID]*websocket.Conn
+}
+
+type stored struct {
+	header frost.Header
+	data   json.RawMessage
+}
+
+// NewHub returns a Hub for the parties with the given tokens.
+func NewHub(tokens map[party.ID]string) *Hub {
+	h := &Hub{
+		tokens:   make(map[party.ID][sha256.Size]byte, len(tokens)),
+		sessions: make(map[string]*session),
+	}
+	for id, token := range tokens {
+		h.tokens[id] = sha256.Sum256([]byte(token))
+	}
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rest, ok := strings.CutPrefix(r.URL.Path, "/v1/sessions/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	id, ok := strings.CutSuffix(rest, "/ws")
+	if !ok || !sessionID.MatchString(id) {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	self, ok := h.authenticate(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	after := 0
+	if v := r.URL.Query().Get("after"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid after", http.StatusBadRequest)
+			return
+		}
+		after = n
+	}
+
+	server := websocket.Server{
+		// parties are authenticated by their token, whatever their origin
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler:   func(conn *websocket.Conn) { h.serve(conn, id, self, after) },
+	}
+	server.ServeHTTP(w, r)
+}
+
+// authenticate returns the party whose token is in the Authorization header
+// or the access_token parameter.
+func (h *Hub) authenticate(r *http.Request) (party.ID, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("access_token")
+	}
+	if token == "" {
+		return 0, false
+	}
+	hash := sha256.Sum256([]byte(token))
+	var found party.ID
+	for id, t := range h.tokens {
+		// compare with every token, so that the timing does not tell which one matched
+		if subtle.ConstantTimeCompare(hash[:], t[:]) == 1 {
+			found = id
+		}
+	}
+	return found, found != 0
+}
+
+// serve runs the connection of party self to session id, pushing the messages
+// from index after on, and storing the messages it receives.
+func (h *Hub) serve(conn *websocket.Conn, id string, self party.ID, after int) {
+	conn.MaxPayloadBytes = maxMessageSize
+
+	h.mu.Lock()
+	sess := h.session(id)
+	if previous := sess.conns[self]; previous != nil {
+		previous.Close()
+	}
+	sess.conns[self] = conn
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		conn.Close()
+		h.mu.Lock()
+		if sess.conns[self] == conn {
+			delete(sess.conns, self)
+		}
+		sess.updated = clock.OrReal(h.Clock).Now()
+		h.mu.Unlock()
+	}()
+	go h.push(conn, sess, self, after, done)
+
+	for {
+		var f frame
+		if err := websocket.JSON.Receive(conn, &f); err != nil {
+			return
+		}
+		ack := frame{Ack: f.Seq}
+		if err := h.store(sess, self, f.Message); err != nil {
+			ack.Error = err.Error()
+		}
+		if err := websocket.JSON.Send(conn, &ack); err != nil {
+			return
+		}
+	}
+}
+
+// push sends the messages of sess for party self to conn, from index next on,
+// until done is closed.
+func (h *Hub) push(conn *websocket.Conn, sess *session, self party.ID, next int, done <-chan struct{}) {
+	for {
+		var frames []frame
+		h.mu.Lock()
+		for ; next < len(sess.msgs); next++ {
+			m := sess.msgs[next]
+			if m.header.From != self && (m.header.IsBroadcast() || m.header.To == self) {
+				frames = append(frames, frame{Message: m.data, Next: next + 1})
+			}
+		}
+		changed := sess.changed
+		h.mu.Unlock()
+
+		for i := range frames {
+			if err := websocket.JSON.Send(conn, &frames[i]); err != nil {
+				conn.Close()
+				return
+			}
+		}
+		select {
+		case <-changed:
+		case <-done:
+			return
+		}
+	}
+}
+
+// store adds the message data sent by party self to sess.
+func (h *Hub) store(sess *session, self party.ID, data json.RawMessage) error {
+	var msg frost.Message
+	if err := msg.UnmarshalJSON(data); err != nil {
+		return fmt.Errorf("invalid message: %w", err)
+	}
+	switch {
+	case msg.From != self:
+		return fmt.Errorf("message from party %d sent by party %d", msg.From, self)
+	case msg.Type == frost.MessageTypeNone || msg.To == self:
+		return errors.New("invalid message header")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, m := range sess.msgs {
+		if m.header == msg.Header {
+			// messages sent again after a reconnect are accepted, other
+			// messages with the same header are not
+			if string(m.data) == string(data) {
+				return nil
+			}
+			return errors.New("a different message with the same header was sent")
+		}
+	}
+	if len(sess.msgs) >= h.maxMessages() {
+		return errors.New("too many messages in the session")
+	}
+	sess.msgs = append(sess.msgs, stored{header: msg.Header, data: data})
+	sess.updated = clock.OrReal(h.Clock).Now()
+	close(sess.changed)
+	sess.changed = make(chan struct{})
+	return nil
+}
+
+// session returns the session id, which it creates if needed. h.mu must be held.
+func (h *Hub) session(id string) *session {
+	now := clock.OrReal(h.Clock).Now()
+	h.expire(now)
+	sess, ok := h.sessions[id]
+	if !ok {
+		sess = &session{
+			updated: now,
+			changed: make(chan struct{}),
+			conns:   make(map[party.ID]*websocket.Conn),
+		}
+		h.sessions[id] = sess
+	}
+	return sess
+}
+
+// expire removes the sessions without messages or connected parties since the TTL.
+func (h *Hub) expire(now time.Time) {
+	ttl := h.TTL
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	for id, sess := range h.sessions {
+		if len(sess.conns) == 0 && now.Sub(sess.updated) > ttl {
+			delete(h.sessions, id)
+		}
+	}
+}
+
+func (h *Hub) maxMessages() int {
+	if h.MaxMessages > 0 {
+		return h.MaxMessages
+	}
+	return 1024
+}
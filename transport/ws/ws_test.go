@@ -0,0 +1,180 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost"
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/router"
+	"github.com/bartke/frost/scalar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+var testTokens = map[party.ID]string{1: "token-1", 2: "token-2", 3: "token-3"}
+
+var testConfig = &Config{Backoff: router.Backoff{MaxAttempts: 5, Initial: time.Millisecond, Multiplier: 2}}
+
+func newTestHub(t *testing.T) string {
+	srv := httptest.NewServer(NewHub(testTokens))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func dial(t *testing.T, ctx context.Context, url, session string, id party.ID) *Client {
+	c, err := Dial(ctx, url, session, testTokens[id], testConfig)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClient_KeygenAndSign(t *testing.T) {
+	url := newTestHub(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const n, threshold = 3, 1
+	publics := make([]*eddsa.Public, n+1)
+	secrets := make([]*eddsa.SecretShare, n+1)
+	var wg sync.WaitGroup
+	for id := party.ID(1); id <= n; id++ {
+		c := dial(t, ctx, url, "keygen", id)
+		wg.Add(1)
+		go func(id party.ID) {
+			defer wg.Done()
+			var err error
+			publics[id], secrets[id], err = frost.KeygenWithContext(ctx, c, id, n, threshold)
+			assert.NoError(t, err)
+		}(id)
+	}
+	wg.Wait()
+	require.NotNil(t, publics[1])
+	assert.True(t, publics[1].Equal(publics[3]))
+
+	signers := party.IDSlice{1, 3}
+	message := []byte("over websocket")
+	sigs := make([]*eddsa.Signature, n+1)
+	for _, id := range signers {
+		c := dial(t, ctx, url, "sign", id)
+		wg.Add(1)
+		go func(id party.ID) {
+			defer wg.Done()
+			var err error
+			sigs[id], err = frost.SignWithContext(ctx, c, signers, secrets[id], publics[id], message)
+			assert.NoError(t, err)
+		}(id)
+	}
+	wg.Wait()
+	require.NotNil(t, sigs[1])
+	assert.True(t, publics[1].GroupKey.Verify(message, sigs[1]))
+}
+
+func TestClient_Resume(t *testing.T) {
+	url := newTestHub(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sender := dial(t, ctx, url, "session-1", 1)
+	receiver := dial(t, ctx, url, "session-1", 2)
+
+	const count = 20
+	sent := make([]*frost.Message, count)
+	for i := range sent {
+		sent[i] = frost.NewKeyGen2(1, 2, scalar.NewScalarRandom())
+		sent[i].Round = uint8(i + 1)
+	}
+
+	for i, msg := range sent {
+		if i%5 == 2 {
+			// drop the connections, the clients reconnect
+			for _, c := range []*Client{sender, receiver} {
+				c.mu.Lock()
+				if c.conn != nil {
+					c.conn.Close()
+				}
+				c.mu.Unlock()
+			}
+		}
+		require.NoError(t, sender.Send(ctx, msg))
+	}
+
+	// every message arrives once, in order
+	for i := range sent {
+		msg, err := receiver.Receive(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, sent[i].Header, msg.Header)
+		assert.Equal(t, 1, msg.KeyGen2.Share.Equal(&sent[i].KeyGen2.Share))
+	}
+	short, cancelShort := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelShort()
+	_, err := receiver.Receive(short)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// a party joining late gets the messages sent before
+	third := dial(t, ctx, url, "session-1", 3)
+	broadcast := frost.NewSign2(1, scalar.NewScalarRandom())
+	require.NoError(t, sender.Send(ctx, broadcast))
+	msg, err := third.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, broadcast.Header, msg.Header)
+}
+
+func TestHub_Refuses(t *testing.T) {
+	url := newTestHub(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := Dial(ctx, url, "session-1", "unknown", testConfig)
+	assert.Error(t, err)
+	_, err = Dial(ctx, url, "../session", testTokens[1], testConfig)
+	assert.Error(t, err)
+
+	c := dial(t, ctx, url, "session-1", 1)
+	assert.Error(t, c.Send(ctx, frost.NewSign2(2, scalar.NewScalarRandom())), "message from another party")
+	assert.Error(t, c.Send(ctx, frost.NewKeyGen2(1, 1, scalar.NewScalarRandom())), "message to itself")
+
+	msg := frost.NewSign2(1, scalar.NewScalarRandom())
+	require.NoError(t, c.Send(ctx, msg))
+	require.NoError(t, c.Send(ctx, msg), "the same message again")
+	assert.Error(t, c.Send(ctx, frost.NewSign2(1, scalar.NewScalarRandom())), "another message with the same header")
+
+	require.NoError(t, c.Close())
+	assert.True(t, errors.Is(c.Send(ctx, msg), ErrClosed))
+	_, err = c.Receive(ctx)
+	assert.True(t, errors.Is(err, ErrClosed))
+}
+
+func TestHub_AccessToken(t *testing.T) {
+	url := newTestHub(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sender := dial(t, ctx, url, "session-1", 1)
+	msg := frost.NewSign2(1, scalar.NewScalarRandom())
+	require.NoError(t, sender.Send(ctx, msg))
+
+	// as a browser connects, with the token in the URL and any origin
+	config, err := websocket.NewConfig(url+"/v1/sessions/session-1/ws?access_token="+testTokens[2], "https://example.com")
+	require.NoError(t, err)
+	conn, err := config.DialContext(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	var f frame
+	require.NoError(t, websocket.JSON.Receive(conn, &f))
+	assert.Equal(t, 1, f.Next)
+	var received frost.Message
+	require.NoError(t, received.UnmarshalJSON(f.Message))
+	assert.Equal(t, msg.Header, received.Header)
+
+	resp, err := http.Get("http" + strings.TrimPrefix(url, "ws") + "/v1/sessions/session-1/ws?access_token=wrong")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
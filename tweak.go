@@ -0,0 +1,36 @@
+package frost
+
+import (
+	"crypto/sha512"
+	"fmt"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+)
+
+// CommitmentTweak returns the tweak t = H(P ∥ data) that commits data into
+// the group key P, like the taproot tweak of BIP-341. Anyone who knows P and
+// data can compute the tweaked key P + [t]B and check the commitment.
+func CommitmentTweak(groupKey *eddsa.PublicKey, data []byte) *ristretto.Scalar {
+	h := sha512.New()
+	h.Write([]byte("FROST-TWEAK-v1"))
+	h.Write(groupKey.ToEd25519())
+	h.Write(data)
+	t, _ := ristretto.NewScalar().SetUniformBytes(h.Sum(nil))
+	return t
+}
+
+// SignInitTweaked is like SignInit, but the signers sign for the tweaked group
+// key P' = P + [t]B. The tweak is added to every share, so that the secret
+// of each signer multiplied by its Lagrange coefficient becomes 𝛌ᵢ(sᵢ + t),
+// and these sum to the tweaked secret. All signers must use the same tweak,
+// and an Aggregator must be created with the public information that
+// shares.Tweak returns.
+func SignInitTweaked(signerIDs party.IDSlice, secret *eddsa.SecretShare, shares *eddsa.Public, message []byte, tweak *ristretto.Scalar) (*Message, *SignerState, error) {
+	tweaked, err := shares.Tweak(tweak)
+	if err != nil {
+		return nil, nil, fmt.Errorf("SignInitTweaked: %w", err)
+	}
+	return SignInit(signerIDs, secret.Tweak(tweak), tweaked, message)
+}
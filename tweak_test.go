@@ -0,0 +1,69 @@
+package frost
+
+import (
+	"testing"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignInitTweaked(t *testing.T) {
+	public, secrets := generateKeys(t, 4, 2)
+	signers := party.IDSlice{1, 2, 4}
+	message := []byte("tweaked")
+	tweak := CommitmentTweak(public.GroupKey, []byte("script root"))
+
+	// P' = P + [t]B
+	var tB, key ristretto.Element
+	tB.ScalarBaseMult(tweak)
+	_, err := key.SetBytesEd25519(public.GroupKey.ToEd25519())
+	require.NoError(t, err)
+	tweakedKey := eddsa.NewPublicKeyFromPoint(key.Add(&key, &tB))
+
+	states := make(map[party.ID]*SignerState)
+	var round1 []*Message
+	for _, id := range signers {
+		msg, state, err := SignInitTweaked(signers, secrets[id], public, message, tweak)
+		require.NoError(t, err)
+		assert.True(t, state.GroupKey.Equal(tweakedKey))
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+
+	sigs, err := runSignRounds(states, round1)
+	require.NoError(t, err)
+	for _, sig := range sigs {
+		assert.True(t, tweakedKey.Verify(message, sig))
+		assert.False(t, public.GroupKey.Verify(message, sig))
+	}
+
+	// the aggregator verifies the shares against the tweaked public shares
+	tweaked, err := public.Tweak(tweak)
+	require.NoError(t, err)
+	agg, err := NewAggregator(signers, tweaked, message)
+	require.NoError(t, err)
+	round1 = round1[:0]
+	for _, id := range signers {
+		msg, state, err := SignInitTweaked(signers, secrets[id], public, message, tweak)
+		require.NoError(t, err)
+		states[id] = state
+		round1 = append(round1, msg)
+	}
+	forwarded, err := agg.AddCommitments(round1)
+	require.NoError(t, err)
+	var shares []*Message
+	for _, id := range signers {
+		msg, _, err := SignRound1(states[id], forwarded)
+		require.NoError(t, err)
+		shares = append(shares, msg)
+	}
+	sig, err := agg.Aggregate(shares)
+	require.NoError(t, err)
+	assert.True(t, tweakedKey.Verify(message, sig))
+
+	// a different commitment gives a different key
+	assert.NotEqual(t, tweak.Bytes(), CommitmentTweak(public.GroupKey, []byte("other")).Bytes())
+}
@@ -0,0 +1,236 @@
+package frost
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/bartke/frost/eddsa"
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/ristretto"
+)
+
+// VectorConfig are the inputs of a seeded run.
+type VectorConfig struct {
+	// Seed is the hex encoded seed from which all randomness is derived.
+	Seed      string        `json:"seed"`
+	N         party.Size    `json:"n"`
+	Threshold party.Size    `json:"threshold"`
+	SignerIDs party.IDSlice `json:"signer_ids"`
+	// Message is hex encoded.
+	Message string `json:"message"`
+}
+
+// KeygenVector is the contribution of one party to the key generation.
+// Scalars are hex encoded little endian, points are hex encoded in the
+// Edwards25519 encoding used by Ed25519.
+type KeygenVector struct {
+	ID party.ID `json:"id"`
+	// Secret is the constant term of the party's polynomial.
+	Secret string `json:"secret"`
+	// Commitments are [aₖ]•B for every coefficient aₖ of the polynomial.
+	Commitments []string `json:"commitments"`
+	// Shares maps every party j to f(j), the share sent to j.
+	Shares map[party.ID]string `json:"shares"`
+}
+
+// GroupVector is the public output of the key generation.
+type GroupVector struct {
+	GroupKey string `json:"group_key"`
+	// VerificationShares maps every party to the public key of its secret share.
+	VerificationShares map[party.ID]string `json:"verification_shares"`
+	// SecretShares maps every party to its final secret share.
+	SecretShares map[party.ID]string `json:"secret_shares"`
+}
+
+// SignerVector is the state of one signer in the signing protocol.
+type SignerVector struct {
+	ID party.ID `json:"id"`
+	// HidingNonce and BindingNonce are the nonces d and e, with commitments D and E.
+	HidingNonce       string `json:"hiding_nonce"`
+	BindingNonce      string `json:"binding_nonce"`
+	HidingCommitment  string `json:"hiding_commitment"`
+	BindingCommitment string `json:"binding_commitment"`
+	// BindingFactor is ρ, see computeRhos.
+	BindingFactor string `json:"binding_factor"`
+	Lagrange      string `json:"lagrange"`
+	// PartialSignature is z = d + (e • ρ) + 𝛌 • s • c.
+	PartialSignature string `json:"partial_signature"`
+}
+
+// SigningVector is the transcript of the signing protocol.
+type SigningVector struct {
+	Signers []SignerVector `json:"signers"`
+	// GroupCommitment is R = ∑ Dᵢ + [ρᵢ] Eᵢ.
+	GroupCommitment string `json:"group_commitment"`
+	// Challenge is c = H(R, GroupKey, Message).
+	Challenge string `json:"challenge"`
+	// Signature is the 64 byte Ed25519 signature R ∥ S.
+	Signature string `json:"signature"`
+}
+
+// Vectors are the intermediate values of a complete key generation and
+// signing run, for verifying compatibility of other implementations.
+type Vectors struct {
+	Config  VectorConfig   `json:"config"`
+	Keygen  []KeygenVector `json:"keygen"`
+	Group   GroupVector    `json:"group"`
+	Signing SigningVector  `json:"signing"`
+}
+
+// encodePoint returns the hex encoded Ed25519 encoding of e.
+func encodePoint(e *ristretto.Element) string {
+	return hex.EncodeToString(e.BytesEd25519())
+}
+
+// seededReader returns a deterministic random stream for the given purpose and party.
+func seededReader(seed []byte, purpose string, id party.ID) io.Reader {
+	return newSeedStream(seed, nil, []byte("FROST-VECTORS-"+purpose+"-"+strconv.Itoa(int(id))))
+}
+
+// GenerateVectors runs key generation for the parties 1..n with threshold t,
+// and signs message with signerIDs, sampling all secrets from seed.
+// The same inputs always produce the same vectors.
+func GenerateVectors(seed []byte, n, t party.Size, signerIDs party.IDSlice, message []byte) (*Vectors, error) {
+	if len(seed) == 0 {
+		return nil, errors.New("vectors: empty seed")
+	}
+	signerIDs = party.NewIDSlice(signerIDs)
+	if signerIDs.N() <= t {
+		return nil, fmt.Errorf("vectors: %d signers cannot sign with threshold %d", signerIDs.N(), t)
+	}
+
+	v := &Vectors{
+		Config: VectorConfig{
+			Seed:      hex.EncodeToString(seed),
+			N:         n,
+			Threshold: t,
+			SignerIDs: signerIDs,
+			Message:   hex.EncodeToString(message),
+		},
+	}
+
+	// Key generation
+	keygenStates := make(map[party.ID]*KeygenState, n)
+	var round1 []*Message
+	for id := party.ID(1); id <= n; id++ {
+		msg, state, err := keygenInit(SessionID{}, nil, id, n, t, seededReader(seed, "KEYGEN", id))
+		if err != nil {
+			return nil, err
+		}
+		commitments, err := msg.KeyGen1.Commitments.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		kv := KeygenVector{
+			ID:     id,
+			Secret: hex.EncodeToString(state.Polynomial.Constant().Bytes()),
+			Shares: make(map[party.ID]string, n),
+		}
+		for c := commitments[party.IDByteSize:]; len(c) > 0; c = c[32:] {
+			var commitment ristretto.Element
+			if _, err := commitment.SetCanonicalBytes(c[:32]); err != nil {
+				return nil, err
+			}
+			kv.Commitments = append(kv.Commitments, encodePoint(&commitment))
+		}
+		for j := party.ID(1); j <= n; j++ {
+			kv.Shares[j] = hex.EncodeToString(state.Polynomial.Evaluate(j.Scalar()).Bytes())
+		}
+		v.Keygen = append(v.Keygen, kv)
+		keygenStates[id] = state
+		round1 = append(round1, msg)
+	}
+
+	round2 := make(map[party.ID][]*Message, n)
+	for id := party.ID(1); id <= n; id++ {
+		msgs, _, err := KeygenRound1(keygenStates[id], round1)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range msgs {
+			round2[msg.To] = append(round2[msg.To], msg)
+		}
+	}
+
+	var public *eddsa.Public
+	secrets := make(map[party.ID]*eddsa.SecretShare, n)
+	for id := party.ID(1); id <= n; id++ {
+		pub, sec, err := KeygenRound2(keygenStates[id], round2[id])
+		if err != nil {
+			return nil, err
+		}
+		public, secrets[id] = pub, sec
+	}
+
+	v.Group = GroupVector{
+		GroupKey:           hex.EncodeToString(public.GroupKey.ToEd25519()),
+		VerificationShares: make(map[party.ID]string, n),
+		SecretShares:       make(map[party.ID]string, n),
+	}
+	for id := party.ID(1); id <= n; id++ {
+		v.Group.VerificationShares[id] = encodePoint(public.Shares[id])
+		v.Group.SecretShares[id] = hex.EncodeToString(secrets[id].Secret.Bytes())
+	}
+
+	// Signing
+	signStates := make(map[party.ID]*SignerState, signerIDs.N())
+	var sign1 []*Message
+	for _, id := range signerIDs {
+		secret, ok := secrets[id]
+		if !ok {
+			return nil, fmt.Errorf("vectors: signer %d is not a party", id)
+		}
+		msg, state, err := signInit(signerIDs, secret, public, message, seededReader(seed, "SIGN", id))
+		if err != nil {
+			return nil, err
+		}
+		signStates[id] = state
+		sign1 = append(sign1, msg)
+	}
+
+	var sign2 []*Message
+	for _, id := range signerIDs {
+		msg, _, err := SignRound1(signStates[id], sign1)
+		if err != nil {
+			return nil, err
+		}
+		sign2 = append(sign2, msg)
+	}
+
+	var sig *eddsa.Signature
+	for _, id := range signerIDs {
+		s, _, err := SignRound2(signStates[id], sign2)
+		if err != nil {
+			return nil, err
+		}
+		sig = s
+	}
+
+	// all signers agree on the transcript, so any state can be used
+	state := signStates[signerIDs[0]]
+	for _, id := range signerIDs {
+		lagrange, err := id.Lagrange(signerIDs)
+		if err != nil {
+			return nil, err
+		}
+		s := state.Signers[id]
+		v.Signing.Signers = append(v.Signing.Signers, SignerVector{
+			ID:                id,
+			HidingNonce:       hex.EncodeToString(signStates[id].D.Bytes()),
+			BindingNonce:      hex.EncodeToString(signStates[id].E.Bytes()),
+			HidingCommitment:  encodePoint(&s.Di),
+			BindingCommitment: encodePoint(&s.Ei),
+			BindingFactor:     hex.EncodeToString(s.Pi.Bytes()),
+			Lagrange:          hex.EncodeToString(lagrange.Bytes()),
+			PartialSignature:  hex.EncodeToString(s.Zi.Bytes()),
+		})
+	}
+	v.Signing.GroupCommitment = encodePoint(&state.R)
+	v.Signing.Challenge = hex.EncodeToString(state.C.Bytes())
+	v.Signing.Signature = hex.EncodeToString(sig.ToEd25519())
+
+	return v, nil
+}
@@ -0,0 +1,58 @@
+package frost
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/bartke/frost/party"
+	"github.com/bartke/frost/polynomial"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateVectors(t *testing.T) {
+	seed := []byte("frost test vectors")
+	message := []byte("hello FROST")
+	signers := party.IDSlice{3, 1, 4}
+
+	v, err := GenerateVectors(seed, 4, 2, signers, message)
+	require.NoError(t, err)
+	assert.Equal(t, party.IDSlice{1, 3, 4}, v.Config.SignerIDs)
+	assert.Len(t, v.Keygen, 4)
+	assert.Len(t, v.Keygen[0].Commitments, 3)
+	assert.Len(t, v.Signing.Signers, 3)
+
+	again, err := GenerateVectors(seed, 4, 2, signers, message)
+	require.NoError(t, err)
+	assert.Equal(t, v, again)
+
+	other, err := GenerateVectors([]byte("another seed"), 4, 2, signers, message)
+	require.NoError(t, err)
+	assert.NotEqual(t, v.Group.GroupKey, other.Group.GroupKey)
+
+	pub, err := hex.DecodeString(v.Group.GroupKey)
+	require.NoError(t, err)
+	sig, err := hex.DecodeString(v.Signing.Signature)
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(pub, message, sig))
+	assert.Equal(t, v.Signing.GroupCommitment, v.Signing.Signature[:64])
+
+	_, err = GenerateVectors(seed, 4, 2, party.IDSlice{1, 2}, message)
+	assert.Error(t, err)
+	_, err = GenerateVectors(seed, 4, 2, party.IDSlice{1, 2, 7}, message)
+	assert.Error(t, err)
+}
+
+func TestSeededReaderLarge(t *testing.T) {
+	// the key generation of a threshold of 254 or more reads past the output
+	// limit of a single HKDF reader
+	b := make([]byte, 64*(int(polynomial.MaxDegree)+2))
+	_, err := io.ReadFull(seededReader([]byte("frost test vectors"), "KEYGEN", 1), b)
+	require.NoError(t, err)
+	again := make([]byte, len(b))
+	_, err = io.ReadFull(seededReader([]byte("frost test vectors"), "KEYGEN", 1), again)
+	require.NoError(t, err)
+	assert.Equal(t, b, again)
+}
@@ -0,0 +1,42 @@
+package frost
+
+import (
+	"runtime"
+	"sync"
+)
+
+// forEach calls f(i) for i = 0, ..., n-1 on up to workers goroutines, or
+// runtime.GOMAXPROCS(0) of them if workers is 0, and returns once all calls
+// returned. With a single worker, or a single call, f runs on the calling
+// goroutine. The calls must not depend on each other.
+func forEach(workers, n int, f func(i int)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				f(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
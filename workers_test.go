@@ -0,0 +1,25 @@
+package frost
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEach(t *testing.T) {
+	for _, workers := range []int{0, 1, 4, 100} {
+		for _, n := range []int{0, 1, 57} {
+			var calls [57]int32
+			var total int32
+			forEach(workers, n, func(i int) {
+				atomic.AddInt32(&calls[i], 1)
+				atomic.AddInt32(&total, 1)
+			})
+			assert.Equal(t, int32(n), total, "workers %d, n %d", workers, n)
+			for i := 0; i < n; i++ {
+				assert.Equal(t, int32(1), calls[i], "workers %d, call %d", workers, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Package x509ca issues X.509 certificates and certificate requests signed
+// with the group key, so that a threshold of parties acts as an internal CA.
+//
+// Signing takes two steps, like attestations and bundles: PrepareCertificate
+// and PrepareRequest return the to-be-signed bytes, which the group signs
+// with the usual protocol, and AssembleCertificate and AssembleRequest add the
+// Ed25519 signature and return the DER encoding. For signing online, a
+// frost.SignerAdapter can be passed to x509.CreateCertificate directly.
+package x509ca
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+
+	"github.com/bartke/frost/eddsa"
+)
+
+// oidEd25519 is the algorithm identifier of Ed25519 signatures, RFC 8410.
+var oidEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+
+// signed is the outer structure shared by certificates and certificate requests.
+type signed struct {
+	TBS       asn1.RawValue
+	Algorithm pkix.AlgorithmIdentifier
+	Signature asn1.BitString
+}
+
+// PrepareCertificate returns the DER encoded TBSCertificate of template for the
+// public key pub, issued by parent, which the group with key ca must sign. For
+// a self-signed root, parent is template and pub the group key. parent must be
+// a certificate of ca, if its PublicKey is set.
+func PrepareCertificate(template, parent *x509.Certificate, pub crypto.PublicKey, ca *eddsa.PublicKey) ([]byte, error) {
+	caKey := ed25519.PublicKey(ca.ToEd25519())
+	if parent.PublicKey != nil && !caKey.Equal(parent.PublicKey) {
+		return nil, errors.New("x509ca: parent is not a certificate of the group key")
+	}
+	// x509.CreateCertificate checks the signature it asks for, so the bytes
+	// are taken from a certificate signed with a throwaway key. Neither the
+	// key nor the signature are part of them.
+	_, throwaway, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("x509ca: %w", err)
+	}
+	issuer := *parent
+	issuer.PublicKey = nil
+	der, err := x509.CreateCertificate(rand.Reader, template, &issuer, pub, throwaway)
+	if err != nil {
+		return nil, fmt.Errorf("x509ca: %w", err)
+	}
+	return tbsOf(der)
+}
+
+// PrepareRequest returns the DER encoded CertificationRequestInfo of a request
+// to certify the group key ca with the fields of template, which the group must
+// sign, e.g. to have the group key certified as an intermediate by another CA.
+func PrepareRequest(template *x509.CertificateRequest, ca *eddsa.PublicKey) ([]byte, error) {
+	_, throwaway, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("x509ca: %w", err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, throwaway)
+	if err != nil {
+		return nil, fmt.Errorf("x509ca: %w", err)
+	}
+	tbs, err := tbsOf(der)
+	if err != nil {
+		return nil, err
+	}
+
+	// the request holds the key of its signer, which is replaced by the group key
+	var info struct {
+		Version    int
+		Subject    asn1.RawValue
+		PublicKey  asn1.RawValue
+		Attributes asn1.RawValue
+	}
+	if rest, err := asn1.Unmarshal(tbs, &info); err != nil || len(rest) > 0 {
+		return nil, errors.New("x509ca: malformed certificate request")
+	}
+	spki, err := ca.MarshalPKIX()
+	if err != nil {
+		return nil, fmt.Errorf("x509ca: %w", err)
+	}
+	info.PublicKey = asn1.RawValue{FullBytes: spki}
+	return asn1.Marshal(info)
+}
+
+// AssembleCertificate returns the DER encoded certificate of tbs, as returned
+// by PrepareCertificate, with sig, the Ed25519 signature of the group over it.
+func AssembleCertificate(tbs, sig []byte, ca *eddsa.PublicKey) ([]byte, error) {
+	der, err := assemble(tbs, sig, ca)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := x509.ParseCertificate(der); err != nil {
+		return nil, fmt.Errorf("x509ca: %w", err)
+	}
+	return der, nil
+}
+
+// AssembleRequest returns the DER encoded certificate request of info, as
+// returned by PrepareRequest, with sig, the Ed25519 signature of the group over it.
+func AssembleRequest(info, sig []byte, ca *eddsa.PublicKey) ([]byte, error) {
+	der, err := assemble(info, sig, ca)
+	if err != nil {
+		return nil, err
+	}
+	req, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, fmt.Errorf("x509ca: %w", err)
+	}
+	if err := req.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("x509ca: %w", err)
+	}
+	return der, nil
+}
+
+// assemble returns tbs signed with sig, after checking sig under ca.
+func assemble(tbs, sig []byte, ca *eddsa.PublicKey) ([]byte, error) {
+	if len(sig) != ed25519.SignatureSize || !ed25519.Verify(ca.ToEd25519(), tbs, sig) {
+		return nil, errors.New("x509ca: invalid signature")
+	}
+	return asn1.Marshal(signed{
+		TBS:       asn1.RawValue{FullBytes: tbs},
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidEd25519},
+		Signature: asn1.BitString{Bytes: sig, BitLength: 8 * len(sig)},
+	})
+}
+
+// tbsOf returns the signed part of a DER encoded certificate or request.
+func tbsOf(der []byte) ([]byte, error) {
+	var s signed
+	if rest, err := asn1.Unmarshal(der, &s); err != nil || len(rest) > 0 {
+		return nil, errors.New("x509ca: malformed certificate")
+	}
+	if !s.Algorithm.Algorithm.Equal(oidEd25519) {
+		return nil, errors.New("x509ca: not an Ed25519 certificate")
+	}
+	return bytes.Clone(s.TBS.FullBytes), nil
+}
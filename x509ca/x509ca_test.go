@@ -0,0 +1,110 @@
+package x509ca
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/bartke/frost/frostclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sign(t *testing.T, group *frostclient.Group, tbs []byte) []byte {
+	sig, err := frostclient.Sign(context.Background(), group, tbs)
+	require.NoError(t, err)
+	return sig.ToEd25519()
+}
+
+func TestCertificate(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	ca := group.Public.GroupKey
+	now := time.Now()
+
+	// a self-signed root of the group key
+	root := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Threshold Root CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	tbs, err := PrepareCertificate(root, root, ed25519.PublicKey(ca.ToEd25519()), ca)
+	require.NoError(t, err)
+	der, err := AssembleCertificate(tbs, sign(t, group, tbs), ca)
+	require.NoError(t, err)
+	rootCert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	require.NoError(t, rootCert.CheckSignatureFrom(rootCert))
+	assert.Equal(t, ed25519.PublicKey(ca.ToEd25519()), rootCert.PublicKey)
+
+	// a leaf issued by the root
+	leafPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	leaf := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "service.internal"},
+		DNSNames:     []string{"service.internal"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	tbs, err = PrepareCertificate(leaf, rootCert, leafPub, ca)
+	require.NoError(t, err)
+	der, err = AssembleCertificate(tbs, sign(t, group, tbs), ca)
+	require.NoError(t, err)
+	leafCert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(rootCert)
+	_, err = leafCert.Verify(x509.VerifyOptions{Roots: roots, DNSName: "service.internal"})
+	require.NoError(t, err)
+	assert.Equal(t, rootCert.SubjectKeyId, leafCert.AuthorityKeyId)
+
+	// a signature over other bytes, or by another group, is rejected
+	_, err = AssembleCertificate(tbs, sign(t, group, tbs[1:]), ca)
+	assert.Error(t, err)
+	other, err := frostclient.CreateGroup(frostclient.Config{N: 2, T: 1})
+	require.NoError(t, err)
+	_, err = AssembleCertificate(tbs, sign(t, other, tbs), ca)
+	assert.Error(t, err)
+
+	// the parent must be a certificate of the group key
+	_, err = PrepareCertificate(leaf, rootCert, leafPub, other.Public.GroupKey)
+	assert.Error(t, err)
+}
+
+func TestRequest(t *testing.T) {
+	group, err := frostclient.CreateGroup(frostclient.Config{N: 3, T: 1})
+	require.NoError(t, err)
+	ca := group.Public.GroupKey
+
+	template := &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "Threshold Intermediate CA", Organization: []string{"Example"}},
+		DNSNames: []string{"ca.internal"},
+	}
+	info, err := PrepareRequest(template, ca)
+	require.NoError(t, err)
+	der, err := AssembleRequest(info, sign(t, group, info), ca)
+	require.NoError(t, err)
+
+	req, err := x509.ParseCertificateRequest(der)
+	require.NoError(t, err)
+	require.NoError(t, req.CheckSignature())
+	assert.Equal(t, ed25519.PublicKey(ca.ToEd25519()), req.PublicKey)
+	assert.Equal(t, "Threshold Intermediate CA", req.Subject.CommonName)
+	assert.Equal(t, []string{"ca.internal"}, req.DNSNames)
+
+	_, err = AssembleRequest(info, make([]byte, ed25519.SignatureSize), ca)
+	assert.Error(t, err)
+}
@@ -1,8 +1,11 @@
 package zk
 
 import (
+	"crypto/rand"
 	"errors"
+	"io"
 
+	"github.com/bartke/frost/internal/vartime"
 	"github.com/bartke/frost/party"
 	"github.com/bartke/frost/ristretto"
 	"github.com/bartke/frost/scalar"
@@ -16,7 +19,7 @@ import (
 // The public parameters are:
 //
 //	partyID: prover's uint32 ID
-//	context: 32 byte context string,
+//	context: context string, e.g. a 32 byte session identifier,
 //	public:  [secret] B
 type Schnorr struct {
 	// S = H( ID || CTX || public || M )
@@ -28,7 +31,7 @@ type Schnorr struct {
 //
 //	partyID is the uint32 ID of the prover
 //	public is the point [private]•B
-//	context is a 32 byte context (if it is set to [0 ... 0] then we may be susceptible to replay attacks)
+//	context binds the proof to a session (if it is set to [0 ... 0] then we may be susceptible to replay attacks)
 //	private is the discrete log of public
 //
 // We sample a random Scalar k, and obtain M = [k]•B
@@ -37,10 +40,16 @@ type Schnorr struct {
 //
 // The proof returned is the tuple (S,R)
 func NewSchnorrProof(partyID party.ID, public *ristretto.Element, context []byte, private *ristretto.Scalar) *Schnorr {
+	return NewSchnorrProofFrom(partyID, public, context, private, rand.Reader)
+}
+
+// NewSchnorrProofFrom is like NewSchnorrProof, but samples the nonce k using rng,
+// or crypto/rand if it is nil.
+func NewSchnorrProofFrom(partyID party.ID, public *ristretto.Element, context []byte, private *ristretto.Scalar, rng io.Reader) *Schnorr {
 	var proof Schnorr
 
 	// Compute commitment for random nonce
-	k := scalar.NewScalarRandom()
+	k := scalar.SetScalarRandomFrom(ristretto.NewScalar(), rng)
 
 	// M = [k] B
 	var M ristretto.Element
@@ -57,13 +66,13 @@ func NewSchnorrProof(partyID party.ID, public *ristretto.Element, context []byte
 //
 //	partyID is the uint32 ID of the prover
 //	public is the point [private]•B
-//	context is a 32 byte context (if it is set to [0 ... 0] then we may be susceptible to replay attacks)
+//	context binds the proof to a session (if it is set to [0 ... 0] then we may be susceptible to replay attacks)
 func (proof *Schnorr) Verify(partyID party.ID, public *ristretto.Element, context []byte) bool {
 	var MPrime, publicNeg ristretto.Element
 
 	publicNeg.Negate(public)
 
-	MPrime.VarTimeDoubleScalarBaseMult(&proof.S, &publicNeg, &proof.R)
+	vartime.DoubleScalarBaseMult(&MPrime, &proof.S, &publicNeg, &proof.R)
 
 	SPrime := challenge(partyID, context, public, &MPrime)
 
@@ -73,7 +82,7 @@ func (proof *Schnorr) Verify(partyID party.ID, public *ristretto.Element, contex
 // challenge computes the hash H(partyID, context, public, M), where
 //
 //	partyID: prover's uint32 ID
-//	context: 32 byte context string,
+//	context: context string, e.g. a 32 byte session identifier,
 //	public:  [secret] B
 //	M:       [k] B
 func challenge(partyID party.ID, context []byte, public, M *ristretto.Element) *ristretto.Scalar {
@@ -82,13 +91,12 @@ func challenge(partyID party.ID, context []byte, public, M *ristretto.Element) *
 
 	h := sha512.New()
 	_, _ = h.Write(partyID.Bytes())
-	_, _ = h.Write(context[:32])
+	_, _ = h.Write(context)
 	_, _ = h.Write(public.Bytes())
 	_, _ = h.Write(M.Bytes())
 
-	buffer := make([]byte, 64)
 	// SetUniformBytes only returns an error when the length is wrong so we're okay here
-	_, _ = S.SetUniformBytes(h.Sum(buffer))
+	_, _ = S.SetUniformBytes(h.Sum(nil))
 	return &S
 }
 
@@ -19,3 +19,21 @@ func TestSchnorrProof(t *testing.T) {
 	require.True(t, publicComputed.Equal(public) == 1)
 	require.True(t, proof.Verify(partyID, public, ctx[:]))
 }
+
+func TestSchnorrProof_Binding(t *testing.T) {
+	ctx := []byte("a context longer than thirty-two bytes, all of which is bound")
+	partyID := party.ID(42)
+	private := scalar.NewScalarRandom()
+	public := new(ristretto.Element).ScalarBaseMult(private)
+	proof := NewSchnorrProof(partyID, public, ctx, private)
+	require.True(t, proof.Verify(partyID, public, ctx))
+
+	require.False(t, proof.Verify(partyID+1, public, ctx))
+	other := append([]byte(nil), ctx...)
+	other[len(other)-1] ^= 1
+	require.False(t, proof.Verify(partyID, public, other))
+	require.False(t, proof.Verify(partyID, new(ristretto.Element).ScalarBaseMult(scalar.NewScalarRandom()), ctx))
+
+	var zero Schnorr
+	require.False(t, zero.Verify(partyID, public, ctx), "the all-zero proof must not verify")
+}